@@ -16,7 +16,9 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"path/filepath"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -34,9 +36,14 @@ import (
 	"github.com/charmbracelet/crush/internal/agent/tools"
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/imagefit"
+	"github.com/charmbracelet/crush/internal/latency"
 	"github.com/charmbracelet/crush/internal/message"
 	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/privacy"
+	"github.com/charmbracelet/crush/internal/redact"
 	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/crush/internal/stats"
 	"github.com/charmbracelet/crush/internal/stringext"
 )
 
@@ -68,6 +75,7 @@ type SessionAgent interface {
 	Run(context.Context, SessionAgentCall) (*fantasy.AgentResult, error)
 	SetModels(large Model, small Model)
 	SetTools(tools []fantasy.AgentTool)
+	SetSystemPrompt(systemPrompt string)
 	Cancel(sessionID string)
 	CancelAll()
 	IsSessionBusy(sessionID string) bool
@@ -76,7 +84,9 @@ type SessionAgent interface {
 	QueuedPromptsList(sessionID string) []string
 	ClearQueue(sessionID string)
 	Summarize(context.Context, string, fantasy.ProviderOptions) error
+	Compact(context.Context, string, fantasy.ProviderOptions) (int64, error)
 	Model() Model
+	SmallModel() Model
 }
 
 type Model struct {
@@ -96,6 +106,11 @@ type sessionAgent struct {
 	messages             message.Service
 	disableAutoSummarize bool
 	isYolo               bool
+	redactor             *redact.Redactor
+	toolUse              config.ToolUse
+	turnLimits           config.TurnLimits
+	repoMap              func(context.Context) string
+	currentTime          func() string
 
 	messageQueue   *csync.Map[string, []SessionAgentCall]
 	activeRequests *csync.Map[string, context.CancelFunc]
@@ -112,6 +127,15 @@ type SessionAgentOptions struct {
 	Sessions             session.Service
 	Messages             message.Service
 	Tools                []fantasy.AgentTool
+	Redactor             *redact.Redactor
+	ToolUse              config.ToolUse
+	TurnLimits           config.TurnLimits
+	// RepoMap returns the current map of the workspace, added to the system
+	// prompt of each run so that it follows changes to the tree.
+	RepoMap func(context.Context) string
+	// CurrentTime returns the current time added to the system prompt of
+	// each run, when the agent is told the time.
+	CurrentTime func() string
 }
 
 func NewSessionAgent(
@@ -128,6 +152,11 @@ func NewSessionAgent(
 		disableAutoSummarize: opts.DisableAutoSummarize,
 		tools:                opts.Tools,
 		isYolo:               opts.IsYolo,
+		redactor:             opts.Redactor,
+		toolUse:              opts.ToolUse,
+		turnLimits:           opts.TurnLimits,
+		repoMap:              opts.RepoMap,
+		currentTime:          opts.CurrentTime,
 		messageQueue:         csync.NewMap[string, []SessionAgentCall](),
 		activeRequests:       csync.NewMap[string, context.CancelFunc](),
 	}
@@ -140,6 +169,7 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 	if call.SessionID == "" {
 		return nil, ErrSessionMissing
 	}
+	call.Attachments = a.redactAttachments(call.Attachments)
 
 	// Queue the message if busy
 	if a.IsSessionBusy(call.SessionID) {
@@ -152,23 +182,21 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 		return nil, nil
 	}
 
-	if len(a.tools) > 0 {
-		// Add Anthropic caching to the last tool.
-		a.tools[len(a.tools)-1].SetProviderOptions(a.getCacheControlOptions())
-	}
-
-	agent := fantasy.NewAgent(
-		a.largeModel.Model,
-		fantasy.WithSystemPrompt(a.systemPrompt),
-		fantasy.WithTools(a.tools...),
-	)
-
 	sessionLock := sync.Mutex{}
 	currentSession, err := a.sessions.Get(ctx, call.SessionID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get session: %w", err)
 	}
 
+	agentTools := a.tools
+	if currentSession.ExplainMode {
+		agentTools = explainModeTools(agentTools)
+	}
+	if len(agentTools) > 0 {
+		// Add Anthropic caching to the last tool.
+		agentTools[len(agentTools)-1].SetProviderOptions(a.getCacheControlOptions())
+	}
+
 	msgs, err := a.getSessionMessages(ctx, currentSession)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get session messages: %w", err)
@@ -191,6 +219,24 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 
 	// Add the session to the context.
 	ctx = context.WithValue(ctx, tools.SessionIDContextKey, call.SessionID)
+	// Sub-agent sessions don't have a shell profile or a working directory
+	// of their own, and keep the ones of their parent from the context.
+	if currentSession.ShellProfile != "" {
+		ctx = context.WithValue(ctx, tools.ShellProfileContextKey, currentSession.ShellProfile)
+	}
+	if currentSession.WorkingDir != "" {
+		ctx = context.WithValue(ctx, tools.WorkingDirContextKey, currentSession.WorkingDir)
+	}
+
+	systemPrompt := a.currentSystemPrompt(ctx)
+	if currentSession.ExplainMode {
+		systemPrompt += explainModePrompt
+	}
+	agent := fantasy.NewAgent(
+		a.largeModel.Model,
+		fantasy.WithSystemPrompt(systemPrompt),
+		fantasy.WithTools(agentTools...),
+	)
 
 	genCtx, cancel := context.WithCancel(ctx)
 	a.activeRequests.Set(call.SessionID, cancel)
@@ -202,25 +248,38 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 
 	startTime := time.Now()
 	a.eventPromptSent(call.SessionID)
+	limits := newTurnLimits(a.turnLimits, startTime)
+
+	providerOptions := call.ProviderOptions
+	if a.toolUse.DisableParallelToolUse {
+		providerOptions = withoutParallelToolUse(providerOptions)
+	}
 
 	var currentAssistant *message.Message
 	var shouldSummarize bool
+	var timer latency.Timer
 	result, err := agent.Stream(genCtx, fantasy.AgentStreamCall{
 		Prompt:           message.PromptWithTextAttachments(call.Prompt, call.Attachments),
 		Files:            files,
 		Messages:         history,
-		ProviderOptions:  call.ProviderOptions,
+		ProviderOptions:  providerOptions,
 		MaxOutputTokens:  &call.MaxOutputTokens,
 		TopP:             call.TopP,
 		Temperature:      call.Temperature,
 		PresencePenalty:  call.PresencePenalty,
 		TopK:             call.TopK,
 		FrequencyPenalty: call.FrequencyPenalty,
+		RepairToolCall:   repairToolCall,
 		PrepareStep: func(callContext context.Context, options fantasy.PrepareStepFunctionOptions) (_ context.Context, prepared fantasy.PrepareStepResult, err error) {
-			prepared.Messages = options.Messages
+			prepared.Messages = a.redactToolResults(options.Messages)
 			for i := range prepared.Messages {
 				prepared.Messages[i].ProviderOptions = nil
 			}
+			// Only the first step is forced, so the model can still end its
+			// turn once it has called a tool.
+			if options.StepNumber == 0 {
+				prepared.ToolChoice = toolChoice(a.toolUse.ToolChoice)
+			}
 
 			queuedCalls, _ := a.messageQueue.Get(call.SessionID)
 			a.messageQueue.Del(call.SessionID)
@@ -268,13 +327,16 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 			callContext = context.WithValue(callContext, tools.SupportsImagesContextKey, a.largeModel.CatwalkCfg.SupportsImages)
 			callContext = context.WithValue(callContext, tools.ModelNameContextKey, a.largeModel.CatwalkCfg.Name)
 			currentAssistant = &assistantMsg
+			timer.Start()
 			return callContext, prepared, err
 		},
 		OnReasoningStart: func(id string, reasoning fantasy.ReasoningContent) error {
+			timer.Token()
 			currentAssistant.AppendReasoningContent(reasoning.Text)
 			return a.messages.Update(genCtx, *currentAssistant)
 		},
 		OnReasoningDelta: func(id string, text string) error {
+			timer.Token()
 			currentAssistant.AppendReasoningContent(text)
 			return a.messages.Update(genCtx, *currentAssistant)
 		},
@@ -299,6 +361,7 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 			return a.messages.Update(genCtx, *currentAssistant)
 		},
 		OnTextDelta: func(id string, text string) error {
+			timer.Token()
 			// Strip leading newline from initial text content. This is is
 			// particularly important in non-interactive mode where leading
 			// newlines are very visible.
@@ -310,6 +373,7 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 			return a.messages.Update(genCtx, *currentAssistant)
 		},
 		OnToolInputStart: func(id string, toolName string) error {
+			timer.Token()
 			toolCall := message.ToolCall{
 				ID:               id,
 				Name:             toolName,
@@ -319,6 +383,12 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 			currentAssistant.AddToolCall(toolCall)
 			return a.messages.Update(genCtx, *currentAssistant)
 		},
+		OnToolInputDelta: func(id string, delta string) error {
+			timer.Token()
+			// Stream the arguments so the UI can preview the call.
+			currentAssistant.AppendToolCallInput(id, delta)
+			return a.messages.Update(genCtx, *currentAssistant)
+		},
 		OnRetry: func(err *fantasy.ProviderError, delay time.Duration) {
 			// TODO: implement
 		},
@@ -331,10 +401,14 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 				Finished:         true,
 			}
 			currentAssistant.AddToolCall(toolCall)
+			stats.ToolCalled(tc.ToolName)
+			limits.toolCalled(tc.ToolCallID, tc.ToolName, tc.Input)
+			limits.toolCallParsed(tc.Invalid)
 			return a.messages.Update(genCtx, *currentAssistant)
 		},
 		OnToolResult: func(result fantasy.ToolResultContent) error {
 			toolResult := a.convertToToolResult(result)
+			limits.toolResult(toolResult)
 			_, createMsgErr := a.messages.Create(genCtx, currentAssistant.SessionID, message.CreateMessageParams{
 				Role: message.Tool,
 				Parts: []message.ContentPart{
@@ -354,6 +428,7 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 				finishReason = message.FinishReasonToolUse
 			}
 			currentAssistant.AddFinish(finishReason, "", "")
+			timer.Record(a.largeModel.ModelCfg.Provider, a.largeModel.ModelCfg.Model, stepResult.Usage.OutputTokens)
 			sessionLock.Lock()
 			updatedSession, getSessionErr := a.sessions.Get(genCtx, call.SessionID)
 			if getSessionErr != nil {
@@ -385,6 +460,9 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 				}
 				return false
 			},
+			func(_ []fantasy.StepResult) bool {
+				return limits.exceeded(time.Now())
+			},
 		},
 	})
 
@@ -492,6 +570,15 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 	}
 	wg.Wait()
 
+	// The model may have ended its turn on its own in the step that reached
+	// the limit.
+	if limits.reached != "" && currentAssistant != nil && currentAssistant.FinishReason() == message.FinishReasonToolUse {
+		publishLimitEvent(call.SessionID, limits.reached)
+		if err := a.stopTurn(ctx, call.SessionID, limits.stopMessage()); err != nil {
+			return nil, err
+		}
+	}
+
 	if shouldSummarize {
 		a.activeRequests.Del(call.SessionID)
 		if summarizeErr := a.Summarize(genCtx, call.SessionID, call.ProviderOptions); summarizeErr != nil {
@@ -523,7 +610,47 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 	return a.Run(ctx, firstQueuedMessage)
 }
 
+// stopTurn ends a turn the agent stopped before the model was done with a
+// message asking how to proceed.
+func (a *sessionAgent) stopTurn(ctx context.Context, sessionID, text string) error {
+	msg, err := a.messages.Create(ctx, sessionID, message.CreateMessageParams{
+		Role:     message.Assistant,
+		Parts:    []message.ContentPart{message.TextContent{Text: text}},
+		Model:    a.largeModel.ModelCfg.Model,
+		Provider: a.largeModel.ModelCfg.Provider,
+	})
+	if err != nil {
+		return err
+	}
+	msg.AddFinish(message.FinishReasonEndTurn, "", "")
+	return a.messages.Update(ctx, msg)
+}
+
 func (a *sessionAgent) Summarize(ctx context.Context, sessionID string, opts fantasy.ProviderOptions) error {
+	return a.summarize(ctx, sessionID, a.largeModel, opts)
+}
+
+// Compact summarizes the session with the small model on demand. The
+// messages before the summary stay in the session for display, but only the
+// summary is sent to the model from then on. It returns the number of
+// context tokens saved.
+func (a *sessionAgent) Compact(ctx context.Context, sessionID string, opts fantasy.ProviderOptions) (int64, error) {
+	before, err := a.sessions.Get(ctx, sessionID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get session: %w", err)
+	}
+	if err := a.summarize(ctx, sessionID, a.smallModel, opts); err != nil {
+		return 0, err
+	}
+	after, err := a.sessions.Get(ctx, sessionID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get session: %w", err)
+	}
+	saved := (before.PromptTokens + before.CompletionTokens) - (after.PromptTokens + after.CompletionTokens)
+	return max(saved, 0), nil
+}
+
+func (a *sessionAgent) summarize(ctx context.Context, sessionID string, model Model, opts fantasy.ProviderOptions) error {
 	if a.IsSessionBusy(sessionID) {
 		return ErrSessionBusy
 	}
@@ -548,13 +675,13 @@ func (a *sessionAgent) Summarize(ctx context.Context, sessionID string, opts fan
 	defer a.activeRequests.Del(sessionID)
 	defer cancel()
 
-	agent := fantasy.NewAgent(a.largeModel.Model,
+	agent := fantasy.NewAgent(model.Model,
 		fantasy.WithSystemPrompt(string(summaryPrompt)),
 	)
 	summaryMessage, err := a.messages.Create(ctx, sessionID, message.CreateMessageParams{
 		Role:             message.Assistant,
-		Model:            a.largeModel.Model.Model(),
-		Provider:         a.largeModel.Model.Provider(),
+		Model:            model.Model.Model(),
+		Provider:         model.Model.Provider(),
 		IsSummaryMessage: true,
 	})
 	if err != nil {
@@ -576,7 +703,7 @@ func (a *sessionAgent) Summarize(ctx context.Context, sessionID string, opts fan
 		Messages:        aiMsgs,
 		ProviderOptions: opts,
 		PrepareStep: func(callContext context.Context, options fantasy.PrepareStepFunctionOptions) (_ context.Context, prepared fantasy.PrepareStepResult, err error) {
-			prepared.Messages = options.Messages
+			prepared.Messages = a.redactToolResults(options.Messages)
 			if a.systemPromptPrefix != "" {
 				prepared.Messages = append([]fantasy.Message{fantasy.NewSystemMessage(a.systemPromptPrefix)}, prepared.Messages...)
 			}
@@ -629,7 +756,7 @@ func (a *sessionAgent) Summarize(ctx context.Context, sessionID string, opts fan
 		}
 	}
 
-	a.updateSessionUsage(a.largeModel, &currentSession, resp.TotalUsage, openrouterCost)
+	a.updateSessionUsage(model, &currentSession, resp.TotalUsage, openrouterCost)
 
 	// Just in case, get just the last usage info.
 	usage := resp.Response.Usage
@@ -658,7 +785,7 @@ func (a *sessionAgent) createUserMessage(ctx context.Context, call SessionAgentC
 	parts := []message.ContentPart{message.TextContent{Text: call.Prompt}}
 	var attachmentParts []message.ContentPart
 	for _, attachment := range call.Attachments {
-		attachmentParts = append(attachmentParts, message.BinaryContent{Path: attachment.FilePath, MIMEType: attachment.MimeType, Data: attachment.Content})
+		attachmentParts = append(attachmentParts, message.BinaryContent{Path: attachment.FilePath, MIMEType: attachment.MimeType, Data: attachment.Content, Note: attachment.Note})
 	}
 	parts = append(parts, attachmentParts...)
 	msg, err := a.messages.Create(ctx, call.SessionID, message.CreateMessageParams{
@@ -728,7 +855,25 @@ func (a *sessionAgent) getSessionMessages(ctx context.Context, session session.S
 			msgs[0].Role = message.User
 		}
 	}
-	return msgs, nil
+	return withoutExcluded(msgs), nil
+}
+
+// withoutExcluded drops the messages excluded from the context, along with
+// the results of their tool calls, which would be left without the calls
+// they answer.
+func withoutExcluded(msgs []message.Message) []message.Message {
+	excludedCalls := make(map[string]bool)
+	return slices.DeleteFunc(msgs, func(msg message.Message) bool {
+		if msg.Excluded {
+			for _, tc := range msg.ToolCalls() {
+				excludedCalls[tc.ID] = true
+			}
+			return true
+		}
+		return slices.ContainsFunc(msg.ToolResults(), func(tr message.ToolResult) bool {
+			return excludedCalls[tr.ToolCallID]
+		})
+	})
 }
 
 // generateTitle generates a session titled based on the initial prompt.
@@ -888,6 +1033,8 @@ func (a *sessionAgent) updateSessionUsage(model Model, session *session.Session,
 
 	session.CompletionTokens = usage.OutputTokens + usage.CacheReadTokens
 	session.PromptTokens = usage.InputTokens + usage.CacheCreationTokens
+	session.TotalPromptTokens += usage.InputTokens + usage.CacheCreationTokens + usage.CacheReadTokens
+	session.TotalCompletionTokens += usage.OutputTokens
 }
 
 func (a *sessionAgent) Cancel(sessionID string) {
@@ -980,10 +1127,56 @@ func (a *sessionAgent) SetTools(tools []fantasy.AgentTool) {
 	a.tools = tools
 }
 
+func (a *sessionAgent) SetSystemPrompt(systemPrompt string) {
+	a.systemPrompt = systemPrompt
+}
+
 func (a *sessionAgent) Model() Model {
 	return a.largeModel
 }
 
+func (a *sessionAgent) SmallModel() Model {
+	return a.smallModel
+}
+
+const repoMapPrompt = `
+<repo_map>
+Files of the workspace with the main symbols they define, kept up to date. Use it to know where to look first.
+
+%s
+</repo_map>
+`
+
+const sessionWorkingDirPrompt = `
+<session_working_dir>
+The user moved this session to %s. Relative paths of tools are resolved against it and commands run in it by default, so treat it as the working directory.
+</session_working_dir>
+`
+
+const currentTimePrompt = `
+<current_time>
+It's now %s.
+</current_time>
+`
+
+// currentSystemPrompt returns the system prompt with the current repo map,
+// the working directory of the session and the current time.
+func (a *sessionAgent) currentSystemPrompt(ctx context.Context) string {
+	prompt := a.systemPrompt
+	if a.repoMap != nil {
+		if repoMap := a.repoMap(ctx); repoMap != "" {
+			prompt += fmt.Sprintf(repoMapPrompt, repoMap)
+		}
+	}
+	if dir := tools.GetWorkingDirFromContext(ctx, ""); dir != "" {
+		prompt += fmt.Sprintf(sessionWorkingDirPrompt, filepath.ToSlash(dir))
+	}
+	if a.currentTime != nil {
+		prompt += fmt.Sprintf(currentTimePrompt, a.currentTime())
+	}
+	return prompt
+}
+
 func (a *sessionAgent) promptPrefix() string {
 	if a.isClaudeCode() {
 		return "You are Claude Code, Anthropic's official CLI for Claude."
@@ -1009,7 +1202,7 @@ func (a *sessionAgent) convertToToolResult(result fantasy.ToolResultContent) mes
 	switch result.Result.GetType() {
 	case fantasy.ToolResultContentTypeText:
 		if r, ok := fantasy.AsToolResultOutputType[fantasy.ToolResultOutputContentText](result.Result); ok {
-			baseResult.Content = r.Text
+			baseResult.Content = a.redactToolOutput(result.ToolName, r.Text)
 		}
 	case fantasy.ToolResultContentTypeError:
 		if r, ok := fantasy.AsToolResultOutputType[fantasy.ToolResultOutputContentError](result.Result); ok {
@@ -1119,3 +1312,106 @@ func (a *sessionAgent) workaroundProviderMediaLimitations(messages []fantasy.Mes
 
 	return convertedMessages
 }
+
+// redactToolOutput masks likely secrets in tool output before it is stored
+// and sent to the provider, appending a note so the redaction is visible to
+// both the user and the model.
+func (a *sessionAgent) redactToolOutput(toolName, content string) string {
+	redacted, n := a.redactor.Redact(content)
+	if n == 0 {
+		return content
+	}
+	slog.Info("Redacted likely secrets from tool output", "tool", toolName, "count", n)
+	return redacted + "\n\n" + redact.Note(n)
+}
+
+// redactToolResults masks likely secrets in the tool results of the messages
+// sent in a step. Within a turn, the results of the previous step are passed
+// on as the tools returned them rather than as they were stored, so they're
+// redacted here too.
+func (a *sessionAgent) redactToolResults(messages []fantasy.Message) []fantasy.Message {
+	if a.redactor == nil {
+		return messages
+	}
+	for i, msg := range messages {
+		if msg.Role != fantasy.MessageRoleTool {
+			continue
+		}
+		var content []fantasy.MessagePart
+		for j, part := range msg.Content {
+			result, ok := fantasy.AsMessagePart[fantasy.ToolResultPart](part)
+			if !ok {
+				continue
+			}
+			output, ok := fantasy.AsToolResultOutputType[fantasy.ToolResultOutputContentText](result.Output)
+			if !ok {
+				continue
+			}
+			redacted, n := a.redactor.Redact(output.Text)
+			if n == 0 {
+				continue
+			}
+			if content == nil {
+				content = slices.Clone(msg.Content)
+			}
+			result.Output = fantasy.ToolResultOutputContentText{Text: redacted + "\n\n" + redact.Note(n)}
+			content[j] = result
+		}
+		if content != nil {
+			messages[i].Content = content
+		}
+	}
+	return messages
+}
+
+// redactAttachments masks likely secrets in text attachments.
+func (a *sessionAgent) redactAttachments(attachments []message.Attachment) []message.Attachment {
+	if a.redactor == nil || len(attachments) == 0 {
+		return attachments
+	}
+	result := make([]message.Attachment, len(attachments))
+	for i, att := range attachments {
+		result[i] = att
+		if !att.IsText() {
+			continue
+		}
+		redacted, n := a.redactor.Redact(string(att.Content))
+		if n > 0 {
+			slog.Info("Redacted likely secrets from attachment", "file", att.FileName, "count", n)
+			result[i].Content = []byte(redacted + "\n\n" + redact.Note(n))
+		}
+	}
+	return result
+}
+
+// fitImages fits the images of the attachments to the limits of the provider.
+// Images that can't be fitted are sent as they are, for the provider to
+// report.
+func fitImages(attachments []message.Attachment, limits imagefit.Limits) []message.Attachment {
+	if len(attachments) == 0 {
+		return attachments
+	}
+	result := make([]message.Attachment, len(attachments))
+	for i, att := range attachments {
+		fitted, err := imagefit.Fit(att, limits)
+		if err != nil {
+			slog.Warn("Failed to fit image to the provider limits", "file", att.FileName, "error", err)
+		}
+		if fitted.Note != "" {
+			slog.Info("Fitted image to the provider limits", "file", att.FileName, "note", fitted.Note)
+		}
+		result[i] = fitted
+	}
+	return result
+}
+
+// checkAttachments refuses to send attachments in a privacy zone, which the
+// user has to remove from the prompt.
+func checkAttachments(zones *privacy.Zones, attachments []message.Attachment) error {
+	for _, att := range attachments {
+		if pattern, ok := zones.Match(att.FilePath); ok {
+			return fmt.Errorf("%s is in a privacy zone (matches %q), remove it from the attachments to send the prompt", att.FilePath, pattern)
+		}
+	}
+	return nil
+}
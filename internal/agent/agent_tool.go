@@ -5,6 +5,9 @@ import (
 	_ "embed"
 	"errors"
 	"fmt"
+	"strings"
+	"sync"
+	"time"
 
 	"charm.land/fantasy"
 
@@ -17,11 +20,16 @@ import (
 var agentToolDescription []byte
 
 type AgentParams struct {
-	Prompt string `json:"prompt" description:"The task for the agent to perform"`
+	Prompt string   `json:"prompt,omitempty" description:"The task for the agent to perform"`
+	Tasks  []string `json:"tasks,omitempty" description:"Independent tasks to run concurrently, each by its own agent, instead of a single prompt"`
 }
 
 const (
 	AgentToolName = "agent"
+
+	// defaultAgentPoolSize is the number of tasks of an agent tool call run
+	// at the same time when options.sub_agents.pool_size isn't set.
+	defaultAgentPoolSize = 4
 )
 
 func (c *coordinator) agentTool(ctx context.Context) (fantasy.AgentTool, error) {
@@ -42,8 +50,8 @@ func (c *coordinator) agentTool(ctx context.Context) (fantasy.AgentTool, error)
 		AgentToolName,
 		string(agentToolDescription),
 		func(ctx context.Context, params AgentParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
-			if params.Prompt == "" {
-				return fantasy.NewTextErrorResponse("prompt is required"), nil
+			if params.Prompt == "" && len(params.Tasks) == 0 {
+				return fantasy.NewTextErrorResponse("prompt or tasks is required"), nil
 			}
 
 			sessionID := tools.GetSessionFromContext(ctx)
@@ -56,50 +64,131 @@ func (c *coordinator) agentTool(ctx context.Context) (fantasy.AgentTool, error)
 				return fantasy.ToolResponse{}, errors.New("agent message id missing from context")
 			}
 
-			agentToolSessionID := c.sessions.CreateAgentToolSessionID(agentMessageID, call.ID)
-			session, err := c.sessions.CreateTaskSession(ctx, agentToolSessionID, sessionID, "New Agent Session")
-			if err != nil {
-				return fantasy.ToolResponse{}, fmt.Errorf("error creating session: %s", err)
-			}
-			model := agent.Model()
-			maxTokens := model.CatwalkCfg.DefaultMaxTokens
-			if model.ModelCfg.MaxTokens != 0 {
-				maxTokens = model.ModelCfg.MaxTokens
+			if len(params.Tasks) == 0 {
+				agentToolSessionID := c.sessions.CreateAgentToolSessionID(agentMessageID, call.ID)
+				return c.runSubAgent(ctx, agent, sessionID, agentToolSessionID, "New Agent Session", params.Prompt)
 			}
 
-			providerCfg, ok := c.cfg.Providers.Get(model.ModelCfg.Provider)
-			if !ok {
-				return fantasy.ToolResponse{}, errors.New("model provider not configured")
-			}
-			result, err := agent.Run(ctx, SessionAgentCall{
-				SessionID:        session.ID,
-				Prompt:           params.Prompt,
-				MaxOutputTokens:  maxTokens,
-				ProviderOptions:  getProviderOptions(model, providerCfg),
-				Temperature:      model.ModelCfg.Temperature,
-				TopP:             model.ModelCfg.TopP,
-				TopK:             model.ModelCfg.TopK,
-				FrequencyPenalty: model.ModelCfg.FrequencyPenalty,
-				PresencePenalty:  model.ModelCfg.PresencePenalty,
-			})
-			if err != nil {
-				return fantasy.NewTextErrorResponse("error generating response"), nil
-			}
-			updatedSession, err := c.sessions.Get(ctx, session.ID)
-			if err != nil {
-				return fantasy.ToolResponse{}, fmt.Errorf("error getting session: %s", err)
-			}
-			parentSession, err := c.sessions.Get(ctx, sessionID)
-			if err != nil {
-				return fantasy.ToolResponse{}, fmt.Errorf("error getting parent session: %s", err)
-			}
+			return c.runTasks(ctx, agent, sessionID, agentMessageID, call.ID, params.Tasks), nil
+		}), nil
+}
 
-			parentSession.Cost += updatedSession.Cost
+// xmlEscaper escapes the text of the elements of the results of tasks, so a
+// task or result can't be mistaken for their tags.
+var xmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
 
-			_, err = c.sessions.Save(ctx, parentSession)
-			if err != nil {
-				return fantasy.ToolResponse{}, fmt.Errorf("error saving parent session: %s", err)
+// runTasks runs each task by its own sub-agent, as many at the same time as
+// the pool size allows, and returns their results in the order of the tasks.
+// Tasks not started yet when ctx is done fail with its error.
+func (c *coordinator) runTasks(ctx context.Context, agent SessionAgent, sessionID, agentMessageID, toolCallID string, tasks []string) fantasy.ToolResponse {
+	poolSize := defaultAgentPoolSize
+	if cfg := c.cfg.Options.SubAgents; cfg != nil && cfg.PoolSize > 0 {
+		poolSize = cfg.PoolSize
+	}
+	responses := make([]fantasy.ToolResponse, len(tasks))
+	errs := make([]error, len(tasks))
+	queue := make(chan int)
+	var wg sync.WaitGroup
+	for range min(poolSize, len(tasks)) {
+		wg.Go(func() {
+			for i := range queue {
+				if err := ctx.Err(); err != nil {
+					errs[i] = err
+					continue
+				}
+				taskSessionID := c.sessions.CreateAgentTaskSessionID(agentMessageID, toolCallID, i)
+				title := fmt.Sprintf("Agent Task %d", i+1)
+				responses[i], errs[i] = c.runSubAgent(ctx, agent, sessionID, taskSessionID, title, tasks[i])
 			}
-			return fantasy.NewTextResponse(result.Response.Content.Text()), nil
-		}), nil
+		})
+	}
+	for i := range tasks {
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+
+	var result strings.Builder
+	failed := 0
+	for i, task := range tasks {
+		content := responses[i].Content
+		if errs[i] != nil {
+			content = errs[i].Error()
+		}
+		status := ""
+		if errs[i] != nil || responses[i].IsError {
+			status = " (failed)"
+			failed++
+		}
+		fmt.Fprintf(&result, "<task index=\"%d\"%s>\n<prompt>%s</prompt>\n<result>\n%s\n</result>\n</task>\n", i+1, status, xmlEscaper.Replace(task), xmlEscaper.Replace(content))
+	}
+	if failed == len(tasks) {
+		return fantasy.NewTextErrorResponse(result.String())
+	}
+	return fantasy.NewTextResponse(result.String())
+}
+
+// runSubAgent runs the task agent on prompt in the session with the given ID,
+// a child of the session with parentSessionID, and adds its cost to the
+// parent session.
+func (c *coordinator) runSubAgent(ctx context.Context, agent SessionAgent, parentSessionID, sessionID, title, prompt string) (fantasy.ToolResponse, error) {
+	if cfg := c.cfg.Options.SubAgents; cfg != nil && cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(cfg.Timeout)*time.Second)
+		defer cancel()
+	}
+
+	session, err := c.sessions.CreateTaskSession(ctx, sessionID, parentSessionID, title)
+	if err != nil {
+		return fantasy.ToolResponse{}, fmt.Errorf("error creating session: %s", err)
+	}
+	model := agent.Model()
+	maxTokens := model.CatwalkCfg.DefaultMaxTokens
+	if model.ModelCfg.MaxTokens != 0 {
+		maxTokens = model.ModelCfg.MaxTokens
+	}
+
+	providerCfg, ok := c.cfg.Providers.Get(model.ModelCfg.Provider)
+	if !ok {
+		return fantasy.ToolResponse{}, errors.New("model provider not configured")
+	}
+	result, err := agent.Run(ctx, SessionAgentCall{
+		SessionID:        session.ID,
+		Prompt:           prompt,
+		MaxOutputTokens:  maxTokens,
+		ProviderOptions:  getProviderOptions(model, providerCfg),
+		Temperature:      model.ModelCfg.Temperature,
+		TopP:             model.ModelCfg.TopP,
+		TopK:             model.ModelCfg.TopK,
+		FrequencyPenalty: model.ModelCfg.FrequencyPenalty,
+		PresencePenalty:  model.ModelCfg.PresencePenalty,
+	})
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fantasy.NewTextErrorResponse("the agent ran out of time"), nil
+	}
+	if err != nil {
+		return fantasy.NewTextErrorResponse("error generating response"), nil
+	}
+	updatedSession, err := c.sessions.Get(ctx, session.ID)
+	if err != nil {
+		return fantasy.ToolResponse{}, fmt.Errorf("error getting session: %s", err)
+	}
+
+	// Concurrent tasks all add their cost to the same parent session.
+	c.subAgentCostMu.Lock()
+	defer c.subAgentCostMu.Unlock()
+	parentSession, err := c.sessions.Get(ctx, parentSessionID)
+	if err != nil {
+		return fantasy.ToolResponse{}, fmt.Errorf("error getting parent session: %s", err)
+	}
+
+	parentSession.Cost += updatedSession.Cost
+	parentSession.TotalPromptTokens += updatedSession.TotalPromptTokens
+	parentSession.TotalCompletionTokens += updatedSession.TotalCompletionTokens
+
+	_, err = c.sessions.Save(ctx, parentSession)
+	if err != nil {
+		return fantasy.ToolResponse{}, fmt.Errorf("error saving parent session: %s", err)
+	}
+	return fantasy.NewTextResponse(result.Response.Content.Text()), nil
 }
@@ -0,0 +1,173 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSubAgent is a task agent answering with run, which costs as much as
+// each run does to the session it runs in.
+type fakeSubAgent struct {
+	SessionAgent
+	sessions session.Service
+	run      func(ctx context.Context, prompt string) (string, error)
+
+	mu      sync.Mutex
+	running int
+	maxRuns int
+	calls   int
+}
+
+func (a *fakeSubAgent) Model() Model {
+	return Model{ModelCfg: config.SelectedModel{Provider: "fake"}}
+}
+
+func (a *fakeSubAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy.AgentResult, error) {
+	a.mu.Lock()
+	a.calls++
+	a.running++
+	a.maxRuns = max(a.maxRuns, a.running)
+	a.mu.Unlock()
+	defer func() {
+		a.mu.Lock()
+		a.running--
+		a.mu.Unlock()
+	}()
+
+	text, err := a.run(ctx, call.Prompt)
+	if err != nil {
+		return nil, err
+	}
+	sess, err := a.sessions.Get(ctx, call.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	sess.Cost += 0.5
+	sess.TotalPromptTokens += 100
+	sess.TotalCompletionTokens += 10
+	if _, err := a.sessions.Save(ctx, sess); err != nil {
+		return nil, err
+	}
+	return &fantasy.AgentResult{
+		Response: fantasy.Response{Content: fantasy.ResponseContent{fantasy.TextContent{Text: text}}},
+	}, nil
+}
+
+func newTaskCoordinator(t *testing.T, poolSize int) (*coordinator, session.Session) {
+	env := testEnv(t)
+	c := &coordinator{
+		cfg: &config.Config{
+			Options:   &config.Options{SubAgents: &config.SubAgents{PoolSize: poolSize}},
+			Providers: csync.NewMapFrom(map[string]config.ProviderConfig{"fake": {ID: "fake"}}),
+		},
+		sessions: env.sessions,
+	}
+	parent, err := env.sessions.Create(t.Context(), "Parent")
+	require.NoError(t, err)
+	return c, parent
+}
+
+func TestRunTasks(t *testing.T) {
+	c, parent := newTaskCoordinator(t, 2)
+	agent := &fakeSubAgent{
+		sessions: c.sessions,
+		run: func(_ context.Context, prompt string) (string, error) {
+			// Later tasks finish first, so the results are out of order.
+			delay := map[string]time.Duration{"one": 80, "two": 60, "three": 40}[prompt]
+			time.Sleep(delay * time.Millisecond)
+			if prompt == "fail" {
+				return "", errors.New("provider error")
+			}
+			return "done: " + prompt, nil
+		},
+	}
+
+	tasks := []string{"one", "two", "three", "fail", "compare <a> & <b>"}
+	resp := c.runTasks(t.Context(), agent, parent.ID, "message", "call", tasks)
+	require.False(t, resp.IsError)
+	require.Equal(t, 5, agent.calls)
+	require.Equal(t, 2, agent.maxRuns)
+
+	// The results are in the order of the tasks, with the failed one marked.
+	var last int
+	for _, want := range []string{
+		"<task index=\"1\">\n<prompt>one</prompt>\n<result>\ndone: one\n</result>\n</task>\n",
+		"<task index=\"2\">\n<prompt>two</prompt>\n<result>\ndone: two\n</result>\n</task>\n",
+		"<task index=\"3\">\n<prompt>three</prompt>\n<result>\ndone: three\n</result>\n</task>\n",
+		"<task index=\"4\" (failed)>\n<prompt>fail</prompt>\n<result>\nerror generating response\n</result>\n</task>\n",
+		"<task index=\"5\">\n<prompt>compare &lt;a&gt; &amp; &lt;b&gt;</prompt>\n<result>\ndone: compare &lt;a&gt; &amp; &lt;b&gt;\n</result>\n</task>\n",
+	} {
+		i := strings.Index(resp.Content, want)
+		require.GreaterOrEqual(t, i, last, "missing or out of order: %q", want)
+		last = i
+	}
+
+	// Only the tasks that succeeded cost something, all added to the parent.
+	parent, err := c.sessions.Get(t.Context(), parent.ID)
+	require.NoError(t, err)
+	require.InDelta(t, 2.0, parent.Cost, 1e-9)
+	require.Equal(t, int64(400), parent.TotalPromptTokens)
+	require.Equal(t, int64(40), parent.TotalCompletionTokens)
+}
+
+func TestRunTasksAllFailed(t *testing.T) {
+	c, parent := newTaskCoordinator(t, 4)
+	agent := &fakeSubAgent{
+		sessions: c.sessions,
+		run: func(context.Context, string) (string, error) {
+			return "", errors.New("provider error")
+		},
+	}
+
+	resp := c.runTasks(t.Context(), agent, parent.ID, "message", "call", []string{"one", "two"})
+	require.True(t, resp.IsError)
+	require.Equal(t, 2, strings.Count(resp.Content, "(failed)"))
+}
+
+func TestRunTasksCancelled(t *testing.T) {
+	c, parent := newTaskCoordinator(t, 1)
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	agent := &fakeSubAgent{
+		sessions: c.sessions,
+		run: func(ctx context.Context, _ string) (string, error) {
+			cancel()
+			return "", ctx.Err()
+		},
+	}
+
+	// The tasks waiting for the running one aren't started once it's
+	// cancelled.
+	resp := c.runTasks(ctx, agent, parent.ID, "message", "call", []string{"one", "two", "three"})
+	require.True(t, resp.IsError)
+	require.Equal(t, 1, agent.calls)
+	require.Equal(t, 3, strings.Count(resp.Content, "(failed)"))
+	require.Equal(t, 2, strings.Count(resp.Content, context.Canceled.Error()))
+}
+
+func TestRunSubAgentTimeout(t *testing.T) {
+	c, parent := newTaskCoordinator(t, 1)
+	c.cfg.Options.SubAgents.Timeout = 1
+	agent := &fakeSubAgent{
+		sessions: c.sessions,
+		run: func(ctx context.Context, _ string) (string, error) {
+			<-ctx.Done()
+			return "", ctx.Err()
+		},
+	}
+
+	resp, err := c.runSubAgent(t.Context(), agent, parent.ID, "message$$call", "New Agent Session", "wait")
+	require.NoError(t, err)
+	require.True(t, resp.IsError)
+	require.Equal(t, "the agent ran out of time", resp.Content)
+}
@@ -163,10 +163,10 @@ func (c *coordinator) agenticFetchTool(_ context.Context, client *http.Client) (
 			fetchTools := []fantasy.AgentTool{
 				webFetchTool,
 				webSearchTool,
-				tools.NewGlobTool(tmpDir),
-				tools.NewGrepTool(tmpDir),
+				tools.NewGlobTool(tmpDir, nil),
+				tools.NewGrepTool(tmpDir, nil),
 				tools.NewSourcegraphTool(client),
-				tools.NewViewTool(c.lspClients, c.permissions, tmpDir),
+				tools.NewViewTool(c.lspClients, c.permissions, nil, tmpDir),
 			}
 
 			agent := NewSessionAgent(SessionAgentOptions{
@@ -179,6 +179,7 @@ func (c *coordinator) agenticFetchTool(_ context.Context, client *http.Client) (
 				Sessions:             c.sessions,
 				Messages:             c.messages,
 				Tools:                fetchTools,
+				Redactor:             c.redactor,
 			})
 
 			agentToolSessionID := c.sessions.CreateAgentToolSessionID(validationResult.AgentMessageID, call.ID)
@@ -0,0 +1,56 @@
+package agent
+
+import (
+	"net/http"
+	"os"
+
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/config"
+)
+
+// Environment variables of shell profiles overriding the organization and
+// project OpenAI bills the requests of a session to.
+const (
+	openaiOrgEnv     = "OPENAI_ORG_ID"
+	openaiProjectEnv = "OPENAI_PROJECT_ID"
+)
+
+// billingTransport sets the organization and project headers of OpenAI
+// requests from the shell profile of the session making them, so sessions
+// working on different projects are billed to them.
+type billingTransport struct {
+	base     http.RoundTripper
+	profiles map[string]config.ShellProfile
+}
+
+// overridesBilling reports whether any of the shell profiles overrides the
+// organization or the project of requests.
+func overridesBilling(profiles map[string]config.ShellProfile) bool {
+	for _, profile := range profiles {
+		if profile.Env[openaiOrgEnv] != "" || profile.Env[openaiProjectEnv] != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *billingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	name := tools.GetShellProfileFromContext(req.Context())
+	if name == "" {
+		name = tools.DefaultShellProfile
+	}
+	profile := t.profiles[name]
+	org := os.ExpandEnv(profile.Env[openaiOrgEnv])
+	project := os.ExpandEnv(profile.Env[openaiProjectEnv])
+	if org == "" && project == "" {
+		return t.base.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	if org != "" {
+		req.Header.Set("OpenAI-Organization", org)
+	}
+	if project != "" {
+		req.Header.Set("OpenAI-Project", project)
+	}
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,44 @@
+package agent
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBillingTransport(t *testing.T) {
+	t.Parallel()
+
+	var org, project string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		org, project = r.Header.Get("OpenAI-Organization"), r.Header.Get("OpenAI-Project")
+	}))
+	defer server.Close()
+
+	profiles := map[string]config.ShellProfile{
+		"client": {Env: map[string]string{openaiOrgEnv: "org-client", openaiProjectEnv: "proj-client"}},
+		"build":  {Env: map[string]string{"GOFLAGS": "-tags=integration"}},
+	}
+	require.True(t, overridesBilling(profiles))
+	client := &http.Client{Transport: &billingTransport{base: http.DefaultTransport, profiles: profiles}}
+
+	for profile, want := range map[string][2]string{
+		"client": {"org-client", "proj-client"},
+		"build":  {"org-config", ""},
+		"":       {"org-config", ""},
+	} {
+		ctx := context.WithValue(t.Context(), tools.ShellProfileContextKey, profile)
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, server.URL, nil)
+		require.NoError(t, err)
+		req.Header.Set("OpenAI-Organization", "org-config")
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, want, [2]string{org, project}, profile)
+	}
+}
@@ -0,0 +1,263 @@
+// Package chattemplate applies the chat template overrides of local models.
+// It adds the stop sequences of the template to chat completion requests,
+// and when the template formats the prompt itself, turns those requests into
+// completion requests of the formatted prompt, and their responses back into
+// chat completions.
+package chattemplate
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/home"
+)
+
+// Message is a message of the conversation, as given to template files.
+type Message struct {
+	Role    string
+	Content string
+}
+
+// Transport applies a chat template to the chat completion requests sent
+// through it.
+type Transport struct {
+	Base     http.RoundTripper
+	template config.ChatTemplate
+	file     *template.Template
+}
+
+// NewClient returns an HTTP client applying the chat template, sending
+// requests through base, or the default transport when nil.
+func NewClient(base http.RoundTripper, tmpl config.ChatTemplate) (*http.Client, error) {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	t := &Transport{Base: base, template: tmpl}
+	if tmpl.File != "" {
+		data, err := os.ReadFile(home.Long(tmpl.File))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read chat template: %w", err)
+		}
+		t.file, err = template.New(tmpl.File).Parse(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse chat template: %w", err)
+		}
+	}
+	return &http.Client{Transport: t}, nil
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body == nil || req.Body == http.NoBody || !strings.HasSuffix(req.URL.Path, "/chat/completions") {
+		return t.Base.RoundTrip(req)
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	rewritten, completion, err := t.rewriteRequest(body)
+	if err != nil {
+		return nil, err
+	}
+
+	req = req.Clone(req.Context())
+	req.Body = io.NopCloser(bytes.NewReader(rewritten))
+	req.ContentLength = int64(len(rewritten))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(rewritten)), nil
+	}
+	if !completion {
+		return t.Base.RoundTrip(req)
+	}
+	req.URL.Path = strings.TrimSuffix(req.URL.Path, "/chat/completions") + "/completions"
+	resp, err := t.Base.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+
+	translated := *resp
+	translated.Header = resp.Header.Clone()
+	translated.Header.Del("Content-Length")
+	translated.ContentLength = -1
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		translated.Body = translateStream(resp.Body)
+		return &translated, nil
+	}
+	defer resp.Body.Close()
+	out, err := translateResponse(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	translated.ContentLength = int64(len(out))
+	translated.Body = io.NopCloser(bytes.NewReader(out))
+	return &translated, nil
+}
+
+// rewriteRequest adds the stop sequences to a chat completion request, and
+// turns it into a completion request of the formatted prompt when the
+// template formats it. Requests with tools are left to the server to format,
+// as the completion endpoint doesn't know about tools.
+func (t *Transport) rewriteRequest(body []byte) (_ []byte, completion bool, _ error) {
+	var req map[string]json.RawMessage
+	if err := json.Unmarshal(body, &req); err != nil {
+		return body, false, nil
+	}
+	set := func(key string, value any) {
+		data, _ := json.Marshal(value)
+		req[key] = data
+	}
+
+	if len(t.template.Stop) > 0 {
+		var stop []string
+		if err := json.Unmarshal(req["stop"], &stop); err != nil {
+			var single string
+			if json.Unmarshal(req["stop"], &single) == nil && single != "" {
+				stop = []string{single}
+			}
+		}
+		set("stop", append(stop, t.template.Stop...))
+	}
+
+	_, hasTools := req["tools"]
+	if !t.template.FormatsPrompt() || hasTools {
+		rewritten, err := json.Marshal(req)
+		return rewritten, false, err
+	}
+
+	var messages []struct {
+		Role    string `json:"role"`
+		Content any    `json:"content"`
+	}
+	if err := json.Unmarshal(req["messages"], &messages); err != nil {
+		return nil, false, fmt.Errorf("failed to decode messages: %w", err)
+	}
+	var msgs []Message
+	for _, msg := range messages {
+		msgs = append(msgs, Message{Role: msg.Role, Content: text(msg.Content)})
+	}
+	prompt, err := t.format(msgs)
+	if err != nil {
+		return nil, false, err
+	}
+	delete(req, "messages")
+	set("prompt", prompt)
+
+	// The completion endpoint of llama.cpp takes the schema of the answer
+	// directly.
+	var format struct {
+		JSONSchema struct {
+			Schema json.RawMessage `json:"schema"`
+		} `json:"json_schema"`
+	}
+	if json.Unmarshal(req["response_format"], &format) == nil && len(format.JSONSchema.Schema) > 0 {
+		req["json_schema"] = format.JSONSchema.Schema
+	}
+	rewritten, err := json.Marshal(req)
+	return rewritten, true, err
+}
+
+// format formats the prompt of the messages with the template file, or the
+// role markers, ending with the marker of the answer of the assistant.
+func (t *Transport) format(messages []Message) (string, error) {
+	var b strings.Builder
+	if t.file != nil {
+		if err := t.file.Execute(&b, struct{ Messages []Message }{messages}); err != nil {
+			return "", fmt.Errorf("failed to format prompt: %w", err)
+		}
+		return b.String(), nil
+	}
+	for _, msg := range messages {
+		switch msg.Role {
+		case "system", "developer":
+			b.WriteString(t.template.System)
+		case "assistant":
+			b.WriteString(t.template.Assistant)
+		default:
+			b.WriteString(t.template.User)
+		}
+		b.WriteString(msg.Content)
+		b.WriteString(t.template.End)
+	}
+	b.WriteString(t.template.Assistant)
+	return b.String(), nil
+}
+
+// text returns the text of the content of a message, given as a string or
+// as parts.
+func text(content any) string {
+	switch content := content.(type) {
+	case string:
+		return content
+	case []any:
+		var texts []string
+		for _, part := range content {
+			part, _ := part.(map[string]any)
+			if t, ok := part["text"].(string); ok {
+				texts = append(texts, t)
+			}
+		}
+		return strings.Join(texts, "\n")
+	}
+	return ""
+}
+
+// translateChoices turns the choices of a completion into the ones of a chat
+// completion, with the text as the message, or the delta when streaming.
+func translateChoices(resp map[string]any, field string) {
+	choices, _ := resp["choices"].([]any)
+	for _, choice := range choices {
+		choice, ok := choice.(map[string]any)
+		if !ok {
+			continue
+		}
+		text, _ := choice["text"].(string)
+		delete(choice, "text")
+		delete(choice, "logprobs")
+		choice[field] = map[string]any{"role": "assistant", "content": text}
+	}
+}
+
+func translateResponse(r io.Reader) ([]byte, error) {
+	var resp map[string]any
+	if err := json.NewDecoder(r).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	resp["object"] = "chat.completion"
+	translateChoices(resp, "message")
+	return json.Marshal(resp)
+}
+
+// translateStream translates the events of a completion stream as they come.
+func translateStream(body io.ReadCloser) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		defer body.Close()
+		scanner := bufio.NewScanner(body)
+		scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if data, ok := strings.CutPrefix(line, "data:"); ok {
+				var chunk map[string]any
+				if json.Unmarshal([]byte(strings.TrimSpace(data)), &chunk) == nil {
+					chunk["object"] = "chat.completion.chunk"
+					translateChoices(chunk, "delta")
+					translated, _ := json.Marshal(chunk)
+					line = "data: " + string(translated)
+				}
+			}
+			if _, err := io.WriteString(pw, line+"\n"); err != nil {
+				return
+			}
+		}
+		pw.CloseWithError(scanner.Err())
+	}()
+	return pr
+}
@@ -0,0 +1,98 @@
+package chattemplate
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+const request = `{
+	"model": "qwen",
+	"stream": true,
+	"messages": [
+		{"role": "system", "content": "You are crush."},
+		{"role": "user", "content": [{"type": "text", "text": "Hi."}]}
+	]
+}`
+
+var chatml = config.ChatTemplate{
+	Stop:      []string{"<|im_end|>"},
+	System:    "<|im_start|>system\n",
+	User:      "<|im_start|>user\n",
+	Assistant: "<|im_start|>assistant\n",
+	End:       "<|im_end|>\n",
+}
+
+func TestRewriteRequest(t *testing.T) {
+	t.Parallel()
+
+	t.Run("markers", func(t *testing.T) {
+		t.Parallel()
+		client, err := NewClient(nil, chatml)
+		require.NoError(t, err)
+		body, completion, err := client.Transport.(*Transport).rewriteRequest([]byte(request))
+		require.NoError(t, err)
+		require.True(t, completion)
+
+		var req map[string]any
+		require.NoError(t, json.Unmarshal(body, &req))
+		require.Nil(t, req["messages"])
+		require.Equal(t, []any{"<|im_end|>"}, req["stop"])
+		require.Equal(t, "<|im_start|>system\nYou are crush.<|im_end|>\n<|im_start|>user\nHi.<|im_end|>\n<|im_start|>assistant\n", req["prompt"])
+	})
+
+	t.Run("file", func(t *testing.T) {
+		t.Parallel()
+		path := filepath.Join(t.TempDir(), "chat.tmpl")
+		require.NoError(t, os.WriteFile(path, []byte("{{range .Messages}}[{{.Role}}] {{.Content}}\n{{end}}[assistant] "), 0o644))
+		client, err := NewClient(nil, config.ChatTemplate{File: path})
+		require.NoError(t, err)
+		body, completion, err := client.Transport.(*Transport).rewriteRequest([]byte(request))
+		require.NoError(t, err)
+		require.True(t, completion)
+		require.Contains(t, string(body), `"prompt":"[system] You are crush.\n[user] Hi.\n[assistant] "`)
+	})
+
+	t.Run("tools", func(t *testing.T) {
+		t.Parallel()
+		client, err := NewClient(nil, chatml)
+		require.NoError(t, err)
+		body, completion, err := client.Transport.(*Transport).rewriteRequest([]byte(`{"messages": [], "tools": [], "stop": "###"}`))
+		require.NoError(t, err)
+		require.False(t, completion)
+		require.JSONEq(t, `{"messages": [], "tools": [], "stop": ["###", "<|im_end|>"]}`, string(body))
+	})
+}
+
+func TestTransport(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v1/completions", r.URL.Path)
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, `data: {"id":"1","object":"text_completion","choices":[{"index":0,"text":"Hello","finish_reason":null}]}`+"\n\n")
+		io.WriteString(w, `data: {"id":"1","object":"text_completion","choices":[{"index":0,"text":"!","finish_reason":"stop"}]}`+"\n\n")
+		io.WriteString(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	client, err := NewClient(nil, chatml)
+	require.NoError(t, err)
+	resp, err := client.Post(server.URL+"/v1/chat/completions", "application/json", strings.NewReader(request))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	require.Contains(t, string(body), `"delta":{"content":"Hello","role":"assistant"}`)
+	require.Contains(t, string(body), `"object":"chat.completion.chunk"`)
+	require.Contains(t, string(body), "data: [DONE]")
+}
@@ -2,9 +2,13 @@ package agent
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
+	"sync"
 	"testing"
 	"time"
 
@@ -25,6 +29,7 @@ import (
 	"github.com/charmbracelet/crush/internal/message"
 	"github.com/charmbracelet/crush/internal/permission"
 	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/crush/internal/shell"
 	"github.com/stretchr/testify/require"
 
 	_ "github.com/joho/godotenv/autoload"
@@ -149,7 +154,15 @@ func testSessionAgent(env fakeEnv, large, small fantasy.LanguageModel, systemPro
 			DefaultMaxTokens: 10000,
 		},
 	}
-	agent := NewSessionAgent(SessionAgentOptions{largeModel, smallModel, "", systemPrompt, false, false, true, env.sessions, env.messages, tools})
+	agent := NewSessionAgent(SessionAgentOptions{
+		LargeModel:   largeModel,
+		SmallModel:   smallModel,
+		SystemPrompt: systemPrompt,
+		IsYolo:       true,
+		Sessions:     env.sessions,
+		Messages:     env.messages,
+		Tools:        tools,
+	})
 	return agent
 }
 
@@ -194,22 +207,92 @@ func coderAgent(r *vcr.Recorder, env fakeEnv, large, small fantasy.LanguageModel
 	}
 
 	allTools := []fantasy.AgentTool{
-		tools.NewBashTool(env.permissions, env.workingDir, cfg.Options.Attribution, modelName),
+		tools.NewBashTool(env.permissions, nil, env.workingDir, cfg.Options.Attribution, modelName, shell.ShellTypePOSIX, cfg.Tools.Limits[tools.BashToolName], cfg.Options.ShellProfiles),
 		tools.NewDownloadTool(env.permissions, env.workingDir, r.GetDefaultClient()),
-		tools.NewEditTool(env.lspClients, env.permissions, env.history, env.workingDir),
-		tools.NewMultiEditTool(env.lspClients, env.permissions, env.history, env.workingDir),
+		tools.NewEditTool(env.lspClients, env.permissions, env.history, nil, env.workingDir),
+		tools.NewMultiEditTool(env.lspClients, env.permissions, env.history, nil, env.workingDir),
 		tools.NewFetchTool(env.permissions, env.workingDir, r.GetDefaultClient()),
-		tools.NewGlobTool(env.workingDir),
-		tools.NewGrepTool(env.workingDir),
-		tools.NewLsTool(env.permissions, env.workingDir, cfg.Tools.Ls),
+		tools.NewGlobTool(env.workingDir, nil),
+		tools.NewGrepTool(env.workingDir, nil),
+		tools.NewLsTool(env.permissions, nil, env.workingDir, cfg.Tools.Ls),
 		tools.NewSourcegraphTool(r.GetDefaultClient()),
-		tools.NewViewTool(env.lspClients, env.permissions, env.workingDir),
-		tools.NewWriteTool(env.lspClients, env.permissions, env.history, env.workingDir),
+		tools.NewViewTool(env.lspClients, env.permissions, nil, env.workingDir),
+		tools.NewWriteTool(env.lspClients, env.permissions, env.history, nil, env.workingDir),
 	}
 
 	return testSessionAgent(env, large, small, systemPrompt, allTools...), nil
 }
 
+// scriptedModel is a language model streaming the steps scripted for it, in
+// order, then plain text, and recording the prompts it's sent.
+type scriptedModel struct {
+	mu      sync.Mutex
+	steps   [][]fantasy.StreamPart
+	prompts []fantasy.Prompt
+}
+
+func (m *scriptedModel) Stream(_ context.Context, call fantasy.Call) (fantasy.StreamResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.prompts = append(m.prompts, call.Prompt)
+	parts := textStep("Done.")
+	if len(m.steps) > 0 {
+		parts, m.steps = m.steps[0], m.steps[1:]
+	}
+	return slices.Values(parts), nil
+}
+
+func (m *scriptedModel) Generate(_ context.Context, call fantasy.Call) (*fantasy.Response, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.prompts = append(m.prompts, call.Prompt)
+	return &fantasy.Response{
+		Content:      fantasy.ResponseContent{fantasy.TextContent{Text: "Done."}},
+		FinishReason: fantasy.FinishReasonStop,
+	}, nil
+}
+
+func (m *scriptedModel) GenerateObject(context.Context, fantasy.ObjectCall) (*fantasy.ObjectResponse, error) {
+	return nil, errors.New("not supported")
+}
+
+func (m *scriptedModel) StreamObject(context.Context, fantasy.ObjectCall) (fantasy.ObjectStreamResponse, error) {
+	return nil, errors.New("not supported")
+}
+
+func (m *scriptedModel) Provider() string { return "scripted" }
+func (m *scriptedModel) Model() string    { return "scripted" }
+
+// sent returns everything the model was sent, as JSON.
+func (m *scriptedModel) sent(t *testing.T) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	b, err := json.Marshal(m.prompts)
+	require.NoError(t, err)
+	return string(b)
+}
+
+// toolCallStep is a step calling a tool.
+func toolCallStep(id, name, input string) []fantasy.StreamPart {
+	return []fantasy.StreamPart{
+		{Type: fantasy.StreamPartTypeToolInputStart, ID: id, ToolCallName: name},
+		{Type: fantasy.StreamPartTypeToolInputDelta, ID: id, Delta: input},
+		{Type: fantasy.StreamPartTypeToolInputEnd, ID: id},
+		{Type: fantasy.StreamPartTypeToolCall, ID: id, ToolCallName: name, ToolCallInput: input},
+		{Type: fantasy.StreamPartTypeFinish, FinishReason: fantasy.FinishReasonToolCalls},
+	}
+}
+
+// textStep is a step answering with text, ending the turn.
+func textStep(text string) []fantasy.StreamPart {
+	return []fantasy.StreamPart{
+		{Type: fantasy.StreamPartTypeTextStart, ID: "text"},
+		{Type: fantasy.StreamPartTypeTextDelta, ID: "text", Delta: text},
+		{Type: fantasy.StreamPartTypeTextEnd, ID: "text"},
+		{Type: fantasy.StreamPartTypeFinish, FinishReason: fantasy.FinishReasonStop},
+	}
+}
+
 // createSimpleGoProject creates a simple Go project structure in the given directory.
 // It creates a go.mod file and a main.go file with a basic hello world program.
 func createSimpleGoProject(t *testing.T, dir string) {
@@ -12,23 +12,39 @@ import (
 	"maps"
 	"net/http"
 	"os"
+	"path/filepath"
 	"slices"
 	"strings"
+	"sync"
+	"time"
 
 	"charm.land/fantasy"
 	"github.com/charmbracelet/catwalk/pkg/catwalk"
+	"github.com/charmbracelet/crush/internal/agent/chattemplate"
 	"github.com/charmbracelet/crush/internal/agent/hyper"
 	"github.com/charmbracelet/crush/internal/agent/prompt"
+	"github.com/charmbracelet/crush/internal/agent/toolgrammar"
 	"github.com/charmbracelet/crush/internal/agent/tools"
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/fileupload"
 	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/imagefit"
+	"github.com/charmbracelet/crush/internal/instructions"
+	"github.com/charmbracelet/crush/internal/keyring"
 	"github.com/charmbracelet/crush/internal/log"
 	"github.com/charmbracelet/crush/internal/lsp"
+	"github.com/charmbracelet/crush/internal/memory"
 	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/netstatus"
 	"github.com/charmbracelet/crush/internal/oauth/copilot"
 	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/ratelimit"
+	"github.com/charmbracelet/crush/internal/redact"
+	"github.com/charmbracelet/crush/internal/semantic"
 	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/crush/internal/shell"
+	"github.com/charmbracelet/crush/internal/symbols"
 	"golang.org/x/sync/errgroup"
 
 	"charm.land/fantasy/providers/anthropic"
@@ -42,6 +58,9 @@ import (
 	"github.com/qjebbs/go-jsons"
 )
 
+// defaultRepoMapTokens is the size of the repo map when it isn't configured.
+const defaultRepoMapTokens = 1024
+
 type Coordinator interface {
 	// INFO: (kujtim) this is not used yet we will use this when we have multiple agents
 	// SetMainAgent(string)
@@ -53,9 +72,21 @@ type Coordinator interface {
 	QueuedPrompts(sessionID string) int
 	QueuedPromptsList(sessionID string) []string
 	ClearQueue(sessionID string)
+	// RunQueue returns the runs in progress, and the runs waiting for a
+	// slot in the order they start in.
+	RunQueue() (running, waiting []QueuedRun)
+	// MoveQueuedRun moves a waiting run by delta places, towards the front
+	// of the queue when negative.
+	MoveQueuedRun(id, delta int)
+	CancelQueuedRun(id int)
 	Summarize(context.Context, string) error
+	Compact(context.Context, string) (int64, error)
 	Model() Model
 	UpdateModels(ctx context.Context) error
+	// ReloadInstructions builds the system prompt again, so changes to the
+	// context files apply from the next prompt.
+	ReloadInstructions(ctx context.Context) error
+	DismissPreCommit(sessionID string)
 }
 
 type coordinator struct {
@@ -65,11 +96,30 @@ type coordinator struct {
 	permissions permission.Service
 	history     history.Service
 	lspClients  *csync.Map[string, *lsp.Client]
+	memories    *memory.Store
 
 	currentAgent SessionAgent
 	agents       map[string]SessionAgent
+	// coderPrompt is the prompt the system prompt of the current agent is
+	// built from.
+	coderPrompt *prompt.Prompt
+
+	preCommitGates *csync.Map[string, context.CancelFunc]
+	// hookOutputs holds the output the after_turn hooks inject in the next
+	// prompt of a session.
+	hookOutputs *csync.Map[string, string]
+	// includedInstructions holds the instruction files of subdirectories
+	// included in the results of tools, by session and path.
+	includedInstructions *csync.Map[string, string]
+	runQueue             *runQueue
+	redactor             *redact.Redactor
+	semanticIndex        *semantic.Index
 
 	readyWg errgroup.Group
+
+	// subAgentCostMu serializes adding the cost of sub-agents to their
+	// parent session.
+	subAgentCostMu sync.Mutex
 }
 
 func NewCoordinator(
@@ -80,6 +130,7 @@ func NewCoordinator(
 	permissions permission.Service,
 	history history.Service,
 	lspClients *csync.Map[string, *lsp.Client],
+	memories *memory.Store,
 ) (Coordinator, error) {
 	c := &coordinator{
 		cfg:         cfg,
@@ -89,6 +140,32 @@ func NewCoordinator(
 		history:     history,
 		lspClients:  lspClients,
 		agents:      make(map[string]SessionAgent),
+
+		preCommitGates:       csync.NewMap[string, context.CancelFunc](),
+		hookOutputs:          csync.NewMap[string, string](),
+		includedInstructions: csync.NewMap[string, string](),
+		runQueue:             newRunQueue(cfg.Options.MaxConcurrentRuns),
+	}
+
+	redactor, err := cfg.Options.SecretScanning.Redactor()
+	if err != nil {
+		return nil, fmt.Errorf("invalid secret scanning configuration: %w", err)
+	}
+	c.redactor = redactor
+
+	if cfg.Tools.SemanticSearch.Enabled {
+		index, err := c.newSemanticIndex()
+		if err != nil {
+			return nil, fmt.Errorf("invalid semantic search configuration: %w", err)
+		}
+		c.semanticIndex = index
+		// Index in the background so that the first search doesn't wait
+		// for the whole workspace to be embedded.
+		go func() {
+			if err := index.Update(ctx); err != nil && !errors.Is(err, context.Canceled) {
+				slog.Warn("Failed to index the workspace for semantic search", "error", err)
+			}
+		}()
 	}
 
 	agentCfg, ok := cfg.Agents[config.AgentCoder]
@@ -107,6 +184,7 @@ func NewCoordinator(
 		return nil, err
 	}
 	c.currentAgent = agent
+	c.coderPrompt = prompt
 	c.agents[config.AgentCoder] = agent
 	return c, nil
 }
@@ -116,6 +194,23 @@ func (c *coordinator) Run(ctx context.Context, sessionID string, prompt string,
 	if err := c.readyWg.Wait(); err != nil {
 		return nil, err
 	}
+	if err := checkAttachments(c.cfg.PrivacyZones(), attachments); err != nil {
+		return nil, err
+	}
+
+	// A prompt to a busy session joins the prompts queued in the session
+	// rather than taking a slot of its own.
+	if !c.currentAgent.IsSessionBusy(sessionID) {
+		var title string
+		if sess, err := c.sessions.Get(ctx, sessionID); err == nil {
+			title = sess.Title
+		}
+		release, err := c.runQueue.acquire(ctx, sessionID, title, prompt)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+	}
 
 	model := c.currentAgent.Model()
 	maxTokens := model.CatwalkCfg.DefaultMaxTokens
@@ -139,6 +234,18 @@ func (c *coordinator) Run(ctx context.Context, sessionID string, prompt string,
 		return nil, errors.New("model provider not configured")
 	}
 
+	attachments = fitImages(attachments, imagefit.LimitsFor(providerCfg.ID, providerCfg.Type))
+
+	hookAttachments, err := c.sessionHooks(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	attachments = append(attachments, hookAttachments...)
+	attachments = append(attachments, c.recallMemories(ctx, sessionID, prompt)...)
+
+	baseURL, _ := c.cfg.Resolve(providerCfg.BaseURL)
+	isRemote := !config.IsLocalEndpoint(baseURL)
+
 	mergedOptions, temp, topP, topK, freqPenalty, presPenalty := mergeCallOptions(model, providerCfg)
 
 	if providerCfg.OAuthToken != nil && providerCfg.OAuthToken.IsExpired() {
@@ -148,22 +255,38 @@ func (c *coordinator) Run(ctx context.Context, sessionID string, prompt string,
 		}
 	}
 
+	call := SessionAgentCall{
+		SessionID:        sessionID,
+		Prompt:           prompt,
+		Attachments:      attachments,
+		MaxOutputTokens:  maxTokens,
+		ProviderOptions:  mergedOptions,
+		Temperature:      temp,
+		TopP:             topP,
+		TopK:             topK,
+		FrequencyPenalty: freqPenalty,
+		PresencePenalty:  presPenalty,
+	}
+	turnStart := time.Now()
 	run := func() (*fantasy.AgentResult, error) {
-		return c.currentAgent.Run(ctx, SessionAgentCall{
-			SessionID:        sessionID,
-			Prompt:           prompt,
-			Attachments:      attachments,
-			MaxOutputTokens:  maxTokens,
-			ProviderOptions:  mergedOptions,
-			Temperature:      temp,
-			TopP:             topP,
-			TopK:             topK,
-			FrequencyPenalty: freqPenalty,
-			PresencePenalty:  presPenalty,
-		})
+		result, err := c.currentAgent.Run(ctx, call)
+		if err == nil && result != nil {
+			c.gatePreCommit(ctx, call, turnStart)
+			c.afterTurnHooks(ctx, call)
+			c.writeSessionNotes(ctx, call, turnStart)
+		}
+		return result, err
 	}
 	result, originalErr := run()
 
+	if isRemote {
+		if originalErr == nil {
+			netstatus.Reachable()
+		} else if netstatus.Unreachable(baseURL, originalErr) {
+			return result, netstatus.OfflineError(cmp.Or(providerCfg.Name, providerCfg.ID), originalErr)
+		}
+	}
+
 	if c.isUnauthorized(originalErr) {
 		switch {
 		case providerCfg.OAuthToken != nil:
@@ -328,17 +451,25 @@ func (c *coordinator) buildAgent(ctx context.Context, prompt *prompt.Prompt, age
 	}
 
 	largeProviderCfg, _ := c.cfg.Providers.Get(large.ModelCfg.Provider)
+	var turnLimits config.TurnLimits
+	if c.cfg.Options.TurnLimits != nil {
+		turnLimits = *c.cfg.Options.TurnLimits
+	}
 	result := NewSessionAgent(SessionAgentOptions{
-		large,
-		small,
-		largeProviderCfg.SystemPromptPrefix,
-		systemPrompt,
-		isSubAgent,
-		c.cfg.Options.DisableAutoSummarize,
-		c.permissions.SkipRequests(),
-		c.sessions,
-		c.messages,
-		nil,
+		LargeModel:           large,
+		SmallModel:           small,
+		SystemPromptPrefix:   largeProviderCfg.SystemPromptPrefix,
+		SystemPrompt:         systemPrompt,
+		IsSubAgent:           isSubAgent,
+		DisableAutoSummarize: c.cfg.Options.DisableAutoSummarize,
+		IsYolo:               c.permissions.SkipRequests(),
+		Sessions:             c.sessions,
+		Messages:             c.messages,
+		Redactor:             c.redactor,
+		ToolUse:              agent.ToolUse,
+		TurnLimits:           turnLimits,
+		RepoMap:              c.repoMapFunc(),
+		CurrentTime:          c.currentTimeFunc(),
 	})
 	c.readyWg.Go(func() error {
 		tools, err := c.buildTools(ctx, agent)
@@ -378,25 +509,61 @@ func (c *coordinator) buildTools(ctx context.Context, agent config.Agent) ([]fan
 		}
 	}
 
+	shellType, err := shell.ParseShellType(c.cfg.Options.Shell)
+	if err != nil {
+		slog.Warn("Invalid shell option, using POSIX emulation", "error", err)
+	}
+
+	zones := c.cfg.PrivacyZones()
 	allTools = append(allTools,
-		tools.NewBashTool(c.permissions, c.cfg.WorkingDir(), c.cfg.Options.Attribution, modelName),
+		tools.NewBashTool(c.permissions, zones, c.cfg.WorkingDir(), c.cfg.Options.Attribution, modelName, shellType, c.cfg.Tools.Limits[tools.BashToolName], c.cfg.Options.ShellProfiles),
 		tools.NewJobOutputTool(),
 		tools.NewJobKillTool(),
+		tools.NewInteractiveTool(c.permissions, zones, c.cfg.WorkingDir(), c.cfg.Tools.Limits[tools.BashToolName], c.cfg.Options.ShellProfiles),
 		tools.NewDownloadTool(c.permissions, c.cfg.WorkingDir(), nil),
-		tools.NewEditTool(c.lspClients, c.permissions, c.history, c.cfg.WorkingDir()),
-		tools.NewMultiEditTool(c.lspClients, c.permissions, c.history, c.cfg.WorkingDir()),
+		tools.NewEditTool(c.lspClients, c.permissions, c.history, zones, c.cfg.WorkingDir()),
+		tools.NewMultiEditTool(c.lspClients, c.permissions, c.history, zones, c.cfg.WorkingDir()),
 		tools.NewFetchTool(c.permissions, c.cfg.WorkingDir(), nil),
-		tools.NewGlobTool(c.cfg.WorkingDir()),
-		tools.NewGrepTool(c.cfg.WorkingDir()),
-		tools.NewLsTool(c.permissions, c.cfg.WorkingDir(), c.cfg.Tools.Ls),
+		tools.NewGlobTool(c.cfg.WorkingDir(), zones),
+		tools.NewGrepTool(c.cfg.WorkingDir(), zones),
+		tools.NewLsTool(c.permissions, zones, c.cfg.WorkingDir(), c.cfg.Tools.Ls),
 		tools.NewSourcegraphTool(nil),
+		tools.NewSymbolsTool(symbols.Default(), zones),
 		tools.NewTodosTool(c.sessions),
-		tools.NewViewTool(c.lspClients, c.permissions, c.cfg.WorkingDir(), c.cfg.Options.SkillsPaths...),
-		tools.NewWriteTool(c.lspClients, c.permissions, c.history, c.cfg.WorkingDir()),
+		tools.NewViewTool(c.lspClients, c.permissions, zones, c.cfg.WorkingDir(), c.cfg.Options.SkillsPaths...),
+		tools.NewWriteTool(c.lspClients, c.permissions, c.history, zones, c.cfg.WorkingDir()),
+		tools.NewScreenshotTool(c.permissions, c.cfg.WorkingDir()),
 	)
 
 	if len(c.cfg.LSP) > 0 {
-		allTools = append(allTools, tools.NewDiagnosticsTool(c.lspClients), tools.NewReferencesTool(c.lspClients))
+		allTools = append(allTools,
+			tools.NewDiagnosticsTool(c.lspClients),
+			tools.NewReferencesTool(c.lspClients),
+			tools.NewRenameTool(c.lspClients, c.permissions, c.history, c.cfg.WorkingDir()),
+			tools.NewCodeActionTool(c.lspClients, c.permissions, c.history, c.cfg.WorkingDir()),
+		)
+	}
+
+	if c.semanticIndex != nil {
+		allTools = append(allTools, tools.NewSemanticSearchTool(c.semanticIndex))
+	}
+
+	if c.cfg.Options.Memory.IsEnabled() && c.memories != nil {
+		allTools = append(allTools, tools.NewMemoryTool(c.memories))
+	}
+
+	// The ops tools reach real infrastructure, so they're opt-in.
+	if c.cfg.Tools.Kubectl.Enabled {
+		allTools = append(allTools, tools.NewKubectlTool(c.permissions, c.cfg.WorkingDir(), c.cfg.Tools.Kubectl))
+	}
+	if c.cfg.Tools.Terraform.Enabled {
+		allTools = append(allTools, tools.NewTerraformTool(c.permissions, c.cfg.WorkingDir()))
+	}
+	if len(c.cfg.Tools.SQL) > 0 {
+		allTools = append(allTools, tools.NewSQLTool(c.permissions, c.cfg.WorkingDir(), c.cfg.Tools.SQL))
+	}
+	if c.cfg.Tools.HTTP.Enabled {
+		allTools = append(allTools, tools.NewHTTPTool(c.permissions, c.cfg.WorkingDir(), c.cfg.Tools.HTTP, c.cfg.IsLocalOnly(), nil))
 	}
 
 	var filteredTools []fantasy.AgentTool
@@ -434,12 +601,106 @@ func (c *coordinator) buildTools(ctx context.Context, agent config.Agent) ([]fan
 		}
 		slog.Debug("MCP not allowed", "tool", tool.Name(), "agent", agent.Name)
 	}
+
+	// The tools of OpenAPI services are named after their operations, so
+	// they're allowed as a whole.
+	if len(c.cfg.Tools.OpenAPI) > 0 && slices.Contains(agent.AllowedTools, tools.OpenAPIToolName) {
+		for _, tool := range tools.GetOpenAPITools(ctx, c.permissions, c.cfg.WorkingDir(), c.cfg.Tools.OpenAPI, c.cfg.IsLocalOnly()) {
+			filteredTools = append(filteredTools, tool)
+		}
+	}
+	if slices.Contains(agent.AllowedTools, tools.PluginToolName) {
+		for _, tool := range tools.GetPluginTools(c.permissions, c.cfg.WorkingDir()) {
+			filteredTools = append(filteredTools, tool)
+		}
+	}
+	for i, tool := range filteredTools {
+		tool = tools.WithHooks(tool, c.cfg.Hooks, c.cfg.WorkingDir())
+		tool = tools.WithInstructions(tool, instructions.Names(c.cfg.Options.ContextPaths), c.cfg.WorkingDir(), c.includedInstructions)
+		tool = tools.WithGlossary(tool, c.cfg.Options.Glossary, c.cfg.WorkingDir())
+		filteredTools[i] = tools.WithValidation(tools.WithLimits(tool, c.cfg.Tools.Limits[tool.Info().Name]))
+	}
 	slices.SortFunc(filteredTools, func(a, b fantasy.AgentTool) int {
 		return strings.Compare(a.Info().Name, b.Info().Name)
 	})
 	return filteredTools, nil
 }
 
+// currentTimeFunc returns the function giving the current time added to
+// the system prompt, or nil when the agent isn't told the time.
+func (c *coordinator) currentTimeFunc() func() string {
+	return prompt.CurrentTime(c.cfg.Options.PromptEnv)
+}
+
+// repoMapFunc returns the function giving the map of the workspace added to
+// the system prompt, or nil when it's disabled.
+func (c *coordinator) repoMapFunc() func(context.Context) string {
+	cfg := c.cfg.Options.RepoMap
+	if cfg != nil && cfg.Disabled {
+		return nil
+	}
+	maxTokens := defaultRepoMapTokens
+	if cfg != nil && cfg.MaxTokens > 0 {
+		maxTokens = cfg.MaxTokens
+	}
+	zones := c.cfg.PrivacyZones()
+	return func(ctx context.Context) string {
+		index := symbols.Default()
+		if index == nil {
+			return ""
+		}
+		// The map matters most on the first turn, which can come before
+		// the workspace is first indexed.
+		waitCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		if !index.Wait(waitCtx) {
+			return ""
+		}
+		return index.RepoMap(maxTokens, zones.Contains)
+	}
+}
+
+// newSemanticIndex returns the embeddings index of the workspace, stored in
+// the data directory. The embeddings come from the configured provider, or
+// from the configured OpenAI-compatible API.
+func (c *coordinator) newSemanticIndex() (*semantic.Index, error) {
+	cfg := c.cfg.Tools.SemanticSearch
+	if cfg.Model == "" {
+		return nil, errors.New("model is required")
+	}
+	baseURL, apiKey := cfg.BaseURL, cfg.APIKey
+	var headers map[string]string
+	if cfg.Provider != "" {
+		providerCfg, ok := c.cfg.Providers.Get(cfg.Provider)
+		if !ok {
+			return nil, fmt.Errorf("provider %s not found", cfg.Provider)
+		}
+		baseURL, apiKey, headers = providerCfg.BaseURL, providerCfg.APIKey, providerCfg.ExtraHeaders
+	} else if apiKey != "" {
+		resolved, err := c.cfg.Resolver().ResolveValue(apiKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve api key: %w", err)
+		}
+		apiKey = resolved
+	}
+	if baseURL == "" {
+		return nil, errors.New("a provider or a base URL is required")
+	}
+	// The embeddings are of the code of the workspace, which local-only
+	// mode keeps from leaving the machine.
+	if !c.cfg.EndpointAllowed(baseURL) {
+		return nil, fmt.Errorf("local-only mode is enabled and %s is not a local endpoint", baseURL)
+	}
+
+	zones := c.cfg.PrivacyZones()
+	return semantic.NewIndex(
+		c.cfg.WorkingDir(),
+		filepath.Join(c.cfg.Options.DataDirectory, "semantic", "index.gob"),
+		semantic.NewOpenAIEmbedder(baseURL, apiKey, cfg.Model, headers),
+		zones.Contains,
+	), nil
+}
+
 // TODO: when we support multiple agents we need to change this so that we pass in the agent specific model config
 func (c *coordinator) buildAgentModels(ctx context.Context, isSubAgent bool) (Model, Model, error) {
 	largeModelCfg, ok := c.cfg.Models[config.SelectedModelTypeLarge]
@@ -466,7 +727,7 @@ func (c *coordinator) buildAgentModels(ctx context.Context, isSubAgent bool) (Mo
 		return Model{}, Model{}, errors.New("large model provider not configured")
 	}
 
-	smallProvider, err := c.buildProvider(smallProviderCfg, largeModelCfg, true)
+	smallProvider, err := c.buildProvider(smallProviderCfg, smallModelCfg, true)
 	if err != nil {
 		return Model{}, Model{}, err
 	}
@@ -508,10 +769,14 @@ func (c *coordinator) buildAgentModels(ctx context.Context, isSubAgent bool) (Mo
 	if err != nil {
 		return Model{}, Model{}, err
 	}
+	largeModel = withParams(largeModel, paramsFor(largeProviderCfg.Type, *largeCatwalkModel))
+	largeModel = withLimiter(largeModel, ratelimit.For(largeProviderCfg.ID, largeProviderCfg.MaxConcurrency, largeProviderCfg.RequestsPerMinute))
 	smallModel, err := smallProvider.LanguageModel(ctx, smallModelID)
 	if err != nil {
 		return Model{}, Model{}, err
 	}
+	smallModel = withParams(smallModel, paramsFor(smallProviderCfg.Type, *smallCatwalkModel))
+	smallModel = withLimiter(smallModel, ratelimit.For(smallProviderCfg.ID, smallProviderCfg.MaxConcurrency, smallProviderCfg.RequestsPerMinute))
 
 	return Model{
 			Model:      largeModel,
@@ -524,7 +789,7 @@ func (c *coordinator) buildAgentModels(ctx context.Context, isSubAgent bool) (Mo
 		}, nil
 }
 
-func (c *coordinator) buildAnthropicProvider(baseURL, apiKey string, headers map[string]string, isOauth bool) (fantasy.Provider, error) {
+func (c *coordinator) buildAnthropicProvider(baseURL, apiKey string, headers map[string]string, isOauth bool, httpClient *http.Client) (fantasy.Provider, error) {
 	var opts []anthropic.Option
 
 	if isOauth {
@@ -544,22 +809,42 @@ func (c *coordinator) buildAnthropicProvider(baseURL, apiKey string, headers map
 		opts = append(opts, anthropic.WithBaseURL(baseURL))
 	}
 
-	if c.cfg.Options.Debug {
-		httpClient := log.NewHTTPClient()
+	if httpClient != nil {
 		opts = append(opts, anthropic.WithHTTPClient(httpClient))
 	}
 	return anthropic.New(opts...)
 }
 
-func (c *coordinator) buildOpenaiProvider(baseURL, apiKey string, headers map[string]string) (fantasy.Provider, error) {
+func (c *coordinator) buildOpenaiProvider(baseURL, apiKey string, headers map[string]string, providerCfg config.ProviderConfig, httpClient *http.Client) (fantasy.Provider, error) {
+	developerRole := func(model string) bool {
+		m := c.cfg.GetModel(providerCfg.ID, model)
+		return m != nil && paramsFor(providerCfg.Type, *m).developerRole
+	}
 	opts := []openai.Option{
 		openai.WithAPIKey(apiKey),
 		openai.WithUseResponsesAPI(),
+		openai.WithLanguageModelOptions(
+			openai.WithLanguageModelToPromptFunc(developerRolePrompt(developerRole)),
+		),
+	}
+	// Sessions can be billed to another organization or project through
+	// their shell profile.
+	if profiles := c.cfg.Options.ShellProfiles; overridesBilling(profiles) {
+		base := http.DefaultTransport
+		if httpClient != nil {
+			base = httpClient.Transport
+		}
+		httpClient = &http.Client{Transport: &billingTransport{base: base, profiles: profiles}}
 	}
-	if c.cfg.Options.Debug {
-		httpClient := log.NewHTTPClient()
+	if httpClient != nil {
 		opts = append(opts, openai.WithHTTPClient(httpClient))
 	}
+	if org, _ := c.cfg.Resolve(providerCfg.Organization); org != "" {
+		opts = append(opts, openai.WithOrganization(org))
+	}
+	if project, _ := c.cfg.Resolve(providerCfg.Project); project != "" {
+		opts = append(opts, openai.WithProject(project))
+	}
 	if len(headers) > 0 {
 		opts = append(opts, openai.WithHeaders(headers))
 	}
@@ -569,12 +854,11 @@ func (c *coordinator) buildOpenaiProvider(baseURL, apiKey string, headers map[st
 	return openai.New(opts...)
 }
 
-func (c *coordinator) buildOpenrouterProvider(_, apiKey string, headers map[string]string) (fantasy.Provider, error) {
+func (c *coordinator) buildOpenrouterProvider(_, apiKey string, headers map[string]string, httpClient *http.Client) (fantasy.Provider, error) {
 	opts := []openrouter.Option{
 		openrouter.WithAPIKey(apiKey),
 	}
-	if c.cfg.Options.Debug {
-		httpClient := log.NewHTTPClient()
+	if httpClient != nil {
 		opts = append(opts, openrouter.WithHTTPClient(httpClient))
 	}
 	if len(headers) > 0 {
@@ -583,19 +867,34 @@ func (c *coordinator) buildOpenrouterProvider(_, apiKey string, headers map[stri
 	return openrouter.New(opts...)
 }
 
-func (c *coordinator) buildOpenaiCompatProvider(baseURL, apiKey string, headers map[string]string, extraBody map[string]any, providerID string, isSubAgent bool) (fantasy.Provider, error) {
+func (c *coordinator) buildOpenaiCompatProvider(baseURL, apiKey string, headers map[string]string, extraBody map[string]any, providerID string, toolGrammar bool, chatTemplate config.ChatTemplate, isSubAgent bool, httpClient *http.Client) (fantasy.Provider, error) {
 	opts := []openaicompat.Option{
 		openaicompat.WithBaseURL(baseURL),
 		openaicompat.WithAPIKey(apiKey),
 	}
 
-	// Set HTTP client based on provider and debug mode.
-	var httpClient *http.Client
+	// Copilot needs a client of its own.
 	if providerID == string(catwalk.InferenceProviderCopilot) {
 		opts = append(opts, openaicompat.WithUseResponsesAPI())
 		httpClient = copilot.NewClient(isSubAgent, c.cfg.Options.Debug)
-	} else if c.cfg.Options.Debug {
-		httpClient = log.NewHTTPClient()
+	}
+	if !chatTemplate.IsZero() {
+		var base http.RoundTripper
+		if httpClient != nil {
+			base = httpClient.Transport
+		}
+		var err error
+		httpClient, err = chattemplate.NewClient(base, chatTemplate)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if toolGrammar {
+		var base http.RoundTripper
+		if httpClient != nil {
+			base = httpClient.Transport
+		}
+		httpClient = toolgrammar.NewClient(base)
 	}
 	if httpClient != nil {
 		opts = append(opts, openaicompat.WithHTTPClient(httpClient))
@@ -612,14 +911,13 @@ func (c *coordinator) buildOpenaiCompatProvider(baseURL, apiKey string, headers
 	return openaicompat.New(opts...)
 }
 
-func (c *coordinator) buildAzureProvider(baseURL, apiKey string, headers map[string]string, options map[string]string) (fantasy.Provider, error) {
+func (c *coordinator) buildAzureProvider(baseURL, apiKey string, headers map[string]string, options map[string]string, httpClient *http.Client) (fantasy.Provider, error) {
 	opts := []azure.Option{
 		azure.WithBaseURL(baseURL),
 		azure.WithAPIKey(apiKey),
 		azure.WithUseResponsesAPI(),
 	}
-	if c.cfg.Options.Debug {
-		httpClient := log.NewHTTPClient()
+	if httpClient != nil {
 		opts = append(opts, azure.WithHTTPClient(httpClient))
 	}
 	if options == nil {
@@ -651,13 +949,12 @@ func (c *coordinator) buildBedrockProvider(headers map[string]string) (fantasy.P
 	return bedrock.New(opts...)
 }
 
-func (c *coordinator) buildGoogleProvider(baseURL, apiKey string, headers map[string]string) (fantasy.Provider, error) {
+func (c *coordinator) buildGoogleProvider(baseURL, apiKey string, headers map[string]string, httpClient *http.Client) (fantasy.Provider, error) {
 	opts := []google.Option{
 		google.WithBaseURL(baseURL),
 		google.WithGeminiAPIKey(apiKey),
 	}
-	if c.cfg.Options.Debug {
-		httpClient := log.NewHTTPClient()
+	if httpClient != nil {
 		opts = append(opts, google.WithHTTPClient(httpClient))
 	}
 	if len(headers) > 0 {
@@ -720,6 +1017,12 @@ func (c *coordinator) buildProvider(providerCfg config.ProviderConfig, model con
 	if headers == nil {
 		headers = make(map[string]string)
 	}
+	// Header values can reference environment variables, like API keys.
+	for name, value := range headers {
+		if resolved, err := c.cfg.Resolve(value); err == nil {
+			headers[name] = resolved
+		}
+	}
 
 	// handle special headers for anthropic
 	if providerCfg.Type == anthropic.Name && c.isAnthropicThinking(model) {
@@ -732,20 +1035,24 @@ func (c *coordinator) buildProvider(providerCfg config.ProviderConfig, model con
 
 	apiKey, _ := c.cfg.Resolve(providerCfg.APIKey)
 	baseURL, _ := c.cfg.Resolve(providerCfg.BaseURL)
+	if !c.cfg.EndpointAllowed(providerCfg.BaseURL) {
+		return nil, fmt.Errorf("provider %q is not allowed in local-only mode: endpoint is not on localhost or a private network", providerCfg.ID)
+	}
 
+	httpClient := c.newHTTPClient(providerCfg, apiKey)
 	switch providerCfg.Type {
 	case openai.Name:
-		return c.buildOpenaiProvider(baseURL, apiKey, headers)
+		return c.buildOpenaiProvider(baseURL, apiKey, headers, providerCfg, httpClient)
 	case anthropic.Name:
-		return c.buildAnthropicProvider(baseURL, apiKey, headers, providerCfg.OAuthToken != nil)
+		return c.buildAnthropicProvider(baseURL, apiKey, headers, providerCfg.OAuthToken != nil, httpClient)
 	case openrouter.Name:
-		return c.buildOpenrouterProvider(baseURL, apiKey, headers)
+		return c.buildOpenrouterProvider(baseURL, apiKey, headers, httpClient)
 	case azure.Name:
-		return c.buildAzureProvider(baseURL, apiKey, headers, providerCfg.ExtraParams)
+		return c.buildAzureProvider(baseURL, apiKey, headers, providerCfg.ExtraParams, httpClient)
 	case bedrock.Name:
 		return c.buildBedrockProvider(headers)
 	case google.Name:
-		return c.buildGoogleProvider(baseURL, apiKey, headers)
+		return c.buildGoogleProvider(baseURL, apiKey, headers, httpClient)
 	case "google-vertex":
 		return c.buildGoogleVertexProvider(headers, providerCfg.ExtraParams)
 	case openaicompat.Name:
@@ -755,7 +1062,7 @@ func (c *coordinator) buildProvider(providerCfg config.ProviderConfig, model con
 			}
 			providerCfg.ExtraBody["tool_stream"] = true
 		}
-		return c.buildOpenaiCompatProvider(baseURL, apiKey, headers, providerCfg.ExtraBody, providerCfg.ID, isSubAgent)
+		return c.buildOpenaiCompatProvider(baseURL, apiKey, headers, providerCfg.ExtraBody, providerCfg.ID, providerCfg.ToolGrammar, providerCfg.ChatTemplates[model.Model], isSubAgent, httpClient)
 	case hyper.Name:
 		return c.buildHyperProvider(baseURL, apiKey)
 	default:
@@ -763,6 +1070,43 @@ func (c *coordinator) buildProvider(providerCfg config.ProviderConfig, model con
 	}
 }
 
+// newHTTPClient returns the client requests to the provider are sent with,
+// logging them in debug mode, uploading large attachments when configured
+// and rotating from apiKey through the other API keys of the provider, or nil
+// for the default one.
+func (c *coordinator) newHTTPClient(providerCfg config.ProviderConfig, apiKey string) *http.Client {
+	var httpClient *http.Client
+	if c.cfg.Options.Debug {
+		httpClient = log.NewHTTPClient()
+	}
+	// Uploads happen below the keyring, with the key of the request
+	// referencing them.
+	if api, ok := fileupload.For(providerCfg.Type); ok && providerCfg.UploadFiles {
+		var base http.RoundTripper
+		if httpClient != nil {
+			base = httpClient.Transport
+		}
+		httpClient = fileupload.NewClient(base, api, tools.GetSessionFromContext)
+	}
+	var keys []string
+	if apiKey != "" {
+		keys = append(keys, apiKey)
+		for _, tmpl := range providerCfg.APIKeys {
+			if key, err := c.cfg.Resolve(tmpl); err == nil && key != "" && !slices.Contains(keys, key) {
+				keys = append(keys, key)
+			}
+		}
+	}
+	if ring := keyring.For(providerCfg.ID, keys); ring != nil {
+		var base http.RoundTripper
+		if httpClient != nil {
+			base = httpClient.Transport
+		}
+		httpClient = keyring.NewClient(base, ring)
+	}
+	return httpClient
+}
+
 func isExactoSupported(modelID string) bool {
 	supportedModels := []string{
 		"moonshotai/kimi-k2-0905",
@@ -775,22 +1119,46 @@ func isExactoSupported(modelID string) bool {
 }
 
 func (c *coordinator) Cancel(sessionID string) {
+	if cancel, ok := c.preCommitGates.Take(sessionID); ok {
+		cancel()
+	}
+	c.runQueue.cancelSession(sessionID)
 	c.currentAgent.Cancel(sessionID)
 }
 
 func (c *coordinator) CancelAll() {
+	c.runQueue.cancelAll()
 	c.currentAgent.CancelAll()
 }
 
+func (c *coordinator) RunQueue() (running, waiting []QueuedRun) {
+	return c.runQueue.runs()
+}
+
+func (c *coordinator) MoveQueuedRun(id, delta int) {
+	c.runQueue.move(id, delta)
+}
+
+func (c *coordinator) CancelQueuedRun(id int) {
+	c.runQueue.cancel(id, context.Canceled)
+}
+
 func (c *coordinator) ClearQueue(sessionID string) {
 	c.currentAgent.ClearQueue(sessionID)
 }
 
 func (c *coordinator) IsBusy() bool {
-	return c.currentAgent.IsBusy()
+	_, waiting := c.runQueue.runs()
+	return c.currentAgent.IsBusy() || c.preCommitGates.Len() > 0 || len(waiting) > 0
 }
 
 func (c *coordinator) IsSessionBusy(sessionID string) bool {
+	if _, gating := c.preCommitGates.Get(sessionID); gating {
+		return true
+	}
+	if c.runQueue.isWaiting(sessionID) {
+		return true
+	}
 	return c.currentAgent.IsSessionBusy(sessionID)
 }
 
@@ -819,6 +1187,17 @@ func (c *coordinator) UpdateModels(ctx context.Context) error {
 	return nil
 }
 
+// ReloadInstructions implements Coordinator.
+func (c *coordinator) ReloadInstructions(ctx context.Context) error {
+	model := c.currentAgent.Model()
+	systemPrompt, err := c.coderPrompt.Build(ctx, model.Model.Provider(), model.Model.Model(), *c.cfg)
+	if err != nil {
+		return err
+	}
+	c.currentAgent.SetSystemPrompt(systemPrompt)
+	return nil
+}
+
 func (c *coordinator) QueuedPrompts(sessionID string) int {
 	return c.currentAgent.QueuedPrompts(sessionID)
 }
@@ -835,6 +1214,15 @@ func (c *coordinator) Summarize(ctx context.Context, sessionID string) error {
 	return c.currentAgent.Summarize(ctx, sessionID, getProviderOptions(c.currentAgent.Model(), providerCfg))
 }
 
+func (c *coordinator) Compact(ctx context.Context, sessionID string) (int64, error) {
+	small := c.currentAgent.SmallModel()
+	providerCfg, ok := c.cfg.Providers.Get(small.ModelCfg.Provider)
+	if !ok {
+		return 0, errors.New("model provider not configured")
+	}
+	return c.currentAgent.Compact(ctx, sessionID, getProviderOptions(small, providerCfg))
+}
+
 func (c *coordinator) isUnauthorized(err error) bool {
 	var providerErr *fantasy.ProviderError
 	return errors.As(err, &providerErr) && providerErr.StatusCode == http.StatusUnauthorized
@@ -5,6 +5,7 @@ import (
 
 	"charm.land/fantasy"
 	"github.com/charmbracelet/crush/internal/event"
+	"github.com/charmbracelet/crush/internal/stats"
 )
 
 func (a sessionAgent) eventPromptSent(sessionID string) {
@@ -35,6 +36,7 @@ func (a sessionAgent) eventTokensUsed(sessionID string, model Model, usage fanta
 			"cost", cost,
 		)...,
 	)
+	stats.TokensUsed(model.ModelCfg.Provider, model.ModelCfg.Model, usage.InputTokens, usage.OutputTokens)
 }
 
 func (a sessionAgent) eventCommon(sessionID string, model Model) []any {
@@ -0,0 +1,47 @@
+package agent
+
+import (
+	"slices"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent/tools"
+)
+
+// explainModeToolNames are the tools the agent keeps in explain mode: the
+// ones that only read the workspace or the web. MCP tools are left out, as
+// there's no telling what they change.
+var explainModeToolNames = []string{
+	AgentToolName,
+	tools.AgenticFetchToolName,
+	tools.DiagnosticsToolName,
+	tools.FetchToolName,
+	tools.GlobToolName,
+	tools.GrepToolName,
+	tools.LSToolName,
+	tools.ReferencesToolName,
+	tools.SemanticSearchToolName,
+	tools.SourcegraphToolName,
+	tools.SymbolsToolName,
+	tools.TodosToolName,
+	tools.ViewToolName,
+	tools.WebFetchToolName,
+	tools.WebSearchToolName,
+}
+
+const explainModePrompt = `
+<explain_mode>
+The user turned on explain mode for this session: only analyze and explain the code. Don't change files, run commands or touch any system, and don't offer to. The tools that could are disabled. When asked for a change, describe what you would change and why instead.
+</explain_mode>
+`
+
+// explainModeTools returns the tools of agentTools that don't change
+// anything.
+func explainModeTools(agentTools []fantasy.AgentTool) []fantasy.AgentTool {
+	var readOnly []fantasy.AgentTool
+	for _, tool := range agentTools {
+		if slices.Contains(explainModeToolNames, tool.Info().Name) {
+			readOnly = append(readOnly, tool)
+		}
+	}
+	return readOnly
+}
@@ -0,0 +1,31 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplainModeTools(t *testing.T) {
+	t.Parallel()
+
+	type params struct{}
+	tool := func(name string) fantasy.AgentTool {
+		return fantasy.NewAgentTool(name, "", func(context.Context, params, fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			return fantasy.NewTextResponse(""), nil
+		})
+	}
+	var agentTools []fantasy.AgentTool
+	for _, name := range []string{tools.ViewToolName, tools.BashToolName, tools.GrepToolName, tools.EditToolName, "mcp_github_create_issue"} {
+		agentTools = append(agentTools, tool(name))
+	}
+
+	var names []string
+	for _, tool := range explainModeTools(agentTools) {
+		names = append(names, tool.Info().Name)
+	}
+	require.Equal(t, []string{tools.ViewToolName, tools.GrepToolName}, names)
+}
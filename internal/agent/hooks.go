@@ -0,0 +1,90 @@
+package agent
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/charmbracelet/crush/internal/hooks"
+	"github.com/charmbracelet/crush/internal/message"
+)
+
+// maxAfterTurnAttempts is how many times the agent is asked to address
+// failing after_turn hooks before the turn ends anyway.
+const maxAfterTurnAttempts = 3
+
+// sessionHooks runs the session_start hooks before the first prompt of a
+// session, and returns what the hooks of the session inject in the prompt:
+// the output of the session_start hooks, and the one the after_turn hooks
+// left for the next prompt. A failing session_start hook keeps the prompt
+// from being sent.
+func (c *coordinator) sessionHooks(ctx context.Context, sessionID string) ([]message.Attachment, error) {
+	var attachments []message.Attachment
+	if len(c.cfg.Hooks.SessionStart) > 0 {
+		sess, err := c.sessions.Get(ctx, sessionID)
+		if err != nil {
+			return nil, err
+		}
+		if sess.MessageCount == 0 {
+			outcome := hooks.Run(ctx, hooks.SessionStart, c.cfg.Hooks.SessionStart, cmp.Or(sess.WorkingDir, c.cfg.WorkingDir()), map[string]string{
+				"CRUSH_SESSION_ID": sessionID,
+			})
+			if outcome.Blocked {
+				return nil, errors.New(outcome.Reason(hooks.SessionStart))
+			}
+			if outcome.Output != "" {
+				attachments = append(attachments, hookAttachment(hooks.SessionStart, outcome.Output))
+			}
+		}
+	}
+	if output, ok := c.hookOutputs.Take(sessionID); ok {
+		attachments = append(attachments, hookAttachment(hooks.AfterTurn, output))
+	}
+	return attachments, nil
+}
+
+// afterTurnHooks runs the after_turn hooks once the agent ends its turn.
+// While they fail the agent is asked to address their output, until they
+// pass or the attempts run out. The output they inject is kept for the next
+// prompt of the session.
+func (c *coordinator) afterTurnHooks(ctx context.Context, call SessionAgentCall) {
+	if len(c.cfg.Hooks.AfterTurn) == 0 {
+		return
+	}
+	dir := c.cfg.WorkingDir()
+	if sess, err := c.sessions.Get(ctx, call.SessionID); err == nil {
+		dir = cmp.Or(sess.WorkingDir, c.cfg.WorkingDir())
+	}
+	for attempt := 0; ; attempt++ {
+		outcome := hooks.Run(ctx, hooks.AfterTurn, c.cfg.Hooks.AfterTurn, dir, map[string]string{
+			"CRUSH_SESSION_ID": call.SessionID,
+		})
+		if !outcome.Blocked {
+			if outcome.Output != "" {
+				c.hookOutputs.Set(call.SessionID, outcome.Output)
+			}
+			return
+		}
+		if attempt+1 >= maxAfterTurnAttempts || ctx.Err() != nil {
+			slog.Warn("After turn hook still fails", "hook", outcome.Hook, "attempts", attempt+1)
+			return
+		}
+
+		fixCall := call
+		fixCall.Attachments = nil
+		fixCall.Prompt = outcome.Reason(hooks.AfterTurn) + "\n\nAddress this before ending your turn."
+		if _, err := c.currentAgent.Run(ctx, fixCall); err != nil {
+			slog.Warn("Agent failed to address the after turn hook", "error", err)
+			return
+		}
+	}
+}
+
+func hookAttachment(event hooks.Event, output string) message.Attachment {
+	return message.Attachment{
+		FileName: string(event) + " hook",
+		MimeType: "text/plain",
+		Content:  []byte(output),
+	}
+}
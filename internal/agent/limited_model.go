@@ -0,0 +1,73 @@
+package agent
+
+import (
+	"context"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/ratelimit"
+)
+
+// limitedModel queues calls to the wrapped model so they respect the
+// provider's concurrency and requests-per-minute limits. Streaming calls
+// hold their slot until the stream has been fully consumed.
+type limitedModel struct {
+	fantasy.LanguageModel
+	limiter *ratelimit.Limiter
+}
+
+func withLimiter(model fantasy.LanguageModel, limiter *ratelimit.Limiter) fantasy.LanguageModel {
+	if limiter == nil {
+		return model
+	}
+	return &limitedModel{LanguageModel: model, limiter: limiter}
+}
+
+func (m *limitedModel) Generate(ctx context.Context, call fantasy.Call) (*fantasy.Response, error) {
+	release, err := m.limiter.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return m.LanguageModel.Generate(ctx, call)
+}
+
+func (m *limitedModel) Stream(ctx context.Context, call fantasy.Call) (fantasy.StreamResponse, error) {
+	release, err := m.limiter.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := m.LanguageModel.Stream(ctx, call)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	return func(yield func(fantasy.StreamPart) bool) {
+		defer release()
+		stream(yield)
+	}, nil
+}
+
+func (m *limitedModel) GenerateObject(ctx context.Context, call fantasy.ObjectCall) (*fantasy.ObjectResponse, error) {
+	release, err := m.limiter.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return m.LanguageModel.GenerateObject(ctx, call)
+}
+
+func (m *limitedModel) StreamObject(ctx context.Context, call fantasy.ObjectCall) (fantasy.ObjectStreamResponse, error) {
+	release, err := m.limiter.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := m.LanguageModel.StreamObject(ctx, call)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	return func(yield func(fantasy.ObjectStreamPart) bool) {
+		defer release()
+		stream(yield)
+	}, nil
+}
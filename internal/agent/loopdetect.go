@@ -0,0 +1,158 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/x/ansi"
+)
+
+const (
+	// maxRepeatedFailures is the number of times the same tool call may
+	// fail in a turn before the agent stops.
+	maxRepeatedFailures = 3
+	// maxOscillations is the number of times the model may go back and
+	// forth between two edits in a turn before the agent stops.
+	maxOscillations = 2
+)
+
+var bashExitCodeRe = regexp.MustCompile(`(?m)^Exit code [1-9][0-9]*$`)
+
+// toolFailed reports whether a tool call failed. Commands of the bash tool
+// that exit with a non-zero code don't make an error result, but fail too.
+func toolFailed(result message.ToolResult) bool {
+	return result.IsError || (result.Name == tools.BashToolName && bashExitCodeRe.MatchString(result.Content))
+}
+
+type loopCall struct {
+	id     string
+	name   string
+	input  string
+	done   bool
+	failed bool
+	output string
+}
+
+// loopDetector watches the tool calls of a turn for loops the model is
+// unlikely to get out of on its own: the same call failing again and again,
+// or edits undoing each other.
+type loopDetector struct {
+	calls []loopCall
+}
+
+// toolCalled records a tool call of the turn.
+func (d *loopDetector) toolCalled(id, name, input string) {
+	d.calls = append(d.calls, loopCall{id: id, name: name, input: canonicalInput(input)})
+}
+
+// toolResult records the result of a tool call of the turn.
+func (d *loopDetector) toolResult(id string, failed bool, output string) {
+	for i := range d.calls {
+		if d.calls[i].id == id {
+			d.calls[i].done, d.calls[i].failed, d.calls[i].output = true, failed, output
+			return
+		}
+	}
+}
+
+// detect returns a description of the loop the turn is stuck in, if any.
+func (d *loopDetector) detect() string {
+	if pattern := d.repeatedFailure(); pattern != "" {
+		return pattern
+	}
+	return d.oscillation()
+}
+
+// repeatedFailure looks for a call that failed maxRepeatedFailures times with
+// the same input.
+func (d *loopDetector) repeatedFailure() string {
+	if len(d.calls) == 0 {
+		return ""
+	}
+	last := d.calls[len(d.calls)-1]
+	if !last.done || !last.failed {
+		return ""
+	}
+	failures := 0
+	for _, c := range d.calls {
+		if c.failed && c.name == last.name && c.input == last.input {
+			failures++
+		}
+	}
+	if failures < maxRepeatedFailures {
+		return ""
+	}
+	return fmt.Sprintf(
+		"I called `%s` %d times in this turn with the same input and it failed every time, last with: %s",
+		last.name, failures, firstLine(last.output),
+	)
+}
+
+// oscillation looks for edits going back and forth between two versions of
+// a file, one undoing the other.
+func (d *loopDetector) oscillation() string {
+	var edits []loopCall
+	for _, c := range d.calls {
+		if slices.Contains([]string{tools.EditToolName, tools.MultiEditToolName, tools.WriteToolName}, c.name) {
+			edits = append(edits, c)
+		}
+	}
+	// A and B alternating, maxOscillations times each.
+	n := 2 * maxOscillations
+	if len(edits) < n {
+		return ""
+	}
+	edits = edits[len(edits)-n:]
+	if edits[0].input == edits[1].input {
+		return ""
+	}
+	for i := 2; i < n; i++ {
+		if edits[i].input != edits[i-2].input {
+			return ""
+		}
+	}
+	return fmt.Sprintf(
+		"My last %d edits went back and forth between the same two changes%s, undoing each other",
+		n, editedFile(edits[0].input),
+	)
+}
+
+// canonicalInput returns the JSON input of a tool call in a canonical form,
+// so that the same call made with its arguments in another order or spacing
+// compares equal.
+func canonicalInput(input string) string {
+	var v any
+	if err := json.Unmarshal([]byte(input), &v); err != nil {
+		return input
+	}
+	canonical, err := json.Marshal(v)
+	if err != nil {
+		return input
+	}
+	return string(canonical)
+}
+
+// editedFile returns " to <path>" for the input of a file editing tool call.
+func editedFile(input string) string {
+	var params struct {
+		FilePath string `json:"file_path"`
+	}
+	if json.Unmarshal([]byte(input), &params) != nil || params.FilePath == "" {
+		return ""
+	}
+	return fmt.Sprintf(" to `%s`", params.FilePath)
+}
+
+func firstLine(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "no output"
+	}
+	line, _, _ := strings.Cut(s, "\n")
+	return "`" + ansi.Truncate(line, 200, "…") + "`"
+}
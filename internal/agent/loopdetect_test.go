@@ -0,0 +1,82 @@
+package agent
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoopDetector(t *testing.T) {
+	t.Parallel()
+
+	t.Run("repeated failure", func(t *testing.T) {
+		t.Parallel()
+		d := &loopDetector{}
+		for i := range maxRepeatedFailures {
+			require.Empty(t, d.detect())
+			id := fmt.Sprint(i)
+			// The same input with its arguments in another order.
+			input := `{"command":"go test ./...","timeout":60}`
+			if i%2 == 1 {
+				input = `{"timeout": 60, "command": "go test ./..."}`
+			}
+			d.toolCalled(id, "bash", input)
+			d.toolResult(id, true, "--- FAIL: TestFoo\nExit code 1")
+		}
+		loop := d.detect()
+		require.Contains(t, loop, "`bash` 3 times")
+		require.Contains(t, loop, "`--- FAIL: TestFoo`")
+	})
+
+	t.Run("failures with different inputs", func(t *testing.T) {
+		t.Parallel()
+		d := &loopDetector{}
+		for i := range 2 * maxRepeatedFailures {
+			id := fmt.Sprint(i)
+			d.toolCalled(id, "view", fmt.Sprintf(`{"file_path":"%d.go"}`, i))
+			d.toolResult(id, true, "file not found")
+		}
+		require.Empty(t, d.detect())
+	})
+
+	t.Run("oscillating edits", func(t *testing.T) {
+		t.Parallel()
+		d := &loopDetector{}
+		forward := `{"file_path":"main.go","old_string":"a","new_string":"b"}`
+		backward := `{"file_path":"main.go","old_string":"b","new_string":"a"}`
+		for i := range 2 * maxOscillations {
+			require.Empty(t, d.detect())
+			id := fmt.Sprint(i)
+			input := forward
+			if i%2 == 1 {
+				input = backward
+			}
+			d.toolCalled(id, "edit", input)
+			d.toolResult(id, false, "edited")
+			d.toolCalled(id+"-view", "view", `{"file_path":"main.go"}`)
+		}
+		require.Contains(t, d.detect(), "to `main.go`")
+	})
+
+	t.Run("successive edits", func(t *testing.T) {
+		t.Parallel()
+		d := &loopDetector{}
+		for i := range 2 * maxOscillations {
+			id := fmt.Sprint(i)
+			d.toolCalled(id, "edit", fmt.Sprintf(`{"file_path":"main.go","old_string":"%d","new_string":"%d"}`, i, i+1))
+			d.toolResult(id, false, "edited")
+		}
+		require.Empty(t, d.detect())
+	})
+}
+
+func TestToolFailed(t *testing.T) {
+	t.Parallel()
+
+	require.True(t, toolFailed(message.ToolResult{Name: "view", IsError: true}))
+	require.True(t, toolFailed(message.ToolResult{Name: "bash", Content: "oops\nExit code 2"}))
+	require.False(t, toolFailed(message.ToolResult{Name: "bash", Content: "ok"}))
+	require.False(t, toolFailed(message.ToolResult{Name: "view", Content: "Exit code 2"}))
+}
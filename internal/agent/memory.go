@@ -0,0 +1,39 @@
+package agent
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/message"
+)
+
+// defaultMemoryRecall is how many memories are recalled at the start of a
+// session when options.memory.recall isn't set.
+const defaultMemoryRecall = 10
+
+// recallMemories returns the memories relevant to the first prompt of a
+// session, to include in it.
+func (c *coordinator) recallMemories(ctx context.Context, sessionID, prompt string) []message.Attachment {
+	opts := c.cfg.Options.Memory
+	if !opts.IsEnabled() || c.memories == nil {
+		return nil
+	}
+	sess, err := c.sessions.Get(ctx, sessionID)
+	if err != nil || sess.MessageCount > 0 {
+		return nil
+	}
+	memories, err := c.memories.Recall(prompt, cmpOrPositive(opts.Recall, defaultMemoryRecall))
+	if err != nil {
+		slog.Warn("Failed to recall memories", "error", err)
+		return nil
+	}
+	if len(memories) == 0 {
+		return nil
+	}
+	return []message.Attachment{{
+		FileName: "memories",
+		MimeType: "text/plain",
+		Content:  []byte("What you remember about this project:\n\n" + tools.FormatMemories(memories)),
+	}}
+}
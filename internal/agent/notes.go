@@ -0,0 +1,191 @@
+package agent
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/diff"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/message"
+)
+
+const (
+	defaultSessionNotesFile = "SESSION_NOTES.md"
+	// maxNoteLength is the most runes of the prompt and of the response a
+	// note keeps.
+	maxNoteLength = 500
+)
+
+// turnNote is what the session notes record of a turn.
+type turnNote struct {
+	Time    time.Time
+	Session string
+	Intent  string
+	Outcome string
+	Changes []fileChange
+}
+
+// fileChange is a file the agent changed during a turn.
+type fileChange struct {
+	Path      string
+	Additions int
+	Deletions int
+}
+
+// markdown renders the note as a section of the notes file.
+func (n turnNote) markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## %s · %s\n\n", n.Time.Format("2006-01-02 15:04"), cmp.Or(n.Session, "Untitled Session"))
+	fmt.Fprintf(&b, "**Intent:** %s\n\n", n.Intent)
+	if n.Outcome != "" {
+		fmt.Fprintf(&b, "**Outcome:** %s\n\n", n.Outcome)
+	}
+	if len(n.Changes) == 0 {
+		b.WriteString("**Changes:** none\n\n")
+		return b.String()
+	}
+	b.WriteString("**Changes:**\n\n")
+	for _, c := range n.Changes {
+		fmt.Fprintf(&b, "- `%s` (+%d -%d)\n", c.Path, c.Additions, c.Deletions)
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// writeSessionNotes appends the note of the turn that started at since to
+// the session notes file.
+func (c *coordinator) writeSessionNotes(ctx context.Context, call SessionAgentCall, since time.Time) {
+	opts := c.cfg.Options.SessionNotes
+	if !opts.IsEnabled() {
+		return
+	}
+	note, err := c.turnNote(ctx, call, since)
+	if err != nil {
+		slog.Warn("Failed to gather the notes of the turn", "error", err)
+		return
+	}
+
+	dir := c.cfg.WorkingDir()
+	if opts.Location == "data_directory" {
+		dir = c.cfg.Options.DataDirectory
+	}
+	path := filepath.Join(dir, cmp.Or(opts.File, defaultSessionNotesFile))
+	if err := appendNote(path, note); err != nil {
+		slog.Warn("Failed to write the session notes", "path", path, "error", err)
+	}
+}
+
+func appendNote(path string, note turnNote) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	text := note.markdown()
+	if info, err := f.Stat(); err == nil && info.Size() == 0 {
+		text = "# Session Notes\n\n" + text
+	}
+	_, err = f.WriteString(text)
+	return err
+}
+
+// turnNote gathers the intent, the outcome and the changes of a turn.
+func (c *coordinator) turnNote(ctx context.Context, call SessionAgentCall, since time.Time) (turnNote, error) {
+	sess, err := c.sessions.Get(ctx, call.SessionID)
+	if err != nil {
+		return turnNote{}, err
+	}
+	note := turnNote{
+		Time:    since,
+		Session: sess.Title,
+		Intent:  firstParagraph(call.Prompt),
+	}
+
+	msgs, err := c.messages.List(ctx, call.SessionID)
+	if err != nil {
+		return turnNote{}, err
+	}
+	for _, msg := range slices.Backward(msgs) {
+		if msg.Role != message.Assistant || msg.CreatedAt < since.Unix() {
+			continue
+		}
+		if text := firstParagraph(msg.Content().Text); text != "" {
+			note.Outcome = text
+			break
+		}
+	}
+
+	note.Changes, err = c.turnChanges(ctx, call.SessionID, since)
+	return note, err
+}
+
+// turnChanges returns the files changed during the turn, with the lines
+// added and deleted since it started.
+func (c *coordinator) turnChanges(ctx context.Context, sessionID string, since time.Time) ([]fileChange, error) {
+	versions, err := c.history.ListBySession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	type span struct{ before, after string }
+	spans := map[string]*span{}
+	var paths []string
+	for _, v := range versions {
+		s, ok := spans[v.Path]
+		if !ok {
+			s = &span{before: v.Content}
+			spans[v.Path] = s
+		}
+		if v.CreatedAt < since.Unix() {
+			s.before = v.Content
+			continue
+		}
+		if !slices.Contains(paths, v.Path) {
+			paths = append(paths, v.Path)
+		}
+		s.after = v.Content
+	}
+
+	var changes []fileChange
+	for _, path := range paths {
+		s := spans[path]
+		before, _ := fsext.ToUnixLineEndings(s.before)
+		after, _ := fsext.ToUnixLineEndings(s.after)
+		if before == after {
+			continue
+		}
+		if rel, err := filepath.Rel(c.cfg.WorkingDir(), path); err == nil && !strings.HasPrefix(rel, "..") {
+			path = rel
+		}
+		_, additions, deletions := diff.GenerateDiff(before, after, path)
+		changes = append(changes, fileChange{Path: path, Additions: additions, Deletions: deletions})
+	}
+	slices.SortFunc(changes, func(a, b fileChange) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return changes, nil
+}
+
+// firstParagraph returns the first paragraph of text on one line, cut to
+// maxNoteLength runes.
+func firstParagraph(text string) string {
+	text = strings.TrimSpace(text)
+	if i := strings.Index(text, "\n\n"); i >= 0 {
+		text = text[:i]
+	}
+	text = strings.Join(strings.Fields(text), " ")
+	if runes := []rune(text); len(runes) > maxNoteLength {
+		text = string(runes[:maxNoteLength]) + "…"
+	}
+	return text
+}
@@ -0,0 +1,45 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTurnNote(t *testing.T) {
+	t.Parallel()
+
+	note := turnNote{
+		Time:    time.Date(2026, 10, 16, 14, 2, 0, 0, time.UTC),
+		Session: "Login fixes",
+		Intent:  firstParagraph("Fix the flaky\nlogin test.\n\nIt fails on CI."),
+		Outcome: firstParagraph("The test raced on the clock.\n\nDetails follow."),
+		Changes: []fileChange{{Path: "auth/login_test.go", Additions: 4, Deletions: 2}},
+	}
+	require.Equal(t, "## 2026-10-16 14:02 · Login fixes\n\n"+
+		"**Intent:** Fix the flaky login test.\n\n"+
+		"**Outcome:** The test raced on the clock.\n\n"+
+		"**Changes:**\n\n- `auth/login_test.go` (+4 -2)\n\n", note.markdown())
+
+	path := filepath.Join(t.TempDir(), "notes", "SESSION_NOTES.md")
+	require.NoError(t, appendNote(path, note))
+	require.NoError(t, appendNote(path, turnNote{Time: note.Time, Intent: "Thanks"}))
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(string(content), "# Session Notes\n\n## "))
+	require.Equal(t, 1, strings.Count(string(content), "# Session Notes"))
+	require.True(t, strings.HasSuffix(string(content), "**Intent:** Thanks\n\n**Changes:** none\n\n"))
+}
+
+func TestFirstParagraph(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "", firstParagraph("  \n"))
+	long := firstParagraph(strings.Repeat("é", maxNoteLength+10))
+	require.Equal(t, maxNoteLength+1, len([]rune(long)))
+	require.True(t, strings.HasSuffix(long, "…"))
+}
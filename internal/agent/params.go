@@ -0,0 +1,144 @@
+package agent
+
+import (
+	"context"
+	"maps"
+
+	"charm.land/fantasy"
+	"charm.land/fantasy/providers/azure"
+	"charm.land/fantasy/providers/openai"
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+	openaigo "github.com/openai/openai-go/v2"
+)
+
+// modelParams describes how a model takes the parameters of a request, when
+// it differs from what the provider usually accepts.
+type modelParams struct {
+	// maxCompletionTokens sends the output limit as max_completion_tokens
+	// instead of max_tokens.
+	maxCompletionTokens bool
+	// developerRole sends the system prompt as a developer message.
+	developerRole bool
+	// noSampling drops temperature, top_p, top_k and the penalties.
+	noSampling bool
+}
+
+// paramsFor maps the catwalk metadata of a model to the parameters it takes.
+// Reasoning models on the OpenAI API, like the o-series, reject temperature
+// and max_tokens, and expect the system prompt as a developer message. This
+// doesn't rely on the model ID, so custom models and Azure deployments work
+// too as long as they're marked with can_reason.
+func paramsFor(providerType catwalk.Type, model catwalk.Model) modelParams {
+	if !model.CanReason {
+		return modelParams{}
+	}
+	switch providerType {
+	case openai.Name, azure.Name:
+		return modelParams{
+			// The Responses API takes max_output_tokens already.
+			maxCompletionTokens: !openai.IsResponsesModel(model.ID),
+			developerRole:       true,
+			noSampling:          true,
+		}
+	}
+	return modelParams{}
+}
+
+// paramsModel adapts the calls to the wrapped model to the parameters it
+// takes.
+type paramsModel struct {
+	fantasy.LanguageModel
+	params modelParams
+}
+
+func withParams(model fantasy.LanguageModel, params modelParams) fantasy.LanguageModel {
+	if !params.maxCompletionTokens && !params.noSampling {
+		return model
+	}
+	return &paramsModel{LanguageModel: model, params: params}
+}
+
+func (m *paramsModel) Generate(ctx context.Context, call fantasy.Call) (*fantasy.Response, error) {
+	return m.LanguageModel.Generate(ctx, m.adapt(call))
+}
+
+func (m *paramsModel) Stream(ctx context.Context, call fantasy.Call) (fantasy.StreamResponse, error) {
+	return m.LanguageModel.Stream(ctx, m.adapt(call))
+}
+
+func (m *paramsModel) GenerateObject(ctx context.Context, call fantasy.ObjectCall) (*fantasy.ObjectResponse, error) {
+	return m.LanguageModel.GenerateObject(ctx, m.adaptObject(call))
+}
+
+func (m *paramsModel) StreamObject(ctx context.Context, call fantasy.ObjectCall) (fantasy.ObjectStreamResponse, error) {
+	return m.LanguageModel.StreamObject(ctx, m.adaptObject(call))
+}
+
+func (m *paramsModel) adapt(call fantasy.Call) fantasy.Call {
+	if m.params.noSampling {
+		call.Temperature, call.TopP, call.TopK = nil, nil, nil
+		call.PresencePenalty, call.FrequencyPenalty = nil, nil
+	}
+	if m.params.maxCompletionTokens && call.MaxOutputTokens != nil {
+		call.ProviderOptions = withMaxCompletionTokens(call.ProviderOptions, *call.MaxOutputTokens)
+		call.MaxOutputTokens = nil
+	}
+	return call
+}
+
+func (m *paramsModel) adaptObject(call fantasy.ObjectCall) fantasy.ObjectCall {
+	if m.params.noSampling {
+		call.Temperature, call.TopP, call.TopK = nil, nil, nil
+		call.PresencePenalty, call.FrequencyPenalty = nil, nil
+	}
+	if m.params.maxCompletionTokens && call.MaxOutputTokens != nil {
+		call.ProviderOptions = withMaxCompletionTokens(call.ProviderOptions, *call.MaxOutputTokens)
+		call.MaxOutputTokens = nil
+	}
+	return call
+}
+
+// withMaxCompletionTokens returns a copy of the provider options with
+// max_completion_tokens set, unless it was configured already.
+func withMaxCompletionTokens(options fantasy.ProviderOptions, tokens int64) fantasy.ProviderOptions {
+	opts := &openai.ProviderOptions{}
+	if existing, ok := options[openai.Name].(*openai.ProviderOptions); ok {
+		if existing.MaxCompletionTokens != nil {
+			return options
+		}
+		copied := *existing
+		opts = &copied
+	}
+	opts.MaxCompletionTokens = &tokens
+
+	options = maps.Clone(options)
+	if options == nil {
+		options = fantasy.ProviderOptions{}
+	}
+	options[openai.Name] = opts
+	return options
+}
+
+// developerRolePrompt converts prompts like the OpenAI provider does, but
+// sends the system prompt as a developer message to the models that expect
+// it.
+func developerRolePrompt(developerRole func(model string) bool) openai.LanguageModelToPromptFunc {
+	return func(prompt fantasy.Prompt, provider, model string) ([]openaigo.ChatCompletionMessageParamUnion, []fantasy.CallWarning) {
+		messages, warnings := openai.DefaultToPrompt(prompt, provider, model)
+		if !developerRole(model) {
+			return messages, warnings
+		}
+		for i, msg := range messages {
+			system := msg.OfSystem
+			if system == nil {
+				continue
+			}
+			if system.Content.OfString.Valid() {
+				messages[i] = openaigo.DeveloperMessage(system.Content.OfString.Value)
+			} else {
+				messages[i] = openaigo.DeveloperMessage(system.Content.OfArrayOfContentParts)
+			}
+		}
+		return messages, warnings
+	}
+}
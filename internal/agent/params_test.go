@@ -0,0 +1,47 @@
+package agent
+
+import (
+	"testing"
+
+	"charm.land/fantasy"
+	"charm.land/fantasy/providers/openai"
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParamsFor(t *testing.T) {
+	t.Parallel()
+
+	reasoning := catwalk.Model{ID: "my-o3-deployment", CanReason: true}
+	require.Equal(t, modelParams{
+		maxCompletionTokens: true,
+		developerRole:       true,
+		noSampling:          true,
+	}, paramsFor(catwalk.TypeAzure, reasoning))
+	require.Equal(t, modelParams{}, paramsFor(catwalk.TypeOpenAI, catwalk.Model{ID: "gpt-4o"}))
+	require.Equal(t, modelParams{}, paramsFor(catwalk.TypeAnthropic, catwalk.Model{ID: "claude-sonnet-4", CanReason: true}))
+}
+
+func TestParamsModelAdapt(t *testing.T) {
+	t.Parallel()
+
+	m := &paramsModel{params: modelParams{maxCompletionTokens: true, noSampling: true}}
+	temp := 0.7
+	tokens := int64(4096)
+	call := m.adapt(fantasy.Call{Temperature: &temp, MaxOutputTokens: &tokens})
+	require.Nil(t, call.Temperature)
+	require.Nil(t, call.MaxOutputTokens)
+	opts, ok := call.ProviderOptions[openai.Name].(*openai.ProviderOptions)
+	require.True(t, ok)
+	require.Equal(t, int64(4096), *opts.MaxCompletionTokens)
+
+	// A configured max_completion_tokens wins.
+	configured := int64(100)
+	call = m.adapt(fantasy.Call{
+		MaxOutputTokens: &tokens,
+		ProviderOptions: fantasy.ProviderOptions{
+			openai.Name: &openai.ProviderOptions{MaxCompletionTokens: &configured},
+		},
+	})
+	require.Equal(t, int64(100), *call.ProviderOptions[openai.Name].(*openai.ProviderOptions).MaxCompletionTokens)
+}
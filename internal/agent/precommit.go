@@ -0,0 +1,133 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/precommit"
+	"github.com/charmbracelet/crush/internal/session"
+)
+
+const (
+	defaultPreCommitMaxAttempts = 3
+	preCommitTodoPrefix         = "Fix pre-commit hook: "
+)
+
+// gatePreCommit runs the repository's pre-commit hooks on the files changed
+// since the turn started. While hooks fail the agent is asked to fix them,
+// and the session is kept busy until they pass, the attempts run out or the
+// user dismisses the failures.
+func (c *coordinator) gatePreCommit(ctx context.Context, call SessionAgentCall, since time.Time) {
+	opts := c.cfg.Options.PreCommit
+	if !opts.IsEnabled() {
+		return
+	}
+	runner, err := precommit.Detect(c.cfg.WorkingDir(), opts.Command)
+	if err != nil {
+		if !errors.Is(err, precommit.ErrNoHooks) {
+			slog.Warn("Failed to detect pre-commit hooks", "error", err)
+		}
+		return
+	}
+
+	gateCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	c.preCommitGates.Set(call.SessionID, cancel)
+	defer c.preCommitGates.Del(call.SessionID)
+
+	maxAttempts := cmpOrPositive(opts.MaxAttempts, defaultPreCommitMaxAttempts)
+	timeout := time.Duration(opts.Timeout) * time.Second
+	for attempt := 0; ; attempt++ {
+		files, err := c.turnChangedFiles(gateCtx, call.SessionID, since)
+		if err != nil {
+			slog.Warn("Failed to list files changed during turn", "error", err)
+			return
+		}
+		if len(files) == 0 {
+			return
+		}
+
+		slog.Info("Running pre-commit hooks", "runner", runner.Kind, "files", len(files), "attempt", attempt+1)
+		result, err := precommit.Run(gateCtx, c.cfg.WorkingDir(), runner, files, timeout)
+		if err != nil {
+			slog.Warn("Pre-commit hooks did not complete", "error", err)
+			return
+		}
+		if err := c.syncPreCommitTodos(ctx, call.SessionID, result); err != nil {
+			slog.Error("Failed to update pre-commit todos", "error", err)
+		}
+		if result.Passed() || attempt+1 >= maxAttempts || gateCtx.Err() != nil {
+			return
+		}
+
+		fixCall := call
+		fixCall.Attachments = nil
+		fixCall.Prompt = result.Summary() + "\nFix these issues so the hooks pass. Do not disable or skip the hooks."
+		if _, err := c.currentAgent.Run(gateCtx, fixCall); err != nil {
+			slog.Warn("Agent failed to fix pre-commit failures", "error", err)
+			return
+		}
+	}
+}
+
+// DismissPreCommit stops waiting on failing pre-commit hooks for the given
+// session and clears the related todos.
+func (c *coordinator) DismissPreCommit(sessionID string) {
+	if cancel, ok := c.preCommitGates.Take(sessionID); ok {
+		cancel()
+	}
+	if err := c.syncPreCommitTodos(context.Background(), sessionID, precommit.Result{}); err != nil {
+		slog.Error("Failed to clear pre-commit todos", "error", err)
+	}
+}
+
+func (c *coordinator) turnChangedFiles(ctx context.Context, sessionID string, since time.Time) ([]string, error) {
+	files, err := c.history.ListLatestSessionFiles(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, f := range files {
+		if f.CreatedAt >= since.Unix() {
+			paths = append(paths, f.Path)
+		}
+	}
+	return precommit.ChangedFiles(c.cfg.WorkingDir(), paths), nil
+}
+
+// syncPreCommitTodos replaces the pre-commit todos in the session with one
+// pending todo per failing hook.
+func (c *coordinator) syncPreCommitTodos(ctx context.Context, sessionID string, result precommit.Result) error {
+	sess, err := c.sessions.Get(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	todos := slices.DeleteFunc(slices.Clone(sess.Todos), func(t session.Todo) bool {
+		return strings.HasPrefix(t.Content, preCommitTodoPrefix)
+	})
+	for _, f := range result.Failures {
+		todos = append(todos, session.Todo{
+			Content:    preCommitTodoPrefix + f.Hook,
+			Status:     session.TodoStatusPending,
+			ActiveForm: fmt.Sprintf("Fixing pre-commit hook %s", f.Hook),
+		})
+	}
+	if slices.Equal(todos, sess.Todos) {
+		return nil
+	}
+	sess.Todos = todos
+	_, err = c.sessions.Save(ctx, sess)
+	return err
+}
+
+func cmpOrPositive(v, fallback int) int {
+	if v > 0 {
+		return v
+	}
+	return fallback
+}
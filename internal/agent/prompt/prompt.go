@@ -4,6 +4,7 @@ import (
 	"cmp"
 	"context"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -12,7 +13,9 @@ import (
 	"time"
 
 	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/glossary"
 	"github.com/charmbracelet/crush/internal/home"
+	"github.com/charmbracelet/crush/internal/i18n"
 	"github.com/charmbracelet/crush/internal/shell"
 	"github.com/charmbracelet/crush/internal/skills"
 )
@@ -34,9 +37,15 @@ type PromptDat struct {
 	IsGitRepo     bool
 	Platform      string
 	Date          string
+	Locale        string
+	Shell         string
 	GitStatus     string
 	ContextFiles  []ContextFile
 	AvailSkillXML string
+	Glossary      string
+	// Language is the name of the language to respond in, empty for the
+	// language of the user.
+	Language string
 }
 
 type ContextFile struct {
@@ -150,7 +159,29 @@ func expandPath(path string, cfg config.Config) string {
 
 func (p *Prompt) promptData(ctx context.Context, provider, model string, cfg config.Config) (PromptDat, error) {
 	workingDir := cmp.Or(p.workingDir, cfg.WorkingDir())
+	var env config.PromptEnv
+	if cfg.Options.PromptEnv != nil {
+		env = *cfg.Options.PromptEnv
+	}
 	platform := cmp.Or(p.platform, runtime.GOOS)
+	if env.HidePlatform {
+		platform = ""
+	}
+	var date string
+	if env.Clock != "none" {
+		now := p.now()
+		if loc := location(env); loc != nil {
+			now = now.In(loc)
+		}
+		date = now.Format("1/2/2006")
+	}
+	var locale, shellName string
+	if env.Locale {
+		locale = hostLocale()
+	}
+	if env.Shell {
+		shellName = describeShell(cfg.Options.Shell)
+	}
 
 	files := map[string][]ContextFile{}
 
@@ -184,8 +215,14 @@ func (p *Prompt) promptData(ctx context.Context, provider, model string, cfg con
 		WorkingDir:    filepath.ToSlash(workingDir),
 		IsGitRepo:     isGit,
 		Platform:      platform,
-		Date:          p.now().Format("1/2/2006"),
+		Date:          date,
+		Locale:        locale,
+		Shell:         shellName,
 		AvailSkillXML: availSkillXML,
+		Glossary:      glossary.Prompt(cfg.Options.Glossary),
+	}
+	if cfg.Options.Language != "" {
+		data.Language = i18n.Name(cfg.Options.Language)
 	}
 	if isGit {
 		var err error
@@ -201,6 +238,59 @@ func (p *Prompt) promptData(ctx context.Context, provider, model string, cfg con
 	return data, nil
 }
 
+// CurrentTime returns the function giving the current local time to tell the
+// agent on every turn when options.prompt_env.clock is datetime, or nil.
+func CurrentTime(env *config.PromptEnv) func() string {
+	if env == nil || env.Clock != "datetime" {
+		return nil
+	}
+	loc := location(*env)
+	return func() string {
+		now := time.Now()
+		if loc != nil {
+			now = now.In(loc)
+		}
+		return now.Format("Monday, January 2, 2006 15:04 MST")
+	}
+}
+
+// location returns the time zone of options.prompt_env.timezone, or nil for
+// the time zone of the host.
+func location(env config.PromptEnv) *time.Location {
+	if env.Timezone == "" {
+		return nil
+	}
+	loc, err := time.LoadLocation(env.Timezone)
+	if err != nil {
+		slog.Warn("Ignoring invalid time zone of options.prompt_env", "timezone", env.Timezone, "error", err)
+		return nil
+	}
+	return loc
+}
+
+// hostLocale returns the locale of the host as set in the environment.
+func hostLocale() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// describeShell describes the shell the bash tool runs commands with.
+func describeShell(name string) string {
+	shellType, _ := shell.ParseShellType(name)
+	switch shellType {
+	case shell.ShellTypePowerShell:
+		return "PowerShell"
+	case shell.ShellTypeCmd:
+		return "cmd.exe"
+	default:
+		return "POSIX shell (bash-compatible emulation)"
+	}
+}
+
 func isGitRepo(dir string) bool {
 	_, err := os.Stat(filepath.Join(dir, ".git"))
 	return err == nil
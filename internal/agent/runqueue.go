@@ -0,0 +1,195 @@
+package agent
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/pubsub"
+)
+
+// QueuedRun is a run of the agent in the run queue, in progress or waiting
+// for a slot.
+type QueuedRun struct {
+	ID        int
+	SessionID string
+	// Session is the title of the session.
+	Session string
+	Prompt  string
+	// Since is when the run started, or was queued when waiting.
+	Since time.Time
+}
+
+// RunQueueEvent is published when runs start, end, or wait in the run queue.
+type RunQueueEvent struct {
+	Running int
+	Waiting int
+}
+
+var runQueueBroker = pubsub.NewBroker[RunQueueEvent]()
+
+// SubscribeRunQueueEvents returns a channel for the changes of the run
+// queue.
+func SubscribeRunQueueEvents(ctx context.Context) <-chan pubsub.Event[RunQueueEvent] {
+	return runQueueBroker.Subscribe(ctx)
+}
+
+// runQueue limits how many sessions the agent works in at once, as set by
+// options.max_concurrent_runs. Runs past the limit wait in order for a slot,
+// and can be reordered or canceled in the meantime.
+type runQueue struct {
+	limit int // No limit when 0.
+
+	mu      sync.Mutex
+	nextID  int
+	running []QueuedRun
+	waiting []*waitingRun
+}
+
+type waitingRun struct {
+	QueuedRun
+	// started receives nil once the run has a slot, or the error it was
+	// canceled with.
+	started chan error
+}
+
+func newRunQueue(limit int) *runQueue {
+	return &runQueue{limit: max(limit, 0)}
+}
+
+// acquire waits for a slot for a run, and returns the function that gives
+// it back once the run is done.
+func (q *runQueue) acquire(ctx context.Context, sessionID, session, prompt string) (func(), error) {
+	q.mu.Lock()
+	q.nextID++
+	run := QueuedRun{ID: q.nextID, SessionID: sessionID, Session: session, Prompt: prompt, Since: time.Now()}
+	// Runs don't jump the queue.
+	if q.limit == 0 || (len(q.running) < q.limit && len(q.waiting) == 0) {
+		q.running = append(q.running, run)
+		q.mu.Unlock()
+		q.publish()
+		return q.releaseFunc(run.ID), nil
+	}
+	w := &waitingRun{QueuedRun: run, started: make(chan error, 1)}
+	q.waiting = append(q.waiting, w)
+	q.mu.Unlock()
+	q.publish()
+
+	select {
+	case err := <-w.started:
+		if err != nil {
+			return nil, err
+		}
+		return q.releaseFunc(run.ID), nil
+	case <-ctx.Done():
+		q.cancel(run.ID, ctx.Err())
+		// The run may have got its slot in the meantime.
+		if err := <-w.started; err == nil {
+			q.releaseFunc(run.ID)()
+		}
+		return nil, ctx.Err()
+	}
+}
+
+func (q *runQueue) releaseFunc(id int) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			q.mu.Lock()
+			q.running = slices.DeleteFunc(q.running, func(r QueuedRun) bool { return r.ID == id })
+			q.startWaiting()
+			q.mu.Unlock()
+			q.publish()
+		})
+	}
+}
+
+// startWaiting starts the waiting runs there are slots for. q.mu must be
+// held.
+func (q *runQueue) startWaiting() {
+	for len(q.waiting) > 0 && len(q.running) < q.limit {
+		w := q.waiting[0]
+		q.waiting = q.waiting[1:]
+		w.Since = time.Now()
+		q.running = append(q.running, w.QueuedRun)
+		w.started <- nil
+	}
+}
+
+// runs returns the runs in progress, and the waiting runs in the order they
+// start in.
+func (q *runQueue) runs() (running, waiting []QueuedRun) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	running = slices.Clone(q.running)
+	for _, w := range q.waiting {
+		waiting = append(waiting, w.QueuedRun)
+	}
+	return running, waiting
+}
+
+func (q *runQueue) isWaiting(sessionID string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return slices.ContainsFunc(q.waiting, func(w *waitingRun) bool { return w.SessionID == sessionID })
+}
+
+// move moves a waiting run by delta places, towards the front of the queue
+// when negative.
+func (q *runQueue) move(id, delta int) {
+	q.mu.Lock()
+	i := slices.IndexFunc(q.waiting, func(w *waitingRun) bool { return w.ID == id })
+	if i < 0 {
+		q.mu.Unlock()
+		return
+	}
+	w := q.waiting[i]
+	q.waiting = slices.Delete(q.waiting, i, i+1)
+	j := min(max(i+delta, 0), len(q.waiting))
+	q.waiting = slices.Insert(q.waiting, j, w)
+	q.mu.Unlock()
+	q.publish()
+}
+
+// cancel cancels the waiting run with the given ID.
+func (q *runQueue) cancel(id int, err error) {
+	q.cancelFunc(func(w *waitingRun) bool { return w.ID == id }, err)
+}
+
+// cancelSession cancels the waiting runs of a session.
+func (q *runQueue) cancelSession(sessionID string) {
+	q.cancelFunc(func(w *waitingRun) bool { return w.SessionID == sessionID }, context.Canceled)
+}
+
+// cancelAll cancels all the waiting runs.
+func (q *runQueue) cancelAll() {
+	q.cancelFunc(func(*waitingRun) bool { return true }, context.Canceled)
+}
+
+func (q *runQueue) cancelFunc(match func(*waitingRun) bool, err error) {
+	q.mu.Lock()
+	var canceled []*waitingRun
+	q.waiting = slices.DeleteFunc(q.waiting, func(w *waitingRun) bool {
+		if match(w) {
+			canceled = append(canceled, w)
+			return true
+		}
+		return false
+	})
+	q.mu.Unlock()
+	if len(canceled) == 0 {
+		return
+	}
+	for _, w := range canceled {
+		w.started <- err
+	}
+	q.publish()
+}
+
+func (q *runQueue) publish() {
+	q.mu.Lock()
+	event := RunQueueEvent{Running: len(q.running), Waiting: len(q.waiting)}
+	q.mu.Unlock()
+	runQueueBroker.Publish(pubsub.UpdatedEvent, event)
+}
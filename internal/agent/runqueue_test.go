@@ -0,0 +1,101 @@
+package agent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunQueue(t *testing.T) {
+	t.Parallel()
+
+	type result struct {
+		release func()
+		err     error
+	}
+	acquire := func(q *runQueue, ctx context.Context, sessionID string) <-chan result {
+		ch := make(chan result, 1)
+		go func() {
+			release, err := q.acquire(ctx, sessionID, "", "prompt")
+			ch <- result{release, err}
+		}()
+		return ch
+	}
+	waitFor := func(q *runQueue, n int) []QueuedRun {
+		var waiting []QueuedRun
+		require.Eventually(t, func() bool {
+			_, waiting = q.runs()
+			return len(waiting) == n
+		}, time.Second, time.Millisecond)
+		return waiting
+	}
+
+	t.Run("limit", func(t *testing.T) {
+		t.Parallel()
+		q := newRunQueue(1)
+		first := <-acquire(q, t.Context(), "a")
+		require.NoError(t, first.err)
+
+		b := acquire(q, t.Context(), "b")
+		waitFor(q, 1)
+		c := acquire(q, t.Context(), "c")
+		waiting := waitFor(q, 2)
+		require.True(t, q.isWaiting("c"))
+
+		// c goes first.
+		q.move(waiting[1].ID, -1)
+		_, waiting = q.runs()
+		require.Equal(t, "c", waiting[0].SessionID)
+
+		first.release()
+		next := <-c
+		require.NoError(t, next.err)
+		running, _ := q.runs()
+		require.Len(t, running, 1)
+		require.Equal(t, "c", running[0].SessionID)
+
+		next.release()
+		require.NoError(t, (<-b).err)
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		t.Parallel()
+		q := newRunQueue(1)
+		first := <-acquire(q, t.Context(), "a")
+		require.NoError(t, first.err)
+
+		b := acquire(q, t.Context(), "b")
+		waiting := waitFor(q, 1)
+		q.cancel(waiting[0].ID, context.Canceled)
+		require.ErrorIs(t, (<-b).err, context.Canceled)
+
+		ctx, cancel := context.WithCancel(t.Context())
+		c := acquire(q, ctx, "c")
+		waitFor(q, 1)
+		cancel()
+		require.ErrorIs(t, (<-c).err, context.Canceled)
+		waitFor(q, 0)
+
+		d := acquire(q, t.Context(), "d")
+		waitFor(q, 1)
+		q.cancelSession("d")
+		require.ErrorIs(t, (<-d).err, context.Canceled)
+
+		first.release()
+		running, _ := q.runs()
+		require.Empty(t, running)
+	})
+
+	t.Run("no limit", func(t *testing.T) {
+		t.Parallel()
+		q := newRunQueue(0)
+		for _, id := range []string{"a", "b", "c"} {
+			require.NoError(t, (<-acquire(q, t.Context(), id)).err)
+		}
+		running, waiting := q.runs()
+		require.Len(t, running, 3)
+		require.Empty(t, waiting)
+	})
+}
@@ -49,8 +49,18 @@ Only include URLs that actually contributed information to your answer. Include
 
 <env>
 Working directory: {{.WorkingDir}}
+{{- if .Platform}}
 Platform: {{.Platform}}
+{{- end}}
+{{- if .Date}}
 Today's date: {{.Date}}
+{{- end}}
+{{- if .Locale}}
+Locale: {{.Locale}}
+{{- end}}
+{{- if .Shell}}
+Shell: {{.Shell}}
+{{- end}}
 </env>
 
 <web_search_tool>
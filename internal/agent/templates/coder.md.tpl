@@ -344,8 +344,18 @@ Adapt verbosity to match the work completed:
 <env>
 Working directory: {{.WorkingDir}}
 Is directory a git repo: {{if .IsGitRepo}}yes{{else}}no{{end}}
+{{- if .Platform}}
 Platform: {{.Platform}}
+{{- end}}
+{{- if .Date}}
 Today's date: {{.Date}}
+{{- end}}
+{{- if .Locale}}
+Locale: {{.Locale}}
+{{- end}}
+{{- if .Shell}}
+Shell: {{.Shell}}
+{{- end}}
 {{if .GitStatus}}
 
 Git status (snapshot at conversation start - may be outdated):
@@ -360,6 +370,16 @@ Diagnostics (lint/typecheck) included in tool output.
 - Ignore issues in files you didn't touch (unless user asks)
 </lsp>
 {{end}}
+{{- if .Glossary}}
+
+{{.Glossary}}
+{{end}}
+{{- if .Language}}
+
+<language>
+Respond to the user in {{.Language}}, including summaries and questions. Keep code, identifiers, commands, commit messages and file contents in the language the project already uses, unless asked otherwise.
+</language>
+{{end}}
 {{- if .AvailSkillXML}}
 
 {{.AvailSkillXML}}
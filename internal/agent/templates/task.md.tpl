@@ -9,7 +9,17 @@ You are an agent for Crush. Given the user's prompt, you should use the tools av
 <env>
 Working directory: {{.WorkingDir}}
 Is directory a git repo: {{if .IsGitRepo}} yes {{else}} no {{end}}
+{{- if .Platform}}
 Platform: {{.Platform}}
+{{- end}}
+{{- if .Date}}
 Today's date: {{.Date}}
+{{- end}}
+{{- if .Locale}}
+Locale: {{.Locale}}
+{{- end}}
+{{- if .Shell}}
+Shell: {{.Shell}}
+{{- end}}
 </env>
 
@@ -0,0 +1,453 @@
+// Package toolgrammar makes small local models call tools reliably by
+// constraining their output with a grammar. Instead of relying on the server
+// to parse tool calls out of whatever the model writes, the tools are
+// described in the system prompt and the model must answer with a JSON
+// object matching a JSON schema built from the schemas of the tools, which
+// llama.cpp and Ollama turn into a grammar. The answer is then turned back
+// into a regular tool call.
+package toolgrammar
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Transport rewrites the chat completion requests with tools sent through
+// it, and the responses to them.
+type Transport struct {
+	Base http.RoundTripper
+}
+
+// NewClient returns an HTTP client constraining tool calls with a grammar,
+// sending requests through base, or the default transport when nil.
+func NewClient(base http.RoundTripper) *http.Client {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &http.Client{Transport: &Transport{Base: base}}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body == nil || req.Body == http.NoBody || !strings.HasSuffix(req.URL.Path, "/chat/completions") {
+		return t.Base.RoundTrip(req)
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	rewritten, stream, ok := rewriteRequest(body)
+	if !ok {
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		return t.Base.RoundTrip(req)
+	}
+
+	req = req.Clone(req.Context())
+	req.Body = io.NopCloser(bytes.NewReader(rewritten))
+	req.ContentLength = int64(len(rewritten))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(rewritten)), nil
+	}
+	resp, err := t.Base.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+	defer resp.Body.Close()
+
+	var out []byte
+	if stream {
+		out, err = translateStream(resp.Body)
+	} else {
+		out, err = translateResponse(resp.Body)
+	}
+	if err != nil {
+		return nil, err
+	}
+	translated := *resp
+	translated.Header = resp.Header.Clone()
+	translated.Header.Del("Content-Length")
+	translated.ContentLength = int64(len(out))
+	translated.Body = io.NopCloser(bytes.NewReader(out))
+	return &translated, nil
+}
+
+type tool struct {
+	Name        string
+	Description string
+	Parameters  json.RawMessage
+}
+
+// rewriteRequest moves the tools of a chat completion request to its system
+// prompt and constrains the answer to the JSON schema of a reply or a call
+// to one of them. It returns false when the request has no tools.
+func rewriteRequest(body []byte) (_ []byte, stream bool, _ bool) {
+	var req map[string]json.RawMessage
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, false, false
+	}
+	var rawTools []struct {
+		Function struct {
+			Name        string          `json:"name"`
+			Description string          `json:"description"`
+			Parameters  json.RawMessage `json:"parameters"`
+		} `json:"function"`
+	}
+	if err := json.Unmarshal(req["tools"], &rawTools); err != nil || len(rawTools) == 0 {
+		return nil, false, false
+	}
+	var tools []tool
+	for _, t := range rawTools {
+		params := t.Function.Parameters
+		if len(params) == 0 || string(params) == "null" {
+			params = json.RawMessage(`{"type":"object","properties":{}}`)
+		}
+		tools = append(tools, tool{Name: t.Function.Name, Description: t.Function.Description, Parameters: params})
+	}
+	_ = json.Unmarshal(req["stream"], &stream)
+
+	allowReply := true
+	switch choice := toolChoice(req["tool_choice"]); choice {
+	case "", "auto":
+	case "none":
+		tools = nil
+	case "required":
+		allowReply = false
+	default:
+		for _, t := range tools {
+			if t.Name == choice {
+				tools = []tool{t}
+				allowReply = false
+				break
+			}
+		}
+	}
+
+	var messages []map[string]any
+	if err := json.Unmarshal(req["messages"], &messages); err != nil {
+		return nil, false, false
+	}
+	messages = rewriteMessages(messages, instructions(tools, allowReply))
+
+	delete(req, "tools")
+	delete(req, "tool_choice")
+	delete(req, "parallel_tool_calls")
+	set := func(key string, value any) {
+		data, _ := json.Marshal(value)
+		req[key] = data
+	}
+	set("messages", messages)
+	set("response_format", map[string]any{
+		"type": "json_schema",
+		"json_schema": map[string]any{
+			"name":   "answer",
+			"strict": true,
+			"schema": answerSchema(tools, allowReply),
+		},
+	})
+	rewritten, err := json.Marshal(req)
+	if err != nil {
+		return nil, false, false
+	}
+	return rewritten, stream, true
+}
+
+// toolChoice returns "auto", "none", "required", or the name of the tool the
+// model has to call.
+func toolChoice(raw json.RawMessage) string {
+	var choice string
+	if err := json.Unmarshal(raw, &choice); err == nil {
+		return choice
+	}
+	var named struct {
+		Function struct {
+			Name string `json:"name"`
+		} `json:"function"`
+	}
+	if err := json.Unmarshal(raw, &named); err == nil {
+		return named.Function.Name
+	}
+	return ""
+}
+
+// answerSchema returns the JSON schema of the answers of the model: a reply
+// to the user, or a call to one of the tools.
+func answerSchema(tools []tool, allowReply bool) map[string]any {
+	var options []any
+	if allowReply || len(tools) == 0 {
+		options = append(options, map[string]any{
+			"type":                 "object",
+			"properties":           map[string]any{"reply": map[string]any{"type": "string"}},
+			"required":             []string{"reply"},
+			"additionalProperties": false,
+		})
+	}
+	for _, t := range tools {
+		options = append(options, map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"tool":      map[string]any{"type": "string", "enum": []string{t.Name}},
+				"arguments": t.Parameters,
+			},
+			"required":             []string{"tool", "arguments"},
+			"additionalProperties": false,
+		})
+	}
+	if len(options) == 1 {
+		return options[0].(map[string]any)
+	}
+	return map[string]any{"anyOf": options}
+}
+
+// instructions tells the model how to answer and describes the tools.
+func instructions(tools []tool, allowReply bool) string {
+	var b strings.Builder
+	b.WriteString("\n\n# Answer format\n\nAlways answer with a single JSON object")
+	switch {
+	case len(tools) == 0:
+		b.WriteString(`: {"reply": "<your message to the user>"}.`)
+		return b.String()
+	case allowReply:
+		b.WriteString(", either:\n")
+		b.WriteString(`- {"reply": "<your message to the user>"} to reply, or` + "\n")
+		b.WriteString(`- {"tool": "<tool name>", "arguments": {...}} to call one of the tools below, with arguments matching its parameters.` + "\n")
+	default:
+		b.WriteString(`: {"tool": "<tool name>", "arguments": {...}} to call one of the tools below, with arguments matching its parameters.` + "\n")
+	}
+	b.WriteString("Call one tool at a time, and wait for its result before the next call.\n\n# Tools\n")
+	for _, t := range tools {
+		fmt.Fprintf(&b, "\n## %s\n\n%s\n\nParameters: %s\n", t.Name, strings.TrimSpace(t.Description), t.Parameters)
+	}
+	return b.String()
+}
+
+// rewriteMessages adds the instructions to the system prompt, and rewrites
+// the tool calls and results of the conversation in the answer format, as
+// the server no longer knows about tools.
+func rewriteMessages(messages []map[string]any, instructions string) []map[string]any {
+	toolNames := map[string]string{}
+	rewritten := make([]map[string]any, 0, len(messages)+1)
+	system := false
+	for _, msg := range messages {
+		switch msg["role"] {
+		case "system", "developer":
+			if !system {
+				msg["content"] = text(msg["content"]) + instructions
+				system = true
+			}
+		case "assistant":
+			var answers []string
+			if content := text(msg["content"]); content != "" {
+				answers = append(answers, marshal(map[string]any{"reply": content}))
+			}
+			calls, _ := msg["tool_calls"].([]any)
+			for _, call := range calls {
+				call, _ := call.(map[string]any)
+				fn, _ := call["function"].(map[string]any)
+				name, _ := fn["name"].(string)
+				id, _ := call["id"].(string)
+				args, _ := fn["arguments"].(string)
+				toolNames[id] = name
+				var parsed any
+				if json.Unmarshal([]byte(args), &parsed) != nil {
+					parsed = map[string]any{}
+				}
+				answers = append(answers, marshal(map[string]any{"tool": name, "arguments": parsed}))
+			}
+			delete(msg, "tool_calls")
+			msg["content"] = strings.Join(answers, "\n")
+		case "tool":
+			id, _ := msg["tool_call_id"].(string)
+			msg = map[string]any{
+				"role":    "user",
+				"content": fmt.Sprintf("Result of the %s call:\n%s", toolNames[id], text(msg["content"])),
+			}
+		}
+		rewritten = append(rewritten, msg)
+	}
+	if !system {
+		rewritten = append([]map[string]any{{"role": "system", "content": strings.TrimSpace(instructions)}}, rewritten...)
+	}
+	return rewritten
+}
+
+// text returns the text of the content of a message, given as a string or
+// as parts.
+func text(content any) string {
+	switch content := content.(type) {
+	case string:
+		return content
+	case []any:
+		var texts []string
+		for _, part := range content {
+			part, _ := part.(map[string]any)
+			if t, ok := part["text"].(string); ok {
+				texts = append(texts, t)
+			}
+		}
+		return strings.Join(texts, "\n")
+	}
+	return ""
+}
+
+func marshal(v any) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+// answer is what the model answered, in the answer format.
+type answer struct {
+	Reply     *string         `json:"reply"`
+	Tool      string          `json:"tool"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+// parseAnswer parses the answer of the model. When it isn't in the answer
+// format, the whole content is taken as a reply.
+func parseAnswer(content string) answer {
+	s := strings.TrimSpace(content)
+	s = strings.TrimPrefix(s, "```json")
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "```"), "```")
+	var a answer
+	if err := json.Unmarshal([]byte(strings.TrimSpace(s)), &a); err != nil || (a.Reply == nil && a.Tool == "") {
+		return answer{Reply: &content}
+	}
+	if a.Tool != "" && len(a.Arguments) == 0 {
+		a.Arguments = json.RawMessage("{}")
+	}
+	return a
+}
+
+func newCallID() string {
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return "call_" + hex.EncodeToString(b)
+}
+
+// translateResponse turns the answer of a chat completion response back
+// into a reply or a tool call.
+func translateResponse(r io.Reader) ([]byte, error) {
+	var resp map[string]any
+	if err := json.NewDecoder(r).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	choices, _ := resp["choices"].([]any)
+	if len(choices) == 0 {
+		return json.Marshal(resp)
+	}
+	choice, _ := choices[0].(map[string]any)
+	msg, _ := choice["message"].(map[string]any)
+	if msg == nil {
+		return json.Marshal(resp)
+	}
+	a := parseAnswer(text(msg["content"]))
+	if a.Tool != "" {
+		msg["content"] = nil
+		msg["tool_calls"] = []any{map[string]any{
+			"id":       newCallID(),
+			"type":     "function",
+			"function": map[string]any{"name": a.Tool, "arguments": string(a.Arguments)},
+		}}
+		choice["finish_reason"] = "tool_calls"
+	} else {
+		msg["content"] = *a.Reply
+	}
+	return json.Marshal(resp)
+}
+
+// translateStream reads the whole stream of a chat completion, as the
+// answer can only be parsed once complete, and returns a stream of the reply
+// or the tool call it holds.
+func translateStream(r io.Reader) ([]byte, error) {
+	var (
+		content      strings.Builder
+		reasoning    strings.Builder
+		reasoningKey string
+		last         map[string]any
+		usage        any
+		finishReason string
+	)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		data = strings.TrimSpace(data)
+		if !ok || data == "" || data == "[DONE]" {
+			continue
+		}
+		var chunk map[string]any
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			continue
+		}
+		last = chunk
+		if u, ok := chunk["usage"]; ok && u != nil {
+			usage = u
+		}
+		choices, _ := chunk["choices"].([]any)
+		if len(choices) == 0 {
+			continue
+		}
+		choice, _ := choices[0].(map[string]any)
+		if reason, ok := choice["finish_reason"].(string); ok && reason != "" {
+			finishReason = reason
+		}
+		delta, _ := choice["delta"].(map[string]any)
+		if c, ok := delta["content"].(string); ok {
+			content.WriteString(c)
+		}
+		for _, key := range []string{"reasoning_content", "reasoning"} {
+			if r, ok := delta[key].(string); ok {
+				reasoningKey = key
+				reasoning.WriteString(r)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read stream: %w", err)
+	}
+
+	var out bytes.Buffer
+	emit := func(delta map[string]any, finishReason any, usage any) {
+		chunk := map[string]any{
+			"object":  "chat.completion.chunk",
+			"choices": []any{map[string]any{"index": 0, "delta": delta, "finish_reason": finishReason}},
+		}
+		for _, key := range []string{"id", "created", "model"} {
+			if last != nil && last[key] != nil {
+				chunk[key] = last[key]
+			}
+		}
+		if usage != nil {
+			chunk["usage"] = usage
+		}
+		fmt.Fprintf(&out, "data: %s\n\n", marshal(chunk))
+	}
+
+	if reasoning.Len() > 0 {
+		emit(map[string]any{"role": "assistant", reasoningKey: reasoning.String()}, nil, nil)
+	}
+	a := parseAnswer(content.String())
+	if a.Tool != "" {
+		emit(map[string]any{"role": "assistant", "tool_calls": []any{map[string]any{
+			"index":    0,
+			"id":       newCallID(),
+			"type":     "function",
+			"function": map[string]any{"name": a.Tool, "arguments": string(a.Arguments)},
+		}}}, nil, nil)
+		finishReason = "tool_calls"
+	} else {
+		emit(map[string]any{"role": "assistant", "content": *a.Reply}, nil, nil)
+		if finishReason == "" || finishReason == "tool_calls" {
+			finishReason = "stop"
+		}
+	}
+	emit(map[string]any{}, finishReason, usage)
+	out.WriteString("data: [DONE]\n\n")
+	return out.Bytes(), nil
+}
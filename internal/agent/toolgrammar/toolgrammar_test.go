@@ -0,0 +1,104 @@
+package toolgrammar
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const request = `{
+	"model": "qwen",
+	"stream": true,
+	"messages": [
+		{"role": "system", "content": "You are crush."},
+		{"role": "user", "content": "List the files."},
+		{"role": "assistant", "content": "", "tool_calls": [{"id": "call_1", "type": "function", "function": {"name": "ls", "arguments": "{\"path\":\".\"}"}}]},
+		{"role": "tool", "tool_call_id": "call_1", "content": "main.go"}
+	],
+	"tools": [{"type": "function", "function": {"name": "ls", "description": "Lists files.", "parameters": {"type": "object", "properties": {"path": {"type": "string"}}, "required": ["path"]}}}],
+	"tool_choice": "auto"
+}`
+
+func TestRewriteRequest(t *testing.T) {
+	t.Parallel()
+
+	body, stream, ok := rewriteRequest([]byte(request))
+	require.True(t, ok)
+	require.True(t, stream)
+
+	var req struct {
+		Messages       []map[string]any `json:"messages"`
+		Tools          any              `json:"tools"`
+		ToolChoice     any              `json:"tool_choice"`
+		ResponseFormat struct {
+			Type       string `json:"type"`
+			JSONSchema struct {
+				Schema struct {
+					AnyOf []map[string]any `json:"anyOf"`
+				} `json:"schema"`
+			} `json:"json_schema"`
+		} `json:"response_format"`
+	}
+	require.NoError(t, json.Unmarshal(body, &req))
+	require.Nil(t, req.Tools)
+	require.Nil(t, req.ToolChoice)
+	require.Equal(t, "json_schema", req.ResponseFormat.Type)
+	require.Len(t, req.ResponseFormat.JSONSchema.Schema.AnyOf, 2)
+
+	require.Len(t, req.Messages, 4)
+	require.Contains(t, req.Messages[0]["content"], "You are crush.")
+	require.Contains(t, req.Messages[0]["content"], "## ls")
+	require.Equal(t, `{"arguments":{"path":"."},"tool":"ls"}`, req.Messages[2]["content"])
+	require.Nil(t, req.Messages[2]["tool_calls"])
+	require.Equal(t, "user", req.Messages[3]["role"])
+	require.Equal(t, "Result of the ls call:\nmain.go", req.Messages[3]["content"])
+
+	_, _, ok = rewriteRequest([]byte(`{"model": "qwen", "messages": []}`))
+	require.False(t, ok)
+}
+
+func TestParseAnswer(t *testing.T) {
+	t.Parallel()
+
+	a := parseAnswer("```json\n{\"tool\": \"view\", \"arguments\": {\"file_path\": \"a.go\"}}\n```")
+	require.Equal(t, "view", a.Tool)
+	require.JSONEq(t, `{"file_path": "a.go"}`, string(a.Arguments))
+
+	a = parseAnswer(`{"reply": "Done."}`)
+	require.Equal(t, "Done.", *a.Reply)
+
+	a = parseAnswer("Not JSON at all.")
+	require.Equal(t, "Not JSON at all.", *a.Reply)
+}
+
+func TestTransport(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		require.NotContains(t, string(body), `"tools"`)
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, part := range []string{`{\"tool\": \"ls\", `, `\"arguments\": {\"path\": \".\"}}`} {
+			io.WriteString(w, `data: {"id":"1","model":"qwen","choices":[{"index":0,"delta":{"content":"`+part+`"}}]}`+"\n\n")
+		}
+		io.WriteString(w, `data: {"id":"1","model":"qwen","choices":[{"index":0,"delta":{},"finish_reason":"stop"}],"usage":{"prompt_tokens":10,"completion_tokens":5}}`+"\n\n")
+		io.WriteString(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	resp, err := NewClient(nil).Post(server.URL+"/v1/chat/completions", "application/json", strings.NewReader(request))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	require.Contains(t, string(body), `"tool_calls":[{"function":{"arguments":"{\"path\": \".\"}","name":"ls"}`)
+	require.Contains(t, string(body), `"finish_reason":"tool_calls"`)
+	require.Contains(t, string(body), `"completion_tokens":5`)
+	require.True(t, strings.HasSuffix(string(body), "data: [DONE]\n\n"))
+}
@@ -0,0 +1,119 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+
+	"charm.land/fantasy"
+)
+
+// defaultToolCallRetries is how many tool calls in a row with malformed
+// arguments the model may retry when options.turn_limits doesn't say.
+const defaultToolCallRetries = 3
+
+// repairToolCall fixes the arguments of a tool call the model got wrong in
+// common ways, like JSON cut short by the output limit. When they can't be
+// fixed, the validation error is sent back to the model so it can retry.
+func repairToolCall(_ context.Context, opts fantasy.ToolCallRepairOptions) (*fantasy.ToolCallContent, error) {
+	repaired, ok := repairJSON(opts.OriginalToolCall.Input)
+	if !ok || repaired == opts.OriginalToolCall.Input {
+		return nil, opts.ValidationError
+	}
+	slog.Info("Repaired tool call arguments", "tool", opts.OriginalToolCall.ToolName, "error", opts.ValidationError)
+	toolCall := opts.OriginalToolCall
+	toolCall.Input = repaired
+	return &toolCall, nil
+}
+
+// repairJSON turns malformed JSON arguments into a JSON object when it can:
+// it strips code fences, unwraps objects encoded as a string, drops trailing
+// commas and closes the strings, arrays and objects left open.
+func repairJSON(input string) (string, bool) {
+	s := strings.TrimSpace(input)
+	if after, ok := strings.CutPrefix(s, "```"); ok {
+		// Drop the language of the fence, if any.
+		if _, body, ok := strings.Cut(after, "\n"); ok {
+			s = body
+		} else {
+			s = after
+		}
+		s = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "```"))
+	}
+	if s == "" {
+		return "{}", true
+	}
+	var unquoted string
+	if err := json.Unmarshal([]byte(s), &unquoted); err == nil {
+		s = strings.TrimSpace(unquoted)
+	}
+
+	var out strings.Builder
+	var closers []byte
+	inString, escaped := false, false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if inString {
+			out.WriteByte(c)
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+		case '{':
+			closers = append(closers, '}')
+		case '[':
+			closers = append(closers, ']')
+		case '}', ']':
+			if len(closers) == 0 || closers[len(closers)-1] != c {
+				return "", false
+			}
+			closers = closers[:len(closers)-1]
+			trimTrailingComma(&out)
+		}
+		out.WriteByte(c)
+	}
+
+	if inString {
+		if escaped {
+			trimmed := strings.TrimSuffix(out.String(), `\`)
+			out.Reset()
+			out.WriteString(trimmed)
+		}
+		out.WriteByte('"')
+	}
+	trimTrailingComma(&out)
+	if strings.HasSuffix(out.String(), ":") {
+		out.WriteString("null")
+	}
+	for i := len(closers) - 1; i >= 0; i-- {
+		out.WriteByte(closers[i])
+	}
+
+	repaired := out.String()
+	var obj map[string]any
+	if err := json.Unmarshal([]byte(repaired), &obj); err != nil {
+		return "", false
+	}
+	return repaired, true
+}
+
+// trimTrailingComma drops a comma, and the whitespace after it, from the end
+// of out.
+func trimTrailingComma(out *strings.Builder) {
+	s := strings.TrimRight(out.String(), " \t\r\n")
+	if !strings.HasSuffix(s, ",") {
+		return
+	}
+	out.Reset()
+	out.WriteString(strings.TrimSuffix(s, ","))
+}
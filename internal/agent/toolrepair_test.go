@@ -0,0 +1,40 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepairJSON(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"empty", "  ", "{}"},
+		{"truncated string", `{"command": "ls -la`, `{"command": "ls -la"}`},
+		{"truncated escape", `{"content": "a\`, `{"content": "a"}`},
+		{"truncated array", `{"paths": ["a", "b",`, `{"paths": ["a", "b"]}`},
+		{"missing value", `{"a": 1, "b":`, `{"a": 1, "b":null}`},
+		{"trailing comma", `{"a": [1, 2,], "b": 3,}`, `{"a": [1, 2], "b": 3}`},
+		{"code fence", "```json\n{\"a\": 1}\n```", `{"a": 1}`},
+		{"quoted object", `"{\"a\": \"}\"}"`, `{"a": "}"}`},
+		{"braces in strings", `{"old": "func() {", "new": "]`, `{"old": "func() {", "new": "]"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, ok := repairJSON(tt.input)
+			require.True(t, ok)
+			require.Equal(t, tt.want, got)
+		})
+	}
+
+	for _, input := range []string{`{"a": 1]`, `[1, 2]`, `{"a": "x", "b`, `not json`} {
+		_, ok := repairJSON(input)
+		require.False(t, ok, input)
+	}
+}
@@ -7,16 +7,21 @@ import (
 	_ "embed"
 	"fmt"
 	"html/template"
+	"maps"
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
 	"time"
 
 	"charm.land/fantasy"
 	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/filepathext"
 	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/privacy"
 	"github.com/charmbracelet/crush/internal/shell"
+	"github.com/charmbracelet/x/ansi"
 )
 
 type BashParams struct {
@@ -49,6 +54,10 @@ const (
 	AutoBackgroundThreshold = 1 * time.Minute // Commands taking longer automatically become background jobs
 	MaxOutputLength         = 30000
 	BashNoOutput            = "no output"
+
+	// DefaultShellProfile is the name of the shell profile used by sessions
+	// that haven't picked one.
+	DefaultShellProfile = "default"
 )
 
 //go:embed bash.tpl
@@ -60,10 +69,15 @@ var bashDescriptionTpl = template.Must(
 )
 
 type bashDescriptionData struct {
-	BannedCommands  string
-	MaxOutputLength int
-	Attribution     config.Attribution
-	ModelName       string
+	BannedCommands     string
+	MaxOutputLength    int
+	Attribution        config.Attribution
+	ModelName          string
+	Shell              string
+	Container          string
+	ContainerWorkspace string
+	Remote             string
+	RemoteWorkspace    string
 }
 
 var bannedCommands = []string{
@@ -139,21 +153,118 @@ var bannedCommands = []string{
 	"ufw",
 }
 
-func bashDescription(attribution *config.Attribution, modelName string) string {
+func bashDescription(attribution *config.Attribution, modelName string, shellType shell.ShellType, limits config.ToolLimits) string {
 	bannedCommandsStr := strings.Join(bannedCommands, ", ")
-	var out bytes.Buffer
-	if err := bashDescriptionTpl.Execute(&out, bashDescriptionData{
+	data := bashDescriptionData{
 		BannedCommands:  bannedCommandsStr,
-		MaxOutputLength: MaxOutputLength,
+		MaxOutputLength: cmp.Or(limits.MaxOutputBytes, MaxOutputLength),
 		Attribution:     *attribution,
 		ModelName:       modelName,
-	}); err != nil {
+		Shell:           shellType.String(),
+	}
+	switch backend := shell.GetBackgroundShellManager().Backend().(type) {
+	case *shell.Container:
+		data.Container = backend.Image()
+		data.ContainerWorkspace = backend.WorkspaceFolder()
+	case *shell.Remote:
+		data.Remote = backend.Host()
+		data.RemoteWorkspace = backend.Dir()
+	}
+	var out bytes.Buffer
+	if err := bashDescriptionTpl.Execute(&out, data); err != nil {
 		// this should never happen.
 		panic("failed to execute bash description template: " + err.Error())
 	}
 	return out.String()
 }
 
+// shellProfile returns the shell profile with the given name, or the default
+// one when the name is empty.
+func shellProfile(profiles map[string]config.ShellProfile, name string) (config.ShellProfile, error) {
+	if name == "" {
+		return profiles[DefaultShellProfile], nil
+	}
+	profile, ok := profiles[name]
+	if !ok {
+		return config.ShellProfile{}, fmt.Errorf("shell profile %q of the session is not configured", name)
+	}
+	return profile, nil
+}
+
+// profileWorkingDir returns the directory commands run in by default with the
+// profile.
+func profileWorkingDir(profile config.ShellProfile, workingDir string) string {
+	if profile.WorkingDir == "" || filepath.IsAbs(profile.WorkingDir) {
+		return cmp.Or(profile.WorkingDir, workingDir)
+	}
+	return filepath.Join(workingDir, profile.WorkingDir)
+}
+
+// shellOptions returns the options of the shell that runs a command with the
+// profile.
+func shellOptions(profile config.ShellProfile, workingDir string, shellType shell.ShellType) (shell.Options, error) {
+	opts := shell.Options{
+		WorkingDir: workingDir,
+		BlockFuncs: blockFuncs(),
+		Type:       shellType,
+	}
+	if profile.Shell != "" {
+		profileType, err := shell.ParseShellType(profile.Shell)
+		if err != nil {
+			return opts, fmt.Errorf("invalid shell of the shell profile: %w", err)
+		}
+		opts.Type = profileType
+	}
+	switch {
+	case shell.GetBackgroundShellManager().Backend() != nil:
+		// The rest of the environment, PATH included, comes from the image
+		// or the remote machine.
+		opts.Env = profileEnv(nil, config.ShellProfile{Env: profile.Env})
+	case len(profile.Env) > 0 || len(profile.Path) > 0:
+		opts.Env = profileEnv(os.Environ(), profile)
+	}
+	return opts, nil
+}
+
+// profileEnv returns the environment with the variables of the profile set,
+// and its directories put in front of PATH.
+func profileEnv(base []string, profile config.ShellProfile) []string {
+	env := slices.Clone(base)
+	// Variable names are case-insensitive on Windows, where PATH is Path.
+	lookup := func(key string) int {
+		for i, kv := range env {
+			name, _, _ := strings.Cut(kv, "=")
+			if name == key || runtime.GOOS == "windows" && strings.EqualFold(name, key) {
+				return i
+			}
+		}
+		return -1
+	}
+	set := func(key, value string) {
+		if i := lookup(key); i >= 0 {
+			env[i] = key + "=" + value
+			return
+		}
+		env = append(env, key+"="+value)
+	}
+	for _, key := range slices.Sorted(maps.Keys(profile.Env)) {
+		set(key, os.ExpandEnv(profile.Env[key]))
+	}
+	if len(profile.Path) > 0 {
+		dirs := make([]string, 0, len(profile.Path)+1)
+		for _, dir := range profile.Path {
+			dirs = append(dirs, os.ExpandEnv(dir))
+		}
+		if i := lookup("PATH"); i >= 0 {
+			if _, path, _ := strings.Cut(env[i], "="); path != "" {
+				dirs = append(dirs, path)
+			}
+		}
+		set("PATH", strings.Join(dirs, string(os.PathListSeparator)))
+	}
+	return env
+}
+
 func blockFuncs() []shell.BlockFunc {
 	return []shell.BlockFunc{
 		shell.CommandsBlocker(bannedCommands),
@@ -186,17 +297,34 @@ func blockFuncs() []shell.BlockFunc {
 	}
 }
 
-func NewBashTool(permissions permission.Service, workingDir string, attribution *config.Attribution, modelName string) fantasy.AgentTool {
+func NewBashTool(permissions permission.Service, zones *privacy.Zones, workingDir string, attribution *config.Attribution, modelName string, shellType shell.ShellType, limits config.ToolLimits, profiles map[string]config.ShellProfile) fantasy.AgentTool {
+	shell.GetBackgroundShellManager().SetShellType(shellType)
 	return fantasy.NewAgentTool(
 		BashToolName,
-		string(bashDescription(attribution, modelName)),
+		string(bashDescription(attribution, modelName, shellType, limits)),
 		func(ctx context.Context, params BashParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			workingDir := GetWorkingDirFromContext(ctx, workingDir)
 			if params.Command == "" {
 				return fantasy.NewTextErrorResponse("missing command"), nil
 			}
 
+			profile, err := shellProfile(profiles, GetShellProfileFromContext(ctx))
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+
 			// Determine working directory
-			execWorkingDir := cmp.Or(params.WorkingDir, workingDir)
+			execWorkingDir := filepathext.FromPortable(cmp.Or(params.WorkingDir, profileWorkingDir(profile, workingDir)))
+			shellOpts, err := shellOptions(profile, execWorkingDir, shellType)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+
+			if path, ok := commandZonePath(zones, execWorkingDir, params.Command); ok {
+				if resp, denied := privacyZoneDenied(ctx, permissions, zones, call, BashToolName, "read", path, BashPermissionsParams(params)); denied {
+					return resp, nil
+				}
+			}
 
 			isSafeReadOnly := false
 			cmdLower := strings.ToLower(params.Command)
@@ -237,7 +365,7 @@ func NewBashTool(permissions permission.Service, workingDir string, attribution
 				bgManager := shell.GetBackgroundShellManager()
 				bgManager.Cleanup()
 				// Use background context so it continues after tool returns
-				bgShell, err := bgManager.Start(context.Background(), execWorkingDir, blockFuncs(), params.Command, params.Description)
+				bgShell, err := bgManager.StartWith(context.Background(), shellOpts, params.Command, params.Description)
 				if err != nil {
 					return fantasy.ToolResponse{}, fmt.Errorf("error starting background shell: %w", err)
 				}
@@ -256,7 +384,7 @@ func NewBashTool(permissions permission.Service, workingDir string, attribution
 						return fantasy.ToolResponse{}, fmt.Errorf("[Job %s] error executing command: %w", bgShell.ID, execErr)
 					}
 
-					stdout = formatOutput(stdout, stderr, execErr)
+					stdout = formatOutput(stdout, stderr, execErr, limits)
 
 					metadata := BashResponseMetadata{
 						StartTime:        startTime.UnixMilli(),
@@ -269,8 +397,8 @@ func NewBashTool(permissions permission.Service, workingDir string, attribution
 					if stdout == "" {
 						return fantasy.WithResponseMetadata(fantasy.NewTextResponse(BashNoOutput), metadata), nil
 					}
-					stdout += fmt.Sprintf("\n\n<cwd>%s</cwd>", normalizeWorkingDir(bgShell.WorkingDir))
-					return fantasy.WithResponseMetadata(fantasy.NewTextResponse(stdout), metadata), nil
+					content := ansi.Strip(stdout) + fmt.Sprintf("\n\n<cwd>%s</cwd>", normalizeWorkingDir(bgShell.WorkingDir))
+					return fantasy.WithResponseMetadata(fantasy.NewTextResponse(content), metadata), nil
 				}
 
 				// Still running after fast-failure check - return as background job
@@ -292,7 +420,7 @@ func NewBashTool(permissions permission.Service, workingDir string, attribution
 			// Start with detached context so it can survive if moved to background
 			bgManager := shell.GetBackgroundShellManager()
 			bgManager.Cleanup()
-			bgShell, err := bgManager.Start(context.Background(), execWorkingDir, blockFuncs(), params.Command, params.Description)
+			bgShell, err := bgManager.StartWith(context.Background(), shellOpts, params.Command, params.Description)
 			if err != nil {
 				return fantasy.ToolResponse{}, fmt.Errorf("error starting shell: %w", err)
 			}
@@ -305,6 +433,7 @@ func NewBashTool(permissions permission.Service, workingDir string, attribution
 			var stdout, stderr string
 			var done bool
 			var execErr error
+			publisher := outputPublisher{toolCallID: call.ID}
 
 		waitLoop:
 			for {
@@ -314,6 +443,7 @@ func NewBashTool(permissions permission.Service, workingDir string, attribution
 					if done {
 						break waitLoop
 					}
+					publisher.publish(stdout, stderr)
 				case <-timeout:
 					stdout, stderr, done, execErr = bgShell.GetOutput()
 					break waitLoop
@@ -337,7 +467,7 @@ func NewBashTool(permissions permission.Service, workingDir string, attribution
 					return fantasy.ToolResponse{}, fmt.Errorf("[Job %s] error executing command: %w", bgShell.ID, execErr)
 				}
 
-				stdout = formatOutput(stdout, stderr, execErr)
+				stdout = formatOutput(stdout, stderr, execErr, limits)
 
 				metadata := BashResponseMetadata{
 					StartTime:        startTime.UnixMilli(),
@@ -350,8 +480,8 @@ func NewBashTool(permissions permission.Service, workingDir string, attribution
 				if stdout == "" {
 					return fantasy.WithResponseMetadata(fantasy.NewTextResponse(BashNoOutput), metadata), nil
 				}
-				stdout += fmt.Sprintf("\n\n<cwd>%s</cwd>", normalizeWorkingDir(bgShell.WorkingDir))
-				return fantasy.WithResponseMetadata(fantasy.NewTextResponse(stdout), metadata), nil
+				content := ansi.Strip(stdout) + fmt.Sprintf("\n\n<cwd>%s</cwd>", normalizeWorkingDir(bgShell.WorkingDir))
+				return fantasy.WithResponseMetadata(fantasy.NewTextResponse(content), metadata), nil
 			}
 
 			// Still running - keep as background job
@@ -369,12 +499,13 @@ func NewBashTool(permissions permission.Service, workingDir string, attribution
 }
 
 // formatOutput formats the output of a completed command with error handling
-func formatOutput(stdout, stderr string, execErr error) string {
+func formatOutput(stdout, stderr string, execErr error, limits config.ToolLimits) string {
 	interrupted := shell.IsInterrupt(execErr)
 	exitCode := shell.ExitCode(execErr)
 
-	stdout = truncateOutput(stdout)
-	stderr = truncateOutput(stderr)
+	maxBytes := cmp.Or(limits.MaxOutputBytes, MaxOutputLength)
+	stdout = truncate(stdout, maxBytes, limits.Truncate)
+	stderr = truncate(stderr, maxBytes, limits.Truncate)
 
 	errorMessage := stderr
 	if errorMessage == "" && execErr != nil {
@@ -406,19 +537,6 @@ func formatOutput(stdout, stderr string, execErr error) string {
 	return stdout
 }
 
-func truncateOutput(content string) string {
-	if len(content) <= MaxOutputLength {
-		return content
-	}
-
-	halfLength := MaxOutputLength / 2
-	start := content[:halfLength]
-	end := content[len(content)-halfLength:]
-
-	truncatedLinesCount := countLines(content[halfLength : len(content)-halfLength])
-	return fmt.Sprintf("%s\n\n... [%d lines truncated] ...\n\n%s", start, truncatedLinesCount, end)
-}
-
 func countLines(s string) int {
 	if s == "" {
 		return 0
@@ -1,9 +1,29 @@
 Executes bash commands with automatic background conversion for long-running tasks.
 
 <cross_platform>
+{{- if .Container }}
+Commands run with sh in a container of the {{ .Container }} image, with the working directory mounted at {{ .ContainerWorkspace }}.
+Only files under the working directory are shared with the host; programs and everything else come from the image.
+Each command runs in a fresh shell: directory changes and variables do not persist.
+{{- else if .Remote }}
+Commands run with sh over SSH on {{ .Remote }}, in the matching directory under {{ .RemoteWorkspace }}.
+Programs and everything outside of the working directory come from the remote machine.
+Each command runs in a fresh shell: directory changes and variables do not persist.
+{{- else if eq .Shell "powershell" }}
+Commands run natively in PowerShell (pwsh when installed, Windows PowerShell otherwise).
+Use PowerShell syntax and cmdlets: "Get-ChildItem", "Get-Content", "Select-String", "$env:NAME".
+Use Windows paths: "C:\foo\bar". Chain commands with ";" and quote paths containing spaces.
+Each command runs in a fresh PowerShell process: directory changes and variables do not persist.
+{{- else if eq .Shell "cmd" }}
+Commands run natively in cmd.exe.
+Use cmd syntax and builtins: "dir", "type", "findstr", "%NAME%".
+Use Windows paths: "C:\foo\bar". Chain commands with "&&" and quote paths containing spaces.
+Each command runs in a fresh cmd.exe process: directory changes and variables do not persist.
+{{- else }}
 Uses mvdan/sh interpreter (Bash-compatible on all platforms including Windows).
 Use forward slashes for paths: "ls C:/foo/bar" not "ls C:\foo\bar".
 Common shell builtins and core utils available on Windows.
+{{- end }}
 </cross_platform>
 
 <execution_steps>
@@ -0,0 +1,57 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShellProfile(t *testing.T) {
+	t.Parallel()
+
+	profiles := map[string]config.ShellProfile{
+		DefaultShellProfile: {WorkingDir: "backend"},
+		"llvm":              {Shell: "posix"},
+	}
+	profile, err := shellProfile(profiles, "")
+	require.NoError(t, err)
+	require.Equal(t, "backend", profile.WorkingDir)
+
+	profile, err = shellProfile(profiles, "llvm")
+	require.NoError(t, err)
+	require.Equal(t, "posix", profile.Shell)
+
+	_, err = shellProfile(profiles, "removed")
+	require.Error(t, err)
+
+	// Without a default profile, sessions run commands as they always did.
+	profile, err = shellProfile(nil, "")
+	require.NoError(t, err)
+	require.Equal(t, config.ShellProfile{}, profile)
+
+	root := t.TempDir()
+	require.Equal(t, root, profileWorkingDir(config.ShellProfile{}, root))
+	require.Equal(t, filepath.Join(root, "backend"), profileWorkingDir(config.ShellProfile{WorkingDir: "backend"}, root))
+}
+
+func TestProfileEnv(t *testing.T) {
+	t.Setenv("CRUSH_TEST_TOOLCHAIN", "/opt/toolchain")
+
+	sep := string(os.PathListSeparator)
+	env := profileEnv([]string{"HOME=/home/crush", "PATH=/usr/bin", "CC=gcc"}, config.ShellProfile{
+		Env:  map[string]string{"CC": "clang", "TOOLCHAIN": "$CRUSH_TEST_TOOLCHAIN"},
+		Path: []string{"$CRUSH_TEST_TOOLCHAIN/bin", "/opt/tools"},
+	})
+	require.Equal(t, []string{
+		"HOME=/home/crush",
+		"PATH=/opt/toolchain/bin" + sep + "/opt/tools" + sep + "/usr/bin",
+		"CC=clang",
+		"TOOLCHAIN=/opt/toolchain",
+	}, env)
+
+	env = profileEnv(nil, config.ShellProfile{Path: []string{"/opt/tools"}})
+	require.Equal(t, []string{"PATH=/opt/tools"}, env)
+}
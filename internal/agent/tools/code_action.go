@@ -0,0 +1,122 @@
+package tools
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/filepathext"
+	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/lsp"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+)
+
+type CodeActionParams struct {
+	FilePath string `json:"file_path" description:"The path to the file"`
+	Line     int    `json:"line" description:"The 1-based line to get the code actions of"`
+	EndLine  int    `json:"end_line,omitempty" description:"The 1-based last line of the selection, to get the code actions of several lines (defaults to line)"`
+	Title    string `json:"title,omitempty" description:"The exact title of the code action to apply. Leave empty to list the available code actions"`
+}
+
+const CodeActionToolName = "lsp_code_action"
+
+//go:embed code_action.md
+var codeActionDescription []byte
+
+func NewCodeActionTool(lspClients *csync.Map[string, *lsp.Client], permissions permission.Service, files history.Service, workingDir string) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		CodeActionToolName,
+		string(codeActionDescription),
+		func(ctx context.Context, params CodeActionParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			workingDir := GetWorkingDirFromContext(ctx, workingDir)
+			if params.FilePath == "" {
+				return fantasy.NewTextErrorResponse("file_path is required"), nil
+			}
+			if params.Line < 1 {
+				return fantasy.NewTextErrorResponse("line must be 1 or more"), nil
+			}
+			params.EndLine = max(params.EndLine, params.Line)
+
+			params.FilePath = filepathext.SmartJoin(workingDir, params.FilePath)
+			client := lspClientForFile(lspClients, params.FilePath)
+			if client == nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("no LSP client handles %s", params.FilePath)), nil
+			}
+
+			actions, err := client.CodeActions(ctx, params.FilePath, params.Line, params.EndLine)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("failed to get code actions: %s", err)), nil
+			}
+			if len(actions) == 0 {
+				return fantasy.NewTextResponse("No code actions available for these lines"), nil
+			}
+			if params.Title == "" {
+				return fantasy.NewTextResponse(formatCodeActions(actions)), nil
+			}
+
+			var action *protocol.CodeAction
+			for i := range actions {
+				if actions[i].Title == params.Title {
+					action = &actions[i]
+					break
+				}
+			}
+			if action == nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("no code action titled '%s'.\n\n%s", params.Title, formatCodeActions(actions))), nil
+			}
+			if action.Disabled != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("the code action is disabled: %s", action.Disabled.Reason)), nil
+			}
+			resolved, err := client.ResolveCodeAction(ctx, *action)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("failed to resolve the code action: %s", err)), nil
+			}
+			if resolved.Edit == nil {
+				return fantasy.NewTextErrorResponse("the code action runs a command on the language server instead of returning edits, so it can't be applied"), nil
+			}
+			changed, err := workspaceEditFiles(*resolved.Edit)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+			if len(changed) == 0 {
+				return fantasy.NewTextErrorResponse("the code action changes no files"), nil
+			}
+
+			editCtx := editContext{ctx, permissions, files, workingDir}
+			if err := applyWorkspaceEdit(editCtx, lspClients, call, CodeActionToolName, params.Title, changed); err != nil {
+				return fantasy.ToolResponse{}, err
+			}
+
+			return fantasy.WithResponseMetadata(
+				fantasy.NewTextResponse(formatWorkspaceEdit("Applied "+params.Title, changed, lspClients)),
+				WorkspaceEditResponseMetadata{Files: changed},
+			), nil
+		})
+}
+
+func formatCodeActions(actions []protocol.CodeAction) string {
+	var out strings.Builder
+	fmt.Fprintf(&out, "%d code action(s) available:\n", len(actions))
+	for _, a := range actions {
+		out.WriteString("- " + a.Title)
+		var notes []string
+		if a.Kind != "" {
+			notes = append(notes, string(a.Kind))
+		}
+		if a.IsPreferred {
+			notes = append(notes, "preferred")
+		}
+		if a.Disabled != nil {
+			notes = append(notes, "disabled: "+a.Disabled.Reason)
+		}
+		if len(notes) > 0 {
+			out.WriteString(" (" + strings.Join(notes, ", ") + ")")
+		}
+		out.WriteString("\n")
+	}
+	return out.String()
+}
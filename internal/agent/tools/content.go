@@ -0,0 +1,53 @@
+package tools
+
+// Content types tools declare in the content_type field of the metadata of
+// their responses, so the results are displayed for what they are rather
+// than as plain text. Plugins can declare them too, and render their own.
+const (
+	// ContentTypeJSON is for results whose content is JSON.
+	ContentTypeJSON = "application/json"
+	// ContentTypeTable is for results whose metadata is a TableMetadata.
+	ContentTypeTable = "application/vnd.crush.table+json"
+	// ContentTypeTestResults is for results whose metadata is a
+	// TestResultsMetadata.
+	ContentTypeTestResults = "application/vnd.crush.test-results+json"
+)
+
+// Statuses of a test in a TestResultsMetadata.
+const (
+	TestPassed  = "pass"
+	TestFailed  = "fail"
+	TestSkipped = "skip"
+)
+
+// ContentMetadata is the part of the metadata of a response that declares
+// its content type.
+type ContentMetadata struct {
+	ContentType string `json:"content_type,omitempty"`
+}
+
+// TableMetadata is the metadata of a result that's a table.
+type TableMetadata struct {
+	ContentType string     `json:"content_type"`
+	Columns     []string   `json:"columns"`
+	Rows        [][]string `json:"rows"`
+	// Truncated is set when there were more rows than the ones given.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// TestResultsMetadata is the metadata of a result that's the outcome of
+// running tests.
+type TestResultsMetadata struct {
+	ContentType string       `json:"content_type"`
+	Tests       []TestResult `json:"tests"`
+}
+
+// TestResult is the outcome of a test.
+type TestResult struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	// Duration is in seconds.
+	Duration float64 `json:"duration,omitempty"`
+	// Message is why the test failed or was skipped.
+	Message string `json:"message,omitempty"`
+}
@@ -19,6 +19,7 @@ import (
 
 	"github.com/charmbracelet/crush/internal/lsp"
 	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/privacy"
 )
 
 type EditParams struct {
@@ -26,6 +27,8 @@ type EditParams struct {
 	OldString  string `json:"old_string" description:"The text to replace"`
 	NewString  string `json:"new_string" description:"The text to replace it with"`
 	ReplaceAll bool   `json:"replace_all,omitempty" description:"Replace all occurrences of old_string (default false)"`
+	CellID     string `json:"cell_id,omitempty" description:"For Jupyter notebooks: the ID, or index, of the cell to edit, or to insert a new cell after"`
+	CellType   string `json:"cell_type,omitempty" description:"For Jupyter notebooks: the type of a new cell, code (default), markdown or raw"`
 }
 
 type EditPermissionsParams struct {
@@ -53,23 +56,37 @@ type editContext struct {
 	workingDir  string
 }
 
-func NewEditTool(lspClients *csync.Map[string, *lsp.Client], permissions permission.Service, files history.Service, workingDir string) fantasy.AgentTool {
+func NewEditTool(lspClients *csync.Map[string, *lsp.Client], permissions permission.Service, files history.Service, zones *privacy.Zones, workingDir string) fantasy.AgentTool {
 	return fantasy.NewAgentTool(
 		EditToolName,
 		string(editDescription),
 		func(ctx context.Context, params EditParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			workingDir := GetWorkingDirFromContext(ctx, workingDir)
 			if params.FilePath == "" {
 				return fantasy.NewTextErrorResponse("file_path is required"), nil
 			}
 
 			params.FilePath = filepathext.SmartJoin(workingDir, params.FilePath)
+			if resp, denied := privacyZoneDenied(ctx, permissions, zones, call, EditToolName, "write", params.FilePath, EditPermissionsParams{
+				FilePath:   params.FilePath,
+				OldContent: params.OldString,
+				NewContent: params.NewString,
+			}); denied {
+				return resp, nil
+			}
+			// File contents are matched with LF line endings, so models that
+			// echo CRLF from Windows files still match.
+			params.OldString, _ = fsext.ToUnixLineEndings(params.OldString)
+			params.NewString, _ = fsext.ToUnixLineEndings(params.NewString)
 
 			var response fantasy.ToolResponse
 			var err error
 
 			editCtx := editContext{ctx, permissions, files, workingDir}
 
-			if params.OldString == "" {
+			if isNotebook(params.FilePath) {
+				response, err = editNotebook(editCtx, params, call)
+			} else if params.OldString == "" {
 				response, err = createNewFile(editCtx, params.FilePath, params.NewString, call)
 			} else if params.NewString == "" {
 				response, err = deleteContent(editCtx, params.FilePath, params.OldString, params.ReplaceAll, call)
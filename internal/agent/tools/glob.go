@@ -13,6 +13,7 @@ import (
 
 	"charm.land/fantasy"
 	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/privacy"
 )
 
 const GlobToolName = "glob"
@@ -30,11 +31,12 @@ type GlobResponseMetadata struct {
 	Truncated     bool `json:"truncated"`
 }
 
-func NewGlobTool(workingDir string) fantasy.AgentTool {
+func NewGlobTool(workingDir string, zones *privacy.Zones) fantasy.AgentTool {
 	return fantasy.NewAgentTool(
 		GlobToolName,
 		string(globDescription),
 		func(ctx context.Context, params GlobParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			workingDir := GetWorkingDirFromContext(ctx, workingDir)
 			if params.Pattern == "" {
 				return fantasy.NewTextErrorResponse("pattern is required"), nil
 			}
@@ -48,6 +50,7 @@ func NewGlobTool(workingDir string) fantasy.AgentTool {
 			if err != nil {
 				return fantasy.ToolResponse{}, fmt.Errorf("error finding files: %w", err)
 			}
+			files, withheld := zones.Filter(files)
 
 			var output string
 			if len(files) == 0 {
@@ -59,6 +62,9 @@ func NewGlobTool(workingDir string) fantasy.AgentTool {
 					output += "\n\n(Results are truncated. Consider using a more specific path or pattern.)"
 				}
 			}
+			if withheld > 0 {
+				output += fmt.Sprintf("\n\n(%d files in privacy zones were withheld.)", withheld)
+			}
 
 			return fantasy.WithResponseMetadata(
 				fantasy.NewTextResponse(output),
@@ -71,9 +77,8 @@ func NewGlobTool(workingDir string) fantasy.AgentTool {
 }
 
 func globFiles(ctx context.Context, pattern, searchPath string, limit int) ([]string, bool, error) {
-	cmdRg := getRgCmd(ctx, pattern)
+	cmdRg := getRgCmd(ctx, searchPath, pattern)
 	if cmdRg != nil {
-		cmdRg.Dir = searchPath
 		matches, err := runRipgrep(cmdRg, searchPath, limit)
 		if err == nil {
 			return matches, len(matches) >= limit && limit > 0, nil
@@ -0,0 +1,68 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/glossary"
+)
+
+// glossaryTool checks the lines it writes for the terms the glossary of the
+// project says to avoid.
+type glossaryTool struct {
+	fantasy.AgentTool
+	glossary   *config.Glossary
+	workingDir string
+}
+
+// WithGlossary wraps the tools writing files, so the uses of the terms to
+// avoid on the lines they add are reported in their results, for the agent
+// to rename them.
+func WithGlossary(tool fantasy.AgentTool, g *config.Glossary, workingDir string) fantasy.AgentTool {
+	if g == nil || len(g.Terms) == 0 && len(g.Banned) == 0 {
+		return tool
+	}
+	switch tool.Info().Name {
+	case EditToolName, MultiEditToolName, WriteToolName:
+		return &glossaryTool{AgentTool: tool, glossary: g, workingDir: workingDir}
+	}
+	return tool
+}
+
+func (t *glossaryTool) Run(ctx context.Context, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	var params struct {
+		FilePath string `json:"file_path"`
+	}
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil || params.FilePath == "" {
+		return t.AgentTool.Run(ctx, call)
+	}
+	path := params.FilePath
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(GetWorkingDirFromContext(ctx, t.workingDir), path)
+	}
+	before, _ := os.ReadFile(path)
+
+	resp, err := t.AgentTool.Run(ctx, call)
+	if err != nil || resp.IsError || resp.Type != "text" {
+		return resp, err
+	}
+	after, err := os.ReadFile(path)
+	if err != nil {
+		return resp, nil
+	}
+	violations := glossary.Check(t.glossary, string(before), string(after))
+	if len(violations) == 0 {
+		return resp, nil
+	}
+	var b strings.Builder
+	for _, v := range violations {
+		b.WriteString(v.String() + "\n")
+	}
+	resp.Content += "\n\n<glossary>\n" + b.String() + "Rename them, unless they're required, like names of external APIs.\n</glossary>"
+	return resp, nil
+}
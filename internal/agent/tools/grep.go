@@ -20,6 +20,7 @@ import (
 
 	"charm.land/fantasy"
 	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/privacy"
 )
 
 // regexCache provides thread-safe caching of compiled regex patterns
@@ -112,11 +113,12 @@ func escapeRegexPattern(pattern string) string {
 	return escaped
 }
 
-func NewGrepTool(workingDir string) fantasy.AgentTool {
+func NewGrepTool(workingDir string, zones *privacy.Zones) fantasy.AgentTool {
 	return fantasy.NewAgentTool(
 		GrepToolName,
 		string(grepDescription),
 		func(ctx context.Context, params GrepParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			workingDir := GetWorkingDirFromContext(ctx, workingDir)
 			if params.Pattern == "" {
 				return fantasy.NewTextErrorResponse("pattern is required"), nil
 			}
@@ -136,6 +138,7 @@ func NewGrepTool(workingDir string) fantasy.AgentTool {
 			if err != nil {
 				return fantasy.NewTextErrorResponse(fmt.Sprintf("error searching files: %v", err)), nil
 			}
+			matches, withheld := withholdPrivateMatches(zones, matches)
 
 			var output strings.Builder
 			if len(matches) == 0 {
@@ -171,6 +174,9 @@ func NewGrepTool(workingDir string) fantasy.AgentTool {
 					output.WriteString("\n(Results are truncated. Consider using a more specific path or pattern.)")
 				}
 			}
+			if withheld > 0 {
+				fmt.Fprintf(&output, "\n(%d matches in privacy zones were withheld.)", withheld)
+			}
 
 			return fantasy.WithResponseMetadata(
 				fantasy.NewTextResponse(output.String()),
@@ -204,19 +210,15 @@ func searchFiles(ctx context.Context, pattern, rootPath, include string, limit i
 }
 
 func searchWithRipgrep(ctx context.Context, pattern, path, include string) ([]grepMatch, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
 	cmd := getRgSearchCmd(ctx, pattern, path, include)
 	if cmd == nil {
 		return nil, fmt.Errorf("ripgrep not found in $PATH")
 	}
 
-	// Only add ignore files if they exist
-	for _, ignoreFile := range []string{".gitignore", ".crushignore"} {
-		ignorePath := filepath.Join(path, ignoreFile)
-		if _, err := os.Stat(ignorePath); err == nil {
-			cmd.Args = append(cmd.Args, "--ignore-file", ignorePath)
-		}
-	}
-
 	output, err := cmd.Output()
 	if err != nil {
 		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
@@ -237,13 +239,15 @@ func searchWithRipgrep(ctx context.Context, pattern, path, include string) ([]gr
 		if match.Type != "match" {
 			continue
 		}
+		// Paths are relative to the directory rg runs in.
+		matchPath := filepath.Join(cmd.Dir, match.Data.Path.Text)
 		for _, m := range match.Data.Submatches {
-			fi, err := os.Stat(match.Data.Path.Text)
+			fi, err := os.Stat(matchPath)
 			if err != nil {
 				continue // Skip files we can't access
 			}
 			matches = append(matches, grepMatch{
-				path:     match.Data.Path.Text,
+				path:     matchPath,
 				modTime:  fi.ModTime(),
 				lineNum:  match.Data.LineNumber,
 				charNum:  m.Start + 1, // ensure 1-based
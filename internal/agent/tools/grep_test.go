@@ -6,6 +6,7 @@ import (
 	"regexp"
 	"testing"
 
+	"github.com/charmbracelet/crush/internal/privacy"
 	"github.com/stretchr/testify/require"
 )
 
@@ -419,3 +420,20 @@ func TestColumnMatch(t *testing.T) {
 		})
 	}
 }
+
+func TestWithholdPrivateMatches(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	zones := privacy.NewZones(dir, []string{".env*", "secrets/"})
+	matches := []grepMatch{
+		{path: filepath.Join(dir, "main.go")},
+		{path: filepath.Join(dir, ".env")},
+		{path: filepath.Join(dir, "secrets", "token.txt")},
+	}
+
+	kept, withheld := withholdPrivateMatches(zones, matches)
+	require.Equal(t, 2, withheld)
+	require.Len(t, kept, 1)
+	require.Equal(t, filepath.Join(dir, "main.go"), kept[0].path)
+}
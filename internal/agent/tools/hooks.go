@@ -0,0 +1,84 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"regexp"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/hooks"
+)
+
+// gitCommitPattern matches commands running git commit, with the global
+// options of git in between, like git -C dir commit.
+var gitCommitPattern = regexp.MustCompile(`\bgit(\s+-{1,2}\S+(\s+[^-\s]\S*)?)*\s+commit\b`)
+
+// hookedTool runs the hooks of an event before the tool.
+type hookedTool struct {
+	fantasy.AgentTool
+	event      hooks.Event
+	hooks      []config.Hook
+	workingDir string
+}
+
+// WithHooks wraps the tools editing files so the before_edit hooks run
+// before them, and the bash tool so the pre_commit hooks run before it
+// commits. A failing hook refuses the call, and tells the model why.
+func WithHooks(tool fantasy.AgentTool, cfg config.Hooks, workingDir string) fantasy.AgentTool {
+	switch tool.Info().Name {
+	case EditToolName, MultiEditToolName, WriteToolName:
+		if len(cfg.BeforeEdit) > 0 {
+			return &hookedTool{AgentTool: tool, event: hooks.BeforeEdit, hooks: cfg.BeforeEdit, workingDir: workingDir}
+		}
+	case BashToolName:
+		if len(cfg.PreCommit) > 0 {
+			return &hookedTool{AgentTool: tool, event: hooks.PreCommit, hooks: cfg.PreCommit, workingDir: workingDir}
+		}
+	}
+	return tool
+}
+
+func (t *hookedTool) Run(ctx context.Context, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	var params struct {
+		FilePath string `json:"file_path"`
+		Command  string `json:"command"`
+	}
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return t.AgentTool.Run(ctx, call)
+	}
+
+	dir := GetWorkingDirFromContext(ctx, t.workingDir)
+	env := map[string]string{
+		"CRUSH_SESSION_ID": GetSessionFromContext(ctx),
+		"CRUSH_TOOL":       t.Info().Name,
+	}
+	switch t.event {
+	case hooks.BeforeEdit:
+		if params.FilePath == "" {
+			return t.AgentTool.Run(ctx, call)
+		}
+		path := params.FilePath
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		env["CRUSH_FILE"] = path
+	case hooks.PreCommit:
+		if !gitCommitPattern.MatchString(params.Command) {
+			return t.AgentTool.Run(ctx, call)
+		}
+		env["CRUSH_COMMAND"] = params.Command
+	}
+
+	outcome := hooks.Run(ctx, t.event, t.hooks, dir, env)
+	if outcome.Blocked {
+		return fantasy.NewTextErrorResponse(outcome.Reason(t.event) + "\n\nThe call was refused. Address the failure before trying again."), nil
+	}
+	resp, err := t.AgentTool.Run(ctx, call)
+	if err != nil || resp.IsError || resp.Type != "text" || outcome.Output == "" {
+		return resp, err
+	}
+	resp.Content += "\n\n<hook_output event=\"" + string(t.event) + "\">\n" + outcome.Output + "\n</hook_output>"
+	return resp, nil
+}
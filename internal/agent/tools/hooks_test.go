@@ -0,0 +1,28 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitCommitPattern(t *testing.T) {
+	t.Parallel()
+
+	for _, command := range []string{
+		"git commit -m 'Fix it'",
+		"git add . && git commit -am wip",
+		"git -C sub commit",
+		"git -c user.name=me --no-pager commit --amend",
+	} {
+		require.True(t, gitCommitPattern.MatchString(command), command)
+	}
+	for _, command := range []string{
+		"git status",
+		"git log --grep commit",
+		"echo commit",
+		"legit commit",
+	} {
+		require.False(t, gitCommitPattern.MatchString(command), command)
+	}
+}
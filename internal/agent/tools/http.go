@@ -0,0 +1,346 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/permission"
+)
+
+type HTTPParams struct {
+	Method  string            `json:"method,omitempty" description:"The HTTP method (default: GET)"`
+	URL     string            `json:"url" description:"The URL to send the request to"`
+	Query   map[string]string `json:"query,omitempty" description:"Query parameters to add to the URL"`
+	Headers map[string]string `json:"headers,omitempty" description:"Headers of the request"`
+	Body    string            `json:"body,omitempty" description:"The raw body of the request"`
+	JSON    any               `json:"json,omitempty" description:"A JSON body for the request, sent with Content-Type: application/json. Use instead of body"`
+	Select  string            `json:"select,omitempty" description:"A dot path into a JSON response to return only that part, like data.items.0.name"`
+	Timeout int               `json:"timeout,omitempty" description:"Optional timeout in seconds (max 120)"`
+}
+
+type HTTPPermissionsParams struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+}
+
+type HTTPResponseMetadata struct {
+	Method      string `json:"method"`
+	URL         string `json:"url"`
+	StatusCode  int    `json:"status_code"`
+	ContentType string `json:"content_type,omitempty"`
+	Truncated   bool   `json:"truncated"`
+}
+
+const HTTPToolName = "http"
+
+// httpResponseHeaders are the response headers shown to the model, as they
+// help deciding what to do next.
+var httpResponseHeaders = []string{"Content-Type", "Location", "Retry-After", "Link"}
+
+//go:embed http.md
+var httpDescription []byte
+
+func NewHTTPTool(permissions permission.Service, workingDir string, cfg config.ToolHTTP, localOnly bool, client *http.Client) fantasy.AgentTool {
+	if client == nil {
+		client = &http.Client{
+			Timeout: 60 * time.Second,
+			Transport: &http.Transport{
+				MaxIdleConns:        100,
+				MaxIdleConnsPerHost: 10,
+				IdleConnTimeout:     90 * time.Second,
+			},
+		}
+	}
+	allowed := func(u *url.URL) error {
+		if localOnly && !config.IsLocalEndpoint(u.String()) {
+			return fmt.Errorf("%s is not a local address, and local-only mode is enabled", u.Host)
+		}
+		if len(cfg.AllowedDomains) > 0 && !httpDomainAllowed(cfg.AllowedDomains, u.Hostname()) {
+			return fmt.Errorf("%s is not in the allowed domains: %s", u.Hostname(), strings.Join(cfg.AllowedDomains, ", "))
+		}
+		return nil
+	}
+	// Follow redirects to allowed hosts only, with the headers of the host
+	// redirected to rather than the ones of the original one.
+	redirectingClient := *client
+	redirectingClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		if err := allowed(req.URL); err != nil {
+			return fmt.Errorf("redirect refused: %w", err)
+		}
+		for name := range httpSecretHeaders(cfg.Headers, via[0].URL) {
+			req.Header.Del(name)
+		}
+		for name, value := range httpSecretHeaders(cfg.Headers, req.URL) {
+			req.Header.Set(name, value)
+		}
+		return nil
+	}
+
+	return fantasy.NewParallelAgentTool(
+		HTTPToolName,
+		string(httpDescription),
+		func(ctx context.Context, params HTTPParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			method := strings.ToUpper(params.Method)
+			if method == "" {
+				method = http.MethodGet
+			}
+			u, err := url.Parse(params.URL)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fantasy.NewTextErrorResponse("url must be an absolute http:// or https:// URL"), nil
+			}
+			if err := allowed(u); err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+			if len(params.Query) > 0 {
+				q := u.Query()
+				for key, value := range params.Query {
+					q.Set(key, value)
+				}
+				u.RawQuery = q.Encode()
+			}
+
+			body := params.Body
+			headers := maps.Clone(params.Headers)
+			if params.JSON != nil {
+				if body != "" {
+					return fantasy.NewTextErrorResponse("set either body or json, not both"), nil
+				}
+				data, err := json.Marshal(params.JSON)
+				if err != nil {
+					return fantasy.NewTextErrorResponse(fmt.Sprintf("invalid json body: %v", err)), nil
+				}
+				body = string(data)
+				if headers == nil {
+					headers = map[string]string{}
+				}
+				headers["Content-Type"] = "application/json"
+			}
+
+			sessionID := GetSessionFromContext(ctx)
+			if sessionID == "" {
+				return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for sending HTTP requests")
+			}
+			p := permissions.Request(
+				permission.CreatePermissionRequest{
+					SessionID:   sessionID,
+					Path:        workingDir,
+					ToolCallID:  call.ID,
+					ToolName:    HTTPToolName,
+					Action:      strings.ToLower(method),
+					Description: fmt.Sprintf("Send %s request to %s", method, u),
+					Params: HTTPPermissionsParams{
+						Method:  method,
+						URL:     u.String(),
+						Headers: headers,
+						Body:    body,
+					},
+				},
+			)
+			if !p {
+				return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
+			}
+
+			requestCtx := ctx
+			if params.Timeout > 0 {
+				var cancel context.CancelFunc
+				requestCtx, cancel = context.WithTimeout(ctx, time.Duration(min(params.Timeout, 120))*time.Second)
+				defer cancel()
+			}
+			req, err := http.NewRequestWithContext(requestCtx, method, u.String(), strings.NewReader(body))
+			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("invalid request: %v", err)), nil
+			}
+			for name, value := range headers {
+				req.Header.Set(name, value)
+			}
+			return sendHTTPRequest(&redirectingClient, req, params.Select, httpSecretHeaders(cfg.Headers, u)), nil
+		})
+}
+
+// sendHTTPRequest sends the request with the secret headers added, and
+// returns its response formatted for the model, without the secrets.
+func sendHTTPRequest(client *http.Client, req *http.Request, selector string, secrets map[string]string) fantasy.ToolResponse {
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", "crush/1.0")
+	}
+	for name, value := range secrets {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fantasy.NewTextErrorResponse(redactHTTPSecrets(fmt.Sprintf("request failed: %v", err), secrets))
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, MaxReadSize))
+	if err != nil {
+		return fantasy.NewTextErrorResponse("failed to read response body: " + err.Error())
+	}
+	contentType := resp.Header.Get("Content-Type")
+	content, err := formatHTTPBody(data, contentType, selector)
+	if err != nil {
+		return fantasy.NewTextErrorResponse(err.Error())
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "%s %s\n", resp.Proto, resp.Status)
+	for _, name := range httpResponseHeaders {
+		if value := resp.Header.Get(name); value != "" {
+			fmt.Fprintf(&out, "%s: %s\n", name, value)
+		}
+	}
+	out.WriteString("\n")
+	out.WriteString(content)
+
+	text := redactHTTPSecrets(out.String(), secrets)
+	truncated := len(text) > MaxOutputLength
+	text = truncate(text, MaxOutputLength, TruncateHead)
+	metadata := HTTPResponseMetadata{
+		Method:      req.Method,
+		URL:         req.URL.String(),
+		StatusCode:  resp.StatusCode,
+		ContentType: contentType,
+		Truncated:   truncated,
+	}
+	if resp.StatusCode >= 400 {
+		return fantasy.WithResponseMetadata(fantasy.NewTextErrorResponse(text), metadata)
+	}
+	return fantasy.WithResponseMetadata(fantasy.NewTextResponse(text), metadata)
+}
+
+// httpDomainAllowed reports whether the host matches one of the domains. A
+// leading *. matches subdomains only.
+func httpDomainAllowed(domains []string, host string) bool {
+	host = strings.ToLower(host)
+	for _, domain := range domains {
+		domain = strings.ToLower(domain)
+		if suffix, ok := strings.CutPrefix(domain, "*."); ok {
+			if strings.HasSuffix(host, "."+suffix) {
+				return true
+			}
+			continue
+		}
+		if host == domain {
+			return true
+		}
+	}
+	return false
+}
+
+// httpSecretHeaders returns the configured headers of the host of the URL,
+// with their environment variables expanded. They're only sent over HTTPS,
+// or to local addresses, so they don't leak in clear text.
+func httpSecretHeaders(headers map[string]map[string]string, u *url.URL) map[string]string {
+	if u.Scheme != "https" && !config.IsLocalEndpoint(u.String()) {
+		return nil
+	}
+	secrets := map[string]string{}
+	for _, domain := range slices.Sorted(maps.Keys(headers)) {
+		if !httpDomainAllowed([]string{domain}, u.Hostname()) {
+			continue
+		}
+		for name, value := range headers[domain] {
+			secrets[name] = os.ExpandEnv(value)
+		}
+	}
+	return secrets
+}
+
+// redactHTTPSecrets hides the values of the secret headers, in case the
+// response echoes them.
+func redactHTTPSecrets(content string, secrets map[string]string) string {
+	for _, value := range secrets {
+		if value != "" {
+			content = strings.ReplaceAll(content, value, "[REDACTED]")
+		}
+	}
+	return content
+}
+
+// formatHTTPBody returns the part of the body picked by the selector, and
+// pretty-prints JSON so that the model reads it easily.
+func formatHTTPBody(data []byte, contentType, selector string) (string, error) {
+	isJSON := strings.Contains(contentType, "json") || json.Valid(data) && len(bytes.TrimSpace(data)) > 0
+	if !isJSON {
+		if selector != "" {
+			return "", errors.New("select only works with JSON responses")
+		}
+		if !utf8.Valid(data) {
+			return fmt.Sprintf("[%d bytes of binary content]", len(data)), nil
+		}
+		return string(data), nil
+	}
+
+	if selector == "" {
+		var pretty bytes.Buffer
+		if err := json.Indent(&pretty, data, "", "  "); err != nil {
+			// Invalid JSON is still worth showing as is.
+			return string(data), nil
+		}
+		return pretty.String(), nil
+	}
+
+	var value any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&value); err != nil {
+		return "", fmt.Errorf("select only works with JSON responses: %w", err)
+	}
+	value, err := selectJSON(value, selector)
+	if err != nil {
+		return "", err
+	}
+	pretty, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(pretty), nil
+}
+
+// selectJSON walks the dot path into the value, with numbers indexing
+// arrays.
+func selectJSON(value any, selector string) (any, error) {
+	walked := "$"
+	for part := range strings.SplitSeq(selector, ".") {
+		switch v := value.(type) {
+		case map[string]any:
+			next, ok := v[part]
+			if !ok {
+				return nil, fmt.Errorf("no %q in %s, keys are: %s", part, walked, strings.Join(slices.Sorted(maps.Keys(v)), ", "))
+			}
+			value = next
+		case []any:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, fmt.Errorf("%s is an array of %d items, %q is not an index of it", walked, len(v), part)
+			}
+			value = v[i]
+		default:
+			return nil, fmt.Errorf("%s is not an object or array, can't select %q in it", walked, part)
+		}
+		walked += "." + part
+	}
+	return value, nil
+}
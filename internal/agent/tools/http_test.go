@@ -0,0 +1,101 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPDomainAllowed(t *testing.T) {
+	t.Parallel()
+
+	domains := []string{"api.github.com", "*.example.com"}
+	require.True(t, httpDomainAllowed(domains, "API.github.com"))
+	require.True(t, httpDomainAllowed(domains, "internal.example.com"))
+	require.False(t, httpDomainAllowed(domains, "example.com"))
+	require.False(t, httpDomainAllowed(domains, "github.com"))
+	require.False(t, httpDomainAllowed(domains, "api.github.com.evil.io"))
+}
+
+func TestHTTPSecretHeaders(t *testing.T) {
+	t.Setenv("CRUSH_TEST_TOKEN", "s3cret")
+
+	headers := map[string]map[string]string{
+		"api.github.com": {"Authorization": "Bearer $CRUSH_TEST_TOKEN"},
+	}
+	secrets := httpSecretHeaders(headers, &url.URL{Scheme: "https", Host: "api.github.com"})
+	require.Equal(t, map[string]string{"Authorization": "Bearer s3cret"}, secrets)
+
+	// Never in clear text, nor to other hosts.
+	require.Empty(t, httpSecretHeaders(headers, &url.URL{Scheme: "http", Host: "api.github.com"}))
+	require.Empty(t, httpSecretHeaders(headers, &url.URL{Scheme: "https", Host: "github.com"}))
+
+	require.Equal(t, "token: [REDACTED]", redactHTTPSecrets("token: Bearer s3cret", secrets))
+}
+
+func TestFormatHTTPBody(t *testing.T) {
+	t.Parallel()
+
+	body := []byte(`{"data":{"items":[{"name":"crush","stars":12345678901234567890}]}}`)
+	out, err := formatHTTPBody(body, "application/json", "")
+	require.NoError(t, err)
+	require.Contains(t, out, "\n  \"data\": {")
+
+	out, err = formatHTTPBody(body, "application/json", "data.items.0")
+	require.NoError(t, err)
+	require.JSONEq(t, `{"name":"crush","stars":12345678901234567890}`, out)
+	require.Contains(t, out, "12345678901234567890")
+
+	_, err = formatHTTPBody(body, "application/json", "data.repos")
+	require.ErrorContains(t, err, "keys are: items")
+	_, err = formatHTTPBody(body, "application/json", "data.items.3")
+	require.ErrorContains(t, err, "array of 1 items")
+	_, err = formatHTTPBody([]byte("<html></html>"), "text/html", "data")
+	require.Error(t, err)
+}
+
+func TestHTTPTool(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"method": r.Method,
+			"body":   string(body),
+			"type":   r.Header.Get("Content-Type"),
+		})
+	}))
+	defer server.Close()
+
+	tool := NewHTTPTool(&mockPermissionService{Broker: pubsub.NewBroker[permission.PermissionRequest]()}, t.TempDir(), config.ToolHTTP{Enabled: true}, false, server.Client())
+	ctx := context.WithValue(t.Context(), SessionIDContextKey, "session")
+
+	input, err := json.Marshal(HTTPParams{Method: "post", URL: server.URL, JSON: map[string]int{"n": 1}})
+	require.NoError(t, err)
+	resp, err := tool.Run(ctx, fantasy.ToolCall{ID: "call", Name: HTTPToolName, Input: string(input)})
+	require.NoError(t, err)
+	require.False(t, resp.IsError, resp.Content)
+	require.Contains(t, resp.Content, "200 OK")
+	require.Contains(t, resp.Content, `"method": "POST"`)
+	require.Contains(t, resp.Content, `"body": "{\"n\":1}"`)
+	require.Contains(t, resp.Content, `"type": "application/json"`)
+
+	tool = NewHTTPTool(&mockPermissionService{Broker: pubsub.NewBroker[permission.PermissionRequest]()}, t.TempDir(), config.ToolHTTP{Enabled: true, AllowedDomains: []string{"api.github.com"}}, false, server.Client())
+	input, err = json.Marshal(HTTPParams{URL: server.URL})
+	require.NoError(t, err)
+	resp, err = tool.Run(ctx, fantasy.ToolCall{ID: "call", Name: HTTPToolName, Input: string(input)})
+	require.NoError(t, err)
+	require.True(t, resp.IsError)
+	require.Contains(t, resp.Content, "not in the allowed domains")
+}
@@ -0,0 +1,74 @@
+package tools
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/instructions"
+)
+
+// instructedTool includes the instruction files of the directories of the
+// files it works on in its results.
+type instructedTool struct {
+	fantasy.AgentTool
+	names      []string
+	workingDir string
+	included   *csync.Map[string, string]
+}
+
+// WithInstructions wraps the tools working on files, so the instruction
+// files of the subdirectories of the workspace the files are in, like
+// frontend/CRUSH.md, are included in their results. Each is included once
+// per session, and again when it changes. included holds the content of
+// the ones included, keyed by session and path.
+func WithInstructions(tool fantasy.AgentTool, names []string, workingDir string, included *csync.Map[string, string]) fantasy.AgentTool {
+	if len(names) == 0 {
+		return tool
+	}
+	switch tool.Info().Name {
+	case ViewToolName, EditToolName, MultiEditToolName, WriteToolName, LSToolName:
+		return &instructedTool{AgentTool: tool, names: names, workingDir: workingDir, included: included}
+	}
+	return tool
+}
+
+func (t *instructedTool) Run(ctx context.Context, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	resp, err := t.AgentTool.Run(ctx, call)
+	if err != nil || resp.IsError || resp.Type != "text" {
+		return resp, err
+	}
+	var params struct {
+		FilePath string `json:"file_path"`
+		Path     string `json:"path"`
+	}
+	if err := json.Unmarshal([]byte(call.Input), &params); err != nil {
+		return resp, nil
+	}
+	path := cmp.Or(params.FilePath, params.Path)
+	if path == "" {
+		return resp, nil
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(GetWorkingDirFromContext(ctx, t.workingDir), path)
+	}
+
+	sessionID := GetSessionFromContext(ctx)
+	for _, f := range instructions.For(t.workingDir, path, t.names) {
+		key := sessionID + "\x00" + f.Path
+		if content, ok := t.included.Get(key); ok && content == f.Content {
+			continue
+		}
+		t.included.Set(key, f.Content)
+		resp.Content += fmt.Sprintf(
+			"\n\n<instructions path=%q>\nThese instructions apply to the files under %s/. Follow them over the ones of its parent directories and of the workspace.\n\n%s\n</instructions>",
+			f.Dir+"/"+filepath.Base(f.Path), f.Dir, strings.TrimSpace(f.Content),
+		)
+	}
+	return resp, nil
+}
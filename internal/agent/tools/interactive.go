@@ -0,0 +1,126 @@
+package tools
+
+import (
+	"cmp"
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/filepathext"
+	"github.com/charmbracelet/crush/internal/handoff"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/privacy"
+	"github.com/charmbracelet/x/ansi"
+)
+
+const InteractiveToolName = "interactive"
+
+//go:embed interactive.md
+var interactiveDescription []byte
+
+type InteractiveParams struct {
+	Description string `json:"description" description:"Why the user needs to run the command, in a short sentence"`
+	Command     string `json:"command" description:"The command the user runs in their terminal"`
+	WorkingDir  string `json:"working_dir,omitempty" description:"The working directory to run the command in (defaults to current directory)"`
+}
+
+type InteractiveResponseMetadata struct {
+	StartTime        int64  `json:"start_time"`
+	EndTime          int64  `json:"end_time"`
+	Output           string `json:"output"`
+	ExitCode         int    `json:"exit_code"`
+	Description      string `json:"description"`
+	WorkingDirectory string `json:"working_directory"`
+}
+
+func NewInteractiveTool(permissions permission.Service, zones *privacy.Zones, workingDir string, limits config.ToolLimits, profiles map[string]config.ShellProfile) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		InteractiveToolName,
+		string(interactiveDescription),
+		func(ctx context.Context, params InteractiveParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			if params.Command == "" {
+				return fantasy.NewTextErrorResponse("missing command"), nil
+			}
+			sessionID := GetSessionFromContext(ctx)
+			if sessionID == "" {
+				return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for running interactive commands")
+			}
+
+			profile, err := shellProfile(profiles, GetShellProfileFromContext(ctx))
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+			execWorkingDir := filepathext.FromPortable(cmp.Or(params.WorkingDir, profileWorkingDir(profile, GetWorkingDirFromContext(ctx, workingDir))))
+			permissionParams := BashPermissionsParams{
+				Description: params.Description,
+				Command:     params.Command,
+				WorkingDir:  execWorkingDir,
+			}
+			if path, ok := commandZonePath(zones, execWorkingDir, params.Command); ok {
+				if resp, denied := privacyZoneDenied(ctx, permissions, zones, call, InteractiveToolName, "read", path, permissionParams); denied {
+					return resp, nil
+				}
+			}
+
+			p := permissions.Request(
+				permission.CreatePermissionRequest{
+					SessionID:   sessionID,
+					Path:        execWorkingDir,
+					ToolCallID:  call.ID,
+					ToolName:    InteractiveToolName,
+					Action:      "execute",
+					Description: fmt.Sprintf("Run interactively: %s", params.Command),
+					Params:      permissionParams,
+				},
+			)
+			if !p {
+				return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
+			}
+
+			startTime := time.Now()
+			result, err := handoff.Run(ctx, handoff.Request{
+				SessionID:   sessionID,
+				ToolCallID:  call.ID,
+				Command:     params.Command,
+				Description: params.Description,
+				WorkingDir:  execWorkingDir,
+				Env:         profileEnv(os.Environ(), profile),
+			})
+			if errors.Is(err, handoff.ErrNoTerminal) {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+			if err != nil {
+				return fantasy.ToolResponse{}, err
+			}
+			if result.Err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("failed to run command: %s", result.Err)), nil
+			}
+
+			// What the user typed and the colors of the terminal don't matter to
+			// the agent.
+			output := strings.ReplaceAll(ansi.Strip(result.Output), "\r\n", "\n")
+			output = truncate(output, cmp.Or(limits.MaxOutputBytes, MaxOutputLength), limits.Truncate)
+			metadata := InteractiveResponseMetadata{
+				StartTime:        startTime.UnixMilli(),
+				EndTime:          time.Now().UnixMilli(),
+				Output:           output,
+				ExitCode:         result.ExitCode,
+				Description:      params.Description,
+				WorkingDirectory: execWorkingDir,
+			}
+			content := output
+			if content == "" {
+				content = BashNoOutput
+			}
+			if result.ExitCode != 0 {
+				content += fmt.Sprintf("\nExit code %d", result.ExitCode)
+			}
+			return fantasy.WithResponseMetadata(fantasy.NewTextResponse(content), metadata), nil
+		})
+}
@@ -8,6 +8,7 @@ import (
 
 	"charm.land/fantasy"
 	"github.com/charmbracelet/crush/internal/shell"
+	"github.com/charmbracelet/x/ansi"
 )
 
 const (
@@ -27,6 +28,7 @@ type JobOutputResponseMetadata struct {
 	Description      string `json:"description"`
 	Done             bool   `json:"done"`
 	WorkingDirectory string `json:"working_directory"`
+	Output           string `json:"output"`
 }
 
 func NewJobOutputTool() fantasy.AgentTool {
@@ -73,13 +75,14 @@ func NewJobOutputTool() fantasy.AgentTool {
 				Description:      bgShell.Description,
 				Done:             done,
 				WorkingDirectory: bgShell.WorkingDir,
+				Output:           output,
 			}
 
 			if output == "" {
 				output = BashNoOutput
 			}
 
-			result := fmt.Sprintf("Status: %s\n\n%s", status, output)
+			result := fmt.Sprintf("Status: %s\n\n%s", status, ansi.Strip(output))
 			return fantasy.WithResponseMetadata(fantasy.NewTextResponse(result), metadata), nil
 		})
 }
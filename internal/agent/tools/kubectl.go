@@ -0,0 +1,175 @@
+package tools
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"slices"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/permission"
+)
+
+type KubectlParams struct {
+	Command   string   `json:"command" description:"The kubectl command to run, like get, describe or logs"`
+	Args      []string `json:"args,omitempty" description:"The arguments of the command, like the resource type, names and flags"`
+	Namespace string   `json:"namespace,omitempty" description:"The namespace to run the command in, instead of the default one"`
+}
+
+type KubectlPermissionsParams struct {
+	Command   string   `json:"command"`
+	Args      []string `json:"args,omitempty"`
+	Context   string   `json:"context,omitempty"`
+	Namespace string   `json:"namespace,omitempty"`
+}
+
+type KubectlResponseMetadata struct {
+	Command   string `json:"command"`
+	Context   string `json:"context,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+const KubectlToolName = "kubectl"
+
+// DefaultKubectlVerbs are the kubectl commands allowed unless configured
+// otherwise. None of them change the cluster.
+var DefaultKubectlVerbs = []string{"get", "describe", "logs"}
+
+// kubectlBlockedFlags would let the agent talk to another cluster, as
+// someone else, than configured.
+var kubectlBlockedFlags = []string{
+	"--as",
+	"--as-group",
+	"--as-uid",
+	"--certificate-authority",
+	"--client-certificate",
+	"--client-key",
+	"--cluster",
+	"--context",
+	"--insecure-skip-tls-verify",
+	"--kubeconfig",
+	"--password",
+	"--server",
+	"-s",
+	"--token",
+	"--user",
+	"--username",
+}
+
+// kubectlStreamingFlags never return, and would hang the tool until it
+// times out.
+var kubectlStreamingFlags = []string{"--follow", "--watch", "--watch-only", "-w"}
+
+//go:embed kubectl.md
+var kubectlDescription []byte
+
+func NewKubectlTool(permissions permission.Service, workingDir string, cfg config.ToolKubectl) fantasy.AgentTool {
+	verbs := cfg.AllowedVerbs
+	if len(verbs) == 0 {
+		verbs = DefaultKubectlVerbs
+	}
+	description := strings.ReplaceAll(string(kubectlDescription), "{{verbs}}", strings.Join(verbs, ", "))
+	return fantasy.NewAgentTool(
+		KubectlToolName,
+		description,
+		func(ctx context.Context, params KubectlParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			workingDir := GetWorkingDirFromContext(ctx, workingDir)
+			if params.Command == "" {
+				return fantasy.NewTextErrorResponse("command is required"), nil
+			}
+			if !slices.Contains(verbs, params.Command) {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("kubectl %s is not allowed, only: %s", params.Command, strings.Join(verbs, ", "))), nil
+			}
+			if err := checkKubectlArgs(params.Command, params.Args); err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+
+			sessionID := GetSessionFromContext(ctx)
+			if sessionID == "" {
+				return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for running kubectl")
+			}
+
+			namespace := params.Namespace
+			if namespace == "" {
+				namespace = cfg.Namespace
+			}
+			args := []string{params.Command}
+			if cfg.Context != "" {
+				args = append(args, "--context", cfg.Context)
+			}
+			if namespace != "" {
+				args = append(args, "--namespace", namespace)
+			}
+			args = append(args, params.Args...)
+			commandLine := "kubectl " + strings.Join(args, " ")
+
+			p := permissions.Request(
+				permission.CreatePermissionRequest{
+					SessionID:   sessionID,
+					Path:        workingDir,
+					ToolCallID:  call.ID,
+					ToolName:    KubectlToolName,
+					Action:      params.Command,
+					Description: fmt.Sprintf("Run %s", commandLine),
+					Params: KubectlPermissionsParams{
+						Command:   params.Command,
+						Args:      params.Args,
+						Context:   cfg.Context,
+						Namespace: namespace,
+					},
+					Sensitive: touchesKubernetesSecrets(params.Args),
+				},
+			)
+			if !p {
+				return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
+			}
+
+			output, err := runCLI(ctx, workingDir, "kubectl", args...)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("error running kubectl: %v", err)), nil
+			}
+			return fantasy.WithResponseMetadata(
+				fantasy.NewTextResponse(output),
+				KubectlResponseMetadata{
+					Command:   commandLine,
+					Context:   cfg.Context,
+					Namespace: namespace,
+				},
+			), nil
+		})
+}
+
+// checkKubectlArgs refuses the flags that would escape the configured cluster,
+// or stream output forever.
+func checkKubectlArgs(command string, args []string) error {
+	for _, arg := range args {
+		name, _, _ := strings.Cut(arg, "=")
+		if slices.Contains(kubectlBlockedFlags, name) {
+			return fmt.Errorf("the %s flag is not allowed: the cluster and credentials are configured by the user", name)
+		}
+		if slices.Contains(kubectlStreamingFlags, name) || command == "logs" && name == "-f" {
+			return fmt.Errorf("the %s flag is not allowed: output must end, use --tail or --since to limit it instead", name)
+		}
+	}
+	return nil
+}
+
+// touchesKubernetesSecrets reports whether the arguments name secrets, whose
+// values shouldn't be read without the user approving it every time.
+func touchesKubernetesSecrets(args []string) bool {
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+		for resource := range strings.SplitSeq(arg, ",") {
+			resource, _, _ = strings.Cut(resource, "/")
+			resource, _, _ = strings.Cut(resource, ".")
+			if resource == "secret" || resource == "secrets" {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,29 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckKubectlArgs(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, checkKubectlArgs("get", []string{"pods", "-o", "wide", "-f", "pod.yaml"}))
+	require.NoError(t, checkKubectlArgs("logs", []string{"deploy/api", "--tail=100", "--previous"}))
+
+	require.Error(t, checkKubectlArgs("get", []string{"pods", "--context", "prod"}))
+	require.Error(t, checkKubectlArgs("get", []string{"pods", "--kubeconfig=/tmp/admin"}))
+	require.Error(t, checkKubectlArgs("get", []string{"pods", "--watch"}))
+	require.Error(t, checkKubectlArgs("logs", []string{"deploy/api", "-f"}))
+}
+
+func TestTouchesKubernetesSecrets(t *testing.T) {
+	t.Parallel()
+
+	require.True(t, touchesKubernetesSecrets([]string{"secrets", "-o", "yaml"}))
+	require.True(t, touchesKubernetesSecrets([]string{"secret/db-password"}))
+	require.True(t, touchesKubernetesSecrets([]string{"configmaps,secrets"}))
+	require.True(t, touchesKubernetesSecrets([]string{"secrets.v1"}))
+	require.False(t, touchesKubernetesSecrets([]string{"pods", "-l", "app=secrets"}))
+}
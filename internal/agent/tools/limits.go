@@ -0,0 +1,108 @@
+package tools
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+)
+
+// Truncation strategies for tool output over the configured limit.
+const (
+	TruncateHead   = "head"
+	TruncateTail   = "tail"
+	TruncateMiddle = "middle"
+)
+
+// TimeoutResponseMetadata is attached to the response of a tool that was
+// stopped because it ran for longer than its configured timeout.
+type TimeoutResponseMetadata struct {
+	Error   string `json:"error"`
+	Timeout int    `json:"timeout_seconds"`
+}
+
+var errToolTimeout = errors.New("tool timed out")
+
+// limitedTool enforces the configured timeout and output limit of a tool.
+type limitedTool struct {
+	fantasy.AgentTool
+	limits config.ToolLimits
+}
+
+// WithLimits wraps the tool so it's stopped once it runs for longer than the
+// configured timeout, and its output is truncated to the configured size.
+// The timeout is reported to the model as a tool error, so it can try a
+// different approach.
+func WithLimits(tool fantasy.AgentTool, limits config.ToolLimits) fantasy.AgentTool {
+	if limits.Timeout <= 0 && limits.MaxOutputBytes <= 0 {
+		return tool
+	}
+	return &limitedTool{AgentTool: tool, limits: limits}
+}
+
+func (t *limitedTool) Run(ctx context.Context, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	if t.limits.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, time.Duration(t.limits.Timeout)*time.Second, errToolTimeout)
+		defer cancel()
+	}
+
+	resp, err := t.AgentTool.Run(ctx, call)
+	if errors.Is(context.Cause(ctx), errToolTimeout) {
+		return fantasy.WithResponseMetadata(
+			fantasy.NewTextErrorResponse(fmt.Sprintf(
+				"%s timed out after %s and was stopped. Try a faster or more targeted approach, or split the work into smaller steps.",
+				t.Info().Name, time.Duration(t.limits.Timeout)*time.Second,
+			)),
+			TimeoutResponseMetadata{Error: "timeout", Timeout: t.limits.Timeout},
+		), nil
+	}
+	if err != nil || resp.Type != "text" {
+		return resp, err
+	}
+	resp.Content = truncate(resp.Content, t.limits.MaxOutputBytes, t.limits.Truncate)
+	return resp, nil
+}
+
+// truncate shortens the content to at most maxBytes, keeping the start, the
+// end or both ends of it, and notes how many lines were left out.
+func truncate(content string, maxBytes int, strategy string) string {
+	if maxBytes <= 0 || len(content) <= maxBytes {
+		return content
+	}
+
+	switch cmp.Or(strategy, TruncateMiddle) {
+	case TruncateHead:
+		head := headBytes(content, maxBytes)
+		return fmt.Sprintf("%s\n\n... [%d lines truncated]", head, countLines(content[len(head):]))
+	case TruncateTail:
+		tail := tailBytes(content, maxBytes)
+		return fmt.Sprintf("[%d lines truncated] ...\n\n%s", countLines(content[:len(content)-len(tail)]), tail)
+	default:
+		start := headBytes(content, maxBytes/2)
+		end := tailBytes(content, maxBytes/2)
+		return fmt.Sprintf("%s\n\n... [%d lines truncated] ...\n\n%s", start, countLines(content[len(start):len(content)-len(end)]), end)
+	}
+}
+
+// headBytes returns the first n bytes of s at most, without splitting a rune.
+func headBytes(s string, n int) string {
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// tailBytes returns the last n bytes of s at most, without splitting a rune.
+func tailBytes(s string, n int) string {
+	i := len(s) - n
+	for i < len(s) && !utf8.RuneStart(s[i]) {
+		i++
+	}
+	return s[i:]
+}
@@ -0,0 +1,53 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTruncate(t *testing.T) {
+	t.Parallel()
+
+	content := "one\ntwo\nthree\nfour\nfive"
+	require.Equal(t, content, truncate(content, 0, TruncateHead))
+	require.Equal(t, content, truncate(content, len(content), TruncateHead))
+	require.Equal(t, "one\ntwo\n\n\n... [3 lines truncated]", truncate(content, 8, TruncateHead))
+	require.Equal(t, "[4 lines truncated] ...\n\nfour\nfive", truncate(content, 9, TruncateTail))
+	require.Equal(t, "one\nt\n\n... [3 lines truncated] ...\n\n\nfive", truncate(content, 10, TruncateMiddle))
+	require.Equal(t, truncate(content, 10, TruncateMiddle), truncate(content, 10, ""))
+
+	// Runes aren't split in half.
+	require.Equal(t, "é\n\n... [1 lines truncated]", truncate("éé", 3, TruncateHead))
+	require.Equal(t, "[1 lines truncated] ...\n\né", truncate("éé", 3, TruncateTail))
+}
+
+func TestWithLimits(t *testing.T) {
+	t.Parallel()
+
+	tool := fantasy.NewAgentTool("slow", "", func(ctx context.Context, _ struct{}, _ fantasy.ToolCall) (fantasy.ToolResponse, error) {
+		<-ctx.Done()
+		return fantasy.ToolResponse{}, ctx.Err()
+	})
+	require.Same(t, tool, WithLimits(tool, config.ToolLimits{}))
+
+	resp, err := WithLimits(tool, config.ToolLimits{Timeout: 1}).Run(t.Context(), fantasy.ToolCall{Input: "{}"})
+	require.NoError(t, err)
+	require.True(t, resp.IsError)
+	require.Contains(t, resp.Content, "slow timed out after 1s")
+	var metadata TimeoutResponseMetadata
+	require.NoError(t, json.Unmarshal([]byte(resp.Metadata), &metadata))
+	require.Equal(t, TimeoutResponseMetadata{Error: "timeout", Timeout: 1}, metadata)
+
+	verbose := fantasy.NewAgentTool("verbose", "", func(context.Context, struct{}, fantasy.ToolCall) (fantasy.ToolResponse, error) {
+		return fantasy.NewTextResponse(strings.Repeat("line\n", 100)), nil
+	})
+	resp, err = WithLimits(verbose, config.ToolLimits{MaxOutputBytes: 10, Truncate: TruncateHead}).Run(t.Context(), fantasy.ToolCall{Input: "{}"})
+	require.NoError(t, err)
+	require.Equal(t, "line\nline\n\n\n... [19 lines truncated]", resp.Content)
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/charmbracelet/crush/internal/filepathext"
 	"github.com/charmbracelet/crush/internal/fsext"
 	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/privacy"
 )
 
 type LSParams struct {
@@ -48,11 +49,12 @@ const (
 //go:embed ls.md
 var lsDescription []byte
 
-func NewLsTool(permissions permission.Service, workingDir string, lsConfig config.ToolLs) fantasy.AgentTool {
+func NewLsTool(permissions permission.Service, zones *privacy.Zones, workingDir string, lsConfig config.ToolLs) fantasy.AgentTool {
 	return fantasy.NewAgentTool(
 		LSToolName,
 		string(lsDescription),
 		func(ctx context.Context, params LSParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			workingDir := GetWorkingDirFromContext(ctx, workingDir)
 			searchPath, err := fsext.Expand(cmp.Or(params.Path, workingDir))
 			if err != nil {
 				return fantasy.NewTextErrorResponse(fmt.Sprintf("error expanding path: %v", err)), nil
@@ -96,7 +98,7 @@ func NewLsTool(permissions permission.Service, workingDir string, lsConfig confi
 				}
 			}
 
-			output, metadata, err := ListDirectoryTree(searchPath, params, lsConfig)
+			output, metadata, err := ListDirectoryTree(searchPath, params, lsConfig, zones)
 			if err != nil {
 				return fantasy.NewTextErrorResponse(err.Error()), nil
 			}
@@ -108,7 +110,9 @@ func NewLsTool(permissions permission.Service, workingDir string, lsConfig confi
 		})
 }
 
-func ListDirectoryTree(searchPath string, params LSParams, lsConfig config.ToolLs) (string, LSResponseMetadata, error) {
+// ListDirectoryTree lists the directory at searchPath as a tree, leaving out
+// the paths in privacy zones.
+func ListDirectoryTree(searchPath string, params LSParams, lsConfig config.ToolLs, zones *privacy.Zones) (string, LSResponseMetadata, error) {
 	if _, err := os.Stat(searchPath); os.IsNotExist(err) {
 		return "", LSResponseMetadata{}, fmt.Errorf("path does not exist: %s", searchPath)
 	}
@@ -124,6 +128,7 @@ func ListDirectoryTree(searchPath string, params LSParams, lsConfig config.ToolL
 	if err != nil {
 		return "", LSResponseMetadata{}, fmt.Errorf("error listing directory: %w", err)
 	}
+	files, withheld := zones.Filter(files)
 
 	metadata := LSResponseMetadata{
 		NumberOfFiles: len(files),
@@ -138,7 +143,11 @@ func ListDirectoryTree(searchPath string, params LSParams, lsConfig config.ToolL
 	if depth > 0 {
 		output = fmt.Sprintf("The directory tree is shown up to a depth of %d. Use a higher depth and a specific path to see more levels.\n", cmp.Or(params.Depth, depth))
 	}
-	return output + "\n" + printTree(tree, searchPath), metadata, nil
+	output += "\n" + printTree(tree, searchPath)
+	if withheld > 0 {
+		output += fmt.Sprintf("\n(%d paths in privacy zones were withheld.)", withheld)
+	}
+	return output, metadata, nil
 }
 
 func createFileTree(sortedPaths []string, rootPath string) []*TreeNode {
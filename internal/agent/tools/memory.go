@@ -0,0 +1,68 @@
+package tools
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/memory"
+)
+
+type MemoryParams struct {
+	Action  string `json:"action" description:"save to remember a fact, or search to find the memories about a topic"`
+	Content string `json:"content,omitempty" description:"The fact or preference to remember, for save"`
+	Query   string `json:"query,omitempty" description:"The topic to find memories about, for search"`
+}
+
+const (
+	MemoryToolName = "memory"
+
+	defaultMemorySearchLimit = 10
+)
+
+//go:embed memory.md
+var memoryDescription []byte
+
+func NewMemoryTool(store *memory.Store) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		MemoryToolName,
+		string(memoryDescription),
+		func(ctx context.Context, params MemoryParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			switch params.Action {
+			case "save":
+				m, added, err := store.Add(params.Content, GetSessionFromContext(ctx))
+				if err != nil {
+					return fantasy.NewTextErrorResponse(fmt.Sprintf("failed to save the memory: %v", err)), nil
+				}
+				if !added {
+					return fantasy.NewTextResponse("This was already remembered."), nil
+				}
+				return fantasy.NewTextResponse("Remembered: " + m.Content), nil
+			case "search":
+				if strings.TrimSpace(params.Query) == "" {
+					return fantasy.NewTextErrorResponse("query is required to search"), nil
+				}
+				memories, err := store.Recall(params.Query, defaultMemorySearchLimit)
+				if err != nil {
+					return fantasy.NewTextErrorResponse(fmt.Sprintf("failed to search the memories: %v", err)), nil
+				}
+				if len(memories) == 0 {
+					return fantasy.NewTextResponse("No memories about this."), nil
+				}
+				return fantasy.NewTextResponse(FormatMemories(memories)), nil
+			default:
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("unknown action %q, use save or search", params.Action)), nil
+			}
+		})
+}
+
+// FormatMemories renders memories as a list for the model.
+func FormatMemories(memories []memory.Memory) string {
+	var b strings.Builder
+	for _, m := range memories {
+		b.WriteString("- " + strings.Join(strings.Fields(m.Content), " ") + "\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/charmbracelet/crush/internal/history"
 	"github.com/charmbracelet/crush/internal/lsp"
 	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/privacy"
 )
 
 type MultiEditOperation struct {
@@ -57,11 +58,12 @@ const MultiEditToolName = "multiedit"
 //go:embed multiedit.md
 var multieditDescription []byte
 
-func NewMultiEditTool(lspClients *csync.Map[string, *lsp.Client], permissions permission.Service, files history.Service, workingDir string) fantasy.AgentTool {
+func NewMultiEditTool(lspClients *csync.Map[string, *lsp.Client], permissions permission.Service, files history.Service, zones *privacy.Zones, workingDir string) fantasy.AgentTool {
 	return fantasy.NewAgentTool(
 		MultiEditToolName,
 		string(multieditDescription),
 		func(ctx context.Context, params MultiEditParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			workingDir := GetWorkingDirFromContext(ctx, workingDir)
 			if params.FilePath == "" {
 				return fantasy.NewTextErrorResponse("file_path is required"), nil
 			}
@@ -71,11 +73,21 @@ func NewMultiEditTool(lspClients *csync.Map[string, *lsp.Client], permissions pe
 			}
 
 			params.FilePath = filepathext.SmartJoin(workingDir, params.FilePath)
+			if resp, denied := privacyZoneDenied(ctx, permissions, zones, call, MultiEditToolName, "write", params.FilePath, MultiEditPermissionsParams{FilePath: params.FilePath}); denied {
+				return resp, nil
+			}
+			if isNotebook(params.FilePath) {
+				return fantasy.NewTextErrorResponse("use the Edit tool with cell_id to edit Jupyter notebooks, so their JSON stays valid"), nil
+			}
 
 			// Validate all edits before applying any
 			if err := validateEdits(params.Edits); err != nil {
 				return fantasy.NewTextErrorResponse(err.Error()), nil
 			}
+			for i := range params.Edits {
+				params.Edits[i].OldString, _ = fsext.ToUnixLineEndings(params.Edits[i].OldString)
+				params.Edits[i].NewString, _ = fsext.ToUnixLineEndings(params.Edits[i].NewString)
+			}
 
 			var response fantasy.ToolResponse
 			var err error
@@ -116,7 +116,7 @@ func TestMultiEditSequentialApplication(t *testing.T) {
 	files := &mockHistoryService{Broker: pubsub.NewBroker[history.File]()}
 
 	// Create multiedit tool.
-	_ = NewMultiEditTool(lspClients, permissions, files, tmpDir)
+	_ = NewMultiEditTool(lspClients, permissions, files, nil, tmpDir)
 
 	// Simulate reading the file first.
 	recordFileRead(testFile)
@@ -0,0 +1,412 @@
+package tools
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/diff"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/permission"
+)
+
+// notebook is a Jupyter notebook. Only the cells are decoded, and only as
+// far as needed, so that writing it back keeps everything else as is.
+type notebook struct {
+	fields map[string]json.RawMessage
+	cells  []notebookCell
+}
+
+type notebookCell map[string]json.RawMessage
+
+// isNotebook reports whether the path is the one of a Jupyter notebook.
+func isNotebook(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".ipynb")
+}
+
+func parseNotebook(data []byte) (*notebook, error) {
+	var nb notebook
+	if err := json.Unmarshal(data, &nb.fields); err != nil {
+		return nil, fmt.Errorf("invalid notebook: %w", err)
+	}
+	cells, ok := nb.fields["cells"]
+	if !ok {
+		return nil, errors.New("invalid notebook: no cells")
+	}
+	if err := json.Unmarshal(cells, &nb.cells); err != nil {
+		return nil, fmt.Errorf("invalid notebook cells: %w", err)
+	}
+	return &nb, nil
+}
+
+// marshal encodes the notebook the way Jupyter does, so that saving it from
+// Jupyter afterwards doesn't make a diff.
+func (nb *notebook) marshal() ([]byte, error) {
+	cells, err := marshalNotebookJSON(nb.cells)
+	if err != nil {
+		return nil, err
+	}
+	nb.fields["cells"] = cells
+
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", " ")
+	if err := enc.Encode(nb.fields); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// marshalNotebookJSON encodes the value without escaping HTML characters,
+// which Jupyter leaves alone.
+func marshalNotebookJSON(v any) (json.RawMessage, error) {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(b.Bytes(), []byte("\n")), nil
+}
+
+// language returns the programming language of the code cells.
+func (nb *notebook) language() string {
+	var metadata struct {
+		Kernelspec struct {
+			Language string `json:"language"`
+		} `json:"kernelspec"`
+		LanguageInfo struct {
+			Name string `json:"name"`
+		} `json:"language_info"`
+	}
+	_ = json.Unmarshal(nb.fields["metadata"], &metadata)
+	if metadata.LanguageInfo.Name != "" {
+		return metadata.LanguageInfo.Name
+	}
+	return metadata.Kernelspec.Language
+}
+
+// hasCellIDs reports whether the format of the notebook gives cells an ID,
+// which it does since 4.5.
+func (nb *notebook) hasCellIDs() bool {
+	var major, minor int
+	_ = json.Unmarshal(nb.fields["nbformat"], &major)
+	_ = json.Unmarshal(nb.fields["nbformat_minor"], &minor)
+	return major > 4 || major == 4 && minor >= 5
+}
+
+// find returns the index of the cell with the ID, or at the index when
+// cells don't have IDs.
+func (nb *notebook) find(id string) (int, error) {
+	for i, cell := range nb.cells {
+		if cell.id() == id {
+			return i, nil
+		}
+	}
+	if i, err := strconv.Atoi(id); err == nil && i >= 0 && i < len(nb.cells) {
+		return i, nil
+	}
+	return 0, fmt.Errorf("no cell %q in the notebook", id)
+}
+
+// render returns the cells of the notebook for the model, without their
+// outputs, which are mostly noise and can be huge.
+func (nb *notebook) render() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "<notebook language=%q cells=\"%d\">\n", nb.language(), len(nb.cells))
+	for i, cell := range nb.cells {
+		fmt.Fprintf(&b, "<cell index=\"%d\"", i)
+		if id := cell.id(); id != "" {
+			fmt.Fprintf(&b, " id=%q", id)
+		}
+		fmt.Fprintf(&b, " type=%q", cell.kind())
+		if outputs := cell.outputs(); outputs > 0 {
+			fmt.Fprintf(&b, " outputs_omitted=\"%d\"", outputs)
+		}
+		b.WriteString(">\n")
+		if source := cell.source(); source != "" {
+			b.WriteString(strings.TrimSuffix(source, "\n"))
+			b.WriteString("\n")
+		}
+		b.WriteString("</cell>\n")
+	}
+	b.WriteString("</notebook>")
+	return b.String()
+}
+
+// readNotebook returns the lines of the rendered notebook from offset, at
+// most limit of them, along with their count. Notebooks that can't be parsed
+// are read as text, so that they can be fixed.
+func readNotebook(filePath string, offset, limit int) (string, int, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", 0, err
+	}
+	nb, err := parseNotebook(data)
+	if err != nil {
+		return readTextFile(filePath, offset, limit)
+	}
+	all := strings.Split(nb.render(), "\n")
+	if offset >= len(all) {
+		return "", len(all), nil
+	}
+	lines := all[offset:min(offset+limit, len(all))]
+	for i, line := range lines {
+		if len(line) > MaxLineLength {
+			lines[i] = line[:MaxLineLength] + "..."
+		}
+	}
+	return strings.Join(lines, "\n"), len(all), nil
+}
+
+func (c notebookCell) id() string {
+	var id string
+	_ = json.Unmarshal(c["id"], &id)
+	return id
+}
+
+func (c notebookCell) kind() string {
+	var kind string
+	_ = json.Unmarshal(c["cell_type"], &kind)
+	return kind
+}
+
+func (c notebookCell) outputs() int {
+	var outputs []json.RawMessage
+	_ = json.Unmarshal(c["outputs"], &outputs)
+	return len(outputs)
+}
+
+// source returns the source of the cell, which is stored as a string or as
+// a list of lines.
+func (c notebookCell) source() string {
+	var lines []string
+	if err := json.Unmarshal(c["source"], &lines); err == nil {
+		return strings.Join(lines, "")
+	}
+	var source string
+	_ = json.Unmarshal(c["source"], &source)
+	return source
+}
+
+// setSource replaces the source of the cell. The outputs of code cells no
+// longer match their source, so they're cleared.
+func (c notebookCell) setSource(source string) {
+	lines := []string{}
+	for line := range strings.SplitAfterSeq(source, "\n") {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	c["source"], _ = marshalNotebookJSON(lines)
+	if c.kind() == "code" {
+		c["outputs"] = json.RawMessage("[]")
+		c["execution_count"] = json.RawMessage("null")
+	}
+}
+
+func newNotebookCell(kind, source string, withID bool) notebookCell {
+	cell := notebookCell{
+		"metadata": json.RawMessage("{}"),
+	}
+	cell["cell_type"], _ = json.Marshal(kind)
+	if withID {
+		id := make([]byte, 4)
+		_, _ = rand.Read(id)
+		cell["id"], _ = json.Marshal(hex.EncodeToString(id))
+	}
+	cell.setSource(source)
+	return cell
+}
+
+// editNotebook edits the source of the cells of a notebook, leaving its JSON
+// structure to the tool. The old string is looked for in the cell with the
+// ID, or in all the cells when there's none. An empty old string inserts a
+// new cell after the one with the ID, or at the end, and replacing the whole
+// source of a cell with nothing deletes it.
+func editNotebook(edit editContext, params EditParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	fileInfo, err := os.Stat(params.FilePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fantasy.NewTextErrorResponse(fmt.Sprintf("file not found: %s. Create notebooks with the Write tool", params.FilePath)), nil
+		}
+		return fantasy.ToolResponse{}, fmt.Errorf("failed to access file: %w", err)
+	}
+	if getLastReadTime(params.FilePath).IsZero() {
+		return fantasy.NewTextErrorResponse("you must read the file before editing it. Use the View tool first"), nil
+	}
+	modTime := fileInfo.ModTime()
+	lastRead := getLastReadTime(params.FilePath)
+	if modTime.After(lastRead) {
+		return fantasy.NewTextErrorResponse(
+			fmt.Sprintf("file %s has been modified since it was last read (mod time: %s, last read: %s)",
+				params.FilePath, modTime.Format(time.RFC3339), lastRead.Format(time.RFC3339),
+			)), nil
+	}
+
+	content, err := os.ReadFile(params.FilePath)
+	if err != nil {
+		return fantasy.ToolResponse{}, fmt.Errorf("failed to read file: %w", err)
+	}
+	nb, err := parseNotebook(content)
+	if err != nil {
+		return fantasy.NewTextErrorResponse(err.Error()), nil
+	}
+	oldRendered := nb.render()
+
+	var description, result string
+	switch {
+	case params.OldString == "":
+		kind := params.CellType
+		if kind == "" {
+			kind = "code"
+		}
+		if kind != "code" && kind != "markdown" && kind != "raw" {
+			return fantasy.NewTextErrorResponse("cell_type must be code, markdown or raw"), nil
+		}
+		at := len(nb.cells)
+		if params.CellID != "" {
+			i, err := nb.find(params.CellID)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+			at = i + 1
+		}
+		cell := newNotebookCell(kind, params.NewString, nb.hasCellIDs())
+		nb.cells = slices.Insert(nb.cells, at, cell)
+		description = fmt.Sprintf("Insert %s cell in notebook %s", kind, params.FilePath)
+		result = fmt.Sprintf("Cell inserted at index %d of notebook: %s", at, params.FilePath)
+		if id := cell.id(); id != "" {
+			result = fmt.Sprintf("Cell %s inserted at index %d of notebook: %s", id, at, params.FilePath)
+		}
+	default:
+		candidates := make([]int, 0, len(nb.cells))
+		if params.CellID != "" {
+			i, err := nb.find(params.CellID)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+			candidates = append(candidates, i)
+		} else {
+			for i := range nb.cells {
+				candidates = append(candidates, i)
+			}
+		}
+
+		var matches []int
+		count := 0
+		for _, i := range candidates {
+			if n := strings.Count(nb.cells[i].source(), params.OldString); n > 0 {
+				matches = append(matches, i)
+				count += n
+			}
+		}
+		switch {
+		case count == 0:
+			return fantasy.NewTextErrorResponse("old_string not found in the cells of the notebook. Make sure it matches exactly, including whitespace and line breaks"), nil
+		case count > 1 && !params.ReplaceAll:
+			return fantasy.NewTextErrorResponse("old_string appears multiple times in the notebook. Please provide more context or a cell_id to ensure a unique match, or set replace_all to true"), nil
+		}
+
+		var removed []int
+		for _, i := range matches {
+			cell := nb.cells[i]
+			source := cell.source()
+			if params.NewString == "" && strings.TrimSpace(source) == strings.TrimSpace(params.OldString) {
+				removed = append(removed, i)
+				continue
+			}
+			cell.setSource(strings.ReplaceAll(source, params.OldString, params.NewString))
+		}
+		for _, i := range slices.Backward(removed) {
+			nb.cells = slices.Delete(nb.cells, i, i+1)
+		}
+		description = fmt.Sprintf("Edit cells of notebook %s", params.FilePath)
+		result = fmt.Sprintf("%d cells edited and %d deleted in notebook: %s", len(matches)-len(removed), len(removed), params.FilePath)
+	}
+
+	newRendered := nb.render()
+	if oldRendered == newRendered {
+		return fantasy.NewTextErrorResponse("new content is the same as old content. No changes made."), nil
+	}
+	newContent, err := nb.marshal()
+	if err != nil {
+		return fantasy.ToolResponse{}, fmt.Errorf("failed to encode notebook: %w", err)
+	}
+
+	sessionID := GetSessionFromContext(edit.ctx)
+	if sessionID == "" {
+		return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for editing a notebook")
+	}
+	_, additions, removals := diff.GenerateDiff(
+		oldRendered,
+		newRendered,
+		strings.TrimPrefix(params.FilePath, edit.workingDir),
+	)
+	p := edit.permissions.Request(
+		permission.CreatePermissionRequest{
+			SessionID:   sessionID,
+			Path:        fsext.PathOrPrefix(params.FilePath, edit.workingDir),
+			ToolCallID:  call.ID,
+			ToolName:    EditToolName,
+			Action:      "write",
+			Description: description,
+			Params: EditPermissionsParams{
+				FilePath:   params.FilePath,
+				OldContent: oldRendered,
+				NewContent: newRendered,
+			},
+		},
+	)
+	if !p {
+		return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
+	}
+
+	if err := os.WriteFile(params.FilePath, newContent, 0o644); err != nil {
+		return fantasy.ToolResponse{}, fmt.Errorf("failed to write file: %w", err)
+	}
+
+	// Check if file exists in history
+	file, err := edit.files.GetByPathAndSession(edit.ctx, params.FilePath, sessionID)
+	if err != nil {
+		_, err = edit.files.Create(edit.ctx, sessionID, params.FilePath, string(content))
+		if err != nil {
+			return fantasy.ToolResponse{}, fmt.Errorf("error creating file history: %w", err)
+		}
+	}
+	if file.Content != string(content) {
+		// User Manually changed the content store an intermediate version
+		_, err = edit.files.CreateVersion(edit.ctx, sessionID, params.FilePath, string(content))
+		if err != nil {
+			slog.Debug("Error creating file history version", "error", err)
+		}
+	}
+	_, err = edit.files.CreateVersion(edit.ctx, sessionID, params.FilePath, string(newContent))
+	if err != nil {
+		slog.Error("Error creating file history version", "error", err)
+	}
+
+	recordFileWrite(params.FilePath)
+	recordFileRead(params.FilePath)
+
+	return fantasy.WithResponseMetadata(
+		fantasy.NewTextResponse(result),
+		EditResponseMetadata{
+			OldContent: oldRendered,
+			NewContent: newRendered,
+			Additions:  additions,
+			Removals:   removals,
+		}), nil
+}
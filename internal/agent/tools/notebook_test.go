@@ -0,0 +1,119 @@
+package tools
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testNotebook = `{
+ "cells": [
+  {
+   "cell_type": "markdown",
+   "id": "intro",
+   "metadata": {},
+   "source": [
+    "# Analysis <draft>\n",
+    "Some notes"
+   ]
+  },
+  {
+   "cell_type": "code",
+   "execution_count": 3,
+   "id": "load",
+   "metadata": {
+    "tags": ["setup"]
+   },
+   "outputs": [
+    {
+     "name": "stdout",
+     "output_type": "stream",
+     "text": ["42\n"]
+    }
+   ],
+   "source": "x = 41\nprint(x + 1)"
+  }
+ ],
+ "metadata": {
+  "kernelspec": {
+   "display_name": "Python 3",
+   "language": "python",
+   "name": "python3"
+  }
+ },
+ "nbformat": 4,
+ "nbformat_minor": 5
+}
+`
+
+func TestNotebookRender(t *testing.T) {
+	t.Parallel()
+
+	nb, err := parseNotebook([]byte(testNotebook))
+	require.NoError(t, err)
+	require.Equal(t, `<notebook language="python" cells="2">
+<cell index="0" id="intro" type="markdown">
+# Analysis <draft>
+Some notes
+</cell>
+<cell index="1" id="load" type="code" outputs_omitted="1">
+x = 41
+print(x + 1)
+</cell>
+</notebook>`, nb.render())
+}
+
+func TestNotebookRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	nb, err := parseNotebook([]byte(testNotebook))
+	require.NoError(t, err)
+	data, err := nb.marshal()
+	require.NoError(t, err)
+	require.JSONEq(t, testNotebook, string(data))
+	require.Contains(t, string(data), `"# Analysis <draft>\n"`, "HTML characters aren't escaped")
+	require.Contains(t, string(data), "\n \"cells\": [", "indented like Jupyter does")
+}
+
+func TestNotebookSetSource(t *testing.T) {
+	t.Parallel()
+
+	nb, err := parseNotebook([]byte(testNotebook))
+	require.NoError(t, err)
+	i, err := nb.find("load")
+	require.NoError(t, err)
+	nb.cells[i].setSource("x = 1\nprint(x)\n")
+	require.Equal(t, "x = 1\nprint(x)\n", nb.cells[i].source())
+	require.Zero(t, nb.cells[i].outputs())
+
+	data, err := nb.marshal()
+	require.NoError(t, err)
+	var decoded struct {
+		Cells []struct {
+			ExecutionCount *int           `json:"execution_count"`
+			Metadata       map[string]any `json:"metadata"`
+			Source         []string       `json:"source"`
+		} `json:"cells"`
+	}
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.Nil(t, decoded.Cells[1].ExecutionCount)
+	require.Equal(t, []string{"x = 1\n", "print(x)\n"}, decoded.Cells[1].Source)
+	require.Equal(t, map[string]any{"tags": []any{"setup"}}, decoded.Cells[1].Metadata)
+}
+
+func TestNotebookFind(t *testing.T) {
+	t.Parallel()
+
+	nb, err := parseNotebook([]byte(testNotebook))
+	require.NoError(t, err)
+	i, err := nb.find("1")
+	require.NoError(t, err)
+	require.Equal(t, 1, i)
+	_, err = nb.find("missing")
+	require.Error(t, err)
+
+	cell := newNotebookCell("markdown", "## Results", nb.hasCellIDs())
+	require.Len(t, cell.id(), 8)
+	require.Equal(t, "## Results", cell.source())
+}
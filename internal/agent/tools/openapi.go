@@ -0,0 +1,307 @@
+package tools
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent/tools/openapi"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/permission"
+)
+
+type OpenAPIPermissionsParams struct {
+	Service   string `json:"service"`
+	Operation string `json:"operation"`
+	Method    string `json:"method"`
+	URL       string `json:"url"`
+	Body      string `json:"body,omitempty"`
+}
+
+const (
+	// OpenAPIToolName allows or disables the tools of all the OpenAPI
+	// services, whose names depend on their operations.
+	OpenAPIToolName = "openapi"
+
+	// OpenAPIToolPrefix starts the names of the tools made of the
+	// operations of OpenAPI services.
+	OpenAPIToolPrefix = "api_"
+)
+
+// openAPIToolNameInvalid matches what can't be in a tool name.
+var openAPIToolNameInvalid = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// GetOpenAPITools returns a tool per operation of the OpenAPI services.
+// Services whose document can't be loaded are skipped with a warning.
+func GetOpenAPITools(ctx context.Context, permissions permission.Service, workingDir string, services map[string]config.OpenAPIService, localOnly bool) []*OpenAPITool {
+	client := &http.Client{
+		Timeout: 60 * time.Second,
+		// The headers are meant for the service only.
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return errors.New("stopped after 10 redirects")
+			}
+			if req.URL.Host != via[0].URL.Host {
+				return fmt.Errorf("redirect to %s refused: it's not the host of the service", req.URL.Host)
+			}
+			return nil
+		},
+	}
+
+	var result []*OpenAPITool
+	for _, service := range slices.Sorted(maps.Keys(services)) {
+		cfg := services[service]
+		loadCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		doc, err := openapi.Load(loadCtx, cfg.Spec, workingDir)
+		cancel()
+		if err != nil {
+			slog.Warn("Could not load OpenAPI document", "service", service, "spec", cfg.Spec, "error", err)
+			continue
+		}
+		baseURL := strings.TrimSuffix(os.ExpandEnv(cmp.Or(cfg.BaseURL, doc.ServerURL)), "/")
+		if u, err := url.Parse(baseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			slog.Warn("OpenAPI service has no absolute base URL, set base_url", "service", service, "base_url", baseURL)
+			continue
+		}
+		for _, op := range doc.Operations {
+			if len(cfg.Operations) > 0 && !slices.Contains(cfg.Operations, op.ID) {
+				continue
+			}
+			result = append(result, &OpenAPITool{
+				service:     service,
+				title:       doc.Title,
+				op:          op,
+				baseURL:     baseURL,
+				headers:     cfg.Headers,
+				localOnly:   localOnly,
+				client:      client,
+				permissions: permissions,
+				workingDir:  workingDir,
+			})
+		}
+	}
+	return result
+}
+
+// OpenAPITool is a tool calling an operation of an OpenAPI service.
+type OpenAPITool struct {
+	service         string
+	title           string
+	op              openapi.Operation
+	baseURL         string
+	headers         map[string]string
+	localOnly       bool
+	client          *http.Client
+	permissions     permission.Service
+	workingDir      string
+	providerOptions fantasy.ProviderOptions
+}
+
+func (o *OpenAPITool) SetProviderOptions(opts fantasy.ProviderOptions) {
+	o.providerOptions = opts
+}
+
+func (o *OpenAPITool) ProviderOptions() fantasy.ProviderOptions {
+	return o.providerOptions
+}
+
+// Name returns the name of the tool, made of the service and operation IDs
+// and within the 64 characters providers allow.
+func (o *OpenAPITool) Name() string {
+	name := openAPIToolNameInvalid.ReplaceAllString(OpenAPIToolPrefix+o.service+"_"+o.op.ID, "_")
+	return name[:min(len(name), 64)]
+}
+
+// Service returns the name of the service of the tool.
+func (o *OpenAPITool) Service() string {
+	return o.service
+}
+
+// bodyName returns the name of the parameter holding the request body, which
+// mustn't collide with the ones of the operation.
+func (o *OpenAPITool) bodyName() string {
+	for _, name := range []string{"body", "request_body"} {
+		if !slices.ContainsFunc(o.op.Parameters, func(p openapi.Parameter) bool { return p.Name == name }) {
+			return name
+		}
+	}
+	return "_body"
+}
+
+func (o *OpenAPITool) Info() fantasy.ToolInfo {
+	var description strings.Builder
+	fmt.Fprintf(&description, "Calls %s %s of the %s service", o.op.Method, o.op.Path, o.service)
+	if o.title != "" {
+		fmt.Fprintf(&description, " (%s)", o.title)
+	}
+	description.WriteString(".")
+	for _, text := range []string{o.op.Summary, o.op.Description} {
+		if text = strings.TrimSpace(text); text != "" {
+			description.WriteString("\n\n" + text)
+		}
+	}
+
+	properties := make(map[string]any, len(o.op.Parameters)+1)
+	var required []string
+	for _, param := range o.op.Parameters {
+		schema := maps.Clone(param.Schema)
+		if _, ok := schema["description"]; !ok && param.Description != "" {
+			schema["description"] = param.Description
+		}
+		properties[param.Name] = schema
+		if param.Required || param.In == "path" {
+			required = append(required, param.Name)
+		}
+	}
+	if o.op.Body != nil {
+		body := maps.Clone(o.op.Body)
+		if _, ok := body["description"]; !ok {
+			body["description"] = "The JSON body of the request"
+		}
+		properties[o.bodyName()] = body
+		if o.op.BodyRequired {
+			required = append(required, o.bodyName())
+		}
+	}
+
+	return fantasy.ToolInfo{
+		Name:        o.Name(),
+		Description: description.String(),
+		Parameters:  properties,
+		Required:    required,
+		Parallel:    o.op.Method == http.MethodGet || o.op.Method == http.MethodHead,
+	}
+}
+
+func (o *OpenAPITool) Run(ctx context.Context, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	var input map[string]any
+	if strings.TrimSpace(call.Input) != "" {
+		dec := json.NewDecoder(strings.NewReader(call.Input))
+		dec.UseNumber()
+		if err := dec.Decode(&input); err != nil {
+			return fantasy.NewTextErrorResponse(fmt.Sprintf("invalid parameters: %v", err)), nil
+		}
+	}
+	req, body, err := o.request(ctx, input)
+	if err != nil {
+		return fantasy.NewTextErrorResponse(err.Error()), nil
+	}
+	if o.localOnly && !config.IsLocalEndpoint(req.URL.String()) {
+		return fantasy.NewTextErrorResponse(fmt.Sprintf("%s is not a local address, and local-only mode is enabled", req.URL.Host)), nil
+	}
+	secrets := make(map[string]string, len(o.headers))
+	for name, value := range o.headers {
+		secrets[name] = os.ExpandEnv(value)
+	}
+	if len(secrets) > 0 && req.URL.Scheme != "https" && !config.IsLocalEndpoint(req.URL.String()) {
+		return fantasy.NewTextErrorResponse(fmt.Sprintf("refusing to send the headers of the %s service in clear text to %s: use https", o.service, req.URL.Host)), nil
+	}
+
+	sessionID := GetSessionFromContext(ctx)
+	if sessionID == "" {
+		return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for calling APIs")
+	}
+	p := o.permissions.Request(
+		permission.CreatePermissionRequest{
+			SessionID:   sessionID,
+			Path:        o.workingDir,
+			ToolCallID:  call.ID,
+			ToolName:    o.Name(),
+			Action:      strings.ToLower(o.op.Method),
+			Description: fmt.Sprintf("Call %s of %s: %s %s", o.op.ID, o.service, o.op.Method, req.URL),
+			Params: OpenAPIPermissionsParams{
+				Service:   o.service,
+				Operation: o.op.ID,
+				Method:    o.op.Method,
+				URL:       req.URL.String(),
+				Body:      body,
+			},
+		},
+	)
+	if !p {
+		return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
+	}
+	return sendHTTPRequest(o.client, req, "", secrets), nil
+}
+
+// request builds the request of the operation from the input of the model,
+// and returns it with its body.
+func (o *OpenAPITool) request(ctx context.Context, input map[string]any) (*http.Request, string, error) {
+	path := o.op.Path
+	query := url.Values{}
+	header := http.Header{}
+	for _, param := range o.op.Parameters {
+		value, ok := input[param.Name]
+		if !ok || value == nil {
+			if param.Required || param.In == "path" {
+				return nil, "", fmt.Errorf("parameter %s is required", param.Name)
+			}
+			continue
+		}
+		switch param.In {
+		case "path":
+			path = strings.ReplaceAll(path, "{"+param.Name+"}", url.PathEscape(openAPIParamString(value)))
+		case "query":
+			if list, ok := value.([]any); ok {
+				for _, item := range list {
+					query.Add(param.Name, openAPIParamString(item))
+				}
+			} else {
+				query.Set(param.Name, openAPIParamString(value))
+			}
+		case "header":
+			header.Set(param.Name, openAPIParamString(value))
+		}
+	}
+
+	rawURL := o.baseURL + path
+	if len(query) > 0 {
+		rawURL += "?" + query.Encode()
+	}
+	var body string
+	if value, ok := input[o.bodyName()]; ok && o.op.Body != nil {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid body: %w", err)
+		}
+		body = string(data)
+		header.Set("Content-Type", "application/json")
+	} else if o.op.BodyRequired {
+		return nil, "", fmt.Errorf("parameter %s is required", o.bodyName())
+	}
+
+	req, err := http.NewRequestWithContext(ctx, o.op.Method, rawURL, strings.NewReader(body))
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid request: %w", err)
+	}
+	maps.Copy(req.Header, header)
+	return req, body, nil
+}
+
+// openAPIParamString formats a parameter value, with objects and arrays as
+// JSON.
+func openAPIParamString(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case json.Number, bool:
+		return fmt.Sprint(v)
+	default:
+		var b bytes.Buffer
+		_ = json.NewEncoder(&b).Encode(v)
+		return strings.TrimSpace(b.String())
+	}
+}
@@ -0,0 +1,275 @@
+// Package openapi reads the operations of OpenAPI 3 documents, to turn them
+// into tools.
+package openapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxRefDepth bounds the inlining of references, which can be recursive.
+const maxRefDepth = 8
+
+// methods are the HTTP methods operations can have, in the order they're
+// listed.
+var methods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// Document is an OpenAPI document, reduced to what's needed to call its
+// operations.
+type Document struct {
+	Title      string
+	ServerURL  string
+	Operations []Operation
+}
+
+// Operation is an operation of a document.
+type Operation struct {
+	ID          string
+	Method      string
+	Path        string
+	Summary     string
+	Description string
+	Parameters  []Parameter
+	// Body is the JSON schema of the request body, if it takes a JSON one.
+	Body         map[string]any
+	BodyRequired bool
+}
+
+// Parameter is a path, query or header parameter of an operation.
+type Parameter struct {
+	Name        string
+	In          string
+	Description string
+	Required    bool
+	Schema      map[string]any
+}
+
+// Load reads the OpenAPI document at the URL or path, relative to the
+// working directory, in JSON or YAML.
+func Load(ctx context.Context, source, workingDir string) (*Document, error) {
+	data, err := read(ctx, source, workingDir)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+func read(ctx context.Context, source, workingDir string) ([]byte, error) {
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		if !filepath.IsAbs(source) {
+			source = filepath.Join(workingDir, source)
+		}
+		return os.ReadFile(source)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not fetch %s: %s", source, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 10*1024*1024))
+}
+
+// Parse parses an OpenAPI 3 document in JSON or YAML. Operations without an
+// operationId get one from their method and path.
+func Parse(data []byte) (*Document, error) {
+	// JSON is YAML, so this handles both.
+	var root map[string]any
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+	version, _ := root["openapi"].(string)
+	if !strings.HasPrefix(version, "3.") {
+		return nil, errors.New("only OpenAPI 3 documents are supported")
+	}
+
+	p := parser{root: root}
+	doc := &Document{}
+	if info, ok := root["info"].(map[string]any); ok {
+		doc.Title, _ = info["title"].(string)
+	}
+	if servers, ok := root["servers"].([]any); ok && len(servers) > 0 {
+		if server, ok := servers[0].(map[string]any); ok {
+			doc.ServerURL, _ = server["url"].(string)
+		}
+	}
+
+	paths, _ := root["paths"].(map[string]any)
+	for _, path := range slices.Sorted(maps.Keys(paths)) {
+		item, ok := p.resolve(paths[path]).(map[string]any)
+		if !ok {
+			continue
+		}
+		shared := p.parameters(item["parameters"])
+		for _, method := range methods {
+			raw, ok := item[method].(map[string]any)
+			if !ok {
+				continue
+			}
+			op := Operation{
+				Method: strings.ToUpper(method),
+				Path:   path,
+			}
+			op.ID, _ = raw["operationId"].(string)
+			if op.ID == "" {
+				op.ID = method + strings.NewReplacer("/", "_", "{", "", "}", "").Replace(path)
+			}
+			op.Summary, _ = raw["summary"].(string)
+			op.Description, _ = raw["description"].(string)
+			op.Parameters = mergeParameters(shared, p.parameters(raw["parameters"]))
+			if body, ok := p.resolve(raw["requestBody"]).(map[string]any); ok {
+				op.BodyRequired, _ = body["required"].(bool)
+				if content, ok := body["content"].(map[string]any); ok {
+					for mediaType, media := range content {
+						if mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json") {
+							continue
+						}
+						if media, ok := media.(map[string]any); ok {
+							op.Body, _ = p.inline(media["schema"], 0).(map[string]any)
+						}
+						break
+					}
+				}
+			}
+			doc.Operations = append(doc.Operations, op)
+		}
+	}
+	return doc, nil
+}
+
+// mergeParameters returns the parameters of the path item, overridden by the
+// ones of the operation with the same name and location.
+func mergeParameters(shared, own []Parameter) []Parameter {
+	merged := slices.Clone(own)
+	for _, param := range shared {
+		if !slices.ContainsFunc(own, func(p Parameter) bool { return p.Name == param.Name && p.In == param.In }) {
+			merged = append(merged, param)
+		}
+	}
+	return merged
+}
+
+type parser struct {
+	root map[string]any
+}
+
+func (p parser) parameters(raw any) []Parameter {
+	list, _ := raw.([]any)
+	var params []Parameter
+	for _, item := range list {
+		param, ok := p.resolve(item).(map[string]any)
+		if !ok {
+			continue
+		}
+		in, _ := param["in"].(string)
+		if in != "path" && in != "query" && in != "header" {
+			continue
+		}
+		var out Parameter
+		out.Name, _ = param["name"].(string)
+		out.In = in
+		out.Description, _ = param["description"].(string)
+		out.Required, _ = param["required"].(bool)
+		out.Schema, _ = p.inline(param["schema"], 0).(map[string]any)
+		if out.Schema == nil {
+			out.Schema = map[string]any{"type": "string"}
+		}
+		params = append(params, out)
+	}
+	return params
+}
+
+// resolve follows the value if it's a local reference.
+func (p parser) resolve(value any) any {
+	for range maxRefDepth {
+		m, ok := value.(map[string]any)
+		if !ok {
+			return value
+		}
+		ref, ok := m["$ref"].(string)
+		if !ok {
+			return value
+		}
+		value = p.lookup(ref)
+	}
+	return value
+}
+
+// lookup returns the value a local reference like #/components/schemas/Pet
+// points to.
+func (p parser) lookup(ref string) any {
+	path, ok := strings.CutPrefix(ref, "#/")
+	if !ok {
+		return nil
+	}
+	var value any = p.root
+	for part := range strings.SplitSeq(path, "/") {
+		m, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		part = strings.NewReplacer("~1", "/", "~0", "~").Replace(part)
+		value = m[part]
+	}
+	return value
+}
+
+// inline returns the schema with its references replaced with what they
+// point to, as tool parameters must be self-contained. Past maxRefDepth,
+// references become schemas accepting anything.
+func (p parser) inline(schema any, depth int) any {
+	s, ok := schema.(map[string]any)
+	if !ok {
+		return schema
+	}
+	if ref, ok := s["$ref"].(string); ok {
+		if depth >= maxRefDepth {
+			return map[string]any{"description": "See " + ref}
+		}
+		return p.inline(p.lookup(ref), depth+1)
+	}
+	out := make(map[string]any, len(s))
+	for key, value := range s {
+		switch key {
+		case "example", "examples", "xml", "externalDocs":
+			// They only grow the tool definitions.
+		case "properties":
+			props, _ := value.(map[string]any)
+			inlined := make(map[string]any, len(props))
+			for name, prop := range props {
+				inlined[name] = p.inline(prop, depth)
+			}
+			out[key] = inlined
+		case "items", "additionalProperties", "not":
+			out[key] = p.inline(value, depth)
+		case "allOf", "anyOf", "oneOf":
+			list, _ := value.([]any)
+			inlined := make([]any, len(list))
+			for i, item := range list {
+				inlined[i] = p.inline(item, depth)
+			}
+			out[key] = inlined
+		default:
+			if !strings.HasPrefix(key, "x-") {
+				out[key] = value
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,121 @@
+package openapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const petstore = `
+openapi: 3.0.3
+info:
+  title: Petstore
+servers:
+  - url: https://pets.example.com/v1
+paths:
+  /pets/{petId}:
+    parameters:
+      - $ref: '#/components/parameters/PetID'
+    get:
+      operationId: getPet
+      summary: Get a pet
+      responses: {}
+    put:
+      parameters:
+        - name: dryRun
+          in: query
+          schema:
+            type: boolean
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Pet'
+      responses: {}
+components:
+  parameters:
+    PetID:
+      name: petId
+      in: path
+      required: true
+      description: The ID of the pet
+      schema:
+        type: integer
+  schemas:
+    Pet:
+      type: object
+      example: {name: Rex}
+      properties:
+        name:
+          type: string
+        example:
+          type: string
+        parent:
+          $ref: '#/components/schemas/Pet'
+`
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	doc, err := Parse([]byte(petstore))
+	require.NoError(t, err)
+	require.Equal(t, "Petstore", doc.Title)
+	require.Equal(t, "https://pets.example.com/v1", doc.ServerURL)
+	require.Len(t, doc.Operations, 2)
+
+	get := doc.Operations[0]
+	require.Equal(t, "getPet", get.ID)
+	require.Equal(t, "GET", get.Method)
+	require.Equal(t, []Parameter{{
+		Name:        "petId",
+		In:          "path",
+		Description: "The ID of the pet",
+		Required:    true,
+		Schema:      map[string]any{"type": "integer"},
+	}}, get.Parameters)
+	require.Nil(t, get.Body)
+
+	put := doc.Operations[1]
+	require.Equal(t, "put_pets_petId", put.ID)
+	require.Equal(t, []string{"dryRun", "petId"}, []string{put.Parameters[0].Name, put.Parameters[1].Name})
+	require.True(t, put.BodyRequired)
+	require.NotContains(t, put.Body, "example")
+	props := put.Body["properties"].(map[string]any)
+	require.Contains(t, props, "example", "properties named like dropped keys are kept")
+	parent := props["parent"].(map[string]any)
+	require.Equal(t, "object", parent["type"], "references are inlined")
+}
+
+func TestParseRecursiveReferences(t *testing.T) {
+	t.Parallel()
+
+	_, err := Parse([]byte(`
+openapi: 3.1.0
+paths:
+  /nodes:
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Node'
+components:
+  schemas:
+    Node:
+      type: object
+      properties:
+        children:
+          type: array
+          items:
+            $ref: '#/components/schemas/Node'
+`))
+	require.NoError(t, err)
+}
+
+func TestParseRejectsSwagger(t *testing.T) {
+	t.Parallel()
+
+	_, err := Parse([]byte(`{"swagger": "2.0", "paths": {}}`))
+	require.Error(t, err)
+}
@@ -0,0 +1,59 @@
+package tools
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/agent/tools/openapi"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAPIToolName(t *testing.T) {
+	t.Parallel()
+
+	tool := &OpenAPITool{service: "billing.v2", op: openapi.Operation{ID: "get invoice"}}
+	require.Equal(t, "api_billing_v2_get_invoice", tool.Name())
+
+	tool.op.ID = strings.Repeat("x", 100)
+	require.Len(t, tool.Name(), 64)
+}
+
+func TestOpenAPIToolRequest(t *testing.T) {
+	t.Parallel()
+
+	tool := &OpenAPITool{
+		service: "pets",
+		baseURL: "https://pets.example.com/v1",
+		op: openapi.Operation{
+			ID:     "updatePet",
+			Method: "PUT",
+			Path:   "/pets/{petId}",
+			Parameters: []openapi.Parameter{
+				{Name: "petId", In: "path", Required: true},
+				{Name: "tags", In: "query"},
+				{Name: "body", In: "header"},
+			},
+			Body: map[string]any{"type": "object"},
+		},
+	}
+	require.Equal(t, "request_body", tool.bodyName())
+
+	req, body, err := tool.request(t.Context(), map[string]any{
+		"petId":        "a/b",
+		"tags":         []any{"x", "y"},
+		"body":         "header value",
+		"request_body": map[string]any{"name": "Rex"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "https://pets.example.com/v1/pets/a%2Fb?tags=x&tags=y", req.URL.String())
+	require.Equal(t, "header value", req.Header.Get("body"))
+	require.Equal(t, "application/json", req.Header.Get("Content-Type"))
+	require.Equal(t, `{"name":"Rex"}`, body)
+	sent, err := io.ReadAll(req.Body)
+	require.NoError(t, err)
+	require.Equal(t, body, string(sent))
+
+	_, _, err = tool.request(t.Context(), map[string]any{})
+	require.ErrorContains(t, err, "petId is required")
+}
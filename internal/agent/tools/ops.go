@@ -0,0 +1,45 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// runCLI runs an ops CLI like kubectl or terraform in dir, and returns its
+// output along with its error output and exit code when it fails.
+func runCLI(ctx context.Context, dir, name string, args ...string) (string, error) {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return "", fmt.Errorf("%s is not installed", name)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Dir = dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return "", err
+	}
+
+	parts := []string{strings.TrimRight(stdout.String(), "\n")}
+	if stderr.Len() > 0 {
+		parts = append(parts, strings.TrimRight(stderr.String(), "\n"))
+	}
+	if exitErr != nil {
+		parts = append(parts, fmt.Sprintf("Exit code %d", exitErr.ExitCode()))
+	}
+	output := strings.TrimSpace(strings.Join(parts, "\n\n"))
+	if output == "" {
+		return BashNoOutput, nil
+	}
+	return truncate(output, MaxOutputLength, TruncateMiddle), nil
+}
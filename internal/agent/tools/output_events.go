@@ -0,0 +1,72 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/pubsub"
+)
+
+const (
+	// outputEventInterval is how often the output of a running command is
+	// published.
+	outputEventInterval = 250 * time.Millisecond
+	// outputEventLines is the number of lines at the end of the output of a
+	// running command that are published.
+	outputEventLines = 10
+)
+
+// OutputEvent is published while a command runs, with what it printed so
+// far, to show it live.
+type OutputEvent struct {
+	ToolCallID string
+	// Tail is the end of the output, a few lines long.
+	Tail string
+	// Bytes is the size of the whole output so far.
+	Bytes int
+}
+
+var outputBroker = pubsub.NewBroker[OutputEvent]()
+
+// SubscribeOutputEvents subscribes to the output of running commands.
+func SubscribeOutputEvents(ctx context.Context) <-chan pubsub.Event[OutputEvent] {
+	return outputBroker.Subscribe(ctx)
+}
+
+// outputPublisher publishes the output of a running command every
+// outputEventInterval, once it printed something.
+type outputPublisher struct {
+	toolCallID string
+	last       time.Time
+}
+
+func (p *outputPublisher) publish(stdout, stderr string) {
+	size := len(stdout) + len(stderr)
+	if p.toolCallID == "" || size == 0 || time.Since(p.last) < outputEventInterval {
+		return
+	}
+	p.last = time.Now()
+	output := stdout
+	if stderr != "" {
+		output = strings.TrimRight(output, "\n") + "\n" + stderr
+	}
+	outputBroker.Publish(pubsub.UpdatedEvent, OutputEvent{
+		ToolCallID: p.toolCallID,
+		Tail:       tailLines(output, outputEventLines),
+		Bytes:      size,
+	})
+}
+
+// tailLines returns the last n lines of s.
+func tailLines(s string, n int) string {
+	s = strings.TrimRight(s, "\n")
+	i := len(s)
+	for range n {
+		i = strings.LastIndexByte(s[:i], '\n')
+		if i < 0 {
+			return s
+		}
+	}
+	return s[i+1:]
+}
@@ -0,0 +1,30 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTailLines(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "c\nd", tailLines("a\nb\nc\nd\n", 2))
+	require.Equal(t, "a\nb", tailLines("a\nb", 5))
+	require.Equal(t, "", tailLines("", 3))
+}
+
+func TestOutputPublisher(t *testing.T) {
+	t.Parallel()
+
+	events := SubscribeOutputEvents(t.Context())
+	p := outputPublisher{toolCallID: "call-output"}
+	p.publish("", "")
+	p.publish("building\n", "warning\n")
+	// Too soon after the last one.
+	p.publish("building\ndone\n", "warning\n")
+
+	event := <-events
+	require.Equal(t, OutputEvent{ToolCallID: "call-output", Tail: "building\nwarning", Bytes: 17}, event.Payload)
+	require.Empty(t, events)
+}
@@ -0,0 +1,103 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/plugin"
+)
+
+// PluginToolName allows or disables the tools of all the plugins, whose
+// names depend on the plugins.
+const PluginToolName = "plugin"
+
+// GetPluginTools returns the tools of the running plugins.
+func GetPluginTools(permissions permission.Service, workingDir string) []*PluginTool {
+	var result []*PluginTool
+	for _, p := range plugin.List() {
+		for _, spec := range p.Manifest.Tools {
+			result = append(result, &PluginTool{
+				plugin:      p,
+				spec:        spec,
+				permissions: permissions,
+				workingDir:  workingDir,
+			})
+		}
+	}
+	return result
+}
+
+// PluginTool is a tool of a plugin.
+type PluginTool struct {
+	plugin          *plugin.Plugin
+	spec            plugin.ToolSpec
+	permissions     permission.Service
+	workingDir      string
+	providerOptions fantasy.ProviderOptions
+}
+
+func (t *PluginTool) SetProviderOptions(opts fantasy.ProviderOptions) {
+	t.providerOptions = opts
+}
+
+func (t *PluginTool) ProviderOptions() fantasy.ProviderOptions {
+	return t.providerOptions
+}
+
+func (t *PluginTool) Name() string {
+	return plugin.ToolName(t.plugin.ID, t.spec.Name)
+}
+
+func (t *PluginTool) Info() fantasy.ToolInfo {
+	parameters := t.spec.Parameters
+	if parameters == nil {
+		parameters = map[string]any{}
+	}
+	required := t.spec.Required
+	if required == nil {
+		required = []string{}
+	}
+	return fantasy.ToolInfo{
+		Name:        t.Name(),
+		Description: t.spec.Description,
+		Parameters:  parameters,
+		Required:    required,
+		Parallel:    t.spec.Parallel,
+	}
+}
+
+func (t *PluginTool) Run(ctx context.Context, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	sessionID := GetSessionFromContext(ctx)
+	if sessionID == "" {
+		return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for calling plugin tools")
+	}
+	p := t.permissions.Request(
+		permission.CreatePermissionRequest{
+			SessionID:   sessionID,
+			ToolCallID:  call.ID,
+			Path:        t.workingDir,
+			ToolName:    t.Name(),
+			Action:      "execute",
+			Description: fmt.Sprintf("Run %s of the %s plugin", t.spec.Name, t.plugin.Manifest.Name),
+			Params:      call.Input,
+		},
+	)
+	if !p {
+		return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
+	}
+
+	result, err := t.plugin.CallTool(ctx, t.spec.Name, call.Input, sessionID)
+	if err != nil {
+		return fantasy.NewTextErrorResponse(err.Error()), nil
+	}
+	response := fantasy.NewTextResponse(result.Content)
+	if result.IsError {
+		response = fantasy.NewTextErrorResponse(result.Content)
+	}
+	if len(result.Metadata) > 0 {
+		response.Metadata = string(result.Metadata)
+	}
+	return response, nil
+}
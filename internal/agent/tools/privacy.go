@@ -0,0 +1,133 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/privacy"
+	"github.com/charmbracelet/crush/internal/stringext"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// privacyZoneError explains to the model why a file was not accessed.
+func privacyZoneError(path, pattern string) string {
+	return fmt.Sprintf("%s is in a privacy zone (matches %q) and the user did not allow access to it. "+
+		"Do not try to access it with other tools; ask the user for any details you need from it.", path, pattern)
+}
+
+// privacyZoneDenied asks the user for an explicit, one-off approval when a
+// tool is about to touch a file in a privacy zone. It reports whether the
+// tool must stop, with the response explaining why to the model.
+func privacyZoneDenied(ctx context.Context, permissions permission.Service, zones *privacy.Zones, call fantasy.ToolCall, toolName, action, path string, params any) (fantasy.ToolResponse, bool) {
+	pattern, inZone := zones.Match(path)
+	if !inZone {
+		return fantasy.ToolResponse{}, false
+	}
+	granted := permissions.Request(
+		permission.CreatePermissionRequest{
+			SessionID:   GetSessionFromContext(ctx),
+			Path:        path,
+			ToolCallID:  call.ID,
+			ToolName:    toolName,
+			Action:      action,
+			Description: fmt.Sprintf("%s file in privacy zone %q: %s", stringext.Capitalize(action), pattern, path),
+			Params:      params,
+			Sensitive:   true,
+		},
+	)
+	if granted {
+		return fantasy.ToolResponse{}, false
+	}
+	return fantasy.NewTextErrorResponse(privacyZoneError(path, pattern)), true
+}
+
+// withholdPrivateMatches drops grep matches from files in a privacy zone,
+// returning the remaining matches and how many were withheld.
+func withholdPrivateMatches(zones *privacy.Zones, matches []grepMatch) ([]grepMatch, int) {
+	if zones == nil {
+		return matches, 0
+	}
+	kept := matches[:0]
+	for _, m := range matches {
+		if _, ok := zones.Match(m.path); ok {
+			continue
+		}
+		kept = append(kept, m)
+	}
+	return kept, len(matches) - len(kept)
+}
+
+// commandZonePath returns the first path in a privacy zone that the command
+// names literally, as an argument, a redirection or the value of a flag.
+// Paths the command only reaches indirectly, through variables, globs or
+// scripts, are not found.
+func commandZonePath(zones *privacy.Zones, workingDir, command string) (string, bool) {
+	if zones == nil {
+		return "", false
+	}
+	file, err := syntax.NewParser().Parse(strings.NewReader(command), "")
+	if err != nil {
+		return "", false
+	}
+	var found string
+	syntax.Walk(file, func(node syntax.Node) bool {
+		if found != "" {
+			return false
+		}
+		word, ok := node.(*syntax.Word)
+		if !ok {
+			return true
+		}
+		lit, ok := literalWord(word)
+		if !ok {
+			// Look for commands substituted into the word.
+			return true
+		}
+		var candidates []string
+		if _, value, ok := strings.Cut(lit, "="); ok && value != "" {
+			candidates = append(candidates, value)
+		}
+		candidates = append(candidates, lit)
+		for _, candidate := range candidates {
+			path := candidate
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(workingDir, path)
+			}
+			if zones.Contains(path) {
+				found = path
+				break
+			}
+		}
+		return false
+	})
+	return found, found != ""
+}
+
+// literalWord returns the value of a word made only of literal and quoted
+// text.
+func literalWord(word *syntax.Word) (string, bool) {
+	var sb strings.Builder
+	for _, part := range word.Parts {
+		switch part := part.(type) {
+		case *syntax.Lit:
+			sb.WriteString(part.Value)
+		case *syntax.SglQuoted:
+			sb.WriteString(part.Value)
+		case *syntax.DblQuoted:
+			for _, inner := range part.Parts {
+				lit, ok := inner.(*syntax.Lit)
+				if !ok {
+					return "", false
+				}
+				sb.WriteString(lit.Value)
+			}
+		default:
+			return "", false
+		}
+	}
+	return sb.String(), sb.Len() > 0
+}
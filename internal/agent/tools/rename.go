@@ -0,0 +1,89 @@
+package tools
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"os"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/filepathext"
+	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/lsp"
+	"github.com/charmbracelet/crush/internal/permission"
+)
+
+type RenameParams struct {
+	FilePath string `json:"file_path" description:"The path to a file where the symbol appears"`
+	Line     int    `json:"line" description:"The 1-based line of the file where the symbol appears"`
+	Symbol   string `json:"symbol" description:"The current name of the symbol"`
+	NewName  string `json:"new_name" description:"The new name of the symbol"`
+}
+
+const RenameToolName = "lsp_rename"
+
+//go:embed rename.md
+var renameDescription []byte
+
+func NewRenameTool(lspClients *csync.Map[string, *lsp.Client], permissions permission.Service, files history.Service, workingDir string) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		RenameToolName,
+		string(renameDescription),
+		func(ctx context.Context, params RenameParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			workingDir := GetWorkingDirFromContext(ctx, workingDir)
+			if params.FilePath == "" || params.Symbol == "" || params.NewName == "" {
+				return fantasy.NewTextErrorResponse("file_path, symbol and new_name are required"), nil
+			}
+			if params.Line < 1 {
+				return fantasy.NewTextErrorResponse("line must be 1 or more"), nil
+			}
+
+			params.FilePath = filepathext.SmartJoin(workingDir, params.FilePath)
+			client := lspClientForFile(lspClients, params.FilePath)
+			if client == nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("no LSP client handles %s", params.FilePath)), nil
+			}
+
+			content, err := os.ReadFile(params.FilePath)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("failed to read file: %s", err)), nil
+			}
+			lines := strings.Split(string(content), "\n")
+			if params.Line > len(lines) {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("line %d is past the end of the file (%d lines)", params.Line, len(lines))), nil
+			}
+			column := strings.Index(lines[params.Line-1], params.Symbol)
+			if column < 0 {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("symbol '%s' not found on line %d", params.Symbol, params.Line)), nil
+			}
+			column += getSymbolOffset(params.Symbol)
+
+			edit, err := client.Rename(ctx, params.FilePath, params.Line, column+1, params.NewName)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("failed to rename: %s", err)), nil
+			}
+			if edit == nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("'%s' can't be renamed at line %d", params.Symbol, params.Line)), nil
+			}
+			changed, err := workspaceEditFiles(*edit)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+			if len(changed) == 0 {
+				return fantasy.NewTextErrorResponse("the rename changes no files"), nil
+			}
+
+			description := fmt.Sprintf("Rename %s to %s", params.Symbol, params.NewName)
+			editCtx := editContext{ctx, permissions, files, workingDir}
+			if err := applyWorkspaceEdit(editCtx, lspClients, call, RenameToolName, description, changed); err != nil {
+				return fantasy.ToolResponse{}, err
+			}
+
+			return fantasy.WithResponseMetadata(
+				fantasy.NewTextResponse(formatWorkspaceEdit(fmt.Sprintf("Renamed %s to %s", params.Symbol, params.NewName), changed, lspClients)),
+				WorkspaceEditResponseMetadata{Files: changed},
+			), nil
+		})
+}
@@ -3,12 +3,14 @@ package tools
 import (
 	"context"
 	"log/slog"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 	"sync"
 
 	"github.com/charmbracelet/crush/internal/log"
+	"github.com/charmbracelet/crush/internal/shell"
 )
 
 var getRg = sync.OnceValue(func() string {
@@ -22,11 +24,28 @@ var getRg = sync.OnceValue(func() string {
 	return path
 })
 
-func getRgCmd(ctx context.Context, globPattern string) *exec.Cmd {
+// rgCommand returns the command that runs rg with the arguments in dir, or
+// nil when rg isn't available. On a remote workspace, rg runs on the remote
+// machine, which is much faster than searching through the mount.
+func rgCommand(ctx context.Context, dir string, args ...string) *exec.Cmd {
+	if remote, ok := shell.GetBackgroundShellManager().Backend().(*shell.Remote); ok {
+		cmd, err := remote.Command(ctx, dir, nil, shell.Quote(append([]string{"rg"}, args...)...))
+		if err != nil {
+			slog.Warn("Failed to run ripgrep on the remote workspace", "error", err)
+			return nil
+		}
+		return cmd
+	}
 	name := getRg()
 	if name == "" {
 		return nil
 	}
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	return cmd
+}
+
+func getRgCmd(ctx context.Context, dir, globPattern string) *exec.Cmd {
 	args := []string{"--files", "-L", "--null"}
 	if globPattern != "" {
 		if !filepath.IsAbs(globPattern) && !strings.HasPrefix(globPattern, "/") {
@@ -34,20 +53,29 @@ func getRgCmd(ctx context.Context, globPattern string) *exec.Cmd {
 		}
 		args = append(args, "--glob", globPattern)
 	}
-	return exec.CommandContext(ctx, name, args...)
+	return rgCommand(ctx, dir, args...)
 }
 
+// getRgSearchCmd returns the command searching path for pattern. It runs in
+// the directory of path, given to rg relative to it, so that the search works
+// the same on remote workspaces.
 func getRgSearchCmd(ctx context.Context, pattern, path, include string) *exec.Cmd {
-	name := getRg()
-	if name == "" {
-		return nil
+	dir, target := path, "."
+	if info, err := os.Stat(path); err == nil && !info.IsDir() {
+		dir, target = filepath.Dir(path), filepath.Base(path)
 	}
 	// Use -n to show line numbers, -0 for null separation to handle Windows paths
 	args := []string{"--json", "-H", "-n", "-0", pattern}
 	if include != "" {
 		args = append(args, "--glob", include)
 	}
-	args = append(args, path)
+	// Only add ignore files if they exist
+	for _, ignoreFile := range []string{".gitignore", ".crushignore"} {
+		if _, err := os.Stat(filepath.Join(dir, ignoreFile)); err == nil {
+			args = append(args, "--ignore-file", ignoreFile)
+		}
+	}
+	args = append(args, "--", target)
 
-	return exec.CommandContext(ctx, name, args...)
+	return rgCommand(ctx, dir, args...)
 }
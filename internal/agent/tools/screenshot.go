@@ -0,0 +1,83 @@
+package tools
+
+import (
+	"context"
+	_ "embed"
+	"encoding/base64"
+	"fmt"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/imagefit"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/screenshot"
+)
+
+type ScreenshotParams struct {
+	Target string `json:"target,omitempty" description:"What to capture: screen (default) or window, picked by the user"`
+}
+
+type ScreenshotPermissionsParams struct {
+	Target string `json:"target"`
+}
+
+const ScreenshotToolName = "screenshot"
+
+//go:embed screenshot.md
+var screenshotDescription []byte
+
+func NewScreenshotTool(permissions permission.Service, workingDir string) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		ScreenshotToolName,
+		string(screenshotDescription),
+		func(ctx context.Context, params ScreenshotParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			target := screenshot.Target(params.Target)
+			switch target {
+			case "":
+				target = screenshot.Screen
+			case screenshot.Screen, screenshot.Window:
+			default:
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("unknown target %q, use screen or window", params.Target)), nil
+			}
+			if !GetSupportsImagesFromContext(ctx) {
+				modelName := GetModelNameFromContext(ctx)
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("This model (%s) does not support image data.", modelName)), nil
+			}
+
+			sessionID := GetSessionFromContext(ctx)
+			if sessionID == "" {
+				return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for taking screenshots")
+			}
+			p := permissions.Request(
+				permission.CreatePermissionRequest{
+					SessionID:   sessionID,
+					Path:        GetWorkingDirFromContext(ctx, workingDir),
+					ToolCallID:  call.ID,
+					ToolName:    ScreenshotToolName,
+					Action:      "capture",
+					Description: fmt.Sprintf("Capture the %s", target),
+					Params:      ScreenshotPermissionsParams{Target: string(target)},
+				},
+			)
+			if !p {
+				return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
+			}
+
+			data, err := screenshot.Capture(ctx, target)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("Failed to take a screenshot: %v", err)), nil
+			}
+			// The provider isn't known here, so the screenshot is fitted to
+			// limits every provider takes.
+			image, err := imagefit.Fit(message.Attachment{
+				FileName: "screenshot.png",
+				MimeType: "image/png",
+				Content:  data,
+			}, imagefit.LimitsFor("", ""))
+			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("Failed to fit the screenshot: %v", err)), nil
+			}
+			return fantasy.NewImageResponse([]byte(base64.StdEncoding.EncodeToString(image.Content)), image.MimeType), nil
+		},
+	)
+}
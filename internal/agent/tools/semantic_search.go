@@ -0,0 +1,78 @@
+package tools
+
+import (
+	"cmp"
+	"context"
+	_ "embed"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/semantic"
+)
+
+type SemanticSearchParams struct {
+	Query string `json:"query" description:"What the code you're looking for does, in plain words, like \"refreshing expired auth tokens\""`
+	Limit int    `json:"limit,omitempty" description:"Maximum number of results (default 10)"`
+}
+
+type SemanticSearchResponseMetadata struct {
+	NumberOfResults int `json:"number_of_results"`
+}
+
+const (
+	SemanticSearchToolName = "semantic_search"
+
+	defaultSemanticSearchLimit = 10
+	// semanticSnippetLines is how much of each matching chunk is shown;
+	// the rest can be read with View.
+	semanticSnippetLines = 20
+)
+
+//go:embed semantic_search.md
+var semanticSearchDescription []byte
+
+func NewSemanticSearchTool(index *semantic.Index) fantasy.AgentTool {
+	return fantasy.NewParallelAgentTool(
+		SemanticSearchToolName,
+		string(semanticSearchDescription),
+		func(ctx context.Context, params SemanticSearchParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			if params.Query == "" {
+				return fantasy.NewTextErrorResponse("query is required"), nil
+			}
+			if index == nil {
+				return fantasy.NewTextErrorResponse("semantic search is not configured, use Grep instead"), nil
+			}
+			// Only the files changed since the last search are embedded, so
+			// this is quick unless the workspace was never indexed.
+			if err := index.Update(ctx); err != nil {
+				if index.Len() == 0 {
+					return fantasy.NewTextErrorResponse(fmt.Sprintf("failed to index the workspace: %v", err)), nil
+				}
+				slog.Warn("Failed to update the semantic search index", "error", err)
+			}
+
+			results, err := index.Search(ctx, params.Query, cmp.Or(params.Limit, defaultSemanticSearchLimit))
+			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("failed to search: %v", err)), nil
+			}
+			if len(results) == 0 {
+				return fantasy.NewTextResponse(fmt.Sprintf("No code found matching %q", params.Query)), nil
+			}
+			var out strings.Builder
+			for _, r := range results {
+				fmt.Fprintf(&out, "%s:%d-%d\n", r.Path, r.StartLine, r.EndLine)
+				lines := strings.Split(r.Text, "\n")
+				out.WriteString(addLineNumbers(strings.Join(lines[:min(len(lines), semanticSnippetLines)], "\n"), r.StartLine))
+				if len(lines) > semanticSnippetLines {
+					fmt.Fprintf(&out, "\n(%d more lines)", len(lines)-semanticSnippetLines)
+				}
+				out.WriteString("\n\n")
+			}
+			return fantasy.WithResponseMetadata(
+				fantasy.NewTextResponse(strings.TrimSuffix(out.String(), "\n\n")),
+				SemanticSearchResponseMetadata{NumberOfResults: len(results)},
+			), nil
+		})
+}
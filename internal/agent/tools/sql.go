@@ -0,0 +1,292 @@
+package tools
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	_ "embed"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/permission"
+)
+
+type SQLParams struct {
+	Database string `json:"database" description:"The name of the database profile to query"`
+	Query    string `json:"query" description:"The SQL statement to run"`
+}
+
+type SQLPermissionsParams struct {
+	Database string `json:"database"`
+	Driver   string `json:"driver"`
+	Query    string `json:"query"`
+}
+
+// SQLResponseMetadata is a table, with the columns and rows of the result.
+type SQLResponseMetadata struct {
+	ContentType string     `json:"content_type,omitempty"`
+	Database    string     `json:"database"`
+	Driver      string     `json:"driver"`
+	Columns     []string   `json:"columns,omitempty"`
+	Rows        [][]string `json:"rows,omitempty"`
+	Truncated   bool       `json:"truncated"`
+}
+
+const (
+	SQLToolName = "sql"
+
+	// DefaultSQLMaxRows is the number of rows of a result sent to the
+	// model, unless configured otherwise.
+	DefaultSQLMaxRows = 100
+
+	// sqlMaxCellLength keeps huge values, like JSON documents or blobs,
+	// from filling the context.
+	sqlMaxCellLength = 200
+)
+
+// sqlReadOnlyKeywords are the statements allowed on databases that don't
+// allow writes. The database enforces it too, where it can.
+var sqlReadOnlyKeywords = []string{"select", "with", "explain", "show", "describe", "desc", "pragma", "values", "table"}
+
+//go:embed sql.md
+var sqlDescription []byte
+
+// sqlResult is the result of a query, with its values formatted as text.
+type sqlResult struct {
+	Columns   []string
+	Rows      [][]string
+	Truncated bool
+}
+
+func NewSQLTool(permissions permission.Service, workingDir string, profiles map[string]config.SQLProfile) fantasy.AgentTool {
+	var names []string
+	for _, name := range slices.Sorted(maps.Keys(profiles)) {
+		mode := "read-only"
+		if profiles[name].AllowWrites {
+			mode = "read-write"
+		}
+		names = append(names, fmt.Sprintf("%s (%s, %s)", name, profiles[name].Driver, mode))
+	}
+	description := strings.ReplaceAll(string(sqlDescription), "{{databases}}", strings.Join(names, ", "))
+	return fantasy.NewAgentTool(
+		SQLToolName,
+		description,
+		func(ctx context.Context, params SQLParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			workingDir := GetWorkingDirFromContext(ctx, workingDir)
+			if params.Query == "" {
+				return fantasy.NewTextErrorResponse("query is required"), nil
+			}
+			profile, ok := profiles[params.Database]
+			if !ok {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("unknown database %q, use one of: %s", params.Database, strings.Join(slices.Sorted(maps.Keys(profiles)), ", "))), nil
+			}
+			if !profile.AllowWrites && !isReadOnlySQL(params.Query) {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("database %s is read-only: only queries like SELECT, WITH, EXPLAIN or SHOW are allowed", params.Database)), nil
+			}
+
+			sessionID := GetSessionFromContext(ctx)
+			if sessionID == "" {
+				return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for running SQL queries")
+			}
+			action := "read"
+			if profile.AllowWrites {
+				action = "write"
+			}
+			p := permissions.Request(
+				permission.CreatePermissionRequest{
+					SessionID:   sessionID,
+					Path:        workingDir,
+					ToolCallID:  call.ID,
+					ToolName:    SQLToolName,
+					Action:      action,
+					Description: fmt.Sprintf("Query %s: %s", params.Database, params.Query),
+					Params: SQLPermissionsParams{
+						Database: params.Database,
+						Driver:   profile.Driver,
+						Query:    params.Query,
+					},
+				},
+			)
+			if !p {
+				return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
+			}
+
+			maxRows := cmp.Or(profile.MaxRows, DefaultSQLMaxRows)
+			result, err := runSQL(ctx, workingDir, profile, params.Query, maxRows)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("error querying %s: %v", params.Database, err)), nil
+			}
+			return fantasy.WithResponseMetadata(
+				fantasy.NewTextResponse(formatSQLResult(result)),
+				SQLResponseMetadata{
+					ContentType: ContentTypeTable,
+					Database:    params.Database,
+					Driver:      profile.Driver,
+					Columns:     result.Columns,
+					Rows:        result.Rows,
+					Truncated:   result.Truncated,
+				},
+			), nil
+		})
+}
+
+// runSQL runs the query on the database of the profile, and returns at most
+// maxRows rows of its result.
+func runSQL(ctx context.Context, workingDir string, profile config.SQLProfile, query string, maxRows int) (sqlResult, error) {
+	dsn := os.ExpandEnv(profile.DSN)
+	switch profile.Driver {
+	case "sqlite":
+		if !filepath.IsAbs(dsn) && !strings.HasPrefix(dsn, "file:") {
+			dsn = filepath.Join(workingDir, dsn)
+		}
+		return querySQLite(ctx, dsn, profile.AllowWrites, query, maxRows)
+	case "postgres":
+		return queryPostgres(ctx, workingDir, dsn, profile.AllowWrites, query, maxRows)
+	case "mysql":
+		return queryMySQL(ctx, workingDir, dsn, profile.AllowWrites, query, maxRows)
+	default:
+		return sqlResult{}, fmt.Errorf("unsupported driver %q", profile.Driver)
+	}
+}
+
+// scanSQLRows reads at most maxRows rows, with their values formatted as
+// text.
+func scanSQLRows(rows *sql.Rows, maxRows int) (sqlResult, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return sqlResult{}, err
+	}
+	result := sqlResult{Columns: columns}
+	values := make([]any, len(columns))
+	dest := make([]any, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	for rows.Next() {
+		if len(result.Rows) == maxRows {
+			result.Truncated = true
+			break
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return sqlResult{}, err
+		}
+		row := make([]string, len(values))
+		for i, v := range values {
+			row[i] = formatSQLValue(v)
+		}
+		result.Rows = append(result.Rows, row)
+	}
+	return result, rows.Err()
+}
+
+func formatSQLValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case []byte:
+		return string(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// isReadOnlySQL reports whether the query is made of statements that only
+// read, judging by their first keyword.
+func isReadOnlySQL(query string) bool {
+	statements := splitSQL(query)
+	for _, statement := range statements {
+		fields := strings.Fields(statement)
+		keyword := strings.ToLower(strings.TrimLeft(fields[0], "("))
+		if !slices.Contains(sqlReadOnlyKeywords, keyword) {
+			return false
+		}
+		// PRAGMA can also change settings of SQLite.
+		if keyword == "pragma" && strings.Contains(statement, "=") {
+			return false
+		}
+	}
+	return len(statements) > 0
+}
+
+// splitSQL splits the query into its statements, without their comments,
+// which could hide the statement that runs. Semicolons and comment markers
+// in quoted strings and identifiers are left alone.
+func splitSQL(query string) []string {
+	var statements []string
+	var b strings.Builder
+	flush := func() {
+		if statement := strings.TrimSpace(b.String()); statement != "" {
+			statements = append(statements, statement)
+		}
+		b.Reset()
+	}
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			end := i + 1 + strings.IndexByte(query[i+1:], c)
+			if end <= i {
+				end = len(query) - 1
+			}
+			b.WriteString(query[i : end+1])
+			i = end
+		case strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				end = len(query) - i
+			}
+			i += end
+			b.WriteByte('\n')
+		case strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				end = len(query) - i - 2
+			}
+			i += end + 3
+			b.WriteByte(' ')
+		case c == ';':
+			flush()
+		default:
+			b.WriteByte(c)
+		}
+	}
+	flush()
+	return statements
+}
+
+// formatSQLResult formats the result as a compact table for the model.
+func formatSQLResult(result sqlResult) string {
+	if len(result.Columns) == 0 {
+		return "Statement executed, no rows returned."
+	}
+	var b strings.Builder
+	b.WriteString(strings.Join(result.Columns, " | "))
+	for _, row := range result.Rows {
+		b.WriteByte('\n')
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cell = strings.NewReplacer("\r", `\r`, "\n", `\n`, "|", `\|`).Replace(cell)
+			if len(cell) > sqlMaxCellLength {
+				cell = headBytes(cell, sqlMaxCellLength) + "…"
+			}
+			cells[i] = cell
+		}
+		b.WriteString(strings.Join(cells, " | "))
+	}
+	b.WriteString("\n\n")
+	switch {
+	case result.Truncated:
+		fmt.Fprintf(&b, "(first %d rows, more were truncated: add a LIMIT or filter the query)", len(result.Rows))
+	case len(result.Rows) == 1:
+		b.WriteString("(1 row)")
+	default:
+		fmt.Fprintf(&b, "(%d rows)", len(result.Rows))
+	}
+	return b.String()
+}
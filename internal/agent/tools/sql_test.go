@@ -0,0 +1,51 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsReadOnlySQL(t *testing.T) {
+	t.Parallel()
+
+	for _, query := range []string{
+		"SELECT * FROM users",
+		"  with recent as (select 1) select * from recent;",
+		"EXPLAIN ANALYZE SELECT 1",
+		"-- count them\nSELECT count(*) FROM users; SHOW TABLES",
+		"SELECT 'a;DELETE FROM users' AS q",
+		"(SELECT 1) UNION (SELECT 2)",
+		"PRAGMA table_info(users)",
+	} {
+		require.True(t, isReadOnlySQL(query), query)
+	}
+	for _, query := range []string{
+		"",
+		"-- nothing",
+		"DELETE FROM users",
+		"SELECT 1; DROP TABLE users",
+		"/* SELECT */ UPDATE users SET admin = true",
+		"SELECT 1 -- ok\n; INSERT INTO users VALUES (1)",
+		"PRAGMA journal_mode = DELETE",
+	} {
+		require.False(t, isReadOnlySQL(query), query)
+	}
+}
+
+func TestFormatSQLResult(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "Statement executed, no rows returned.", formatSQLResult(sqlResult{}))
+	require.Equal(t, "id | name\n1 | Ann\\nLee\n2 | a \\| b\n\n(2 rows)", formatSQLResult(sqlResult{
+		Columns: []string{"id", "name"},
+		Rows:    [][]string{{"1", "Ann\nLee"}, {"2", "a | b"}},
+	}))
+
+	result := recordsToSQLResult([][]string{{"n"}, {"1"}, {"2"}, {"3"}}, 2)
+	require.True(t, result.Truncated)
+	require.Equal(t, [][]string{{"1"}, {"2"}}, result.Rows)
+	out := formatSQLResult(result)
+	require.True(t, strings.HasSuffix(out, "(first 2 rows, more were truncated: add a LIMIT or filter the query)"), out)
+}
@@ -0,0 +1,66 @@
+package tools
+
+import (
+	"cmp"
+	"context"
+	_ "embed"
+	"fmt"
+	"strings"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/privacy"
+	"github.com/charmbracelet/crush/internal/symbols"
+)
+
+type SymbolsParams struct {
+	Query string `json:"query" description:"The name of the symbol to find, or part of it. Type.method finds a method of a type"`
+	Kind  string `json:"kind,omitempty" description:"Only return symbols of this kind: function, method, type, class, interface, constant, variable or module"`
+	Limit int    `json:"limit,omitempty" description:"Maximum number of results (default 50)"`
+}
+
+type SymbolsResponseMetadata struct {
+	NumberOfSymbols int `json:"number_of_symbols"`
+}
+
+const (
+	SymbolsToolName = "symbols"
+
+	defaultSymbolsLimit = 50
+)
+
+//go:embed symbols.md
+var symbolsDescription []byte
+
+func NewSymbolsTool(index *symbols.Index, zones *privacy.Zones) fantasy.AgentTool {
+	return fantasy.NewParallelAgentTool(
+		SymbolsToolName,
+		string(symbolsDescription),
+		func(ctx context.Context, params SymbolsParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			if params.Query == "" {
+				return fantasy.NewTextErrorResponse("query is required"), nil
+			}
+			if index == nil {
+				return fantasy.NewTextErrorResponse("the symbol index is not available, use Grep instead"), nil
+			}
+			// The first indexing of big workspaces can take a moment.
+			waitCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+			defer cancel()
+			if !index.Wait(waitCtx) {
+				return fantasy.NewTextErrorResponse("the workspace is still being indexed, try again shortly or use Grep"), nil
+			}
+
+			found := index.Lookup(params.Query, symbols.Kind(params.Kind), cmp.Or(params.Limit, defaultSymbolsLimit), zones.Contains)
+			if len(found) == 0 {
+				return fantasy.NewTextResponse(fmt.Sprintf("No symbols found matching %q", params.Query)), nil
+			}
+			var out strings.Builder
+			for _, s := range found {
+				fmt.Fprintf(&out, "%s:%d: %s %s\n", s.Path, s.Line, s.Kind, s.QualifiedName())
+			}
+			return fantasy.WithResponseMetadata(
+				fantasy.NewTextResponse(strings.TrimSuffix(out.String(), "\n")),
+				SymbolsResponseMetadata{NumberOfSymbols: len(found)},
+			), nil
+		})
+}
@@ -0,0 +1,110 @@
+package tools
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/permission"
+)
+
+type TerraformParams struct {
+	Command  string   `json:"command" description:"The terraform command to run: plan or validate"`
+	Dir      string   `json:"dir,omitempty" description:"The directory of the configuration, relative to the working directory (defaults to the working directory)"`
+	VarFiles []string `json:"var_files,omitempty" description:"Variable files to plan with, relative to the directory of the configuration"`
+	Targets  []string `json:"targets,omitempty" description:"Resource addresses to limit the plan to"`
+}
+
+type TerraformPermissionsParams struct {
+	Command  string   `json:"command"`
+	Dir      string   `json:"dir"`
+	VarFiles []string `json:"var_files,omitempty"`
+	Targets  []string `json:"targets,omitempty"`
+}
+
+type TerraformResponseMetadata struct {
+	Command string `json:"command"`
+	Dir     string `json:"dir"`
+}
+
+const TerraformToolName = "terraform"
+
+//go:embed terraform.md
+var terraformDescription []byte
+
+func NewTerraformTool(permissions permission.Service, workingDir string) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		TerraformToolName,
+		string(terraformDescription),
+		func(ctx context.Context, params TerraformParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			workingDir := GetWorkingDirFromContext(ctx, workingDir)
+			var args []string
+			switch params.Command {
+			case "plan":
+				// Plans don't lock the state, so they never get in the way of
+				// an apply run by someone else.
+				args = []string{"plan", "-input=false", "-no-color", "-lock=false"}
+				for _, file := range params.VarFiles {
+					args = append(args, "-var-file="+file)
+				}
+				for _, target := range params.Targets {
+					args = append(args, "-target="+target)
+				}
+			case "validate":
+				args = []string{"validate", "-no-color"}
+			case "":
+				return fantasy.NewTextErrorResponse("command is required"), nil
+			default:
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("terraform %s is not allowed, only plan and validate", params.Command)), nil
+			}
+
+			dir := filepath.Join(workingDir, params.Dir)
+			if filepath.IsAbs(params.Dir) {
+				dir = filepath.Clean(params.Dir)
+			}
+			if rel, err := filepath.Rel(workingDir, dir); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("%s is outside of the working directory", params.Dir)), nil
+			}
+
+			sessionID := GetSessionFromContext(ctx)
+			if sessionID == "" {
+				return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for running terraform")
+			}
+			commandLine := "terraform " + strings.Join(args, " ")
+			p := permissions.Request(
+				permission.CreatePermissionRequest{
+					SessionID:   sessionID,
+					Path:        dir,
+					ToolCallID:  call.ID,
+					ToolName:    TerraformToolName,
+					Action:      params.Command,
+					Description: fmt.Sprintf("Run %s in %s", commandLine, fsext.PrettyPath(dir)),
+					Params: TerraformPermissionsParams{
+						Command:  params.Command,
+						Dir:      dir,
+						VarFiles: params.VarFiles,
+						Targets:  params.Targets,
+					},
+				},
+			)
+			if !p {
+				return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
+			}
+
+			output, err := runCLI(ctx, dir, "terraform", args...)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("error running terraform: %v", err)), nil
+			}
+			return fantasy.WithResponseMetadata(
+				fantasy.NewTextResponse(output),
+				TerraformResponseMetadata{
+					Command: commandLine,
+					Dir:     dir,
+				},
+			), nil
+		})
+}
@@ -9,6 +9,8 @@ type (
 	messageIDContextKey string
 	supportsImagesKey   string
 	modelNameKey        string
+	shellProfileKey     string
+	workingDirKey       string
 )
 
 const (
@@ -20,6 +22,12 @@ const (
 	SupportsImagesContextKey supportsImagesKey = "supports_images"
 	// ModelNameContextKey is the key for the model name in the context.
 	ModelNameContextKey modelNameKey = "model_name"
+	// ShellProfileContextKey is the key for the shell profile of the session
+	// in the context.
+	ShellProfileContextKey shellProfileKey = "shell_profile"
+	// WorkingDirContextKey is the key for the working directory of the
+	// session in the context.
+	WorkingDirContextKey workingDirKey = "working_dir"
 )
 
 // GetSessionFromContext retrieves the session ID from the context.
@@ -72,3 +80,23 @@ func GetModelNameFromContext(ctx context.Context) string {
 	}
 	return s
 }
+
+// GetShellProfileFromContext retrieves the name of the shell profile of the
+// session from the context.
+func GetShellProfileFromContext(ctx context.Context) string {
+	profile, ok := ctx.Value(ShellProfileContextKey).(string)
+	if !ok {
+		return ""
+	}
+	return profile
+}
+
+// GetWorkingDirFromContext retrieves the working directory of the session
+// from the context, or returns fallback when the session has none.
+func GetWorkingDirFromContext(ctx context.Context, fallback string) string {
+	dir, ok := ctx.Value(WorkingDirContextKey).(string)
+	if !ok || dir == "" {
+		return fallback
+	}
+	return dir
+}
@@ -0,0 +1,254 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"charm.land/fantasy"
+)
+
+// validatedTool checks the arguments of a tool call against the schema of
+// the tool before running it.
+type validatedTool struct {
+	fantasy.AgentTool
+}
+
+// WithValidation wraps the tool so the arguments of its calls are checked
+// against its schema first. Calls with invalid arguments don't run, and the
+// model is told which fields are wrong and what they should be, so it can
+// fix them instead of guessing.
+func WithValidation(tool fantasy.AgentTool) fantasy.AgentTool {
+	return &validatedTool{AgentTool: tool}
+}
+
+func (t *validatedTool) Run(ctx context.Context, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	info := t.Info()
+	problems := validateArgs(info, call.Input)
+	if len(problems) == 0 {
+		return t.AgentTool.Run(ctx, call)
+	}
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "Invalid arguments for %s:\n", info.Name)
+	for _, problem := range problems {
+		fmt.Fprintf(&msg, "- %s\n", problem)
+	}
+	msg.WriteString("Fix the arguments and call the tool again.")
+	return fantasy.NewTextErrorResponse(msg.String()), nil
+}
+
+// validateArgs returns what's wrong with the JSON arguments of a call to a
+// tool, one problem per field. Arguments that aren't JSON are left to the
+// tool to report.
+func validateArgs(info fantasy.ToolInfo, input string) []string {
+	if strings.TrimSpace(input) == "" {
+		input = "{}"
+	}
+	dec := json.NewDecoder(bytes.NewReader([]byte(input)))
+	dec.UseNumber()
+	var args any
+	if err := dec.Decode(&args); err != nil {
+		return nil
+	}
+	schema := map[string]any{
+		"type":       "object",
+		"properties": info.Parameters,
+		"required":   info.Required,
+	}
+	var problems []string
+	validateValue("", args, schema, &problems)
+
+	// Unknown parameters are usually harmless, but point to the mistake when
+	// something else is wrong, like a misspelled required parameter.
+	if obj, ok := args.(map[string]any); ok && len(problems) > 0 && len(info.Parameters) > 0 {
+		for _, name := range slices.Sorted(maps.Keys(obj)) {
+			if _, known := info.Parameters[name]; !known {
+				problems = append(problems, fmt.Sprintf("%s: unknown parameter, expected one of %s", name, strings.Join(slices.Sorted(maps.Keys(info.Parameters)), ", ")))
+			}
+		}
+	}
+	return problems
+}
+
+// validateValue checks a value against a JSON schema, for the keywords that
+// matter to tool arguments: type, properties, required, items, enum, minimum
+// and maximum. Other keywords are ignored.
+func validateValue(path string, value any, schema map[string]any, problems *[]string) {
+	field := path
+	if field == "" {
+		field = "arguments"
+	}
+	// Fields of type any are objects without properties in the generated
+	// schemas, so those can hold anything.
+	if _, hasProps := schema["properties"]; schema["type"] == "object" && !hasProps {
+		return
+	}
+	if types := schemaTypes(schema["type"]); len(types) > 0 && !slices.ContainsFunc(types, func(t string) bool { return hasType(value, t) }) {
+		*problems = append(*problems, fmt.Sprintf("%s: expected %s, got %s", field, strings.Join(types, " or "), describeValue(value)))
+		return
+	}
+	if enum, ok := schema["enum"].([]any); ok && len(enum) > 0 && !slices.ContainsFunc(enum, func(e any) bool { return enumEqual(e, value) }) {
+		var options []string
+		for _, e := range enum {
+			data, _ := json.Marshal(e)
+			options = append(options, string(data))
+		}
+		*problems = append(*problems, fmt.Sprintf("%s: expected one of %s, got %s", field, strings.Join(options, ", "), describeValue(value)))
+		return
+	}
+	if n, ok := value.(json.Number); ok {
+		f, _ := n.Float64()
+		if lo, ok := schemaNumber(schema["minimum"]); ok && f < lo {
+			*problems = append(*problems, fmt.Sprintf("%s: must be at least %v, got %s", field, lo, n))
+		}
+		if hi, ok := schemaNumber(schema["maximum"]); ok && f > hi {
+			*problems = append(*problems, fmt.Sprintf("%s: must be at most %v, got %s", field, hi, n))
+		}
+	}
+
+	switch value := value.(type) {
+	case map[string]any:
+		required := stringList(schema["required"])
+		for _, name := range required {
+			if _, ok := value[name]; !ok {
+				*problems = append(*problems, fmt.Sprintf("%s: required but missing", joinField(path, name)))
+			}
+		}
+		props, _ := schema["properties"].(map[string]any)
+		for _, name := range slices.Sorted(maps.Keys(value)) {
+			// Null is how many models leave out an optional parameter, and
+			// decoding it leaves the field as it was.
+			if value[name] == nil && !slices.Contains(required, name) {
+				continue
+			}
+			if prop, ok := props[name].(map[string]any); ok {
+				validateValue(joinField(path, name), value[name], prop, problems)
+			}
+		}
+	case []any:
+		items, ok := schema["items"].(map[string]any)
+		if !ok {
+			return
+		}
+		for i, item := range value {
+			validateValue(fmt.Sprintf("%s[%d]", field, i), item, items, problems)
+		}
+	}
+}
+
+func joinField(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// schemaTypes returns the types a schema allows, given as a string or a list
+// of strings.
+func schemaTypes(v any) []string {
+	if t, ok := v.(string); ok {
+		return []string{t}
+	}
+	return stringList(v)
+}
+
+func stringList(v any) []string {
+	switch v := v.(type) {
+	case []string:
+		return v
+	case []any:
+		var list []string
+		for _, s := range v {
+			if s, ok := s.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	}
+	return nil
+}
+
+func schemaNumber(v any) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func hasType(value any, t string) bool {
+	switch t {
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	case "number":
+		_, ok := value.(json.Number)
+		return ok
+	case "integer":
+		n, ok := value.(json.Number)
+		if !ok {
+			return false
+		}
+		_, err := n.Int64()
+		return err == nil
+	}
+	// Unknown types aren't checked.
+	return true
+}
+
+// describeValue names the JSON type of a value, with the value itself when
+// it's short, so the model sees what it sent.
+func describeValue(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return "null"
+	case map[string]any:
+		return "an object"
+	case []any:
+		return "an array"
+	case bool:
+		return fmt.Sprintf("boolean %t", value)
+	case json.Number:
+		return "number " + value.String()
+	case string:
+		if len(value) > 40 {
+			return "a string"
+		}
+		return fmt.Sprintf("string %q", value)
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// enumEqual reports whether the enum value a is the argument value b. Only
+// scalars are compared.
+func enumEqual(a, b any) bool {
+	switch b := b.(type) {
+	case json.Number:
+		f, _ := b.Float64()
+		af, ok := schemaNumber(a)
+		return ok && af == f
+	case string, bool, nil:
+		return a == b
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateArgs(t *testing.T) {
+	t.Parallel()
+
+	info := fantasy.ToolInfo{
+		Name: "edit",
+		Parameters: map[string]any{
+			"file_path": map[string]any{"type": "string"},
+			"limit":     map[string]any{"type": "integer", "minimum": 1},
+			"mode":      map[string]any{"type": "string", "enum": []any{"append", "replace"}},
+			"body":      map[string]any{"type": "object"},
+			"edits": map[string]any{
+				"type": "array",
+				"items": map[string]any{
+					"type":       "object",
+					"properties": map[string]any{"old_string": map[string]any{"type": "string"}},
+					"required":   []string{"old_string"},
+				},
+			},
+		},
+		Required: []string{"file_path"},
+	}
+
+	require.Empty(t, validateArgs(info, `{"file_path": "a.go", "limit": 10, "mode": "append", "body": [1], "edits": [{"old_string": "x"}]}`))
+	require.Empty(t, validateArgs(info, `not json`))
+
+	require.Equal(t, []string{
+		"edits[0].old_string: required but missing",
+		`edits[1].old_string: expected string, got number 3`,
+		`limit: expected integer, got string "10"`,
+		`mode: expected one of "append", "replace", got string "prepend"`,
+	}, validateArgs(info, `{"file_path": "a.go", "limit": "10", "mode": "prepend", "edits": [{}, {"old_string": 3}]}`))
+
+	require.Equal(t, []string{
+		"file_path: required but missing",
+		"limit: must be at least 1, got 0",
+		"path: unknown parameter, expected one of body, edits, file_path, limit, mode",
+	}, validateArgs(info, `{"path": "a.go", "limit": 0}`))
+
+	require.Equal(t, []string{"arguments: expected object, got an array"}, validateArgs(info, `[]`))
+
+	// Null optional parameters are left out, but required ones still need a
+	// value.
+	require.Empty(t, validateArgs(info, `{"file_path": "a.go", "limit": null, "mode": null, "edits": [{"old_string": "x"}]}`))
+	require.Equal(t, []string{
+		"edits[0].old_string: expected string, got null",
+		"file_path: expected string, got null",
+	}, validateArgs(info, `{"file_path": null, "edits": [{"old_string": null}]}`))
+}
+
+func TestWithValidation(t *testing.T) {
+	t.Parallel()
+
+	type params struct {
+		FilePath string `json:"file_path"`
+		Offset   int    `json:"offset,omitempty"`
+	}
+	ran := false
+	tool := WithValidation(fantasy.NewAgentTool("view", "", func(context.Context, params, fantasy.ToolCall) (fantasy.ToolResponse, error) {
+		ran = true
+		return fantasy.NewTextResponse("ok"), nil
+	}))
+
+	resp, err := tool.Run(t.Context(), fantasy.ToolCall{Input: `{"file_path": "a.go", "offset": "2"}`})
+	require.NoError(t, err)
+	require.True(t, resp.IsError)
+	require.Equal(t, "Invalid arguments for view:\n- offset: expected integer, got string \"2\"\nFix the arguments and call the tool again.", resp.Content)
+	require.False(t, ran)
+
+	resp, err = tool.Run(t.Context(), fantasy.ToolCall{Input: `{"file_path": "a.go", "offset": 2}`})
+	require.NoError(t, err)
+	require.Equal(t, "ok", resp.Content)
+	require.True(t, ran)
+}
@@ -0,0 +1,188 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/diff"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/lsp"
+	"github.com/charmbracelet/crush/internal/lsp/util"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+)
+
+// WorkspaceEditPermissionsParams are the params of the permission asked for
+// each file a language server edits.
+type WorkspaceEditPermissionsParams struct {
+	FilePath   string `json:"file_path"`
+	OldContent string `json:"old_content,omitempty"`
+	NewContent string `json:"new_content,omitempty"`
+}
+
+// WorkspaceEditFile is a file edited by a language server.
+type WorkspaceEditFile struct {
+	FilePath   string `json:"file_path"`
+	OldContent string `json:"old_content,omitempty"`
+	NewContent string `json:"new_content,omitempty"`
+	Additions  int    `json:"additions"`
+	Removals   int    `json:"removals"`
+}
+
+// WorkspaceEditResponseMetadata is the metadata of the tools applying the
+// edits of language servers.
+type WorkspaceEditResponseMetadata struct {
+	Files []WorkspaceEditFile `json:"files"`
+}
+
+// workspaceEditFiles returns the files the edit changes with their new
+// content, without writing them.
+func workspaceEditFiles(edit protocol.WorkspaceEdit) ([]WorkspaceEditFile, error) {
+	edits := make(map[string][]protocol.TextEdit)
+	add := func(uri protocol.DocumentURI, textEdits []protocol.TextEdit) error {
+		path, err := uri.Path()
+		if err != nil {
+			return fmt.Errorf("invalid URI %s: %w", uri, err)
+		}
+		edits[path] = append(edits[path], textEdits...)
+		return nil
+	}
+	for uri, textEdits := range edit.Changes {
+		if err := add(uri, textEdits); err != nil {
+			return nil, err
+		}
+	}
+	for _, change := range edit.DocumentChanges {
+		if change.CreateFile != nil || change.RenameFile != nil || change.DeleteFile != nil {
+			return nil, errors.New("the edit creates, renames or deletes files, which isn't supported")
+		}
+		if change.TextDocumentEdit == nil {
+			continue
+		}
+		textEdits := make([]protocol.TextEdit, len(change.TextDocumentEdit.Edits))
+		for i, e := range change.TextDocumentEdit.Edits {
+			var err error
+			if textEdits[i], err = e.AsTextEdit(); err != nil {
+				return nil, fmt.Errorf("invalid edit type: %w", err)
+			}
+		}
+		if err := add(change.TextDocumentEdit.TextDocument.URI, textEdits); err != nil {
+			return nil, err
+		}
+	}
+
+	var files []WorkspaceEditFile
+	for path, textEdits := range edits {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+		newContent, err := util.ApplyTextEdits(string(content), textEdits)
+		if err != nil {
+			return nil, fmt.Errorf("failed to edit %s: %w", path, err)
+		}
+		if newContent == string(content) {
+			continue
+		}
+		files = append(files, WorkspaceEditFile{
+			FilePath:   path,
+			OldContent: string(content),
+			NewContent: newContent,
+		})
+	}
+	slices.SortFunc(files, func(a, b WorkspaceEditFile) int {
+		return strings.Compare(a.FilePath, b.FilePath)
+	})
+	return files, nil
+}
+
+// applyWorkspaceEdit asks permission to write each file, showing its diff
+// like the edit tools do, and only writes them once all are allowed so that
+// a refactoring is never left halfway. The files are added to the file
+// history and the LSPs are notified of the changes.
+func applyWorkspaceEdit(edit editContext, lspClients *csync.Map[string, *lsp.Client], call fantasy.ToolCall, toolName, description string, files []WorkspaceEditFile) error {
+	sessionID := GetSessionFromContext(edit.ctx)
+	if sessionID == "" {
+		return fmt.Errorf("session ID is required for editing files")
+	}
+
+	for i := range files {
+		f := &files[i]
+		_, f.Additions, f.Removals = diff.GenerateDiff(f.OldContent, f.NewContent, strings.TrimPrefix(f.FilePath, edit.workingDir))
+		p := edit.permissions.Request(permission.CreatePermissionRequest{
+			SessionID:   sessionID,
+			Path:        fsext.PathOrPrefix(f.FilePath, edit.workingDir),
+			ToolCallID:  call.ID,
+			ToolName:    toolName,
+			Action:      "write",
+			Description: fmt.Sprintf("%s: edit file %s (%d of %d)", description, f.FilePath, i+1, len(files)),
+			Params: WorkspaceEditPermissionsParams{
+				FilePath:   f.FilePath,
+				OldContent: f.OldContent,
+				NewContent: f.NewContent,
+			},
+		})
+		if !p {
+			return permission.ErrorPermissionDenied
+		}
+	}
+
+	for _, f := range files {
+		if err := os.WriteFile(f.FilePath, []byte(f.NewContent), 0o644); err != nil {
+			return fmt.Errorf("failed to write file: %w", err)
+		}
+
+		// Update file history
+		file, err := edit.files.GetByPathAndSession(edit.ctx, f.FilePath, sessionID)
+		if err != nil {
+			_, err = edit.files.Create(edit.ctx, sessionID, f.FilePath, f.OldContent)
+			if err != nil {
+				return fmt.Errorf("error creating file history: %w", err)
+			}
+		}
+		if file.Content != f.OldContent {
+			// User manually changed the content, store an intermediate version
+			_, err = edit.files.CreateVersion(edit.ctx, sessionID, f.FilePath, f.OldContent)
+			if err != nil {
+				slog.Error("Error creating file history version", "error", err)
+			}
+		}
+		_, err = edit.files.CreateVersion(edit.ctx, sessionID, f.FilePath, f.NewContent)
+		if err != nil {
+			slog.Error("Error creating file history version", "error", err)
+		}
+
+		recordFileWrite(f.FilePath)
+		recordFileRead(f.FilePath)
+		notifyLSPs(edit.ctx, lspClients, f.FilePath)
+	}
+	return nil
+}
+
+// formatWorkspaceEdit describes the files changed by an edit for the agent.
+func formatWorkspaceEdit(summary string, files []WorkspaceEditFile, lspClients *csync.Map[string, *lsp.Client]) string {
+	var out strings.Builder
+	fmt.Fprintf(&out, "<result>\n%s, %d file(s) changed:\n", summary, len(files))
+	for _, f := range files {
+		fmt.Fprintf(&out, "%s (+%d -%d)\n", f.FilePath, f.Additions, f.Removals)
+	}
+	out.WriteString("</result>\n")
+	out.WriteString(getDiagnostics(files[0].FilePath, lspClients))
+	return out.String()
+}
+
+// lspClientForFile returns the client of the first LSP handling the file.
+func lspClientForFile(lspClients *csync.Map[string, *lsp.Client], path string) *lsp.Client {
+	for c := range lspClients.Seq() {
+		if c.HandlesFile(path) {
+			return c
+		}
+	}
+	return nil
+}
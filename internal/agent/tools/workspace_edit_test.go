@@ -0,0 +1,47 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkspaceEditFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.go")
+	b := filepath.Join(dir, "b.go")
+	require.NoError(t, os.WriteFile(a, []byte("func oldName() {}\n"), 0o644))
+	require.NoError(t, os.WriteFile(b, []byte("func main() {\n\toldName()\n}\n"), 0o644))
+
+	rename := func(line, character uint32) protocol.TextEdit {
+		return protocol.TextEdit{
+			Range: protocol.Range{
+				Start: protocol.Position{Line: line, Character: character},
+				End:   protocol.Position{Line: line, Character: character + 7},
+			},
+			NewText: "newName",
+		}
+	}
+	files, err := workspaceEditFiles(protocol.WorkspaceEdit{
+		Changes: map[protocol.DocumentURI][]protocol.TextEdit{
+			protocol.URIFromPath(b): {rename(1, 1)},
+			protocol.URIFromPath(a): {rename(0, 5)},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	require.Equal(t, a, files[0].FilePath)
+	require.Equal(t, "func newName() {}\n", files[0].NewContent)
+	require.Equal(t, b, files[1].FilePath)
+	require.Equal(t, "func main() {\n\tnewName()\n}\n", files[1].NewContent)
+
+	// Nothing is written until the edit is applied.
+	content, err := os.ReadFile(a)
+	require.NoError(t, err)
+	require.Equal(t, "func oldName() {}\n", string(content))
+}
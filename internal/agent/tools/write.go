@@ -19,6 +19,7 @@ import (
 
 	"github.com/charmbracelet/crush/internal/lsp"
 	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/privacy"
 )
 
 //go:embed write.md
@@ -50,11 +51,12 @@ type WriteResponseMetadata struct {
 
 const WriteToolName = "write"
 
-func NewWriteTool(lspClients *csync.Map[string, *lsp.Client], permissions permission.Service, files history.Service, workingDir string) fantasy.AgentTool {
+func NewWriteTool(lspClients *csync.Map[string, *lsp.Client], permissions permission.Service, files history.Service, zones *privacy.Zones, workingDir string) fantasy.AgentTool {
 	return fantasy.NewAgentTool(
 		WriteToolName,
 		string(writeDescription),
 		func(ctx context.Context, params WriteParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			workingDir := GetWorkingDirFromContext(ctx, workingDir)
 			if params.FilePath == "" {
 				return fantasy.NewTextErrorResponse("file_path is required"), nil
 			}
@@ -64,6 +66,17 @@ func NewWriteTool(lspClients *csync.Map[string, *lsp.Client], permissions permis
 			}
 
 			filePath := filepathext.SmartJoin(workingDir, params.FilePath)
+			if resp, denied := privacyZoneDenied(ctx, permissions, zones, call, WriteToolName, "write", filePath, WritePermissionsParams{
+				FilePath:   filePath,
+				NewContent: params.Content,
+			}); denied {
+				return resp, nil
+			}
+			if isNotebook(filePath) {
+				if _, err := parseNotebook([]byte(params.Content)); err != nil {
+					return fantasy.NewTextErrorResponse(fmt.Sprintf("%v. Write notebooks as their full JSON, or edit their cells with the Edit tool", err)), nil
+				}
+			}
 
 			fileInfo, err := os.Stat(filePath)
 			if err == nil {
@@ -79,6 +92,10 @@ func NewWriteTool(lspClients *csync.Map[string, *lsp.Client], permissions permis
 				}
 
 				oldContent, readErr := os.ReadFile(filePath)
+				// Keep the line endings of files that already use CRLF.
+				if readErr == nil && strings.Contains(string(oldContent), "\r\n") {
+					params.Content, _ = fsext.ToWindowsLineEndings(params.Content)
+				}
 				if readErr == nil && string(oldContent) == params.Content {
 					return fantasy.NewTextErrorResponse(fmt.Sprintf("File %s already contains the exact content. No changes made.", filePath)), nil
 				}
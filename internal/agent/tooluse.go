@@ -0,0 +1,57 @@
+package agent
+
+import (
+	"maps"
+
+	"charm.land/fantasy"
+	"charm.land/fantasy/providers/anthropic"
+	"charm.land/fantasy/providers/openai"
+	"charm.land/fantasy/providers/openrouter"
+)
+
+// toolChoice maps the tool choice of the config to fantasy's, which every
+// provider maps to its own equivalent. It returns nil when the model is free
+// to decide.
+func toolChoice(choice string) *fantasy.ToolChoice {
+	var c fantasy.ToolChoice
+	switch choice {
+	case "", string(fantasy.ToolChoiceAuto):
+		return nil
+	case "any", string(fantasy.ToolChoiceRequired):
+		c = fantasy.ToolChoiceRequired
+	case string(fantasy.ToolChoiceNone):
+		c = fantasy.ToolChoiceNone
+	default:
+		c = fantasy.SpecificToolChoice(choice)
+	}
+	return &c
+}
+
+// withoutParallelToolUse returns a copy of the provider options that makes
+// the model call at most one tool at a time, on the providers that support
+// it.
+func withoutParallelToolUse(options fantasy.ProviderOptions) fantasy.ProviderOptions {
+	options = maps.Clone(options)
+	disabled, parallel := true, false
+	for name, opts := range options {
+		switch o := opts.(type) {
+		case *anthropic.ProviderOptions:
+			copied := *o
+			copied.DisableParallelToolUse = &disabled
+			options[name] = &copied
+		case *openai.ProviderOptions:
+			copied := *o
+			copied.ParallelToolCalls = &parallel
+			options[name] = &copied
+		case *openai.ResponsesProviderOptions:
+			copied := *o
+			copied.ParallelToolCalls = &parallel
+			options[name] = &copied
+		case *openrouter.ProviderOptions:
+			copied := *o
+			copied.ParallelToolCalls = &parallel
+			options[name] = &copied
+		}
+	}
+	return options
+}
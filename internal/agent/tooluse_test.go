@@ -0,0 +1,32 @@
+package agent
+
+import (
+	"testing"
+
+	"charm.land/fantasy"
+	"charm.land/fantasy/providers/anthropic"
+	"charm.land/fantasy/providers/openai"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToolChoice(t *testing.T) {
+	t.Parallel()
+
+	require.Nil(t, toolChoice(""))
+	require.Nil(t, toolChoice("auto"))
+	require.Equal(t, fantasy.ToolChoiceRequired, *toolChoice("any"))
+	require.Equal(t, fantasy.ToolChoiceNone, *toolChoice("none"))
+	require.Equal(t, fantasy.SpecificToolChoice("bash"), *toolChoice("bash"))
+}
+
+func TestWithoutParallelToolUse(t *testing.T) {
+	t.Parallel()
+
+	original := &anthropic.ProviderOptions{}
+	options := withoutParallelToolUse(fantasy.ProviderOptions{anthropic.Name: original})
+	require.True(t, *options[anthropic.Name].(*anthropic.ProviderOptions).DisableParallelToolUse)
+	require.Nil(t, original.DisableParallelToolUse)
+
+	options = withoutParallelToolUse(fantasy.ProviderOptions{openai.Name: &openai.ResponsesProviderOptions{}})
+	require.False(t, *options[openai.Name].(*openai.ResponsesProviderOptions).ParallelToolCalls)
+}
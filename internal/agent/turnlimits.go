@@ -0,0 +1,116 @@
+package agent
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/pubsub"
+)
+
+// LimitEvent is published when a turn of the agent stops because it reached
+// one of its limits or got stuck in a loop.
+type LimitEvent struct {
+	SessionID string
+	// Reason describes the limit the turn reached.
+	Reason string
+}
+
+var limitBroker = pubsub.NewBroker[LimitEvent]()
+
+// SubscribeLimitEvents returns a channel for the turns stopped at their
+// limits.
+func SubscribeLimitEvents(ctx context.Context) <-chan pubsub.Event[LimitEvent] {
+	return limitBroker.Subscribe(ctx)
+}
+
+func publishLimitEvent(sessionID, reason string) {
+	limitBroker.Publish(pubsub.CreatedEvent, LimitEvent{SessionID: sessionID, Reason: reason})
+}
+
+// turnLimits keeps track of a turn of the agent against the limits of
+// options.turn_limits, so that a model stuck in a loop doesn't run forever.
+type turnLimits struct {
+	cfg   config.TurnLimits
+	start time.Time
+
+	toolCalls int
+	failures  int // Tool calls in a row that failed.
+	invalid   int // Tool calls in a row with malformed arguments.
+	loops     *loopDetector
+
+	// reached describes the limit the turn reached, if any.
+	reached string
+}
+
+func newTurnLimits(cfg config.TurnLimits, start time.Time) *turnLimits {
+	l := &turnLimits{cfg: cfg, start: start}
+	if !cfg.DisableLoopDetection {
+		l.loops = &loopDetector{}
+	}
+	return l
+}
+
+// toolCalled records a tool call of the turn.
+func (l *turnLimits) toolCalled(id, name, input string) {
+	l.toolCalls++
+	if l.loops != nil {
+		l.loops.toolCalled(id, name, input)
+	}
+}
+
+// toolCallParsed records whether the arguments of a tool call of the turn
+// were malformed, even after repair.
+func (l *turnLimits) toolCallParsed(invalid bool) {
+	if invalid {
+		l.invalid++
+	} else {
+		l.invalid = 0
+	}
+}
+
+// toolResult records the result of a tool call of the turn.
+func (l *turnLimits) toolResult(result message.ToolResult) {
+	failed := toolFailed(result)
+	if failed {
+		l.failures++
+	} else {
+		l.failures = 0
+	}
+	if l.loops != nil {
+		l.loops.toolResult(result.ToolCallID, failed, result.Content)
+	}
+}
+
+// exceeded reports whether the turn reached one of its limits at time now,
+// or got stuck in a loop.
+func (l *turnLimits) exceeded(now time.Time) bool {
+	if l.loops != nil {
+		if loop := l.loops.detect(); loop != "" {
+			l.reached = loop
+			return true
+		}
+	}
+	switch {
+	case l.cfg.MaxToolCalls > 0 && l.toolCalls >= l.cfg.MaxToolCalls:
+		l.reached = fmt.Sprintf("I've made %d tool calls in this turn, the limit set by `options.turn_limits.max_tool_calls`", l.toolCalls)
+	case l.cfg.MaxDuration > 0 && now.Sub(l.start) >= time.Duration(l.cfg.MaxDuration)*time.Second:
+		l.reached = fmt.Sprintf("This turn has been running for %s, the limit set by `options.turn_limits.max_duration`", now.Sub(l.start).Truncate(time.Second))
+	case l.cfg.MaxConsecutiveFailures > 0 && l.failures >= l.cfg.MaxConsecutiveFailures:
+		l.reached = fmt.Sprintf("My last %d tool calls failed, the limit set by `options.turn_limits.max_consecutive_failures`", l.failures)
+	case l.invalid > cmp.Or(l.cfg.MaxToolCallRetries, defaultToolCallRetries):
+		l.reached = fmt.Sprintf("The arguments of my last %d tool calls were malformed, past the retries allowed by `options.turn_limits.max_tool_call_retries`", l.invalid)
+	default:
+		return false
+	}
+	return true
+}
+
+// stopMessage returns the message the agent ends its turn with once it
+// reached a limit.
+func (l *turnLimits) stopMessage() string {
+	return l.reached + ", so I stopped here. Should I keep going, try a different approach, or stop?"
+}
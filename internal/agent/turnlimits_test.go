@@ -0,0 +1,76 @@
+package agent
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTurnLimits(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+
+	t.Run("no limits", func(t *testing.T) {
+		t.Parallel()
+		l := newTurnLimits(config.TurnLimits{DisableLoopDetection: true}, start)
+		for i := range 100 {
+			id := fmt.Sprint(i)
+			l.toolCalled(id, "view", fmt.Sprintf(`{"file_path":"%d.go"}`, i))
+			l.toolResult(message.ToolResult{ToolCallID: id, IsError: true})
+		}
+		require.False(t, l.exceeded(start.Add(24*time.Hour)))
+	})
+
+	t.Run("max tool calls", func(t *testing.T) {
+		t.Parallel()
+		l := newTurnLimits(config.TurnLimits{MaxToolCalls: 3}, start)
+		l.toolCalled("1", "ls", "{}")
+		l.toolCalled("2", "ls", "{}")
+		require.False(t, l.exceeded(start))
+		l.toolCalled("3", "ls", "{}")
+		require.True(t, l.exceeded(start))
+		require.Contains(t, l.stopMessage(), "3 tool calls")
+	})
+
+	t.Run("max duration", func(t *testing.T) {
+		t.Parallel()
+		l := newTurnLimits(config.TurnLimits{MaxDuration: 60}, start)
+		require.False(t, l.exceeded(start.Add(59*time.Second)))
+		require.True(t, l.exceeded(start.Add(90*time.Second)))
+		require.Contains(t, l.stopMessage(), "1m30s")
+	})
+
+	t.Run("max consecutive failures", func(t *testing.T) {
+		t.Parallel()
+		l := newTurnLimits(config.TurnLimits{MaxConsecutiveFailures: 2}, start)
+		l.toolResult(message.ToolResult{Name: "view", IsError: true})
+		l.toolResult(message.ToolResult{Name: "view"})
+		l.toolResult(message.ToolResult{Name: "view", IsError: true})
+		require.False(t, l.exceeded(start))
+		l.toolResult(message.ToolResult{Name: "bash", Content: "FAIL\nExit code 1"})
+		require.True(t, l.exceeded(start))
+		require.Contains(t, l.stopMessage(), "last 2 tool calls failed")
+	})
+
+	t.Run("malformed tool calls", func(t *testing.T) {
+		t.Parallel()
+		l := newTurnLimits(config.TurnLimits{}, start)
+		for range defaultToolCallRetries {
+			l.toolCallParsed(true)
+		}
+		require.False(t, l.exceeded(start))
+		l.toolCallParsed(false)
+		for range defaultToolCallRetries {
+			l.toolCallParsed(true)
+		}
+		require.False(t, l.exceeded(start))
+		l.toolCallParsed(true)
+		require.True(t, l.exceeded(start))
+		require.Contains(t, l.stopMessage(), "last 4 tool calls were malformed")
+	})
+}
@@ -23,3 +23,84 @@ func Escape(content string) string {
 	}
 	return sb.String()
 }
+
+// Sanitize keeps the colors and text attributes of content, like the output
+// of commands, and drops every other escape sequence, like the ones moving
+// the cursor or setting the title of the terminal, which would mess up the
+// UI. Lines rewritten with carriage returns, like progress bars, keep their
+// last state, and the remaining control characters are escaped.
+func Sanitize(content string) string {
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		line = strings.TrimSuffix(line, "\r")
+		if j := strings.LastIndexByte(line, '\r'); j >= 0 {
+			line = line[j+1:]
+		}
+		lines[i] = sanitizeLine(line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func sanitizeLine(line string) string {
+	var sb strings.Builder
+	sb.Grow(len(line))
+	for i := 0; i < len(line); {
+		if line[i] != ansi.ESC {
+			j := i + 1
+			for j < len(line) && line[j] != ansi.ESC {
+				j++
+			}
+			sb.WriteString(Escape(line[i:j]))
+			i = j
+			continue
+		}
+		n, keep := escapeSequence(line[i:])
+		if keep {
+			sb.WriteString(line[i : i+n])
+		}
+		i += n
+	}
+	return sb.String()
+}
+
+// escapeSequence returns the length of the escape sequence s starts with,
+// and whether it only sets the style of the text.
+func escapeSequence(s string) (n int, style bool) {
+	if len(s) < 2 {
+		return len(s), false
+	}
+	switch s[1] {
+	case '[':
+		// CSI: parameter bytes, intermediate bytes, then the final byte.
+		i := 2
+		for i < len(s) && s[i] >= 0x30 && s[i] <= 0x3f {
+			i++
+		}
+		params := i
+		for i < len(s) && s[i] >= 0x20 && s[i] <= 0x2f {
+			i++
+		}
+		if i >= len(s) || s[i] < 0x40 || s[i] > 0x7e {
+			return i, false
+		}
+		return i + 1, s[i] == 'm' && params == i && !strings.ContainsAny(s[2:i], "<=>?")
+	case ']', 'P', '_', '^', 'X':
+		// OSC, DCS, APC, PM and SOS: strings ended by BEL or ST.
+		for i := 2; i < len(s); i++ {
+			switch {
+			case s[i] == ansi.BEL:
+				return i + 1, false
+			case s[i] == ansi.ESC && i+1 < len(s) && s[i+1] == '\\':
+				return i + 2, false
+			}
+		}
+		return len(s), false
+	}
+	// Other escape sequences: intermediate bytes, then the final byte, like
+	// the ones designating character sets.
+	i := 1
+	for i < len(s)-1 && s[i] >= 0x20 && s[i] <= 0x2f {
+		i++
+	}
+	return i + 1, false
+}
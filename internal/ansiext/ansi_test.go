@@ -0,0 +1,34 @@
+package ansiext
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSanitize(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain", "ok\nPASS", "ok\nPASS"},
+		{"colors", "\x1b[1;31mFAIL\x1b[0m main_test.go", "\x1b[1;31mFAIL\x1b[0m main_test.go"},
+		{"true color", "\x1b[38;2;255;0;0mred\x1b[m", "\x1b[38;2;255;0;0mred\x1b[m"},
+		{"cursor movement", "\x1b[2K\x1b[1Aline\x1b[?25l", "line"},
+		{"private sgr", "\x1b[>4;2mkeys", "keys"},
+		{"title", "\x1b]0;title\x07text\x1b]8;;https://charm.sh\x1b\\link", "textlink"},
+		{"progress", "10%\r50%\r100%\r\ndone", "100%\ndone"},
+		{"control characters", "bell\x07 tab\t", "bell␇ tab␉"},
+		{"incomplete", "text\x1b[31", "text"},
+		{"charset", "\x1b(Bbox\x1b=", "box"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.want, Sanitize(tt.in))
+		})
+	}
+}
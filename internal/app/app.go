@@ -10,7 +10,7 @@ import (
 	"io"
 	"log/slog"
 	"os"
-	"strings"
+	"path/filepath"
 	"sync"
 	"time"
 
@@ -18,19 +18,30 @@ import (
 	"charm.land/fantasy"
 	"charm.land/lipgloss/v2"
 	"github.com/charmbracelet/crush/internal/agent"
+	"github.com/charmbracelet/crush/internal/agent/tools"
 	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/csync"
 	"github.com/charmbracelet/crush/internal/db"
+	"github.com/charmbracelet/crush/internal/fileupload"
 	"github.com/charmbracelet/crush/internal/format"
+	"github.com/charmbracelet/crush/internal/handoff"
 	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/i18n"
 	"github.com/charmbracelet/crush/internal/log"
 	"github.com/charmbracelet/crush/internal/lsp"
+	"github.com/charmbracelet/crush/internal/memory"
 	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/netstatus"
 	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/plugin"
+	"github.com/charmbracelet/crush/internal/preview"
+	"github.com/charmbracelet/crush/internal/prompthistory"
 	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/charmbracelet/crush/internal/ratelimit"
 	"github.com/charmbracelet/crush/internal/session"
 	"github.com/charmbracelet/crush/internal/shell"
+	"github.com/charmbracelet/crush/internal/symbols"
 	"github.com/charmbracelet/crush/internal/tui/components/anim"
 	"github.com/charmbracelet/crush/internal/tui/styles"
 	"github.com/charmbracelet/crush/internal/update"
@@ -50,6 +61,13 @@ type App struct {
 
 	LSPClients *csync.Map[string, *lsp.Client]
 
+	// Memories are the facts about the project remembered across sessions.
+	Memories *memory.Store
+
+	// Prompts is the history of the prompts sent, in the project and in all
+	// of them.
+	Prompts *prompthistory.History
+
 	config *config.Config
 
 	serviceEventsWG *sync.WaitGroup
@@ -80,6 +98,8 @@ func New(ctx context.Context, conn *sql.DB, cfg *config.Config) (*App, error) {
 		History:     files,
 		Permissions: permission.NewPermissionService(cfg.WorkingDir(), skipPermissionsRequests, allowedTools),
 		LSPClients:  csync.NewMap[string, *lsp.Client](),
+		Memories:    memory.NewStore(cfg.Options.DataDirectory),
+		Prompts:     prompthistory.New(cfg.Options.DataDirectory, filepath.Dir(config.GlobalConfigData()), cfg.WorkingDir()),
 
 		globalCtx: ctx,
 
@@ -91,6 +111,28 @@ func New(ctx context.Context, conn *sql.DB, cfg *config.Config) (*App, error) {
 	}
 
 	app.setupEvents()
+	app.setupFileUploads()
+	if err := app.setupWebhooks(); err != nil {
+		return nil, err
+	}
+	if err := app.setupBridge(); err != nil {
+		return nil, err
+	}
+
+	// Translate the interface. Models are told the language in the system
+	// prompt, even when there's no translation.
+	if err := i18n.SetLanguage(cfg.Options.Language); err != nil {
+		slog.Warn("The interface is not translated", "error", err)
+	}
+
+	// Watch for network loss, unless we never talk to remote hosts anyway.
+	if !cfg.IsLocalOnly() {
+		netstatus.Start(ctx)
+	}
+
+	// Index the symbols of the workspace in the background, for the symbols
+	// tool and completions.
+	symbols.Start(ctx, cfg.WorkingDir())
 
 	// Initialize LSP clients in the background.
 	app.initLSPClients(ctx)
@@ -103,8 +145,31 @@ func New(ctx context.Context, conn *sql.DB, cfg *config.Config) (*App, error) {
 		mcp.Initialize(ctx, app.Permissions, cfg)
 	}()
 
+	// Start the plugins before the agent is created, which gets their tools.
+	plugin.Initialize(ctx, cfg)
+
 	// cleanup database upon app shutdown
-	app.cleanupFuncs = append(app.cleanupFuncs, conn.Close, mcp.Close)
+	app.cleanupFuncs = append(app.cleanupFuncs, conn.Close, mcp.Close, plugin.Close, preview.Close)
+
+	// Run the commands of the agent in a container, when configured. This
+	// has to happen before the tools are built.
+	if c := cfg.Options.Container; c != nil {
+		if shell.GetBackgroundShellManager().Backend() != nil {
+			return nil, errors.New("commands can't run in a container on a remote workspace")
+		}
+		container, err := shell.NewContainer(cfg.WorkingDir(), shell.ContainerOptions{
+			Runtime:         c.Runtime,
+			Image:           c.Image,
+			Devcontainer:    c.Devcontainer,
+			WorkspaceFolder: c.WorkspaceFolder,
+			RunArgs:         c.RunArgs,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to set up the container: %w", err)
+		}
+		shell.GetBackgroundShellManager().SetBackend(container)
+		app.cleanupFuncs = append(app.cleanupFuncs, container.Stop)
+	}
 
 	// TODO: remove the concept of agent config, most likely.
 	if !cfg.IsConfigured() {
@@ -123,8 +188,8 @@ func (app *App) Config() *config.Config {
 }
 
 // RunNonInteractive runs the application in non-interactive mode with the
-// given prompt, printing to stdout.
-func (app *App) RunNonInteractive(ctx context.Context, output io.Writer, prompt string, quiet bool) error {
+// given prompt and attachments, printing to output in the given format.
+func (app *App) RunNonInteractive(ctx context.Context, output io.Writer, prompt string, format OutputFormat, quiet bool, attachments ...message.Attachment) error {
 	slog.Info("Running in non-interactive mode")
 
 	ctx, cancel := context.WithCancel(ctx)
@@ -203,7 +268,7 @@ func (app *App) RunNonInteractive(ctx context.Context, output io.Writer, prompt
 	done := make(chan response, 1)
 
 	go func(ctx context.Context, sessionID, prompt string) {
-		result, err := app.AgentCoordinator.Run(ctx, sess.ID, prompt)
+		result, err := app.AgentCoordinator.Run(ctx, sess.ID, prompt, attachments...)
 		if err != nil {
 			done <- response{
 				err: fmt.Errorf("failed to start agent processing stream: %w", err),
@@ -214,8 +279,12 @@ func (app *App) RunNonInteractive(ctx context.Context, output io.Writer, prompt
 		}
 	}(ctx, sess.ID, prompt)
 
+	printer := app.newRunPrinter(format, output)
+	if err := printer.start(sess.ID); err != nil {
+		return err
+	}
+
 	messageEvents := app.Messages.Subscribe(ctx)
-	messageReadBytes := make(map[string]int)
 
 	defer func() {
 		if stderrTTY {
@@ -240,33 +309,25 @@ func (app *App) RunNonInteractive(ctx context.Context, output io.Writer, prompt
 			if result.err != nil {
 				if errors.Is(result.err, context.Canceled) || errors.Is(result.err, agent.ErrRequestCancelled) {
 					slog.Info("Non-interactive: agent processing cancelled", "session_id", sess.ID)
-					return nil
+					return printer.finish(context.WithoutCancel(ctx), sess.ID, result.err)
 				}
-				return fmt.Errorf("agent processing failed: %w", result.err)
+				err := fmt.Errorf("agent processing failed: %w", result.err)
+				if printErr := printer.finish(context.WithoutCancel(ctx), sess.ID, err); printErr != nil {
+					slog.Error("Non-interactive: failed to print output", "error", printErr)
+				}
+				return err
 			}
-			return nil
+			return printer.finish(ctx, sess.ID, nil)
 
 		case event := <-messageEvents:
 			msg := event.Payload
-			if msg.SessionID == sess.ID && msg.Role == message.Assistant && len(msg.Parts) > 0 {
-				stopSpinner()
-
-				content := msg.Content().String()
-				readBytes := messageReadBytes[msg.ID]
-
-				if len(content) < readBytes {
-					slog.Error("Non-interactive: message content is shorter than read bytes", "message_length", len(content), "read_bytes", readBytes)
-					return fmt.Errorf("message content is shorter than read bytes: %d < %d", len(content), readBytes)
+			if msg.SessionID == sess.ID && len(msg.Parts) > 0 {
+				if msg.Role == message.Assistant {
+					stopSpinner()
 				}
-
-				part := content[readBytes:]
-				// Trim leading whitespace. Sometimes the LLM includes leading
-				// formatting and intentation, which we don't want here.
-				if readBytes == 0 {
-					part = strings.TrimLeft(part, " \t")
+				if err := printer.message(msg); err != nil {
+					return err
 				}
-				fmt.Fprint(output, part)
-				messageReadBytes[msg.ID] = len(content)
 			}
 
 		case <-ctx.Done():
@@ -293,6 +354,11 @@ func (app *App) setupEvents() {
 	setupSubscriber(ctx, app.serviceEventsWG, "history", app.History.Subscribe, app.events)
 	setupSubscriber(ctx, app.serviceEventsWG, "mcp", mcp.SubscribeEvents, app.events)
 	setupSubscriber(ctx, app.serviceEventsWG, "lsp", SubscribeLSPEvents, app.events)
+	setupSubscriber(ctx, app.serviceEventsWG, "netstatus", netstatus.SubscribeEvents, app.events)
+	setupSubscriber(ctx, app.serviceEventsWG, "ratelimit", ratelimit.SubscribeEvents, app.events)
+	setupSubscriber(ctx, app.serviceEventsWG, "runqueue", agent.SubscribeRunQueueEvents, app.events)
+	setupSubscriber(ctx, app.serviceEventsWG, "handoff", handoff.SubscribeEvents, app.events)
+	setupSubscriber(ctx, app.serviceEventsWG, "tool-output", tools.SubscribeOutputEvents, app.events)
 	cleanupFunc := func() error {
 		cancel()
 		app.serviceEventsWG.Wait()
@@ -334,6 +400,33 @@ func setupSubscriber[T any](
 	})
 }
 
+// setupFileUploads deletes the attachments uploaded to the file APIs of
+// providers once the sessions they were sent in are deleted, and all of them
+// on shutdown.
+func (app *App) setupFileUploads() {
+	ctx, cancel := context.WithCancel(app.globalCtx)
+	sessions := app.Sessions.Subscribe(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for event := range sessions {
+			if event.Type != pubsub.DeletedEvent {
+				continue
+			}
+			if err := fileupload.Forget(ctx, event.Payload.ID); err != nil {
+				slog.Warn("Failed to delete the uploads of a session", "session", event.Payload.ID, "error", err)
+			}
+		}
+	}()
+	app.cleanupFuncs = append(app.cleanupFuncs, func() error {
+		cancel()
+		<-done
+		deleteCtx, deleteCancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer deleteCancel()
+		return fileupload.Close(deleteCtx)
+	})
+}
+
 func (app *App) InitCoderAgent(ctx context.Context) error {
 	coderAgentCfg := app.config.Agents[config.AgentCoder]
 	if coderAgentCfg.ID == "" {
@@ -348,6 +441,7 @@ func (app *App) InitCoderAgent(ctx context.Context) error {
 		app.Permissions,
 		app.History,
 		app.LSPClients,
+		app.Memories,
 	)
 	if err != nil {
 		slog.Error("Failed to create coder agent", "err", err)
@@ -373,6 +467,10 @@ func (app *App) Subscribe(program *tea.Program) {
 	})
 	defer app.tuiWG.Done()
 
+	// The TUI hands the terminal over to run interactive commands.
+	detach := handoff.Attach()
+	defer detach()
+
 	for {
 		select {
 		case <-tuiCtx.Done():
@@ -388,6 +486,12 @@ func (app *App) Subscribe(program *tea.Program) {
 	}
 }
 
+// AddCleanup registers a function to call on shutdown, along with the
+// cleanup of the app itself.
+func (app *App) AddCleanup(fn func() error) {
+	app.cleanupFuncs = append(app.cleanupFuncs, fn)
+}
+
 // Shutdown performs a graceful shutdown of the application.
 func (app *App) Shutdown() {
 	start := time.Now()
@@ -437,9 +541,12 @@ func (app *App) checkForUpdates(ctx context.Context) {
 	if err != nil || !info.Available() {
 		return
 	}
+	update.SetPending(info)
 	app.events <- pubsub.UpdateAvailableMsg{
 		CurrentVersion: info.Current,
 		LatestVersion:  info.Latest,
 		IsDevelopment:  info.IsDevelopment(),
+		Notes:          info.Notes,
+		URL:            info.URL,
 	}
 }
@@ -0,0 +1,93 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/charmbracelet/crush/internal/bridge"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/pubsub"
+)
+
+// setupBridge mirrors the permission requests and turn summaries of the
+// agent to the chat channel of the config.
+func (app *App) setupBridge() error {
+	if app.config.Bridge == nil {
+		return nil
+	}
+	// Slack and Discord are never local.
+	if app.config.IsLocalOnly() {
+		return errors.New("bridge: chat platforms can't be reached in local-only mode")
+	}
+	redactor, err := app.config.Options.SecretScanning.Redactor()
+	if err != nil {
+		return fmt.Errorf("invalid secret scanning configuration: %w", err)
+	}
+	cfg := *app.config.Bridge
+	token, err := app.config.Resolve(cfg.Token)
+	if err != nil {
+		return fmt.Errorf("bridge: resolving token: %w", err)
+	}
+	cfg.Token = token
+	b, err := bridge.New(cfg, app.Permissions, redactor)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(app.globalCtx)
+	messages := app.Messages.Subscribe(ctx)
+	requests := app.Permissions.Subscribe(ctx)
+	notifications := app.Permissions.SubscribeNotifications(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		app.forwardBridgeEvents(ctx, b, messages, requests, notifications)
+	}()
+	app.cleanupFuncs = append(app.cleanupFuncs, func() error {
+		cancel()
+		<-done
+		b.Wait()
+		return nil
+	})
+	return nil
+}
+
+func (app *App) forwardBridgeEvents(
+	ctx context.Context,
+	b *bridge.Bridge,
+	messages <-chan pubsub.Event[message.Message],
+	requests <-chan pubsub.Event[permission.PermissionRequest],
+	notifications <-chan pubsub.Event[permission.PermissionNotification],
+) {
+	completed := make(map[string]bool)
+	// The channels are closed once ctx is done.
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-messages:
+			if !ok {
+				return
+			}
+			msg := event.Payload
+			if sess, ok := app.completedRun(ctx, msg, completed); ok {
+				b.Summary(ctx, sess.Title, msg.Content().Text)
+			}
+		case event, ok := <-requests:
+			if !ok {
+				return
+			}
+			req := event.Payload
+			b.Ask(ctx, req, app.sessionTitle(ctx, req.SessionID))
+		case event, ok := <-notifications:
+			if !ok {
+				return
+			}
+			if n := event.Payload; n.Granted || n.Denied {
+				b.Answered(n.ToolCallID)
+			}
+		}
+	}
+}
@@ -2,6 +2,7 @@ package app
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"time"
 
@@ -9,6 +10,9 @@ import (
 	"github.com/charmbracelet/crush/internal/lsp"
 )
 
+// ErrLSPServerExited is the error of the clients whose server crashed.
+var ErrLSPServerExited = errors.New("server exited unexpectedly")
+
 // initLSPClients initializes LSP clients.
 func (app *App) initLSPClients(ctx context.Context) {
 	for name, clientConfig := range app.config.LSP {
@@ -21,6 +25,51 @@ func (app *App) initLSPClients(ctx context.Context) {
 	slog.Info("LSP clients initialization started in background")
 }
 
+// StartLSPClient starts the client of a language server configured after
+// startup.
+func (app *App) StartLSPClient(name string) {
+	clientConfig, ok := app.config.LSP[name]
+	if !ok || clientConfig.Disabled {
+		return
+	}
+	go app.createAndStartLSPClient(app.globalCtx, name, clientConfig)
+}
+
+// StopLSPClient shuts down the client of a language server until it's
+// started again.
+func (app *App) StopLSPClient(name string) {
+	if client, ok := app.LSPClients.Take(name); ok {
+		ctx, cancel := context.WithTimeout(app.globalCtx, 5*time.Second)
+		defer cancel()
+		if err := client.Close(ctx); err != nil {
+			slog.Error("Failed to shutdown LSP client", "name", name, "error", err)
+		}
+	}
+	updateLSPState(name, lsp.StateStopped, nil, nil, 0)
+}
+
+// RestartLSPClient stops the client of a language server and starts it
+// again.
+func (app *App) RestartLSPClient(name string) {
+	app.StopLSPClient(name)
+	app.StartLSPClient(name)
+}
+
+// SetLSPEnabled enables or disables a language server in the config file,
+// starting or stopping its client.
+func (app *App) SetLSPEnabled(name string, enabled bool) error {
+	if err := app.config.SetLSPDisabled(name, !enabled); err != nil {
+		return err
+	}
+	if enabled {
+		app.StartLSPClient(name)
+	} else {
+		app.StopLSPClient(name)
+		updateLSPState(name, lsp.StateDisabled, nil, nil, 0)
+	}
+	return nil
+}
+
 // createAndStartLSPClient creates a new LSP client, initializes it, and starts its workspace watcher
 func (app *App) createAndStartLSPClient(ctx context.Context, name string, config config.LSPConfig) {
 	slog.Debug("Creating LSP client", "name", name, "command", config.Command, "fileTypes", config.FileTypes, "args", config.Args)
@@ -45,6 +94,7 @@ func (app *App) createAndStartLSPClient(ctx context.Context, name string, config
 
 	// Set diagnostics callback
 	lspClient.SetDiagnosticsCallback(updateLSPDiagnostics)
+	lspClient.SetErrorCallback(recordLSPError)
 
 	// Increase initialization timeout as some servers take more time to start.
 	initCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
@@ -77,4 +127,31 @@ func (app *App) createAndStartLSPClient(ctx context.Context, name string, config
 
 	// Add to map with mutex protection before starting goroutine
 	app.LSPClients.Set(name, lspClient)
+
+	go app.watchLSPClient(ctx, name, lspClient)
+}
+
+// watchLSPClient reports the server of a client as crashed when its process
+// exits while the client is in use.
+func (app *App) watchLSPClient(ctx context.Context, name string, client *lsp.Client) {
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if current, ok := app.LSPClients.Get(name); !ok || current != client {
+			return
+		}
+		if client.IsRunning() {
+			continue
+		}
+		slog.Error("LSP server exited unexpectedly", "name", name)
+		app.LSPClients.Del(name)
+		client.SetServerState(lsp.StateError)
+		updateLSPState(name, lsp.StateError, ErrLSPServerExited, nil, 0)
+		return
+	}
 }
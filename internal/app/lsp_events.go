@@ -3,6 +3,7 @@ package app
 import (
 	"context"
 	"maps"
+	"slices"
 	"time"
 
 	"github.com/charmbracelet/crush/internal/csync"
@@ -16,8 +17,12 @@ type LSPEventType string
 const (
 	LSPEventStateChanged       LSPEventType = "state_changed"
 	LSPEventDiagnosticsChanged LSPEventType = "diagnostics_changed"
+	LSPEventErrorReported      LSPEventType = "error_reported"
 )
 
+// maxRecentLSPErrors is the number of errors kept per LSP client.
+const maxRecentLSPErrors = 5
+
 // LSPEvent represents an event in the LSP system
 type LSPEvent struct {
 	Type            LSPEventType
@@ -35,6 +40,14 @@ type LSPClientInfo struct {
 	Client          *lsp.Client
 	DiagnosticCount int
 	ConnectedAt     time.Time
+	// RecentErrors are the last errors of the client, oldest first.
+	RecentErrors []LSPError
+}
+
+// LSPError is an error of an LSP client or reported by its server.
+type LSPError struct {
+	Time time.Time
+	Err  error
 }
 
 var (
@@ -69,6 +82,12 @@ func updateLSPState(name string, state lsp.ServerState, err error, client *lsp.C
 	if state == lsp.StateReady {
 		info.ConnectedAt = time.Now()
 	}
+	if prev, ok := lspStates.Get(name); ok {
+		info.RecentErrors = prev.RecentErrors
+	}
+	if err != nil {
+		info.RecentErrors = appendLSPError(info.RecentErrors, err)
+	}
 	lspStates.Set(name, info)
 
 	// Publish state change event
@@ -97,3 +116,27 @@ func updateLSPDiagnostics(name string, diagnosticCount int) {
 		})
 	}
 }
+
+// recordLSPError records an error reported by the server of an LSP client
+// and publishes an event
+func recordLSPError(name string, err error) {
+	info, exists := lspStates.Get(name)
+	if !exists {
+		return
+	}
+	info.RecentErrors = appendLSPError(info.RecentErrors, err)
+	lspStates.Set(name, info)
+
+	lspBroker.Publish(pubsub.UpdatedEvent, LSPEvent{
+		Type:            LSPEventErrorReported,
+		Name:            name,
+		State:           info.State,
+		Error:           err,
+		DiagnosticCount: info.DiagnosticCount,
+	})
+}
+
+func appendLSPError(errs []LSPError, err error) []LSPError {
+	errs = append(slices.Clone(errs), LSPError{Time: time.Now(), Err: err})
+	return errs[max(0, len(errs)-maxRecentLSPErrors):]
+}
@@ -0,0 +1,274 @@
+package app
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/filepathext"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/preview"
+	"github.com/charmbracelet/crush/internal/session"
+)
+
+// ChangeSessionDir moves a session to the directory at path, resolved
+// against the current directory of the session, and returns the new
+// directory. An empty path moves the session back to the working directory
+// of Crush.
+func (app *App) ChangeSessionDir(ctx context.Context, sessionID, path string) (string, error) {
+	sess, err := app.Sessions.Get(ctx, sessionID)
+	if err != nil {
+		return "", err
+	}
+	root := app.config.WorkingDir()
+	dir := root
+	if path != "" {
+		expanded, err := fsext.Expand(path)
+		if err != nil {
+			return "", fmt.Errorf("invalid path %s: %w", path, err)
+		}
+		dir = filepath.Clean(filepathext.SmartJoin(cmp.Or(sess.WorkingDir, root), expanded))
+		info, err := os.Stat(dir)
+		if err != nil {
+			return "", err
+		}
+		if !info.IsDir() {
+			return "", fmt.Errorf("%s is not a directory", dir)
+		}
+	}
+
+	stored := dir
+	if dir == root {
+		stored = ""
+	}
+	if err := app.Sessions.SetWorkingDir(ctx, sessionID, stored); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// ForkSession creates a new session holding a copy of the conversation up to
+// and including the given message, so it can be continued in another
+// direction without touching the original.
+func (app *App) ForkSession(ctx context.Context, sessionID, messageID string) (session.Session, error) {
+	orig, err := app.Sessions.Get(ctx, sessionID)
+	if err != nil {
+		return session.Session{}, err
+	}
+	msgs, err := app.Messages.List(ctx, sessionID)
+	if err != nil {
+		return session.Session{}, err
+	}
+	end := slices.IndexFunc(msgs, func(m message.Message) bool { return m.ID == messageID })
+	if end == -1 {
+		return session.Session{}, fmt.Errorf("message %s not found in session", messageID)
+	}
+	end = withToolResults(msgs, end)
+
+	title := orig.Title
+	if title == "" {
+		title = "Untitled Session"
+	}
+	fork, err := app.Sessions.Create(ctx, "Fork of "+title)
+	if err != nil {
+		return session.Session{}, fmt.Errorf("failed to create session: %w", err)
+	}
+	for _, msg := range msgs[:end+1] {
+		parts := msg.Parts
+		if msg.Role != message.Assistant {
+			// Create adds the finish part back for these.
+			parts = slices.DeleteFunc(slices.Clone(parts), func(p message.ContentPart) bool {
+				_, ok := p.(message.Finish)
+				return ok
+			})
+		}
+		if _, err := app.Messages.Create(ctx, fork.ID, message.CreateMessageParams{
+			Role:             msg.Role,
+			Parts:            parts,
+			Model:            msg.Model,
+			Provider:         msg.Provider,
+			IsSummaryMessage: msg.IsSummaryMessage,
+		}); err != nil {
+			return fork, fmt.Errorf("failed to copy message: %w", err)
+		}
+	}
+	return fork, nil
+}
+
+// DeleteMessage deletes a message from a session. When it's an assistant
+// message, the results of its tool calls go with it, as they'd be left
+// without the calls they answer.
+func (app *App) DeleteMessage(ctx context.Context, sessionID, messageID string) error {
+	msgs, err := app.Messages.List(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	start := slices.IndexFunc(msgs, func(m message.Message) bool { return m.ID == messageID })
+	if start == -1 {
+		return fmt.Errorf("message %s not found in session", messageID)
+	}
+	for _, msg := range msgs[start : withToolResults(msgs, start)+1] {
+		if err := app.Messages.Delete(ctx, msg.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteToolCall removes a single tool call from an assistant message, along
+// with its result. Messages left with nothing in them are deleted.
+func (app *App) DeleteToolCall(ctx context.Context, sessionID, messageID, toolCallID string) error {
+	msgs, err := app.Messages.List(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	start := slices.IndexFunc(msgs, func(m message.Message) bool { return m.ID == messageID })
+	if start == -1 {
+		return fmt.Errorf("message %s not found in session", messageID)
+	}
+	for _, msg := range msgs[start : withToolResults(msgs, start)+1] {
+		parts := slices.DeleteFunc(slices.Clone(msg.Parts), func(p message.ContentPart) bool {
+			switch p := p.(type) {
+			case message.ToolCall:
+				return p.ID == toolCallID
+			case message.ToolResult:
+				return p.ToolCallID == toolCallID
+			}
+			return false
+		})
+		if len(parts) == len(msg.Parts) {
+			continue
+		}
+		msg.Parts = parts
+		if isEmpty(msg) {
+			err = app.Messages.Delete(ctx, msg.ID)
+		} else {
+			err = app.Messages.Update(ctx, msg)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isEmpty reports whether a message has nothing left to send to the model.
+func isEmpty(msg message.Message) bool {
+	return strings.TrimSpace(msg.Content().Text) == "" &&
+		len(msg.ToolCalls()) == 0 &&
+		len(msg.ToolResults()) == 0 &&
+		len(msg.BinaryContent()) == 0
+}
+
+// RewindSession deletes the given message and everything after it.
+func (app *App) RewindSession(ctx context.Context, sessionID, messageID string) error {
+	msgs, err := app.Messages.List(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	start := slices.IndexFunc(msgs, func(m message.Message) bool { return m.ID == messageID })
+	if start == -1 {
+		return fmt.Errorf("message %s not found in session", messageID)
+	}
+	for _, msg := range slices.Backward(msgs[start:]) {
+		if err := app.Messages.Delete(ctx, msg.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExportMessage writes a message as Markdown to a file in the working
+// directory and returns its path.
+func (app *App) ExportMessage(msg message.Message) (string, error) {
+	path := filepath.Join(app.config.WorkingDir(), fmt.Sprintf("crush-message-%.8s.md", msg.ID))
+	if err := os.WriteFile(path, []byte(messageMarkdown(msg)+"\n"), 0o644); err != nil {
+		return "", fmt.Errorf("failed to export message: %w", err)
+	}
+	return path, nil
+}
+
+// PreviewMessage serves a message rendered as HTML on a local server, to be
+// read in a browser, and returns its URL.
+func (app *App) PreviewMessage(msg message.Message) (string, error) {
+	title := "User Message"
+	if msg.Role == message.Assistant {
+		title = "Assistant Message"
+	}
+	return preview.Serve(title, messageMarkdown(msg))
+}
+
+// PreviewSession serves the messages of a session rendered as HTML on a
+// local server, to be read in a browser, and returns its URL.
+func (app *App) PreviewSession(ctx context.Context, sessionID string) (string, error) {
+	sess, err := app.Sessions.Get(ctx, sessionID)
+	if err != nil {
+		return "", err
+	}
+	msgs, err := app.Messages.List(ctx, sessionID)
+	if err != nil {
+		return "", err
+	}
+	return preview.Serve(cmp.Or(sess.Title, "Untitled Session"), sessionMarkdown(msgs))
+}
+
+// withToolResults returns the index of the last message that belongs with
+// the message at i: the tool messages answering an assistant message's tool
+// calls follow it.
+func withToolResults(msgs []message.Message, i int) int {
+	if msgs[i].Role != message.Assistant || len(msgs[i].ToolCalls()) == 0 {
+		return i
+	}
+	for i+1 < len(msgs) && msgs[i+1].Role == message.Tool {
+		i++
+	}
+	return i
+}
+
+// messageMarkdown renders the text, tool calls and tool errors of a message
+// as Markdown.
+func messageMarkdown(msg message.Message) string {
+	var b strings.Builder
+	switch msg.Role {
+	case message.User, message.Assistant:
+		if text := strings.TrimSpace(msg.Content().Text); text != "" {
+			b.WriteString(text + "\n\n")
+		}
+		for _, tc := range msg.ToolCalls() {
+			fmt.Fprintf(&b, "**Tool call:** `%s`\n\n%s\n\n", tc.Name, fenced(tc.Input, "json"))
+		}
+	case message.Tool:
+		for _, tr := range msg.ToolResults() {
+			if tr.IsError {
+				fmt.Fprintf(&b, "**Tool error:** `%s`\n\n%s\n\n", tr.Name, fenced(tr.Content, ""))
+			}
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// sessionMarkdown renders the messages of a session as Markdown, each under
+// a heading saying who wrote it. The results of tool calls go along with the
+// message that made them.
+func sessionMarkdown(msgs []message.Message) string {
+	var parts []string
+	for _, msg := range msgs {
+		text := messageMarkdown(msg)
+		if text == "" {
+			continue
+		}
+		switch msg.Role {
+		case message.User:
+			text = "## User\n\n" + text
+		case message.Assistant:
+			text = "## Assistant\n\n" + text
+		}
+		parts = append(parts, text)
+	}
+	return strings.Join(parts, "\n\n")
+}
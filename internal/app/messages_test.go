@@ -0,0 +1,220 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/stretchr/testify/require"
+)
+
+func testApp(t *testing.T) *App {
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	q := db.New(conn)
+	return &App{
+		Sessions: session.NewService(q),
+		Messages: message.NewService(q),
+		History:  history.NewService(q, conn),
+	}
+}
+
+// conversation creates a session where the assistant calls two tools, whose
+// results are in the tool message following its message, and returns the
+// session with its messages.
+func conversation(t *testing.T, app *App) (session.Session, []message.Message) {
+	sess, err := app.Sessions.Create(t.Context(), "Tests")
+	require.NoError(t, err)
+
+	var msgs []message.Message
+	for _, params := range []message.CreateMessageParams{
+		{Role: message.User, Parts: []message.ContentPart{message.TextContent{Text: "Run the tests"}}},
+		{Role: message.Assistant, Parts: []message.ContentPart{
+			message.TextContent{Text: "Running them."},
+			message.ToolCall{ID: "call-1", Name: "bash", Input: `{"command":"go test ./..."}`, Finished: true},
+			message.ToolCall{ID: "call-2", Name: "view", Input: `{"file_path":"go.mod"}`, Finished: true},
+			message.Finish{Reason: message.FinishReasonToolUse},
+		}},
+		{Role: message.Tool, Parts: []message.ContentPart{
+			message.ToolResult{ToolCallID: "call-1", Name: "bash", Content: "ok"},
+			message.ToolResult{ToolCallID: "call-2", Name: "view", Content: "module example"},
+		}},
+		{Role: message.Assistant, Parts: []message.ContentPart{
+			message.TextContent{Text: "All pass."},
+			message.Finish{Reason: message.FinishReasonEndTurn},
+		}},
+		{Role: message.User, Parts: []message.ContentPart{message.TextContent{Text: "Thanks"}}},
+	} {
+		msg, err := app.Messages.Create(t.Context(), sess.ID, params)
+		require.NoError(t, err)
+		msgs = append(msgs, msg)
+	}
+	return sess, msgs
+}
+
+func ids(msgs []message.Message) []string {
+	ids := make([]string, len(msgs))
+	for i, msg := range msgs {
+		ids[i] = msg.ID
+	}
+	return ids
+}
+
+func TestDeleteMessage(t *testing.T) {
+	t.Parallel()
+
+	t.Run("ToolResultsGoWithTheirCalls", func(t *testing.T) {
+		t.Parallel()
+		app := testApp(t)
+		sess, msgs := conversation(t, app)
+
+		require.NoError(t, app.DeleteMessage(t.Context(), sess.ID, msgs[1].ID))
+		left, err := app.Messages.List(t.Context(), sess.ID)
+		require.NoError(t, err)
+		require.Equal(t, ids([]message.Message{msgs[0], msgs[3], msgs[4]}), ids(left))
+	})
+
+	t.Run("OtherMessagesAreLeftAlone", func(t *testing.T) {
+		t.Parallel()
+		app := testApp(t)
+		sess, msgs := conversation(t, app)
+
+		require.NoError(t, app.DeleteMessage(t.Context(), sess.ID, msgs[3].ID))
+		left, err := app.Messages.List(t.Context(), sess.ID)
+		require.NoError(t, err)
+		require.Equal(t, ids([]message.Message{msgs[0], msgs[1], msgs[2], msgs[4]}), ids(left))
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		t.Parallel()
+		app := testApp(t)
+		sess, _ := conversation(t, app)
+
+		require.ErrorContains(t, app.DeleteMessage(t.Context(), sess.ID, "missing"), "not found")
+	})
+}
+
+func TestDeleteToolCall(t *testing.T) {
+	t.Parallel()
+
+	t.Run("ResultGoesWithTheCall", func(t *testing.T) {
+		t.Parallel()
+		app := testApp(t)
+		sess, msgs := conversation(t, app)
+
+		require.NoError(t, app.DeleteToolCall(t.Context(), sess.ID, msgs[1].ID, "call-1"))
+		left, err := app.Messages.List(t.Context(), sess.ID)
+		require.NoError(t, err)
+		require.Equal(t, ids(msgs), ids(left))
+
+		calls := left[1].ToolCalls()
+		require.Len(t, calls, 1)
+		require.Equal(t, "call-2", calls[0].ID)
+		require.Equal(t, "Running them.", left[1].Content().Text)
+		results := left[2].ToolResults()
+		require.Len(t, results, 1)
+		require.Equal(t, "call-2", results[0].ToolCallID)
+
+		// The other messages are untouched.
+		for _, i := range []int{0, 3, 4} {
+			require.Equal(t, msgs[i].Parts, left[i].Parts)
+		}
+	})
+
+	t.Run("EmptiedMessagesAreDeleted", func(t *testing.T) {
+		t.Parallel()
+		app := testApp(t)
+		sess, msgs := conversation(t, app)
+
+		msgs[1].Parts = []message.ContentPart{msgs[1].Parts[1], msgs[1].Parts[2], msgs[1].Parts[3]}
+		require.NoError(t, app.Messages.Update(t.Context(), msgs[1]))
+
+		require.NoError(t, app.DeleteToolCall(t.Context(), sess.ID, msgs[1].ID, "call-1"))
+		require.NoError(t, app.DeleteToolCall(t.Context(), sess.ID, msgs[1].ID, "call-2"))
+		left, err := app.Messages.List(t.Context(), sess.ID)
+		require.NoError(t, err)
+		require.Equal(t, ids([]message.Message{msgs[0], msgs[3], msgs[4]}), ids(left))
+	})
+}
+
+func TestIsEmpty(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		parts []message.ContentPart
+		empty bool
+	}{
+		{"Nothing", nil, true},
+		{"OnlyFinish", []message.ContentPart{message.Finish{Reason: message.FinishReasonEndTurn}}, true},
+		{"BlankText", []message.ContentPart{message.TextContent{Text: " \n"}}, true},
+		{"Text", []message.ContentPart{message.TextContent{Text: "Hi"}}, false},
+		{"ToolCall", []message.ContentPart{message.ToolCall{ID: "call"}}, false},
+		{"ToolResult", []message.ContentPart{message.ToolResult{ToolCallID: "call"}}, false},
+		{"Image", []message.ContentPart{message.BinaryContent{Path: "a.png", MIMEType: "image/png", Data: []byte{1}}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.empty, isEmpty(message.Message{Parts: tt.parts}))
+		})
+	}
+}
+
+func TestForkSession(t *testing.T) {
+	t.Parallel()
+
+	app := testApp(t)
+	sess, msgs := conversation(t, app)
+
+	// Forking at the message calling the tools takes their results along.
+	fork, err := app.ForkSession(t.Context(), sess.ID, msgs[1].ID)
+	require.NoError(t, err)
+	require.Equal(t, "Fork of Tests", fork.Title)
+	forked, err := app.Messages.List(t.Context(), fork.ID)
+	require.NoError(t, err)
+	require.Len(t, forked, 3)
+	for i, msg := range forked {
+		require.Equal(t, msgs[i].Role, msg.Role)
+		require.Equal(t, msgs[i].Parts, msg.Parts)
+	}
+	results := map[string]bool{}
+	for _, msg := range forked {
+		for _, tr := range msg.ToolResults() {
+			results[tr.ToolCallID] = true
+		}
+	}
+	for _, msg := range forked {
+		for _, tc := range msg.ToolCalls() {
+			require.True(t, results[tc.ID], "tool call %s has no result", tc.ID)
+		}
+	}
+
+	// The original session is untouched.
+	left, err := app.Messages.List(t.Context(), sess.ID)
+	require.NoError(t, err)
+	require.Equal(t, ids(msgs), ids(left))
+
+	_, err = app.ForkSession(t.Context(), sess.ID, "missing")
+	require.ErrorContains(t, err, "not found")
+}
+
+func TestWithToolResults(t *testing.T) {
+	t.Parallel()
+
+	msgs := []message.Message{
+		{Role: message.User},
+		{Role: message.Assistant, Parts: []message.ContentPart{message.ToolCall{ID: "call-1"}}},
+		{Role: message.Tool},
+		{Role: message.Tool},
+		{Role: message.Assistant},
+		{Role: message.Assistant, Parts: []message.ContentPart{message.ToolCall{ID: "call-2"}}},
+	}
+	for i, want := range []int{0, 3, 2, 3, 4, 5} {
+		require.Equal(t, want, withToolResults(msgs, i), "message %d", i)
+	}
+}
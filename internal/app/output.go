@@ -0,0 +1,381 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/diff"
+	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/message"
+)
+
+// OutputFormat selects what RunNonInteractive prints.
+type OutputFormat string
+
+const (
+	// OutputText streams the assistant's replies as they're generated.
+	OutputText OutputFormat = "text"
+	// OutputMarkdown prints a transcript of the run, including tool calls
+	// and file changes, once it's done.
+	OutputMarkdown OutputFormat = "markdown"
+	// OutputJSON streams newline-delimited JSON events.
+	OutputJSON OutputFormat = "json"
+	// OutputDiff prints a unified diff of the files changed by the run.
+	OutputDiff OutputFormat = "diff"
+)
+
+// OutputFormats lists the supported output formats.
+var OutputFormats = []OutputFormat{OutputText, OutputMarkdown, OutputJSON, OutputDiff}
+
+// ParseOutputFormat parses an output format name.
+func ParseOutputFormat(name string) (OutputFormat, error) {
+	if name == "" {
+		return OutputText, nil
+	}
+	format := OutputFormat(strings.ToLower(name))
+	if !slices.Contains(OutputFormats, format) {
+		return "", fmt.Errorf("unknown output format %q: expected text, markdown, json or diff", name)
+	}
+	return format, nil
+}
+
+// runPrinter prints the progress and outcome of a non-interactive run.
+type runPrinter interface {
+	start(sessionID string) error
+	message(msg message.Message) error
+	finish(ctx context.Context, sessionID string, runErr error) error
+}
+
+func (app *App) newRunPrinter(format OutputFormat, output io.Writer) runPrinter {
+	switch format {
+	case OutputMarkdown:
+		return &markdownPrinter{app: app, output: output}
+	case OutputJSON:
+		enc := json.NewEncoder(output)
+		enc.SetEscapeHTML(false)
+		return &jsonPrinter{
+			app:         app,
+			enc:         enc,
+			readBytes:   make(map[string]int),
+			toolCalls:   make(map[string]bool),
+			toolResults: make(map[string]bool),
+		}
+	case OutputDiff:
+		return &diffPrinter{app: app, output: output}
+	default:
+		return &textPrinter{output: output, readBytes: make(map[string]int)}
+	}
+}
+
+// textPrinter streams the assistant's text as it's generated.
+type textPrinter struct {
+	output    io.Writer
+	readBytes map[string]int
+}
+
+func (p *textPrinter) start(string) error { return nil }
+
+func (p *textPrinter) message(msg message.Message) error {
+	if msg.Role != message.Assistant || len(msg.Parts) == 0 {
+		return nil
+	}
+	part, err := newContent(msg, p.readBytes)
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(p.output, part)
+	return nil
+}
+
+func (p *textPrinter) finish(context.Context, string, error) error { return nil }
+
+// newContent returns the assistant text added to msg since it was last seen.
+func newContent(msg message.Message, readBytes map[string]int) (string, error) {
+	content := msg.Content().String()
+	read := readBytes[msg.ID]
+
+	if len(content) < read {
+		slog.Error("Non-interactive: message content is shorter than read bytes", "message_length", len(content), "read_bytes", read)
+		return "", fmt.Errorf("message content is shorter than read bytes: %d < %d", len(content), read)
+	}
+
+	part := content[read:]
+	// Trim leading whitespace. Sometimes the LLM includes leading
+	// formatting and intentation, which we don't want here.
+	if read == 0 {
+		part = strings.TrimLeft(part, " \t")
+	}
+	readBytes[msg.ID] = len(content)
+	return part, nil
+}
+
+// runEvent is a single line of the JSON output.
+type runEvent struct {
+	Type       string              `json:"type"`
+	SessionID  string              `json:"session_id,omitempty"`
+	Text       string              `json:"text,omitempty"`
+	ToolCall   *message.ToolCall   `json:"tool_call,omitempty"`
+	ToolResult *message.ToolResult `json:"tool_result,omitempty"`
+	Diff       *fileDiff           `json:"diff,omitempty"`
+	Usage      *runUsage           `json:"usage,omitempty"`
+	Error      string              `json:"error,omitempty"`
+}
+
+type runUsage struct {
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	Cost             float64 `json:"cost"`
+}
+
+// jsonPrinter streams the run as newline-delimited JSON events: start, text,
+// tool_call, tool_result, diff, and finally result or error.
+type jsonPrinter struct {
+	app         *App
+	enc         *json.Encoder
+	readBytes   map[string]int
+	toolCalls   map[string]bool
+	toolResults map[string]bool
+}
+
+func (p *jsonPrinter) start(sessionID string) error {
+	return p.enc.Encode(runEvent{Type: "start", SessionID: sessionID})
+}
+
+func (p *jsonPrinter) message(msg message.Message) error {
+	switch msg.Role {
+	case message.Assistant:
+		if len(msg.Parts) == 0 {
+			return nil
+		}
+		part, err := newContent(msg, p.readBytes)
+		if err != nil {
+			return err
+		}
+		if part != "" {
+			if err := p.enc.Encode(runEvent{Type: "text", Text: part}); err != nil {
+				return err
+			}
+		}
+		for _, tc := range msg.ToolCalls() {
+			if !tc.Finished || p.toolCalls[tc.ID] {
+				continue
+			}
+			p.toolCalls[tc.ID] = true
+			if err := p.enc.Encode(runEvent{Type: "tool_call", ToolCall: &tc}); err != nil {
+				return err
+			}
+		}
+	case message.Tool:
+		for _, tr := range msg.ToolResults() {
+			if p.toolResults[tr.ToolCallID] {
+				continue
+			}
+			p.toolResults[tr.ToolCallID] = true
+			if err := p.enc.Encode(runEvent{Type: "tool_result", ToolResult: &tr}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (p *jsonPrinter) finish(ctx context.Context, sessionID string, runErr error) error {
+	diffs, err := p.app.sessionDiffs(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	for _, d := range diffs {
+		if err := p.enc.Encode(runEvent{Type: "diff", Diff: &d}); err != nil {
+			return err
+		}
+	}
+	if runErr != nil {
+		return p.enc.Encode(runEvent{Type: "error", SessionID: sessionID, Error: runErr.Error()})
+	}
+
+	final, err := p.app.finalMessage(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	result := runEvent{Type: "result", SessionID: sessionID, Text: final}
+	if sess, err := p.app.Sessions.Get(ctx, sessionID); err == nil {
+		result.Usage = &runUsage{
+			PromptTokens:     sess.PromptTokens,
+			CompletionTokens: sess.CompletionTokens,
+			Cost:             sess.Cost,
+		}
+	}
+	return p.enc.Encode(result)
+}
+
+// markdownPrinter prints a transcript of the run once it's done.
+type markdownPrinter struct {
+	app    *App
+	output io.Writer
+}
+
+func (p *markdownPrinter) start(string) error { return nil }
+
+func (p *markdownPrinter) message(message.Message) error { return nil }
+
+func (p *markdownPrinter) finish(ctx context.Context, sessionID string, runErr error) error {
+	msgs, err := p.app.Messages.List(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	for _, msg := range msgs {
+		if msg.Role == message.User {
+			continue
+		}
+		if md := messageMarkdown(msg); md != "" {
+			b.WriteString(md + "\n\n")
+		}
+	}
+
+	diffs, err := p.app.sessionDiffs(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if len(diffs) > 0 {
+		b.WriteString("## Changes\n\n")
+		for _, d := range diffs {
+			fmt.Fprintf(&b, "### `%s` (+%d -%d)\n\n%s\n\n", d.Path, d.Additions, d.Removals, fenced(d.Diff, "diff"))
+		}
+	}
+	if runErr != nil {
+		fmt.Fprintf(&b, "**Error:** %s\n", runErr)
+	}
+
+	_, err = io.WriteString(p.output, strings.TrimRight(b.String(), "\n"))
+	return err
+}
+
+// diffPrinter prints the unified diff of all files changed by the run, so
+// the output can be fed to tools like git apply.
+type diffPrinter struct {
+	app    *App
+	output io.Writer
+}
+
+func (p *diffPrinter) start(string) error { return nil }
+
+func (p *diffPrinter) message(message.Message) error { return nil }
+
+func (p *diffPrinter) finish(ctx context.Context, sessionID string, _ error) error {
+	diffs, err := p.app.sessionDiffs(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	for _, d := range diffs {
+		if _, err := io.WriteString(p.output, d.Diff); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fileDiff is the change made to one file during a session.
+type fileDiff struct {
+	Path      string `json:"path"`
+	Diff      string `json:"diff"`
+	Additions int    `json:"additions"`
+	Removals  int    `json:"removals"`
+}
+
+// sessionDiffs returns the changes made to each file during the session and
+// by its sub-agents, comparing the first and latest recorded versions,
+// sorted by path.
+func (app *App) sessionDiffs(ctx context.Context, sessionID string) ([]fileDiff, error) {
+	files, err := app.sessionFiles(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	initial := make(map[string]history.File)
+	latest := make(map[string]history.File)
+	for _, file := range files {
+		if f, ok := initial[file.Path]; !ok || file.Version < f.Version {
+			initial[file.Path] = file
+		}
+		if f, ok := latest[file.Path]; !ok || file.Version > f.Version {
+			latest[file.Path] = file
+		}
+	}
+
+	cwd := app.config.WorkingDir()
+	diffs := make([]fileDiff, 0, len(latest))
+	for path, file := range latest {
+		name := strings.TrimPrefix(path, cwd)
+		unified, additions, removals := diff.GenerateDiff(initial[path].Content, file.Content, name)
+		if additions == 0 && removals == 0 {
+			continue
+		}
+		diffs = append(diffs, fileDiff{
+			Path:      strings.TrimPrefix(name, "/"),
+			Diff:      unified,
+			Additions: additions,
+			Removals:  removals,
+		})
+	}
+	slices.SortFunc(diffs, func(a, b fileDiff) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return diffs, nil
+}
+
+// sessionFiles returns the file history of the session and of the sessions
+// of its sub-agents, at any depth. Versions are numbered per path across
+// sessions, so they can be compared between them.
+func (app *App) sessionFiles(ctx context.Context, sessionID string) ([]history.File, error) {
+	files, err := app.History.ListBySession(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list session files: %w", err)
+	}
+	children, err := app.Sessions.ListChildren(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list child sessions: %w", err)
+	}
+	for _, child := range children {
+		childFiles, err := app.sessionFiles(ctx, child.ID)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, childFiles...)
+	}
+	return files, nil
+}
+
+// finalMessage returns the text of the last assistant message in the
+// session.
+func (app *App) finalMessage(ctx context.Context, sessionID string) (string, error) {
+	msgs, err := app.Messages.List(ctx, sessionID)
+	if err != nil {
+		return "", err
+	}
+	for _, msg := range slices.Backward(msgs) {
+		if msg.Role != message.Assistant {
+			continue
+		}
+		if text := strings.TrimSpace(msg.Content().Text); text != "" {
+			return text, nil
+		}
+	}
+	return "", nil
+}
+
+// fenced wraps text in a code fence long enough not to clash with any fence
+// inside it.
+func fenced(text, lang string) string {
+	fence := "```"
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+	return fence + lang + "\n" + strings.TrimRight(text, "\n") + "\n" + fence
+}
@@ -0,0 +1,287 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/diff"
+	"github.com/charmbracelet/crush/internal/log"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/stretchr/testify/require"
+)
+
+// outputApp returns an app with its configuration loaded for a temporary
+// working directory, without loading the real providers.
+func outputApp(t *testing.T) *App {
+	// Pre-initialize logger to os.DevNull to prevent file lock on Windows.
+	log.Setup(os.DevNull, log.Options{})
+
+	cfgDir := t.TempDir()
+	dataDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", cfgDir)
+	t.Setenv("XDG_DATA_HOME", dataDir)
+	confPath := filepath.Join(cfgDir, "crush", "crush.json")
+	require.NoError(t, os.MkdirAll(filepath.Dir(confPath), 0o755))
+	require.NoError(t, os.WriteFile(confPath, []byte(`{"options":{"disable_provider_auto_update":true}}`), 0o644))
+	require.NoError(t, os.MkdirAll(filepath.Join(dataDir, "crush"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dataDir, "crush", "providers.json"), []byte("[]"), 0o644))
+
+	cfg, err := config.Load(t.TempDir(), dataDir, false)
+	require.NoError(t, err)
+	app := testApp(t)
+	app.config = cfg
+	return app
+}
+
+// changeFiles records changes to two files in the session, and a file read
+// but left as it was, and returns the diffs of the changes, sorted by path.
+func changeFiles(t *testing.T, app *App, sessionID string) []fileDiff {
+	cwd := app.config.WorkingDir()
+	changes := []struct{ path, before, after string }{
+		{"main.go", "package main\n", "package main\n\nfunc main() {}\n"},
+		{"README.md", "", "# Example\n"},
+		{"go.mod", "module example\n", "module example\n"},
+	}
+	var diffs []fileDiff
+	for _, c := range changes {
+		path := filepath.Join(cwd, c.path)
+		_, err := app.History.Create(t.Context(), sessionID, path, c.before)
+		require.NoError(t, err)
+		_, err = app.History.CreateVersion(t.Context(), sessionID, path, c.after)
+		require.NoError(t, err)
+		if c.before == c.after {
+			continue
+		}
+		unified, additions, removals := diff.GenerateDiff(c.before, c.after, c.path)
+		diffs = append(diffs, fileDiff{Path: c.path, Diff: unified, Additions: additions, Removals: removals})
+	}
+	// README.md sorts before main.go.
+	diffs[0], diffs[1] = diffs[1], diffs[0]
+	return diffs
+}
+
+func TestParseOutputFormat(t *testing.T) {
+	t.Parallel()
+
+	for name, want := range map[string]OutputFormat{
+		"":         OutputText,
+		"text":     OutputText,
+		"Markdown": OutputMarkdown,
+		"JSON":     OutputJSON,
+		"diff":     OutputDiff,
+	} {
+		format, err := ParseOutputFormat(name)
+		require.NoError(t, err)
+		require.Equal(t, want, format)
+	}
+	_, err := ParseOutputFormat("yaml")
+	require.ErrorContains(t, err, "unknown output format")
+}
+
+func assistant(id, text string, parts ...message.ContentPart) message.Message {
+	return message.Message{
+		ID:    id,
+		Role:  message.Assistant,
+		Parts: append([]message.ContentPart{message.TextContent{Text: text}}, parts...),
+	}
+}
+
+func TestTextPrinter(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	p := testApp(t).newRunPrinter(OutputText, &out)
+	require.NoError(t, p.start("session"))
+
+	// Only the text added since the last update is printed, without the
+	// leading indentation.
+	require.NoError(t, p.message(assistant("one", "  Hello")))
+	require.NoError(t, p.message(assistant("one", "  Hello, world.")))
+	require.NoError(t, p.message(message.Message{ID: "user", Role: message.User, Parts: []message.ContentPart{message.TextContent{Text: "Hi"}}}))
+	require.NoError(t, p.message(assistant("two", "\tBye.")))
+	require.NoError(t, p.finish(t.Context(), "session", nil))
+	require.Equal(t, "Hello, world.Bye.", out.String())
+
+	require.Error(t, p.message(assistant("one", "Hello")))
+}
+
+func TestJSONPrinter(t *testing.T) {
+	app := outputApp(t)
+	sess, err := app.Sessions.Create(t.Context(), "Run")
+	require.NoError(t, err)
+	diffs := changeFiles(t, app, sess.ID)
+
+	var out bytes.Buffer
+	p := app.newRunPrinter(OutputJSON, &out)
+	call := message.ToolCall{ID: "call", Name: "edit", Input: `{"file_path":"main.go"}`}
+	finished := call
+	finished.Finished = true
+	result := message.ToolResult{ToolCallID: "call", Name: "edit", Content: "edited"}
+	tool := message.Message{ID: "tool", Role: message.Tool, Parts: []message.ContentPart{result}}
+
+	require.NoError(t, p.start(sess.ID))
+	require.NoError(t, p.message(assistant("one", "Let me")))
+	require.NoError(t, p.message(assistant("one", "Let me edit it.", call)))
+	require.NoError(t, p.message(assistant("one", "Let me edit it.", finished)))
+	require.NoError(t, p.message(assistant("one", "Let me edit it.", finished)))
+	require.NoError(t, p.message(tool))
+	require.NoError(t, p.message(tool))
+	_, err = app.Messages.Create(t.Context(), sess.ID, message.CreateMessageParams{
+		Role:  message.Assistant,
+		Parts: []message.ContentPart{message.TextContent{Text: "Done."}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, p.message(assistant("two", "Done.")))
+	sess.PromptTokens, sess.CompletionTokens, sess.Cost = 100, 20, 0.25
+	_, err = app.Sessions.Save(t.Context(), sess)
+	require.NoError(t, err)
+	require.NoError(t, p.finish(t.Context(), sess.ID, nil))
+
+	// Every tool call and result is sent once, the diffs before the result.
+	want := []runEvent{
+		{Type: "start", SessionID: sess.ID},
+		{Type: "text", Text: "Let me"},
+		{Type: "text", Text: " edit it."},
+		{Type: "tool_call", ToolCall: &finished},
+		{Type: "tool_result", ToolResult: &result},
+		{Type: "text", Text: "Done."},
+		{Type: "diff", Diff: &diffs[0]},
+		{Type: "diff", Diff: &diffs[1]},
+		{Type: "result", SessionID: sess.ID, Text: "Done.", Usage: &runUsage{PromptTokens: 100, CompletionTokens: 20, Cost: 0.25}},
+	}
+	require.Equal(t, want, decodeEvents(t, out.String()))
+
+	// A failed run ends with an error instead of the result.
+	out.Reset()
+	require.NoError(t, p.finish(t.Context(), sess.ID, errors.New("context canceled")))
+	events := decodeEvents(t, out.String())
+	require.Equal(t, runEvent{Type: "error", SessionID: sess.ID, Error: "context canceled"}, events[len(events)-1])
+}
+
+func decodeEvents(t *testing.T, out string) []runEvent {
+	var events []runEvent
+	for line := range strings.Lines(out) {
+		var event runEvent
+		require.NoError(t, json.Unmarshal([]byte(line), &event))
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestMarkdownPrinter(t *testing.T) {
+	app := outputApp(t)
+	sess, _ := conversation(t, app)
+	diffs := changeFiles(t, app, sess.ID)
+	_, err := app.Messages.Create(t.Context(), sess.ID, message.CreateMessageParams{
+		Role: message.Tool,
+		Parts: []message.ContentPart{
+			message.ToolResult{ToolCallID: "call-3", Name: "bash", Content: "exit status 1", IsError: true},
+		},
+	})
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	p := app.newRunPrinter(OutputMarkdown, &out)
+	require.NoError(t, p.start(sess.ID))
+	require.NoError(t, p.finish(t.Context(), sess.ID, errors.New("boom")))
+
+	// The user's messages and the successful tool results are left out.
+	want := "Running them.\n\n" +
+		"**Tool call:** `bash`\n\n```json\n{\"command\":\"go test ./...\"}\n```\n\n" +
+		"**Tool call:** `view`\n\n```json\n{\"file_path\":\"go.mod\"}\n```\n\n" +
+		"All pass.\n\n" +
+		"**Tool error:** `bash`\n\n```\nexit status 1\n```\n\n" +
+		"## Changes\n\n" +
+		"### `README.md` (+1 -0)\n\n" + fenced(diffs[0].Diff, "diff") + "\n\n" +
+		"### `main.go` (+2 -0)\n\n" + fenced(diffs[1].Diff, "diff") + "\n\n" +
+		"**Error:** boom"
+	require.Equal(t, want, out.String())
+}
+
+func TestDiffPrinter(t *testing.T) {
+	app := outputApp(t)
+	sess, err := app.Sessions.Create(t.Context(), "Run")
+	require.NoError(t, err)
+	diffs := changeFiles(t, app, sess.ID)
+
+	var out bytes.Buffer
+	p := app.newRunPrinter(OutputDiff, &out)
+	require.NoError(t, p.start(sess.ID))
+	require.NoError(t, p.finish(t.Context(), sess.ID, nil))
+	require.Equal(t, diffs[0].Diff+diffs[1].Diff, out.String())
+}
+
+func TestSessionDiffs(t *testing.T) {
+	app := outputApp(t)
+	sess, err := app.Sessions.Create(t.Context(), "Run")
+	require.NoError(t, err)
+	want := changeFiles(t, app, sess.ID)
+
+	// Only the first and latest versions are compared.
+	path := filepath.Join(app.config.WorkingDir(), "main.go")
+	_, err = app.History.CreateVersion(t.Context(), sess.ID, path, "package main\n\nfunc main() {}\n")
+	require.NoError(t, err)
+
+	diffs, err := app.sessionDiffs(t.Context(), sess.ID)
+	require.NoError(t, err)
+	require.Equal(t, want, diffs)
+	require.Equal(t, "README.md", diffs[0].Path)
+	require.Equal(t, 1, diffs[0].Additions)
+	require.Equal(t, "main.go", diffs[1].Path)
+	require.True(t, strings.HasPrefix(diffs[1].Diff, "--- a/main.go\n+++ b/main.go\n"))
+
+	// Sessions without changes have no diffs.
+	other, err := app.Sessions.Create(t.Context(), "Other")
+	require.NoError(t, err)
+	diffs, err = app.sessionDiffs(t.Context(), other.ID)
+	require.NoError(t, err)
+	require.Empty(t, diffs)
+}
+
+func TestSessionDiffsIncludeSubAgents(t *testing.T) {
+	app := outputApp(t)
+	sess, err := app.Sessions.Create(t.Context(), "Run")
+	require.NoError(t, err)
+	task, err := app.Sessions.CreateTaskSession(t.Context(), "call", sess.ID, "Task")
+	require.NoError(t, err)
+	nested, err := app.Sessions.CreateTaskSession(t.Context(), "nested", task.ID, "Nested")
+	require.NoError(t, err)
+	want := changeFiles(t, app, nested.ID)
+
+	// The parent edits main.go further after the sub-agent did.
+	path := filepath.Join(app.config.WorkingDir(), "main.go")
+	after := "package main\n\nfunc main() {\n\tprintln()\n}\n"
+	_, err = app.History.CreateVersion(t.Context(), sess.ID, path, after)
+	require.NoError(t, err)
+	unified, additions, removals := diff.GenerateDiff("package main\n", after, "main.go")
+	want[1] = fileDiff{Path: "main.go", Diff: unified, Additions: additions, Removals: removals}
+
+	diffs, err := app.sessionDiffs(t.Context(), sess.ID)
+	require.NoError(t, err)
+	require.Equal(t, want, diffs)
+}
+
+func TestFenced(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name, text, lang, want string
+	}{
+		{"Plain", "go test ./...\n\n", "sh", "```sh\ngo test ./...\n```"},
+		{"WithFence", "```go\nfmt.Println()\n```", "md", "````md\n```go\nfmt.Println()\n```\n````"},
+		{"WithLongerFence", "````\n```\n````", "", "`````\n````\n```\n````\n`````"},
+		{"WithInlineBackticks", "use `go vet`", "", "```\nuse `go vet`\n```"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.want, fenced(tt.text, tt.lang))
+		})
+	}
+}
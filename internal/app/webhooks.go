@@ -0,0 +1,158 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/charmbracelet/crush/internal/agent"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/charmbracelet/crush/internal/redact"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/crush/internal/webhook"
+)
+
+// setupWebhooks posts the events of the agent to the webhooks of the config.
+// Likely secrets are masked, and in local-only mode only webhooks on the
+// same machine are allowed.
+func (app *App) setupWebhooks() error {
+	if len(app.config.Webhooks) == 0 {
+		return nil
+	}
+	redactor, err := app.config.Options.SecretScanning.Redactor()
+	if err != nil {
+		return fmt.Errorf("invalid secret scanning configuration: %w", err)
+	}
+	hooks := slices.Clone(app.config.Webhooks)
+	for i, h := range hooks {
+		if app.config.IsLocalOnly() && !config.IsLoopbackEndpoint(h.URL) {
+			return fmt.Errorf("webhook %s: only webhooks on localhost are allowed in local-only mode", h.URL)
+		}
+		if h.Secret == "" {
+			continue
+		}
+		secret, err := app.config.Resolve(h.Secret)
+		if err != nil {
+			return fmt.Errorf("webhook %s: resolving secret: %w", h.URL, err)
+		}
+		hooks[i].Secret = secret
+	}
+	notifier, err := webhook.New(hooks)
+	if err != nil {
+		return err
+	}
+
+	// Subscribe right away so that no event is missed.
+	ctx, cancel := context.WithCancel(app.globalCtx)
+	messages := app.Messages.Subscribe(ctx)
+	permissions := app.Permissions.Subscribe(ctx)
+	limits := agent.SubscribeLimitEvents(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		app.forwardWebhookEvents(notifier, redactor, messages, permissions, limits)
+	}()
+	app.cleanupFuncs = append(app.cleanupFuncs, func() error {
+		cancel()
+		<-done
+		notifier.Wait()
+		return nil
+	})
+	return nil
+}
+
+// forwardWebhookEvents sends the events of the channels to the notifier
+// until all of them are closed, so that events still buffered on shutdown
+// go out too.
+func (app *App) forwardWebhookEvents(
+	notifier *webhook.Notifier,
+	redactor *redact.Redactor,
+	messages <-chan pubsub.Event[message.Message],
+	permissions <-chan pubsub.Event[permission.PermissionRequest],
+	limits <-chan pubsub.Event[agent.LimitEvent],
+) {
+	// The context of the app may be done while draining.
+	ctx := context.Background()
+	completed := make(map[string]bool)
+	for messages != nil || permissions != nil || limits != nil {
+		select {
+		case event, ok := <-messages:
+			if !ok {
+				messages = nil
+				continue
+			}
+			msg := event.Payload
+			sess, ok := app.completedRun(ctx, msg, completed)
+			if !ok {
+				continue
+			}
+			notifier.Send(webhook.Payload{
+				Event:     webhook.EventRunCompleted,
+				SessionID: sess.ID,
+				Session:   redactText(redactor, sess.Title),
+				Text:      redactText(redactor, msg.Content().Text),
+			})
+		case event, ok := <-permissions:
+			if !ok {
+				permissions = nil
+				continue
+			}
+			req := event.Payload
+			notifier.Send(webhook.Payload{
+				Event:     webhook.EventPermissionRequested,
+				SessionID: req.SessionID,
+				Session:   redactText(redactor, app.sessionTitle(ctx, req.SessionID)),
+				Permission: &webhook.Permission{
+					Tool:        req.ToolName,
+					Action:      req.Action,
+					Path:        req.Path,
+					Description: redactText(redactor, req.Description),
+				},
+			})
+		case event, ok := <-limits:
+			if !ok {
+				limits = nil
+				continue
+			}
+			notifier.Send(webhook.Payload{
+				Event:     webhook.EventBudgetExceeded,
+				SessionID: event.Payload.SessionID,
+				Session:   redactText(redactor, app.sessionTitle(ctx, event.Payload.SessionID)),
+				Text:      event.Payload.Reason,
+			})
+		}
+	}
+}
+
+// completedRun reports whether msg ends a turn of the agent in a top-level
+// session, and returns the session. Messages are updated after they finish
+// too, so completed keeps track of the messages already seen.
+func (app *App) completedRun(ctx context.Context, msg message.Message, completed map[string]bool) (session.Session, bool) {
+	if msg.Role != message.Assistant || msg.IsSummaryMessage || msg.FinishReason() != message.FinishReasonEndTurn || completed[msg.ID] {
+		return session.Session{}, false
+	}
+	completed[msg.ID] = true
+	sess, err := app.Sessions.Get(ctx, msg.SessionID)
+	// Sub-agents and titles run in child sessions.
+	if err != nil || sess.ParentSessionID != "" {
+		return session.Session{}, false
+	}
+	return sess, true
+}
+
+func (app *App) sessionTitle(ctx context.Context, sessionID string) string {
+	sess, err := app.Sessions.Get(ctx, sessionID)
+	if err != nil {
+		return ""
+	}
+	return sess.Title
+}
+
+// redactText masks the likely secrets in s before it leaves Crush.
+func redactText(redactor *redact.Redactor, s string) string {
+	s, _ = redactor.Redact(s)
+	return s
+}
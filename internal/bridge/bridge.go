@@ -0,0 +1,310 @@
+// Package bridge posts the permission requests and turn summaries of the
+// agent to a Slack or Discord channel, and answers the requests from the
+// replies to them, so that unattended runs can be supervised remotely.
+package bridge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/redact"
+)
+
+const (
+	defaultPollInterval = 5 * time.Second
+	// requestTimeout bounds each call to the API of the platform.
+	requestTimeout = 30 * time.Second
+	// maxTextLength keeps messages within the limits of the platforms.
+	maxTextLength = 1500
+)
+
+// Approver answers permission requests, like permission.Service.
+type Approver interface {
+	Grant(permission permission.PermissionRequest)
+	GrantPersistent(permission permission.PermissionRequest)
+	Deny(permission permission.PermissionRequest)
+}
+
+// reply is a message of a user in reply to a message of the bridge.
+type reply struct {
+	user string
+	text string
+}
+
+// chat is a channel of a chat platform.
+type chat interface {
+	// post posts text to the channel, in reply to the message with ID
+	// replyTo unless empty, and returns the ID of the new message.
+	post(ctx context.Context, text, replyTo string) (string, error)
+	// replies returns the replies of users to the message with the given
+	// ID, oldest first.
+	replies(ctx context.Context, id string) ([]reply, error)
+}
+
+type answer int
+
+const (
+	answerApprove answer = iota
+	answerAlways
+	answerDeny
+)
+
+// answers are the words a reply can start with to answer a request.
+var answers = map[string]answer{
+	"approve": answerApprove,
+	"allow":   answerApprove,
+	"yes":     answerApprove,
+	"y":       answerApprove,
+	"always":  answerAlways,
+	"deny":    answerDeny,
+	"no":      answerDeny,
+	"n":       answerDeny,
+}
+
+// Bridge mirrors the agent to a chat channel.
+type Bridge struct {
+	chat      chat
+	approver  Approver
+	allowed   []string
+	interval  time.Duration
+	summaries bool
+	redactor  *redact.Redactor
+
+	mu sync.Mutex
+	// pending stops asking for the requests, by tool call ID.
+	pending map[string]context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// New returns a bridge to the channel of cfg, with its token already
+// resolved, answering requests through approver. Likely secrets are masked
+// with redactor before anything is posted.
+func New(cfg config.ChatBridge, approver Approver, redactor *redact.Redactor) (*Bridge, error) {
+	if cfg.Token == "" || cfg.Channel == "" {
+		return nil, errors.New("bridge: token and channel are required")
+	}
+	// Approving runs commands on this machine, so replies of just anyone
+	// in the channel don't count.
+	if len(cfg.AllowedUsers) == 0 {
+		return nil, errors.New("bridge: allowed_users is required")
+	}
+	var c chat
+	switch cfg.Platform {
+	case "slack":
+		c = &slack{api: slackAPI, token: cfg.Token, channel: cfg.Channel}
+	case "discord":
+		c = &discord{api: discordAPI, token: cfg.Token, channel: cfg.Channel}
+	default:
+		return nil, fmt.Errorf("bridge: unknown platform %q", cfg.Platform)
+	}
+	return newBridge(c, cfg, approver, redactor), nil
+}
+
+func newBridge(c chat, cfg config.ChatBridge, approver Approver, redactor *redact.Redactor) *Bridge {
+	interval := defaultPollInterval
+	if cfg.PollInterval > 0 {
+		interval = time.Duration(cfg.PollInterval) * time.Second
+	}
+	return &Bridge{
+		chat:      c,
+		approver:  approver,
+		allowed:   cfg.AllowedUsers,
+		interval:  interval,
+		summaries: !cfg.DisableSummaries,
+		redactor:  redactor,
+		pending:   make(map[string]context.CancelFunc),
+	}
+}
+
+// Ask posts the permission request to the channel, and answers it from the
+// first reply of an allowed user, until ctx is done or the request is
+// answered elsewhere.
+func (b *Bridge) Ask(ctx context.Context, req permission.PermissionRequest, session string) {
+	askCtx, cancel := context.WithCancel(ctx)
+	b.mu.Lock()
+	b.pending[req.ToolCallID] = cancel
+	b.mu.Unlock()
+
+	shown := req
+	shown.Description = b.redact(req.Description)
+	text := requestText(shown, b.redact(session))
+	b.wg.Go(func() {
+		defer b.stop(req.ToolCallID)
+		id, err := b.chat.post(askCtx, text, "")
+		if err != nil {
+			if askCtx.Err() == nil {
+				slog.Error("Failed to post permission request to the bridge", "tool", req.ToolName, "error", err)
+			}
+			return
+		}
+		ticker := time.NewTicker(b.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-askCtx.Done():
+				// Let the channel know, unless Crush is shutting down.
+				if ctx.Err() == nil {
+					b.post(ctx, "Answered in Crush.", id)
+				}
+				return
+			case <-ticker.C:
+			}
+			replies, err := b.chat.replies(askCtx, id)
+			if err != nil {
+				if askCtx.Err() == nil {
+					slog.Warn("Failed to get replies from the bridge", "error", err)
+				}
+				continue
+			}
+			for _, r := range replies {
+				a, ok := parseAnswer(r.text)
+				if !ok || !slices.Contains(b.allowed, r.user) {
+					continue
+				}
+				// Forget the request first, so answering it doesn't count
+				// as being answered elsewhere.
+				b.stop(req.ToolCallID)
+				b.answer(ctx, req, a, id)
+				return
+			}
+		}
+	})
+}
+
+// Answered stops asking for the request of the tool call, once it was
+// answered, by the user in Crush or from the channel.
+func (b *Bridge) Answered(toolCallID string) {
+	b.mu.Lock()
+	cancel, ok := b.pending[toolCallID]
+	b.mu.Unlock()
+	if ok {
+		cancel()
+	}
+}
+
+func (b *Bridge) stop(toolCallID string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if cancel, ok := b.pending[toolCallID]; ok {
+		delete(b.pending, toolCallID)
+		cancel()
+	}
+}
+
+func (b *Bridge) answer(ctx context.Context, req permission.PermissionRequest, a answer, id string) {
+	switch a {
+	case answerApprove:
+		b.post(ctx, "Approved.", id)
+		b.approver.Grant(req)
+	case answerAlways:
+		b.post(ctx, "Approved for the session.", id)
+		b.approver.GrantPersistent(req)
+	case answerDeny:
+		b.post(ctx, "Denied.", id)
+		b.approver.Deny(req)
+	}
+}
+
+// Summary posts the last message of a turn of the agent, unless summaries
+// are disabled.
+func (b *Bridge) Summary(ctx context.Context, session, text string) {
+	if !b.summaries {
+		return
+	}
+	// Post the last turn before shutdown too.
+	ctx = context.WithoutCancel(ctx)
+	b.wg.Go(func() {
+		b.post(ctx, fmt.Sprintf("Crush finished its turn in %q:\n\n%s", b.redact(session), truncate(b.redact(text))), "")
+	})
+}
+
+// redact masks the likely secrets in text, before it's cut to fit.
+func (b *Bridge) redact(text string) string {
+	text, _ = b.redactor.Redact(text)
+	return text
+}
+
+// Wait waits for the messages being posted and the requests being asked.
+func (b *Bridge) Wait() {
+	b.wg.Wait()
+}
+
+func (b *Bridge) post(ctx context.Context, text, replyTo string) {
+	if _, err := b.chat.post(ctx, text, replyTo); err != nil {
+		slog.Error("Failed to post to the bridge", "error", err)
+	}
+}
+
+func requestText(req permission.PermissionRequest, session string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Crush asks for permission in %q: `%s` wants to %s in `%s`.\n", session, req.ToolName, req.Action, req.Path)
+	if req.Description != "" {
+		fmt.Fprintf(&sb, "\n%s\n", truncate(req.Description))
+	}
+	sb.WriteString("\nReply to this message with approve, always or deny.")
+	return sb.String()
+}
+
+func truncate(text string) string {
+	if len(text) <= maxTextLength {
+		return text
+	}
+	return strings.ToValidUTF8(text[:maxTextLength], "") + "…"
+}
+
+// parseAnswer returns the answer of a reply, from its first word that
+// isn't a mention.
+func parseAnswer(text string) (answer, bool) {
+	for _, word := range strings.Fields(strings.ToLower(text)) {
+		if strings.HasPrefix(word, "<@") {
+			continue
+		}
+		a, ok := answers[strings.Trim(word, ".,!")]
+		return a, ok
+	}
+	return 0, false
+}
+
+// doJSON sends body as JSON, when not nil, and decodes the JSON response
+// into out.
+func doJSON(ctx context.Context, method, url, authorization string, body, out any) error {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	var r io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, r)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", authorization)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s %s: %s", method, req.URL.Path, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
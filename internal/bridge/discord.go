@@ -0,0 +1,71 @@
+package bridge
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"slices"
+)
+
+const discordAPI = "https://discord.com/api/v10/"
+
+// discord is a Discord channel, where replies are messages replying to a
+// message. The bot needs the Message Content intent to read the replies.
+type discord struct {
+	api     string
+	token   string
+	channel string
+}
+
+type discordMessage struct {
+	ID      string `json:"id"`
+	Content string `json:"content"`
+	Author  struct {
+		ID  string `json:"id"`
+		Bot bool   `json:"bot"`
+	} `json:"author"`
+	MessageReference *struct {
+		MessageID string `json:"message_id"`
+	} `json:"message_reference"`
+}
+
+func (d *discord) post(ctx context.Context, text, replyTo string) (string, error) {
+	body := map[string]any{
+		"content": text,
+		// Don't ping anyone.
+		"allowed_mentions": map[string]any{"parse": []string{}},
+	}
+	if replyTo != "" {
+		body["message_reference"] = map[string]string{"message_id": replyTo}
+	}
+	var msg discordMessage
+	if err := doJSON(ctx, http.MethodPost, d.messagesURL(nil), "Bot "+d.token, body, &msg); err != nil {
+		return "", err
+	}
+	return msg.ID, nil
+}
+
+func (d *discord) replies(ctx context.Context, id string) ([]reply, error) {
+	var msgs []discordMessage
+	q := url.Values{"after": {id}, "limit": {"100"}}
+	if err := doJSON(ctx, http.MethodGet, d.messagesURL(q), "Bot "+d.token, nil, &msgs); err != nil {
+		return nil, err
+	}
+	var replies []reply
+	// Messages come newest first.
+	for _, m := range slices.Backward(msgs) {
+		if m.Author.Bot || m.MessageReference == nil || m.MessageReference.MessageID != id {
+			continue
+		}
+		replies = append(replies, reply{user: m.Author.ID, text: m.Content})
+	}
+	return replies, nil
+}
+
+func (d *discord) messagesURL(q url.Values) string {
+	u := d.api + "channels/" + url.PathEscape(d.channel) + "/messages"
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+	return u
+}
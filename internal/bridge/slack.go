@@ -0,0 +1,74 @@
+package bridge
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+const slackAPI = "https://slack.com/api/"
+
+// slack is a Slack channel, where replies are messages in the thread of a
+// message. The bot needs the chat:write scope, and channels:history or
+// groups:history to read the replies.
+type slack struct {
+	api     string
+	token   string
+	channel string
+}
+
+type slackResponse struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error"`
+}
+
+func (r slackResponse) err() error {
+	if !r.OK {
+		return fmt.Errorf("slack: %s", r.Error)
+	}
+	return nil
+}
+
+func (s *slack) post(ctx context.Context, text, replyTo string) (string, error) {
+	body := map[string]string{"channel": s.channel, "text": text}
+	if replyTo != "" {
+		body["thread_ts"] = replyTo
+	}
+	var resp struct {
+		slackResponse
+		TS string `json:"ts"`
+	}
+	if err := doJSON(ctx, http.MethodPost, s.api+"chat.postMessage", "Bearer "+s.token, body, &resp); err != nil {
+		return "", err
+	}
+	return resp.TS, resp.err()
+}
+
+func (s *slack) replies(ctx context.Context, id string) ([]reply, error) {
+	q := url.Values{"channel": {s.channel}, "ts": {id}}
+	var resp struct {
+		slackResponse
+		Messages []struct {
+			TS    string `json:"ts"`
+			User  string `json:"user"`
+			Text  string `json:"text"`
+			BotID string `json:"bot_id"`
+		} `json:"messages"`
+	}
+	if err := doJSON(ctx, http.MethodGet, s.api+"conversations.replies?"+q.Encode(), "Bearer "+s.token, nil, &resp); err != nil {
+		return nil, err
+	}
+	if err := resp.err(); err != nil {
+		return nil, err
+	}
+	var replies []reply
+	for _, m := range resp.Messages {
+		// The thread starts with the message itself.
+		if m.TS == id || m.BotID != "" {
+			continue
+		}
+		replies = append(replies, reply{user: m.User, text: m.Text})
+	}
+	return replies, nil
+}
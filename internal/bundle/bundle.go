@@ -0,0 +1,340 @@
+// Package bundle exports the settings of Crush into a portable bundle, to
+// import them on another machine or keep them in sync between machines.
+// Secrets never make it into a bundle: it references them by their name in
+// the keychain of the operating system instead.
+package bundle
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/keychain"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// Version is the version of the bundle format.
+const Version = 1
+
+// Bundle is the settings of Crush, without their secrets.
+type Bundle struct {
+	Version  int       `json:"version"`
+	Exported time.Time `json:"exported"`
+	// Files are the settings files, by their name in the bundle.
+	Files map[string]json.RawMessage `json:"files"`
+	// Secrets are the names in the keychain of the secrets the files
+	// reference.
+	Secrets []string `json:"secrets,omitempty"`
+}
+
+// Secret is a secret taken out of the settings.
+type Secret struct {
+	Name  string
+	Value string
+}
+
+// Locations returns where the settings files of a bundle are, by their name
+// in the bundle: the global config, the settings the app keeps, and those
+// of every profile.
+func Locations() map[string]string {
+	locations := map[string]string{
+		"crush.json": config.GlobalConfig(),
+		"data.json":  config.GlobalConfigData(),
+	}
+	for _, name := range config.Profiles() {
+		locations["profiles/"+name+"/crush.json"] = config.ProfileConfig(name)
+		locations["profiles/"+name+"/data.json"] = config.ProfileConfigData(name)
+	}
+	return locations
+}
+
+// location returns where the file of a bundle goes on this machine.
+func location(name string) (string, bool) {
+	switch name {
+	case "crush.json":
+		return config.GlobalConfig(), true
+	case "data.json":
+		return config.GlobalConfigData(), true
+	}
+	profile, file, ok := strings.Cut(strings.TrimPrefix(name, "profiles/"), "/")
+	if !ok || !strings.HasPrefix(name, "profiles/") || !config.ValidProfile(profile) {
+		return "", false
+	}
+	switch file {
+	case "crush.json":
+		return config.ProfileConfig(profile), true
+	case "data.json":
+		return config.ProfileConfigData(profile), true
+	}
+	return "", false
+}
+
+// Export bundles the settings files in locations, returning the secrets
+// taken out of them.
+func Export(locations map[string]string) (*Bundle, []Secret, error) {
+	b := &Bundle{
+		Version:  Version,
+		Exported: time.Now().UTC().Truncate(time.Second),
+		Files:    map[string]json.RawMessage{},
+	}
+	var secrets []Secret
+	names := make([]string, 0, len(locations))
+	for name := range locations {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		data, err := os.ReadFile(locations[name])
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read %s: %w", locations[name], err)
+		}
+		if len(bytes.TrimSpace(data)) == 0 {
+			continue
+		}
+		if !gjson.ValidBytes(data) {
+			return nil, nil, fmt.Errorf("%s is not valid JSON", locations[name])
+		}
+		prefix := ""
+		if profile, _, ok := strings.Cut(strings.TrimPrefix(name, "profiles/"), "/"); ok {
+			prefix = profile
+		}
+		data, found, err := stripSecrets(data, prefix, secrets)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to take the secrets out of %s: %w", locations[name], err)
+		}
+		for _, s := range found {
+			if !slices.Contains(b.Secrets, s.Name) {
+				b.Secrets = append(b.Secrets, s.Name)
+				secrets = append(secrets, s)
+			}
+		}
+		b.Files[name] = data
+	}
+	if len(b.Files) == 0 {
+		return nil, nil, fmt.Errorf("no settings to export")
+	}
+	return b, secrets, nil
+}
+
+// secretKeys are the keys of settings holding secrets.
+var secretKeys = []string{"api_key", "api_keys", "secret", "token", "password", "client_secret", "access_token", "refresh_token"}
+
+// secretName matches the names of headers and environment variables likely
+// to hold secrets.
+var secretName = regexp.MustCompile(`(?i)key|token|secret|password|auth|credential`)
+
+// nonAlnum matches what can't be part of the name of a secret.
+var nonAlnum = regexp.MustCompile(`[^a-z0-9]+`)
+
+// stripSecrets replaces the literal secrets in the settings with references
+// to the keychain, and removes OAuth tokens, which can't be moved to another
+// machine. Secrets with the same name as known ones get a number, unless
+// they're the same secret.
+func stripSecrets(data []byte, prefix string, known []Secret) ([]byte, []Secret, error) {
+	var found []Secret
+	var replace, remove [][]string
+	walk(gjson.ParseBytes(data), nil, func(path []string, v gjson.Result) bool {
+		key := strings.ToLower(path[len(path)-1])
+		if key == "oauth" {
+			remove = append(remove, slices.Clone(path))
+			return false
+		}
+		if v.Type != gjson.String || isReference(v.Str) || !isSecret(path) {
+			return true
+		}
+		replace = append(replace, slices.Clone(path))
+		return true
+	})
+
+	var err error
+	for _, path := range remove {
+		if data, err = sjson.DeleteBytes(data, sjsonPath(path)); err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, path := range replace {
+		value := gjson.GetBytes(data, sjsonPath(path)).Str
+		name := secretHandle(prefix, path, value, append(slices.Clone(known), found...))
+		if !slices.ContainsFunc(found, func(s Secret) bool { return s.Name == name }) {
+			found = append(found, Secret{Name: name, Value: value})
+		}
+		if data, err = sjson.SetBytes(data, sjsonPath(path), config.KeychainRef(name)); err != nil {
+			return nil, nil, err
+		}
+	}
+	return data, found, nil
+}
+
+// walk calls fn with the path of every value in v, descending into objects
+// and arrays unless fn returns false.
+func walk(v gjson.Result, path []string, fn func(path []string, v gjson.Result) bool) {
+	if len(path) > 0 && !fn(path, v) {
+		return
+	}
+	switch {
+	case v.IsObject():
+		v.ForEach(func(key, value gjson.Result) bool {
+			walk(value, append(path, key.Str), fn)
+			return true
+		})
+	case v.IsArray():
+		for i, value := range v.Array() {
+			walk(value, append(path, strconv.Itoa(i)), fn)
+		}
+	}
+}
+
+// isSecret reports whether the value at path is a secret: a setting known to
+// hold one, or a header or environment variable named like one.
+func isSecret(path []string) bool {
+	key := strings.ToLower(path[len(path)-1])
+	if slices.Contains(secretKeys, key) {
+		return true
+	}
+	if len(path) < 2 {
+		return false
+	}
+	parent := strings.ToLower(path[len(path)-2])
+	switch parent {
+	case "api_keys":
+		return true
+	case "headers", "extra_headers", "env":
+		return secretName.MatchString(key)
+	}
+	return false
+}
+
+// isReference reports whether the value references the secret rather than
+// holding it, like $OPENAI_API_KEY or $(pass show openai).
+func isReference(value string) bool {
+	return strings.Contains(value, "$")
+}
+
+// secretHandle returns the name of a secret in the keychain, made of the
+// profile and where the secret is in the settings.
+func secretHandle(prefix string, path []string, value string, known []Secret) string {
+	base := nonAlnum.ReplaceAllString(strings.ToLower(strings.Join(append([]string{prefix}, path...), "-")), "-")
+	base = "crush-" + strings.Trim(base, "-")
+	name := base
+	for i := 2; ; i++ {
+		idx := slices.IndexFunc(known, func(s Secret) bool { return s.Name == name })
+		if idx == -1 || known[idx].Value == value {
+			return name
+		}
+		name = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+func sjsonPath(path []string) string {
+	escaped := make([]string, len(path))
+	for i, p := range path {
+		escaped[i] = strings.NewReplacer(".", `\.`, "*", `\*`, "?", `\?`).Replace(p)
+	}
+	return strings.Join(escaped, ".")
+}
+
+// SaveSecrets keeps the secrets in the keychain, so the references of the
+// bundle resolve on this machine too.
+func SaveSecrets(secrets []Secret) error {
+	for _, s := range secrets {
+		if err := keychain.Set(s.Name, s.Value); err != nil {
+			return fmt.Errorf("failed to keep %s in the keychain: %w", s.Name, err)
+		}
+	}
+	return nil
+}
+
+// Read reads a bundle.
+func Read(data []byte) (*Bundle, error) {
+	var b Bundle
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("not a settings bundle: %w", err)
+	}
+	if b.Version != Version {
+		return nil, fmt.Errorf("unsupported settings bundle version %d, expected %d", b.Version, Version)
+	}
+	return &b, nil
+}
+
+// Import writes the settings files of the bundle where they go on this
+// machine, keeping a copy of the files it changes with a .bak extension. It
+// returns the paths of the files it changed.
+func (b *Bundle) Import() ([]string, error) {
+	names := make([]string, 0, len(b.Files))
+	for name := range b.Files {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var changed []string
+	for _, name := range names {
+		path, ok := location(name)
+		if !ok {
+			return changed, fmt.Errorf("unknown settings file %q in the bundle", name)
+		}
+		var data bytes.Buffer
+		if err := json.Indent(&data, b.Files[name], "", "  "); err != nil {
+			return changed, fmt.Errorf("invalid settings file %q in the bundle: %w", name, err)
+		}
+		data.WriteByte('\n')
+
+		old, err := os.ReadFile(path)
+		switch {
+		case err == nil && bytes.Equal(old, data.Bytes()):
+			continue
+		case err == nil:
+			if err := os.WriteFile(path+".bak", old, 0o600); err != nil {
+				return changed, fmt.Errorf("failed to back up %s: %w", path, err)
+			}
+		case !os.IsNotExist(err):
+			return changed, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return changed, fmt.Errorf("failed to create directory %q: %w", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, data.Bytes(), 0o600); err != nil {
+			return changed, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		changed = append(changed, path)
+	}
+	return changed, nil
+}
+
+// Same reports whether two bundles hold the same settings, whenever they
+// were exported.
+func (b *Bundle) Same(other *Bundle) bool {
+	if len(b.Files) != len(other.Files) || !slices.Equal(b.Secrets, other.Secrets) {
+		return false
+	}
+	for name, data := range b.Files {
+		var x, y bytes.Buffer
+		if json.Compact(&x, data) != nil || json.Compact(&y, other.Files[name]) != nil || !bytes.Equal(x.Bytes(), y.Bytes()) {
+			return false
+		}
+	}
+	return true
+}
+
+// MissingSecrets returns the secrets the bundle references that aren't in
+// the keychain of this machine.
+func (b *Bundle) MissingSecrets() []string {
+	var missing []string
+	for _, name := range b.Secrets {
+		if _, err := keychain.Get(name); err != nil {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
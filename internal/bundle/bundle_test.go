@@ -0,0 +1,129 @@
+package bundle
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExport(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	global := filepath.Join(dir, "crush.json")
+	data := filepath.Join(dir, "data.json")
+	work := filepath.Join(dir, "work.json")
+	require.NoError(t, os.WriteFile(global, []byte(`{
+  "providers": {
+    "openai": {"api_key": "sk-global", "api_keys": ["$OPENAI_KEY_2", "sk-second"]},
+    "vllm": {"base_url": "http://gpu:8000/v1", "extra_headers": {"X-Api-Key": "vllm-key", "X-Team": "infra"}}
+  },
+  "mcp": {"github": {"command": "github-mcp", "env": {"GITHUB_TOKEN": "ghp"}}},
+  "options": {"sync_path": "~/dotfiles/crush"}
+}`), 0o600))
+	require.NoError(t, os.WriteFile(data, []byte(`{
+  "models": {"large": {"model": "gpt-5", "provider": "openai"}},
+  "providers": {
+    "openai": {"api_key": "sk-global"},
+    "anthropic": {"api_key": "$(pass show anthropic)", "oauth": {"access_token": "at", "refresh_token": "rt"}}
+  }
+}`), 0o600))
+	require.NoError(t, os.WriteFile(work, []byte(`{"providers": {"openai": {"api_key": "sk-work"}}}`), 0o600))
+
+	b, secrets, err := Export(map[string]string{
+		"crush.json":               global,
+		"data.json":                data,
+		"profiles/work/crush.json": work,
+		"profiles/home/crush.json": filepath.Join(dir, "missing.json"),
+	})
+	require.NoError(t, err)
+	require.Equal(t, Version, b.Version)
+	require.Len(t, b.Files, 3)
+	require.Equal(t, []Secret{
+		{Name: "crush-providers-openai-api-key", Value: "sk-global"},
+		{Name: "crush-providers-openai-api-keys-1", Value: "sk-second"},
+		{Name: "crush-providers-vllm-extra-headers-x-api-key", Value: "vllm-key"},
+		{Name: "crush-mcp-github-env-github-token", Value: "ghp"},
+		{Name: "crush-work-providers-openai-api-key", Value: "sk-work"},
+	}, secrets)
+	require.Equal(t, []string{
+		"crush-providers-openai-api-key",
+		"crush-providers-openai-api-keys-1",
+		"crush-providers-vllm-extra-headers-x-api-key",
+		"crush-mcp-github-env-github-token",
+		"crush-work-providers-openai-api-key",
+	}, b.Secrets)
+
+	require.JSONEq(t, `{
+  "providers": {
+    "openai": {"api_key": "${keychain:crush-providers-openai-api-key}", "api_keys": ["$OPENAI_KEY_2", "${keychain:crush-providers-openai-api-keys-1}"]},
+    "vllm": {"base_url": "http://gpu:8000/v1", "extra_headers": {"X-Api-Key": "${keychain:crush-providers-vllm-extra-headers-x-api-key}", "X-Team": "infra"}}
+  },
+  "mcp": {"github": {"command": "github-mcp", "env": {"GITHUB_TOKEN": "${keychain:crush-mcp-github-env-github-token}"}}},
+  "options": {"sync_path": "~/dotfiles/crush"}
+}`, string(b.Files["crush.json"]))
+	require.JSONEq(t, `{
+  "models": {"large": {"model": "gpt-5", "provider": "openai"}},
+  "providers": {
+    "openai": {"api_key": "${keychain:crush-providers-openai-api-key}"},
+    "anthropic": {"api_key": "$(pass show anthropic)"}
+  }
+}`, string(b.Files["data.json"]))
+
+	_, _, err = Export(map[string]string{"crush.json": filepath.Join(dir, "missing.json")})
+	require.ErrorContains(t, err, "no settings to export")
+}
+
+func TestSecretHandle(t *testing.T) {
+	t.Parallel()
+
+	path := []string{"providers", "openai", "api_key"}
+	require.Equal(t, "crush-providers-openai-api-key", secretHandle("", path, "a", nil))
+	known := []Secret{{Name: "crush-providers-openai-api-key", Value: "a"}}
+	require.Equal(t, "crush-providers-openai-api-key", secretHandle("", path, "a", known))
+	require.Equal(t, "crush-providers-openai-api-key-2", secretHandle("", path, "b", known))
+}
+
+func TestImport(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("CRUSH_GLOBAL_CONFIG", dir)
+	t.Setenv("CRUSH_GLOBAL_DATA", filepath.Join(dir, "data"))
+
+	global := filepath.Join(dir, "crush.json")
+	require.NoError(t, os.WriteFile(global, []byte(`{"options": {}}`), 0o600))
+
+	b, err := Read([]byte(`{
+  "version": 1,
+  "files": {
+    "crush.json": {"options": {"debug": true}},
+    "profiles/work/crush.json": {"providers": {}}
+  }
+}`))
+	require.NoError(t, err)
+	changed, err := b.Import()
+	require.NoError(t, err)
+	require.Equal(t, []string{global, filepath.Join(dir, "profiles", "work.json")}, changed)
+
+	content, err := os.ReadFile(global)
+	require.NoError(t, err)
+	require.Equal(t, "{\n  \"options\": {\n    \"debug\": true\n  }\n}\n", string(content))
+	backup, err := os.ReadFile(global + ".bak")
+	require.NoError(t, err)
+	require.Equal(t, `{"options": {}}`, string(backup))
+
+	changed, err = b.Import()
+	require.NoError(t, err)
+	require.Empty(t, changed)
+
+	same, err := Read([]byte(`{"version": 1, "exported": "2026-01-01T00:00:00Z", "files": {"crush.json": {"options":{"debug":true}}, "profiles/work/crush.json": {"providers":{}}}}`))
+	require.NoError(t, err)
+	require.True(t, b.Same(same))
+
+	_, err = Read([]byte(`{"version": 2}`))
+	require.ErrorContains(t, err, "unsupported settings bundle version")
+	b.Files["../../etc/passwd"] = []byte(`{}`)
+	_, err = b.Import()
+	require.ErrorContains(t, err, "unknown settings file")
+}
@@ -0,0 +1,116 @@
+package bundle
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FileName is the name of the bundle in a sync directory.
+const FileName = "crush-settings.json"
+
+// gitTimeout bounds how long git has to pull or push the settings.
+const gitTimeout = 2 * time.Minute
+
+// Push writes the bundle into the sync directory, a folder synced by a cloud
+// drive or a git repository. In a git repository, it commits the bundle and
+// pushes it when the repository has a remote.
+func Push(ctx context.Context, dir string, b *Bundle) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create sync directory %q: %w", dir, err)
+	}
+	if isGit(dir) {
+		// Start from the latest settings, so pushing doesn't conflict.
+		if err := pull(ctx, dir); err != nil {
+			return err
+		}
+	}
+	path := filepath.Join(dir, FileName)
+	if old, err := os.ReadFile(path); err == nil {
+		if synced, err := Read(old); err == nil && synced.Same(b) {
+			return nil
+		}
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write the settings: %w", err)
+	}
+	if !isGit(dir) {
+		return nil
+	}
+
+	status, err := git(ctx, dir, "status", "--porcelain", "--", FileName)
+	if err != nil {
+		return err
+	}
+	if status == "" {
+		return nil
+	}
+	if _, err := git(ctx, dir, "add", "--", FileName); err != nil {
+		return err
+	}
+	if _, err := git(ctx, dir, "commit", "-m", "Update Crush settings", "--", FileName); err != nil {
+		return err
+	}
+	if hasRemote(ctx, dir) {
+		if _, err := git(ctx, dir, "push"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Pull reads the bundle from the sync directory, pulling the git repository
+// first when it is one.
+func Pull(ctx context.Context, dir string) (*Bundle, error) {
+	if isGit(dir) {
+		if err := pull(ctx, dir); err != nil {
+			return nil, err
+		}
+	}
+	data, err := os.ReadFile(filepath.Join(dir, FileName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the synced settings: %w", err)
+	}
+	return Read(data)
+}
+
+func pull(ctx context.Context, dir string) error {
+	if !hasRemote(ctx, dir) {
+		return nil
+	}
+	_, err := git(ctx, dir, "pull", "--ff-only")
+	return err
+}
+
+func isGit(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, ".git"))
+	return err == nil
+}
+
+func hasRemote(ctx context.Context, dir string) bool {
+	remotes, err := git(ctx, dir, "remote")
+	return err == nil && remotes != ""
+}
+
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, gitTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %s: %w", args[0], strings.TrimSpace(stderr.String()), err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
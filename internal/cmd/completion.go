@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+)
+
+var shells = []cobra.Completion{"bash", "zsh", "fish", "powershell"}
+
+func init() {
+	shellenvCmd.Flags().Bool("no-aliases", false, "Don't define the cr and crr aliases")
+	shellenvCmd.Flags().Bool("no-keybinding", false, "Don't bind Ctrl+X Ctrl+C to open Crush")
+}
+
+var completionCmd = &cobra.Command{
+	Use:   "completion <shell>",
+	Short: "Generate the autocompletion script for a shell",
+	Long: `Generate the autocompletion script for Crush for the specified shell.
+Supported shells are: bash, zsh, fish, powershell.
+To also get aliases and a keybinding, use "crush shellenv" instead.`,
+	Example: `
+# Load completions in the current bash session
+source <(crush completion bash)
+
+# Load completions in the current zsh session
+source <(crush completion zsh)
+
+# Load completions in the current fish session
+crush completion fish | source
+
+# Load completions in the current PowerShell session
+crush completion powershell | Out-String | Invoke-Expression
+  `,
+	ValidArgs:             shells,
+	Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	DisableFlagsInUseLine: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return writeCompletion(cmd.OutOrStdout(), cmd.Root(), args[0])
+	},
+}
+
+var shellenvCmd = &cobra.Command{
+	Use:   "shellenv <shell>",
+	Short: "Print shell setup for completions, aliases and a keybinding",
+	Long: `Print a script that sets up Crush in your shell. It loads the
+autocompletion script, defines the "cr" (crush) and "crr" (crush run) aliases
+and binds Ctrl+X Ctrl+C to open Crush in the current directory.
+Supported shells are: bash, zsh, fish, powershell.`,
+	Example: `
+# Add to ~/.bashrc
+eval "$(crush shellenv bash)"
+
+# Add to ~/.zshrc (after compinit)
+eval "$(crush shellenv zsh)"
+
+# Add to ~/.config/fish/config.fish
+crush shellenv fish | source
+
+# Add to your PowerShell $PROFILE
+crush shellenv powershell | Out-String | Invoke-Expression
+  `,
+	ValidArgs: shells,
+	Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		noAliases, _ := cmd.Flags().GetBool("no-aliases")
+		noKeybinding, _ := cmd.Flags().GetBool("no-keybinding")
+		return writeShellenv(cmd.OutOrStdout(), cmd.Root(), args[0], !noAliases, !noKeybinding)
+	},
+}
+
+// writeCompletion writes the completion script for the given shell, generated
+// from the whole command tree of root.
+func writeCompletion(w io.Writer, root *cobra.Command, shell string) error {
+	switch shell {
+	case "bash":
+		return root.GenBashCompletionV2(w, true)
+	case "zsh":
+		return root.GenZshCompletion(w)
+	case "fish":
+		return root.GenFishCompletion(w, true)
+	case "powershell":
+		return root.GenPowerShellCompletionWithDesc(w)
+	default:
+		return fmt.Errorf("unsupported shell: %s", shell)
+	}
+}
+
+// shellSetup holds the alias and keybinding snippets for a shell.
+type shellSetup struct {
+	aliases    string
+	keybinding string
+}
+
+var shellSetups = map[string]shellSetup{
+	"bash": {
+		aliases: `alias cr='crush'
+alias crr='crush run'
+complete -o default -F __start_crush cr
+`,
+		keybinding: `if [[ $- == *i* ]]; then
+  bind -x '"\C-x\C-c": crush --cwd "$PWD"'
+fi
+`,
+	},
+	"zsh": {
+		aliases: `alias cr='crush'
+alias crr='crush run'
+compdef cr=crush
+`,
+		keybinding: `_crush_widget() {
+  zle -I
+  crush --cwd "$PWD" </dev/tty
+  zle reset-prompt
+}
+zle -N _crush_widget
+bindkey '^X^C' _crush_widget
+`,
+	},
+	"fish": {
+		aliases: `alias cr crush
+alias crr 'crush run'
+complete -c cr -w crush
+`,
+		keybinding: `bind \cx\cc 'crush --cwd (pwd); commandline -f repaint'
+`,
+	},
+	"powershell": {
+		aliases: `Set-Alias -Name cr -Value crush
+function crr { crush run @args }
+`,
+		keybinding: `if (Get-Module -ListAvailable -Name PSReadLine) {
+  Set-PSReadLineKeyHandler -Chord 'Ctrl+x,Ctrl+c' -ScriptBlock {
+    [Microsoft.PowerShell.PSConsoleReadLine]::RevertLine()
+    [Microsoft.PowerShell.PSConsoleReadLine]::Insert('crush')
+    [Microsoft.PowerShell.PSConsoleReadLine]::AcceptLine()
+  }
+}
+`,
+	},
+}
+
+// writeShellenv writes the completion script for the given shell followed by
+// the aliases and keybinding, when enabled.
+func writeShellenv(w io.Writer, root *cobra.Command, shell string, aliases, keybinding bool) error {
+	setup, ok := shellSetups[shell]
+	if !ok {
+		return fmt.Errorf("unsupported shell: %s", shell)
+	}
+	if err := writeCompletion(w, root, shell); err != nil {
+		return err
+	}
+	if aliases {
+		if _, err := fmt.Fprintf(w, "\n# Crush aliases\n%s", setup.aliases); err != nil {
+			return err
+		}
+	}
+	if keybinding {
+		if _, err := fmt.Fprintf(w, "\n# Open Crush in the current directory with Ctrl+X Ctrl+C\n%s", setup.keybinding); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteShellenv(t *testing.T) {
+	t.Parallel()
+
+	root := &cobra.Command{Use: "crush"}
+
+	for _, shell := range shells {
+		t.Run(shell, func(t *testing.T) {
+			t.Parallel()
+
+			var b bytes.Buffer
+			require.NoError(t, writeShellenv(&b, root, shell, true, true))
+			require.Contains(t, b.String(), "crush")
+			require.Contains(t, b.String(), shellSetups[shell].aliases)
+			require.Contains(t, b.String(), shellSetups[shell].keybinding)
+
+			b.Reset()
+			require.NoError(t, writeShellenv(&b, root, shell, false, false))
+			require.NotContains(t, b.String(), "# Crush aliases")
+			require.NotContains(t, b.String(), "Ctrl+X Ctrl+C")
+		})
+	}
+
+	require.Error(t, writeShellenv(&bytes.Buffer{}, root, "tcsh", true, true))
+}
@@ -0,0 +1,236 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/bundle"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/home"
+	"github.com/charmbracelet/crush/internal/keychain"
+	"github.com/charmbracelet/x/term"
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Move your settings between machines",
+	Long: `Export your settings into a portable bundle, import them on another machine, or keep them in sync through a folder or a git repository.
+Bundles hold the global configuration, the settings Crush keeps, like your preferred models, and those of every profile. Secrets are never part of a bundle: literal API keys, tokens and passwords are replaced with references to the keychain of the operating system, and OAuth logins are left out.`,
+}
+
+var configExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export your settings into a bundle",
+	Example: `
+# Export your settings into a file
+crush config export -o crush-settings.json
+
+# Also keep the secrets in the keychain, so the bundle works on this machine
+crush config export -o crush-settings.json --save-secrets
+  `,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output, _ := cmd.Flags().GetString("output")
+		saveSecrets, _ := cmd.Flags().GetBool("save-secrets")
+
+		b, secrets, err := exportSettings(saveSecrets)
+		if err != nil {
+			return err
+		}
+		data, err := json.MarshalIndent(b, "", "  ")
+		if err != nil {
+			return err
+		}
+		data = append(data, '\n')
+		if output == "" {
+			if _, err := cmd.OutOrStdout().Write(data); err != nil {
+				return err
+			}
+		} else {
+			if err := os.WriteFile(output, data, 0o600); err != nil {
+				return fmt.Errorf("failed to write %s: %w", output, err)
+			}
+			cmd.PrintErrf("Exported %d settings files to %s\n", len(b.Files), output)
+		}
+		printSecrets(cmd, secrets, saveSecrets)
+		return nil
+	},
+}
+
+var configImportCmd = &cobra.Command{
+	Use:   "import <bundle>",
+	Short: "Import your settings from a bundle",
+	Long:  "Import your settings from a bundle exported with crush config export. Files replaced by the import are kept with a .bak extension.",
+	Example: `
+# Import settings exported on another machine
+crush config import crush-settings.json
+  `,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		data, err := os.ReadFile(home.Long(args[0]))
+		if err != nil {
+			return err
+		}
+		b, err := bundle.Read(data)
+		if err != nil {
+			return err
+		}
+		return importSettings(cmd, b)
+	},
+}
+
+var configSyncCmd = &cobra.Command{
+	Use:   "sync <push|pull>",
+	Short: "Sync your settings through a folder or a git repository",
+	Long: `Push your settings to the sync directory, or pull them from it. The sync directory is set with sync_path in the options of your configuration, or with --path.
+It can be a folder synced by a cloud drive, or a git repository: pushing commits the settings and pushes them when the repository has a remote, and pulling pulls it first.`,
+	Example: `
+# Push your settings to the sync directory
+crush config sync push
+
+# Pull the settings pushed from another machine
+crush config sync pull --path ~/dotfiles/crush
+  `,
+	ValidArgs: []string{"push", "pull"},
+	Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, _ := cmd.Flags().GetString("path")
+		saveSecrets, _ := cmd.Flags().GetBool("save-secrets")
+
+		if dir == "" {
+			cwd, err := ResolveCwd(cmd)
+			if err != nil {
+				return err
+			}
+			dataDir, _ := cmd.Flags().GetString("data-dir")
+			cfg, err := config.Load(cwd, dataDir, false)
+			if err != nil {
+				return fmt.Errorf("failed to load configuration: %v", err)
+			}
+			dir = cfg.Options.SyncPath
+		}
+		if dir == "" {
+			return fmt.Errorf("no sync directory: set sync_path in the options of your configuration, or pass --path")
+		}
+		dir = home.Long(dir)
+
+		if args[0] == "pull" {
+			b, err := bundle.Pull(cmd.Context(), dir)
+			if err != nil {
+				return err
+			}
+			return importSettings(cmd, b)
+		}
+
+		b, secrets, err := exportSettings(saveSecrets)
+		if err != nil {
+			return err
+		}
+		if err := bundle.Push(cmd.Context(), dir, b); err != nil {
+			return err
+		}
+		cmd.Printf("Pushed %d settings files to %s\n", len(b.Files), home.Short(dir))
+		printSecrets(cmd, secrets, saveSecrets)
+		return nil
+	},
+}
+
+var configSecretCmd = &cobra.Command{
+	Use:   "secret <name>",
+	Short: "Keep a secret referenced by your settings in the keychain",
+	Long:  "Keep a secret referenced by your settings, like ${keychain:crush-providers-openai-api-key}, in the keychain of the operating system. The secret is read from the terminal without echoing it, or from standard input.",
+	Example: `
+# Set the OpenAI API key referenced by settings imported from another machine
+crush config secret crush-providers-openai-api-key
+
+# Set it from a password manager
+pass show openai | crush config secret crush-providers-openai-api-key
+  `,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var secret string
+		if term.IsTerminal(os.Stdin.Fd()) {
+			cmd.PrintErrf("Secret for %s: ", args[0])
+			data, err := term.ReadPassword(os.Stdin.Fd())
+			cmd.PrintErrln()
+			if err != nil {
+				return fmt.Errorf("failed to read the secret: %w", err)
+			}
+			secret = string(data)
+		} else {
+			line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+			if err != nil && err != io.EOF {
+				return fmt.Errorf("failed to read the secret: %w", err)
+			}
+			secret = strings.TrimRight(line, "\r\n")
+		}
+		if secret == "" {
+			return fmt.Errorf("no secret given")
+		}
+		if err := keychain.Set(args[0], secret); err != nil {
+			return err
+		}
+		cmd.Printf("Saved %s in the keychain\n", args[0])
+		return nil
+	},
+}
+
+func exportSettings(saveSecrets bool) (*bundle.Bundle, []bundle.Secret, error) {
+	b, secrets, err := bundle.Export(bundle.Locations())
+	if err != nil {
+		return nil, nil, err
+	}
+	if saveSecrets {
+		if err := bundle.SaveSecrets(secrets); err != nil {
+			return nil, nil, err
+		}
+	}
+	return b, secrets, nil
+}
+
+func importSettings(cmd *cobra.Command, b *bundle.Bundle) error {
+	changed, err := b.Import()
+	for _, path := range changed {
+		cmd.Printf("Updated %s\n", home.Short(path))
+	}
+	if err != nil {
+		return err
+	}
+	if len(changed) == 0 {
+		cmd.Println("Your settings are up to date.")
+	}
+	if missing := b.MissingSecrets(); len(missing) > 0 {
+		cmd.Println("\nThese secrets aren't in the keychain of this machine yet, set them with crush config secret <name>:")
+		for _, name := range missing {
+			cmd.Printf("  - %s\n", name)
+		}
+	}
+	return nil
+}
+
+func printSecrets(cmd *cobra.Command, secrets []bundle.Secret, saved bool) {
+	if len(secrets) == 0 {
+		return
+	}
+	if saved {
+		cmd.PrintErrf("Saved %d secrets in the keychain.\n", len(secrets))
+		return
+	}
+	cmd.PrintErrln("\nThese secrets were left out and are referenced from the keychain, set them with crush config secret <name>, or export with --save-secrets:")
+	for _, s := range secrets {
+		cmd.PrintErrf("  - %s\n", s.Name)
+	}
+}
+
+func init() {
+	configExportCmd.Flags().StringP("output", "o", "", "File to write the bundle to instead of standard output")
+	configExportCmd.Flags().Bool("save-secrets", false, "Keep the secrets left out of the bundle in the keychain of this machine")
+	configSyncCmd.Flags().String("path", "", "Sync directory, instead of sync_path in the options of your configuration")
+	configSyncCmd.Flags().Bool("save-secrets", false, "Keep the secrets left out of the pushed settings in the keychain of this machine")
+	configCmd.AddCommand(configExportCmd, configImportCmd, configSyncCmd, configSecretCmd)
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/home"
+	"github.com/charmbracelet/crush/internal/importer"
+	"github.com/spf13/cobra"
+)
+
+var importCmd = &cobra.Command{
+	Use:       "import <aider|continue|claude-desktop|opencode>",
+	Short:     "Import the configuration of another tool",
+	Long:      "Import the providers, MCP servers and instruction files configured for another coding tool into the Crush configuration of the project, or the global one. Entries already in the configuration are never overwritten.",
+	ValidArgs: []string{"aider", "continue", "claude-desktop", "opencode"},
+	Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	Example: `
+# Import the aider configuration of the project into its crush.json
+crush import aider
+
+# Import the MCP servers of Claude Desktop into the global configuration
+crush import claude-desktop --global
+
+# Show what importing a specific opencode configuration would change
+crush import opencode --file ~/work/opencode.json --dry-run
+  `,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		global, _ := cmd.Flags().GetBool("global")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		files, _ := cmd.Flags().GetStringSlice("file")
+
+		cwd, err := ResolveCwd(cmd)
+		if err != nil {
+			return err
+		}
+		for i, f := range files {
+			files[i] = home.Long(f)
+		}
+
+		result, err := importer.Import(importer.Tool(args[0]), cwd, files...)
+		if err != nil {
+			return err
+		}
+		for _, source := range result.Sources {
+			cmd.Printf("Read %s\n", home.Short(source))
+		}
+		if result.Empty() {
+			cmd.Println("Nothing to import.")
+			printNotes(cmd, result.Notes)
+			return nil
+		}
+
+		target := filepath.Join(cwd, "crush.json")
+		if global {
+			target = config.GlobalConfig()
+		}
+		data, err := os.ReadFile(target)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to read %s: %w", target, err)
+		}
+		data, changes, err := result.Apply(data)
+		if err != nil {
+			return fmt.Errorf("failed to update %s: %w", target, err)
+		}
+
+		for _, added := range changes.Added {
+			cmd.Printf("  + %s\n", added)
+		}
+		for _, kept := range changes.Kept {
+			cmd.Printf("  = %s (already configured, kept as is)\n", kept)
+		}
+		printNotes(cmd, result.Notes)
+
+		if dryRun {
+			cmd.Printf("\nWould write %s:\n%s", home.Short(target), data)
+			return nil
+		}
+		if len(changes.Added) == 0 {
+			cmd.Println("Nothing new to import.")
+			return nil
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return fmt.Errorf("failed to create config directory %q: %w", filepath.Dir(target), err)
+		}
+		if err := os.WriteFile(target, data, 0o600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", target, err)
+		}
+		cmd.Printf("Updated %s\n", home.Short(target))
+		return nil
+	},
+}
+
+func printNotes(cmd *cobra.Command, notes []string) {
+	if len(notes) == 0 {
+		return
+	}
+	cmd.Println("\nNotes:")
+	for _, note := range notes {
+		cmd.Printf("  - %s\n", note)
+	}
+}
+
+func init() {
+	importCmd.Flags().Bool("global", false, "Import into the global configuration instead of the one of the project")
+	importCmd.Flags().Bool("dry-run", false, "Show the resulting configuration without writing it")
+	importCmd.Flags().StringSlice("file", nil, "Configuration file of the tool to import, instead of where the tool keeps it")
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/charmbracelet/crush/internal/multiplexer"
+	"github.com/spf13/cobra"
+)
+
+var paneCmd = &cobra.Command{
+	Use:   "pane [popup|right|down] [-- flags]",
+	Short: "Open Crush in a tmux or zellij pane",
+	Long: `Open Crush in a popup or a split of the current tmux or zellij pane, in the
+working directory of that pane. Flags after -- are passed to Crush. While it
+runs there, Crush titles its pane with the session and its status.`,
+	Example: `
+# Open Crush in a popup
+crush pane
+
+# Open Crush in a split on the right, continuing the last session
+crush pane right -- --continue
+
+# Bind prefix + C to a Crush popup in ~/.tmux.conf
+bind-key C run-shell "crush pane popup"
+
+# Bind prefix + | to a Crush split in ~/.tmux.conf
+bind-key | run-shell "crush pane right"
+  `,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if n := cmd.ArgsLenAtDash(); n > 1 || n < 0 && len(args) > 1 {
+			return errors.New("pass flags for Crush after --")
+		}
+		return nil
+	},
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		var layouts []string
+		for _, layout := range multiplexer.Layouts {
+			layouts = append(layouts, string(layout))
+		}
+		return layouts, cobra.ShellCompDirectiveNoFileComp
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		layout := multiplexer.Popup
+		if n := cmd.ArgsLenAtDash(); n != 0 && len(args) > 0 {
+			layout = multiplexer.Layout(args[0])
+			if !slices.Contains(multiplexer.Layouts, layout) {
+				return fmt.Errorf("unknown layout %q: use popup, right or down", args[0])
+			}
+			args = args[1:]
+		}
+		exe, err := os.Executable()
+		if err != nil {
+			return fmt.Errorf("failed to find the Crush executable: %w", err)
+		}
+		// Without --cwd, Crush opens where the current pane is.
+		dir, _ := cmd.Flags().GetString("cwd")
+		if dir != "" {
+			if dir, err = filepath.Abs(dir); err != nil {
+				return err
+			}
+		}
+		// The pane doesn't inherit the environment of this command.
+		for _, flag := range []string{"data-dir", "profile"} {
+			if value, _ := cmd.Flags().GetString(flag); value != "" {
+				args = append([]string{"--" + flag, value}, args...)
+			}
+		}
+		return multiplexer.Open(layout, dir, append([]string{exe}, args...))
+	},
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// switchProfile is the config profile to restart Crush with once the TUI
+// exits, if one was picked in it.
+var switchProfile *string
+
+// setProfile makes the config profile of the --profile flag the one config
+// is loaded with.
+func setProfile(cmd *cobra.Command, _ []string) error {
+	if profile, _ := cmd.Flags().GetString("profile"); profile != "" {
+		return os.Setenv(config.ProfileEnv, profile)
+	}
+	return nil
+}
+
+// restartWithProfile runs Crush again with another config profile, and
+// returns its exit code if it can't take the place of this process.
+// Sessions belong to profiles, so it doesn't resume the session of this run.
+func restartWithProfile(profile string) int {
+	exe, err := os.Executable()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to restart Crush:", err)
+		return 1
+	}
+	return restart(exe, withoutSessionFlags(os.Args[1:]), profileEnv(os.Environ(), profile))
+}
+
+// profileEnv returns env with the config profile set to profile.
+func profileEnv(env []string, profile string) []string {
+	env = slices.DeleteFunc(slices.Clone(env), func(kv string) bool {
+		return strings.HasPrefix(kv, config.ProfileEnv+"=")
+	})
+	return append(env, config.ProfileEnv+"="+profile)
+}
+
+// withoutSessionFlags returns the arguments without the flags picking the
+// profile and the session to open.
+func withoutSessionFlags(args []string) []string {
+	var out []string
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; {
+		case arg == "--profile" || arg == "--resume":
+			i++ // Skip the value too.
+		case strings.HasPrefix(arg, "--profile=") || strings.HasPrefix(arg, "--resume="):
+		case arg == "--continue" || arg == "-c":
+		default:
+			out = append(out, arg)
+		}
+	}
+	return out
+}
@@ -0,0 +1,17 @@
+//go:build !windows
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// restart replaces this process with exe, so switching profiles doesn't
+// nest a process per switch.
+func restart(exe string, args, env []string) int {
+	err := syscall.Exec(exe, append([]string{exe}, args...), env)
+	fmt.Fprintln(os.Stderr, "Failed to restart Crush:", err)
+	return 1
+}
@@ -0,0 +1,22 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithoutSessionFlags(t *testing.T) {
+	t.Parallel()
+
+	args := []string{"-d", "--profile", "work", "--resume=3f2a", "-c", "--cwd", "/src", "--profile=home"}
+	require.Equal(t, []string{"-d", "--cwd", "/src"}, withoutSessionFlags(args))
+}
+
+func TestProfileEnv(t *testing.T) {
+	t.Parallel()
+
+	env := []string{"HOME=/home/me", "CRUSH_PROFILE=home", "TERM=xterm"}
+	require.Equal(t, []string{"HOME=/home/me", "TERM=xterm", "CRUSH_PROFILE=work"}, profileEnv(env, "work"))
+	require.Equal(t, "CRUSH_PROFILE=home", env[1])
+}
@@ -0,0 +1,27 @@
+//go:build windows
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// restart runs exe and waits for it to exit, since a process can't take
+// the place of another on Windows.
+func restart(exe string, args, env []string) int {
+	cmd := exec.Command(exe, args...)
+	cmd.Env = env
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode()
+		}
+		fmt.Fprintln(os.Stderr, "Failed to restart Crush:", err)
+		return 1
+	}
+	return 0
+}
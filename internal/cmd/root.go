@@ -1,25 +1,34 @@
 package cmd
 
 import (
+	"bufio"
 	"bytes"
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
-	"io"
 	"log/slog"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
 	"github.com/charmbracelet/colorprofile"
 	"github.com/charmbracelet/crush/internal/app"
 	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/crash"
 	"github.com/charmbracelet/crush/internal/db"
 	"github.com/charmbracelet/crush/internal/event"
+	"github.com/charmbracelet/crush/internal/latency"
+	"github.com/charmbracelet/crush/internal/library"
 	"github.com/charmbracelet/crush/internal/projects"
+	"github.com/charmbracelet/crush/internal/redact"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/crush/internal/shell"
+	"github.com/charmbracelet/crush/internal/stats"
 	"github.com/charmbracelet/crush/internal/stringext"
 	"github.com/charmbracelet/crush/internal/tui"
 	"github.com/charmbracelet/crush/internal/version"
@@ -32,11 +41,17 @@ import (
 )
 
 func init() {
-	rootCmd.PersistentFlags().StringP("cwd", "c", "", "Current working directory")
+	rootCmd.PersistentFlags().String("cwd", "", "Current working directory")
 	rootCmd.PersistentFlags().StringP("data-dir", "D", "", "Custom crush data directory")
 	rootCmd.PersistentFlags().BoolP("debug", "d", false, "Debug")
+	rootCmd.PersistentFlags().String("remote", "", "Work on a remote workspace over SSH, as [user@]host:/path")
+	rootCmd.PersistentFlags().String("profile", "", "Config profile to run with, like work or personal")
+	rootCmd.MarkFlagsMutuallyExclusive("cwd", "remote")
 	rootCmd.Flags().BoolP("help", "h", false, "Help")
 	rootCmd.Flags().BoolP("yolo", "y", false, "Automatically accept all permissions (dangerous mode)")
+	rootCmd.Flags().BoolP("continue", "c", false, "Continue the most recent session")
+	rootCmd.Flags().String("resume", "", "Resume the session with the given ID")
+	rootCmd.MarkFlagsMutuallyExclusive("continue", "resume")
 
 	rootCmd.AddCommand(
 		runCmd,
@@ -46,13 +61,24 @@ func init() {
 		logsCmd,
 		schemaCmd,
 		loginCmd,
+		completionCmd,
+		shellenvCmd,
+		shareCmd,
+		statsCmd,
+		scheduleCmd,
+		importCmd,
+		configCmd,
+		selfUpdateCmd,
+		paneCmd,
 	)
 }
 
 var rootCmd = &cobra.Command{
-	Use:   "crush",
-	Short: "An AI assistant for software development",
-	Long:  "An AI assistant for software development and similar tasks with direct access to the terminal",
+	Use:               "crush",
+	Short:             "An AI assistant for software development",
+	Long:              "An AI assistant for software development and similar tasks with direct access to the terminal",
+	PersistentPreRunE: setProfile,
+	Args:              rootArgs,
 	Example: `
 # Run in interactive mode
 crush
@@ -61,11 +87,23 @@ crush
 crush -d
 
 # Run with debug logging in a specific directory
-crush -d -c /path/to/project
+crush -d --cwd /path/to/project
+
+# Continue the most recent session
+crush -c
+
+# Resume a specific session
+crush --resume 3f2a9c1e-8b4d-4e6f-a1b2-c3d4e5f6a7b8
 
 # Run with custom data directory
 crush -D /path/to/custom/.crush
 
+# Work on a project on a remote machine over SSH
+crush --remote dev@devbox:/home/dev/project
+
+# Run with the settings of the work profile
+crush --profile work
+
 # Print version
 crush -v
 
@@ -82,29 +120,73 @@ crush -y
 		}
 		defer app.Shutdown()
 
+		resume, err := resumeSession(cmd, app)
+		if err != nil {
+			return err
+		}
+
+		stdin, err := stdinAttachment()
+		if err != nil {
+			return err
+		}
+
 		event.AppInitialized()
 
 		// Set up the TUI.
 		var env uv.Environ = os.Environ()
 		ui := tui.New(app)
 		ui.QueryVersion = shouldQueryTerminalVersion(env)
+		ui.ResumeSession = resume
+		if stdin != nil {
+			ui.Attachments = append(ui.Attachments, *stdin)
+		}
+		ui.Crash, err = crash.TakePending(app.Config().Options.DataDirectory)
+		if err != nil {
+			slog.Warn("Failed to read the previous crash report", "error", err)
+		}
 
+		guard := tui.NewPanicGuard(ui)
 		program := tea.NewProgram(
-			ui,
+			guard,
 			tea.WithEnvironment(env),
 			tea.WithContext(cmd.Context()),
 			tea.WithFilter(tui.MouseEventFilter)) // Filter mouse events based on focus state
 		go app.Subscribe(program)
 
-		if _, err := program.Run(); err != nil {
+		// Crush titles the terminal window, so save the title it had to
+		// restore it on exit.
+		restoreTitle := !app.Config().Options.TUI.DisableWindowTitle && term.IsTerminal(os.Stdout.Fd())
+		if restoreTitle {
+			_, _ = fmt.Fprint(os.Stdout, ansi.WindowOp(22, 0))
+		}
+		_, err = program.Run()
+		if restoreTitle {
+			_, _ = fmt.Fprint(os.Stdout, ansi.WindowOp(23, 0))
+		}
+		ui.SaveSessionState()
+		if errors.Is(err, tea.ErrProgramPanic) {
+			return reportCrash(app, guard, ui.SelectedSessionID())
+		}
+		if err != nil {
 			event.Error(err)
 			slog.Error("TUI run error", "error", err)
 			return errors.New("Crush crashed. If metrics are enabled, we were notified about it. If you'd like to report it, please copy the stacktrace above and open an issue at https://github.com/charmbracelet/crush/issues/new?template=bug.yml") //nolint:staticcheck
 		}
+		// Restart once this run has shut down.
+		if ui.SwitchProfile != nil {
+			switchProfile = ui.SwitchProfile
+			return nil
+		}
+		if id := ui.SelectedSessionID(); id != "" {
+			fmt.Fprintf(os.Stderr, "Resume this session with: crush --resume %s\n", id)
+		}
 		return nil
 	},
 	PostRun: func(cmd *cobra.Command, args []string) {
 		event.AppExited()
+		if switchProfile != nil {
+			os.Exit(restartWithProfile(*switchProfile))
+		}
 	},
 }
 
@@ -174,10 +256,11 @@ func setupAppWithProgressBar(cmd *cobra.Command) (*app.App, error) {
 
 // setupApp handles the common setup logic for both interactive and non-interactive modes.
 // It returns the app instance, config, cleanup function, and any error.
-func setupApp(cmd *cobra.Command) (*app.App, error) {
+func setupApp(cmd *cobra.Command) (_ *app.App, err error) {
 	debug, _ := cmd.Flags().GetBool("debug")
 	yolo, _ := cmd.Flags().GetBool("yolo")
 	dataDir, _ := cmd.Flags().GetString("data-dir")
+	remoteSpec, _ := cmd.Flags().GetString("remote")
 	ctx := cmd.Context()
 
 	cwd, err := ResolveCwd(cmd)
@@ -185,6 +268,28 @@ func setupApp(cmd *cobra.Command) (*app.App, error) {
 		return nil, err
 	}
 
+	var remote *shell.Remote
+	if remoteSpec != "" {
+		remote, err = mountRemote(ctx, remoteSpec)
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			if err != nil {
+				_ = remote.Unmount()
+			}
+		}()
+		cwd = remote.Mount()
+		// SQLite doesn't work reliably over sshfs, so keep the data of the
+		// workspace on the local machine.
+		if dataDir == "" {
+			dataDir = filepath.Join(filepath.Dir(config.GlobalConfigData()), "remote", shell.RemoteID(remoteSpec))
+		}
+		// Commands run on the remote machine. This has to happen before the
+		// tools are built.
+		shell.GetBackgroundShellManager().SetBackend(remote)
+	}
+
 	cfg, err := config.Init(cwd, dataDir, debug)
 	if err != nil {
 		return nil, err
@@ -199,6 +304,10 @@ func setupApp(cmd *cobra.Command) (*app.App, error) {
 		return nil, err
 	}
 
+	if cfg.Options.Library != nil {
+		syncLibrary(ctx, cfg)
+	}
+
 	// Register this project in the centralized projects list.
 	if err := projects.Register(cwd, cfg.Options.DataDirectory); err != nil {
 		slog.Warn("Failed to register project", "error", err)
@@ -216,44 +325,166 @@ func setupApp(cmd *cobra.Command) (*app.App, error) {
 		slog.Error("Failed to create app instance", "error", err)
 		return nil, err
 	}
+	if remote != nil {
+		appInstance.AddCleanup(remote.Unmount)
+	}
 
 	if shouldEnableMetrics() {
 		event.Init()
 	}
+	if cfg.Options.UsageStats {
+		stats.Init()
+	}
+	latency.Init()
 
 	return appInstance, nil
 }
 
-func shouldEnableMetrics() bool {
-	if v, _ := strconv.ParseBool(os.Getenv("CRUSH_DISABLE_METRICS")); v {
-		return false
+// mountRemote mounts the remote workspace and makes it the working
+// directory.
+func mountRemote(ctx context.Context, spec string) (*shell.Remote, error) {
+	remote, err := shell.MountRemote(ctx, spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mount remote workspace: %w", err)
 	}
-	if v, _ := strconv.ParseBool(os.Getenv("DO_NOT_TRACK")); v {
-		return false
+	if err := os.Chdir(remote.Mount()); err != nil {
+		_ = remote.Unmount()
+		return nil, fmt.Errorf("failed to change directory: %v", err)
 	}
-	if config.Get().Options.DisableMetrics {
+	return remote, nil
+}
+
+// syncLibrary applies the last copy of the shared library to the config and
+// refreshes it in the background, so a slow or unreachable source doesn't
+// hold up the start. Prompts and permissions of the refreshed copy apply from
+// the next start.
+func syncLibrary(ctx context.Context, cfg *config.Config) {
+	dir := library.Dir(cfg.Options.DataDirectory)
+	if err := library.Apply(cfg, dir, confirmLibraryTools); err != nil {
+		slog.Warn("Failed to apply the shared library", "error", err)
+	}
+	lib := *cfg.Options.Library
+	go func() {
+		syncCtx, cancel := context.WithTimeout(ctx, library.SyncTimeout)
+		defer cancel()
+		if err := library.Sync(syncCtx, lib, dir); err != nil {
+			slog.Warn("Failed to refresh the shared library, using the last copy", "error", err)
+		}
+	}()
+}
+
+// confirmLibraryTools asks on the terminal whether the shared library may
+// let the tools run without asking. Without a terminal to ask on, it
+// declines.
+func confirmLibraryTools(tools []string) bool {
+	if !term.IsTerminal(os.Stdin.Fd()) || !term.IsTerminal(os.Stderr.Fd()) {
+		slog.Warn("Not allowing the tools of the shared library without confirmation", "tools", tools)
 		return false
 	}
-	return true
+	fmt.Fprintf(os.Stderr, "The shared library allows these tools to run without asking: %s\nAllow them? [y/N] ", strings.Join(tools, ", "))
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
 }
 
-func MaybePrependStdin(prompt string) (string, error) {
-	if term.IsTerminal(os.Stdin.Fd()) {
-		return prompt, nil
+// reportCrash writes a crash bundle for a panic caught by the TUI and tells
+// the user where to find it.
+func reportCrash(appInstance *app.App, guard *tui.PanicGuard, sessionID string) error {
+	value := guard.Panic
+	if value == nil {
+		value = "unknown panic"
 	}
-	fi, err := os.Stdin.Stat()
+	event.Error(value, "panic", true, "name", "tui")
+	slog.Error("TUI panic", "panic", value, "stack", string(guard.Stack))
+
+	cfg := appInstance.Config()
+	redactor, err := cfg.Options.SecretScanning.Redactor()
 	if err != nil {
-		return prompt, err
+		// The configuration was already checked on start, but the bundle
+		// is still better off with the built-in rules than unredacted.
+		redactor, _ = redact.New(nil, nil)
 	}
-	// Check if stdin is a named pipe ( | ) or regular file ( < ).
-	if fi.Mode()&os.ModeNamedPipe == 0 && !fi.Mode().IsRegular() {
-		return prompt, nil
+	dir, err := crash.Write(cfg.Options.DataDirectory, crash.Report{
+		Time:      time.Now(),
+		Version:   version.Version,
+		Panic:     fmt.Sprint(value),
+		Stack:     string(guard.Stack),
+		SessionID: sessionID,
+	}, cfg, redactor)
+	if dir == "" {
+		slog.Error("Failed to write crash report", "error", err)
+		return errors.New("Crush crashed and the crash report could not be written. If you'd like to report it, please copy the stacktrace above and open an issue at https://github.com/charmbracelet/crush/issues/new?template=bug.yml") //nolint:staticcheck
 	}
-	bts, err := io.ReadAll(os.Stdin)
 	if err != nil {
-		return prompt, err
+		slog.Warn("Crash report is incomplete", "error", err)
+	}
+	if sessionID != "" {
+		fmt.Fprintln(os.Stderr, "Your session was saved. Start Crush again to reopen it.")
+	}
+	return fmt.Errorf("Crush crashed. A crash report was saved to %s. If you'd like to report it, please attach it to an issue at https://github.com/charmbracelet/crush/issues/new?template=bug.yml", dir) //nolint:staticcheck
+}
+
+// rootArgs rejects arguments, which would be unknown commands. -c used to
+// be the short form of --cwd, so a directory following it gets pointed at
+// --cwd instead of continuing a session somewhere else.
+func rootArgs(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return nil
+	}
+	if cont, _ := cmd.Flags().GetBool("continue"); cont {
+		if info, err := os.Stat(args[0]); err == nil && info.IsDir() {
+			return fmt.Errorf("-c is short for --continue, use --cwd %s to work in that directory", args[0])
+		}
 	}
-	return string(bts) + "\n\n" + prompt, nil
+	msg := fmt.Sprintf("unknown command %q for %q", args[0], cmd.CommandPath())
+	if suggestions := cmd.SuggestionsFor(args[0]); len(suggestions) > 0 {
+		msg += "\n\nDid you mean this?\n\t" + strings.Join(suggestions, "\n\t")
+	}
+	return errors.New(msg)
+}
+
+// resumeSession returns the session requested with --resume or --continue, or
+// nil when a new session should be started.
+func resumeSession(cmd *cobra.Command, appInstance *app.App) (*session.Session, error) {
+	ctx := cmd.Context()
+	if id, _ := cmd.Flags().GetString("resume"); id != "" {
+		sess, err := appInstance.Sessions.Get(ctx, id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("session %q not found in this project", id)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to load session %q: %w", id, err)
+		}
+		if sess.ParentSessionID != "" {
+			return nil, fmt.Errorf("session %q is a sub-agent session and can't be resumed", id)
+		}
+		return &sess, nil
+	}
+	if cont, _ := cmd.Flags().GetBool("continue"); cont {
+		sess, err := appInstance.Sessions.Latest(ctx)
+		if errors.Is(err, session.ErrNoSessions) {
+			slog.Info("No session to continue, starting a new one")
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the latest session: %w", err)
+		}
+		return &sess, nil
+	}
+	return nil, nil
+}
+
+func shouldEnableMetrics() bool {
+	if v, _ := strconv.ParseBool(os.Getenv("CRUSH_DISABLE_METRICS")); v {
+		return false
+	}
+	if v, _ := strconv.ParseBool(os.Getenv("DO_NOT_TRACK")); v {
+		return false
+	}
+	if config.Get().Options.DisableMetrics {
+		return false
+	}
+	return true
 }
 
 func ResolveCwd(cmd *cobra.Command) (string, error) {
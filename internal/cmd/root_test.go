@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRootArgs(t *testing.T) {
+	newCmd := func(args ...string) *cobra.Command {
+		cmd := &cobra.Command{Use: "crush"}
+		cmd.Flags().BoolP("continue", "c", false, "")
+		require.NoError(t, cmd.Flags().Parse(args))
+		return cmd
+	}
+
+	require.NoError(t, rootArgs(newCmd("-c"), nil))
+
+	// -c used to take the working directory.
+	dir := t.TempDir()
+	err := rootArgs(newCmd("-c", dir), []string{dir})
+	require.EqualError(t, err, "-c is short for --continue, use --cwd "+dir+" to work in that directory")
+
+	err = rootArgs(newCmd(), []string{"bogus"})
+	require.EqualError(t, err, `unknown command "bogus" for "crush"`)
+}
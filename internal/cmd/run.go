@@ -8,7 +8,9 @@ import (
 	"os/signal"
 	"strings"
 
+	"github.com/charmbracelet/crush/internal/app"
 	"github.com/charmbracelet/crush/internal/event"
+	"github.com/charmbracelet/crush/internal/message"
 	"github.com/spf13/cobra"
 )
 
@@ -16,7 +18,9 @@ var runCmd = &cobra.Command{
 	Use:   "run [prompt...]",
 	Short: "Run a single non-interactive prompt",
 	Long: `Run a single prompt in non-interactive mode and exit.
-The prompt can be provided as arguments or piped from stdin.`,
+The prompt can be provided as arguments or piped from stdin. When both are
+given, the piped input is attached to the prompt in a code block, truncated
+past 256 KiB. A piped prompt can be up to 4 MiB.`,
 	Example: `
 # Run a simple prompt
 crush run Explain the use of context in Go
@@ -24,35 +28,65 @@ crush run Explain the use of context in Go
 # Pipe input from stdin
 curl https://charm.land | crush run "Summarize this website"
 
+# Review a diff (piped input is attached to the prompt)
+git diff | crush run "Review this"
+
 # Read from a file
-crush run "What is this code doing?" <<< prrr.go
+crush run "What is this code doing?" < prrr.go
 
 # Run in quiet mode (hide the spinner)
 crush run --quiet "Generate a README for this project"
+
+# Stream machine-readable JSON events (tool calls, diffs, final message)
+crush run --output json "Fix the failing tests"
+
+# Print only the changes made, as a patch
+crush run --output diff "Rename Foo to Bar" > rename.patch
   `,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		quiet, _ := cmd.Flags().GetBool("quiet")
+		outputName, _ := cmd.Flags().GetString("output")
+		format, err := app.ParseOutputFormat(outputName)
+		if err != nil {
+			return err
+		}
 
 		// Cancel on SIGINT or SIGTERM.
 		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, os.Kill)
 		defer cancel()
 
-		app, err := setupApp(cmd)
+		appInstance, err := setupApp(cmd)
 		if err != nil {
 			return err
 		}
-		defer app.Shutdown()
+		defer appInstance.Shutdown()
 
-		if !app.Config().IsConfigured() {
+		if !appInstance.Config().IsConfigured() {
 			return fmt.Errorf("no providers configured - please run 'crush' to set up a provider interactively")
 		}
 
 		prompt := strings.Join(args, " ")
 
-		prompt, err = MaybePrependStdin(prompt)
+		var attachments []message.Attachment
+		data, err := readStdin()
 		if err != nil {
 			slog.Error("Failed to read from stdin", "error", err)
-			return err
+			return fmt.Errorf("failed to read from stdin: %w", err)
+		}
+		// Piped input is the prompt itself when there's no other, and is
+		// attached to it otherwise.
+		if prompt == "" {
+			if prompt, err = stdinPrompt(data); err != nil {
+				return err
+			}
+		} else {
+			stdin, err := newStdinAttachment(data)
+			if err != nil {
+				return err
+			}
+			if stdin != nil {
+				attachments = append(attachments, *stdin)
+			}
 		}
 
 		if prompt == "" {
@@ -62,7 +96,7 @@ crush run --quiet "Generate a README for this project"
 		event.SetNonInteractive(true)
 		event.AppInitialized()
 
-		return app.RunNonInteractive(ctx, os.Stdout, prompt, quiet)
+		return appInstance.RunNonInteractive(ctx, os.Stdout, prompt, format, quiet, attachments...)
 	},
 	PostRun: func(cmd *cobra.Command, args []string) {
 		event.AppExited()
@@ -71,4 +105,9 @@ crush run --quiet "Generate a README for this project"
 
 func init() {
 	runCmd.Flags().BoolP("quiet", "q", false, "Hide spinner")
+	runCmd.Flags().StringP("output", "o", string(app.OutputText), "Output format: text, markdown, json or diff")
+	_ = runCmd.RegisterFlagCompletionFunc("output", cobra.FixedCompletions(
+		[]cobra.Completion{"text", "markdown", "json", "diff"},
+		cobra.ShellCompDirectiveNoFileComp,
+	))
 }
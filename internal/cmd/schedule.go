@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/app"
+	"github.com/charmbracelet/crush/internal/event"
+	"github.com/charmbracelet/crush/internal/schedule"
+	"github.com/spf13/cobra"
+)
+
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "Run the scheduled prompts of the config",
+	Long: `Run the prompts of the schedules of the config at their times until stopped, writing each report
+to a file or posting it to a webhook. Like crush run, prompts run without asking for permissions.`,
+	Example: `
+# Run the scheduled prompts until interrupted
+crush schedule
+
+# List the schedules and when they run next
+crush schedule --list
+
+# Run a schedule right away, once
+crush schedule --now nightly-commits
+  `,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		list, _ := cmd.Flags().GetBool("list")
+		now, _ := cmd.Flags().GetString("now")
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, os.Kill)
+		defer cancel()
+
+		appInstance, err := setupApp(cmd)
+		if err != nil {
+			return err
+		}
+		defer appInstance.Shutdown()
+
+		cfg := appInstance.Config()
+		jobs, err := schedule.Jobs(cfg.Schedules)
+		if err != nil {
+			return err
+		}
+
+		if list {
+			for _, j := range jobs {
+				fmt.Printf("%s\t%s\tnext: %s\n", j.Name, j.Schedule.Cron, j.Next(time.Now()).Format(time.DateTime))
+			}
+			return nil
+		}
+
+		if !cfg.IsConfigured() {
+			return fmt.Errorf("no providers configured - please run 'crush' to set up a provider interactively")
+		}
+
+		event.SetNonInteractive(true)
+		event.AppInitialized()
+
+		prompt := func(ctx context.Context, prompt string) (string, error) {
+			var report strings.Builder
+			err := appInstance.RunNonInteractive(ctx, &report, prompt, app.OutputText, true)
+			return strings.TrimSpace(report.String()), err
+		}
+
+		if now != "" {
+			for _, j := range jobs {
+				if j.Name == now {
+					return schedule.RunJob(ctx, cfg.WorkingDir(), j, time.Now(), prompt)
+				}
+			}
+			return fmt.Errorf("no enabled schedule named %q", now)
+		}
+		return schedule.Run(ctx, cfg.WorkingDir(), jobs, prompt)
+	},
+	PostRun: func(cmd *cobra.Command, args []string) {
+		event.AppExited()
+	},
+}
+
+func init() {
+	scheduleCmd.Flags().Bool("list", false, "List the schedules and when they run next")
+	scheduleCmd.Flags().String("now", "", "Run the schedule with the given name once, right away")
+	scheduleCmd.MarkFlagsMutuallyExclusive("list", "now")
+}
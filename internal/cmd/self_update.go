@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/update"
+	"github.com/charmbracelet/crush/internal/version"
+	"github.com/spf13/cobra"
+)
+
+var selfUpdateCmd = &cobra.Command{
+	Use:   "self-update",
+	Short: "Update Crush to the latest release",
+	Long: `Download the latest release of Crush for this platform and replace the running executable with it.
+The archive is verified against the checksums of the release, and the checksums against their signature with cosign, before anything is replaced. Installs managed by a package manager, like Homebrew or npm, should be updated with it instead.`,
+	Example: `
+# Update to the latest release
+crush self-update
+
+# Show the changelog of the latest release without updating
+crush self-update --check
+  `,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		check, _ := cmd.Flags().GetBool("check")
+		force, _ := cmd.Flags().GetBool("force")
+		skipSignature, _ := cmd.Flags().GetBool("skip-signature")
+
+		ctx, cancel := context.WithTimeout(cmd.Context(), 10*time.Minute)
+		defer cancel()
+		info, err := update.Check(ctx, version.Version, update.Default)
+		if err != nil {
+			return err
+		}
+		if !info.Available() && !force {
+			cmd.Printf("Crush is up to date (v%s).\n", info.Current)
+			return nil
+		}
+
+		cmd.Printf("Crush v%s is available, you have v%s.\n", info.Latest, info.Current)
+		if info.Notes != "" {
+			cmd.Printf("\n%s\n\n", info.Notes)
+		}
+		if check {
+			cmd.Printf("Run crush self-update to update, or see %s\n", info.URL)
+			return nil
+		}
+
+		if info.IsDevelopment() && !force {
+			return fmt.Errorf("this is a development build of Crush, pass --force to replace it with v%s", info.Latest)
+		}
+		exe, err := os.Executable()
+		if err != nil {
+			return err
+		}
+		if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+			exe = resolved
+		}
+		if manager := update.ManagedBy(exe); manager != "" && !force {
+			return fmt.Errorf("crush was installed with %s, update it with %s instead, or pass --force", manager, manager)
+		}
+
+		cmd.Printf("Updating %s…\n", exe)
+		err = update.Install(ctx, info.Release, exe, update.InstallOptions{SkipSignature: skipSignature})
+		if errors.Is(err, update.ErrNoCosign) {
+			return fmt.Errorf("%w, or pass --skip-signature to only verify the checksum", err)
+		}
+		if err != nil {
+			return err
+		}
+		cmd.Printf("Updated Crush to v%s.\n", info.Latest)
+		return nil
+	},
+}
+
+func init() {
+	selfUpdateCmd.Flags().Bool("check", false, "Only show the latest release and its changelog")
+	selfUpdateCmd.Flags().Bool("force", false, "Update development builds and installs managed by a package manager, or reinstall the latest release")
+	selfUpdateCmd.Flags().Bool("skip-signature", false, "Only verify the checksum of the archive, without cosign")
+}
@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/crush/internal/share"
+	"github.com/spf13/cobra"
+)
+
+var shareCmd = &cobra.Command{
+	Use:   "share",
+	Short: "Share a read-only live view of a session",
+	Long: `Serve a read-only live view of a session as a web page, so a teammate can watch the agent work in real time.
+Anyone with the join link can watch, so only listen on addresses you trust. Likely secrets are masked.`,
+	Example: `
+# Share the most recent session on a random local port
+crush share
+
+# Share a specific session with your local network
+crush share --session 3f2a9c1e-8b4d-4e6f-a1b2-c3d4e5f6a7b8 --addr 0.0.0.0:8080
+  `,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dataDir, _ := cmd.Flags().GetString("data-dir")
+		addr, _ := cmd.Flags().GetString("addr")
+		sessionID, _ := cmd.Flags().GetString("session")
+		ctx := cmd.Context()
+
+		cwd, err := ResolveCwd(cmd)
+		if err != nil {
+			return err
+		}
+		cfg, err := config.Load(cwd, dataDir, false)
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %v", err)
+		}
+		conn, err := db.Connect(ctx, cfg.Options.DataDirectory)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		q := db.New(conn)
+		sessions := session.NewService(q)
+		messages := message.NewService(q)
+
+		sess, err := sharedSession(ctx, sessions, sessionID)
+		if err != nil {
+			return err
+		}
+		redactor, err := cfg.Options.SecretScanning.Redactor()
+		if err != nil {
+			return fmt.Errorf("invalid secret scanning configuration: %w", err)
+		}
+		server, err := share.New(sessions, messages, redactor, sess.ID)
+		if err != nil {
+			return err
+		}
+
+		ln, err := net.Listen("tcp", addr)
+		if err != nil {
+			return fmt.Errorf("failed to listen on %s: %w", addr, err)
+		}
+		httpServer := &http.Server{
+			Handler:           server,
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		go func() {
+			<-ctx.Done()
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			_ = httpServer.Shutdown(shutdownCtx)
+		}()
+
+		title := sess.Title
+		if title == "" {
+			title = "Untitled Session"
+		}
+		cmd.Printf("Sharing %q (read-only). Join link:\n\n  %s\n\nPress ctrl+c to stop sharing.\n", title, joinLink(ln.Addr(), server.Path()))
+
+		if err := httpServer.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	},
+}
+
+func init() {
+	shareCmd.Flags().String("addr", "127.0.0.1:0", "Address to serve the view on")
+	shareCmd.Flags().String("session", "", "ID of the session to share (default: the most recent one)")
+}
+
+// sharedSession returns the session with the given ID, or the most recent one
+// when no ID is given.
+func sharedSession(ctx context.Context, sessions session.Service, id string) (session.Session, error) {
+	if id == "" {
+		sess, err := sessions.Latest(ctx)
+		if errors.Is(err, session.ErrNoSessions) {
+			return session.Session{}, errors.New("no session to share in this project yet")
+		}
+		return sess, err
+	}
+	sess, err := sessions.Get(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return session.Session{}, fmt.Errorf("session %q not found in this project", id)
+	}
+	return sess, err
+}
+
+// joinLink returns the URL to watch the shared session at. When listening on
+// all interfaces, it uses an address of the machine that others on the
+// network can reach.
+func joinLink(addr net.Addr, path string) string {
+	host, port, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return "http://" + addr.String() + path
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsUnspecified() {
+		host = "localhost"
+		if addrs, err := net.InterfaceAddrs(); err == nil {
+			for _, a := range addrs {
+				if ipNet, ok := a.(*net.IPNet); ok && !ipNet.IP.IsLoopback() && ipNet.IP.To4() != nil {
+					host = ipNet.IP.String()
+					break
+				}
+			}
+		}
+	}
+	return "http://" + net.JoinHostPort(host, port) + path
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"charm.land/lipgloss/v2"
+	"charm.land/lipgloss/v2/table"
+	"github.com/charmbracelet/crush/internal/stats"
+	"github.com/charmbracelet/x/term"
+	"github.com/spf13/cobra"
+)
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show your usage statistics",
+	Long: `Show the usage statistics recorded on this machine: sessions per week, models used, tools called and how many edits you accepted.
+Statistics are only recorded when usage_stats is enabled in the options of your configuration. They never include prompts, responses or file contents, and never leave your machine.`,
+	Example: `
+# Show your usage over the last 8 weeks
+crush stats
+
+# Output the statistics as JSON
+crush stats --json --weeks 52
+  `,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		jsonOutput, _ := cmd.Flags().GetBool("json")
+		weeks, _ := cmd.Flags().GetInt("weeks")
+		if weeks < 1 {
+			return fmt.Errorf("--weeks must be at least 1, got %d", weeks)
+		}
+
+		events, err := stats.Load(stats.FilePath())
+		if err != nil {
+			return fmt.Errorf("failed to read usage statistics: %w", err)
+		}
+		summary := stats.Summarize(events, time.Now(), weeks)
+
+		if jsonOutput {
+			data, err := json.Marshal(summary)
+			if err != nil {
+				return err
+			}
+			cmd.Println(string(data))
+			return nil
+		}
+
+		if len(events) == 0 {
+			cmd.Println(`No usage statistics recorded yet. Set "usage_stats": true in the options of your configuration to start recording them.`)
+			return nil
+		}
+
+		rate := "n/a"
+		if r := summary.AcceptanceRate(); r >= 0 {
+			rate = fmt.Sprintf("%.0f%% (%d of %d)", r*100, summary.EditsAccepted, summary.EditsAccepted+summary.EditsRejected)
+		}
+
+		if term.IsTerminal(os.Stdout.Fd()) {
+			// We're in a TTY: make it fancy.
+			newTable := func(headers ...string) *table.Table {
+				return table.New().
+					Border(lipgloss.RoundedBorder()).
+					StyleFunc(func(row, col int) lipgloss.Style {
+						return lipgloss.NewStyle().Padding(0, 2)
+					}).
+					Headers(headers...)
+			}
+
+			weeksTable := newTable("Week of", "Sessions")
+			for _, w := range summary.Weeks {
+				weeksTable.Row(w.Start.Format("2006-01-02"), fmt.Sprint(w.Sessions))
+			}
+			modelsTable := newTable("Provider", "Model", "Requests", "Input Tokens", "Output Tokens")
+			for _, m := range summary.Models {
+				modelsTable.Row(m.Provider, m.Model, fmt.Sprint(m.Steps), fmt.Sprint(m.InputTokens), fmt.Sprint(m.OutputTokens))
+			}
+			toolsTable := newTable("Tool", "Calls")
+			for _, t := range summary.Tools {
+				toolsTable.Row(t.Tool, fmt.Sprint(t.Calls))
+			}
+
+			lipgloss.Println(weeksTable)
+			lipgloss.Println(modelsTable)
+			lipgloss.Println(toolsTable)
+			cmd.Printf("Edits accepted: %s\n", rate)
+			return nil
+		}
+
+		// Not a TTY: plain output
+		for _, w := range summary.Weeks {
+			cmd.Printf("week\t%s\t%d\n", w.Start.Format("2006-01-02"), w.Sessions)
+		}
+		for _, m := range summary.Models {
+			cmd.Printf("model\t%s\t%s\t%d\t%d\t%d\n", m.Provider, m.Model, m.Steps, m.InputTokens, m.OutputTokens)
+		}
+		for _, t := range summary.Tools {
+			cmd.Printf("tool\t%s\t%d\n", t.Tool, t.Calls)
+		}
+		cmd.Printf("edits accepted\t%s\n", rate)
+		return nil
+	},
+}
+
+func init() {
+	statsCmd.Flags().Bool("json", false, "Output as JSON")
+	statsCmd.Flags().Int("weeks", 8, "Number of weeks to count sessions for")
+}
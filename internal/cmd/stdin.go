@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/x/term"
+)
+
+const (
+	// maxStdinSize is the most piped input attached to a prompt. Anything
+	// past it is dropped with a note, so huge logs don't blow the context
+	// window.
+	maxStdinSize = 256 * 1024
+	// maxStdinRead is the most piped input read at all, one byte more is
+	// read to tell when there was more.
+	maxStdinRead = 4 * 1024 * 1024
+)
+
+// readStdin returns the data piped or redirected into Crush, up to one byte
+// past maxStdinRead, or nil when stdin is a terminal.
+func readStdin() ([]byte, error) {
+	if term.IsTerminal(os.Stdin.Fd()) {
+		return nil, nil
+	}
+	fi, err := os.Stdin.Stat()
+	if err != nil {
+		return nil, err
+	}
+	// Check if stdin is a named pipe ( | ) or regular file ( < ).
+	if fi.Mode()&os.ModeNamedPipe == 0 && !fi.Mode().IsRegular() {
+		return nil, nil
+	}
+	return io.ReadAll(io.LimitReader(os.Stdin, maxStdinRead+1))
+}
+
+// stdinAttachment reads piped input and turns it into a text attachment for
+// the first prompt. It returns nil when nothing was piped in.
+func stdinAttachment() (*message.Attachment, error) {
+	data, err := readStdin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from stdin: %w", err)
+	}
+	return newStdinAttachment(data)
+}
+
+func newStdinAttachment(data []byte) (*message.Attachment, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
+	}
+	if len(data) > maxStdinRead {
+		// Drop the extra byte read, and what the read cut of a character.
+		cut := maxStdinRead
+		for cut > 0 && !utf8.RuneStart(data[cut]) {
+			cut--
+		}
+		data = data[:cut]
+	}
+	if !isText(data) {
+		return nil, errBinaryStdin
+	}
+	return &message.Attachment{
+		FileName: "stdin",
+		MimeType: "text/plain",
+		Content:  []byte(fenceStdin(string(data), maxStdinSize)),
+	}, nil
+}
+
+// stdinPrompt returns piped input as the prompt, as it is.
+func stdinPrompt(data []byte) (string, error) {
+	if len(data) > maxStdinRead {
+		return "", fmt.Errorf("piped prompt is over %d MiB, pipe it with a prompt as an argument to attach it instead", maxStdinRead/(1024*1024))
+	}
+	if !isText(data) {
+		return "", errBinaryStdin
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+var errBinaryStdin = errors.New("piped input looks like binary data, only text is supported")
+
+func isText(data []byte) bool {
+	return bytes.IndexByte(data, 0) == -1 && utf8.Valid(data)
+}
+
+// fenceStdin truncates input to limit bytes and wraps it in a code fence
+// with a language hint when one can be guessed.
+func fenceStdin(input string, limit int) string {
+	input = strings.TrimRight(input, "\n")
+	var note string
+	if len(input) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(input[cut]) {
+			cut--
+		}
+		if nl := strings.LastIndexByte(input[:cut], '\n'); nl > 0 {
+			cut = nl
+		}
+		note = fmt.Sprintf("\n(input truncated: only the first %d of %d bytes are shown)", cut, len(input))
+		input = input[:cut]
+	}
+
+	fence := "```"
+	for strings.Contains(input, fence) {
+		fence += "`"
+	}
+	return fence + stdinLanguage(input) + "\n" + input + "\n" + fence + note
+}
+
+// stdinLanguage guesses the code fence language of piped input.
+func stdinLanguage(input string) string {
+	trimmed := strings.TrimSpace(input)
+	switch {
+	case strings.HasPrefix(trimmed, "diff --git "),
+		strings.HasPrefix(trimmed, "--- ") && strings.Contains(trimmed, "\n+++ "),
+		strings.HasPrefix(trimmed, "Index: "):
+		return "diff"
+	case (strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[")) && json.Valid([]byte(trimmed)):
+		return "json"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFenceStdin(t *testing.T) {
+	t.Parallel()
+
+	t.Run("plain", func(t *testing.T) {
+		t.Parallel()
+		require.Equal(t, "```\nhello\n```", fenceStdin("hello\n", 100))
+	})
+
+	t.Run("diff", func(t *testing.T) {
+		t.Parallel()
+		input := "diff --git a/x b/x\n--- a/x\n+++ b/x\n@@ -1 +1 @@\n-a\n+b\n"
+		require.True(t, strings.HasPrefix(fenceStdin(input, 1000), "```diff\n"))
+	})
+
+	t.Run("json", func(t *testing.T) {
+		t.Parallel()
+		require.Equal(t, "```json\n{\"a\": 1}\n```", fenceStdin(`{"a": 1}`, 100))
+	})
+
+	t.Run("nested fence", func(t *testing.T) {
+		t.Parallel()
+		require.Equal(t, "````\n```go\nx\n```\n````", fenceStdin("```go\nx\n```", 100))
+	})
+
+	t.Run("truncated on a line boundary", func(t *testing.T) {
+		t.Parallel()
+		got := fenceStdin("line one\nline two\nline three", 12)
+		require.Equal(t, "```\nline one\n```\n(input truncated: only the first 8 of 28 bytes are shown)", got)
+	})
+}
+
+func TestNewStdinAttachment(t *testing.T) {
+	t.Parallel()
+
+	att, err := newStdinAttachment([]byte("  \n"))
+	require.NoError(t, err)
+	require.Nil(t, att)
+
+	_, err = newStdinAttachment([]byte{'a', 0, 'b'})
+	require.Error(t, err)
+
+	att, err = newStdinAttachment([]byte("some log output\n"))
+	require.NoError(t, err)
+	require.NotNil(t, att)
+	require.True(t, att.IsText())
+	require.Equal(t, "```\nsome log output\n```", string(att.Content))
+
+	// Input read past the limit is cut on a character boundary.
+	data := append(bytes.Repeat([]byte("a"), maxStdinRead-1), "é"...)
+	att, err = newStdinAttachment(data)
+	require.NoError(t, err)
+	require.True(t, strings.HasSuffix(string(att.Content), fmt.Sprintf("of %d bytes are shown)", maxStdinRead-1)))
+}
+
+func TestStdinPrompt(t *testing.T) {
+	t.Parallel()
+
+	// Piped prompts are neither fenced nor truncated.
+	input := "Review this:\n\n```go\nfunc main() {}\n```\n" + strings.Repeat("x", maxStdinSize)
+	prompt, err := stdinPrompt([]byte(input + "\n"))
+	require.NoError(t, err)
+	require.Equal(t, input, prompt)
+
+	_, err = stdinPrompt([]byte{'a', 0, 'b'})
+	require.Error(t, err)
+
+	_, err = stdinPrompt(bytes.Repeat([]byte("a"), maxStdinRead+1))
+	require.ErrorContains(t, err, "piped prompt is over 4 MiB")
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/charmbracelet/catwalk/pkg/catwalk"
 	"github.com/charmbracelet/catwalk/pkg/embedded"
+	"github.com/charmbracelet/crush/internal/netstatus"
 )
 
 type catwalkClient interface {
@@ -66,6 +67,12 @@ func (s *catwalkSync) Get(ctx context.Context) ([]catwalk.Provider, error) {
 		}
 		if err != nil {
 			// On error, fall back to cached (which defaults to embedded if empty).
+			if netstatus.Unreachable(CatwalkURL(), err) {
+				slog.Warn("Network unreachable, will refresh Catwalk providers once back online")
+				netstatus.WhenOnline("catwalk", func(context.Context) error {
+					return UpdateProviders("")
+				})
+			}
 			s.result = cached
 			return
 		}
@@ -13,6 +13,9 @@ import (
 
 const (
 	InitFlagFilename = "init"
+	// LSPOfferedFilename lists the language servers already offered to be
+	// set up for the project, one per line.
+	LSPOfferedFilename = "lsp_offered"
 )
 
 type ProjectInitFlag struct {
@@ -133,3 +136,40 @@ func HasInitialDataConfig() bool {
 	}
 	return Get().IsConfigured()
 }
+
+// OfferedLSPs returns the names of the language servers the user was already
+// offered to set up for the project.
+func OfferedLSPs() []string {
+	cfg := Get()
+	if cfg == nil {
+		return nil
+	}
+	data, err := os.ReadFile(filepath.Join(cfg.Options.DataDirectory, LSPOfferedFilename))
+	if err != nil {
+		return nil
+	}
+	return strings.Fields(string(data))
+}
+
+// MarkLSPsOffered records that the language servers were offered, so that
+// they're not offered again whatever the answer was.
+func MarkLSPsOffered(names []string) error {
+	cfg := Get()
+	if cfg == nil {
+		return fmt.Errorf("config not loaded")
+	}
+	offered := OfferedLSPs()
+	for _, name := range names {
+		if !slices.Contains(offered, name) {
+			offered = append(offered, name)
+		}
+	}
+	if err := os.MkdirAll(cfg.Options.DataDirectory, 0o755); err != nil {
+		return fmt.Errorf("failed to create data directory: %w", err)
+	}
+	flagFilePath := filepath.Join(cfg.Options.DataDirectory, LSPOfferedFilename)
+	if err := os.WriteFile(flagFilePath, []byte(strings.Join(offered, "\n")+"\n"), 0o644); err != nil {
+		return fmt.Errorf("failed to write lsp offered file: %w", err)
+	}
+	return nil
+}
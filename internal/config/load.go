@@ -1,6 +1,7 @@
 package config
 
 import (
+	"bytes"
 	"cmp"
 	"context"
 	"encoding/json"
@@ -25,6 +26,7 @@ import (
 	"github.com/charmbracelet/crush/internal/home"
 	"github.com/charmbracelet/crush/internal/log"
 	powernapConfig "github.com/charmbracelet/x/powernap/pkg/config"
+	"github.com/tidwall/sjson"
 )
 
 const defaultCatwalkURL = "https://catwalk.charm.sh"
@@ -46,6 +48,11 @@ func LoadReader(fd io.Reader) (*Config, error) {
 
 // Load loads the configuration from the default paths.
 func Load(workingDir, dataDir string, debug bool) (*Config, error) {
+	if profile := Profile(); profile != "" {
+		if err := checkProfile(profile); err != nil {
+			return nil, err
+		}
+	}
 	configPaths := lookupConfigs(workingDir)
 
 	cfg, err := loadFromConfigPaths(configPaths)
@@ -54,6 +61,9 @@ func Load(workingDir, dataDir string, debug bool) (*Config, error) {
 	}
 
 	cfg.dataConfigDir = GlobalConfigData()
+	if profile := Profile(); profile != "" {
+		cfg.dataConfigDir = ProfileConfigData(profile)
+	}
 
 	cfg.setDefaults(workingDir, dataDir)
 
@@ -62,10 +72,14 @@ func Load(workingDir, dataDir string, debug bool) (*Config, error) {
 	}
 
 	// Setup logs
+	logOpts, logErr := cfg.Options.logOptions()
 	log.Setup(
 		filepath.Join(cfg.Options.DataDirectory, "logs", fmt.Sprintf("%s.log", appName)),
-		cfg.Options.Debug,
+		logOpts,
 	)
+	if logErr != nil {
+		slog.Warn("Ignoring log level", "error", logErr)
+	}
 
 	if !isInsideWorktree() {
 		const depth = 2
@@ -91,6 +105,7 @@ func Load(workingDir, dataDir string, debug bool) (*Config, error) {
 	if err := cfg.configureProviders(env, valueResolver, cfg.knownProviders); err != nil {
 		return nil, fmt.Errorf("failed to configure providers: %w", err)
 	}
+	cfg.removeNonLocalProviders()
 
 	if !cfg.IsConfigured() {
 		slog.Warn("No providers configured")
@@ -147,6 +162,8 @@ func (c *Config) configureProviders(env env.Env, resolver VariableResolver, know
 			}
 			if config.APIKey != "" {
 				p.APIKey = config.APIKey
+			} else if len(config.APIKeys) > 0 {
+				p.APIKey = config.APIKeys[0]
 			}
 			if len(config.Models) > 0 {
 				models := []catwalk.Model{}
@@ -190,12 +207,17 @@ func (c *Config) configureProviders(env env.Env, resolver VariableResolver, know
 			BaseURL:            p.APIEndpoint,
 			APIKey:             p.APIKey,
 			APIKeyTemplate:     p.APIKey, // Store original template for re-resolution
+			APIKeys:            config.APIKeys,
 			OAuthToken:         config.OAuthToken,
 			Type:               p.Type,
 			Disable:            config.Disable,
+			MaxConcurrency:     config.MaxConcurrency,
+			RequestsPerMinute:  config.RequestsPerMinute,
 			SystemPromptPrefix: config.SystemPromptPrefix,
 			ExtraHeaders:       headers,
 			ExtraBody:          config.ExtraBody,
+			Organization:       config.Organization,
+			Project:            config.Project,
 			ExtraParams:        make(map[string]string),
 			Models:             p.Models,
 		}
@@ -209,6 +231,15 @@ func (c *Config) configureProviders(env env.Env, resolver VariableResolver, know
 			}
 		}
 
+		if err := prepared.ValidateExtras(); err != nil {
+			if configExists {
+				slog.Warn("Skipping provider due to invalid extra headers or body", "provider", p.ID, "error", err)
+				c.Providers.Del(string(p.ID))
+			}
+			continue
+		}
+		warnIgnoredOptions(prepared)
+
 		switch p.ID {
 		// Handle specific providers that require additional configuration
 		case catwalk.InferenceProviderVertexAI:
@@ -289,6 +320,15 @@ func (c *Config) configureProviders(env env.Env, resolver VariableResolver, know
 			c.Providers.Del(id)
 			continue
 		}
+		if err := providerConfig.ValidateExtras(); err != nil {
+			slog.Warn("Skipping custom provider due to invalid extra headers or body", "provider", id, "error", err)
+			c.Providers.Del(id)
+			continue
+		}
+		warnIgnoredOptions(providerConfig)
+		if providerConfig.APIKey == "" && len(providerConfig.APIKeys) > 0 {
+			providerConfig.APIKey = providerConfig.APIKeys[0]
+		}
 		if providerConfig.APIKey == "" {
 			slog.Warn("Provider is missing API key, this might be OK for local providers", "provider", id)
 		}
@@ -318,6 +358,31 @@ func (c *Config) configureProviders(env env.Env, resolver VariableResolver, know
 	return nil
 }
 
+// warnIgnoredOptions warns when options are set for a provider type that
+// doesn't send them.
+func warnIgnoredOptions(pc ProviderConfig) {
+	if len(pc.ExtraBody) > 0 && pc.Type != catwalk.TypeOpenAICompat {
+		slog.Warn("Ignoring extra_body, it only works with openai-compatible providers", "provider", pc.ID, "type", pc.Type)
+	}
+	if (pc.Organization != "" || pc.Project != "") && pc.Type != catwalk.TypeOpenAI {
+		slog.Warn("Ignoring organization and project, they only work with openai providers", "provider", pc.ID, "type", pc.Type)
+	}
+}
+
+// removeNonLocalProviders drops every provider whose endpoint is not local
+// when local-only mode is enabled.
+func (c *Config) removeNonLocalProviders() {
+	if !c.IsLocalOnly() {
+		return
+	}
+	for id, providerConfig := range c.Providers.Seq2() {
+		if !c.EndpointAllowed(providerConfig.BaseURL) {
+			slog.Warn("Skipping provider in local-only mode", "provider", id)
+			c.Providers.Del(id)
+		}
+	}
+}
+
 func (c *Config) setDefaults(workingDir, dataDir string) {
 	c.workingDir = workingDir
 	if c.Options == nil {
@@ -340,6 +405,10 @@ func (c *Config) setDefaults(workingDir, dataDir string) {
 		} else {
 			c.Options.DataDirectory = filepath.Join(workingDir, defaultDataDirectory)
 		}
+		// Each profile keeps its own sessions.
+		if profile := Profile(); profile != "" {
+			c.Options.DataDirectory = filepath.Join(c.Options.DataDirectory, "profiles", profile)
+		}
 	}
 	if c.Providers == nil {
 		c.Providers = csync.NewMap[string, ProviderConfig]()
@@ -362,6 +431,7 @@ func (c *Config) setDefaults(workingDir, dataDir string) {
 
 	// Add the default context paths if they are not already present
 	c.Options.ContextPaths = append(defaultContextPaths, c.Options.ContextPaths...)
+	c.Options.ContextPaths = append(c.Options.ContextPaths, GlobalContextFile())
 	slices.Sort(c.Options.ContextPaths)
 	c.Options.ContextPaths = slices.Compact(c.Options.ContextPaths)
 
@@ -614,6 +684,11 @@ func lookupConfigs(cwd string) []string {
 		GlobalConfig(),
 		GlobalConfigData(),
 	}
+	// The profile overrides the global config, and the project configs
+	// override the profile.
+	if profile := Profile(); profile != "" {
+		configPaths = append(configPaths, ProfileConfig(profile), ProfileConfigData(profile))
+	}
 
 	configNames := []string{appName + ".json", "." + appName + ".json"}
 
@@ -632,6 +707,7 @@ func lookupConfigs(cwd string) []string {
 func loadFromConfigPaths(configPaths []string) (*Config, error) {
 	var configs []io.Reader
 
+	profile := Profile()
 	for _, path := range configPaths {
 		fd, err := os.Open(path)
 		if err != nil {
@@ -642,6 +718,19 @@ func loadFromConfigPaths(configPaths []string) (*Config, error) {
 		}
 		defer fd.Close()
 
+		// A profile brings its own providers, so the global ones and their
+		// API keys stay out of it.
+		if profile != "" && (path == GlobalConfig() || path == GlobalConfigData()) {
+			data, err := io.ReadAll(fd)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+			}
+			if data, err = sjson.DeleteBytes(data, "providers"); err != nil {
+				return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+			}
+			configs = append(configs, bytes.NewReader(data))
+			continue
+		}
 		configs = append(configs, fd)
 	}
 
@@ -746,6 +835,12 @@ func isInsideWorktree() bool {
 	return err == nil && strings.TrimSpace(string(bts)) == "true"
 }
 
+// GlobalContextFile returns the path of the context file included in every
+// project, next to the global config file.
+func GlobalContextFile() string {
+	return filepath.Join(filepath.Dir(GlobalConfig()), "CRUSH.md")
+}
+
 // GlobalSkillsDir returns the default directory for Agent Skills.
 // Skills in this directory are auto-discovered and their files can be read
 // without permission prompts.
@@ -469,7 +469,7 @@ func TestConfig_setupAgentsWithNoDisabledTools(t *testing.T) {
 
 	taskAgent, ok := cfg.Agents[AgentTask]
 	require.True(t, ok)
-	assert.Equal(t, []string{"glob", "grep", "ls", "sourcegraph", "view"}, taskAgent.AllowedTools)
+	assert.Equal(t, []string{"glob", "grep", "ls", "sourcegraph", "symbols", "semantic_search", "view"}, taskAgent.AllowedTools)
 }
 
 func TestConfig_setupAgentsWithDisabledTools(t *testing.T) {
@@ -487,11 +487,11 @@ func TestConfig_setupAgentsWithDisabledTools(t *testing.T) {
 	coderAgent, ok := cfg.Agents[AgentCoder]
 	require.True(t, ok)
 
-	assert.Equal(t, []string{"agent", "bash", "job_output", "job_kill", "multiedit", "lsp_diagnostics", "lsp_references", "fetch", "agentic_fetch", "glob", "ls", "sourcegraph", "todos", "view", "write"}, coderAgent.AllowedTools)
+	assert.Equal(t, []string{"agent", "bash", "job_output", "job_kill", "interactive", "multiedit", "lsp_diagnostics", "lsp_references", "lsp_rename", "lsp_code_action", "fetch", "agentic_fetch", "glob", "ls", "sourcegraph", "symbols", "semantic_search", "memory", "todos", "view", "write", "screenshot", "kubectl", "terraform", "sql", "http", "openapi", "plugin"}, coderAgent.AllowedTools)
 
 	taskAgent, ok := cfg.Agents[AgentTask]
 	require.True(t, ok)
-	assert.Equal(t, []string{"glob", "ls", "sourcegraph", "view"}, taskAgent.AllowedTools)
+	assert.Equal(t, []string{"glob", "ls", "sourcegraph", "symbols", "semantic_search", "view"}, taskAgent.AllowedTools)
 }
 
 func TestConfig_setupAgentsWithEveryReadOnlyToolDisabled(t *testing.T) {
@@ -502,6 +502,8 @@ func TestConfig_setupAgentsWithEveryReadOnlyToolDisabled(t *testing.T) {
 				"grep",
 				"ls",
 				"sourcegraph",
+				"symbols",
+				"semantic_search",
 				"view",
 			},
 		},
@@ -510,7 +512,7 @@ func TestConfig_setupAgentsWithEveryReadOnlyToolDisabled(t *testing.T) {
 	cfg.SetupAgents()
 	coderAgent, ok := cfg.Agents[AgentCoder]
 	require.True(t, ok)
-	assert.Equal(t, []string{"agent", "bash", "job_output", "job_kill", "download", "edit", "multiedit", "lsp_diagnostics", "lsp_references", "fetch", "agentic_fetch", "todos", "write"}, coderAgent.AllowedTools)
+	assert.Equal(t, []string{"agent", "bash", "job_output", "job_kill", "interactive", "download", "edit", "multiedit", "lsp_diagnostics", "lsp_references", "lsp_rename", "lsp_code_action", "fetch", "agentic_fetch", "memory", "todos", "write", "screenshot", "kubectl", "terraform", "sql", "http", "openapi", "plugin"}, coderAgent.AllowedTools)
 
 	taskAgent, ok := cfg.Agents[AgentTask]
 	require.True(t, ok)
@@ -730,6 +732,55 @@ func TestConfig_configureProvidersCustomProviderValidation(t *testing.T) {
 		_, exists := cfg.Providers.Get("custom")
 		require.False(t, exists)
 	})
+
+	t.Run("custom provider with invalid extras is removed", func(t *testing.T) {
+		for name, extras := range map[string]ProviderConfig{
+			"header name":  {ExtraHeaders: map[string]string{"X Title": "Crush"}},
+			"header value": {ExtraHeaders: map[string]string{"X-Title": "Crush\r\nX-Other: 1"}},
+			"body field":   {ExtraBody: map[string]any{"messages": []any{}}},
+		} {
+			t.Run(name, func(t *testing.T) {
+				extras.APIKey = "test-key"
+				extras.BaseURL = "https://api.custom.com/v1"
+				extras.Models = []catwalk.Model{{ID: "test-model"}}
+				cfg := &Config{
+					Providers: csync.NewMapFrom(map[string]ProviderConfig{"custom": extras}),
+				}
+				cfg.setDefaults("/tmp", "")
+
+				env := env.NewFromMap(map[string]string{})
+				resolver := NewEnvironmentVariableResolver(env)
+				err := cfg.configureProviders(env, resolver, []catwalk.Provider{})
+				require.NoError(t, err)
+				require.Equal(t, cfg.Providers.Len(), 0)
+			})
+		}
+	})
+
+	t.Run("custom provider with valid extras is kept", func(t *testing.T) {
+		cfg := &Config{
+			Providers: csync.NewMapFrom(map[string]ProviderConfig{
+				"custom": {
+					APIKey:       "test-key",
+					BaseURL:      "https://api.custom.com/v1",
+					ExtraHeaders: map[string]string{"HTTP-Referer": "https://charm.land", "X-Title": "Crush"},
+					ExtraBody:    map[string]any{"cache_prompt": true},
+					Models:       []catwalk.Model{{ID: "test-model"}},
+				},
+			}),
+		}
+		cfg.setDefaults("/tmp", "")
+
+		env := env.NewFromMap(map[string]string{})
+		resolver := NewEnvironmentVariableResolver(env)
+		err := cfg.configureProviders(env, resolver, []catwalk.Provider{})
+		require.NoError(t, err)
+
+		customProvider, exists := cfg.Providers.Get("custom")
+		require.True(t, exists)
+		require.Equal(t, "Crush", customProvider.ExtraHeaders["X-Title"])
+		require.Equal(t, true, customProvider.ExtraBody["cache_prompt"])
+	})
 }
 
 func TestConfig_configureProvidersEnhancedCredentialValidation(t *testing.T) {
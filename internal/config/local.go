@@ -0,0 +1,78 @@
+package config
+
+import (
+	"net"
+	"net/url"
+	"strings"
+)
+
+// webToolNames are the built-in tools that reach out to the network. They are
+// disabled in local-only mode.
+var webToolNames = []string{"download", "fetch", "agentic_fetch", "sourcegraph"}
+
+// IsLocalEndpoint reports whether rawURL points at localhost or an address in
+// a private, loopback or link-local range. Hostnames other than localhost are
+// never considered local, since they can resolve anywhere.
+func IsLocalEndpoint(rawURL string) bool {
+	host, ok := endpointHost(rawURL)
+	if !ok {
+		return false
+	}
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast()
+}
+
+// IsLoopbackEndpoint reports whether rawURL points at localhost or a loopback
+// address, so that requests to it never leave the machine.
+func IsLoopbackEndpoint(rawURL string) bool {
+	host, ok := endpointHost(rawURL)
+	if !ok {
+		return false
+	}
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func endpointHost(rawURL string) (string, bool) {
+	if rawURL == "" {
+		return "", false
+	}
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "http://" + rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", false
+	}
+	return strings.ToLower(u.Hostname()), true
+}
+
+// IsLocalOnly reports whether local-only mode is enabled.
+func (c *Config) IsLocalOnly() bool {
+	return c.Options != nil && c.Options.LocalOnly
+}
+
+// EndpointAllowed reports whether requests may be sent to the given provider
+// endpoint. Everything is allowed unless local-only mode is enabled.
+func (c *Config) EndpointAllowed(baseURL string) bool {
+	if !c.IsLocalOnly() {
+		return true
+	}
+	if c.resolver != nil {
+		resolved, err := c.resolver.ResolveValue(baseURL)
+		if err != nil {
+			return false
+		}
+		baseURL = resolved
+	}
+	return IsLocalEndpoint(baseURL)
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsLocalEndpoint(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]bool{
+		"http://localhost:11434/v1":     true,
+		"http://127.0.0.1:1234/v1":      true,
+		"http://[::1]:8080":             true,
+		"http://192.168.1.20:8000/v1":   true,
+		"http://10.0.0.5/v1":            true,
+		"http://172.16.4.2:11434":       true,
+		"http://ollama.localhost:11434": true,
+		"localhost:11434":               true,
+		"https://api.openai.com/v1":     false,
+		"https://8.8.8.8/v1":            false,
+		"http://my-gpu-box:11434":       false,
+		"":                              false,
+	}
+	for endpoint, want := range tests {
+		t.Run(endpoint, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, want, IsLocalEndpoint(endpoint))
+		})
+	}
+}
+
+func TestIsLoopbackEndpoint(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]bool{
+		"http://localhost:8080/hook":    true,
+		"http://127.0.0.1:9000/hook":    true,
+		"http://[::1]:8080":             true,
+		"http://hooks.localhost/crush":  true,
+		"http://192.168.1.20:8000/hook": false,
+		"https://hooks.slack.com/x/y/z": false,
+		"http://my-gpu-box:11434":       false,
+		"":                              false,
+	}
+	for endpoint, want := range tests {
+		t.Run(endpoint, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, want, IsLoopbackEndpoint(endpoint))
+		})
+	}
+}
+
+func TestSetupAgentsLocalOnly(t *testing.T) {
+	t.Parallel()
+
+	cfg := &Config{
+		Options: &Options{
+			LocalOnly:     true,
+			DisabledTools: []string{"bash"},
+		},
+	}
+	cfg.SetupAgents()
+
+	coder := cfg.Agents[AgentCoder]
+	for _, name := range append([]string{"bash"}, webToolNames...) {
+		require.NotContains(t, coder.AllowedTools, name)
+	}
+	require.Contains(t, coder.AllowedTools, "view")
+	require.NotContains(t, cfg.Agents[AgentTask].AllowedTools, "sourcegraph")
+	require.Equal(t, []string{"bash"}, cfg.Options.DisabledTools)
+}
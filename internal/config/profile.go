@@ -0,0 +1,70 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ProfileEnv is the environment variable naming the config profile Crush
+// runs with, set by the --profile flag.
+const ProfileEnv = "CRUSH_PROFILE"
+
+var profileName = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// Profile returns the name of the config profile Crush runs with, or an
+// empty string when it runs without one.
+func Profile() string {
+	return os.Getenv(ProfileEnv)
+}
+
+// ProfilesDir returns the directory the config profiles are kept in, next to
+// the global config.
+func ProfilesDir() string {
+	return filepath.Join(filepath.Dir(GlobalConfig()), "profiles")
+}
+
+// ProfileConfig returns the path to the config file of a profile.
+func ProfileConfig(name string) string {
+	return filepath.Join(ProfilesDir(), name+".json")
+}
+
+// ProfileConfigData returns the path to the file the app keeps the settings
+// of a profile in, like its preferred and recent models, next to the global
+// one.
+func ProfileConfigData(name string) string {
+	return filepath.Join(filepath.Dir(GlobalConfigData()), "profiles", name+".json")
+}
+
+// Profiles returns the names of the config profiles, sorted.
+func Profiles() []string {
+	paths, _ := filepath.Glob(filepath.Join(ProfilesDir(), "*.json"))
+	var names []string
+	for _, path := range paths {
+		name := strings.TrimSuffix(filepath.Base(path), ".json")
+		if ValidProfile(name) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// ValidProfile reports whether name can be the name of a profile.
+func ValidProfile(name string) bool {
+	return profileName.MatchString(name)
+}
+
+// checkProfile returns an error when the profile can't be used.
+func checkProfile(name string) error {
+	if !ValidProfile(name) {
+		return fmt.Errorf("invalid profile name %q: use letters, digits, dots, dashes and underscores", name)
+	}
+	if _, err := os.Stat(ProfileConfig(name)); err != nil {
+		return fmt.Errorf("profile %q not found: create %s with the settings of the profile", name, ProfileConfig(name))
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfiles(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("CRUSH_GLOBAL_CONFIG", dir)
+	t.Setenv("CRUSH_GLOBAL_DATA", filepath.Join(dir, "data"))
+	t.Setenv(ProfileEnv, "")
+
+	require.Empty(t, Profiles())
+	require.NoError(t, os.MkdirAll(ProfilesDir(), 0o755))
+	for _, name := range []string{"work.json", "personal.json", "notes.txt"} {
+		require.NoError(t, os.WriteFile(filepath.Join(ProfilesDir(), name), []byte(`{}`), 0o644))
+	}
+	require.Equal(t, []string{"personal", "work"}, Profiles())
+
+	require.NoError(t, checkProfile("work"))
+	require.ErrorContains(t, checkProfile("home"), "not found")
+	require.ErrorContains(t, checkProfile("../work"), "invalid profile name")
+
+	cwd := t.TempDir()
+	require.Equal(t, []string{GlobalConfig(), GlobalConfigData()}, lookupConfigs(cwd))
+	t.Setenv(ProfileEnv, "work")
+	require.Equal(t, []string{GlobalConfig(), GlobalConfigData(), ProfileConfig("work"), ProfileConfigData("work")}, lookupConfigs(cwd))
+
+	cfg := &Config{}
+	cfg.setDefaults(cwd, "")
+	require.Equal(t, filepath.Join(cwd, defaultDataDirectory, "profiles", "work"), cfg.Options.DataDirectory)
+}
+
+func TestProfileWithoutGlobalProviders(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("CRUSH_GLOBAL_CONFIG", dir)
+	t.Setenv("CRUSH_GLOBAL_DATA", filepath.Join(dir, "data"))
+	t.Setenv(ProfileEnv, "work")
+
+	write := func(path, content string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	write(GlobalConfig(), `{"providers": {"personal": {"api_key": "personal-key"}}, "options": {"debug": true}}`)
+	write(GlobalConfigData(), `{"providers": {"openai": {"api_key": "personal-openai-key"}}}`)
+	write(ProfileConfig("work"), `{"providers": {"work": {"api_key": "work-key"}}}`)
+
+	cfg, err := loadFromConfigPaths(lookupConfigs(t.TempDir()))
+	require.NoError(t, err)
+	require.Equal(t, 1, cfg.Providers.Len())
+	_, ok := cfg.Providers.Get("work")
+	require.True(t, ok)
+	// The rest of the global config still applies.
+	require.True(t, cfg.Options.Debug)
+
+	t.Setenv(ProfileEnv, "")
+	cfg, err = loadFromConfigPaths(lookupConfigs(t.TempDir()))
+	require.NoError(t, err)
+	require.Equal(t, 2, cfg.Providers.Len())
+	_, ok = cfg.Providers.Get("personal")
+	require.True(t, ok)
+}
@@ -54,6 +54,11 @@ func cachePathFor(name string) string {
 	return filepath.Join(home.Dir(), ".local", "share", appName, name+".json")
 }
 
+// CatwalkURL returns the URL of the Catwalk server providers are fetched from.
+func CatwalkURL() string {
+	return cmp.Or(os.Getenv("CATWALK_URL"), defaultCatwalkURL)
+}
+
 // UpdateProviders updates the Catwalk providers list from a specified source.
 func UpdateProviders(pathOrURL string) error {
 	var providers []catwalk.Provider
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/charmbracelet/crush/internal/env"
+	"github.com/charmbracelet/crush/internal/keychain"
 	"github.com/charmbracelet/crush/internal/shell"
 )
 
@@ -34,10 +35,21 @@ func NewShellVariableResolver(env env.Env) VariableResolver {
 	}
 }
 
+// keychainPrefix marks the ${...} references to secrets kept in the keychain
+// of the operating system.
+const keychainPrefix = "keychain:"
+
+// KeychainRef returns the reference to the secret with the name in the
+// keychain of the operating system.
+func KeychainRef(name string) string {
+	return "${" + keychainPrefix + name + "}"
+}
+
 // ResolveValue is a method for resolving values, such as environment variables.
 // it will resolve shell-like variable substitution anywhere in the string, including:
 // - $(command) for command substitution
 // - $VAR or ${VAR} for environment variables
+// - ${keychain:NAME} for secrets kept in the keychain of the operating system
 func (r *shellVariableResolver) ResolveValue(value string) (string, error) {
 	// Special case: lone $ is an error (backward compatibility)
 	if value == "$" {
@@ -139,9 +151,18 @@ func (r *shellVariableResolver) ResolveValue(value string) (string, error) {
 			varName = result[start+1 : end]
 		}
 
-		envValue := r.env.Get(varName)
-		if envValue == "" {
-			return "", fmt.Errorf("environment variable %q not set", varName)
+		var envValue string
+		if name, ok := strings.CutPrefix(varName, keychainPrefix); ok {
+			secret, err := keychain.Get(name)
+			if err != nil {
+				return "", fmt.Errorf("failed to read %q from the keychain: %w", name, err)
+			}
+			envValue = secret
+		} else {
+			envValue = r.env.Get(varName)
+			if envValue == "" {
+				return "", fmt.Errorf("environment variable %q not set", varName)
+			}
 		}
 
 		result = result[:start] + envValue + result[end:]
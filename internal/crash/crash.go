@@ -0,0 +1,179 @@
+// Package crash writes crash report bundles when the TUI panics and remembers
+// the session that was open so it can be reopened on the next start.
+package crash
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/redact"
+)
+
+const (
+	dirName     = "crashes"
+	pendingName = "pending.json"
+
+	// maxLogSize is how much of the end of the log file goes in a bundle.
+	maxLogSize = 1 << 20
+)
+
+// Report describes a crash.
+type Report struct {
+	Time    time.Time `json:"time"`
+	Version string    `json:"version"`
+	Panic   string    `json:"panic"`
+	Stack   string    `json:"stack,omitempty"`
+	// SessionID is the session that was open when Crush crashed.
+	SessionID string `json:"session_id,omitempty"`
+	// Dir is the directory the crash bundle was written to.
+	Dir string `json:"dir,omitempty"`
+}
+
+// Write writes a crash bundle to a new directory in the crashes folder of the
+// data directory. The bundle holds the report with the stack trace, the end of
+// the log file and the configuration, all with secrets redacted. The crash is
+// also marked as pending, so the next start can offer to reopen the session.
+// The redactor masks the secrets of the report and the logs, as configured.
+func Write(dataDir string, r Report, cfg any, redactor *redact.Redactor) (string, error) {
+	r.Dir = filepath.Join(dataDir, dirName, r.Time.Format("20060102-150405"))
+	if err := os.MkdirAll(r.Dir, 0o700); err != nil {
+		return "", fmt.Errorf("failed to create crash directory: %w", err)
+	}
+
+	report := fmt.Sprintf(
+		"Crush %s crashed at %s\n\nSession: %s\n\nPanic: %s\n\nStack Trace:\n%s\n",
+		r.Version,
+		r.Time.Format(time.RFC3339),
+		r.SessionID,
+		r.Panic,
+		r.Stack,
+	)
+	report, _ = redactor.Redact(report)
+	if err := os.WriteFile(filepath.Join(r.Dir, "report.txt"), []byte(report), 0o600); err != nil {
+		return "", fmt.Errorf("failed to write crash report: %w", err)
+	}
+
+	// The logs and config are nice to have, so failing to collect them
+	// doesn't fail the whole bundle.
+	var errs []error
+	if logs, err := tail(filepath.Join(dataDir, "logs", "crush.log"), maxLogSize); err == nil {
+		logs, _ = redactor.Redact(logs)
+		errs = append(errs, os.WriteFile(filepath.Join(r.Dir, "crush.log"), []byte(logs), 0o600))
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		errs = append(errs, err)
+	}
+	if cfg != nil {
+		data, err := redactConfig(cfg)
+		if err == nil {
+			data, _ = redactor.Redact(data)
+			err = os.WriteFile(filepath.Join(r.Dir, "config.json"), []byte(data), 0o600)
+		}
+		errs = append(errs, err)
+	}
+
+	pending, err := json.Marshal(r)
+	if err != nil {
+		return r.Dir, err
+	}
+	errs = append(errs, os.WriteFile(filepath.Join(dataDir, dirName, pendingName), pending, 0o600))
+	return r.Dir, errors.Join(errs...)
+}
+
+// TakePending returns the last crash that hasn't been reported to the user
+// yet and clears it, or nil when there's none.
+func TakePending(dataDir string) (*Report, error) {
+	path := filepath.Join(dataDir, dirName, pendingName)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Remove(path); err != nil {
+		return nil, err
+	}
+	var r Report
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("failed to read pending crash: %w", err)
+	}
+	return &r, nil
+}
+
+// tail returns up to limit bytes from the end of the file, starting at a line
+// boundary.
+func tail(path string, limit int64) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	offset := max(0, fi.Size()-limit)
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return "", err
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return "", err
+	}
+	content := string(data)
+	if offset > 0 {
+		if nl := strings.IndexByte(content, '\n'); nl >= 0 {
+			content = content[nl+1:]
+		}
+	}
+	return content, nil
+}
+
+// sensitiveKey matches configuration keys whose values are always masked,
+// whatever they look like.
+var sensitiveKey = regexp.MustCompile(`(?i)^(api_?key|.*token|.*secret|.*password|authorization|oauth|.*headers|extra_body|env)$`)
+
+// redactConfig encodes cfg as indented JSON with the values of sensitive
+// keys masked.
+func redactConfig(cfg any) (string, error) {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return "", err
+	}
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return "", err
+	}
+	data, err = json.MarshalIndent(maskSensitive(v), "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func maskSensitive(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, val := range v {
+			if sensitiveKey.MatchString(k) && val != nil && val != "" {
+				v[k] = "[REDACTED]"
+				continue
+			}
+			v[k] = maskSensitive(val)
+		}
+	case []any:
+		for i, val := range v {
+			v[i] = maskSensitive(val)
+		}
+	}
+	return v
+}
@@ -0,0 +1,71 @@
+package crash
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/redact"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteAndTakePending(t *testing.T) {
+	t.Parallel()
+
+	dataDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dataDir, "logs"), 0o700))
+	logs := strings.Repeat("older line\n", 200_000) + `{"msg":"request","key":"sk-ant-REDACTED"}` + "\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dataDir, "logs", "crush.log"), []byte(logs), 0o600))
+
+	cfg := map[string]any{
+		"providers": map[string]any{
+			"anthropic": map[string]any{"api_key": "not-a-real-key", "base_url": "https://api.anthropic.com"},
+		},
+		"mcp": map[string]any{
+			"github": map[string]any{"headers": map[string]string{"Authorization": "Bearer xyz"}},
+		},
+		"models": map[string]any{"large": map[string]any{"max_tokens": 4096}},
+	}
+
+	redactor, err := redact.New(nil, nil)
+	require.NoError(t, err)
+	dir, err := Write(dataDir, Report{
+		Time:      time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC),
+		Version:   "v1.0.0",
+		Panic:     "runtime error: index out of range",
+		Stack:     "goroutine 1 [running]:",
+		SessionID: "session-1",
+	}, cfg, redactor)
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dataDir, "crashes", "20261016-120000"), dir)
+
+	report, err := os.ReadFile(filepath.Join(dir, "report.txt"))
+	require.NoError(t, err)
+	require.Contains(t, string(report), "runtime error: index out of range")
+	require.Contains(t, string(report), "goroutine 1 [running]:")
+
+	bundleLogs, err := os.ReadFile(filepath.Join(dir, "crush.log"))
+	require.NoError(t, err)
+	require.LessOrEqual(t, len(bundleLogs), maxLogSize)
+	require.True(t, strings.HasPrefix(string(bundleLogs), "older line\n"))
+	require.NotContains(t, string(bundleLogs), "sk-ant-REDACTED")
+
+	config, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	require.NoError(t, err)
+	require.NotContains(t, string(config), "not-a-real-key")
+	require.NotContains(t, string(config), "Bearer xyz")
+	require.Contains(t, string(config), "https://api.anthropic.com")
+	require.Contains(t, string(config), "4096")
+
+	pending, err := TakePending(dataDir)
+	require.NoError(t, err)
+	require.NotNil(t, pending)
+	require.Equal(t, "session-1", pending.SessionID)
+	require.Equal(t, dir, pending.Dir)
+
+	pending, err = TakePending(dataDir)
+	require.NoError(t, err)
+	require.Nil(t, pending)
+}
@@ -60,6 +60,9 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.getSessionByIDStmt, err = db.PrepareContext(ctx, getSessionByID); err != nil {
 		return nil, fmt.Errorf("error preparing query GetSessionByID: %w", err)
 	}
+	if q.listChildSessionsStmt, err = db.PrepareContext(ctx, listChildSessions); err != nil {
+		return nil, fmt.Errorf("error preparing query ListChildSessions: %w", err)
+	}
 	if q.listFilesByPathStmt, err = db.PrepareContext(ctx, listFilesByPath); err != nil {
 		return nil, fmt.Errorf("error preparing query ListFilesByPath: %w", err)
 	}
@@ -81,12 +84,27 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.updateMessageStmt, err = db.PrepareContext(ctx, updateMessage); err != nil {
 		return nil, fmt.Errorf("error preparing query UpdateMessage: %w", err)
 	}
+	if q.updateMessageExcludedStmt, err = db.PrepareContext(ctx, updateMessageExcluded); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateMessageExcluded: %w", err)
+	}
 	if q.updateSessionStmt, err = db.PrepareContext(ctx, updateSession); err != nil {
 		return nil, fmt.Errorf("error preparing query UpdateSession: %w", err)
 	}
+	if q.updateSessionExplainModeStmt, err = db.PrepareContext(ctx, updateSessionExplainMode); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateSessionExplainMode: %w", err)
+	}
+	if q.updateSessionShellProfileStmt, err = db.PrepareContext(ctx, updateSessionShellProfile); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateSessionShellProfile: %w", err)
+	}
 	if q.updateSessionTitleAndUsageStmt, err = db.PrepareContext(ctx, updateSessionTitleAndUsage); err != nil {
 		return nil, fmt.Errorf("error preparing query UpdateSessionTitleAndUsage: %w", err)
 	}
+	if q.updateSessionUIStateStmt, err = db.PrepareContext(ctx, updateSessionUIState); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateSessionUIState: %w", err)
+	}
+	if q.updateSessionWorkingDirStmt, err = db.PrepareContext(ctx, updateSessionWorkingDir); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateSessionWorkingDir: %w", err)
+	}
 	return &q, nil
 }
 
@@ -152,6 +170,11 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing getSessionByIDStmt: %w", cerr)
 		}
 	}
+	if q.listChildSessionsStmt != nil {
+		if cerr := q.listChildSessionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listChildSessionsStmt: %w", cerr)
+		}
+	}
 	if q.listFilesByPathStmt != nil {
 		if cerr := q.listFilesByPathStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing listFilesByPathStmt: %w", cerr)
@@ -187,16 +210,41 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing updateMessageStmt: %w", cerr)
 		}
 	}
+	if q.updateMessageExcludedStmt != nil {
+		if cerr := q.updateMessageExcludedStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateMessageExcludedStmt: %w", cerr)
+		}
+	}
 	if q.updateSessionStmt != nil {
 		if cerr := q.updateSessionStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing updateSessionStmt: %w", cerr)
 		}
 	}
+	if q.updateSessionExplainModeStmt != nil {
+		if cerr := q.updateSessionExplainModeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateSessionExplainModeStmt: %w", cerr)
+		}
+	}
+	if q.updateSessionShellProfileStmt != nil {
+		if cerr := q.updateSessionShellProfileStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateSessionShellProfileStmt: %w", cerr)
+		}
+	}
 	if q.updateSessionTitleAndUsageStmt != nil {
 		if cerr := q.updateSessionTitleAndUsageStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing updateSessionTitleAndUsageStmt: %w", cerr)
 		}
 	}
+	if q.updateSessionUIStateStmt != nil {
+		if cerr := q.updateSessionUIStateStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateSessionUIStateStmt: %w", cerr)
+		}
+	}
+	if q.updateSessionWorkingDirStmt != nil {
+		if cerr := q.updateSessionWorkingDirStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateSessionWorkingDirStmt: %w", cerr)
+		}
+	}
 	return err
 }
 
@@ -248,6 +296,7 @@ type Queries struct {
 	getFileByPathAndSessionStmt    *sql.Stmt
 	getMessageStmt                 *sql.Stmt
 	getSessionByIDStmt             *sql.Stmt
+	listChildSessionsStmt          *sql.Stmt
 	listFilesByPathStmt            *sql.Stmt
 	listFilesBySessionStmt         *sql.Stmt
 	listLatestSessionFilesStmt     *sql.Stmt
@@ -255,8 +304,13 @@ type Queries struct {
 	listNewFilesStmt               *sql.Stmt
 	listSessionsStmt               *sql.Stmt
 	updateMessageStmt              *sql.Stmt
+	updateMessageExcludedStmt      *sql.Stmt
 	updateSessionStmt              *sql.Stmt
+	updateSessionExplainModeStmt   *sql.Stmt
+	updateSessionShellProfileStmt  *sql.Stmt
 	updateSessionTitleAndUsageStmt *sql.Stmt
+	updateSessionUIStateStmt       *sql.Stmt
+	updateSessionWorkingDirStmt    *sql.Stmt
 }
 
 func (q *Queries) WithTx(tx *sql.Tx) *Queries {
@@ -275,6 +329,7 @@ func (q *Queries) WithTx(tx *sql.Tx) *Queries {
 		getFileByPathAndSessionStmt:    q.getFileByPathAndSessionStmt,
 		getMessageStmt:                 q.getMessageStmt,
 		getSessionByIDStmt:             q.getSessionByIDStmt,
+		listChildSessionsStmt:          q.listChildSessionsStmt,
 		listFilesByPathStmt:            q.listFilesByPathStmt,
 		listFilesBySessionStmt:         q.listFilesBySessionStmt,
 		listLatestSessionFilesStmt:     q.listLatestSessionFilesStmt,
@@ -282,7 +337,12 @@ func (q *Queries) WithTx(tx *sql.Tx) *Queries {
 		listNewFilesStmt:               q.listNewFilesStmt,
 		listSessionsStmt:               q.listSessionsStmt,
 		updateMessageStmt:              q.updateMessageStmt,
+		updateMessageExcludedStmt:      q.updateMessageExcludedStmt,
 		updateSessionStmt:              q.updateSessionStmt,
+		updateSessionExplainModeStmt:   q.updateSessionExplainModeStmt,
+		updateSessionShellProfileStmt:  q.updateSessionShellProfileStmt,
 		updateSessionTitleAndUsageStmt: q.updateSessionTitleAndUsageStmt,
+		updateSessionUIStateStmt:       q.updateSessionUIStateStmt,
+		updateSessionWorkingDirStmt:    q.updateSessionWorkingDirStmt,
 	}
 }
@@ -24,7 +24,7 @@ INSERT INTO messages (
 ) VALUES (
     ?, ?, ?, ?, ?, ?, ?, strftime('%s', 'now'), strftime('%s', 'now')
 )
-RETURNING id, session_id, role, parts, model, created_at, updated_at, finished_at, provider, is_summary_message
+RETURNING id, session_id, role, parts, model, created_at, updated_at, finished_at, provider, is_summary_message, excluded
 `
 
 type CreateMessageParams struct {
@@ -59,6 +59,7 @@ func (q *Queries) CreateMessage(ctx context.Context, arg CreateMessageParams) (M
 		&i.FinishedAt,
 		&i.Provider,
 		&i.IsSummaryMessage,
+		&i.Excluded,
 	)
 	return i, err
 }
@@ -84,7 +85,7 @@ func (q *Queries) DeleteSessionMessages(ctx context.Context, sessionID string) e
 }
 
 const getMessage = `-- name: GetMessage :one
-SELECT id, session_id, role, parts, model, created_at, updated_at, finished_at, provider, is_summary_message
+SELECT id, session_id, role, parts, model, created_at, updated_at, finished_at, provider, is_summary_message, excluded
 FROM messages
 WHERE id = ? LIMIT 1
 `
@@ -103,12 +104,13 @@ func (q *Queries) GetMessage(ctx context.Context, id string) (Message, error) {
 		&i.FinishedAt,
 		&i.Provider,
 		&i.IsSummaryMessage,
+		&i.Excluded,
 	)
 	return i, err
 }
 
 const listMessagesBySession = `-- name: ListMessagesBySession :many
-SELECT id, session_id, role, parts, model, created_at, updated_at, finished_at, provider, is_summary_message
+SELECT id, session_id, role, parts, model, created_at, updated_at, finished_at, provider, is_summary_message, excluded
 FROM messages
 WHERE session_id = ?
 ORDER BY created_at ASC
@@ -134,6 +136,7 @@ func (q *Queries) ListMessagesBySession(ctx context.Context, sessionID string) (
 			&i.FinishedAt,
 			&i.Provider,
 			&i.IsSummaryMessage,
+			&i.Excluded,
 		); err != nil {
 			return nil, err
 		}
@@ -167,3 +170,19 @@ func (q *Queries) UpdateMessage(ctx context.Context, arg UpdateMessageParams) er
 	_, err := q.exec(ctx, q.updateMessageStmt, updateMessage, arg.Parts, arg.FinishedAt, arg.ID)
 	return err
 }
+
+const updateMessageExcluded = `-- name: UpdateMessageExcluded :exec
+UPDATE messages
+SET excluded = ?
+WHERE id = ?
+`
+
+type UpdateMessageExcludedParams struct {
+	Excluded int64  `json:"excluded"`
+	ID       string `json:"id"`
+}
+
+func (q *Queries) UpdateMessageExcluded(ctx context.Context, arg UpdateMessageExcludedParams) error {
+	_, err := q.exec(ctx, q.updateMessageExcludedStmt, updateMessageExcluded, arg.Excluded, arg.ID)
+	return err
+}
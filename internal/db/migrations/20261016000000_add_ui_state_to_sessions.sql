@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE sessions ADD COLUMN draft TEXT;
+ALTER TABLE sessions ADD COLUMN scroll_offset INTEGER NOT NULL DEFAULT 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE sessions DROP COLUMN scroll_offset;
+ALTER TABLE sessions DROP COLUMN draft;
+-- +goose StatementEnd
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE sessions ADD COLUMN shell_profile TEXT;
+
+-- +goose Down
+ALTER TABLE sessions DROP COLUMN shell_profile;
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE sessions ADD COLUMN explain_mode INTEGER DEFAULT 0 NOT NULL;
+
+-- +goose Down
+ALTER TABLE sessions DROP COLUMN explain_mode;
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE sessions ADD COLUMN total_prompt_tokens INTEGER DEFAULT 0 NOT NULL;
+ALTER TABLE sessions ADD COLUMN total_completion_tokens INTEGER DEFAULT 0 NOT NULL;
+
+-- +goose Down
+ALTER TABLE sessions DROP COLUMN total_completion_tokens;
+ALTER TABLE sessions DROP COLUMN total_prompt_tokens;
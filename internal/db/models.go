@@ -29,18 +29,27 @@ type Message struct {
 	FinishedAt       sql.NullInt64  `json:"finished_at"`
 	Provider         sql.NullString `json:"provider"`
 	IsSummaryMessage int64          `json:"is_summary_message"`
+	Excluded         int64          `json:"excluded"`
 }
 
 type Session struct {
-	ID               string         `json:"id"`
-	ParentSessionID  sql.NullString `json:"parent_session_id"`
-	Title            string         `json:"title"`
-	MessageCount     int64          `json:"message_count"`
-	PromptTokens     int64          `json:"prompt_tokens"`
-	CompletionTokens int64          `json:"completion_tokens"`
-	Cost             float64        `json:"cost"`
-	UpdatedAt        int64          `json:"updated_at"`
-	CreatedAt        int64          `json:"created_at"`
-	SummaryMessageID sql.NullString `json:"summary_message_id"`
-	Todos            sql.NullString `json:"todos"`
+	ID                    string         `json:"id"`
+	ParentSessionID       sql.NullString `json:"parent_session_id"`
+	Title                 string         `json:"title"`
+	MessageCount          int64          `json:"message_count"`
+	PromptTokens          int64          `json:"prompt_tokens"`
+	CompletionTokens      int64          `json:"completion_tokens"`
+	Cost                  float64        `json:"cost"`
+	UpdatedAt             int64          `json:"updated_at"`
+	CreatedAt             int64          `json:"created_at"`
+	SummaryMessageID      sql.NullString `json:"summary_message_id"`
+	Todos                 sql.NullString `json:"todos"`
+	Draft                 sql.NullString `json:"draft"`
+	ScrollOffset          int64          `json:"scroll_offset"`
+	UiState               sql.NullString `json:"ui_state"`
+	ShellProfile          sql.NullString `json:"shell_profile"`
+	WorkingDir            sql.NullString `json:"working_dir"`
+	ExplainMode           int64          `json:"explain_mode"`
+	TotalPromptTokens     int64          `json:"total_prompt_tokens"`
+	TotalCompletionTokens int64          `json:"total_completion_tokens"`
 }
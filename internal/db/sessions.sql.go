@@ -33,7 +33,7 @@ INSERT INTO sessions (
     null,
     strftime('%s', 'now'),
     strftime('%s', 'now')
-) RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos
+) RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, draft, scroll_offset, ui_state, shell_profile, working_dir, explain_mode, total_prompt_tokens, total_completion_tokens
 `
 
 type CreateSessionParams struct {
@@ -69,6 +69,14 @@ func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (S
 		&i.CreatedAt,
 		&i.SummaryMessageID,
 		&i.Todos,
+		&i.Draft,
+		&i.ScrollOffset,
+		&i.UiState,
+		&i.ShellProfile,
+		&i.WorkingDir,
+		&i.ExplainMode,
+		&i.TotalPromptTokens,
+		&i.TotalCompletionTokens,
 	)
 	return i, err
 }
@@ -84,7 +92,7 @@ func (q *Queries) DeleteSession(ctx context.Context, id string) error {
 }
 
 const getSessionByID = `-- name: GetSessionByID :one
-SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos
+SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, draft, scroll_offset, ui_state, shell_profile, working_dir, explain_mode, total_prompt_tokens, total_completion_tokens
 FROM sessions
 WHERE id = ? LIMIT 1
 `
@@ -104,12 +112,70 @@ func (q *Queries) GetSessionByID(ctx context.Context, id string) (Session, error
 		&i.CreatedAt,
 		&i.SummaryMessageID,
 		&i.Todos,
+		&i.Draft,
+		&i.ScrollOffset,
+		&i.UiState,
+		&i.ShellProfile,
+		&i.WorkingDir,
+		&i.ExplainMode,
+		&i.TotalPromptTokens,
+		&i.TotalCompletionTokens,
 	)
 	return i, err
 }
 
+const listChildSessions = `-- name: ListChildSessions :many
+SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, draft, scroll_offset, ui_state, shell_profile, working_dir, explain_mode, total_prompt_tokens, total_completion_tokens
+FROM sessions
+WHERE parent_session_id = ?
+ORDER BY created_at ASC
+`
+
+func (q *Queries) ListChildSessions(ctx context.Context, parentSessionID sql.NullString) ([]Session, error) {
+	rows, err := q.query(ctx, q.listChildSessionsStmt, listChildSessions, parentSessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Session{}
+	for rows.Next() {
+		var i Session
+		if err := rows.Scan(
+			&i.ID,
+			&i.ParentSessionID,
+			&i.Title,
+			&i.MessageCount,
+			&i.PromptTokens,
+			&i.CompletionTokens,
+			&i.Cost,
+			&i.UpdatedAt,
+			&i.CreatedAt,
+			&i.SummaryMessageID,
+			&i.Todos,
+			&i.Draft,
+			&i.ScrollOffset,
+			&i.UiState,
+			&i.ShellProfile,
+			&i.WorkingDir,
+			&i.ExplainMode,
+			&i.TotalPromptTokens,
+			&i.TotalCompletionTokens,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listSessions = `-- name: ListSessions :many
-SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos
+SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, draft, scroll_offset, ui_state, shell_profile, working_dir, explain_mode, total_prompt_tokens, total_completion_tokens
 FROM sessions
 WHERE parent_session_id is NULL
 ORDER BY updated_at DESC
@@ -136,6 +202,14 @@ func (q *Queries) ListSessions(ctx context.Context) ([]Session, error) {
 			&i.CreatedAt,
 			&i.SummaryMessageID,
 			&i.Todos,
+			&i.Draft,
+			&i.ScrollOffset,
+			&i.UiState,
+			&i.ShellProfile,
+			&i.WorkingDir,
+			&i.ExplainMode,
+			&i.TotalPromptTokens,
+			&i.TotalCompletionTokens,
 		); err != nil {
 			return nil, err
 		}
@@ -158,19 +232,23 @@ SET
     completion_tokens = ?,
     summary_message_id = ?,
     cost = ?,
+    total_prompt_tokens = ?,
+    total_completion_tokens = ?,
     todos = ?
 WHERE id = ?
-RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos
+RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, draft, scroll_offset, ui_state, shell_profile, working_dir, explain_mode, total_prompt_tokens, total_completion_tokens
 `
 
 type UpdateSessionParams struct {
-	Title            string         `json:"title"`
-	PromptTokens     int64          `json:"prompt_tokens"`
-	CompletionTokens int64          `json:"completion_tokens"`
-	SummaryMessageID sql.NullString `json:"summary_message_id"`
-	Cost             float64        `json:"cost"`
-	Todos            sql.NullString `json:"todos"`
-	ID               string         `json:"id"`
+	Title                 string         `json:"title"`
+	PromptTokens          int64          `json:"prompt_tokens"`
+	CompletionTokens      int64          `json:"completion_tokens"`
+	SummaryMessageID      sql.NullString `json:"summary_message_id"`
+	Cost                  float64        `json:"cost"`
+	TotalPromptTokens     int64          `json:"total_prompt_tokens"`
+	TotalCompletionTokens int64          `json:"total_completion_tokens"`
+	Todos                 sql.NullString `json:"todos"`
+	ID                    string         `json:"id"`
 }
 
 func (q *Queries) UpdateSession(ctx context.Context, arg UpdateSessionParams) (Session, error) {
@@ -180,6 +258,8 @@ func (q *Queries) UpdateSession(ctx context.Context, arg UpdateSessionParams) (S
 		arg.CompletionTokens,
 		arg.SummaryMessageID,
 		arg.Cost,
+		arg.TotalPromptTokens,
+		arg.TotalCompletionTokens,
 		arg.Todos,
 		arg.ID,
 	)
@@ -196,6 +276,14 @@ func (q *Queries) UpdateSession(ctx context.Context, arg UpdateSessionParams) (S
 		&i.CreatedAt,
 		&i.SummaryMessageID,
 		&i.Todos,
+		&i.Draft,
+		&i.ScrollOffset,
+		&i.UiState,
+		&i.ShellProfile,
+		&i.WorkingDir,
+		&i.ExplainMode,
+		&i.TotalPromptTokens,
+		&i.TotalCompletionTokens,
 	)
 	return i, err
 }
@@ -206,16 +294,20 @@ SET
     title = ?,
     prompt_tokens = prompt_tokens + ?,
     completion_tokens = completion_tokens + ?,
-    cost = cost + ?
+    cost = cost + ?,
+    total_prompt_tokens = total_prompt_tokens + ?,
+    total_completion_tokens = total_completion_tokens + ?
 WHERE id = ?
 `
 
 type UpdateSessionTitleAndUsageParams struct {
-	Title            string  `json:"title"`
-	PromptTokens     int64   `json:"prompt_tokens"`
-	CompletionTokens int64   `json:"completion_tokens"`
-	Cost             float64 `json:"cost"`
-	ID               string  `json:"id"`
+	Title                 string  `json:"title"`
+	PromptTokens          int64   `json:"prompt_tokens"`
+	CompletionTokens      int64   `json:"completion_tokens"`
+	Cost                  float64 `json:"cost"`
+	TotalPromptTokens     int64   `json:"total_prompt_tokens"`
+	TotalCompletionTokens int64   `json:"total_completion_tokens"`
+	ID                    string  `json:"id"`
 }
 
 func (q *Queries) UpdateSessionTitleAndUsage(ctx context.Context, arg UpdateSessionTitleAndUsageParams) error {
@@ -224,6 +316,91 @@ func (q *Queries) UpdateSessionTitleAndUsage(ctx context.Context, arg UpdateSess
 		arg.PromptTokens,
 		arg.CompletionTokens,
 		arg.Cost,
+		arg.TotalPromptTokens,
+		arg.TotalCompletionTokens,
+		arg.ID,
+	)
+	return err
+}
+
+const updateSessionUIState = `-- name: UpdateSessionUIState :exec
+UPDATE sessions
+SET
+    draft = ?,
+    scroll_offset = ?,
+    ui_state = ?
+WHERE id = ?
+`
+
+type UpdateSessionUIStateParams struct {
+	Draft        sql.NullString `json:"draft"`
+	ScrollOffset int64          `json:"scroll_offset"`
+	UiState      sql.NullString `json:"ui_state"`
+	ID           string         `json:"id"`
+}
+
+func (q *Queries) UpdateSessionUIState(ctx context.Context, arg UpdateSessionUIStateParams) error {
+	_, err := q.exec(ctx, q.updateSessionUIStateStmt, updateSessionUIState,
+		arg.Draft,
+		arg.ScrollOffset,
+		arg.UiState,
+		arg.ID,
+	)
+	return err
+}
+
+const updateSessionExplainMode = `-- name: UpdateSessionExplainMode :exec
+UPDATE sessions
+SET explain_mode = ?
+WHERE id = ?
+`
+
+type UpdateSessionExplainModeParams struct {
+	ExplainMode int64  `json:"explain_mode"`
+	ID          string `json:"id"`
+}
+
+func (q *Queries) UpdateSessionExplainMode(ctx context.Context, arg UpdateSessionExplainModeParams) error {
+	_, err := q.exec(ctx, q.updateSessionExplainModeStmt, updateSessionExplainMode,
+		arg.ExplainMode,
+		arg.ID,
+	)
+	return err
+}
+
+const updateSessionShellProfile = `-- name: UpdateSessionShellProfile :exec
+UPDATE sessions
+SET shell_profile = ?
+WHERE id = ?
+`
+
+type UpdateSessionShellProfileParams struct {
+	ShellProfile sql.NullString `json:"shell_profile"`
+	ID           string         `json:"id"`
+}
+
+func (q *Queries) UpdateSessionShellProfile(ctx context.Context, arg UpdateSessionShellProfileParams) error {
+	_, err := q.exec(ctx, q.updateSessionShellProfileStmt, updateSessionShellProfile,
+		arg.ShellProfile,
+		arg.ID,
+	)
+	return err
+}
+
+const updateSessionWorkingDir = `-- name: UpdateSessionWorkingDir :exec
+UPDATE sessions
+SET working_dir = ?
+WHERE id = ?
+`
+
+type UpdateSessionWorkingDirParams struct {
+	WorkingDir sql.NullString `json:"working_dir"`
+	ID         string         `json:"id"`
+}
+
+func (q *Queries) UpdateSessionWorkingDir(ctx context.Context, arg UpdateSessionWorkingDirParams) error {
+	_, err := q.exec(ctx, q.updateSessionWorkingDirStmt, updateSessionWorkingDir,
+		arg.WorkingDir,
 		arg.ID,
 	)
 	return err
@@ -33,6 +33,10 @@ SET
     updated_at = strftime('%s', 'now')
 WHERE id = ?;
 
+-- name: UpdateMessageExcluded :exec
+UPDATE messages
+SET excluded = ?
+WHERE id = ?;
 
 -- name: DeleteMessage :exec
 DELETE FROM messages
@@ -28,6 +28,12 @@ SELECT *
 FROM sessions
 WHERE id = ? LIMIT 1;
 
+-- name: ListChildSessions :many
+SELECT *
+FROM sessions
+WHERE parent_session_id = ?
+ORDER BY created_at ASC;
+
 -- name: ListSessions :many
 SELECT *
 FROM sessions
@@ -42,6 +48,8 @@ SET
     completion_tokens = ?,
     summary_message_id = ?,
     cost = ?,
+    total_prompt_tokens = ?,
+    total_completion_tokens = ?,
     todos = ?
 WHERE id = ?
 RETURNING *;
@@ -52,10 +60,35 @@ SET
     title = ?,
     prompt_tokens = prompt_tokens + ?,
     completion_tokens = completion_tokens + ?,
-    cost = cost + ?
+    cost = cost + ?,
+    total_prompt_tokens = total_prompt_tokens + ?,
+    total_completion_tokens = total_completion_tokens + ?
 WHERE id = ?;
 
 
 -- name: DeleteSession :exec
 DELETE FROM sessions
 WHERE id = ?;
+
+-- name: UpdateSessionUIState :exec
+UPDATE sessions
+SET
+    draft = ?,
+    scroll_offset = ?,
+    ui_state = ?
+WHERE id = ?;
+
+-- name: UpdateSessionExplainMode :exec
+UPDATE sessions
+SET explain_mode = ?
+WHERE id = ?;
+
+-- name: UpdateSessionShellProfile :exec
+UPDATE sessions
+SET shell_profile = ?
+WHERE id = ?;
+
+-- name: UpdateSessionWorkingDir :exec
+UPDATE sessions
+SET working_dir = ?
+WHERE id = ?;
@@ -0,0 +1,197 @@
+// Package diagram finds the Mermaid and PlantUML diagrams in Markdown, and
+// renders them to images with the tools installed, or with a Kroki server.
+package diagram
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Kind is the language a diagram is written in.
+type Kind string
+
+const (
+	Mermaid  Kind = "mermaid"
+	PlantUML Kind = "plantuml"
+)
+
+// languages maps the languages of code blocks to the kind of diagram they
+// hold.
+var languages = map[string]Kind{
+	"mermaid":  Mermaid,
+	"mmd":      Mermaid,
+	"plantuml": PlantUML,
+	"puml":     PlantUML,
+	"uml":      PlantUML,
+}
+
+// tools are the commands rendering each kind of diagram locally.
+var tools = map[Kind]string{
+	Mermaid:  "mmdc",
+	PlantUML: "plantuml",
+}
+
+// Block is a diagram found in a code block.
+type Block struct {
+	Kind   Kind
+	Source string
+}
+
+// Find returns the diagrams in the fenced code blocks of Markdown text.
+func Find(text string) []Block {
+	var (
+		blocks []Block
+		fence  string
+		kind   Kind
+		source strings.Builder
+	)
+	for line := range strings.SplitSeq(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if fence == "" {
+			if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+				fence = trimmed[:3]
+				lang, _, _ := strings.Cut(strings.TrimSpace(trimmed[3:]), " ")
+				kind = languages[strings.ToLower(lang)]
+				source.Reset()
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, fence) {
+			if kind != "" && strings.TrimSpace(source.String()) != "" {
+				blocks = append(blocks, Block{Kind: kind, Source: source.String()})
+			}
+			fence = ""
+			continue
+		}
+		source.WriteString(line + "\n")
+	}
+	return blocks
+}
+
+// Renderer renders diagrams to PNG images.
+type Renderer struct {
+	// Dir is where the images are written. Diagrams rendered before are
+	// taken from there.
+	Dir string
+	// WebRenderer is the URL of the Kroki server rendering the diagrams
+	// whose tool isn't installed. They aren't sent anywhere when it's empty.
+	WebRenderer string
+}
+
+// Render renders a diagram, and returns the path of its image.
+func (r Renderer) Render(ctx context.Context, b Block) (string, error) {
+	sum := sha256.Sum256([]byte(string(b.Kind) + "\x00" + b.Source))
+	path := filepath.Join(r.Dir, hex.EncodeToString(sum[:8])+".png")
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+	if err := os.MkdirAll(r.Dir, 0o700); err != nil {
+		return "", err
+	}
+
+	var (
+		image []byte
+		err   error
+	)
+	tool, ok := tools[b.Kind]
+	if !ok {
+		return "", fmt.Errorf("unknown kind of diagram: %s", b.Kind)
+	}
+	switch _, lookErr := exec.LookPath(tool); {
+	case lookErr == nil:
+		image, err = renderLocal(ctx, tool, b)
+	case r.WebRenderer != "":
+		image, err = renderWeb(ctx, r.WebRenderer, b)
+	default:
+		return "", fmt.Errorf("%s is not installed, and no web renderer is set to render %s diagrams", tool, b.Kind)
+	}
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, image, 0o600); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// renderLocal renders a diagram with its tool.
+func renderLocal(ctx context.Context, tool string, b Block) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	if b.Kind == Mermaid {
+		// mmdc only writes images to files.
+		dir, err := os.MkdirTemp("", "crush-mermaid-*")
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(dir)
+		out := filepath.Join(dir, "diagram.png")
+		cmd := exec.CommandContext(ctx, tool, "--input", "-", "--output", out, "--backgroundColor", "white")
+		cmd.Stdin = strings.NewReader(b.Source)
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return nil, toolError(tool, err, stderr.String())
+		}
+		return os.ReadFile(out)
+	}
+
+	cmd := exec.CommandContext(ctx, tool, "-tpng", "-pipe")
+	cmd.Stdin = strings.NewReader(b.Source)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, toolError(tool, err, stderr.String())
+	}
+	return stdout.Bytes(), nil
+}
+
+func toolError(tool string, err error, stderr string) error {
+	if stderr = strings.TrimSpace(stderr); stderr != "" {
+		return fmt.Errorf("%s failed: %s", tool, stderr)
+	}
+	return fmt.Errorf("%s failed: %w", tool, err)
+}
+
+// renderWeb renders a diagram with a Kroki server.
+func renderWeb(ctx context.Context, baseURL string, b Block) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	url := strings.TrimSuffix(baseURL, "/") + "/" + string(b.Kind) + "/png"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, strings.NewReader(b.Source))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "text/plain")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render the diagram: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to render the diagram: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		msg := strings.TrimSpace(string(body))
+		if msg == "" {
+			msg = resp.Status
+		}
+		return nil, errors.New("failed to render the diagram: " + msg)
+	}
+	return body, nil
+}
@@ -0,0 +1,61 @@
+package diagram
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFind(t *testing.T) {
+	text := "Here's the flow:\n\n" +
+		"```mermaid\ngraph TD\n  A --> B\n```\n\n" +
+		"```go\nfmt.Println()\n```\n\n" +
+		"~~~puml\n@startuml\nA -> B\n@enduml\n~~~\n\n" +
+		"```mermaid\n```\n"
+	require.Equal(t, []Block{
+		{Kind: Mermaid, Source: "graph TD\n  A --> B\n"},
+		{Kind: PlantUML, Source: "@startuml\nA -> B\n@enduml\n"},
+	}, Find(text))
+	require.Empty(t, Find("No diagrams here."))
+}
+
+func TestRenderWeb(t *testing.T) {
+	// Keep the tools from being found, in case they're installed.
+	t.Setenv("PATH", "")
+
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		body, _ := io.ReadAll(r.Body)
+		if r.URL.Path != "/mermaid/png" || string(body) != "graph TD\n" {
+			http.Error(w, "Syntax error in graph", http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte("PNG"))
+	}))
+	defer server.Close()
+
+	r := Renderer{Dir: t.TempDir(), WebRenderer: server.URL + "/"}
+	path, err := r.Render(t.Context(), Block{Kind: Mermaid, Source: "graph TD\n"})
+	require.NoError(t, err)
+	image, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "PNG", string(image))
+
+	// Diagrams rendered before aren't rendered again.
+	again, err := r.Render(t.Context(), Block{Kind: Mermaid, Source: "graph TD\n"})
+	require.NoError(t, err)
+	require.Equal(t, path, again)
+	require.Equal(t, 1, requests)
+
+	_, err = r.Render(t.Context(), Block{Kind: Mermaid, Source: "graph\n"})
+	require.ErrorContains(t, err, "Syntax error in graph")
+
+	r.WebRenderer = ""
+	_, err = r.Render(t.Context(), Block{Kind: PlantUML, Source: "@startuml\n"})
+	require.ErrorContains(t, err, "plantuml is not installed")
+}
@@ -1,14 +1,18 @@
 package diff
 
 import (
+	"path/filepath"
 	"strings"
 
 	"github.com/aymanbagabas/go-udiff"
 )
 
-// GenerateDiff creates a unified diff from two file contents
+// GenerateDiff creates a unified diff from two file contents. Line endings
+// are normalized first so CRLF files don't show every line as changed.
 func GenerateDiff(beforeContent, afterContent, fileName string) (string, int, int) {
-	fileName = strings.TrimPrefix(fileName, "/")
+	fileName = strings.TrimPrefix(filepath.ToSlash(fileName), "/")
+	beforeContent = strings.ReplaceAll(beforeContent, "\r\n", "\n")
+	afterContent = strings.ReplaceAll(afterContent, "\r\n", "\n")
 
 	var (
 		unified   = udiff.Unified("a/"+fileName, "b/"+fileName, beforeContent, afterContent)
@@ -9,6 +9,7 @@ import (
 // SmartJoin joins two paths, treating the second path as absolute if it is an
 // absolute path.
 func SmartJoin(one, two string) string {
+	two = FromPortable(two)
 	if SmartIsAbs(two) {
 		return two
 	}
@@ -25,3 +26,52 @@ func SmartIsAbs(path string) bool {
 		return filepath.IsAbs(path)
 	}
 }
+
+// FromPortable translates a path written with forward slashes into the native
+// form. On Windows, MSYS style drive paths ("/c/foo") and WSL style drive
+// paths ("/mnt/c/foo") are turned into "C:\foo". On other systems the path is
+// returned unchanged.
+func FromPortable(path string) string {
+	return fromPortable(path, runtime.GOOS)
+}
+
+func fromPortable(path, goos string) string {
+	if goos != "windows" || path == "" {
+		return path
+	}
+	slashed := strings.ReplaceAll(path, `\`, "/")
+	if rest, ok := strings.CutPrefix(slashed, "/mnt/"); ok {
+		if drive, tail, ok := cutDrive(rest); ok {
+			return strings.ToUpper(drive) + ":" + toBackslash(tail)
+		}
+	}
+	if rest, ok := strings.CutPrefix(slashed, "/"); ok && !strings.HasPrefix(rest, "/") {
+		if drive, tail, ok := cutDrive(rest); ok {
+			return strings.ToUpper(drive) + ":" + toBackslash(tail)
+		}
+	}
+	return toBackslash(slashed)
+}
+
+// cutDrive splits "c/foo" into "c" and "/foo". The tail is "/" when the path
+// names the drive itself.
+func cutDrive(path string) (drive, tail string, ok bool) {
+	if len(path) == 0 || !isLetter(path[0]) {
+		return "", "", false
+	}
+	if len(path) == 1 {
+		return path, "/", true
+	}
+	if path[1] != '/' {
+		return "", "", false
+	}
+	return path[:1], path[1:], true
+}
+
+func isLetter(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+func toBackslash(path string) string {
+	return strings.ReplaceAll(path, "/", `\`)
+}
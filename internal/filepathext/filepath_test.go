@@ -0,0 +1,34 @@
+package filepathext
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromPortable(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		path string
+		goos string
+		want string
+	}{
+		{"unix unchanged", "/c/foo", "linux", "/c/foo"},
+		{"msys drive", "/c/foo/bar.go", "windows", `C:\foo\bar.go`},
+		{"msys drive root", "/d", "windows", `D:\`},
+		{"wsl drive", "/mnt/c/Users/me", "windows", `C:\Users\me`},
+		{"forward slashes", "C:/foo/bar", "windows", `C:\foo\bar`},
+		{"relative", "foo/bar", "windows", `foo\bar`},
+		{"not a drive", "/cd/foo", "windows", `\cd\foo`},
+		{"unc", "//server/share", "windows", `\\server\share`},
+		{"empty", "", "windows", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.want, fromPortable(tt.path, tt.goos))
+		})
+	}
+}
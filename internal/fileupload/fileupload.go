@@ -0,0 +1,339 @@
+// Package fileupload uploads the large attachments of requests to the file
+// API of the provider once, and sends a reference to the uploaded file in
+// their place, so long conversations with images and documents don't send
+// the same base64 data again on every turn. The uploads are deleted when the
+// session they were sent in is, or when Crush exits.
+package fileupload
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// MinSize is the size of the smallest attachment uploaded. Smaller ones cost
+// little to send again, and a round trip more to upload.
+const MinSize = 256 << 10
+
+// API is a file API attachments are uploaded to.
+type API string
+
+const (
+	// OpenAI is the files API of OpenAI, referenced by file ID from the
+	// Responses and Chat Completions APIs.
+	OpenAI API = "openai"
+	// Gemini is the files API of the Gemini API, referenced by URI.
+	Gemini API = "gemini"
+)
+
+// For returns the file API of a type of provider, if it has one.
+func For(providerType string) (API, bool) {
+	switch providerType {
+	case "openai":
+		return OpenAI, true
+	case "google":
+		return Gemini, true
+	default:
+		return "", false
+	}
+}
+
+// uploaded is an attachment uploaded to a file API.
+type uploaded struct {
+	// ref is what requests reference the file by.
+	ref string
+	// deleteURL is where the file is deleted.
+	deleteURL string
+}
+
+// file is an attachment uploaded, or about to be, and the sessions it was
+// sent in.
+type file struct {
+	mu       sync.Mutex
+	uploaded uploaded
+	header   http.Header
+	base     http.RoundTripper
+	sessions map[string]bool
+}
+
+var (
+	mu sync.Mutex
+	// files are the attachments uploaded, by the account and the content
+	// they were uploaded with.
+	files = make(map[string]*file)
+)
+
+// Transport replaces the large attachments of the JSON requests it sends
+// with references to them, uploaded to the file API of the provider with the
+// credentials of the request.
+type Transport struct {
+	Base http.RoundTripper
+	api  API
+	// sessionOf returns the session a request is sent for.
+	sessionOf func(context.Context) string
+}
+
+// NewClient returns a client uploading the large attachments of its requests
+// to the file API, sending them with base. sessionOf returns the session a
+// request is sent for, from its context, whose deletion deletes the uploads.
+func NewClient(base http.RoundTripper, api API, sessionOf func(context.Context) string) *http.Client {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &http.Client{Transport: &Transport{Base: base, api: api, sessionOf: sessionOf}}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodPost || req.Body == nil || req.Body == http.NoBody ||
+		req.ContentLength >= 0 && req.ContentLength < MinSize ||
+		!strings.HasPrefix(req.Header.Get("Content-Type"), "application/json") {
+		return t.Base.RoundTrip(req)
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	if rewritten, ok := t.rewrite(req, body); ok {
+		body = rewritten
+	}
+	req = req.Clone(req.Context())
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	req.ContentLength = int64(len(body))
+	req.Header.Del("Content-Length")
+	return t.Base.RoundTrip(req)
+}
+
+// rewrite returns the body with its large attachments replaced, if any were.
+func (t *Transport) rewrite(req *http.Request, body []byte) ([]byte, bool) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, false
+	}
+	ref := func(mediaType string, data []byte) (string, bool) {
+		return t.ref(req, mediaType, data)
+	}
+	var replace func(map[string]any) bool
+	switch t.api {
+	case OpenAI:
+		replace = func(m map[string]any) bool { return replaceOpenAI(m, ref) }
+	case Gemini:
+		replace = func(m map[string]any) bool { return replaceGemini(m, ref) }
+	default:
+		return nil, false
+	}
+	if !walk(v, replace) {
+		return nil, false
+	}
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, false
+	}
+	return bytes.TrimSuffix(b.Bytes(), []byte("\n")), true
+}
+
+// walk calls replace on every object of the value, reporting whether it
+// replaced anything.
+func walk(v any, replace func(map[string]any) bool) bool {
+	changed := false
+	switch v := v.(type) {
+	case map[string]any:
+		changed = replace(v)
+		for _, child := range v {
+			changed = walk(child, replace) || changed
+		}
+	case []any:
+		for _, child := range v {
+			changed = walk(child, replace) || changed
+		}
+	}
+	return changed
+}
+
+// ref returns the reference of an attachment of the request, uploading it
+// the first time it's sent with the credentials of the request. Attachments
+// too small, or failing to upload, are left inline.
+func (t *Transport) ref(req *http.Request, mediaType string, data []byte) (string, bool) {
+	if len(data) < MinSize {
+		return "", false
+	}
+	endpoint, ok := t.endpoint(req)
+	if !ok {
+		return "", false
+	}
+	header := uploadHeader(req)
+	f := lookup(fingerprint(endpoint, header, data))
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.uploaded.ref == "" {
+		var up uploaded
+		var err error
+		switch t.api {
+		case OpenAI:
+			up, err = uploadOpenAI(req.Context(), t.Base, endpoint, header, mediaType, data)
+		case Gemini:
+			up, err = uploadGemini(req.Context(), t.Base, endpoint, header, mediaType, data)
+		}
+		if err != nil {
+			slog.Warn("Failed to upload attachment, sending it inline", "api", t.api, "error", err)
+			// A file uploaded but not usable isn't kept.
+			if up.deleteURL != "" {
+				_ = deleteFile(req.Context(), &file{uploaded: up, header: header, base: t.Base})
+			}
+			return "", false
+		}
+		slog.Debug("Uploaded attachment", "api", t.api, "ref", up.ref, "size", len(data))
+		f.uploaded, f.header, f.base = up, header, t.Base
+	}
+	f.sessions[t.sessionOf(req.Context())] = true
+	return f.uploaded.ref, true
+}
+
+// endpoint returns the URL files are uploaded to for the request.
+func (t *Transport) endpoint(req *http.Request) (string, bool) {
+	switch t.api {
+	case OpenAI:
+		return openaiFilesURL(req.URL)
+	case Gemini:
+		return geminiFilesURL(req.URL)
+	default:
+		return "", false
+	}
+}
+
+// lookup returns the upload of an attachment, adding it when it's new.
+func lookup(key string) *file {
+	mu.Lock()
+	defer mu.Unlock()
+	f, ok := files[key]
+	if !ok {
+		f = &file{sessions: make(map[string]bool)}
+		files[key] = f
+	}
+	return f
+}
+
+// uploadHeader returns the headers of the requests to the file API: those of
+// the request, carrying its credentials, without the ones about its body.
+func uploadHeader(req *http.Request) http.Header {
+	header := req.Header.Clone()
+	for _, name := range []string{"Content-Type", "Content-Length", "Accept", "Accept-Encoding", "Idempotency-Key"} {
+		header.Del(name)
+	}
+	return header
+}
+
+// fingerprint identifies an attachment uploaded to an account, so the same
+// one sent with other credentials is uploaded again where they can see it.
+func fingerprint(endpoint string, header http.Header, data []byte) string {
+	h := sha256.New()
+	h.Write([]byte(endpoint))
+	for _, name := range []string{"Authorization", "Api-Key", "X-Goog-Api-Key", "OpenAI-Organization", "OpenAI-Project"} {
+		h.Write([]byte{0})
+		h.Write([]byte(header.Get(name)))
+	}
+	h.Write([]byte{0})
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// parseDataURL returns the media type and data of a base64 data URL.
+func parseDataURL(s string) (string, []byte, bool) {
+	rest, ok := strings.CutPrefix(s, "data:")
+	if !ok {
+		return "", nil, false
+	}
+	meta, encoded, ok := strings.Cut(rest, ",")
+	if !ok {
+		return "", nil, false
+	}
+	mediaType, ok := strings.CutSuffix(meta, ";base64")
+	if !ok {
+		return "", nil, false
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", nil, false
+	}
+	return mediaType, data, true
+}
+
+// Forget deletes the uploads only sent in the session, as it's gone.
+func Forget(ctx context.Context, sessionID string) error {
+	return remove(ctx, func(f *file) bool {
+		delete(f.sessions, sessionID)
+		return len(f.sessions) == 0
+	})
+}
+
+// Close deletes all the uploads.
+func Close(ctx context.Context) error {
+	return remove(ctx, func(*file) bool { return true })
+}
+
+// remove deletes the uploads done reports true for.
+func remove(ctx context.Context, done func(*file) bool) error {
+	mu.Lock()
+	var gone []*file
+	for key, f := range files {
+		f.mu.Lock()
+		if done(f) {
+			delete(files, key)
+			gone = append(gone, f)
+		}
+		f.mu.Unlock()
+	}
+	mu.Unlock()
+
+	var errs []error
+	for _, f := range gone {
+		if f.uploaded.deleteURL == "" {
+			continue
+		}
+		if err := deleteFile(ctx, f); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func deleteFile(ctx context.Context, f *file) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, f.uploaded.deleteURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header = f.header.Clone()
+	resp, err := f.base.RoundTrip(req)
+	if err != nil {
+		return fmt.Errorf("failed to delete %s: %w", f.uploaded.ref, err)
+	}
+	defer resp.Body.Close()
+	// Files already gone, like Gemini ones past their expiry, are fine.
+	if resp.StatusCode >= 300 && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("failed to delete %s: %s", f.uploaded.ref, resp.Status)
+	}
+	return nil
+}
+
+// apiError returns the error of a failed request to a file API.
+func apiError(action string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("failed to %s: %s: %s", action, resp.Status, bytes.TrimSpace(body))
+}
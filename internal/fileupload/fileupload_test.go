@@ -0,0 +1,121 @@
+package fileupload
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type sessionKey struct{}
+
+func sessionOf(ctx context.Context) string {
+	id, _ := ctx.Value(sessionKey{}).(string)
+	return id
+}
+
+// server is a fake provider recording the requests it gets.
+type server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	uploads  int
+	deleted  []string
+	requests []string
+}
+
+func newServer(t *testing.T) *server {
+	s := &server{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		require.Equal(t, "Bearer key", r.Header.Get("Authorization"))
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/files":
+			require.NoError(t, r.ParseMultipartForm(1<<20))
+			require.Equal(t, "vision", r.FormValue("purpose"))
+			require.Equal(t, "86400", r.FormValue("expires_after[seconds]"))
+			s.uploads++
+			_, _ = w.Write([]byte(`{"id":"file-1"}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/upload/v1beta/files":
+			w.Header().Set("X-Goog-Upload-URL", s.URL+"/upload/session")
+		case r.Method == http.MethodPost && r.URL.Path == "/upload/session":
+			s.uploads++
+			_, _ = w.Write([]byte(`{"file":{"name":"files/abc","uri":"https://files/abc","state":"ACTIVE"}}`))
+		case r.Method == http.MethodDelete:
+			s.deleted = append(s.deleted, r.URL.Path)
+		default:
+			body, _ := io.ReadAll(r.Body)
+			s.requests = append(s.requests, string(body))
+			_, _ = w.Write([]byte(`{}`))
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func send(t *testing.T, client *http.Client, url, session string, body any) {
+	b, err := json.Marshal(body)
+	require.NoError(t, err)
+	ctx := context.WithValue(t.Context(), sessionKey{}, session)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, strings.NewReader(string(b)))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer key")
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+}
+
+func TestOpenAI(t *testing.T) {
+	s := newServer(t)
+	client := NewClient(nil, OpenAI, sessionOf)
+	image := "data:image/png;base64," + base64.StdEncoding.EncodeToString(make([]byte, MinSize))
+	small := "data:image/png;base64," + base64.StdEncoding.EncodeToString(make([]byte, 16))
+	body := map[string]any{"input": []any{map[string]any{"content": []any{
+		map[string]any{"type": "input_image", "image_url": image},
+		map[string]any{"type": "input_image", "image_url": small},
+	}}}}
+
+	// The image is uploaded once, and referenced from both turns.
+	send(t, client, s.URL+"/v1/responses", "one", body)
+	send(t, client, s.URL+"/v1/responses", "two", body)
+	require.Equal(t, 1, s.uploads)
+	require.Len(t, s.requests, 2)
+	for _, req := range s.requests {
+		require.Contains(t, req, `{"file_id":"file-1","type":"input_image"}`)
+		require.Contains(t, req, small)
+		require.NotContains(t, req, image)
+	}
+
+	// It's deleted with the last session it was sent in.
+	require.NoError(t, Forget(t.Context(), "one"))
+	require.Empty(t, s.deleted)
+	require.NoError(t, Forget(t.Context(), "two"))
+	require.Equal(t, []string{"/v1/files/file-1"}, s.deleted)
+}
+
+func TestGemini(t *testing.T) {
+	s := newServer(t)
+	client := NewClient(nil, Gemini, sessionOf)
+	data := base64.StdEncoding.EncodeToString(make([]byte, MinSize+1))
+	body := map[string]any{"contents": []any{map[string]any{"parts": []any{
+		map[string]any{"inlineData": map[string]any{"mimeType": "application/pdf", "data": data}},
+	}}}}
+
+	send(t, client, s.URL+"/v1beta/models/gemini-2.5-pro:streamGenerateContent", "three", body)
+	require.Equal(t, 1, s.uploads)
+	require.Equal(t, []string{
+		`{"contents":[{"parts":[{"fileData":{"fileUri":"https://files/abc","mimeType":"application/pdf"}}]}]}`,
+	}, s.requests)
+
+	require.NoError(t, Close(t.Context()))
+	require.Equal(t, []string{"/v1beta/files/abc"}, s.deleted)
+}
@@ -0,0 +1,168 @@
+package fileupload
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// geminiPollInterval is how often a file still being processed is
+	// checked.
+	geminiPollInterval = time.Second
+	// geminiPollAttempts is how many times it's checked before giving up
+	// and sending the attachment inline.
+	geminiPollAttempts = 10
+)
+
+// geminiFile is a file of the Gemini files API.
+type geminiFile struct {
+	Name  string `json:"name"`
+	URI   string `json:"uri"`
+	State string `json:"state"`
+}
+
+// geminiFilesURL returns the URL of the version of the Gemini API the
+// request is sent to, like https://generativelanguage.googleapis.com/v1beta,
+// which files are uploaded to and deleted from.
+func geminiFilesURL(u *url.URL) (string, bool) {
+	i := strings.Index(u.Path, "/models/")
+	if i < 0 {
+		return "", false
+	}
+	api := url.URL{Scheme: u.Scheme, Host: u.Host, Path: u.Path[:i], RawQuery: keyQuery(u)}
+	return api.String(), true
+}
+
+// keyQuery returns the query of the URL passing the API key, if it has one.
+func keyQuery(u *url.URL) string {
+	if key := u.Query().Get("key"); key != "" {
+		return url.Values{"key": {key}}.Encode()
+	}
+	return ""
+}
+
+// replaceGemini replaces the inline data of a part with the URI of its
+// upload.
+func replaceGemini(m map[string]any, ref func(string, []byte) (string, bool)) bool {
+	blob, ok := m["inlineData"].(map[string]any)
+	if !ok {
+		return false
+	}
+	mediaType, _ := blob["mimeType"].(string)
+	encoded, _ := blob["data"].(string)
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if mediaType == "" || err != nil {
+		return false
+	}
+	uri, ok := ref(mediaType, data)
+	if !ok {
+		return false
+	}
+	delete(m, "inlineData")
+	m["fileData"] = map[string]any{"mimeType": mediaType, "fileUri": uri}
+	return true
+}
+
+// uploadGemini uploads an attachment to the files API of Gemini, with the
+// resumable upload protocol, and waits for it to be processed. Gemini
+// deletes uploads after two days by itself.
+func uploadGemini(ctx context.Context, base http.RoundTripper, apiURL string, header http.Header, mediaType string, data []byte) (uploaded, error) {
+	api, err := url.Parse(apiURL)
+	if err != nil {
+		return uploaded{}, err
+	}
+	// https://host/v1beta becomes https://host/upload/v1beta/files.
+	dir, version := api.Path[:strings.LastIndex(api.Path, "/")], api.Path[strings.LastIndex(api.Path, "/"):]
+	start := *api
+	start.Path = dir + "/upload" + version + "/files"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, start.String(), strings.NewReader(`{"file":{"displayName":"crush-attachment"}}`))
+	if err != nil {
+		return uploaded{}, err
+	}
+	req.Header = header.Clone()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Goog-Upload-Protocol", "resumable")
+	req.Header.Set("X-Goog-Upload-Command", "start")
+	req.Header.Set("X-Goog-Upload-Header-Content-Length", strconv.Itoa(len(data)))
+	req.Header.Set("X-Goog-Upload-Header-Content-Type", mediaType)
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return uploaded{}, err
+	}
+	resp.Body.Close()
+	uploadURL := resp.Header.Get("X-Goog-Upload-URL")
+	if resp.StatusCode != http.StatusOK || uploadURL == "" {
+		return uploaded{}, fmt.Errorf("failed to start upload: %s", resp.Status)
+	}
+
+	req, err = http.NewRequestWithContext(ctx, http.MethodPost, uploadURL, bytes.NewReader(data))
+	if err != nil {
+		return uploaded{}, err
+	}
+	req.Header = header.Clone()
+	req.Header.Set("X-Goog-Upload-Offset", "0")
+	req.Header.Set("X-Goog-Upload-Command", "upload, finalize")
+	resp, err = base.RoundTrip(req)
+	if err != nil {
+		return uploaded{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return uploaded{}, apiError("upload file", resp)
+	}
+	var created struct {
+		File geminiFile `json:"file"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		return uploaded{}, err
+	}
+
+	fileURL := *api
+	fileURL.Path = api.Path + "/" + created.File.Name
+	up := uploaded{ref: created.File.URI, deleteURL: fileURL.String()}
+	file := created.File
+	for attempt := 0; file.State == "PROCESSING"; attempt++ {
+		if attempt == geminiPollAttempts {
+			return up, fmt.Errorf("file %s is still being processed", file.Name)
+		}
+		select {
+		case <-ctx.Done():
+			return up, ctx.Err()
+		case <-time.After(geminiPollInterval):
+		}
+		if file, err = getGeminiFile(ctx, base, up.deleteURL, header); err != nil {
+			return up, err
+		}
+	}
+	if file.State == "FAILED" || up.ref == "" {
+		return up, fmt.Errorf("file %s failed to be processed", file.Name)
+	}
+	return up, nil
+}
+
+func getGeminiFile(ctx context.Context, base http.RoundTripper, fileURL string, header http.Header) (geminiFile, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fileURL, nil)
+	if err != nil {
+		return geminiFile{}, err
+	}
+	req.Header = header.Clone()
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return geminiFile{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return geminiFile{}, apiError("get file", resp)
+	}
+	var file geminiFile
+	return file, json.NewDecoder(resp.Body).Decode(&file)
+}
@@ -0,0 +1,131 @@
+package fileupload
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// openaiExpiry is how long OpenAI keeps the uploads, should Crush exit
+// without deleting them.
+const openaiExpiry = 24 * time.Hour
+
+// openaiFilesURL returns the URL of the files API next to the Responses or
+// Chat Completions endpoint of the request.
+func openaiFilesURL(u *url.URL) (string, bool) {
+	for _, endpoint := range []string{"/responses", "/chat/completions"} {
+		if base, ok := strings.CutSuffix(u.Path, endpoint); ok {
+			files := *u
+			files.Path, files.RawPath = base+"/files", ""
+			return files.String(), true
+		}
+	}
+	return "", false
+}
+
+// replaceOpenAI replaces the data URL of an image or a document with the ID
+// of its upload: the input_image and input_file parts of the Responses API,
+// and the file parts of the Chat Completions API. Chat Completions only
+// takes images by URL, so they're left inline.
+func replaceOpenAI(m map[string]any, ref func(string, []byte) (string, bool)) bool {
+	field := "file_data"
+	if m["type"] == "input_image" {
+		field = "image_url"
+	}
+	s, ok := m[field].(string)
+	if !ok {
+		return false
+	}
+	mediaType, data, ok := parseDataURL(s)
+	if !ok {
+		return false
+	}
+	id, ok := ref(mediaType, data)
+	if !ok {
+		return false
+	}
+	delete(m, field)
+	delete(m, "filename")
+	m["file_id"] = id
+	return true
+}
+
+// uploadOpenAI uploads an attachment to the files API of OpenAI, expiring
+// after a day.
+func uploadOpenAI(ctx context.Context, base http.RoundTripper, filesURL string, header http.Header, mediaType string, data []byte) (uploaded, error) {
+	purpose := "user_data"
+	if strings.HasPrefix(mediaType, "image/") {
+		purpose = "vision"
+	}
+	name := "attachment"
+	if exts, _ := mime.ExtensionsByType(mediaType); len(exts) > 0 {
+		name += exts[0]
+	}
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	fields := [][2]string{
+		{"purpose", purpose},
+		{"expires_after[anchor]", "created_at"},
+		{"expires_after[seconds]", strconv.Itoa(int(openaiExpiry.Seconds()))},
+	}
+	for _, field := range fields {
+		if err := w.WriteField(field[0], field[1]); err != nil {
+			return uploaded{}, err
+		}
+	}
+	part, err := w.CreatePart(textproto.MIMEHeader{
+		"Content-Disposition": {fmt.Sprintf(`form-data; name="file"; filename=%q`, name)},
+		"Content-Type":        {mediaType},
+	})
+	if err != nil {
+		return uploaded{}, err
+	}
+	if _, err := part.Write(data); err != nil {
+		return uploaded{}, err
+	}
+	if err := w.Close(); err != nil {
+		return uploaded{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, filesURL, &body)
+	if err != nil {
+		return uploaded{}, err
+	}
+	req.Header = header.Clone()
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return uploaded{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return uploaded{}, apiError("upload file", resp)
+	}
+	var created struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		return uploaded{}, err
+	}
+	if created.ID == "" {
+		return uploaded{}, fmt.Errorf("failed to upload file: no ID in the response")
+	}
+	deleteURL, err := url.JoinPath(strings.SplitN(filesURL, "?", 2)[0], created.ID)
+	if err != nil {
+		return uploaded{}, err
+	}
+	if _, query, ok := strings.Cut(filesURL, "?"); ok {
+		deleteURL += "?" + query
+	}
+	return uploaded{ref: created.ID, deleteURL: deleteURL}, nil
+}
@@ -144,6 +144,42 @@ func GlobWithDoubleStar(pattern, searchPath string, limit int) ([]string, bool,
 	return results, truncated || errors.Is(err, filepath.SkipAll), nil
 }
 
+// ExpandFiles expands a file, a directory or a glob pattern (such as
+// src/**/*.go) relative to root into the files it stands for, leaving out the
+// ones ignored by .gitignore and .crushignore files. The files are sorted by
+// path.
+func ExpandFiles(pattern, root string, limit int) ([]string, bool, error) {
+	abs := pattern
+	if !filepath.IsAbs(abs) {
+		abs = filepath.Join(root, abs)
+	}
+	if info, err := os.Stat(abs); err == nil {
+		if !info.IsDir() {
+			return []string{abs}, false, nil
+		}
+		// Walk directories from root when possible, so its ignore files
+		// apply.
+		if rel, err := filepath.Rel(root, abs); err == nil && filepath.IsLocal(rel) {
+			pattern = filepath.ToSlash(filepath.Join(rel, "**"))
+		} else {
+			root, pattern = abs, "**"
+		}
+	} else if filepath.IsAbs(pattern) {
+		root, pattern = doublestar.SplitPattern(filepath.ToSlash(pattern))
+	}
+
+	matches, truncated, err := GlobWithDoubleStar(pattern, root, limit)
+	if err != nil {
+		return nil, false, err
+	}
+	files := slices.DeleteFunc(matches, func(match string) bool {
+		info, err := os.Stat(match)
+		return err != nil || info.IsDir()
+	})
+	slices.Sort(files)
+	return files, truncated, nil
+}
+
 // ShouldExcludeFile checks if a file should be excluded from processing
 // based on common patterns and ignore rules
 func ShouldExcludeFile(rootPath, filePath string) bool {
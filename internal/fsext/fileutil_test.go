@@ -267,3 +267,39 @@ func TestGlobWithDoubleStar(t *testing.T) {
 		require.Equal(t, []string{oldestFile, middleDir, newestFile}, matches)
 	})
 }
+
+func TestExpandFiles(t *testing.T) {
+	testDir := t.TempDir()
+
+	mainGo := filepath.Join(testDir, "src", "main.go")
+	utilsGo := filepath.Join(testDir, "src", "util", "utils.go")
+	notesMd := filepath.Join(testDir, "src", "notes.md")
+	ignoredGo := filepath.Join(testDir, "src", "gen", "ignored.go")
+
+	for _, file := range []string{mainGo, utilsGo, notesMd, ignoredGo} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(file), 0o755))
+		require.NoError(t, os.WriteFile(file, []byte("test content"), 0o644))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(testDir, "src", ".gitignore"), []byte("gen/\n"), 0o644))
+
+	t.Run("expands a glob", func(t *testing.T) {
+		files, truncated, err := ExpandFiles("src/**/*.go", testDir, 0)
+		require.NoError(t, err)
+		require.False(t, truncated)
+		require.Equal(t, []string{mainGo, utilsGo}, files)
+	})
+
+	t.Run("expands a directory", func(t *testing.T) {
+		files, truncated, err := ExpandFiles("src/util", testDir, 0)
+		require.NoError(t, err)
+		require.False(t, truncated)
+		require.Equal(t, []string{utilsGo}, files)
+	})
+
+	t.Run("keeps a file", func(t *testing.T) {
+		files, truncated, err := ExpandFiles(notesMd, testDir, 0)
+		require.NoError(t, err)
+		require.False(t, truncated)
+		require.Equal(t, []string{notesMd}, files)
+	})
+}
@@ -0,0 +1,170 @@
+// Package gitdiff reads the unstaged changes of a git work tree and stages
+// them hunk by hunk, like git add -p does.
+package gitdiff
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Hunk is a hunk of the diff of a file.
+type Hunk struct {
+	// Header is the @@ line of the hunk.
+	Header string
+	// Lines are the lines of the hunk, with their ' ', '+', '-' or '\'
+	// prefix.
+	Lines []string
+}
+
+// Additions returns the number of lines the hunk adds.
+func (h Hunk) Additions() int {
+	return h.count('+')
+}
+
+// Removals returns the number of lines the hunk removes.
+func (h Hunk) Removals() int {
+	return h.count('-')
+}
+
+func (h Hunk) count(prefix byte) int {
+	n := 0
+	for _, l := range h.Lines {
+		if len(l) > 0 && l[0] == prefix {
+			n++
+		}
+	}
+	return n
+}
+
+// File is a file with unstaged changes.
+type File struct {
+	// Path is the path of the file, relative to the root of the work tree.
+	Path string
+	// Header is the part of the diff before the first hunk, from the
+	// diff --git line down to the +++ line.
+	Header string
+	Hunks  []Hunk
+	// Untracked files have no diff, they can only be staged whole.
+	Untracked bool
+}
+
+// Patch returns the patch of a single hunk of the file.
+func (f File) Patch(h Hunk) string {
+	var b strings.Builder
+	b.WriteString(f.Header)
+	b.WriteString(h.Header + "\n")
+	for _, l := range h.Lines {
+		b.WriteString(l + "\n")
+	}
+	return b.String()
+}
+
+// Unstaged returns the files of the work tree at dir with changes that
+// aren't staged, untracked files last.
+func Unstaged(ctx context.Context, dir string) ([]File, error) {
+	out, err := git(ctx, dir, "", "diff", "--no-color", "--no-ext-diff", "--no-renames", "--src-prefix=a/", "--dst-prefix=b/")
+	if err != nil {
+		return nil, err
+	}
+	files := Parse(out)
+
+	out, err = git(ctx, dir, "", "ls-files", "--others", "--exclude-standard", "-z")
+	if err != nil {
+		return nil, err
+	}
+	for path := range strings.SplitSeq(out, "\x00") {
+		if path != "" {
+			files = append(files, File{Path: path, Untracked: true})
+		}
+	}
+	return files, nil
+}
+
+// Parse parses the output of git diff.
+func Parse(diff string) []File {
+	var files []File
+	var file *File
+	var hunk *Hunk
+	var header strings.Builder
+	flush := func() {
+		if file == nil {
+			return
+		}
+		if file.Header == "" {
+			file.Header = header.String()
+		}
+		files = append(files, *file)
+		file, hunk = nil, nil
+	}
+
+	for line := range strings.Lines(diff) {
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			flush()
+			file = &File{Path: diffGitPath(line)}
+			header.Reset()
+			header.WriteString(line + "\n")
+		case file == nil:
+			// Nothing before the first file is part of the diff.
+		case strings.HasPrefix(line, "@@"):
+			if file.Header == "" {
+				file.Header = header.String()
+			}
+			file.Hunks = append(file.Hunks, Hunk{Header: line})
+			hunk = &file.Hunks[len(file.Hunks)-1]
+		case hunk != nil:
+			hunk.Lines = append(hunk.Lines, line)
+		default:
+			header.WriteString(line + "\n")
+			if path, ok := strings.CutPrefix(line, "+++ b/"); ok {
+				file.Path = path
+			} else if path, ok := strings.CutPrefix(line, "--- a/"); ok && file.Path == "" {
+				file.Path = path
+			}
+		}
+	}
+	flush()
+	return files
+}
+
+// diffGitPath returns the path of the diff --git line of a file, which
+// the +++ line overrides when there is one.
+func diffGitPath(line string) string {
+	_, path, ok := strings.Cut(strings.TrimPrefix(line, "diff --git "), " b/")
+	if !ok {
+		return ""
+	}
+	return path
+}
+
+// StageHunk stages a single hunk of a file of the work tree at dir.
+func StageHunk(ctx context.Context, dir string, f File, h Hunk) error {
+	_, err := git(ctx, dir, f.Patch(h), "apply", "--cached", "--whitespace=nowarn", "-")
+	return err
+}
+
+// StageFile stages all the changes of a file of the work tree at dir.
+func StageFile(ctx context.Context, dir string, f File) error {
+	_, err := git(ctx, dir, "", "add", "--", f.Path)
+	return err
+}
+
+func git(ctx context.Context, dir, stdin string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-c", "core.quotePath=false"}, args...)...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
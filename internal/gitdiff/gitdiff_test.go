@@ -0,0 +1,114 @@
+package gitdiff
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const sampleDiff = `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -1,3 +1,3 @@
+ package main
+-var a = 1
++var a = 2
+
+@@ -10,2 +10,3 @@ func main() {
+ 	println(a)
++	println(b)
+ }
+diff --git a/old.txt b/old.txt
+deleted file mode 100644
+index 3333333..0000000
+--- a/old.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-gone
+\ No newline at end of file
+diff --git a/logo.png b/logo.png
+index 4444444..5555555 100644
+Binary files a/logo.png and b/logo.png differ
+`
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	files := Parse(sampleDiff)
+	require.Len(t, files, 3)
+
+	main := files[0]
+	require.Equal(t, "main.go", main.Path)
+	require.Equal(t, "diff --git a/main.go b/main.go\nindex 1111111..2222222 100644\n--- a/main.go\n+++ b/main.go\n", main.Header)
+	require.Len(t, main.Hunks, 2)
+	require.Equal(t, "@@ -10,2 +10,3 @@ func main() {", main.Hunks[1].Header)
+	require.Equal(t, 1, main.Hunks[0].Additions())
+	require.Equal(t, 1, main.Hunks[0].Removals())
+	require.Equal(t, 1, main.Hunks[1].Additions())
+	require.Equal(t, 0, main.Hunks[1].Removals())
+
+	old := files[1]
+	require.Equal(t, "old.txt", old.Path)
+	require.Equal(t, []string{"-gone", `\ No newline at end of file`}, old.Hunks[0].Lines)
+
+	binary := files[2]
+	require.Equal(t, "logo.png", binary.Path)
+	require.Empty(t, binary.Hunks)
+	require.Contains(t, binary.Header, "Binary files")
+}
+
+func TestStageHunk(t *testing.T) {
+	t.Parallel()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	run := func(args ...string) string {
+		out, err := git(t.Context(), dir, "", args...)
+		require.NoError(t, err)
+		return out
+	}
+	run("init", "-q")
+	run("config", "user.email", "test@example.com")
+	run("config", "user.name", "test")
+
+	lines := make([]string, 20)
+	for i := range lines {
+		lines[i] = "line"
+	}
+	path := filepath.Join(dir, "file.txt")
+	require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644))
+	run("add", "file.txt")
+	run("commit", "-q", "-m", "initial")
+
+	lines[1] = "first change"
+	lines[18] = "second change"
+	require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "new.txt"), []byte("new\n"), 0o644))
+
+	files, err := Unstaged(t.Context(), dir)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	require.Equal(t, "file.txt", files[0].Path)
+	require.Len(t, files[0].Hunks, 2)
+	require.Equal(t, File{Path: "new.txt", Untracked: true}, files[1])
+
+	require.NoError(t, StageHunk(t.Context(), dir, files[0], files[0].Hunks[1]))
+	staged := run("diff", "--cached")
+	require.Contains(t, staged, "+second change")
+	require.NotContains(t, staged, "+first change")
+
+	files, err = Unstaged(t.Context(), dir)
+	require.NoError(t, err)
+	require.Len(t, files[0].Hunks, 1)
+	require.Contains(t, files[0].Hunks[0].Lines, "+first change")
+
+	require.NoError(t, StageFile(t.Context(), dir, files[1]))
+	require.Contains(t, run("diff", "--cached", "--name-only"), "new.txt")
+}
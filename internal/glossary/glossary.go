@@ -0,0 +1,165 @@
+// Package glossary tells the agent the terminology of a project, and finds
+// the terms to avoid in the lines it writes.
+package glossary
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/crush/internal/config"
+)
+
+// Violation is a use of a term to avoid.
+type Violation struct {
+	// Line is the 1-based number of the line using the term.
+	Line int
+	Term string
+	// Instead is the term to use instead, empty for banned terms.
+	Instead string
+}
+
+func (v Violation) String() string {
+	if v.Instead == "" {
+		return fmt.Sprintf("Line %d uses %q, which the glossary of the project bans.", v.Line, v.Term)
+	}
+	return fmt.Sprintf("Line %d uses %q, the glossary of the project says %q instead.", v.Line, v.Term, v.Instead)
+}
+
+// Prompt returns the glossary for the system prompt, or an empty string when
+// there's none.
+func Prompt(g *config.Glossary) string {
+	if g == nil || len(g.Terms) == 0 && len(g.Banned) == 0 && len(g.Conventions) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("<glossary>\nUse the terminology of the project in code, comments, documentation and messages.\n")
+	if len(g.Terms) > 0 {
+		b.WriteString("\nTerms:\n")
+		for _, term := range slices.Sorted(maps.Keys(g.Terms)) {
+			t := g.Terms[term]
+			b.WriteString("- " + term)
+			if t.Definition != "" {
+				b.WriteString(": " + t.Definition)
+			}
+			if len(t.Avoid) > 0 {
+				b.WriteString(" (not " + strings.Join(t.Avoid, ", ") + ")")
+			}
+			b.WriteString("\n")
+		}
+	}
+	if len(g.Banned) > 0 {
+		b.WriteString("\nNever use: " + strings.Join(g.Banned, ", ") + "\n")
+	}
+	if len(g.Conventions) > 0 {
+		b.WriteString("\nNaming conventions:\n")
+		for _, c := range g.Conventions {
+			b.WriteString("- " + c + "\n")
+		}
+	}
+	b.WriteString("</glossary>")
+	return b.String()
+}
+
+// avoid is a term to avoid, with the one to use instead.
+type avoid struct {
+	term    string
+	instead string
+	words   []string
+}
+
+// Check returns the uses of the terms to avoid on the lines of after that
+// aren't in before, so the ones already there aren't reported again.
+func Check(g *config.Glossary, before, after string) []Violation {
+	var avoids []avoid
+	if g != nil {
+		for _, term := range slices.Sorted(maps.Keys(g.Terms)) {
+			for _, a := range g.Terms[term].Avoid {
+				avoids = append(avoids, avoid{term: a, instead: term, words: words(a)})
+			}
+		}
+		for _, banned := range g.Banned {
+			avoids = append(avoids, avoid{term: banned, words: words(banned)})
+		}
+	}
+	if len(avoids) == 0 {
+		return nil
+	}
+
+	old := map[string]int{}
+	for line := range strings.Lines(before) {
+		old[strings.TrimRight(line, "\r\n")]++
+	}
+	var violations []Violation
+	n := 0
+	for line := range strings.Lines(after) {
+		n++
+		line = strings.TrimRight(line, "\r\n")
+		if old[line] > 0 {
+			old[line]--
+			continue
+		}
+		lineWords := words(line)
+		for _, a := range avoids {
+			if contains(lineWords, a.words) {
+				violations = append(violations, Violation{Line: n, Term: a.term, Instead: a.instead})
+			}
+		}
+	}
+	return violations
+}
+
+// contains reports whether consecutive words of text spell the term, so
+// "white list" is found in whiteList, white_list and whitelist alike.
+func contains(text, term []string) bool {
+	want := strings.Join(term, "")
+	if want == "" {
+		return false
+	}
+	for i := range text {
+		var got string
+		for _, word := range text[i:] {
+			got += word
+			if len(got) >= len(want) {
+				break
+			}
+		}
+		if got == want {
+			return true
+		}
+	}
+	return false
+}
+
+// words splits text into lowercase words, also at the case changes of
+// camelCase and PascalCase identifiers.
+func words(text string) []string {
+	var words []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, strings.ToLower(string(word)))
+			word = word[:0]
+		}
+	}
+	runes := []rune(text)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+		if unicode.IsUpper(r) && len(word) > 0 {
+			prev := word[len(word)-1]
+			// A new word starts at an upper case letter after a lower case
+			// one, or at the last upper case letter of an acronym.
+			if !unicode.IsUpper(prev) || i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+				flush()
+			}
+		}
+		word = append(word, r)
+	}
+	flush()
+	return words
+}
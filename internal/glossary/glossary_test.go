@@ -0,0 +1,64 @@
+package glossary
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWords(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, []string{"parse", "http", "server", "url"}, words("parseHTTPServer(url)"))
+	require.Equal(t, []string{"ip", "white", "list"}, words("IP_WhiteList"))
+	require.Nil(t, words(" -- "))
+}
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	g := &config.Glossary{
+		Terms: map[string]config.GlossaryTerm{
+			"allowlist": {Avoid: []string{"whitelist"}},
+			"workspace": {Avoid: []string{"project folder"}},
+		},
+		Banned: []string{"master"},
+	}
+	before := "var whitelist []string\n"
+	after := "var whitelist []string\nvar ipWhiteList []string\n// Open the project_folder.\nbranch := \"main\"\nmasterpiece := true\nuse(master)\n"
+	require.Equal(t, []Violation{
+		{Line: 2, Term: "whitelist", Instead: "allowlist"},
+		{Line: 3, Term: "project folder", Instead: "workspace"},
+		{Line: 6, Term: "master"},
+	}, Check(g, before, after))
+
+	require.Nil(t, Check(nil, "", after))
+	require.Nil(t, Check(g, after, after))
+}
+
+func TestPrompt(t *testing.T) {
+	t.Parallel()
+
+	require.Empty(t, Prompt(nil))
+	require.Empty(t, Prompt(&config.Glossary{}))
+	require.Equal(t, `<glossary>
+Use the terminology of the project in code, comments, documentation and messages.
+
+Terms:
+- allowlist (not whitelist)
+- workspace: The directory Crush works in
+
+Never use: master
+
+Naming conventions:
+- Suffix errors with Err
+</glossary>`, Prompt(&config.Glossary{
+		Terms: map[string]config.GlossaryTerm{
+			"workspace": {Definition: "The directory Crush works in"},
+			"allowlist": {Avoid: []string{"whitelist"}},
+		},
+		Banned:      []string{"master"},
+		Conventions: []string{"Suffix errors with Err"},
+	}))
+}
@@ -0,0 +1,216 @@
+// Package handoff hands the terminal over to the user to run the commands
+// the agent can't, like the ones prompting for a password or starting a
+// REPL. The TUI suspends itself while the command runs in the terminal, and
+// the agent gets what the command printed once it exits.
+package handoff
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/google/uuid"
+)
+
+// ErrNoTerminal is returned when there's no TUI to hand the terminal over
+// from, like when running non-interactively.
+var ErrNoTerminal = errors.New("no terminal to hand over: interactive commands only run from the TUI")
+
+// maxOutput is the number of bytes of the output of a command kept for the
+// agent, the rest is only shown in the terminal.
+const maxOutput = 64 * 1024
+
+// Request asks the user to run a command in the terminal.
+type Request struct {
+	ID          string
+	SessionID   string
+	ToolCallID  string
+	Command     string
+	Description string
+	WorkingDir  string
+	Env         []string
+}
+
+// Result is how a command run in the terminal ended.
+type Result struct {
+	Output   string
+	ExitCode int
+	// Err is set when the command couldn't run at all.
+	Err error
+}
+
+var (
+	broker   = pubsub.NewBroker[Request]()
+	pending  = csync.NewMap[string, chan Result]()
+	attached atomic.Int32
+	// The terminal can only be handed over for one command at a time.
+	runMu sync.Mutex
+)
+
+// SubscribeEvents subscribes to the requests to run commands in the
+// terminal.
+func SubscribeEvents(ctx context.Context) <-chan pubsub.Event[Request] {
+	return broker.Subscribe(ctx)
+}
+
+// Attach marks a terminal as available to hand over, until the returned
+// function is called.
+func Attach() (detach func()) {
+	attached.Add(1)
+	var once sync.Once
+	return func() {
+		once.Do(func() { attached.Add(-1) })
+	}
+}
+
+// Run asks the user to run a command in the terminal, and waits until it
+// exits.
+func Run(ctx context.Context, req Request) (Result, error) {
+	if attached.Load() == 0 {
+		return Result{}, ErrNoTerminal
+	}
+	runMu.Lock()
+	defer runMu.Unlock()
+
+	req.ID = uuid.NewString()
+	ch := make(chan Result, 1)
+	pending.Set(req.ID, ch)
+	defer pending.Del(req.ID)
+
+	broker.Publish(pubsub.CreatedEvent, req)
+	select {
+	case result := <-ch:
+		return result, nil
+	case <-ctx.Done():
+		return Result{}, ctx.Err()
+	}
+}
+
+// Respond reports how the command of a request ended.
+func Respond(id string, result Result) {
+	if ch, ok := pending.Get(id); ok {
+		select {
+		case ch <- result:
+		default:
+		}
+	}
+}
+
+// Command runs the command of a request in the terminal, keeping what it
+// prints for the agent. It's meant to be run by the TUI with tea.Exec, which
+// sets the terminal as its input and output.
+type Command struct {
+	req    Request
+	cmd    *exec.Cmd
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+	output limitedBuffer
+}
+
+// NewCommand returns the command running a request in the shell of the user.
+func NewCommand(req Request) *Command {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", req.Command)
+	} else {
+		sh := os.Getenv("SHELL")
+		if sh == "" {
+			sh = "/bin/sh"
+		}
+		cmd = exec.Command(sh, "-c", req.Command)
+	}
+	cmd.Dir = req.WorkingDir
+	cmd.Env = req.Env
+	return &Command{req: req, cmd: cmd}
+}
+
+func (c *Command) SetStdin(r io.Reader) {
+	c.stdin = r
+}
+
+func (c *Command) SetStdout(w io.Writer) {
+	c.stdout = w
+}
+
+func (c *Command) SetStderr(w io.Writer) {
+	c.stderr = w
+}
+
+// Run runs the command, after telling the user what it is and why the agent
+// needs them to run it. When the input is a terminal, the command runs on a
+// pseudo-terminal of its own, so that it behaves as it would outside of
+// Crush.
+func (c *Command) Run() error {
+	if c.stdout != nil {
+		fmt.Fprintf(c.stdout, "\r\n\x1b[1mCrush handed you the terminal to run:\x1b[0m %s\r\n", c.req.Command)
+		if c.req.Description != "" {
+			fmt.Fprintf(c.stdout, "\x1b[2m%s. Crush comes back when the command exits.\x1b[0m\r\n\r\n", c.req.Description)
+		}
+	}
+	if ran, err := c.runOnTerminal(); ran {
+		return err
+	}
+	c.cmd.Stdin = c.stdin
+	c.cmd.Stdout = c.tee(c.stdout)
+	c.cmd.Stderr = c.tee(c.stderr)
+	return c.cmd.Run()
+}
+
+// tee returns a writer writing to w, if any, and keeping the output for the
+// agent.
+func (c *Command) tee(w io.Writer) io.Writer {
+	if w == nil {
+		return &c.output
+	}
+	return io.MultiWriter(w, &c.output)
+}
+
+// Result returns how the command ended, given the error it ran with.
+func (c *Command) Result(err error) Result {
+	result := Result{Output: c.output.String()}
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(err, &exitErr):
+		result.ExitCode = exitErr.ExitCode()
+	case err != nil:
+		result.Err = err
+	}
+	return result
+}
+
+// limitedBuffer keeps the last bytes written to it.
+type limitedBuffer struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf = append(b.buf, p...)
+	if len(b.buf) > maxOutput {
+		b.buf = b.buf[len(b.buf)-maxOutput:]
+	}
+	return len(p), nil
+}
+
+func (b *limitedBuffer) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf = nil
+}
+
+func (b *limitedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return string(b.buf)
+}
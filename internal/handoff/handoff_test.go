@@ -0,0 +1,72 @@
+package handoff
+
+import (
+	"bytes"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/creack/pty"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	_, err := Run(t.Context(), Request{Command: "true"})
+	require.ErrorIs(t, err, ErrNoTerminal)
+
+	detach := Attach()
+	defer detach()
+	events := SubscribeEvents(t.Context())
+	go func() {
+		event := <-events
+		Respond(event.Payload.ID, Result{Output: event.Payload.Command, ExitCode: 1})
+	}()
+	result, err := Run(t.Context(), Request{Command: "psql"})
+	require.NoError(t, err)
+	require.Equal(t, Result{Output: "psql", ExitCode: 1}, result)
+}
+
+func TestCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+	t.Setenv("SHELL", "/bin/sh")
+	cmd := NewCommand(Request{Command: "read name; echo hello $name; exit 3", Description: "Say hello"})
+	var terminal bytes.Buffer
+	cmd.SetStdin(strings.NewReader("crush\n"))
+	cmd.SetStdout(&terminal)
+	cmd.SetStderr(&terminal)
+
+	result := cmd.Result(cmd.Run())
+	require.NoError(t, result.Err)
+	require.Equal(t, 3, result.ExitCode)
+	require.Equal(t, "hello crush\n", result.Output)
+	require.Contains(t, terminal.String(), "Say hello")
+}
+
+func TestCommandTerminal(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+	t.Setenv("SHELL", "/bin/sh")
+	ptmx, tty, err := pty.Open()
+	require.NoError(t, err)
+	defer ptmx.Close()
+	defer tty.Close()
+	go func() { _, _ = io.Copy(io.Discard, ptmx) }()
+	_, err = ptmx.Write([]byte("crush\n"))
+	require.NoError(t, err)
+
+	// The command gets a terminal of its own, not pipes.
+	cmd := NewCommand(Request{Command: "test -t 0 && test -t 1 && echo on a terminal; read name; echo hello $name"})
+	cmd.SetStdin(tty)
+	cmd.SetStdout(tty)
+	cmd.SetStderr(tty)
+
+	result := cmd.Result(cmd.Run())
+	require.NoError(t, result.Err)
+	require.Equal(t, 0, result.ExitCode)
+	require.Contains(t, result.Output, "on a terminal\r\n")
+	require.Contains(t, result.Output, "hello crush\r\n")
+}
@@ -0,0 +1,79 @@
+//go:build !windows
+
+package handoff
+
+import (
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/creack/pty"
+	"github.com/muesli/cancelreader"
+)
+
+// runOnTerminal runs the command on a pseudo-terminal when the input is a
+// terminal, passing the keys typed through and teeing what it prints. It
+// reports whether it ran the command.
+func (c *Command) runOnTerminal() (bool, error) {
+	in, ok := c.stdin.(*os.File)
+	if !ok || !term.IsTerminal(in.Fd()) {
+		return false, nil
+	}
+	// The input must be handed back to the TUI once the command exits.
+	input, err := cancelreader.NewReader(in)
+	if err != nil {
+		return false, nil
+	}
+	defer input.Close()
+
+	ptmx, err := pty.Start(c.cmd)
+	if err != nil {
+		return true, err
+	}
+	defer ptmx.Close()
+
+	resize := func() {
+		if width, height, err := term.GetSize(in.Fd()); err == nil {
+			_ = pty.Setsize(ptmx, &pty.Winsize{Cols: uint16(width), Rows: uint16(height)})
+		}
+	}
+	resize()
+	winch := make(chan os.Signal, 1)
+	signal.Notify(winch, syscall.SIGWINCH)
+	defer signal.Stop(winch)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-winch:
+				resize()
+			}
+		}
+	}()
+
+	// The pseudo-terminal handles the keys, so they go through as typed.
+	if state, err := term.MakeRaw(in.Fd()); err == nil {
+		defer term.Restore(in.Fd(), state) //nolint:errcheck
+	}
+	typed := make(chan struct{})
+	go func() {
+		defer close(typed)
+		_, _ = io.Copy(ptmx, input)
+	}()
+	printed := make(chan struct{})
+	go func() {
+		defer close(printed)
+		_, _ = io.Copy(c.tee(c.stdout), ptmx)
+	}()
+
+	err = c.cmd.Wait()
+	<-printed
+	input.Cancel()
+	<-typed
+	return true, err
+}
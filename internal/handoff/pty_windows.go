@@ -0,0 +1,9 @@
+//go:build windows
+
+package handoff
+
+// runOnTerminal reports false: on Windows, commands read from the terminal
+// and print through pipes.
+func (c *Command) runOnTerminal() (bool, error) {
+	return false, nil
+}
@@ -0,0 +1,100 @@
+// Package hooks runs the shell commands configured on events of the agent,
+// like the start of a session or an edit. A hook exiting with a non-zero
+// status blocks what triggered it, and its output tells why.
+package hooks
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/shell"
+)
+
+// Event is what hooks run on.
+type Event string
+
+const (
+	SessionStart Event = "session_start"
+	BeforeEdit   Event = "before_edit"
+	AfterTurn    Event = "after_turn"
+	PreCommit    Event = "pre_commit"
+)
+
+// DefaultTimeout is how long a hook may run when it has no timeout.
+const DefaultTimeout = 60 * time.Second
+
+// maxOutput bounds the output of a hook shown to the agent.
+const maxOutput = 8000
+
+// Outcome is the outcome of the hooks of an event.
+type Outcome struct {
+	// Blocked is set when a hook failed, which blocks the action.
+	Blocked bool
+	// Hook is the command of the hook that failed.
+	Hook string
+	// Output is the output of the hook that failed, or the output of the
+	// passing hooks that inject it.
+	Output string
+}
+
+// Reason describes why the action was blocked, for the agent or the user.
+func (o Outcome) Reason(event Event) string {
+	if o.Output == "" {
+		return fmt.Sprintf("The %s hook `%s` failed.", event, o.Hook)
+	}
+	return fmt.Sprintf("The %s hook `%s` failed:\n\n%s", event, o.Hook, o.Output)
+}
+
+// Run runs the hooks of an event in order in dir, until one fails. The
+// event is in $CRUSH_EVENT, along with env.
+func Run(ctx context.Context, event Event, hooks []config.Hook, dir string, env map[string]string) Outcome {
+	environ := append(os.Environ(), "CRUSH_EVENT="+string(event))
+	for _, name := range slices.Sorted(maps.Keys(env)) {
+		environ = append(environ, name+"="+env[name])
+	}
+
+	var injected []string
+	for _, hook := range hooks {
+		if strings.TrimSpace(hook.Command) == "" {
+			continue
+		}
+		timeout := DefaultTimeout
+		if hook.Timeout > 0 {
+			timeout = time.Duration(hook.Timeout) * time.Second
+		}
+		hookCtx, cancel := context.WithTimeout(ctx, timeout)
+		sh := shell.NewShell(&shell.Options{WorkingDir: dir, Env: environ})
+		stdout, stderr, err := sh.Exec(hookCtx, hook.Command)
+		cancel()
+
+		output := limit(strings.TrimSpace(strings.TrimSpace(stdout) + "\n" + strings.TrimSpace(stderr)))
+		if err != nil {
+			if shell.IsInterrupt(err) {
+				output = strings.TrimSpace(output + "\n" + fmt.Sprintf("(stopped after %s)", timeout))
+			}
+			return Outcome{Blocked: true, Hook: hook.Command, Output: output}
+		}
+		if hook.Inject && output != "" {
+			injected = append(injected, output)
+		}
+	}
+	return Outcome{Output: strings.Join(injected, "\n\n")}
+}
+
+// limit keeps the end of long output, where failures are usually reported.
+func limit(output string) string {
+	if len(output) <= maxOutput {
+		return output
+	}
+	cut := len(output) - maxOutput
+	for cut < len(output) && output[cut]&0xC0 == 0x80 {
+		cut++
+	}
+	return "[…]\n" + output[cut:]
+}
@@ -0,0 +1,48 @@
+package hooks
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+
+	outcome := Run(t.Context(), BeforeEdit, []config.Hook{
+		{Command: "echo checked $CRUSH_FILE on $CRUSH_EVENT", Inject: true},
+		{Command: "echo not injected"},
+		{Command: "echo also injected", Inject: true},
+	}, dir, map[string]string{"CRUSH_FILE": "main.go"})
+	require.False(t, outcome.Blocked)
+	require.Equal(t, "checked main.go on before_edit\n\nalso injected", outcome.Output)
+
+	outcome = Run(t.Context(), PreCommit, []config.Hook{
+		{Command: "echo fine"},
+		{Command: "echo lint failed >&2; exit 1"},
+		{Command: "echo never runs", Inject: true},
+	}, dir, nil)
+	require.True(t, outcome.Blocked)
+	require.Equal(t, "echo lint failed >&2; exit 1", outcome.Hook)
+	require.Equal(t, "lint failed", outcome.Output)
+	require.Equal(t, "The pre_commit hook `echo lint failed >&2; exit 1` failed:\n\nlint failed", outcome.Reason(PreCommit))
+
+	outcome = Run(t.Context(), AfterTurn, []config.Hook{{Command: "sleep 2", Timeout: 1}}, dir, nil)
+	require.True(t, outcome.Blocked)
+	require.Contains(t, outcome.Output, "stopped after 1s")
+
+	outcome = Run(t.Context(), SessionStart, nil, dir, nil)
+	require.False(t, outcome.Blocked)
+	require.Empty(t, outcome.Output)
+}
+
+func TestLimit(t *testing.T) {
+	require.Equal(t, "short", limit("short"))
+	long := strings.Repeat("é", maxOutput)
+	limited := limit(long)
+	require.True(t, strings.HasPrefix(limited, "[…]\n"))
+	require.LessOrEqual(t, len(limited), maxOutput+len("[…]\n"))
+	require.NotContains(t, limited, "�")
+}
@@ -0,0 +1,111 @@
+// Package i18n translates the strings of the interface to the language set
+// in the options, with the message catalogs of the locales directory.
+//
+// A catalog is a JSON object mapping the English strings to their
+// translation. Strings missing from the catalog are shown in English, so a
+// language can be translated a bit at a time: add the strings to
+// locales/<tag>.json, where tag is a BCP 47 language tag like zh-CN.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"sync/atomic"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/language/display"
+)
+
+//go:embed locales/*.json
+var locales embed.FS
+
+// catalog is the translations of a language.
+type catalog struct {
+	tag      language.Tag
+	messages map[string]string
+}
+
+var current atomic.Pointer[catalog]
+
+// Languages returns the tags of the languages with a catalog.
+func Languages() []language.Tag {
+	entries, _ := locales.ReadDir("locales")
+	tags := make([]language.Tag, 0, len(entries))
+	for _, entry := range entries {
+		if tag, err := language.Parse(strings.TrimSuffix(entry.Name(), ".json")); err == nil {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// SetLanguage translates strings to the language of the BCP 47 tag from now
+// on, or leaves them in English when lang is empty or English. It returns an
+// error when no catalog matches the language, leaving strings in English.
+func SetLanguage(lang string) error {
+	if lang == "" {
+		current.Store(nil)
+		return nil
+	}
+	tag, err := language.Parse(lang)
+	if err != nil {
+		return fmt.Errorf("invalid language %q: %w", lang, err)
+	}
+	if base, _ := tag.Base(); base.String() == "en" {
+		current.Store(nil)
+		return nil
+	}
+	c, err := load(tag)
+	if err != nil {
+		current.Store(nil)
+		return err
+	}
+	current.Store(c)
+	return nil
+}
+
+// load reads the catalog closest to the language.
+func load(tag language.Tag) (*catalog, error) {
+	// English comes first, as what the matcher falls back to.
+	tags := append([]language.Tag{language.English}, Languages()...)
+	_, i, confidence := language.NewMatcher(tags).Match(tag)
+	if i == 0 || confidence < language.High {
+		return nil, fmt.Errorf("no translation for %s", Name(tag.String()))
+	}
+	data, err := locales.ReadFile(path.Join("locales", tags[i].String()+".json"))
+	if err != nil {
+		return nil, err
+	}
+	c := &catalog{tag: tags[i]}
+	if err := json.Unmarshal(data, &c.messages); err != nil {
+		return nil, fmt.Errorf("invalid catalog %s: %w", tags[i], err)
+	}
+	return c, nil
+}
+
+// T returns the translation of the English string s, or s when there's
+// none.
+func T(s string) string {
+	if c := current.Load(); c != nil {
+		if t, ok := c.messages[s]; ok && t != "" {
+			return t
+		}
+	}
+	return s
+}
+
+// Name returns the English name of the language of the BCP 47 tag, or lang
+// itself when it's not a known one.
+func Name(lang string) string {
+	tag, err := language.Parse(lang)
+	if err != nil {
+		return lang
+	}
+	if name := display.English.Tags().Name(tag); name != "" {
+		return name
+	}
+	return lang
+}
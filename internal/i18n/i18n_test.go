@@ -0,0 +1,48 @@
+package i18n
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCatalogs(t *testing.T) {
+	entries, err := locales.ReadDir("locales")
+	require.NoError(t, err)
+	require.Len(t, Languages(), len(entries), "catalogs must be named after a language tag")
+	for _, entry := range entries {
+		data, err := locales.ReadFile("locales/" + entry.Name())
+		require.NoError(t, err)
+		var messages map[string]string
+		require.NoError(t, json.Unmarshal(data, &messages), entry.Name())
+	}
+}
+
+func TestSetLanguage(t *testing.T) {
+	t.Cleanup(func() { current.Store(nil) })
+
+	require.NoError(t, SetLanguage("es"))
+	require.Equal(t, "Salir", T("Quit"))
+	require.Equal(t, "Not translated", T("Not translated"))
+
+	// The closest catalog is used.
+	require.NoError(t, SetLanguage("zh"))
+	require.Equal(t, "退出", T("Quit"))
+	require.NoError(t, SetLanguage("es-MX"))
+	require.Equal(t, "Salir", T("Quit"))
+
+	require.NoError(t, SetLanguage("en-GB"))
+	require.Equal(t, "Quit", T("Quit"))
+
+	require.Error(t, SetLanguage("tlh"))
+	require.Equal(t, "Quit", T("Quit"))
+	require.Error(t, SetLanguage("not a language"))
+}
+
+func TestName(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "Spanish", Name("es"))
+	require.Equal(t, "not a language", Name("not a language"))
+}
@@ -0,0 +1,194 @@
+// Package imagefit fits images to the limits providers put on them, so a
+// large screenshot is downscaled, compressed or converted before it's sent
+// instead of being refused.
+package imagefit
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/disintegration/imageorient"
+	"github.com/nfnt/resize"
+)
+
+const mb = 1024 * 1024
+
+// Limits are the constraints of a provider on images.
+type Limits struct {
+	// MaxDimension is the longest side of an image, in pixels. Providers
+	// downscale larger ones themselves, or refuse them.
+	MaxDimension int
+	// MaxBytes is the size of an image.
+	MaxBytes int
+	// Formats are the MIME types of the images accepted.
+	Formats []string
+}
+
+var (
+	commonFormats = []string{"image/jpeg", "image/png", "image/gif", "image/webp"}
+
+	anthropicLimits = Limits{MaxDimension: 1568, MaxBytes: 5 * mb, Formats: commonFormats}
+	bedrockLimits   = Limits{MaxDimension: 1568, MaxBytes: 3.75 * mb, Formats: commonFormats}
+	openAILimits    = Limits{MaxDimension: 2048, MaxBytes: 20 * mb, Formats: commonFormats}
+	geminiLimits    = Limits{MaxDimension: 3072, MaxBytes: 7 * mb, Formats: []string{"image/jpeg", "image/png", "image/webp", "image/heic", "image/heif"}}
+	defaultLimits   = Limits{MaxDimension: 2048, MaxBytes: 5 * mb, Formats: commonFormats}
+)
+
+// LimitsFor returns the limits on images of a provider, by its ID and type.
+// Providers of unknown types get conservative ones.
+func LimitsFor(providerID string, providerType catwalk.Type) Limits {
+	switch {
+	case providerID == string(catwalk.InferenceProviderBedrock):
+		return bedrockLimits
+	case providerID == string(catwalk.InferenceProviderVertexAI):
+		return geminiLimits
+	}
+	switch providerType {
+	case catwalk.TypeAnthropic:
+		return anthropicLimits
+	case catwalk.TypeOpenAI, catwalk.TypeAzure:
+		return openAILimits
+	case catwalk.TypeGoogle:
+		return geminiLimits
+	default:
+		return defaultLimits
+	}
+}
+
+// jpegQualities are the qualities tried in turn to compress an image under
+// the size limit, before downscaling it further.
+var jpegQualities = []int{85, 70, 55}
+
+// Fit returns the attachment with its image fitted to the limits, noting in
+// its Note what was changed. Attachments that aren't images, or whose image
+// already fits, are returned as they are.
+func Fit(attachment message.Attachment, limits Limits) (message.Attachment, error) {
+	if !attachment.IsImage() {
+		return attachment, nil
+	}
+	config, format, err := image.DecodeConfig(bytes.NewReader(attachment.Content))
+	if err != nil {
+		// Formats without a decoder, like WebP, are sent as they are when
+		// the provider takes them.
+		if slices.Contains(limits.Formats, attachment.MimeType) && len(attachment.Content) <= limits.MaxBytes {
+			return attachment, nil
+		}
+		return attachment, fmt.Errorf("failed to decode image %s: %w", attachment.FileName, err)
+	}
+	if slices.Contains(limits.Formats, attachment.MimeType) &&
+		max(config.Width, config.Height) <= limits.MaxDimension &&
+		len(attachment.Content) <= limits.MaxBytes {
+		return attachment, nil
+	}
+
+	img, _, err := imageorient.Decode(bytes.NewReader(attachment.Content))
+	if err != nil {
+		return attachment, fmt.Errorf("failed to decode image %s: %w", attachment.FileName, err)
+	}
+	bounds := img.Bounds()
+	if max(bounds.Dx(), bounds.Dy()) > limits.MaxDimension {
+		size := uint(limits.MaxDimension)
+		img = resize.Thumbnail(size, size, img, resize.Lanczos3)
+	}
+
+	// PNG keeps screenshots sharp, so it's kept when it fits, and JPEG
+	// compresses the rest.
+	var data []byte
+	mimeType := "image/png"
+	if format == "png" && slices.Contains(limits.Formats, mimeType) {
+		data, err = encodePNG(img)
+		if err != nil {
+			return attachment, err
+		}
+	}
+	for data == nil || len(data) > limits.MaxBytes {
+		mimeType = "image/jpeg"
+		if data, err = encodeJPEG(img, limits.MaxBytes); err != nil {
+			return attachment, err
+		}
+		if len(data) <= limits.MaxBytes {
+			break
+		}
+		b := img.Bounds()
+		if max(b.Dx(), b.Dy()) < 64 {
+			return attachment, fmt.Errorf("failed to fit image %s under %d bytes", attachment.FileName, limits.MaxBytes)
+		}
+		img = resize.Resize(uint(b.Dx()*3/4), 0, img, resize.Lanczos3)
+	}
+
+	fitted := attachment
+	fitted.Content = data
+	fitted.MimeType = mimeType
+	fitted.Note = note(attachment, config, format, img.Bounds(), mimeType, len(data))
+	if format != formatName(mimeType) {
+		fitted.FileName = strings.TrimSuffix(attachment.FileName, filepath.Ext(attachment.FileName)) + "." + formatName(mimeType)
+	}
+	return fitted, nil
+}
+
+func encodePNG(img image.Image) ([]byte, error) {
+	var b bytes.Buffer
+	if err := png.Encode(&b, img); err != nil {
+		return nil, fmt.Errorf("failed to encode image: %w", err)
+	}
+	return b.Bytes(), nil
+}
+
+// encodeJPEG encodes the image at the best quality that fits maxBytes, or the
+// lowest one tried. Transparent pixels turn white, not black.
+func encodeJPEG(img image.Image, maxBytes int) ([]byte, error) {
+	opaque := image.NewRGBA(img.Bounds())
+	draw.Draw(opaque, opaque.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(opaque, opaque.Bounds(), img, img.Bounds().Min, draw.Over)
+	var b bytes.Buffer
+	for _, quality := range jpegQualities {
+		b.Reset()
+		if err := jpeg.Encode(&b, opaque, &jpeg.Options{Quality: quality}); err != nil {
+			return nil, fmt.Errorf("failed to encode image: %w", err)
+		}
+		if b.Len() <= maxBytes {
+			break
+		}
+	}
+	return b.Bytes(), nil
+}
+
+// note describes how an image was fitted, like "downscaled from 3840×2160 to
+// 1568×882, converted from PNG to JPEG".
+func note(original message.Attachment, config image.Config, format string, bounds image.Rectangle, mimeType string, size int) string {
+	var changes []string
+	if bounds.Dx() != config.Width || bounds.Dy() != config.Height {
+		changes = append(changes, fmt.Sprintf("downscaled from %d×%d to %d×%d", config.Width, config.Height, bounds.Dx(), bounds.Dy()))
+	}
+	if format != formatName(mimeType) {
+		changes = append(changes, fmt.Sprintf("converted from %s to %s", strings.ToUpper(format), strings.ToUpper(formatName(mimeType))))
+	}
+	if size < len(original.Content) {
+		changes = append(changes, fmt.Sprintf("compressed from %s to %s", formatSize(len(original.Content)), formatSize(size)))
+	}
+	return strings.Join(changes, ", ")
+}
+
+// formatName returns the name of the format of an image encoded as the MIME
+// type, as the image package names it.
+func formatName(mimeType string) string {
+	return strings.TrimPrefix(mimeType, "image/")
+}
+
+func formatSize(bytes int) string {
+	if bytes < mb {
+		return fmt.Sprintf("%.1f KB", float64(bytes)/1024)
+	}
+	return fmt.Sprintf("%.1f MB", float64(bytes)/mb)
+}
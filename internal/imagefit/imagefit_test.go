@@ -0,0 +1,94 @@
+package imagefit
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"testing"
+
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/stretchr/testify/require"
+)
+
+func testImage(width, height int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := range width {
+		for y := range height {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: uint8(x * y), A: 255})
+		}
+	}
+	return img
+}
+
+func pngAttachment(t *testing.T, width, height int) message.Attachment {
+	t.Helper()
+	var b bytes.Buffer
+	require.NoError(t, png.Encode(&b, testImage(width, height)))
+	return message.Attachment{FilePath: "/tmp/shot.png", FileName: "shot.png", MimeType: "image/png", Content: b.Bytes()}
+}
+
+func TestFitDownscales(t *testing.T) {
+	t.Parallel()
+
+	fitted, err := Fit(pngAttachment(t, 800, 200), Limits{MaxDimension: 400, MaxBytes: 10 * mb, Formats: commonFormats})
+	require.NoError(t, err)
+	config, format, err := image.DecodeConfig(bytes.NewReader(fitted.Content))
+	require.NoError(t, err)
+	require.Equal(t, "png", format)
+	require.Equal(t, 400, config.Width)
+	require.Equal(t, 100, config.Height)
+	require.Equal(t, "image/png", fitted.MimeType)
+	require.Equal(t, "shot.png", fitted.FileName)
+	require.Contains(t, fitted.Note, "downscaled from 800×200 to 400×100")
+}
+
+func TestFitCompresses(t *testing.T) {
+	t.Parallel()
+
+	attachment := pngAttachment(t, 300, 300)
+	fitted, err := Fit(attachment, Limits{MaxDimension: 1000, MaxBytes: len(attachment.Content) / 2, Formats: commonFormats})
+	require.NoError(t, err)
+	require.Equal(t, "image/jpeg", fitted.MimeType)
+	require.Equal(t, "shot.jpeg", fitted.FileName)
+	require.LessOrEqual(t, len(fitted.Content), len(attachment.Content)/2)
+	require.Contains(t, fitted.Note, "converted from PNG to JPEG")
+	require.Contains(t, fitted.Note, "compressed from")
+}
+
+func TestFitConverts(t *testing.T) {
+	t.Parallel()
+
+	var b bytes.Buffer
+	require.NoError(t, gif.Encode(&b, testImage(50, 50), nil))
+	attachment := message.Attachment{FileName: "anim.gif", MimeType: "image/gif", Content: b.Bytes()}
+	fitted, err := Fit(attachment, geminiLimits)
+	require.NoError(t, err)
+	require.Equal(t, "image/jpeg", fitted.MimeType)
+	require.Contains(t, fitted.Note, "converted from GIF to JPEG")
+}
+
+func TestFitLeavesFittingImages(t *testing.T) {
+	t.Parallel()
+
+	attachment := pngAttachment(t, 100, 100)
+	fitted, err := Fit(attachment, anthropicLimits)
+	require.NoError(t, err)
+	require.Equal(t, attachment, fitted)
+
+	text := message.Attachment{FileName: "a.txt", MimeType: "text/plain", Content: []byte("hi")}
+	fitted, err = Fit(text, anthropicLimits)
+	require.NoError(t, err)
+	require.Equal(t, text, fitted)
+}
+
+func TestLimitsFor(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, anthropicLimits, LimitsFor("anthropic", catwalk.TypeAnthropic))
+	require.Equal(t, bedrockLimits, LimitsFor(string(catwalk.InferenceProviderBedrock), catwalk.TypeAnthropic))
+	require.Equal(t, geminiLimits, LimitsFor("gemini", catwalk.TypeGoogle))
+	require.Equal(t, defaultLimits, LimitsFor("local", catwalk.TypeOpenAICompat))
+}
@@ -0,0 +1,90 @@
+package importer
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/home"
+	"gopkg.in/yaml.v3"
+)
+
+// aiderPaths are where aider looks for its configuration: the home
+// directory, then the project.
+func aiderPaths(cwd string) []string {
+	return []string{
+		filepath.Join(home.Dir(), ".aider.conf.yml"),
+		filepath.Join(cwd, ".aider.conf.yml"),
+	}
+}
+
+// aiderConfig is the part of .aider.conf.yml worth importing.
+type aiderConfig struct {
+	Model           string `yaml:"model"`
+	OpenAIAPIKey    string `yaml:"openai-api-key"`
+	OpenAIAPIBase   string `yaml:"openai-api-base"`
+	AnthropicAPIKey string `yaml:"anthropic-api-key"`
+	// APIKey is a "provider=key" pair, or a list of them.
+	APIKey any `yaml:"api-key"`
+	// Read is a file aider always adds to the chat as read-only, or a list
+	// of them.
+	Read any `yaml:"read"`
+}
+
+func readAider(r *Result, _ string, data []byte) error {
+	var cfg aiderConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return err
+	}
+
+	if cfg.OpenAIAPIBase != "" {
+		// aider prefixes the models of OpenAI compatible APIs with openai/.
+		model := strings.TrimPrefix(cfg.Model, "openai/")
+		r.addModel("openai-compat", Provider{
+			Name:    "OpenAI Compatible",
+			Type:    "openai-compat",
+			BaseURL: cfg.OpenAIAPIBase,
+			APIKey:  cfg.OpenAIAPIKey,
+		}, Model{ID: model})
+		if model == "" {
+			r.note("aider talks to an OpenAI compatible API at %s, add the models to use to the openai-compat provider", cfg.OpenAIAPIBase)
+		}
+	} else {
+		r.setAPIKey("openai", cfg.OpenAIAPIKey)
+	}
+	r.setAPIKey("anthropic", cfg.AnthropicAPIKey)
+	for _, pair := range stringList(cfg.APIKey) {
+		name, key, ok := strings.Cut(pair, "=")
+		if !ok {
+			continue
+		}
+		id, known := knownProviders[strings.ToLower(strings.TrimSpace(name))]
+		if !known {
+			r.note("skipped the API key of %s, which Crush doesn't know about", name)
+			continue
+		}
+		r.setAPIKey(id, strings.TrimSpace(key))
+	}
+
+	for _, path := range stringList(cfg.Read) {
+		r.addContextPath(path)
+	}
+	return nil
+}
+
+// stringList returns a YAML or JSON value that's either a string or a list
+// of them as a list.
+func stringList(v any) []string {
+	switch v := v.(type) {
+	case string:
+		return []string{v}
+	case []any:
+		var list []string
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package importer
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/home"
+)
+
+// claudeDesktopPaths are where Claude Desktop keeps its configuration on
+// the current platform.
+func claudeDesktopPaths() []string {
+	const file = "claude_desktop_config.json"
+	switch runtime.GOOS {
+	case "darwin":
+		return []string{filepath.Join(home.Dir(), "Library", "Application Support", "Claude", file)}
+	case "windows":
+		return []string{filepath.Join(os.Getenv("APPDATA"), "Claude", file)}
+	default:
+		return []string{filepath.Join(home.Dir(), ".config", "Claude", file)}
+	}
+}
+
+// claudeDesktopConfig is the part of the Claude Desktop configuration worth
+// importing: it only configures MCP servers.
+type claudeDesktopConfig struct {
+	MCPServers map[string]struct {
+		Command string            `json:"command"`
+		Args    []string          `json:"args"`
+		Env     map[string]string `json:"env"`
+	} `json:"mcpServers"`
+}
+
+func readClaudeDesktop(r *Result, _ string, data []byte) error {
+	var cfg claudeDesktopConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return err
+	}
+	for name, s := range cfg.MCPServers {
+		r.MCP[name] = config.MCPConfig{
+			Type:    config.MCPStdio,
+			Command: s.Command,
+			Args:    s.Args,
+			Env:     s.Env,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package importer
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/home"
+	"gopkg.in/yaml.v3"
+)
+
+// continuePaths are where Continue keeps its configuration: the legacy
+// config.json, then config.yaml.
+func continuePaths() []string {
+	dir := filepath.Join(home.Dir(), ".continue")
+	return []string{
+		filepath.Join(dir, "config.json"),
+		filepath.Join(dir, "config.yaml"),
+	}
+}
+
+// continueConfig is the part of the Continue configuration worth importing.
+// As JSON is YAML, it reads both config.yaml and the legacy config.json.
+type continueConfig struct {
+	Models       []continueModel `yaml:"models"`
+	MCPServers   []continueMCP   `yaml:"mcpServers"`
+	Experimental struct {
+		MCPServers []struct {
+			Transport continueMCP `yaml:"transport"`
+		} `yaml:"modelContextProtocolServers"`
+	} `yaml:"experimental"`
+	Rules []any `yaml:"rules"`
+}
+
+type continueModel struct {
+	// Name is the display name of config.yaml, Title the one of config.json.
+	Name     string `yaml:"name"`
+	Title    string `yaml:"title"`
+	Provider string `yaml:"provider"`
+	Model    string `yaml:"model"`
+	APIKey   string `yaml:"apiKey"`
+	APIBase  string `yaml:"apiBase"`
+}
+
+type continueMCP struct {
+	Name    string            `yaml:"name"`
+	Type    string            `yaml:"type"`
+	Command string            `yaml:"command"`
+	Args    []string          `yaml:"args"`
+	Env     map[string]string `yaml:"env"`
+	URL     string            `yaml:"url"`
+}
+
+func readContinue(r *Result, _ string, data []byte) error {
+	var cfg continueConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return err
+	}
+
+	for _, m := range cfg.Models {
+		provider := strings.ToLower(m.Provider)
+		id, known := knownProviders[provider]
+		if known && m.APIBase == "" {
+			r.setAPIKey(id, m.APIKey)
+			continue
+		}
+		baseURL := cmp.Or(m.APIBase, localProviders[provider])
+		if baseURL == "" {
+			r.note("skipped the model %s of %s, which Crush doesn't know about", m.Model, m.Provider)
+			continue
+		}
+		typ := "openai-compat"
+		if provider == "anthropic" {
+			typ = "anthropic"
+		}
+		r.addModel(provider, Provider{
+			Name:    m.Provider,
+			Type:    typ,
+			BaseURL: baseURL,
+			APIKey:  m.APIKey,
+		}, Model{ID: m.Model, Name: cmp.Or(m.Name, m.Title)})
+	}
+
+	servers := cfg.MCPServers
+	for _, s := range cfg.Experimental.MCPServers {
+		servers = append(servers, s.Transport)
+	}
+	for i, s := range servers {
+		name := cmp.Or(s.Name, fmt.Sprintf("continue-%d", i+1))
+		mcp := config.MCPConfig{
+			Command: s.Command,
+			Args:    s.Args,
+			Env:     s.Env,
+			URL:     s.URL,
+		}
+		switch s.Type {
+		case "", "stdio":
+			mcp.Type = config.MCPStdio
+		case "sse":
+			mcp.Type = config.MCPSSE
+		case "streamable-http", "http":
+			mcp.Type = config.MCPHttp
+		default:
+			r.note("skipped the MCP server %s, whose %s transport Crush doesn't support", name, s.Type)
+			continue
+		}
+		r.MCP[name] = mcp
+	}
+
+	if len(cfg.Rules) > 0 {
+		r.note("skipped %d rules written in the Continue configuration, move them to CRUSH.md to keep them", len(cfg.Rules))
+	}
+	return nil
+}
+
+// readContinueRules adds the rules of the project, which Continue keeps as
+// files, to the context paths.
+func readContinueRules(r *Result, cwd string) {
+	dir := filepath.Join(".continue", "rules")
+	if info, err := os.Stat(filepath.Join(cwd, dir)); err == nil && info.IsDir() {
+		r.addContextPath(dir + "/")
+		r.Sources = append(r.Sources, filepath.Join(cwd, dir))
+	}
+}
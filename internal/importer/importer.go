@@ -0,0 +1,292 @@
+// Package importer translates the configuration of other coding tools, like
+// aider, Continue, Claude Desktop and opencode, into Crush configuration, so
+// switching over doesn't mean setting up providers and MCP servers again.
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// Tool is a tool whose configuration can be imported.
+type Tool string
+
+const (
+	Aider         Tool = "aider"
+	Continue      Tool = "continue"
+	ClaudeDesktop Tool = "claude-desktop"
+	OpenCode      Tool = "opencode"
+)
+
+// Tools are the tools whose configuration can be imported.
+var Tools = []Tool{Aider, Continue, ClaudeDesktop, OpenCode}
+
+// Provider is a provider of the Crush configuration. Known providers only
+// need their API key, custom ones also need their type, endpoint and models.
+type Provider struct {
+	Name    string  `json:"name,omitempty"`
+	Type    string  `json:"type,omitempty"`
+	BaseURL string  `json:"base_url,omitempty"`
+	APIKey  string  `json:"api_key,omitempty"`
+	Models  []Model `json:"models,omitempty"`
+}
+
+// Model is a model of a custom provider.
+type Model struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+}
+
+// Result is the configuration imported from a tool.
+type Result struct {
+	Tool Tool
+	// Sources are the files the configuration was read from.
+	Sources      []string
+	Providers    map[string]Provider
+	MCP          map[string]config.MCPConfig
+	ContextPaths []string
+	// Notes are what couldn't be imported, or needs a second look.
+	Notes []string
+}
+
+// knownProviders maps the provider names used by other tools to the ones
+// Crush knows about, which only need an API key to be set up.
+var knownProviders = map[string]string{
+	"openai":     "openai",
+	"anthropic":  "anthropic",
+	"gemini":     "gemini",
+	"google":     "gemini",
+	"openrouter": "openrouter",
+	"groq":       "groq",
+	"xai":        "xai",
+	"cerebras":   "cerebras",
+	"deepseek":   "deepseek",
+	"venice":     "venice",
+	"zai":        "zai",
+}
+
+// localProviders are the endpoints of local model servers, used when the
+// configuration of a tool doesn't say where they run.
+var localProviders = map[string]string{
+	"ollama":   "http://localhost:11434/v1",
+	"lmstudio": "http://localhost:1234/v1",
+}
+
+// Import reads the configuration of a tool from the given files, or from
+// where the tool keeps it when there are none, for the project in cwd.
+func Import(tool Tool, cwd string, paths ...string) (*Result, error) {
+	r := &Result{
+		Tool:      tool,
+		Providers: map[string]Provider{},
+		MCP:       map[string]config.MCPConfig{},
+	}
+	var read func(r *Result, path string, data []byte) error
+	switch tool {
+	case Aider:
+		read = readAider
+	case Continue:
+		read = readContinue
+	case ClaudeDesktop:
+		read = readClaudeDesktop
+	case OpenCode:
+		read = readOpenCode
+	default:
+		return nil, fmt.Errorf("unknown tool %q, expected one of %s", tool, toolNames())
+	}
+	if len(paths) == 0 {
+		paths = Paths(tool, cwd)
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		if err := read(r, path, data); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		r.Sources = append(r.Sources, path)
+	}
+	if tool == Continue {
+		readContinueRules(r, cwd)
+	}
+	if len(r.Sources) == 0 {
+		return nil, fmt.Errorf("no %s configuration found, looked in %s", tool, strings.Join(paths, ", "))
+	}
+	return r, nil
+}
+
+// Paths returns where a tool keeps its configuration, from the least to
+// the most specific, as the later ones override the earlier ones.
+func Paths(tool Tool, cwd string) []string {
+	switch tool {
+	case Aider:
+		return aiderPaths(cwd)
+	case Continue:
+		return continuePaths()
+	case ClaudeDesktop:
+		return claudeDesktopPaths()
+	case OpenCode:
+		return openCodePaths(cwd)
+	}
+	return nil
+}
+
+func toolNames() string {
+	names := make([]string, len(Tools))
+	for i, t := range Tools {
+		names[i] = string(t)
+	}
+	return strings.Join(names, ", ")
+}
+
+// Empty reports whether nothing was imported.
+func (r *Result) Empty() bool {
+	return len(r.Providers) == 0 && len(r.MCP) == 0 && len(r.ContextPaths) == 0
+}
+
+func (r *Result) note(format string, args ...any) {
+	r.Notes = append(r.Notes, fmt.Sprintf(format, args...))
+}
+
+// setAPIKey sets the API key of a provider, noting when it's a literal key
+// copied into the configuration.
+func (r *Result) setAPIKey(id, key string) {
+	if key == "" {
+		return
+	}
+	p := r.Providers[id]
+	p.APIKey = key
+	r.Providers[id] = p
+	r.noteAPIKey(id, key)
+}
+
+// noteAPIKey notes when an API key is a literal key copied into the
+// configuration rather than an environment variable.
+func (r *Result) noteAPIKey(id, key string) {
+	if !strings.HasPrefix(key, "$") {
+		r.note("copied the API key of %s as is, consider replacing it with an environment variable like $%s_API_KEY", id, strings.ToUpper(id))
+	}
+}
+
+// addModel adds a model to a custom provider.
+func (r *Result) addModel(id string, provider Provider, model Model) {
+	p, ok := r.Providers[id]
+	if !ok {
+		p = provider
+		p.APIKey = ""
+		p.Models = nil
+	}
+	if provider.APIKey != "" && p.APIKey == "" {
+		p.APIKey = provider.APIKey
+		r.noteAPIKey(id, provider.APIKey)
+	}
+	if model.ID != "" && !slices.ContainsFunc(p.Models, func(m Model) bool { return m.ID == model.ID }) {
+		p.Models = append(p.Models, model)
+	}
+	r.Providers[id] = p
+}
+
+func (r *Result) addContextPath(path string) {
+	if path != "" && !slices.Contains(r.ContextPaths, path) {
+		r.ContextPaths = append(r.ContextPaths, path)
+	}
+}
+
+// Changes are what applying a result changed in a configuration.
+type Changes struct {
+	Added []string
+	// Kept are the entries already in the configuration, which are never
+	// overwritten.
+	Kept []string
+}
+
+// Apply merges the result into the Crush configuration in data, adding the
+// providers, MCP servers and context paths it doesn't have yet.
+func (r *Result) Apply(data []byte) ([]byte, Changes, error) {
+	var changes Changes
+	if len(strings.TrimSpace(string(data))) == 0 {
+		data = []byte("{}")
+	}
+	if !gjson.ValidBytes(data) {
+		return nil, changes, fmt.Errorf("configuration is not valid JSON")
+	}
+
+	set := func(kind, name, path string, value any) error {
+		if gjson.GetBytes(data, path).Exists() {
+			changes.Kept = append(changes.Kept, kind+" "+name)
+			return nil
+		}
+		var err error
+		data, err = sjson.SetBytes(data, path, value)
+		if err != nil {
+			return fmt.Errorf("failed to set %s %s: %w", kind, name, err)
+		}
+		changes.Added = append(changes.Added, kind+" "+name)
+		return nil
+	}
+	for _, id := range sortedKeys(r.Providers) {
+		if err := set("provider", id, "providers."+escapeKey(id), r.Providers[id]); err != nil {
+			return nil, changes, err
+		}
+	}
+	for _, name := range sortedKeys(r.MCP) {
+		if err := set("MCP server", name, "mcp."+escapeKey(name), r.MCP[name]); err != nil {
+			return nil, changes, err
+		}
+	}
+
+	var existing []string
+	for _, p := range gjson.GetBytes(data, "options.context_paths").Array() {
+		existing = append(existing, p.String())
+	}
+	for _, path := range r.ContextPaths {
+		if slices.Contains(existing, path) {
+			changes.Kept = append(changes.Kept, "context path "+path)
+			continue
+		}
+		var err error
+		data, err = sjson.SetBytes(data, "options.context_paths.-1", path)
+		if err != nil {
+			return nil, changes, fmt.Errorf("failed to add context path %s: %w", path, err)
+		}
+		existing = append(existing, path)
+		changes.Added = append(changes.Added, "context path "+path)
+	}
+
+	data, err := indent(data)
+	return data, changes, err
+}
+
+// indent formats the configuration consistently, as sjson inserts values
+// without any indentation.
+func indent(data []byte) ([]byte, error) {
+	var v json.RawMessage = data
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+func escapeKey(key string) string {
+	return strings.NewReplacer(".", `\.`, "*", `\*`, "?", `\?`).Replace(key)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,199 @@
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func write(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestImport(t *testing.T) {
+	t.Parallel()
+
+	t.Run("aider", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		path := write(t, dir, ".aider.conf.yml", `
+model: openai/qwen3-coder
+openai-api-base: http://localhost:8000/v1
+anthropic-api-key: $ANTHROPIC_API_KEY
+api-key:
+  - gemini=AIza123
+  - nope=abc
+read: [CONVENTIONS.md]
+`)
+		r, err := Import(Aider, dir, path)
+		require.NoError(t, err)
+		require.Equal(t, map[string]Provider{
+			"anthropic": {APIKey: "$ANTHROPIC_API_KEY"},
+			"gemini":    {APIKey: "AIza123"},
+			"openai-compat": {
+				Name:    "OpenAI Compatible",
+				Type:    "openai-compat",
+				BaseURL: "http://localhost:8000/v1",
+				Models:  []Model{{ID: "qwen3-coder"}},
+			},
+		}, r.Providers)
+		require.Equal(t, []string{"CONVENTIONS.md"}, r.ContextPaths)
+		require.Len(t, r.Notes, 2)
+	})
+
+	t.Run("continue", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		write(t, dir, ".continue/rules/go.md", "Use gofumpt.")
+		path := write(t, dir, "config.yaml", `
+models:
+  - name: Llama
+    provider: ollama
+    model: llama3.1
+  - name: Sonnet
+    provider: anthropic
+    model: claude-sonnet-4
+    apiKey: ${{ secrets.ANTHROPIC_API_KEY }}
+mcpServers:
+  - name: sqlite
+    command: npx
+    args: [-y, mcp-sqlite]
+  - name: docs
+    type: streamable-http
+    url: https://docs.example.com/mcp
+`)
+		r, err := Import(Continue, dir, path)
+		require.NoError(t, err)
+		require.Equal(t, Provider{
+			Name:    "ollama",
+			Type:    "openai-compat",
+			BaseURL: "http://localhost:11434/v1",
+			Models:  []Model{{ID: "llama3.1", Name: "Llama"}},
+		}, r.Providers["ollama"])
+		require.Contains(t, r.Providers, "anthropic")
+		require.Equal(t, map[string]config.MCPConfig{
+			"sqlite": {Type: config.MCPStdio, Command: "npx", Args: []string{"-y", "mcp-sqlite"}},
+			"docs":   {Type: config.MCPHttp, URL: "https://docs.example.com/mcp"},
+		}, r.MCP)
+		require.Equal(t, []string{".continue/rules/"}, r.ContextPaths)
+	})
+
+	t.Run("claude desktop", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		path := write(t, dir, "claude_desktop_config.json", `{
+			"mcpServers": {
+				"github": {"command": "docker", "args": ["run", "ghcr.io/github/github-mcp-server"], "env": {"GITHUB_TOKEN": "ghp"}}
+			}
+		}`)
+		r, err := Import(ClaudeDesktop, dir, path)
+		require.NoError(t, err)
+		require.Equal(t, map[string]config.MCPConfig{
+			"github": {
+				Type:    config.MCPStdio,
+				Command: "docker",
+				Args:    []string{"run", "ghcr.io/github/github-mcp-server"},
+				Env:     map[string]string{"GITHUB_TOKEN": "ghp"},
+			},
+		}, r.MCP)
+		require.Empty(t, r.Providers)
+	})
+
+	t.Run("opencode", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		path := write(t, dir, "opencode.json", `{
+			"provider": {
+				"openai": {"options": {"apiKey": "{env:OPENAI_KEY}"}},
+				"vllm": {
+					"npm": "@ai-sdk/openai-compatible",
+					"name": "vLLM",
+					"options": {"baseURL": "http://gpu:8000/v1"},
+					"models": {"qwen": {"name": "Qwen"}}
+				}
+			},
+			"mcp": {
+				"fs": {"type": "local", "command": ["npx", "-y", "mcp-fs"], "enabled": false},
+				"search": {"type": "remote", "url": "https://search.example.com/mcp", "headers": {"Authorization": "Bearer {env:SEARCH_TOKEN}"}}
+			},
+			"instructions": ["CONTRIBUTING.md", "docs/*.md"]
+		}`)
+		r, err := Import(OpenCode, dir, path)
+		require.NoError(t, err)
+		require.Equal(t, map[string]Provider{
+			"openai": {APIKey: "$OPENAI_KEY"},
+			"vllm": {
+				Name:    "vLLM",
+				Type:    "openai-compat",
+				BaseURL: "http://gpu:8000/v1",
+				Models:  []Model{{ID: "qwen", Name: "Qwen"}},
+			},
+		}, r.Providers)
+		require.Equal(t, map[string]config.MCPConfig{
+			"fs":     {Type: config.MCPStdio, Command: "npx", Args: []string{"-y", "mcp-fs"}, Disabled: true},
+			"search": {Type: config.MCPHttp, URL: "https://search.example.com/mcp", Headers: map[string]string{"Authorization": "Bearer $SEARCH_TOKEN"}},
+		}, r.MCP)
+		require.Equal(t, []string{"CONTRIBUTING.md"}, r.ContextPaths)
+		require.Len(t, r.Notes, 1)
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		_, err := Import(OpenCode, dir, filepath.Join(dir, "opencode.json"))
+		require.ErrorContains(t, err, "no opencode configuration found")
+		_, err = Import("cursor", dir)
+		require.ErrorContains(t, err, "unknown tool")
+	})
+}
+
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	r := &Result{
+		Providers: map[string]Provider{
+			"openai": {APIKey: "$OPENAI_API_KEY"},
+			"vllm":   {Type: "openai-compat", BaseURL: "http://gpu:8000/v1", Models: []Model{{ID: "qwen"}}},
+		},
+		MCP: map[string]config.MCPConfig{
+			"fs":     {Type: config.MCPStdio, Command: "mcp-fs"},
+			"search": {Type: config.MCPHttp, URL: "https://search.example.com/mcp"},
+		},
+		ContextPaths: []string{"CONTRIBUTING.md", "CRUSH.md"},
+	}
+	data, changes, err := r.Apply([]byte(`{
+  "providers": {"openai": {"api_key": "$MY_KEY"}},
+  "mcp": {"fs": {"type": "stdio", "command": "my-fs"}},
+  "options": {"context_paths": ["CRUSH.md"]}
+}`))
+	require.NoError(t, err)
+	require.Equal(t, []string{"provider vllm", "MCP server search", "context path CONTRIBUTING.md"}, changes.Added)
+	require.Equal(t, []string{"provider openai", "MCP server fs", "context path CRUSH.md"}, changes.Kept)
+	require.JSONEq(t, `{
+		"providers": {
+			"openai": {"api_key": "$MY_KEY"},
+			"vllm": {"type": "openai-compat", "base_url": "http://gpu:8000/v1", "models": [{"id": "qwen"}]}
+		},
+		"mcp": {
+			"fs": {"type": "stdio", "command": "my-fs"},
+			"search": {"type": "http", "url": "https://search.example.com/mcp"}
+		},
+		"options": {"context_paths": ["CRUSH.md", "CONTRIBUTING.md"]}
+	}`, string(data))
+	require.Contains(t, string(data), "\n  \"providers\": {\n")
+
+	data, changes, err = r.Apply(nil)
+	require.NoError(t, err)
+	require.Len(t, changes.Added, 6)
+	require.Contains(t, string(data), `"CONTRIBUTING.md"`)
+
+	_, _, err = r.Apply([]byte(`{"providers":`))
+	require.Error(t, err)
+}
@@ -0,0 +1,148 @@
+package importer
+
+import (
+	"cmp"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/home"
+)
+
+// openCodePaths are where opencode looks for its configuration: the global
+// one, then the project.
+func openCodePaths(cwd string) []string {
+	dir := filepath.Join(home.Dir(), ".config", "opencode")
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		dir = filepath.Join(xdg, "opencode")
+	}
+	return []string{
+		filepath.Join(dir, "opencode.json"),
+		filepath.Join(cwd, "opencode.json"),
+	}
+}
+
+// openCodeConfig is the part of opencode.json worth importing.
+type openCodeConfig struct {
+	Provider map[string]struct {
+		NPM     string `json:"npm"`
+		Name    string `json:"name"`
+		Options struct {
+			BaseURL string `json:"baseURL"`
+			APIKey  string `json:"apiKey"`
+		} `json:"options"`
+		Models map[string]struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	} `json:"provider"`
+	MCP map[string]struct {
+		Type        string            `json:"type"`
+		Command     []string          `json:"command"`
+		Environment map[string]string `json:"environment"`
+		URL         string            `json:"url"`
+		Headers     map[string]string `json:"headers"`
+		Enabled     *bool             `json:"enabled"`
+	} `json:"mcp"`
+	Instructions []string `json:"instructions"`
+}
+
+// openCodeEnv matches the {env:NAME} references of opencode to environment
+// variables.
+var openCodeEnv = regexp.MustCompile(`\{env:([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// fromOpenCode rewrites the environment variable references of opencode as
+// the ones of Crush.
+func fromOpenCode(s string) string {
+	return openCodeEnv.ReplaceAllString(s, "$$${1}")
+}
+
+func fromOpenCodeMap(m map[string]string) map[string]string {
+	if len(m) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[k] = fromOpenCode(v)
+	}
+	return out
+}
+
+func readOpenCode(r *Result, _ string, data []byte) error {
+	var cfg openCodeConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return err
+	}
+
+	for _, id := range sortedKeys(cfg.Provider) {
+		p := cfg.Provider[id]
+		apiKey := fromOpenCode(p.Options.APIKey)
+		if strings.Contains(apiKey, "{file:") {
+			r.note("skipped the API key of %s, read from a file, set it in the provider by hand", id)
+			apiKey = ""
+		}
+		if known, ok := knownProviders[id]; ok && p.Options.BaseURL == "" {
+			r.setAPIKey(known, apiKey)
+			continue
+		}
+		baseURL := cmp.Or(fromOpenCode(p.Options.BaseURL), localProviders[id])
+		if baseURL == "" {
+			r.note("skipped the provider %s, which has no base URL", id)
+			continue
+		}
+		typ := "openai-compat"
+		if p.NPM == "@ai-sdk/anthropic" {
+			typ = "anthropic"
+		}
+		provider := Provider{
+			Name:    cmp.Or(p.Name, id),
+			Type:    typ,
+			BaseURL: baseURL,
+			APIKey:  apiKey,
+		}
+		if len(p.Models) == 0 {
+			r.addModel(id, provider, Model{})
+			r.note("the provider %s lists no models, add the models to use to it", id)
+		}
+		for _, model := range sortedKeys(p.Models) {
+			r.addModel(id, provider, Model{ID: model, Name: p.Models[model].Name})
+		}
+	}
+
+	for _, name := range sortedKeys(cfg.MCP) {
+		s := cfg.MCP[name]
+		mcp := config.MCPConfig{
+			Env:      fromOpenCodeMap(s.Environment),
+			Headers:  fromOpenCodeMap(s.Headers),
+			Disabled: s.Enabled != nil && !*s.Enabled,
+		}
+		switch s.Type {
+		case "local":
+			if len(s.Command) == 0 {
+				r.note("skipped the MCP server %s, which has no command", name)
+				continue
+			}
+			mcp.Type = config.MCPStdio
+			mcp.Command = s.Command[0]
+			mcp.Args = s.Command[1:]
+		case "remote":
+			mcp.Type = config.MCPHttp
+			mcp.URL = fromOpenCode(s.URL)
+		default:
+			r.note("skipped the MCP server %s, whose %s type Crush doesn't support", name, s.Type)
+			continue
+		}
+		r.MCP[name] = mcp
+	}
+
+	for _, path := range cfg.Instructions {
+		if strings.ContainsAny(path, "*?[") {
+			r.note("skipped the instructions %s, as context paths can't be patterns", path)
+			continue
+		}
+		r.addContextPath(path)
+	}
+	return nil
+}
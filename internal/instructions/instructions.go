@@ -0,0 +1,122 @@
+// Package instructions finds the instruction files of the subdirectories of
+// the workspace, like frontend/CRUSH.md, that apply to the files beneath
+// them. The ones at the root of the workspace are context files, always in
+// the system prompt.
+package instructions
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/fsext"
+)
+
+// File is the instruction file of a subdirectory.
+type File struct {
+	// Path is the absolute path of the file.
+	Path string
+	// Dir is the directory the instructions apply to, relative to the
+	// workspace and with forward slashes.
+	Dir     string
+	Content string
+}
+
+// Names returns the names instruction files can have: the context paths
+// that are plain file names, like CRUSH.md or AGENTS.md.
+func Names(contextPaths []string) []string {
+	var names []string
+	for _, p := range contextPaths {
+		if p != "" && !strings.ContainsAny(p, `/\`) && !slices.Contains(names, p) {
+			names = append(names, p)
+		}
+	}
+	return names
+}
+
+// For returns the instruction files that apply to a path in the workspace,
+// from the outermost directory to the innermost. Instructions of inner
+// directories take precedence over the ones of outer directories, which
+// take precedence over the context files of the workspace.
+func For(root, path string, names []string) []File {
+	root, path = filepath.Clean(root), filepath.Clean(path)
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil
+	}
+	dir := path
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		dir = filepath.Dir(path)
+	}
+
+	var files []File
+	for dir != root && dir != filepath.Dir(dir) {
+		files = append(inDir(root, dir, names), files...)
+		dir = filepath.Dir(dir)
+	}
+	return files
+}
+
+// Workspace returns the instruction files at the root of the workspace.
+func Workspace(root string, names []string) []File {
+	root = filepath.Clean(root)
+	return inDir(root, root, names)
+}
+
+// All returns the instruction files of all the subdirectories of the
+// workspace that aren't ignored, sorted by directory.
+func All(root string, names []string) ([]File, error) {
+	root = filepath.Clean(root)
+	paths, _, err := fsext.ListDirectory(root, nil, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	var files []File
+	for _, path := range paths {
+		path = filepath.FromSlash(path)
+		if !slices.Contains(names, filepath.Base(path)) || filepath.Dir(path) == root {
+			continue
+		}
+		if file, ok := read(root, path); ok {
+			files = append(files, file)
+		}
+	}
+	slices.SortFunc(files, func(a, b File) int {
+		return strings.Compare(a.Dir+"/"+filepath.Base(a.Path), b.Dir+"/"+filepath.Base(b.Path))
+	})
+	return files, nil
+}
+
+// inDir returns the instruction files of a directory, in the order of
+// names.
+func inDir(root, dir string, names []string) []File {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var files []File
+	for _, name := range names {
+		// Entries are looked up by name rather than with os.Stat, so a
+		// file isn't found twice on case-insensitive file systems.
+		if !slices.ContainsFunc(entries, func(e os.DirEntry) bool { return e.Name() == name && !e.IsDir() }) {
+			continue
+		}
+		if file, ok := read(root, filepath.Join(dir, name)); ok {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+func read(root, path string) (File, bool) {
+	content, err := os.ReadFile(path)
+	if err != nil || strings.TrimSpace(string(content)) == "" {
+		return File{}, false
+	}
+	dir, err := filepath.Rel(root, filepath.Dir(path))
+	if err != nil {
+		return File{}, false
+	}
+	return File{Path: path, Dir: filepath.ToSlash(dir), Content: string(content)}, true
+}
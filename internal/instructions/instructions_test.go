@@ -0,0 +1,50 @@
+package instructions
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNames(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, []string{".cursorrules", "CRUSH.md", "AGENTS.md"}, Names([]string{
+		".github/copilot-instructions.md", ".cursorrules", ".cursor/rules/", "CRUSH.md", "AGENTS.md", "CRUSH.md",
+	}))
+}
+
+func TestFor(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	write := func(path, content string) {
+		path = filepath.Join(root, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	write("CRUSH.md", "root")
+	write("web/CRUSH.md", "web")
+	write("web/AGENTS.md", "agents")
+	write("web/app/CRUSH.md", "app")
+	write("web/app/main.ts", "")
+	write("web/lib/CRUSH.md", "  \n")
+	write("web/lib/util.ts", "")
+
+	names := []string{"CRUSH.md", "AGENTS.md"}
+	dirs := func(files []File) []string {
+		var dirs []string
+		for _, f := range files {
+			dirs = append(dirs, f.Dir+":"+f.Content)
+		}
+		return dirs
+	}
+	require.Equal(t, []string{"web:web", "web:agents", "web/app:app"}, dirs(For(root, filepath.Join(root, "web/app/main.ts"), names)))
+	require.Equal(t, []string{"web:web", "web:agents", "web/app:app"}, dirs(For(root, filepath.Join(root, "web/app"), names)))
+	require.Equal(t, []string{"web:web", "web:agents"}, dirs(For(root, filepath.Join(root, "web/lib/util.ts"), names)))
+	require.Empty(t, For(root, filepath.Join(root, "main.go"), names))
+	require.Empty(t, For(root, root, names))
+	require.Empty(t, For(root, filepath.Dir(root), names))
+}
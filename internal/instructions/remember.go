@@ -0,0 +1,95 @@
+package instructions
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// memoryHeading is the heading of the section of instruction files the
+// instructions to remember are added to.
+const memoryHeading = "## Memory"
+
+// projectNames are the names of the instruction file of the workspace
+// instructions are remembered in, the first one when none exists.
+var projectNames = []string{"CRUSH.md", "Crush.md", "crush.md"}
+
+// ProjectFile returns the path of the CRUSH.md of the workspace.
+func ProjectFile(root string) string {
+	for _, name := range projectNames {
+		path := filepath.Join(root, name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return filepath.Join(root, projectNames[0])
+}
+
+// Remember adds an instruction to the Memory section of an instruction
+// file, creating the section and the file when missing.
+func Remember(path, instruction string) error {
+	instruction = strings.Join(strings.Fields(instruction), " ")
+	if instruction == "" {
+		return errors.New("there's no instruction to remember")
+	}
+	content, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(remember(string(content), instruction)), 0o644)
+}
+
+// remember returns content with the instruction as the last item of its
+// Memory section.
+func remember(content, instruction string) string {
+	item := "- " + instruction + "\n"
+	lines := strings.SplitAfter(content, "\n")
+	start := slices.IndexFunc(lines, func(line string) bool {
+		return strings.TrimSpace(line) == memoryHeading
+	})
+	if start < 0 {
+		content = strings.TrimRight(content, "\n")
+		if content != "" {
+			content += "\n\n"
+		}
+		return content + memoryHeading + "\n\n" + item
+	}
+
+	// The section ends at the next heading of the same level or above.
+	end := len(lines)
+	for i := start + 1; i < len(lines); i++ {
+		if strings.HasPrefix(lines[i], "# ") || strings.HasPrefix(lines[i], "## ") {
+			end = i
+			break
+		}
+	}
+	at := end
+	for at > start+1 && strings.TrimSpace(lines[at-1]) == "" {
+		at--
+	}
+
+	var b strings.Builder
+	for _, line := range lines[:at] {
+		b.WriteString(line)
+	}
+	if !strings.HasSuffix(b.String(), "\n") {
+		b.WriteString("\n")
+	}
+	if at == start+1 {
+		b.WriteString("\n")
+	}
+	b.WriteString(item)
+	if at < len(lines) && at == end && lines[at] != "" {
+		b.WriteString("\n")
+	}
+	for _, line := range lines[at:] {
+		b.WriteString(line)
+	}
+	return b.String()
+}
@@ -0,0 +1,39 @@
+package instructions
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemember(t *testing.T) {
+	t.Parallel()
+
+	for content, want := range map[string]string{
+		"":                                "## Memory\n\n- Use tabs\n",
+		"# Project\n\nRun make.":          "# Project\n\nRun make.\n\n## Memory\n\n- Use tabs\n",
+		"## Memory\n\n- Be brief\n":       "## Memory\n\n- Be brief\n- Use tabs\n",
+		"## Memory\n- Be brief":           "## Memory\n- Be brief\n- Use tabs\n",
+		"## Memory\n## Build\n\nRun make": "## Memory\n\n- Use tabs\n\n## Build\n\nRun make",
+		"# P\n\n## Memory\n\n- Be brief\n\n## Build\n\nRun make\n": "# P\n\n## Memory\n\n- Be brief\n- Use tabs\n\n## Build\n\nRun make\n",
+	} {
+		require.Equal(t, want, remember(content, "Use tabs"), content)
+	}
+}
+
+func TestRememberFile(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	require.Equal(t, filepath.Join(root, "CRUSH.md"), ProjectFile(root))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "crush.md"), []byte("# Notes\n"), 0o644))
+	path := ProjectFile(root)
+
+	require.NoError(t, Remember(path, "  Prefer\n table-driven   tests "))
+	require.Error(t, Remember(path, " \n"))
+	content, err := os.ReadFile(filepath.Join(root, "crush.md"))
+	require.NoError(t, err)
+	require.Equal(t, "# Notes\n\n## Memory\n\n- Prefer table-driven tests\n", string(content))
+}
@@ -0,0 +1,87 @@
+// Package keychain keeps secrets in the keychain of the operating system, so
+// configuration can reference them by name instead of holding them. It uses
+// security on macOS and secret-tool from libsecret on Linux.
+package keychain
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// service is the service the secrets of Crush are kept under.
+const service = "crush"
+
+// timeout bounds how long the keychain has to answer, as it may prompt the
+// user to unlock it.
+const timeout = time.Minute
+
+// ErrUnsupported is returned on platforms without a supported keychain.
+var ErrUnsupported = errors.New("no supported keychain on this platform: use an environment variable instead")
+
+// ErrNotFound is returned when the keychain has no secret with the name.
+var ErrNotFound = errors.New("secret not found in the keychain")
+
+// Get returns the secret with the name.
+func Get(name string) (string, error) {
+	var cmd []string
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = []string{"security", "find-generic-password", "-s", service, "-a", name, "-w"}
+	case "linux":
+		cmd = []string{"secret-tool", "lookup", "service", service, "account", name}
+	default:
+		return "", ErrUnsupported
+	}
+	out, err := run(cmd, "")
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && (len(exitErr.Stderr) == 0 || bytes.Contains(exitErr.Stderr, []byte("could not be found"))) {
+		// Both tools fail without saying why when there's no such secret,
+		// security with a message.
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	secret := strings.TrimRight(out, "\r\n")
+	if secret == "" {
+		return "", ErrNotFound
+	}
+	return secret, nil
+}
+
+// Set keeps the secret under the name, replacing the one it had.
+func Set(name, secret string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		// security only takes the secret as an argument.
+		_, err := run([]string{"security", "add-generic-password", "-U", "-s", service, "-a", name, "-w", secret}, "")
+		return err
+	case "linux":
+		_, err := run([]string{"secret-tool", "store", "--label", service + " " + name, "service", service, "account", name}, secret)
+		return err
+	default:
+		return ErrUnsupported
+	}
+}
+
+func run(args []string, stdin string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdin = strings.NewReader(stdin)
+	out, err := cmd.Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+		return "", fmt.Errorf("%s: %s: %w", args[0], bytes.TrimSpace(exitErr.Stderr), err)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to run %s: %w", args[0], err)
+	}
+	return string(out), nil
+}
@@ -0,0 +1,274 @@
+// Package keyring rotates between the API keys of a provider, moving on to
+// the next key when one is rejected or rate limited, so teams sharing
+// rate-limited keys keep working.
+package keyring
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/csync"
+)
+
+const (
+	// rateLimitCooldown is how long a rate limited key is left alone when the
+	// provider doesn't say when to retry.
+	rateLimitCooldown = time.Minute
+	// unauthorizedCooldown is how long a rejected key is left alone, as it
+	// was likely revoked.
+	unauthorizedCooldown = time.Hour
+)
+
+var rings = csync.NewMap[string, *Keyring]()
+
+// For returns the shared keyring of a provider, so every agent and session
+// talking to it rotates through the same keys. It returns nil when there's
+// a single key. Changing the keys replaces the keyring.
+func For(provider string, keys []string) *Keyring {
+	if len(keys) < 2 {
+		rings.Del(provider)
+		return nil
+	}
+	if r, ok := rings.Get(provider); ok && slices.Equal(r.values(), keys) {
+		return r
+	}
+	r := New(provider, keys)
+	rings.Set(provider, r)
+	return r
+}
+
+// All returns the status of the keys of every provider with a keyring,
+// sorted by provider.
+func All() []Status {
+	var all []Status
+	for _, r := range rings.Seq2() {
+		all = append(all, r.Status())
+	}
+	slices.SortFunc(all, func(a, b Status) int {
+		return strings.Compare(a.Provider, b.Provider)
+	})
+	return all
+}
+
+// Status is how the keys of a provider have been used.
+type Status struct {
+	Provider string
+	Keys     []KeyStatus
+}
+
+// KeyStatus is how a key has been used.
+type KeyStatus struct {
+	// Key is the key with all but its last characters masked.
+	Key          string
+	Active       bool
+	Requests     int
+	RateLimited  int
+	Unauthorized int
+	// Until is when the key is used again after being rate limited or
+	// rejected, zero when it's available.
+	Until time.Time
+}
+
+// Keyring is the API keys of a provider, and which one requests use.
+type Keyring struct {
+	provider string
+	now      func() time.Time
+
+	mu     sync.Mutex
+	keys   []*key
+	active int
+}
+
+type key struct {
+	value        string
+	requests     int
+	rateLimited  int
+	unauthorized int
+	until        time.Time
+}
+
+// New returns a keyring starting with the first key.
+func New(provider string, keys []string) *Keyring {
+	r := &Keyring{provider: provider, now: time.Now}
+	for _, k := range keys {
+		r.keys = append(r.keys, &key{value: k})
+	}
+	return r
+}
+
+func (r *Keyring) values() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	values := make([]string, len(r.keys))
+	for i, k := range r.keys {
+		values[i] = k.value
+	}
+	return values
+}
+
+// Status returns how the keys have been used.
+func (r *Keyring) Status() Status {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	status := Status{Provider: r.provider}
+	now := r.now()
+	for i, k := range r.keys {
+		ks := KeyStatus{
+			Key:          Mask(k.value),
+			Active:       i == r.active,
+			Requests:     k.requests,
+			RateLimited:  k.rateLimited,
+			Unauthorized: k.unauthorized,
+		}
+		if k.until.After(now) {
+			ks.Until = k.until
+		}
+		status.Keys = append(status.Keys, ks)
+	}
+	return status
+}
+
+// acquire returns the key the next request uses: the active one unless it's
+// resting, then the next available one, or the one available the soonest.
+func (r *Keyring) acquire() (int, string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.now()
+	next := r.active
+	for i := range r.keys {
+		j := (r.active + i) % len(r.keys)
+		if !r.keys[j].until.After(now) {
+			next = j
+			break
+		}
+		if r.keys[j].until.Before(r.keys[next].until) {
+			next = j
+		}
+	}
+	r.active = next
+	r.keys[next].requests++
+	return next, r.keys[next].value
+}
+
+// record records how the provider answered a request with a key, and
+// reports whether the request is worth retrying with another key.
+func (r *Keyring) record(i, status int, retryAfter time.Duration) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.now()
+	k := r.keys[i]
+	switch status {
+	case http.StatusTooManyRequests:
+		k.rateLimited++
+		k.until = now.Add(cmp.Or(retryAfter, rateLimitCooldown))
+	case http.StatusUnauthorized:
+		k.unauthorized++
+		k.until = now.Add(unauthorizedCooldown)
+	default:
+		k.until = time.Time{}
+		return false
+	}
+	for _, other := range r.keys {
+		if !other.until.After(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// Mask hides all but the last characters of a key.
+func Mask(key string) string {
+	if len(key) <= 8 {
+		return strings.Repeat("•", len(key))
+	}
+	return "…" + key[len(key)-4:]
+}
+
+// Transport sends requests with the active key of a keyring, retrying them
+// with the next key when the provider rejects or rate limits the active one.
+// The client sending them is set up with the first key, which is swapped in
+// the authentication headers and query parameters of requests.
+type Transport struct {
+	Base http.RoundTripper
+	ring *Keyring
+}
+
+// NewClient returns an HTTP client rotating through the keys of the keyring,
+// sending requests through base, or the default transport when nil.
+func NewClient(base http.RoundTripper, ring *Keyring) *http.Client {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &http.Client{Transport: &Transport{Base: base, ring: ring}}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+	}
+	first := t.ring.keys[0].value
+	for attempt := 1; ; attempt++ {
+		i, value := t.ring.acquire()
+		r := withKey(req, first, value)
+		if body != nil {
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			r.GetBody = func() (io.ReadCloser, error) {
+				return io.NopCloser(bytes.NewReader(body)), nil
+			}
+		}
+		resp, err := t.Base.RoundTrip(r)
+		if err != nil {
+			return nil, err
+		}
+		retry := t.ring.record(i, resp.StatusCode, retryAfter(resp.Header))
+		if !retry || attempt >= len(t.ring.keys) {
+			return resp, nil
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+}
+
+// withKey returns a copy of the request with the key it was set up with
+// replaced by another one.
+func withKey(req *http.Request, from, to string) *http.Request {
+	r := req.Clone(req.Context())
+	if from == "" || from == to {
+		return r
+	}
+	for name, values := range r.Header {
+		for i, v := range values {
+			r.Header[name][i] = strings.ReplaceAll(v, from, to)
+		}
+	}
+	r.URL.RawQuery = strings.ReplaceAll(r.URL.RawQuery, url.QueryEscape(from), url.QueryEscape(to))
+	return r
+}
+
+// retryAfter returns how long the provider asks to wait before retrying, or
+// zero when it doesn't say.
+func retryAfter(header http.Header) time.Duration {
+	v := header.Get("Retry-After")
+	if seconds, err := strconv.Atoi(v); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0)
+	}
+	return 0
+}
@@ -0,0 +1,81 @@
+package keyring
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransport(t *testing.T) {
+	t.Parallel()
+
+	var seen []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		seen = append(seen, key+"/"+r.URL.Query().Get("key"))
+		switch key {
+		case "key-one":
+			w.Header().Set("Retry-After", "30")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case "key-two":
+			w.WriteHeader(http.StatusUnauthorized)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	ring := New("openai", []string{"key-one", "key-two", "key-three"})
+	now := time.Now()
+	ring.now = func() time.Time { return now }
+	client := NewClient(nil, ring)
+
+	send := func() int {
+		req, err := http.NewRequestWithContext(t.Context(), http.MethodPost, server.URL+"?key=key-one", strings.NewReader("{}"))
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer key-one")
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	require.Equal(t, http.StatusOK, send())
+	require.Equal(t, []string{"key-one/key-one", "key-two/key-two", "key-three/key-three"}, seen)
+
+	// The last key that worked stays active.
+	seen = nil
+	require.Equal(t, http.StatusOK, send())
+	require.Equal(t, []string{"key-three/key-three"}, seen)
+
+	status := ring.Status()
+	require.Equal(t, "openai", status.Provider)
+	require.Equal(t, 1, status.Keys[0].RateLimited)
+	require.Equal(t, now.Add(30*time.Second), status.Keys[0].Until)
+	require.Equal(t, 1, status.Keys[1].Unauthorized)
+	require.True(t, status.Keys[2].Active)
+	require.Equal(t, 2, status.Keys[2].Requests)
+
+	// Once the rate limit is over, the first key is available again.
+	now = now.Add(time.Minute)
+	require.True(t, ring.Status().Keys[0].Until.IsZero())
+	require.False(t, ring.Status().Keys[1].Until.IsZero())
+}
+
+func TestFor(t *testing.T) {
+	require.Nil(t, For("single", []string{"key"}))
+	ring := For("team", []string{"key-one", "key-two"})
+	require.Same(t, ring, For("team", []string{"key-one", "key-two"}))
+	require.NotSame(t, ring, For("team", []string{"key-one", "key-three"}))
+	require.Len(t, All(), 1)
+}
+
+func TestMask(t *testing.T) {
+	t.Parallel()
+	require.Equal(t, "…cdef", Mask("sk-0123456789abcdef"))
+	require.Equal(t, "•••", Mask("abc"))
+}
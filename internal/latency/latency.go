@@ -0,0 +1,237 @@
+// Package latency keeps how fast models respond on this machine: the time to
+// their first token and the output tokens they stream per second, averaged
+// over their latest responses, to help choose between models.
+package latency
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+)
+
+const (
+	fileName = "latency.json"
+	// window is how many of the latest responses of a model are averaged.
+	window = 20
+)
+
+// Sample is how fast a model gave a single response.
+type Sample struct {
+	TTFT time.Duration `json:"ttft"`
+	// TokensPerSecond is 0 when the response was too short to tell.
+	TokensPerSecond float64 `json:"tokens_per_second,omitempty"`
+}
+
+// Stats are the averages over the latest responses of a model.
+type Stats struct {
+	Provider        string
+	Model           string
+	TTFT            time.Duration
+	TokensPerSecond float64
+	Samples         int
+}
+
+// String formats the averages like "850ms · 62 tok/s".
+func (s Stats) String() string {
+	ttft := s.TTFT.Round(time.Millisecond).String()
+	if s.TTFT >= time.Second {
+		ttft = fmt.Sprintf("%.1fs", s.TTFT.Seconds())
+	}
+	if s.TokensPerSecond == 0 {
+		return ttft
+	}
+	return fmt.Sprintf("%s · %.0f tok/s", ttft, s.TokensPerSecond)
+}
+
+type store struct {
+	mu     sync.Mutex
+	path   string
+	loaded bool
+	// samples are the latest samples by provider and model, oldest first.
+	samples map[string]map[string][]Sample
+}
+
+var defaultStore = &store{}
+
+// FilePath returns the path to the file the latencies are kept in.
+func FilePath() string {
+	return filepath.Join(filepath.Dir(config.GlobalConfigData()), fileName)
+}
+
+// Init starts recording the latencies of models. Until it's called,
+// recording does nothing.
+func Init() {
+	defaultStore.mu.Lock()
+	defer defaultStore.mu.Unlock()
+	defaultStore.path = FilePath()
+	defaultStore.loaded = false
+}
+
+func (s *store) load() {
+	if s.loaded {
+		return
+	}
+	s.loaded = true
+	s.samples = map[string]map[string][]Sample{}
+	if s.path == "" {
+		return
+	}
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	if err == nil {
+		err = json.Unmarshal(data, &s.samples)
+	}
+	if err != nil {
+		slog.Warn("Failed to load model latencies", "path", s.path, "error", err)
+		s.samples = map[string]map[string][]Sample{}
+	}
+}
+
+func (s *store) save() error {
+	data, err := json.Marshal(s.samples)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, data, 0o600)
+}
+
+func (s *store) record(provider, model string, sample Sample) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.path == "" {
+		return
+	}
+	s.load()
+	models, ok := s.samples[provider]
+	if !ok {
+		models = map[string][]Sample{}
+		s.samples[provider] = models
+	}
+	samples := append(models[model], sample)
+	models[model] = samples[max(len(samples)-window, 0):]
+	if err := s.save(); err != nil {
+		slog.Warn("Failed to save model latencies", "path", s.path, "error", err)
+	}
+}
+
+func (s *store) get(provider, model string) (Stats, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+	samples := s.samples[provider][model]
+	if len(samples) == 0 {
+		return Stats{}, false
+	}
+	return average(provider, model, samples), true
+}
+
+func (s *store) all() []Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+	var all []Stats
+	for provider, models := range s.samples {
+		for model, samples := range models {
+			if len(samples) > 0 {
+				all = append(all, average(provider, model, samples))
+			}
+		}
+	}
+	slices.SortFunc(all, func(a, b Stats) int {
+		return cmp.Or(strings.Compare(a.Provider, b.Provider), strings.Compare(a.Model, b.Model))
+	})
+	return all
+}
+
+// average averages the samples. Responses too short to tell how fast the
+// model streams don't count towards its tokens per second.
+func average(provider, model string, samples []Sample) Stats {
+	var ttft time.Duration
+	var tps float64
+	var rated int
+	for _, sample := range samples {
+		ttft += sample.TTFT
+		if sample.TokensPerSecond > 0 {
+			tps += sample.TokensPerSecond
+			rated++
+		}
+	}
+	stats := Stats{
+		Provider: provider,
+		Model:    model,
+		TTFT:     ttft / time.Duration(len(samples)),
+		Samples:  len(samples),
+	}
+	if rated > 0 {
+		stats.TokensPerSecond = tps / float64(rated)
+	}
+	return stats
+}
+
+// Get returns the averages of a model, and false when none of its responses
+// were recorded.
+func Get(provider, model string) (Stats, bool) {
+	return defaultStore.get(provider, model)
+}
+
+// All returns the averages of every model with recorded responses, by
+// provider and model.
+func All() []Stats {
+	return defaultStore.all()
+}
+
+// Timer times the responses of a model as they stream.
+type Timer struct {
+	start, first, last time.Time
+}
+
+// Start starts timing a response, when its request is sent.
+func (t *Timer) Start() {
+	*t = Timer{start: time.Now()}
+}
+
+// Token marks that a chunk of the response was received.
+func (t *Timer) Token() {
+	now := time.Now()
+	if t.first.IsZero() {
+		t.first = now
+	}
+	t.last = now
+}
+
+// Record records how fast the model gave the response, once it's done. It
+// does nothing when the response wasn't started or nothing was received.
+func (t *Timer) Record(provider, model string, outputTokens int64) {
+	defer func() { *t = Timer{} }()
+	if sample, ok := t.sample(outputTokens); ok {
+		defaultStore.record(provider, model, sample)
+	}
+}
+
+func (t *Timer) sample(outputTokens int64) (Sample, bool) {
+	if t.start.IsZero() || t.first.IsZero() {
+		return Sample{}, false
+	}
+	sample := Sample{TTFT: t.first.Sub(t.start)}
+	// The first chunk arrives at once, so the rate counts the tokens after
+	// it.
+	if streaming := t.last.Sub(t.first); streaming > 0 && outputTokens > 1 {
+		sample.TokensPerSecond = float64(outputTokens-1) / streaming.Seconds()
+	}
+	return sample, true
+}
@@ -0,0 +1,54 @@
+package latency
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), fileName)
+	s := &store{path: path}
+	_, ok := s.get("anthropic", "claude")
+	require.False(t, ok)
+
+	for i := range window + 5 {
+		s.record("anthropic", "claude", Sample{TTFT: time.Duration(i) * time.Second, TokensPerSecond: float64(i)})
+	}
+	s.record("openai", "gpt", Sample{TTFT: time.Second})
+	s.record("openai", "gpt", Sample{TTFT: 3 * time.Second, TokensPerSecond: 50})
+
+	stats, ok := s.get("anthropic", "claude")
+	require.True(t, ok)
+	require.Equal(t, window, stats.Samples)
+	// The first 5 samples fell out of the window: 5 to 24 are averaged.
+	require.Equal(t, 14500*time.Millisecond, stats.TTFT)
+	require.InDelta(t, 14.5, stats.TokensPerSecond, 0.001)
+
+	// Reloaded from the file.
+	s = &store{path: path}
+	all := s.all()
+	require.Len(t, all, 2)
+	require.Equal(t, "claude", all[0].Model)
+	require.Equal(t, Stats{Provider: "openai", Model: "gpt", TTFT: 2 * time.Second, TokensPerSecond: 50, Samples: 2}, all[1])
+	require.Equal(t, "2.0s · 50 tok/s", all[1].String())
+}
+
+func TestTimer(t *testing.T) {
+	t.Parallel()
+
+	var timer Timer
+	_, ok := timer.sample(10)
+	require.False(t, ok)
+
+	start := time.Now()
+	timer = Timer{start: start, first: start.Add(500 * time.Millisecond), last: start.Add(2500 * time.Millisecond)}
+	sample, ok := timer.sample(101)
+	require.True(t, ok)
+	require.Equal(t, Sample{TTFT: 500 * time.Millisecond, TokensPerSecond: 50}, sample)
+	require.Equal(t, "500ms", Stats{TTFT: sample.TTFT}.String())
+}
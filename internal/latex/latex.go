@@ -0,0 +1,658 @@
+// Package latex finds the LaTeX math in Markdown, and approximates it with
+// Unicode for terminals, which can't typeset it.
+package latex
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Formula is a piece of math found in text.
+type Formula struct {
+	// TeX is the formula without its delimiters.
+	TeX string
+	// Display is set for formulas set apart from the text, like $$x$$ and
+	// \[x\], rather than inline, like $x$ and \(x\).
+	Display bool
+}
+
+// Find returns the formulas in Markdown text, leaving out code.
+func Find(text string) []Formula {
+	var formulas []Formula
+	Replace(text, func(f Formula) string {
+		formulas = append(formulas, f)
+		return ""
+	})
+	return formulas
+}
+
+// Render replaces the formulas in Markdown text with their Unicode
+// approximation. The ones too complex to approximate are kept as written,
+// in code spans and blocks so they stand out from the text.
+func Render(text string) string {
+	return Replace(text, func(f Formula) string {
+		out, ok := ToUnicode(f.TeX)
+		switch {
+		case ok && f.Display:
+			return "\n\n" + strings.Join(strings.Split(escapeMarkdown(out), "\n"), "\\\n") + "\n\n"
+		case ok:
+			return escapeMarkdown(out)
+		case f.Display:
+			return "\n\n```latex\n" + strings.TrimSpace(f.TeX) + "\n```\n\n"
+		default:
+			return codeSpan(f.TeX)
+		}
+	})
+}
+
+// Replace calls fn with each formula in Markdown text, and replaces the
+// formula, delimiters included, with what fn returns. Fenced code blocks
+// and code spans are left alone.
+func Replace(text string, fn func(Formula) string) string {
+	var out, prose strings.Builder
+	flush := func() {
+		out.WriteString(replaceProse(prose.String(), fn))
+		prose.Reset()
+	}
+	var fence string
+	for line := range strings.SplitAfterSeq(text, "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		switch {
+		case fence != "":
+			out.WriteString(line)
+			if strings.HasPrefix(strings.TrimSpace(line), fence) {
+				fence = ""
+			}
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			flush()
+			fence = trimmed[:3]
+			out.WriteString(line)
+		default:
+			prose.WriteString(line)
+		}
+	}
+	flush()
+	return out.String()
+}
+
+// replaceProse replaces the formulas of text outside of fenced code blocks.
+func replaceProse(text string, fn func(Formula) string) string {
+	if !strings.ContainsAny(text, "$\\") {
+		return text
+	}
+	var out strings.Builder
+	for i := 0; i < len(text); {
+		switch c := text[i]; {
+		case c == '`':
+			// Code spans end with as many backticks as they start with.
+			n := countPrefix(text[i:], '`')
+			end := strings.Index(text[i+n:], strings.Repeat("`", n))
+			if end < 0 {
+				out.WriteString(text[i : i+n])
+				i += n
+				continue
+			}
+			out.WriteString(text[i : i+n+end+n])
+			i += n + end + n
+			continue
+		case strings.HasPrefix(text[i:], "$$"):
+			if end := strings.Index(text[i+2:], "$$"); end > 0 {
+				out.WriteString(fn(Formula{TeX: text[i+2 : i+2+end], Display: true}))
+				i += 2 + end + 2
+				continue
+			}
+		case strings.HasPrefix(text[i:], `\[`):
+			if end := strings.Index(text[i+2:], `\]`); end > 0 {
+				out.WriteString(fn(Formula{TeX: text[i+2 : i+2+end], Display: true}))
+				i += 2 + end + 2
+				continue
+			}
+		case strings.HasPrefix(text[i:], `\(`):
+			if end := strings.Index(text[i+2:], `\)`); end > 0 {
+				out.WriteString(fn(Formula{TeX: text[i+2 : i+2+end]}))
+				i += 2 + end + 2
+				continue
+			}
+		case c == '\\' && i+1 < len(text):
+			// Escaped characters, like \$, aren't delimiters.
+			out.WriteString(text[i : i+2])
+			i += 2
+			continue
+		case c == '$':
+			if end, ok := inlineEnd(text[i+1:]); ok {
+				out.WriteString(fn(Formula{TeX: text[i+1 : i+1+end]}))
+				i += 1 + end + 1
+				continue
+			}
+		}
+		out.WriteByte(text[i])
+		i++
+	}
+	return out.String()
+}
+
+// inlineEnd returns where the formula opened by a single dollar sign ends in
+// text, following Pandoc: the formula can't start or end with a space, nor
+// span lines, and the closing dollar sign can't be followed by a digit. So
+// prices like $5 and $10 aren't taken for math, a formula starting with a
+// digit can't hold spaces either.
+func inlineEnd(text string) (int, bool) {
+	if text == "" || text[0] == ' ' || text[0] == '\t' || text[0] == '\n' {
+		return 0, false
+	}
+	price := text[0] >= '0' && text[0] <= '9'
+	for i := 1; i < len(text); i++ {
+		switch text[i] {
+		case '\n':
+			return 0, false
+		case ' ', '\t':
+			if price {
+				return 0, false
+			}
+		case '\\':
+			i++
+		case '$':
+			if text[i-1] == ' ' || text[i-1] == '\t' {
+				continue
+			}
+			if i+1 < len(text) && text[i+1] >= '0' && text[i+1] <= '9' {
+				continue
+			}
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func countPrefix(s string, c byte) int {
+	n := 0
+	for n < len(s) && s[n] == c {
+		n++
+	}
+	return n
+}
+
+// escapeMarkdown escapes the characters Markdown would take for markup.
+func escapeMarkdown(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune("\\`*_[]<>|~#+", r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// codeSpan wraps s in a code span, with enough backticks to hold the ones in
+// s.
+func codeSpan(s string) string {
+	ticks := "`"
+	for strings.Contains(s, ticks) {
+		ticks += "`"
+	}
+	if strings.HasPrefix(s, "`") || strings.HasSuffix(s, "`") {
+		s = " " + s + " "
+	}
+	return ticks + s + ticks
+}
+
+// ToUnicode approximates a formula with Unicode. It returns false when the
+// formula uses something that can't be approximated, like a matrix or a
+// command it doesn't know.
+func ToUnicode(tex string) (string, bool) {
+	p := &parser{src: tex}
+	out, ok := p.parse(false)
+	if !ok {
+		return "", false
+	}
+	lines := strings.Split(out, "\n")
+	for i, line := range lines {
+		lines[i] = strings.Join(strings.Fields(line), " ")
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n")), true
+}
+
+// parser converts TeX to Unicode, one token at a time.
+type parser struct {
+	src string
+	pos int
+}
+
+// parse converts the source until its end, or until the end of the group it
+// is in.
+func (p *parser) parse(inGroup bool) (string, bool) {
+	var b strings.Builder
+	for p.pos < len(p.src) {
+		if p.src[p.pos] == '}' {
+			if !inGroup {
+				return "", false
+			}
+			p.pos++
+			return b.String(), true
+		}
+		out, ok := p.token()
+		if !ok {
+			return "", false
+		}
+		b.WriteString(out)
+	}
+	return b.String(), !inGroup
+}
+
+// token converts the next token and its arguments.
+func (p *parser) token() (string, bool) {
+	c := p.src[p.pos]
+	switch c {
+	case '{':
+		p.pos++
+		return p.parse(true)
+	case '^', '_':
+		p.pos++
+		arg, ok := p.arg()
+		if !ok {
+			return "", false
+		}
+		return script(arg, c == '^'), true
+	case '\\':
+		return p.command()
+	case '&':
+		p.pos++
+		return "", true
+	case '~':
+		p.pos++
+		return " ", true
+	case '\'':
+		p.pos++
+		return "′", true
+	}
+	r, size := utf8.DecodeRuneInString(p.src[p.pos:])
+	p.pos += size
+	switch r {
+	case '-':
+		return "−", true
+	case '*':
+		return "∗", true
+	}
+	return string(r), true
+}
+
+// arg converts the argument of a command or script: a group, or a single
+// token.
+func (p *parser) arg() (string, bool) {
+	p.skipSpaces()
+	if p.pos >= len(p.src) || p.src[p.pos] == '}' {
+		return "", false
+	}
+	return p.token()
+}
+
+// rawArg returns the argument of a command as written, for text.
+func (p *parser) rawArg() (string, bool) {
+	p.skipSpaces()
+	if p.pos >= len(p.src) {
+		return "", false
+	}
+	if p.src[p.pos] != '{' {
+		r, size := utf8.DecodeRuneInString(p.src[p.pos:])
+		p.pos += size
+		return string(r), true
+	}
+	depth := 0
+	for i := p.pos; i < len(p.src); i++ {
+		switch p.src[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				arg := p.src[p.pos+1 : i]
+				p.pos = i + 1
+				return arg, true
+			}
+		}
+	}
+	return "", false
+}
+
+// optArg returns the optional argument of a command, like the n of
+// \sqrt[n]{x}, as written.
+func (p *parser) optArg() string {
+	p.skipSpaces()
+	if p.pos >= len(p.src) || p.src[p.pos] != '[' {
+		return ""
+	}
+	end := strings.IndexByte(p.src[p.pos:], ']')
+	if end < 0 {
+		return ""
+	}
+	arg := p.src[p.pos+1 : p.pos+end]
+	p.pos += end + 1
+	return arg
+}
+
+func (p *parser) skipSpaces() {
+	for p.pos < len(p.src) && unicode.IsSpace(rune(p.src[p.pos])) {
+		p.pos++
+	}
+}
+
+// command converts a command and its arguments.
+func (p *parser) command() (string, bool) {
+	p.pos++ // The backslash.
+	if p.pos >= len(p.src) {
+		return "", false
+	}
+	start := p.pos
+	for p.pos < len(p.src) && isLetter(p.src[p.pos]) {
+		p.pos++
+	}
+	if p.pos == start {
+		// A command made of a single symbol, like \{ or \,.
+		c := p.src[p.pos]
+		p.pos++
+		switch c {
+		case '\\':
+			return "\n", true
+		case ',', ':', ';', ' ':
+			return " ", true
+		case '!':
+			return "", true
+		case '{', '}', '$', '%', '&', '#', '_':
+			return string(c), true
+		case '|':
+			return "‖", true
+		}
+		return "", false
+	}
+	name := p.src[start:p.pos]
+
+	if s, ok := symbols[name]; ok {
+		return s, true
+	}
+	if _, ok := functions[name]; ok {
+		return name, true
+	}
+	if mark, ok := accents[name]; ok {
+		arg, ok := p.arg()
+		if !ok {
+			return "", false
+		}
+		return accent(arg, mark), true
+	}
+
+	switch name {
+	case "frac", "dfrac", "tfrac", "cfrac":
+		num, ok := p.arg()
+		if !ok {
+			return "", false
+		}
+		den, ok := p.arg()
+		if !ok {
+			return "", false
+		}
+		return fraction(num, den), true
+	case "binom", "dbinom", "tbinom":
+		n, ok := p.arg()
+		if !ok {
+			return "", false
+		}
+		k, ok := p.arg()
+		if !ok {
+			return "", false
+		}
+		return "C(" + n + ", " + k + ")", true
+	case "sqrt":
+		degree := p.optArg()
+		arg, ok := p.arg()
+		if !ok {
+			return "", false
+		}
+		root := "√"
+		switch degree {
+		case "":
+		case "3":
+			root = "∛"
+		case "4":
+			root = "∜"
+		default:
+			root = script(degree, true) + "√"
+		}
+		return root + group(arg), true
+	case "text", "textrm", "textit", "textbf", "mbox", "operatorname", "mathrm":
+		arg, ok := p.rawArg()
+		if !ok {
+			return "", false
+		}
+		if name == "operatorname" || name == "mathrm" {
+			return strings.ReplaceAll(arg, " ", ""), true
+		}
+		return arg, true
+	case "mathbf", "mathit", "mathsf", "mathtt", "boldsymbol", "bm", "mathcal", "mathscr", "mathfrak":
+		return p.arg()
+	case "mathbb":
+		arg, ok := p.arg()
+		if !ok {
+			return "", false
+		}
+		return doubleStruck(arg), true
+	case "left", "right", "big", "Big", "bigg", "Bigg", "bigl", "bigr", "Bigl", "Bigr", "biggl", "biggr", "Biggl", "Biggr":
+		// Delimiters are the same size in a terminal.
+		p.skipSpaces()
+		if p.pos < len(p.src) && p.src[p.pos] == '.' {
+			p.pos++
+		}
+		return "", true
+	case "displaystyle", "textstyle", "limits", "nolimits":
+		return "", true
+	case "begin", "end":
+		env, ok := p.rawArg()
+		if !ok || !layoutEnvironments[env] {
+			return "", false
+		}
+		return "", true
+	}
+	return "", false
+}
+
+// layoutEnvironments are the environments that only lay out their lines,
+// and can be dropped.
+var layoutEnvironments = map[string]bool{
+	"aligned": true, "align": true, "align*": true, "gathered": true,
+	"gather": true, "gather*": true, "equation": true, "equation*": true,
+	"split": true,
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// isAtom reports whether s needs no parentheses to be read as one term,
+// like x, 42 or α.
+func isAtom(s string) bool {
+	if s == "" {
+		return true
+	}
+	for _, r := range s {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '.' && !isScript(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// group wraps s in parentheses, unless it's a single term.
+func group(s string) string {
+	if isAtom(s) {
+		return s
+	}
+	return "(" + s + ")"
+}
+
+func fraction(num, den string) string {
+	if f, ok := vulgarFractions[num+"/"+den]; ok {
+		return f
+	}
+	return group(num) + "/" + group(den)
+}
+
+// script writes s as a superscript or a subscript, with the Unicode
+// characters for them when they all exist, or else with ^ or _.
+func script(s string, super bool) string {
+	table, mark := subscripts, "_"
+	if super {
+		table, mark = superscripts, "^"
+	}
+	var b strings.Builder
+	for _, r := range s {
+		sr, ok := table[r]
+		if !ok && utf8.RuneCountInString(s) == 1 {
+			return mark + s
+		}
+		if !ok {
+			return mark + "(" + s + ")"
+		}
+		b.WriteRune(sr)
+	}
+	return b.String()
+}
+
+func isScript(r rune) bool {
+	for _, table := range []map[rune]rune{superscripts, subscripts} {
+		for _, sr := range table {
+			if sr == r {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// accent puts a combining mark over each character of s.
+func accent(s string, mark rune) string {
+	var b strings.Builder
+	for _, r := range s {
+		b.WriteRune(r)
+		if !unicode.IsSpace(r) {
+			b.WriteRune(mark)
+		}
+	}
+	return b.String()
+}
+
+// doubleStruck writes the letters of s in double-struck capitals, like ℝ.
+func doubleStruck(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case doubleStruckExceptions[r] != 0:
+			b.WriteRune(doubleStruckExceptions[r])
+		case r >= 'A' && r <= 'Z':
+			b.WriteRune(0x1D538 + r - 'A')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// doubleStruckExceptions are the double-struck capitals that predate the
+// mathematical alphanumeric block of Unicode, and are left out of it.
+var doubleStruckExceptions = map[rune]rune{
+	'C': 'ℂ', 'H': 'ℍ', 'N': 'ℕ', 'P': 'ℙ', 'Q': 'ℚ', 'R': 'ℝ', 'Z': 'ℤ',
+}
+
+var accents = map[string]rune{
+	"hat":       '̂',
+	"widehat":   '̂',
+	"tilde":     '̃',
+	"widetilde": '̃',
+	"bar":       '̄',
+	"overline":  '̅',
+	"dot":       '̇',
+	"ddot":      '̈',
+	"vec":       '⃗',
+	"underline": '̲',
+}
+
+// functions are the operators written as their name, like \sin.
+var functions = map[string]struct{}{
+	"sin": {}, "cos": {}, "tan": {}, "cot": {}, "sec": {}, "csc": {},
+	"arcsin": {}, "arccos": {}, "arctan": {}, "sinh": {}, "cosh": {},
+	"tanh": {}, "coth": {}, "log": {}, "ln": {}, "lg": {}, "exp": {},
+	"lim": {}, "liminf": {}, "limsup": {}, "max": {}, "min": {}, "sup": {},
+	"inf": {}, "det": {}, "deg": {}, "dim": {}, "ker": {}, "gcd": {},
+	"arg": {}, "hom": {}, "Pr": {}, "mod": {}, "bmod": {},
+}
+
+var symbols = map[string]string{
+	// Greek letters.
+	"alpha": "α", "beta": "β", "gamma": "γ", "delta": "δ", "epsilon": "ϵ",
+	"varepsilon": "ε", "zeta": "ζ", "eta": "η", "theta": "θ", "vartheta": "ϑ",
+	"iota": "ι", "kappa": "κ", "lambda": "λ", "mu": "μ", "nu": "ν", "xi": "ξ",
+	"pi": "π", "varpi": "ϖ", "rho": "ρ", "varrho": "ϱ", "sigma": "σ",
+	"varsigma": "ς", "tau": "τ", "upsilon": "υ", "phi": "ϕ", "varphi": "φ",
+	"chi": "χ", "psi": "ψ", "omega": "ω",
+	"Gamma": "Γ", "Delta": "Δ", "Theta": "Θ", "Lambda": "Λ", "Xi": "Ξ",
+	"Pi": "Π", "Sigma": "Σ", "Upsilon": "Υ", "Phi": "Φ", "Psi": "Ψ",
+	"Omega": "Ω",
+
+	// Operators.
+	"pm": "±", "mp": "∓", "times": "×", "div": "÷", "cdot": "·", "ast": "∗",
+	"star": "⋆", "circ": "∘", "bullet": "•", "oplus": "⊕", "otimes": "⊗",
+	"cap": "∩", "cup": "∪", "wedge": "∧", "land": "∧", "vee": "∨", "lor": "∨",
+	"neg": "¬", "lnot": "¬", "setminus": "∖",
+	"sum": "∑", "prod": "∏", "coprod": "∐", "int": "∫", "iint": "∬",
+	"iiint": "∭", "oint": "∮", "bigcup": "⋃", "bigcap": "⋂",
+	"bigoplus": "⨁", "bigotimes": "⨂",
+
+	// Relations.
+	"leq": "≤", "le": "≤", "geq": "≥", "ge": "≥", "neq": "≠", "ne": "≠",
+	"approx": "≈", "equiv": "≡", "sim": "∼", "simeq": "≃", "cong": "≅",
+	"propto": "∝", "ll": "≪", "gg": "≫", "in": "∈", "notin": "∉", "ni": "∋",
+	"subset": "⊂", "supset": "⊃", "subseteq": "⊆", "supseteq": "⊇",
+	"perp": "⊥", "parallel": "∥", "mid": "∣", "models": "⊨", "vdash": "⊢",
+
+	// Arrows.
+	"to": "→", "rightarrow": "→", "leftarrow": "←", "gets": "←",
+	"leftrightarrow": "↔", "Rightarrow": "⇒", "Leftarrow": "⇐",
+	"Leftrightarrow": "⇔", "implies": "⟹", "impliedby": "⟸", "iff": "⟺",
+	"mapsto": "↦", "uparrow": "↑", "downarrow": "↓", "longrightarrow": "⟶",
+	"longleftarrow": "⟵", "hookrightarrow": "↪",
+
+	// Everything else.
+	"infty": "∞", "partial": "∂", "nabla": "∇", "forall": "∀",
+	"exists": "∃", "nexists": "∄", "emptyset": "∅", "varnothing": "∅",
+	"ell": "ℓ", "hbar": "ℏ", "Re": "ℜ", "Im": "ℑ", "aleph": "ℵ",
+	"angle": "∠", "triangle": "△", "prime": "′", "degree": "°",
+	"ldots": "…", "cdots": "⋯", "vdots": "⋮", "ddots": "⋱", "dots": "…",
+	"langle": "⟨", "rangle": "⟩", "lceil": "⌈", "rceil": "⌉",
+	"lfloor": "⌊", "rfloor": "⌋", "lvert": "|", "rvert": "|", "vert": "|",
+	"lVert": "‖", "rVert": "‖", "Vert": "‖", "lbrace": "{", "rbrace": "}",
+	"therefore": "∴", "because": "∵", "square": "□", "checkmark": "✓",
+	"quad": "  ", "qquad": "    ",
+}
+
+var vulgarFractions = map[string]string{
+	"1/2": "½", "1/3": "⅓", "2/3": "⅔", "1/4": "¼", "3/4": "¾",
+	"1/5": "⅕", "1/6": "⅙", "1/8": "⅛",
+}
+
+var superscripts = map[rune]rune{
+	'0': '⁰', '1': '¹', '2': '²', '3': '³', '4': '⁴', '5': '⁵', '6': '⁶',
+	'7': '⁷', '8': '⁸', '9': '⁹', '+': '⁺', '−': '⁻', '-': '⁻', '=': '⁼',
+	'(': '⁽', ')': '⁾', 'a': 'ᵃ', 'b': 'ᵇ', 'c': 'ᶜ', 'd': 'ᵈ', 'e': 'ᵉ',
+	'f': 'ᶠ', 'g': 'ᵍ', 'h': 'ʰ', 'i': 'ⁱ', 'j': 'ʲ', 'k': 'ᵏ', 'l': 'ˡ',
+	'm': 'ᵐ', 'n': 'ⁿ', 'o': 'ᵒ', 'p': 'ᵖ', 'r': 'ʳ', 's': 'ˢ', 't': 'ᵗ',
+	'u': 'ᵘ', 'v': 'ᵛ', 'w': 'ʷ', 'x': 'ˣ', 'y': 'ʸ', 'z': 'ᶻ', 'T': 'ᵀ',
+	'′': '′',
+}
+
+var subscripts = map[rune]rune{
+	'0': '₀', '1': '₁', '2': '₂', '3': '₃', '4': '₄', '5': '₅', '6': '₆',
+	'7': '₇', '8': '₈', '9': '₉', '+': '₊', '−': '₋', '-': '₋', '=': '₌',
+	'(': '₍', ')': '₎', 'a': 'ₐ', 'e': 'ₑ', 'h': 'ₕ', 'i': 'ᵢ', 'j': 'ⱼ',
+	'k': 'ₖ', 'l': 'ₗ', 'm': 'ₘ', 'n': 'ₙ', 'o': 'ₒ', 'p': 'ₚ', 'r': 'ᵣ',
+	's': 'ₛ', 't': 'ₜ', 'u': 'ᵤ', 'v': 'ᵥ', 'x': 'ₓ',
+}
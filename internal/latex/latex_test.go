@@ -0,0 +1,61 @@
+package latex
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestToUnicode(t *testing.T) {
+	for tex, want := range map[string]string{
+		`x^2 + y^2 = z^2`:                                "x² + y² = z²",
+		`\alpha_1 \leq \beta_{i+1}`:                      "α₁ ≤ βᵢ₊₁",
+		`\frac{1}{2} + \frac{a+b}{c}`:                    "½ + (a+b)/c",
+		`\sum_{i=1}^{n} i = \frac{n(n+1)}{2}`:            "∑ᵢ₌₁ⁿ i = (n(n+1))/2",
+		`\sqrt{x} \cdot \sqrt[3]{8}`:                     "√x · ∛8",
+		`e^{i\pi} + 1 = 0`:                               "e^(iπ) + 1 = 0",
+		`f: \mathbb{R} \to \mathbb{R}`:                   "f: ℝ → ℝ",
+		`\left( \sin x \right)`:                          "( sin x )",
+		`\text{if } x > 0`:                               "if x > 0",
+		`\hat{x} \neq y^\star`:                           "x̂ ≠ y^⋆",
+		`\begin{aligned} a &= b \\ c &= d \end{aligned}`: "a = b\nc = d",
+	} {
+		got, ok := ToUnicode(tex)
+		require.True(t, ok, tex)
+		require.Equal(t, want, got, tex)
+	}
+
+	for _, tex := range []string{
+		`\begin{pmatrix} a & b \\ c & d \end{pmatrix}`,
+		`\unknowncommand{x}`,
+		`\frac{1}`,
+		`x}`,
+	} {
+		_, ok := ToUnicode(tex)
+		require.False(t, ok, tex)
+	}
+}
+
+func TestFind(t *testing.T) {
+	text := "The area is $\\pi r^2$, or \\(A\\), and:\n\n" +
+		"$$\nE = mc^2\n$$\n\n" +
+		"\\[ a \\]\n" +
+		"It costs $5 and $10, not \\$x$.\n" +
+		"`$code$` stays.\n" +
+		"```\n$also code$\n```\n"
+	require.Equal(t, []Formula{
+		{TeX: `\pi r^2`},
+		{TeX: `A`},
+		{TeX: "\nE = mc^2\n", Display: true},
+		{TeX: ` a `, Display: true},
+	}, Find(text))
+}
+
+func TestRender(t *testing.T) {
+	require.Equal(t, "Since x² ≥ 0, done.", Render(`Since $x^2 \geq 0$, done.`))
+	require.Equal(t, "Let a\\_(xy) be", Render(`Let $a_{xy}$ be`))
+	require.Equal(t, "The matrix `\\begin{matrix}1\\end{matrix}` here", Render(`The matrix $\begin{matrix}1\end{matrix}$ here`))
+	require.Equal(t, "So:\n\n\na = b\\\nc = d\n\n\n", Render("So:\n$$a = b \\\\ c = d$$\n"))
+	require.Equal(t, "So:\n\n\n```latex\n\\begin{bmatrix}1\\end{bmatrix}\n```\n\n\n", Render("So:\n$$\\begin{bmatrix}1\\end{bmatrix}$$\n"))
+	require.Equal(t, "No math here.", Render("No math here."))
+}
@@ -0,0 +1,32 @@
+package latex
+
+import (
+	"html"
+	"strings"
+)
+
+// katex is where the preview loads KaTeX from, to typeset the formulas.
+const katex = "https://cdn.jsdelivr.net/npm/katex@0.16.11/dist/"
+
+// Preview returns an HTML page typesetting the formulas, to be opened in a
+// browser when their Unicode approximation doesn't do them justice.
+func Preview(title string, formulas []Formula) string {
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	b.WriteString("<title>" + html.EscapeString(title) + "</title>\n")
+	b.WriteString("<link rel=\"stylesheet\" href=\"" + katex + "katex.min.css\">\n")
+	b.WriteString("<script src=\"" + katex + "katex.min.js\"></script>\n")
+	b.WriteString("<style>body{font-family:sans-serif;max-width:60em;margin:2em auto;padding:0 1em}" +
+		".formula{margin:1.5em 0;padding:1em;border-bottom:1px solid #ddd;overflow-x:auto}" +
+		"details{color:#666;font-size:.85em}</style>\n")
+	b.WriteString("</head>\n<body>\n<h1>" + html.EscapeString(title) + "</h1>\n")
+	for _, f := range formulas {
+		tex := html.EscapeString(strings.TrimSpace(f.TeX))
+		b.WriteString("<div class=\"formula\">\n<div class=\"tex\">" + tex + "</div>\n")
+		b.WriteString("<details><summary>TeX</summary><pre>" + tex + "</pre></details>\n</div>\n")
+	}
+	b.WriteString("<script>\nfor (const el of document.querySelectorAll('.tex')) {\n" +
+		"  katex.render(el.textContent, el, {displayMode: true, throwOnError: false});\n}\n</script>\n")
+	b.WriteString("</body>\n</html>\n")
+	return b.String()
+}
@@ -0,0 +1,326 @@
+// Package library keeps a local copy of a library of commands, prompts and
+// permission policies shared by a team, so an organization can hand the same
+// agent workflows to everyone.
+//
+// A library is a git repository or a .tar.gz archive laid out as:
+//
+//	commands/          custom commands, like ~/.config/crush/commands
+//	prompts/           files added to the context of every session
+//	permissions.json   tools allowed or disabled, and privacy zones
+package library
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+)
+
+const (
+	// CommandsDir holds the commands of a library.
+	CommandsDir = "commands"
+	// PromptsDir holds the files added to the context of every session.
+	PromptsDir = "prompts"
+	// PermissionsFile holds the permission policies of a library.
+	PermissionsFile = "permissions.json"
+
+	// SyncTimeout bounds how long fetching the library in the background
+	// can take.
+	SyncTimeout = 10 * time.Second
+
+	// maxArchiveSize bounds the size of a downloaded archive.
+	maxArchiveSize = 64 << 20
+)
+
+// Dir returns where the library is kept in the given data directory.
+func Dir(dataDir string) string {
+	return filepath.Join(dataDir, "library")
+}
+
+// Sync fetches the latest version of the library into dir. The previous copy
+// is left untouched when fetching fails, so it can still be used offline.
+func Sync(ctx context.Context, lib config.Library, dir string) error {
+	if lib.Source == "" {
+		return errors.New("library source is not set")
+	}
+	if isGit(lib.Source) {
+		return syncGit(ctx, lib, dir)
+	}
+	return syncArchive(ctx, lib.Source, dir)
+}
+
+// isGit reports whether the source is a git repository rather than an
+// archive.
+func isGit(source string) bool {
+	if strings.HasPrefix(source, "git@") || strings.HasPrefix(source, "ssh://") || strings.HasPrefix(source, "git://") {
+		return true
+	}
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		return strings.HasSuffix(strings.TrimSuffix(source, "/"), ".git")
+	}
+	// Anything else is a local path, which only makes sense as a repository.
+	return true
+}
+
+func syncGit(ctx context.Context, lib config.Library, dir string) error {
+	ref := lib.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+		if err := git(ctx, dir, "remote", "set-url", "origin", lib.Source); err != nil {
+			return err
+		}
+		if err := git(ctx, dir, "fetch", "--depth", "1", "origin", ref); err != nil {
+			return err
+		}
+		return git(ctx, dir, "reset", "--hard", "FETCH_HEAD")
+	}
+
+	return replaceDir(dir, func(tmp string) error {
+		args := []string{"clone", "--depth", "1"}
+		if lib.Ref != "" {
+			args = append(args, "--branch", lib.Ref)
+		}
+		return git(ctx, "", append(args, lib.Source, tmp)...)
+	})
+}
+
+func git(ctx context.Context, dir string, args ...string) error {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	// Never wait for credentials nobody is there to type.
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func syncArchive(ctx context.Context, url, dir string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to download library: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download library: %s", resp.Status)
+	}
+	return replaceDir(dir, func(tmp string) error {
+		return extract(io.LimitReader(resp.Body, maxArchiveSize), tmp)
+	})
+}
+
+// replaceDir fills a new directory next to dir with fill, and swaps it in
+// place of dir once it's complete.
+func replaceDir(dir string, fill func(tmp string) error) error {
+	if err := os.MkdirAll(filepath.Dir(dir), 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.MkdirTemp(filepath.Dir(dir), "library-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+	// git clone wants to create the directory itself.
+	if err := os.Remove(tmp); err != nil {
+		return err
+	}
+	if err := fill(tmp); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	return os.Rename(tmp, dir)
+}
+
+// extract unpacks a .tar.gz archive into dir. When everything in the archive
+// is in a single top-level directory, as in archives of git forges, its
+// contents are unpacked instead.
+func extract(r io.Reader, dir string) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("library is not a .tar.gz archive: %w", err)
+	}
+	defer gz.Close()
+
+	type file struct {
+		name string
+		mode os.FileMode
+		data []byte
+	}
+	var files []file
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read library archive: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		name := filepath.FromSlash(strings.TrimPrefix(hdr.Name, "./"))
+		if !filepath.IsLocal(name) {
+			return fmt.Errorf("library archive has an invalid path: %s", hdr.Name)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return fmt.Errorf("failed to read library archive: %w", err)
+		}
+		files = append(files, file{name: name, mode: hdr.FileInfo().Mode().Perm(), data: data})
+	}
+
+	names := make([]string, len(files))
+	for i, f := range files {
+		names[i] = f.name
+	}
+	prefix := commonDir(names)
+	for _, f := range files {
+		path := filepath.Join(dir, strings.TrimPrefix(f.name, prefix))
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, f.data, f.mode|0o600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// commonDir returns the top-level directory all the names are in, with a
+// trailing separator, or an empty string when there is none or it's part of
+// the library itself.
+func commonDir(names []string) string {
+	prefix := ""
+	for _, name := range names {
+		top, _, ok := strings.Cut(name, string(filepath.Separator))
+		if !ok || (prefix != "" && prefix != top) {
+			return ""
+		}
+		prefix = top
+	}
+	if prefix == "" || prefix == CommandsDir || prefix == PromptsDir {
+		return ""
+	}
+	return prefix + string(filepath.Separator)
+}
+
+// policies are the permission policies a library can set.
+type policies struct {
+	AllowedTools  []string `json:"allowed_tools,omitempty"`
+	DisabledTools []string `json:"disabled_tools,omitempty"`
+	PrivacyZones  []string `json:"privacy_zones,omitempty"`
+}
+
+// approvedPath returns where the tools the user agreed to let the library
+// allow are kept. It's next to dir, as syncing replaces dir.
+func approvedPath(dir string) string {
+	return dir + "-allowed-tools.json"
+}
+
+// Apply adds the prompts and the permission policies of the library kept in
+// dir to the config. It does nothing when the library was never fetched.
+//
+// Tools the library allows that the config doesn't are only allowed once
+// confirm accepts them, so a change to the library can't let tools run
+// without asking behind the user's back. confirm is only asked again when
+// the library allows more tools; a nil confirm accepts none.
+func Apply(cfg *config.Config, dir string, confirm func(tools []string) bool) error {
+	if _, err := os.Stat(dir); err != nil {
+		return nil
+	}
+
+	prompts := filepath.Join(dir, PromptsDir)
+	if _, err := os.Stat(prompts); err == nil && !slices.Contains(cfg.Options.ContextPaths, prompts) {
+		cfg.Options.ContextPaths = append(cfg.Options.ContextPaths, prompts)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, PermissionsFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var p policies
+	if err := json.Unmarshal(data, &p); err != nil {
+		return fmt.Errorf("invalid %s in library: %w", PermissionsFile, err)
+	}
+	if cfg.Permissions == nil {
+		cfg.Permissions = &config.Permissions{}
+	}
+	allowed, err := approveTools(dir, cfg.Permissions.AllowedTools, p.AllowedTools, confirm)
+	if err != nil {
+		return err
+	}
+	cfg.Permissions.AllowedTools = merge(cfg.Permissions.AllowedTools, allowed)
+	cfg.Options.DisabledTools = merge(cfg.Options.DisabledTools, p.DisabledTools)
+	cfg.Options.PrivacyZones = merge(cfg.Options.PrivacyZones, p.PrivacyZones)
+	return nil
+}
+
+// approveTools returns the tools of the library to allow: the ones already
+// allowed or approved before, and the new ones when confirm accepts them.
+func approveTools(dir string, allowed, tools []string, confirm func([]string) bool) ([]string, error) {
+	var approved []string
+	data, err := os.ReadFile(approvedPath(dir))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &approved); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", approvedPath(dir), err)
+		}
+	}
+
+	var ask []string
+	for _, tool := range tools {
+		if !slices.Contains(allowed, tool) && !slices.Contains(approved, tool) {
+			ask = append(ask, tool)
+		}
+	}
+	if len(ask) == 0 {
+		return tools, nil
+	}
+	if confirm == nil || !confirm(ask) {
+		return slices.DeleteFunc(slices.Clone(tools), func(tool string) bool {
+			return slices.Contains(ask, tool)
+		}), nil
+	}
+	data, err = json.Marshal(merge(approved, ask))
+	if err != nil {
+		return nil, err
+	}
+	return tools, os.WriteFile(approvedPath(dir), data, 0o600)
+}
+
+// merge adds the values of b missing from a.
+func merge(a, b []string) []string {
+	for _, v := range b {
+		if !slices.Contains(a, v) {
+			a = append(a, v)
+		}
+	}
+	return a
+}
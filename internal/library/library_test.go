@@ -0,0 +1,139 @@
+package library
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func archive(t *testing.T, files map[string]string) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0o644,
+			Size:     int64(len(content)),
+			Typeflag: tar.TypeReg,
+		}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return &buf
+}
+
+func TestExtract(t *testing.T) {
+	t.Parallel()
+
+	t.Run("strips the top-level directory", func(t *testing.T) {
+		t.Parallel()
+		dir := filepath.Join(t.TempDir(), "library")
+		require.NoError(t, extract(archive(t, map[string]string{
+			"crush-library-main/commands/review.md": "Review $FILE",
+			"crush-library-main/permissions.json":   "{}",
+		}), dir))
+
+		content, err := os.ReadFile(filepath.Join(dir, "commands", "review.md"))
+		require.NoError(t, err)
+		require.Equal(t, "Review $FILE", string(content))
+		require.FileExists(t, filepath.Join(dir, "permissions.json"))
+	})
+
+	t.Run("keeps the library directories", func(t *testing.T) {
+		t.Parallel()
+		dir := filepath.Join(t.TempDir(), "library")
+		require.NoError(t, extract(archive(t, map[string]string{
+			"commands/review.md": "Review",
+		}), dir))
+		require.FileExists(t, filepath.Join(dir, "commands", "review.md"))
+	})
+
+	t.Run("rejects paths outside the directory", func(t *testing.T) {
+		t.Parallel()
+		dir := filepath.Join(t.TempDir(), "library")
+		require.Error(t, extract(archive(t, map[string]string{
+			"../escape.md": "nope",
+		}), dir))
+	})
+}
+
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, PromptsDir), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, PermissionsFile), []byte(`{
+		"allowed_tools": ["view", "ls"],
+		"disabled_tools": ["sourcegraph"],
+		"privacy_zones": [".env*"]
+	}`), 0o644))
+
+	cfg := &config.Config{
+		Options:     &config.Options{},
+		Permissions: &config.Permissions{AllowedTools: []string{"view"}},
+	}
+	var asked []string
+	confirm := func(tools []string) bool {
+		asked = tools
+		return true
+	}
+	require.NoError(t, Apply(cfg, dir, confirm))
+	require.Equal(t, []string{"ls"}, asked)
+	require.Equal(t, []string{filepath.Join(dir, PromptsDir)}, cfg.Options.ContextPaths)
+	require.Equal(t, []string{"view", "ls"}, cfg.Permissions.AllowedTools)
+	require.Equal(t, []string{"sourcegraph"}, cfg.Options.DisabledTools)
+	require.Equal(t, []string{".env*"}, cfg.Options.PrivacyZones)
+
+	// Applying twice doesn't add anything.
+	require.NoError(t, Apply(cfg, dir, confirm))
+	require.Len(t, cfg.Options.ContextPaths, 1)
+	require.Len(t, cfg.Permissions.AllowedTools, 2)
+}
+
+func TestApplyConfirmsAllowedTools(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join(t.TempDir(), "library")
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	permissions := func(tools string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, PermissionsFile), []byte(`{"allowed_tools": [`+tools+`]}`), 0o644))
+	}
+	apply := func(confirm func([]string) bool) []string {
+		cfg := &config.Config{Options: &config.Options{}}
+		require.NoError(t, Apply(cfg, dir, confirm))
+		return cfg.Permissions.AllowedTools
+	}
+	var asked [][]string
+	answer := func(ok bool) func([]string) bool {
+		return func(tools []string) bool {
+			asked = append(asked, tools)
+			return ok
+		}
+	}
+
+	// Declined tools aren't allowed, and are asked about again.
+	permissions(`"view"`)
+	require.Empty(t, apply(answer(false)))
+	require.Empty(t, apply(nil))
+	require.Equal(t, []string{"view"}, apply(answer(true)))
+	require.Equal(t, [][]string{{"view"}, {"view"}}, asked)
+
+	// Approved tools aren't asked about again.
+	require.Equal(t, []string{"view"}, apply(answer(false)))
+	require.Len(t, asked, 2)
+
+	// Only the tools the library adds later are.
+	permissions(`"view", "bash"`)
+	require.Equal(t, []string{"view"}, apply(answer(false)))
+	require.Equal(t, []string{"bash"}, asked[2])
+}
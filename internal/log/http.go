@@ -44,6 +44,7 @@ func (h *HTTPRoundTripLogger) RoundTrip(req *http.Request) (*http.Response, erro
 			"HTTP Request",
 			"method", req.Method,
 			"url", req.URL,
+			"headers", formatHeaders(req.Header),
 			"body", bodyToString(save),
 		)
 	}
@@ -1,6 +1,7 @@
 package log
 
 import (
+	"cmp"
 	"fmt"
 	"log/slog"
 	"os"
@@ -16,33 +17,84 @@ import (
 var (
 	initOnce    sync.Once
 	initialized atomic.Bool
+
+	// level is shared by the default logger so it can be changed at
+	// runtime.
+	level = new(slog.LevelVar)
+)
+
+// Default rotation settings, used when Options leaves them unset.
+const (
+	defaultMaxSize = 10 // MB
+	defaultMaxAge  = 30 // Days
 )
 
-func Setup(logFile string, debug bool) {
+// Options configures the log file.
+type Options struct {
+	// Level is the minimum level written to the log.
+	Level slog.Level
+	// MaxSize is the size in megabytes at which the log is rotated.
+	MaxSize int
+	// MaxBackups is the number of rotated logs to keep. Zero keeps all of
+	// them, subject to MaxAge.
+	MaxBackups int
+	// MaxAge is the number of days to keep rotated logs.
+	MaxAge int
+}
+
+func Setup(logFile string, opts Options) {
 	initOnce.Do(func() {
 		logRotator := &lumberjack.Logger{
 			Filename:   logFile,
-			MaxSize:    10,    // Max size in MB
-			MaxBackups: 0,     // Number of backups
-			MaxAge:     30,    // Days
+			MaxSize:    cmp.Or(opts.MaxSize, defaultMaxSize),
+			MaxBackups: opts.MaxBackups,
+			MaxAge:     cmp.Or(opts.MaxAge, defaultMaxAge),
 			Compress:   false, // Enable compression
 		}
 
-		level := slog.LevelInfo
-		if debug {
-			level = slog.LevelDebug
-		}
+		level.Set(opts.Level)
 
 		logger := slog.NewJSONHandler(logRotator, &slog.HandlerOptions{
 			Level:     level,
 			AddSource: true,
 		})
 
-		slog.SetDefault(slog.New(logger))
+		slog.SetDefault(slog.New(&recordingHandler{Handler: logger, buf: recent}))
 		initialized.Store(true)
 	})
 }
 
+// Level returns the current minimum log level.
+func Level() slog.Level {
+	return level.Level()
+}
+
+// SetLevel changes the minimum log level of the running process.
+func SetLevel(l slog.Level) {
+	if level.Level() == l {
+		return
+	}
+	level.Set(l)
+	slog.Info("Log level changed", "level", l.String())
+}
+
+// Levels lists the log levels that can be configured, from the most to the
+// least verbose.
+var Levels = []slog.Level{slog.LevelDebug, slog.LevelInfo, slog.LevelWarn, slog.LevelError}
+
+// ParseLevel parses a log level name such as "debug" or "warn". An empty name
+// is the info level.
+func ParseLevel(name string) (slog.Level, error) {
+	if name == "" {
+		return slog.LevelInfo, nil
+	}
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(name)); err != nil {
+		return slog.LevelInfo, fmt.Errorf("invalid log level %q: expected debug, info, warn or error", name)
+	}
+	return l, nil
+}
+
 func Initialized() bool {
 	return initialized.Load()
 }
@@ -0,0 +1,31 @@
+package log
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLevel(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		want slog.Level
+	}{
+		{"", slog.LevelInfo},
+		{"debug", slog.LevelDebug},
+		{"info", slog.LevelInfo},
+		{"warn", slog.LevelWarn},
+		{"ERROR", slog.LevelError},
+	}
+	for _, tt := range tests {
+		got, err := ParseLevel(tt.name)
+		require.NoError(t, err, tt.name)
+		require.Equal(t, tt.want, got, tt.name)
+	}
+
+	_, err := ParseLevel("verbose")
+	require.Error(t, err)
+}
@@ -0,0 +1,153 @@
+package log
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxRecent is the number of log entries kept in memory for the log pane.
+const maxRecent = 2000
+
+// Entry is a log record kept in memory so it can be shown in the TUI.
+type Entry struct {
+	Time    time.Time
+	Level   slog.Level
+	Message string
+	// Attrs holds the record's attributes formatted as key=value pairs.
+	Attrs string
+}
+
+// Matches reports whether query appears in the entry's message or
+// attributes, ignoring case.
+func (e Entry) Matches(query string) bool {
+	query = strings.ToLower(query)
+	return strings.Contains(strings.ToLower(e.Message), query) ||
+		strings.Contains(strings.ToLower(e.Attrs), query)
+}
+
+// entryBuffer keeps the most recent log entries.
+type entryBuffer struct {
+	mu      sync.Mutex
+	entries []Entry
+	count   uint64
+}
+
+var recent = &entryBuffer{}
+
+func (b *entryBuffer) add(e Entry) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.entries = append(b.entries, e)
+	// Trim in batches so adding stays cheap.
+	if len(b.entries) >= 2*maxRecent {
+		b.entries = slices.Clone(b.entries[len(b.entries)-maxRecent:])
+	}
+	b.count++
+}
+
+func (b *entryBuffer) recent() []Entry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return slices.Clone(b.entries[max(0, len(b.entries)-maxRecent):])
+}
+
+func (b *entryBuffer) total() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.count
+}
+
+// Recent returns the most recent log entries, oldest first.
+func Recent() []Entry {
+	return recent.recent()
+}
+
+// Count returns the number of entries logged so far. It can be polled to
+// cheaply find out whether Recent has anything new.
+func Count() uint64 {
+	return recent.total()
+}
+
+// recordingHandler writes records to the wrapped handler and keeps a copy of
+// them in memory.
+type recordingHandler struct {
+	slog.Handler
+	buf    *entryBuffer
+	attrs  string
+	prefix string
+}
+
+func (h *recordingHandler) Handle(ctx context.Context, r slog.Record) error {
+	err := h.Handler.Handle(ctx, r)
+
+	var attrs strings.Builder
+	attrs.WriteString(h.attrs)
+	r.Attrs(func(a slog.Attr) bool {
+		appendAttr(&attrs, h.prefix, a)
+		return true
+	})
+	h.buf.add(Entry{
+		Time:    r.Time,
+		Level:   r.Level,
+		Message: r.Message,
+		Attrs:   attrs.String(),
+	})
+	return err
+}
+
+func (h *recordingHandler) WithAttrs(as []slog.Attr) slog.Handler {
+	var attrs strings.Builder
+	attrs.WriteString(h.attrs)
+	for _, a := range as {
+		appendAttr(&attrs, h.prefix, a)
+	}
+	return &recordingHandler{
+		Handler: h.Handler.WithAttrs(as),
+		buf:     h.buf,
+		attrs:   attrs.String(),
+		prefix:  h.prefix,
+	}
+}
+
+func (h *recordingHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &recordingHandler{
+		Handler: h.Handler.WithGroup(name),
+		buf:     h.buf,
+		attrs:   h.attrs,
+		prefix:  h.prefix + name + ".",
+	}
+}
+
+// appendAttr writes a as key=value, flattening groups into dotted keys.
+func appendAttr(b *strings.Builder, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			appendAttr(b, prefix, ga)
+		}
+		return
+	}
+	if b.Len() > 0 {
+		b.WriteByte(' ')
+	}
+	b.WriteString(prefix + a.Key + "=")
+	value := a.Value.String()
+	if strings.ContainsAny(value, " \t\n\"=") || value == "" {
+		value = strconv.Quote(value)
+	}
+	b.WriteString(value)
+}
@@ -0,0 +1,42 @@
+package log
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordingHandler(t *testing.T) {
+	t.Parallel()
+
+	buf := &entryBuffer{}
+	logger := slog.New(&recordingHandler{
+		Handler: slog.NewTextHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelDebug}),
+		buf:     buf,
+	})
+
+	logger.With("provider", "openai").WithGroup("req").Warn("Request failed", "status", 500, "body", "bad gateway")
+	logger.Debug("Plain")
+
+	entries := buf.recent()
+	require.Len(t, entries, 2)
+	require.Equal(t, slog.LevelWarn, entries[0].Level)
+	require.Equal(t, "Request failed", entries[0].Message)
+	require.Equal(t, `provider=openai req.status=500 req.body="bad gateway"`, entries[0].Attrs)
+	require.True(t, entries[0].Matches("GATEWAY"))
+	require.False(t, entries[1].Matches("gateway"))
+	require.Empty(t, entries[1].Attrs)
+}
+
+func TestEntryBufferTrims(t *testing.T) {
+	t.Parallel()
+
+	buf := &entryBuffer{}
+	for range 3 * maxRecent {
+		buf.add(Entry{})
+	}
+	require.Len(t, buf.recent(), maxRecent)
+	require.Equal(t, uint64(3*maxRecent), buf.total())
+}
@@ -3,6 +3,7 @@ package lsp
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"maps"
@@ -34,6 +35,9 @@ type Client struct {
 	// Diagnostic change callback
 	onDiagnosticsChanged func(name string, count int)
 
+	// Callback for the errors the server reports
+	onServerError func(name string, err error)
+
 	// Diagnostic cache
 	diagnostics *csync.VersionedMap[protocol.DocumentURI, []protocol.Diagnostic]
 
@@ -129,7 +133,10 @@ func (c *Client) Initialize(ctx context.Context, workspaceDir string) (*protocol
 	c.RegisterServerRequestHandler("workspace/applyEdit", HandleApplyEdit)
 	c.RegisterServerRequestHandler("workspace/configuration", HandleWorkspaceConfiguration)
 	c.RegisterServerRequestHandler("client/registerCapability", HandleRegisterCapability)
-	c.RegisterNotificationHandler("window/showMessage", HandleServerMessage)
+	c.RegisterNotificationHandler("window/showMessage", func(ctx context.Context, method string, params json.RawMessage) {
+		HandleServerMessage(ctx, method, params)
+		c.handleServerError(params)
+	})
 	c.RegisterNotificationHandler("textDocument/publishDiagnostics", func(_ context.Context, _ string, params json.RawMessage) {
 		HandleDiagnostics(c, params)
 	})
@@ -161,6 +168,8 @@ const (
 	StateReady
 	StateError
 	StateDisabled
+	// StateStopped is the state of servers stopped by the user.
+	StateStopped
 )
 
 // GetServerState returns the current state of the LSP server
@@ -186,6 +195,30 @@ func (c *Client) SetDiagnosticsCallback(callback func(name string, count int)) {
 	c.onDiagnosticsChanged = callback
 }
 
+// SetErrorCallback sets the callback function for the errors the server
+// reports.
+func (c *Client) SetErrorCallback(callback func(name string, err error)) {
+	c.onServerError = callback
+}
+
+// handleServerError passes the error messages of the server to the error
+// callback.
+func (c *Client) handleServerError(params json.RawMessage) {
+	if c.onServerError == nil {
+		return
+	}
+	var msg protocol.ShowMessageParams
+	if err := json.Unmarshal(params, &msg); err != nil || msg.Type != protocol.Error {
+		return
+	}
+	c.onServerError(c.name, errors.New(msg.Message))
+}
+
+// IsRunning reports whether the server process is still running.
+func (c *Client) IsRunning() bool {
+	return c.client.IsRunning()
+}
+
 // WaitForServerReady waits for the server to be ready
 func (c *Client) WaitForServerReady(ctx context.Context) error {
 	cfg := config.Get()
@@ -0,0 +1,171 @@
+package lsp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/config"
+)
+
+// Server is a language server Crush knows how to set up.
+type Server struct {
+	Name        string
+	Language    string
+	Command     string
+	Args        []string
+	FileTypes   []string
+	RootMarkers []string
+	// Install installs the server with the toolchain of its language, when
+	// there's a common way to.
+	Install []string
+}
+
+// Config returns the configuration of the server.
+func (s Server) Config() config.LSPConfig {
+	return config.LSPConfig{
+		Command:     s.Command,
+		Args:        s.Args,
+		FileTypes:   s.FileTypes,
+		RootMarkers: s.RootMarkers,
+	}
+}
+
+// KnownServers are the servers suggested for the projects of their
+// languages.
+var KnownServers = []Server{
+	{
+		Name:        "gopls",
+		Language:    "Go",
+		Command:     "gopls",
+		FileTypes:   []string{"go", "mod", "sum", "work"},
+		RootMarkers: []string{"go.mod", "go.work"},
+		Install:     []string{"go", "install", "golang.org/x/tools/gopls@latest"},
+	},
+	{
+		Name:        "typescript",
+		Language:    "TypeScript/JavaScript",
+		Command:     "typescript-language-server",
+		Args:        []string{"--stdio"},
+		FileTypes:   []string{"ts", "tsx", "js", "jsx", "mjs", "cjs", "mts", "cts"},
+		RootMarkers: []string{"tsconfig.json", "jsconfig.json", "package.json"},
+		Install:     []string{"npm", "install", "--global", "typescript", "typescript-language-server"},
+	},
+	{
+		Name:        "pyright",
+		Language:    "Python",
+		Command:     "pyright-langserver",
+		Args:        []string{"--stdio"},
+		FileTypes:   []string{"py", "pyi"},
+		RootMarkers: []string{"pyproject.toml", "setup.py", "setup.cfg", "requirements.txt", "Pipfile"},
+		Install:     []string{"npm", "install", "--global", "pyright"},
+	},
+	{
+		Name:        "rust-analyzer",
+		Language:    "Rust",
+		Command:     "rust-analyzer",
+		FileTypes:   []string{"rs"},
+		RootMarkers: []string{"Cargo.toml"},
+		Install:     []string{"rustup", "component", "add", "rust-analyzer"},
+	},
+	{
+		Name:        "ruby-lsp",
+		Language:    "Ruby",
+		Command:     "ruby-lsp",
+		FileTypes:   []string{"rb"},
+		RootMarkers: []string{"Gemfile"},
+		Install:     []string{"gem", "install", "ruby-lsp"},
+	},
+	{
+		Name:        "intelephense",
+		Language:    "PHP",
+		Command:     "intelephense",
+		Args:        []string{"--stdio"},
+		FileTypes:   []string{"php"},
+		RootMarkers: []string{"composer.json"},
+		Install:     []string{"npm", "install", "--global", "intelephense"},
+	},
+	{
+		Name:        "clangd",
+		Language:    "C/C++",
+		Command:     "clangd",
+		FileTypes:   []string{"c", "h", "cc", "cpp", "cxx", "hpp", "hh"},
+		RootMarkers: []string{"compile_commands.json", "compile_flags.txt", ".clangd", "CMakeLists.txt"},
+	},
+	{
+		Name:        "jdtls",
+		Language:    "Java",
+		Command:     "jdtls",
+		FileTypes:   []string{"java"},
+		RootMarkers: []string{"pom.xml", "build.gradle", "build.gradle.kts"},
+	},
+	{
+		Name:        "zls",
+		Language:    "Zig",
+		Command:     "zls",
+		FileTypes:   []string{"zig", "zon"},
+		RootMarkers: []string{"build.zig"},
+	},
+}
+
+// Suggestion is a known server for a language of a project.
+type Suggestion struct {
+	Server
+	// Installed reports whether the command of the server was found.
+	Installed bool
+	// CanInstall reports whether the server isn't installed but its install
+	// command can be run.
+	CanInstall bool
+}
+
+// Detect returns the known servers for the languages of the project in dir,
+// except the ones already configured.
+func Detect(dir string, configured map[string]config.LSPConfig) []Suggestion {
+	var suggestions []Suggestion
+	for _, s := range KnownServers {
+		if isConfigured(s, configured) || !HasRootMarkers(dir, s.RootMarkers) {
+			continue
+		}
+		suggestion := Suggestion{Server: s}
+		if _, err := exec.LookPath(s.Command); err == nil {
+			suggestion.Installed = true
+		} else if len(s.Install) > 0 {
+			_, err := exec.LookPath(s.Install[0])
+			suggestion.CanInstall = err == nil
+		}
+		suggestions = append(suggestions, suggestion)
+	}
+	return suggestions
+}
+
+func isConfigured(s Server, configured map[string]config.LSPConfig) bool {
+	if _, ok := configured[s.Name]; ok {
+		return true
+	}
+	for _, cfg := range configured {
+		if strings.TrimSuffix(filepath.Base(cfg.Command), ".exe") == s.Command {
+			return true
+		}
+	}
+	return false
+}
+
+// Install runs the install command of the server.
+func Install(ctx context.Context, s Server) error {
+	if len(s.Install) == 0 {
+		return fmt.Errorf("%s can't be installed automatically", s.Name)
+	}
+	var out bytes.Buffer
+	cmd := exec.CommandContext(ctx, s.Install[0], s.Install[1:]...)
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if err := cmd.Run(); err != nil {
+		// The last lines of the output usually tell what went wrong.
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		return fmt.Errorf("%s: %w: %s", strings.Join(s.Install, " "), err, strings.Join(lines[max(0, len(lines)-3):], "\n"))
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package lsp
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetect(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.Empty(t, Detect(dir, nil))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module test"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Cargo.toml"), []byte("[package]"), 0o644))
+	var names []string
+	for _, s := range Detect(dir, nil) {
+		names = append(names, s.Name)
+	}
+	require.Equal(t, []string{"gopls", "rust-analyzer"}, names)
+
+	// Servers configured under another name aren't suggested again.
+	suggestions := Detect(dir, map[string]config.LSPConfig{
+		"go": {Command: "/usr/local/bin/gopls"},
+	})
+	require.Len(t, suggestions, 1)
+	require.Equal(t, "rust-analyzer", suggestions[0].Name)
+	require.Equal(t, []string{"Cargo.toml"}, suggestions[0].Config().RootMarkers)
+}
@@ -0,0 +1,77 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Process is a child process of Crush.
+type Process struct {
+	PID int
+	// RSS is the resident memory of the process in bytes.
+	RSS  int64
+	Args string
+}
+
+// ChildProcesses returns the processes started by Crush, which include the
+// language servers. It isn't supported on Windows.
+func ChildProcesses(ctx context.Context) ([]Process, error) {
+	if runtime.GOOS == "windows" {
+		return nil, errors.ErrUnsupported
+	}
+	out, err := exec.CommandContext(ctx, "ps", "-A", "-o", "pid=,ppid=,rss=,args=").Output()
+	if err != nil {
+		return nil, err
+	}
+	return parseProcesses(out, os.Getpid()), nil
+}
+
+// parseProcesses parses the output of ps, keeping the children of ppid.
+func parseProcesses(out []byte, ppid int) []Process {
+	var procs []Process
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 {
+			continue
+		}
+		parent, err := strconv.Atoi(fields[1])
+		if err != nil || parent != ppid {
+			continue
+		}
+		pid, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+		rss, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			continue
+		}
+		procs = append(procs, Process{
+			PID:  pid,
+			RSS:  rss * 1024,
+			Args: strings.Join(fields[3:], " "),
+		})
+	}
+	return procs
+}
+
+// Process returns the process of the server among procs.
+func (c *Client) Process(procs []Process) (Process, bool) {
+	command := filepath.Base(c.config.Command)
+	for _, p := range procs {
+		fields := strings.Fields(p.Args)
+		if len(fields) > 0 && filepath.Base(fields[0]) == command {
+			return p, true
+		}
+	}
+	return Process{}, false
+}
@@ -0,0 +1,32 @@
+package lsp
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseProcesses(t *testing.T) {
+	t.Parallel()
+
+	out := []byte("  100     1  2048 /sbin/init\n" +
+		"  200   100 51200 /home/user/go/bin/gopls serve\n" +
+		"  201   100  1024 typescript-language-server --stdio\n" +
+		"  300   200   512 go list -json\n" +
+		"garbage\n")
+	procs := parseProcesses(out, 100)
+	require.Equal(t, []Process{
+		{PID: 200, RSS: 51200 * 1024, Args: "/home/user/go/bin/gopls serve"},
+		{PID: 201, RSS: 1024 * 1024, Args: "typescript-language-server --stdio"},
+	}, procs)
+
+	c := &Client{config: config.LSPConfig{Command: "gopls"}}
+	p, ok := c.Process(procs)
+	require.True(t, ok)
+	require.Equal(t, 200, p.PID)
+
+	c = &Client{config: config.LSPConfig{Command: "rust-analyzer"}}
+	_, ok = c.Process(procs)
+	require.False(t, ok)
+}
@@ -0,0 +1,97 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+)
+
+// Rename returns the edits renaming the symbol at the given 1-based position
+// everywhere it's used.
+func (c *Client) Rename(ctx context.Context, filepath string, line, character int, newName string) (*protocol.WorkspaceEdit, error) {
+	if err := c.OpenFileOnDemand(ctx, filepath); err != nil {
+		return nil, err
+	}
+	params := protocol.RenameParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: protocol.URIFromPath(filepath)},
+		Position: protocol.Position{
+			Line:      uint32(line - 1),
+			Character: uint32(character - 1),
+		},
+		NewName: newName,
+	}
+	var edit *protocol.WorkspaceEdit
+	if err := c.client.Call(ctx, "textDocument/rename", params, &edit); err != nil {
+		return nil, err
+	}
+	return edit, nil
+}
+
+// CodeActions returns the code actions available for the given 1-based
+// lines, with the diagnostics of those lines for the server to offer fixes.
+func (c *Client) CodeActions(ctx context.Context, filepath string, startLine, endLine int) ([]protocol.CodeAction, error) {
+	if err := c.OpenFileOnDemand(ctx, filepath); err != nil {
+		return nil, err
+	}
+	uri := protocol.URIFromPath(filepath)
+	rng := protocol.Range{
+		Start: protocol.Position{Line: uint32(startLine - 1)},
+		End:   protocol.Position{Line: uint32(endLine)},
+	}
+	var diagnostics []protocol.Diagnostic
+	for _, d := range c.GetFileDiagnostics(uri) {
+		if d.Range.Start.Line < rng.End.Line && d.Range.End.Line >= rng.Start.Line {
+			diagnostics = append(diagnostics, d)
+		}
+	}
+	params := protocol.CodeActionParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+		Range:        rng,
+		Context:      protocol.CodeActionContext{Diagnostics: diagnostics},
+	}
+	var items []json.RawMessage
+	if err := c.client.Call(ctx, "textDocument/codeAction", params, &items); err != nil {
+		return nil, err
+	}
+
+	// Servers answer with code actions or bare commands, which are told
+	// apart by their command being a string.
+	actions := make([]protocol.CodeAction, 0, len(items))
+	for _, item := range items {
+		var probe struct {
+			Command json.RawMessage `json:"command"`
+		}
+		if err := json.Unmarshal(item, &probe); err != nil {
+			return nil, fmt.Errorf("invalid code action: %w", err)
+		}
+		if len(probe.Command) > 0 && probe.Command[0] == '"' {
+			var command protocol.Command
+			if err := json.Unmarshal(item, &command); err != nil {
+				return nil, fmt.Errorf("invalid code action: %w", err)
+			}
+			actions = append(actions, protocol.CodeAction{Title: command.Title, Command: &command})
+			continue
+		}
+		var action protocol.CodeAction
+		if err := json.Unmarshal(item, &action); err != nil {
+			return nil, fmt.Errorf("invalid code action: %w", err)
+		}
+		actions = append(actions, action)
+	}
+	return actions, nil
+}
+
+// ResolveCodeAction returns the code action with its edit, for servers that
+// only compute it once the action is picked.
+func (c *Client) ResolveCodeAction(ctx context.Context, action protocol.CodeAction) (protocol.CodeAction, error) {
+	if action.Edit != nil || action.Data == nil {
+		return action, nil
+	}
+	var resolved protocol.CodeAction
+	if err := c.client.Call(ctx, "codeAction/resolve", action, &resolved); err != nil {
+		return action, err
+	}
+	return resolved, nil
+}
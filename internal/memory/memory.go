@@ -0,0 +1,236 @@
+// Package memory keeps the facts and preferences the agent learns about a
+// project across sessions, in a file of the data directory, and recalls the
+// ones relevant to a prompt by the keywords they share with it.
+package memory
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// fileName is the name of the file of the data directory the memories are
+// saved to.
+const fileName = "memories.json"
+
+// Memory is a fact or preference about the project.
+type Memory struct {
+	ID      string `json:"id"`
+	Content string `json:"content"`
+	// SessionID is the session the memory was learned in.
+	SessionID string `json:"session_id,omitempty"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+// Store is the memories of a project.
+type Store struct {
+	path string
+
+	mu       sync.Mutex
+	memories []Memory
+	loaded   bool
+}
+
+// NewStore returns the memories saved to the data directory.
+func NewStore(dataDir string) *Store {
+	return &Store{path: filepath.Join(dataDir, fileName)}
+}
+
+// load reads the memories once. Must be called with mu held.
+func (s *Store) load() error {
+	if s.loaded {
+		return nil
+	}
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		s.loaded = true
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &s.memories); err != nil {
+		return err
+	}
+	s.loaded = true
+	return nil
+}
+
+// save writes the memories. Must be called with mu held.
+func (s *Store) save() error {
+	data, err := json.MarshalIndent(s.memories, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// List returns the memories, the newest first.
+func (s *Store) List() ([]Memory, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	memories := slices.Clone(s.memories)
+	slices.Reverse(memories)
+	return memories, nil
+}
+
+// Add remembers content learned in a session. It reports false without
+// adding it when the same memory is already there.
+func (s *Store) Add(content, sessionID string) (Memory, bool, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return Memory{}, false, errors.New("the memory is empty")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(); err != nil {
+		return Memory{}, false, err
+	}
+	key := normalize(content)
+	if i := slices.IndexFunc(s.memories, func(m Memory) bool { return normalize(m.Content) == key }); i >= 0 {
+		return s.memories[i], false, nil
+	}
+	m := Memory{
+		ID:        uuid.NewString(),
+		Content:   content,
+		SessionID: sessionID,
+		CreatedAt: time.Now().Unix(),
+	}
+	s.memories = append(s.memories, m)
+	if err := s.save(); err != nil {
+		s.memories = s.memories[:len(s.memories)-1]
+		return Memory{}, false, err
+	}
+	return m, true, nil
+}
+
+// Delete forgets a memory.
+func (s *Store) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(); err != nil {
+		return err
+	}
+	i := slices.IndexFunc(s.memories, func(m Memory) bool { return m.ID == id })
+	if i < 0 {
+		return errors.New("memory not found")
+	}
+	previous := s.memories
+	s.memories = slices.Delete(slices.Clone(s.memories), i, i+1)
+	if err := s.save(); err != nil {
+		s.memories = previous
+		return err
+	}
+	return nil
+}
+
+// Recall returns at most limit memories relevant to the query, the most
+// relevant first. All the memories are relevant while there are no more
+// than limit of them.
+func (s *Store) Recall(query string, limit int) ([]Memory, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	if len(s.memories) <= limit {
+		return slices.Clone(s.memories), nil
+	}
+
+	scores := scores(query, s.memories)
+	var indexes []int
+	for i, score := range scores {
+		if score > 0 {
+			indexes = append(indexes, i)
+		}
+	}
+	slices.SortStableFunc(indexes, func(a, b int) int {
+		return cmp.Compare(scores[b], scores[a])
+	})
+	memories := make([]Memory, 0, min(limit, len(indexes)))
+	for _, i := range indexes[:min(limit, len(indexes))] {
+		memories = append(memories, s.memories[i])
+	}
+	return memories, nil
+}
+
+// scores scores the memories by the words of the query they contain,
+// weighting rare words more.
+func scores(query string, memories []Memory) []float64 {
+	scores := make([]float64, len(memories))
+	words := make([][]string, len(memories))
+	for i, m := range memories {
+		words[i] = keywords(m.Content)
+	}
+	for _, word := range keywords(query) {
+		df := 0
+		for _, w := range words {
+			if slices.Contains(w, word) {
+				df++
+			}
+		}
+		if df == 0 {
+			continue
+		}
+		idf := math.Log(1 + (float64(len(memories))-float64(df)+0.5)/(float64(df)+0.5))
+		for i, w := range words {
+			if slices.Contains(w, word) {
+				scores[i] += idf
+			}
+		}
+	}
+	return scores
+}
+
+// stopWords are the words too common to tell what a text is about.
+var stopWords = []string{
+	"the", "and", "for", "are", "but", "not", "you", "all", "any", "can",
+	"has", "have", "was", "were", "with", "this", "that", "from", "they",
+	"will", "would", "should", "could", "into", "than", "then", "them",
+	"use", "uses", "when", "what", "which", "who", "how", "our", "its",
+	"also", "only", "some", "does", "don", "about", "there", "their",
+}
+
+// keywords returns the distinct lowercase words of text, leaving out stop
+// words and the ones shorter than three letters.
+func keywords(text string) []string {
+	var words []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len([]rune(word)) < 3 || slices.Contains(stopWords, word) || slices.Contains(words, word) {
+			continue
+		}
+		words = append(words, word)
+	}
+	return words
+}
+
+// normalize returns what tells memories apart, so memories differing only
+// in case, spacing or punctuation are the same.
+func normalize(content string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(content), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
@@ -0,0 +1,65 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	store := NewStore(dir)
+	tabs, added, err := store.Add("The project uses tabs in YAML files.", "session")
+	require.NoError(t, err)
+	require.True(t, added)
+	_, added, err = store.Add("the project uses tabs in yaml files", "other")
+	require.NoError(t, err)
+	require.False(t, added)
+	_, _, err = store.Add(" ", "session")
+	require.Error(t, err)
+	pnpm, _, err := store.Add("Install dependencies with pnpm, never npm.", "session")
+	require.NoError(t, err)
+
+	// Memories are saved, and the newest are listed first.
+	memories, err := NewStore(dir).List()
+	require.NoError(t, err)
+	require.Equal(t, []Memory{pnpm, tabs}, memories)
+
+	require.NoError(t, store.Delete(tabs.ID))
+	require.Error(t, store.Delete(tabs.ID))
+	memories, err = NewStore(dir).List()
+	require.NoError(t, err)
+	require.Equal(t, []Memory{pnpm}, memories)
+}
+
+func TestRecall(t *testing.T) {
+	t.Parallel()
+
+	store := NewStore(t.TempDir())
+	for _, content := range []string{
+		"Install dependencies with pnpm.",
+		"The API server listens on port 8080.",
+		"Tests of the API need a running Postgres.",
+		"The user prefers short commit messages.",
+	} {
+		_, _, err := store.Add(content, "")
+		require.NoError(t, err)
+	}
+
+	// All memories are recalled while there are few of them.
+	memories, err := store.Recall("anything", 10)
+	require.NoError(t, err)
+	require.Len(t, memories, 4)
+
+	memories, err = store.Recall("Why do the API tests fail?", 2)
+	require.NoError(t, err)
+	require.Len(t, memories, 2)
+	require.Equal(t, "Tests of the API need a running Postgres.", memories[0].Content)
+	require.Equal(t, "The API server listens on port 8080.", memories[1].Content)
+
+	memories, err = store.Recall("Write a README", 2)
+	require.NoError(t, err)
+	require.Empty(t, memories)
+}
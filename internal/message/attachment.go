@@ -7,6 +7,9 @@ type Attachment struct {
 	FileName string
 	MimeType string
 	Content  []byte
+	// Note describes how the content was changed before it was sent, like
+	// an image downscaled to fit the provider.
+	Note string
 }
 
 func (a Attachment) IsText() bool  { return strings.HasPrefix(a.MimeType, "text/") }
@@ -82,6 +82,8 @@ type BinaryContent struct {
 	Path     string
 	MIMEType string
 	Data     []byte
+	// Note describes how the data was changed before it was sent.
+	Note string
 }
 
 func (bc BinaryContent) String(p catwalk.InferenceProvider) string {
@@ -135,6 +137,8 @@ type Message struct {
 	CreatedAt        int64
 	UpdatedAt        int64
 	IsSummaryMessage bool
+	// Excluded messages stay in the history but aren't sent to the model.
+	Excluded bool
 }
 
 func (m *Message) Content() TextContent {
@@ -24,6 +24,7 @@ type Service interface {
 	pubsub.Subscriber[Message]
 	Create(ctx context.Context, sessionID string, params CreateMessageParams) (Message, error)
 	Update(ctx context.Context, message Message) error
+	SetExcluded(ctx context.Context, id string, excluded bool) error
 	Get(ctx context.Context, id string) (Message, error)
 	List(ctx context.Context, sessionID string) ([]Message, error)
 	Delete(ctx context.Context, id string) error
@@ -134,6 +135,27 @@ func (s *service) Update(ctx context.Context, message Message) error {
 	return nil
 }
 
+// SetExcluded sets whether a message is left out of the context sent to the
+// model.
+func (s *service) SetExcluded(ctx context.Context, id string, excluded bool) error {
+	var value int64
+	if excluded {
+		value = 1
+	}
+	if err := s.q.UpdateMessageExcluded(ctx, db.UpdateMessageExcludedParams{
+		Excluded: value,
+		ID:       id,
+	}); err != nil {
+		return err
+	}
+	message, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	s.Publish(pubsub.UpdatedEvent, message.Clone())
+	return nil
+}
+
 func (s *service) Get(ctx context.Context, id string) (Message, error) {
 	dbMessage, err := s.q.GetMessage(ctx, id)
 	if err != nil {
@@ -172,6 +194,7 @@ func (s *service) fromDBItem(item db.Message) (Message, error) {
 		CreatedAt:        item.CreatedAt,
 		UpdatedAt:        item.UpdatedAt,
 		IsSummaryMessage: item.IsSummaryMessage != 0,
+		Excluded:         item.Excluded != 0,
 	}, nil
 }
 
@@ -0,0 +1,42 @@
+package message
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Quote is an earlier message embedded in a prompt to point the model at it.
+type Quote struct {
+	MessageID string
+	Role      MessageRole
+	Text      string
+}
+
+var quotePattern = regexp.MustCompile(`(?s)^<quote message_id="([^"]*)" role="([^"]*)">\n(.*?)\n</quote>\n*`)
+
+// QuotePrompt prepends a quote of msg to prompt. The quote is sent to the
+// model as is, and shown collapsed in the conversation.
+func QuotePrompt(msg Message, prompt string) string {
+	return fmt.Sprintf(
+		"<quote message_id=%q role=%q>\n%s\n</quote>\n\n%s",
+		msg.ID,
+		msg.Role,
+		strings.TrimSpace(msg.Content().Text),
+		prompt,
+	)
+}
+
+// ParseQuote splits a prompt made by QuotePrompt into the quote and the rest
+// of the prompt. It returns false when the prompt doesn't start with a quote.
+func ParseQuote(prompt string) (Quote, string, bool) {
+	m := quotePattern.FindStringSubmatchIndex(prompt)
+	if m == nil {
+		return Quote{}, prompt, false
+	}
+	return Quote{
+		MessageID: prompt[m[2]:m[3]],
+		Role:      MessageRole(prompt[m[4]:m[5]]),
+		Text:      prompt[m[6]:m[7]],
+	}, prompt[m[1]:], true
+}
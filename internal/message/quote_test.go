@@ -0,0 +1,33 @@
+package message
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuotePrompt(t *testing.T) {
+	t.Parallel()
+
+	quoted := Message{
+		ID:   "msg-1",
+		Role: Assistant,
+		Parts: []ContentPart{
+			TextContent{Text: "Use a mutex.\n\nOr a channel.\n"},
+		},
+	}
+	prompt := QuotePrompt(quoted, "Why not a channel?")
+
+	q, rest, ok := ParseQuote(prompt)
+	require.True(t, ok)
+	require.Equal(t, Quote{MessageID: "msg-1", Role: Assistant, Text: "Use a mutex.\n\nOr a channel."}, q)
+	require.Equal(t, "Why not a channel?", rest)
+}
+
+func TestParseQuoteWithoutQuote(t *testing.T) {
+	t.Parallel()
+
+	_, rest, ok := ParseQuote("just a prompt")
+	require.False(t, ok)
+	require.Equal(t, "just a prompt", rest)
+}
@@ -0,0 +1,131 @@
+// Package multiplexer fits Crush into terminal multiplexers: it opens Crush in
+// a popup or a split of the current tmux or zellij pane, and titles the pane
+// Crush runs in.
+package multiplexer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// Kind is a terminal multiplexer.
+type Kind string
+
+const (
+	None   Kind = ""
+	Tmux   Kind = "tmux"
+	Zellij Kind = "zellij"
+)
+
+// Layout is where a new pane opens.
+type Layout string
+
+const (
+	// Popup opens a floating pane over the current one.
+	Popup Layout = "popup"
+	// Right splits the current pane, opening the new one on its right.
+	Right Layout = "right"
+	// Down splits the current pane, opening the new one below it.
+	Down Layout = "down"
+)
+
+// Layouts are the layouts a pane can open in.
+var Layouts = []Layout{Popup, Right, Down}
+
+// timeout bounds how long the multiplexer has to answer.
+const timeout = 5 * time.Second
+
+// ErrNotInMultiplexer is returned when Crush doesn't run in tmux or zellij.
+var ErrNotInMultiplexer = errors.New("not running inside tmux or zellij")
+
+// Detect returns the multiplexer Crush runs in, if any.
+func Detect() Kind {
+	switch {
+	case os.Getenv("TMUX") != "":
+		return Tmux
+	case os.Getenv("ZELLIJ") != "":
+		return Zellij
+	default:
+		return None
+	}
+}
+
+// Command returns the command opening a pane of the multiplexer in the
+// layout, running args in dir. An empty dir is the working directory of the
+// current pane.
+func Command(kind Kind, layout Layout, dir string, args []string) ([]string, error) {
+	switch kind {
+	case Tmux:
+		if dir == "" {
+			dir = "#{pane_current_path}"
+		}
+		var cmd []string
+		switch layout {
+		case Popup:
+			cmd = []string{"tmux", "display-popup", "-E", "-w", "85%", "-h", "85%", "-d", dir}
+		case Right:
+			cmd = []string{"tmux", "split-window", "-h", "-c", dir}
+		case Down:
+			cmd = []string{"tmux", "split-window", "-v", "-c", dir}
+		default:
+			return nil, fmt.Errorf("unknown layout %q", layout)
+		}
+		return append(append(cmd, "--"), args...), nil
+	case Zellij:
+		cmd := []string{"zellij", "run", "--close-on-exit"}
+		switch layout {
+		case Popup:
+			cmd = append(cmd, "--floating")
+		case Right, Down:
+			cmd = append(cmd, "--direction", string(layout))
+		default:
+			return nil, fmt.Errorf("unknown layout %q", layout)
+		}
+		if dir != "" {
+			cmd = append(cmd, "--cwd", dir)
+		}
+		return append(append(cmd, "--"), args...), nil
+	default:
+		return nil, ErrNotInMultiplexer
+	}
+}
+
+// Open opens a pane of the multiplexer Crush runs in, in the layout, running
+// args in dir.
+func Open(layout Layout, dir string, args []string) error {
+	cmd, err := Command(Detect(), layout, dir, args)
+	if err != nil {
+		return err
+	}
+	// A tmux popup stays open until Crush exits in it.
+	return run(context.Background(), cmd)
+}
+
+// SetPaneTitle titles the tmux pane Crush runs in. zellij titles panes after
+// the title of the terminal instead, so it does nothing there.
+func SetPaneTitle(title string) error {
+	pane := os.Getenv("TMUX_PANE")
+	if Detect() != Tmux || pane == "" {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return run(ctx, []string{"tmux", "select-pane", "-t", pane, "-T", title})
+}
+
+func run(ctx context.Context, args []string) error {
+	_, err := exec.CommandContext(ctx, args[0], args[1:]...).Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+		return fmt.Errorf("%s: %s: %w", args[0], bytes.TrimSpace(exitErr.Stderr), err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to run %s: %w", args[0], err)
+	}
+	return nil
+}
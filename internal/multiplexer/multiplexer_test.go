@@ -0,0 +1,45 @@
+package multiplexer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommand(t *testing.T) {
+	t.Parallel()
+
+	args := []string{"/usr/bin/crush", "--continue"}
+	for _, tt := range []struct {
+		kind   Kind
+		layout Layout
+		dir    string
+		want   []string
+	}{
+		{Tmux, Popup, "", []string{"tmux", "display-popup", "-E", "-w", "85%", "-h", "85%", "-d", "#{pane_current_path}", "--", "/usr/bin/crush", "--continue"}},
+		{Tmux, Right, "/src", []string{"tmux", "split-window", "-h", "-c", "/src", "--", "/usr/bin/crush", "--continue"}},
+		{Tmux, Down, "", []string{"tmux", "split-window", "-v", "-c", "#{pane_current_path}", "--", "/usr/bin/crush", "--continue"}},
+		{Zellij, Popup, "", []string{"zellij", "run", "--close-on-exit", "--floating", "--", "/usr/bin/crush", "--continue"}},
+		{Zellij, Down, "/src", []string{"zellij", "run", "--close-on-exit", "--direction", "down", "--cwd", "/src", "--", "/usr/bin/crush", "--continue"}},
+	} {
+		cmd, err := Command(tt.kind, tt.layout, tt.dir, args)
+		require.NoError(t, err)
+		require.Equal(t, tt.want, cmd)
+	}
+
+	_, err := Command(None, Popup, "", args)
+	require.ErrorIs(t, err, ErrNotInMultiplexer)
+	_, err = Command(Tmux, "left", "", args)
+	require.Error(t, err)
+}
+
+func TestDetect(t *testing.T) {
+	t.Setenv("TMUX", "")
+	t.Setenv("ZELLIJ", "0")
+	require.Equal(t, Zellij, Detect())
+	t.Setenv("TMUX", "/tmp/tmux-1000/default,1234,0")
+	require.Equal(t, Tmux, Detect())
+	t.Setenv("TMUX", "")
+	t.Setenv("ZELLIJ", "")
+	require.Equal(t, None, Detect())
+}
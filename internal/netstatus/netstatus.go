@@ -0,0 +1,226 @@
+// Package netstatus keeps track of whether the network is reachable so Crush
+// can degrade gracefully while offline.
+package netstatus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/pubsub"
+)
+
+const (
+	probeInterval = 5 * time.Second
+	probeTimeout  = 5 * time.Second
+)
+
+// ErrOffline is returned when a remote request is attempted while offline.
+var ErrOffline = errors.New("offline")
+
+// Event is published whenever the network status changes.
+type Event struct {
+	Online bool
+}
+
+type monitor struct {
+	online  atomic.Bool
+	broker  *pubsub.Broker[Event]
+	pending *csync.Map[string, func(context.Context) error]
+	recheck chan struct{}
+
+	mu      sync.Mutex
+	ctx     context.Context
+	probe   func(context.Context) error
+	probing bool
+}
+
+func newMonitor() *monitor {
+	m := &monitor{
+		broker:  pubsub.NewBroker[Event](),
+		pending: csync.NewMap[string, func(context.Context) error](),
+		recheck: make(chan struct{}, 1),
+		ctx:     context.Background(),
+	}
+	m.online.Store(true)
+	return m
+}
+
+var defaultMonitor = newMonitor()
+
+// Start sets the context that bounds probing and queued tasks. Nothing is
+// probed until a request fails with a network error.
+func Start(ctx context.Context) {
+	defaultMonitor.mu.Lock()
+	defer defaultMonitor.mu.Unlock()
+	defaultMonitor.ctx = ctx
+}
+
+// Online reports whether the network is thought to be reachable. It is true
+// until a request fails with a network error, and becomes true again once a
+// request succeeds or the failing endpoint answers.
+func Online() bool {
+	return defaultMonitor.online.Load()
+}
+
+// Unreachable switches to offline mode if err, the outcome of a request to
+// probeURL, is a network error. probeURL is then probed until it answers;
+// any HTTP response counts as being back online. It reports whether err was
+// a network error.
+func Unreachable(probeURL string, err error) bool {
+	if !IsNetworkError(err) {
+		return false
+	}
+	defaultMonitor.unreachable(httpProbe(probeURL), probeURL != "")
+	return true
+}
+
+// Reachable records that a remote request succeeded.
+func Reachable() {
+	defaultMonitor.mu.Lock()
+	ctx := defaultMonitor.ctx
+	defaultMonitor.mu.Unlock()
+	defaultMonitor.setOnline(ctx, true)
+}
+
+// SubscribeEvents returns a channel for network status events.
+func SubscribeEvents(ctx context.Context) <-chan pubsub.Event[Event] {
+	return defaultMonitor.broker.Subscribe(ctx)
+}
+
+// Recheck asks the monitor to probe the network as soon as possible.
+func Recheck() {
+	select {
+	case defaultMonitor.recheck <- struct{}{}:
+	default:
+	}
+}
+
+// WhenOnline queues fn to run the next time the network is confirmed to be
+// reachable. Queuing another function under the same key replaces it.
+func WhenOnline(key string, fn func(context.Context) error) {
+	defaultMonitor.pending.Set(key, fn)
+	Recheck()
+}
+
+// IsNetworkError reports whether err looks like the network itself is
+// unreachable, as opposed to the remote end rejecting the request.
+func IsNetworkError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, ErrOffline) || errors.Is(err, syscall.ENETUNREACH) || errors.Is(err, syscall.EHOSTUNREACH) {
+		return true
+	}
+	// A host that doesn't exist is a bad endpoint, not a lost network.
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsTimeout || dnsErr.IsTemporary
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial" && opErr.Timeout()
+}
+
+// OfflineError wraps err with a friendlier explanation for when name could
+// not be reached because the network is down.
+func OfflineError(name string, err error) error {
+	return fmt.Errorf("%w: could not reach %s, check your network connection (%v)", ErrOffline, name, err)
+}
+
+func (m *monitor) unreachable(probe func(context.Context) error, canProbe bool) {
+	m.mu.Lock()
+	ctx := m.ctx
+	start := canProbe && !m.probing
+	if canProbe {
+		m.probe = probe
+		m.probing = true
+	}
+	m.mu.Unlock()
+
+	m.setOnline(ctx, false)
+	if start {
+		go m.run(ctx)
+	}
+}
+
+// run probes the endpoint that last failed until it answers.
+func (m *monitor) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			m.stopProbing()
+			return
+		case <-time.After(probeInterval):
+		case <-m.recheck:
+		}
+		m.mu.Lock()
+		probe := m.probe
+		m.mu.Unlock()
+
+		probeCtx, cancel := context.WithTimeout(ctx, probeTimeout)
+		err := probe(probeCtx)
+		cancel()
+		if ctx.Err() != nil {
+			m.stopProbing()
+			return
+		}
+		if err == nil {
+			m.stopProbing()
+			m.setOnline(ctx, true)
+			return
+		}
+	}
+}
+
+func (m *monitor) stopProbing() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.probing = false
+}
+
+func (m *monitor) setOnline(ctx context.Context, online bool) {
+	if m.online.Swap(online) != online {
+		if online {
+			slog.Info("Network is reachable again")
+		} else {
+			slog.Warn("Network is unreachable, switching to offline mode")
+		}
+		m.broker.Publish(pubsub.UpdatedEvent, Event{Online: online})
+	}
+	if !online {
+		return
+	}
+	for key := range m.pending.Seq2() {
+		fn, ok := m.pending.Take(key)
+		if !ok {
+			continue
+		}
+		go func() {
+			if err := fn(ctx); err != nil {
+				slog.Warn("Queued network task failed", "task", key, "error", err)
+			}
+		}()
+	}
+}
+
+func httpProbe(url string) func(context.Context) error {
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	}
+}
@@ -0,0 +1,81 @@
+package netstatus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsNetworkError(t *testing.T) {
+	t.Parallel()
+
+	require.False(t, IsNetworkError(nil))
+	require.False(t, IsNetworkError(errors.New("401 unauthorized")))
+	require.True(t, IsNetworkError(fmt.Errorf("request: %w", &net.DNSError{Err: "i/o timeout", Name: "api.example.com", IsTimeout: true})))
+	require.False(t, IsNetworkError(fmt.Errorf("request: %w", &net.DNSError{Err: "no such host", Name: "api.exmaple.com", IsNotFound: true})))
+	require.True(t, IsNetworkError(OfflineError("OpenAI", errors.New("boom"))))
+}
+
+func TestSetOnline(t *testing.T) {
+	t.Parallel()
+
+	m := newMonitor()
+	events := m.broker.Subscribe(t.Context())
+
+	ran := make(chan struct{})
+	m.pending.Set("refresh", func(context.Context) error {
+		close(ran)
+		return nil
+	})
+
+	m.setOnline(t.Context(), false)
+	require.False(t, m.online.Load())
+	event := <-events
+	require.False(t, event.Payload.Online)
+	require.Equal(t, 1, m.pending.Len())
+
+	m.setOnline(t.Context(), true)
+	event = <-events
+	require.True(t, event.Payload.Online)
+
+	select {
+	case <-ran:
+	case <-time.After(time.Second):
+		t.Fatal("queued task did not run after coming back online")
+	}
+	require.Equal(t, 0, m.pending.Len())
+}
+
+func TestUnreachable(t *testing.T) {
+	t.Parallel()
+
+	m := newMonitor()
+	m.ctx = t.Context()
+	var probes atomic.Int32
+	m.unreachable(func(context.Context) error {
+		if probes.Add(1) == 1 {
+			return errors.New("connection refused")
+		}
+		return nil
+	}, true)
+	require.False(t, m.online.Load())
+
+	m.recheck <- struct{}{}
+	m.recheck <- struct{}{}
+	require.Eventually(t, m.online.Load, time.Second, 10*time.Millisecond)
+	require.Equal(t, int32(2), probes.Load())
+
+	// Without an endpoint to probe, only a successful request brings the
+	// monitor back online.
+	m.unreachable(nil, false)
+	require.False(t, m.online.Load())
+	m.mu.Lock()
+	require.False(t, m.probing)
+	m.mu.Unlock()
+}
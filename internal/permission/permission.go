@@ -10,6 +10,7 @@ import (
 
 	"github.com/charmbracelet/crush/internal/csync"
 	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/charmbracelet/crush/internal/stats"
 	"github.com/google/uuid"
 )
 
@@ -23,6 +24,9 @@ type CreatePermissionRequest struct {
 	Action      string `json:"action"`
 	Params      any    `json:"params"`
 	Path        string `json:"path"`
+	// Sensitive requests are never auto-approved and can only be granted
+	// once; they are refused outright when prompts are skipped.
+	Sensitive bool `json:"sensitive"`
 }
 
 type PermissionNotification struct {
@@ -40,6 +44,7 @@ type PermissionRequest struct {
 	Action      string `json:"action"`
 	Params      any    `json:"params"`
 	Path        string `json:"path"`
+	Sensitive   bool   `json:"sensitive"`
 }
 
 type Service interface {
@@ -77,14 +82,17 @@ func (s *permissionService) GrantPersistent(permission PermissionRequest) {
 		ToolCallID: permission.ToolCallID,
 		Granted:    true,
 	})
+	stats.PermissionDecided(permission.ToolName, true)
 	respCh, ok := s.pendingRequests.Get(permission.ID)
 	if ok {
 		respCh <- true
 	}
 
-	s.sessionPermissionsMu.Lock()
-	s.sessionPermissions = append(s.sessionPermissions, permission)
-	s.sessionPermissionsMu.Unlock()
+	if !permission.Sensitive {
+		s.sessionPermissionsMu.Lock()
+		s.sessionPermissions = append(s.sessionPermissions, permission)
+		s.sessionPermissionsMu.Unlock()
+	}
 
 	if s.activeRequest != nil && s.activeRequest.ID == permission.ID {
 		s.activeRequest = nil
@@ -96,6 +104,7 @@ func (s *permissionService) Grant(permission PermissionRequest) {
 		ToolCallID: permission.ToolCallID,
 		Granted:    true,
 	})
+	stats.PermissionDecided(permission.ToolName, true)
 	respCh, ok := s.pendingRequests.Get(permission.ID)
 	if ok {
 		respCh <- true
@@ -112,6 +121,7 @@ func (s *permissionService) Deny(permission PermissionRequest) {
 		Granted:    false,
 		Denied:     true,
 	})
+	stats.PermissionDecided(permission.ToolName, false)
 	respCh, ok := s.pendingRequests.Get(permission.ID)
 	if ok {
 		respCh <- false
@@ -124,7 +134,7 @@ func (s *permissionService) Deny(permission PermissionRequest) {
 
 func (s *permissionService) Request(opts CreatePermissionRequest) bool {
 	if s.skip {
-		return true
+		return !opts.Sensitive
 	}
 
 	// tell the UI that a permission was requested
@@ -136,7 +146,7 @@ func (s *permissionService) Request(opts CreatePermissionRequest) bool {
 
 	// Check if the tool/action combination is in the allowlist
 	commandKey := opts.ToolName + ":" + opts.Action
-	if slices.Contains(s.allowedTools, commandKey) || slices.Contains(s.allowedTools, opts.ToolName) {
+	if !opts.Sensitive && (slices.Contains(s.allowedTools, commandKey) || slices.Contains(s.allowedTools, opts.ToolName)) {
 		return true
 	}
 
@@ -144,7 +154,7 @@ func (s *permissionService) Request(opts CreatePermissionRequest) bool {
 	autoApprove := s.autoApproveSessions[opts.SessionID]
 	s.autoApproveSessionsMu.RUnlock()
 
-	if autoApprove {
+	if autoApprove && !opts.Sensitive {
 		return true
 	}
 
@@ -170,6 +180,7 @@ func (s *permissionService) Request(opts CreatePermissionRequest) bool {
 		Description: opts.Description,
 		Action:      opts.Action,
 		Params:      opts.Params,
+		Sensitive:   opts.Sensitive,
 	}
 
 	s.sessionPermissionsMu.RLock()
@@ -94,6 +94,54 @@ func TestPermissionService_SkipMode(t *testing.T) {
 	}
 }
 
+func TestPermissionService_Sensitive(t *testing.T) {
+	t.Run("refused in skip mode", func(t *testing.T) {
+		service := NewPermissionService("/tmp", true, []string{})
+
+		result := service.Request(CreatePermissionRequest{
+			SessionID: "test-session",
+			ToolName:  "view",
+			Action:    "read",
+			Path:      "/tmp/.env",
+			Sensitive: true,
+		})
+		assert.False(t, result, "sensitive requests should not be granted in skip mode")
+	})
+	t.Run("persistent grants apply once", func(t *testing.T) {
+		service := NewPermissionService("/tmp", false, []string{"view"})
+		service.AutoApproveSession("session1")
+
+		req := CreatePermissionRequest{
+			SessionID: "session1",
+			ToolName:  "view",
+			Action:    "read",
+			Path:      "/tmp/.env",
+			Sensitive: true,
+		}
+
+		events := service.Subscribe(t.Context())
+		var result bool
+		var wg sync.WaitGroup
+
+		wg.Go(func() {
+			result = service.Request(req)
+		})
+		event := <-events
+		assert.True(t, event.Payload.Sensitive)
+		service.GrantPersistent(event.Payload)
+		wg.Wait()
+		assert.True(t, result, "first request should be granted")
+
+		wg.Go(func() {
+			result = service.Request(req)
+		})
+		event = <-events
+		service.Deny(event.Payload)
+		wg.Wait()
+		assert.False(t, result, "second request should prompt again")
+	})
+}
+
 func TestPermissionService_SequentialProperties(t *testing.T) {
 	t.Run("Sequential permission requests with persistent grants", func(t *testing.T) {
 		service := NewPermissionService("/tmp", false, []string{})
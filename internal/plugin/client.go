@@ -0,0 +1,220 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os/exec"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// maxMessageSize bounds the size of a message of a plugin.
+const maxMessageSize = 16 << 20
+
+// errClosed is returned for requests to a plugin that exited.
+var errClosed = errors.New("plugin exited")
+
+type request struct {
+	JSONRPC string `json:"jsonrpc"`
+	ID      int64  `json:"id"`
+	Method  string `json:"method"`
+	Params  any    `json:"params,omitempty"`
+}
+
+type response struct {
+	ID     *int64          `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  *rpcError       `json:"error"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
+// client talks JSON-RPC 2.0 with a plugin process, a message per line on its
+// standard input and output.
+type client struct {
+	name  string
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+
+	nextID  atomic.Int64
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	pending map[int64]chan response
+	err     error
+	done    chan struct{}
+	// stderrDone is closed once the standard error is read, which has to
+	// happen before waiting for the process.
+	stderrDone chan struct{}
+}
+
+func startClient(name string, cmd *exec.Cmd) (*client, error) {
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	c := &client{
+		name:       name,
+		cmd:        cmd,
+		stdin:      stdin,
+		pending:    map[int64]chan response{},
+		done:       make(chan struct{}),
+		stderrDone: make(chan struct{}),
+	}
+	go c.logStderr(stderr)
+	go c.read(stdout)
+	return c, nil
+}
+
+// call sends a request and decodes the result of its response into result.
+func (c *client) call(ctx context.Context, method string, params, result any) error {
+	id := c.nextID.Add(1)
+	ch := make(chan response, 1)
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return c.err
+	}
+	c.pending[id] = ch
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+
+	data, err := json.Marshal(request{JSONRPC: "2.0", ID: id, Method: method, Params: params})
+	if err != nil {
+		return err
+	}
+	c.writeMu.Lock()
+	_, err = c.stdin.Write(append(data, '\n'))
+	c.writeMu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to send %s: %w", method, err)
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case resp, ok := <-ch:
+		if !ok {
+			return c.err
+		}
+		if resp.Error != nil {
+			return resp.Error
+		}
+		if result == nil {
+			return nil
+		}
+		if err := json.Unmarshal(resp.Result, result); err != nil {
+			return fmt.Errorf("invalid result of %s: %w", method, err)
+		}
+		return nil
+	}
+}
+
+// read dispatches the responses of the plugin until it exits. Messages that
+// aren't responses are notifications, of which only log is understood.
+func (c *client) read(stdout io.Reader) {
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), maxMessageSize)
+	for scanner.Scan() {
+		var resp response
+		if err := json.Unmarshal(scanner.Bytes(), &resp); err != nil {
+			slog.Warn("Invalid message from plugin", "plugin", c.name, "error", err)
+			continue
+		}
+		if resp.ID == nil {
+			c.notify(resp)
+			continue
+		}
+		c.mu.Lock()
+		ch, ok := c.pending[*resp.ID]
+		c.mu.Unlock()
+		if ok {
+			ch <- resp
+		}
+	}
+
+	err := errClosed
+	if scanErr := scanner.Err(); scanErr != nil {
+		err = fmt.Errorf("%w: %v", errClosed, scanErr)
+	}
+	c.mu.Lock()
+	c.err = err
+	for id, ch := range c.pending {
+		close(ch)
+		delete(c.pending, id)
+	}
+	c.mu.Unlock()
+	<-c.stderrDone
+	_ = c.cmd.Wait()
+	close(c.done)
+}
+
+func (c *client) notify(msg response) {
+	if msg.Method != "log" {
+		slog.Debug("Ignoring notification from plugin", "plugin", c.name, "method", msg.Method)
+		return
+	}
+	var params struct {
+		Level   string `json:"level"`
+		Message string `json:"message"`
+	}
+	_ = json.Unmarshal(msg.Params, &params)
+	switch params.Level {
+	case "error":
+		slog.Error(params.Message, "plugin", c.name)
+	case "warn":
+		slog.Warn(params.Message, "plugin", c.name)
+	default:
+		slog.Info(params.Message, "plugin", c.name)
+	}
+}
+
+func (c *client) logStderr(stderr io.Reader) {
+	defer close(c.stderrDone)
+	scanner := bufio.NewScanner(stderr)
+	for scanner.Scan() {
+		slog.Debug("Plugin output", "plugin", c.name, "line", scanner.Text())
+	}
+}
+
+// close closes the standard input of the plugin, which should make it exit,
+// and kills it when it doesn't in time.
+func (c *client) close() error {
+	_ = c.stdin.Close()
+	select {
+	case <-c.done:
+		return nil
+	case <-time.After(2 * time.Second):
+		return c.cmd.Process.Kill()
+	}
+}
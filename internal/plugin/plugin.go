@@ -0,0 +1,346 @@
+// Package plugin runs the plugins of Crush: executables in the plugins
+// directory that register tools, commands and renderers of tool results by
+// speaking JSON-RPC 2.0 on their standard input and output, a message per
+// line.
+//
+// Crush starts every plugin and sends it initialize, to which the plugin
+// answers with its manifest. The tools of the manifest are called with
+// tools/call once the user allows it, its commands with commands/run, and
+// the results of the tools it renders are sent to render.
+package plugin
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/version"
+)
+
+const (
+	// ProtocolVersion is the version of the protocol, sent to the plugins
+	// with initialize.
+	ProtocolVersion = 1
+
+	// ToolPrefix starts the names of the tools of plugins, followed by the
+	// ID of the plugin and the name of the tool.
+	ToolPrefix = "plugin_"
+
+	initializeTimeout = 15 * time.Second
+	defaultTimeout    = 60 * time.Second
+)
+
+var (
+	plugins = csync.NewMap[string, *Plugin]()
+
+	// toolNameInvalid matches what can't be in a tool name.
+	toolNameInvalid = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+)
+
+// Manifest is what a plugin registers, in answer to initialize.
+type Manifest struct {
+	Name        string        `json:"name"`
+	Version     string        `json:"version,omitempty"`
+	Description string        `json:"description,omitempty"`
+	Tools       []ToolSpec    `json:"tools,omitempty"`
+	Commands    []CommandSpec `json:"commands,omitempty"`
+	// Renderers are the names of the tools of the plugin it renders the
+	// results of in the chat.
+	Renderers []string `json:"renderers,omitempty"`
+	// ContentRenderers are the content types the plugin renders the results
+	// of, whatever the tool declaring them.
+	ContentRenderers []string `json:"content_renderers,omitempty"`
+}
+
+// ToolSpec describes a tool of a plugin to the model.
+type ToolSpec struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Parameters are the JSON schemas of the parameters, keyed by name.
+	Parameters map[string]any `json:"parameters,omitempty"`
+	Required   []string       `json:"required,omitempty"`
+	Parallel   bool           `json:"parallel,omitempty"`
+}
+
+// CommandSpec describes a command of a plugin, listed with the user
+// commands.
+type CommandSpec struct {
+	Name        string `json:"name"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	// Arguments are asked to the user before running the command.
+	Arguments []string `json:"arguments,omitempty"`
+}
+
+// ToolResult is the result of a call of a tool.
+type ToolResult struct {
+	Content string `json:"content"`
+	IsError bool   `json:"is_error,omitempty"`
+	// Metadata is kept with the result for the renderer of the tool.
+	Metadata json.RawMessage `json:"metadata,omitempty"`
+}
+
+// CommandResult is the result of a command: a prompt sent to the agent, or
+// a message shown to the user.
+type CommandResult struct {
+	Prompt  string `json:"prompt,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// RenderRequest asks a plugin to render the result of a call of one of its
+// tools, or of a content type it renders, in the given number of columns.
+type RenderRequest struct {
+	// Tool is the name of the tool in the plugin, or the full name of the
+	// tool when rendering a content type.
+	Tool        string          `json:"tool"`
+	ContentType string          `json:"content_type,omitempty"`
+	Input       string          `json:"input"`
+	Content     string          `json:"content"`
+	Metadata    json.RawMessage `json:"metadata,omitempty"`
+	IsError     bool            `json:"is_error,omitempty"`
+	Width       int             `json:"width"`
+}
+
+// Plugin is a running plugin.
+type Plugin struct {
+	// ID is the name of the executable without extension, which its
+	// settings are keyed by.
+	ID       string
+	Path     string
+	Manifest Manifest
+
+	cfg    config.PluginConfig
+	client *client
+}
+
+// Dir returns the directory plugins are discovered in, next to the global
+// configuration. Plugins aren't discovered in projects, so that opening a
+// repository never runs its executables.
+func Dir() string {
+	return filepath.Join(filepath.Dir(config.GlobalConfig()), "plugins")
+}
+
+// Discover returns the paths of the executables in dir, sorted.
+func Discover(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") || !entry.Type().IsRegular() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || !isExecutable(entry.Name(), info.Mode()) {
+			continue
+		}
+		paths = append(paths, filepath.Join(dir, entry.Name()))
+	}
+	return paths, nil
+}
+
+func isExecutable(name string, mode os.FileMode) bool {
+	if runtime.GOOS == "windows" {
+		switch strings.ToLower(filepath.Ext(name)) {
+		case ".exe", ".bat", ".cmd":
+			return true
+		}
+		return false
+	}
+	return mode&0o111 != 0
+}
+
+// ID returns the ID of the plugin at path.
+func ID(path string) string {
+	base := filepath.Base(path)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// Start starts the plugin at path and initializes it.
+func Start(ctx context.Context, path string, cfg config.PluginConfig, workingDir string) (*Plugin, error) {
+	id := ID(path)
+	cmd := exec.Command(path)
+	cmd.Dir = workingDir
+	cmd.Env = append(os.Environ(), "CRUSH_PLUGIN_PROTOCOL=1")
+	cmd.Env = append(cmd.Env, cfg.ResolvedEnv()...)
+	c, err := startClient(id, cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start plugin %s: %w", id, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, initializeTimeout)
+	defer cancel()
+	var manifest Manifest
+	err = c.call(ctx, "initialize", map[string]any{
+		"protocol_version": ProtocolVersion,
+		"crush_version":    version.Version,
+		"working_dir":      workingDir,
+	}, &manifest)
+	if err != nil {
+		_ = c.close()
+		return nil, fmt.Errorf("failed to initialize plugin %s: %w", id, err)
+	}
+	manifest.Name = cmp.Or(manifest.Name, id)
+	manifest.Tools = slices.DeleteFunc(manifest.Tools, func(t ToolSpec) bool {
+		return t.Name == "" || slices.Contains(cfg.DisabledTools, t.Name)
+	})
+	manifest.Renderers = slices.DeleteFunc(manifest.Renderers, func(name string) bool {
+		return !slices.ContainsFunc(manifest.Tools, func(t ToolSpec) bool { return t.Name == name })
+	})
+	return &Plugin{
+		ID:       id,
+		Path:     path,
+		Manifest: manifest,
+		cfg:      cfg,
+		client:   c,
+	}, nil
+}
+
+// Initialize starts the plugins of the plugins directory that aren't
+// disabled. Plugins that fail to start are skipped with a warning.
+func Initialize(ctx context.Context, cfg *config.Config) {
+	paths, err := Discover(Dir())
+	if err != nil {
+		slog.Warn("Could not list the plugins", "dir", Dir(), "error", err)
+		return
+	}
+	var wg sync.WaitGroup
+	for _, path := range paths {
+		pcfg := cfg.Plugins[ID(path)]
+		if pcfg.Disabled {
+			slog.Debug("Skipping disabled plugin", "plugin", ID(path))
+			continue
+		}
+		wg.Go(func() {
+			p, err := Start(ctx, path, pcfg, cfg.WorkingDir())
+			if err != nil {
+				slog.Warn("Could not start plugin", "path", path, "error", err)
+				return
+			}
+			slog.Info("Started plugin", "plugin", p.ID, "tools", len(p.Manifest.Tools), "commands", len(p.Manifest.Commands))
+			plugins.Set(p.ID, p)
+		})
+	}
+	wg.Wait()
+}
+
+// List returns the running plugins, sorted by ID.
+func List() []*Plugin {
+	return slices.SortedFunc(plugins.Seq(), func(a, b *Plugin) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+}
+
+// Close stops the running plugins.
+func Close() error {
+	var wg sync.WaitGroup
+	for id, p := range maps.Collect(plugins.Seq2()) {
+		plugins.Del(id)
+		wg.Go(func() {
+			if err := p.client.close(); err != nil {
+				slog.Warn("Could not stop plugin", "plugin", id, "error", err)
+			}
+		})
+	}
+	wg.Wait()
+	return nil
+}
+
+// ToolName returns the name the model knows a tool of a plugin by, within
+// the 64 characters providers allow.
+func ToolName(pluginID, tool string) string {
+	name := toolNameInvalid.ReplaceAllString(ToolPrefix+pluginID+"_"+tool, "_")
+	return name[:min(len(name), 64)]
+}
+
+// Renderer returns the plugin rendering the results of the tool named
+// name, and the name of the tool in the plugin.
+func Renderer(name string) (*Plugin, string, bool) {
+	if !strings.HasPrefix(name, ToolPrefix) {
+		return nil, "", false
+	}
+	for p := range plugins.Seq() {
+		for _, tool := range p.Manifest.Renderers {
+			if ToolName(p.ID, tool) == name {
+				return p, tool, true
+			}
+		}
+	}
+	return nil, "", false
+}
+
+// ContentRenderer returns the plugin rendering the results of a content
+// type.
+func ContentRenderer(contentType string) (*Plugin, bool) {
+	for _, p := range List() {
+		if slices.Contains(p.Manifest.ContentRenderers, contentType) {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+func (p *Plugin) timeout() time.Duration {
+	if p.cfg.Timeout > 0 {
+		return time.Duration(p.cfg.Timeout) * time.Second
+	}
+	return defaultTimeout
+}
+
+// CallTool calls a tool of the plugin with the JSON input of the model.
+func (p *Plugin) CallTool(ctx context.Context, tool, input, sessionID string) (ToolResult, error) {
+	raw := json.RawMessage(cmp.Or(strings.TrimSpace(input), "{}"))
+	if !json.Valid(raw) {
+		return ToolResult{}, fmt.Errorf("invalid input of %s: not JSON", tool)
+	}
+	ctx, cancel := context.WithTimeout(ctx, p.timeout())
+	defer cancel()
+	var result ToolResult
+	err := p.client.call(ctx, "tools/call", map[string]any{
+		"name":       tool,
+		"input":      raw,
+		"session_id": sessionID,
+	}, &result)
+	return result, err
+}
+
+// RunCommand runs a command of the plugin with the arguments the user gave.
+func (p *Plugin) RunCommand(ctx context.Context, command string, args map[string]string) (CommandResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout())
+	defer cancel()
+	var result CommandResult
+	err := p.client.call(ctx, "commands/run", map[string]any{
+		"name":      command,
+		"arguments": args,
+	}, &result)
+	return result, err
+}
+
+// Render renders the result of a call of a tool of the plugin.
+func (p *Plugin) Render(ctx context.Context, req RenderRequest) (string, error) {
+	var result struct {
+		Text string `json:"text"`
+	}
+	if err := p.client.call(ctx, "render", req, &result); err != nil {
+		return "", err
+	}
+	return result.Text, nil
+}
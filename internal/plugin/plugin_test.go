@@ -0,0 +1,150 @@
+package plugin
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMain makes the test binary a plugin when it's started as one.
+func TestMain(m *testing.M) {
+	if os.Getenv("CRUSH_TEST_PLUGIN") == "1" {
+		runTestPlugin()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runTestPlugin is a plugin with an echo tool, a command and a renderer.
+func runTestPlugin() {
+	scanner := bufio.NewScanner(os.Stdin)
+	out := json.NewEncoder(os.Stdout)
+	for scanner.Scan() {
+		var req struct {
+			ID     int64           `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+		_ = json.Unmarshal(scanner.Bytes(), &req)
+		resp := map[string]any{"jsonrpc": "2.0", "id": req.ID}
+		switch req.Method {
+		case "initialize":
+			_ = out.Encode(map[string]any{"jsonrpc": "2.0", "method": "log", "params": map[string]string{"message": "hello"}})
+			resp["result"] = Manifest{
+				Name: "Test",
+				Tools: []ToolSpec{
+					{Name: "echo", Description: "Echoes", Parameters: map[string]any{"text": map[string]any{"type": "string"}}},
+					{Name: "secret", Description: "Disabled"},
+				},
+				Commands:         []CommandSpec{{Name: "greet", Arguments: []string{"name"}}},
+				Renderers:        []string{"echo", "secret"},
+				ContentRenderers: []string{"application/vnd.test+json"},
+			}
+		case "tools/call":
+			var params struct {
+				Name  string `json:"name"`
+				Input struct {
+					Text string `json:"text"`
+				} `json:"input"`
+			}
+			_ = json.Unmarshal(req.Params, &params)
+			resp["result"] = ToolResult{Content: params.Input.Text}
+		case "commands/run":
+			var params struct {
+				Arguments map[string]string `json:"arguments"`
+			}
+			_ = json.Unmarshal(req.Params, &params)
+			resp["result"] = CommandResult{Prompt: "Say hello to " + params.Arguments["name"]}
+		case "render":
+			var params RenderRequest
+			_ = json.Unmarshal(req.Params, &params)
+			resp["result"] = map[string]string{"text": fmt.Sprintf("%d:%s", params.Width, strings.ToUpper(params.Content))}
+		default:
+			resp["error"] = map[string]any{"code": -32601, "message": "method not found"}
+		}
+		_ = out.Encode(resp)
+	}
+}
+
+func TestPlugin(t *testing.T) {
+	p, err := Start(t.Context(), os.Args[0], config.PluginConfig{
+		Env:           map[string]string{"CRUSH_TEST_PLUGIN": "1"},
+		DisabledTools: []string{"secret"},
+	}, t.TempDir())
+	require.NoError(t, err)
+	defer p.client.close()
+
+	require.Equal(t, "Test", p.Manifest.Name)
+	require.Len(t, p.Manifest.Tools, 1)
+	require.Equal(t, "echo", p.Manifest.Tools[0].Name)
+
+	result, err := p.CallTool(t.Context(), "echo", `{"text":"hi"}`, "session")
+	require.NoError(t, err)
+	require.Equal(t, "hi", result.Content)
+
+	_, err = p.CallTool(t.Context(), "echo", `{"text":`, "session")
+	require.ErrorContains(t, err, "not JSON")
+
+	command, err := p.RunCommand(t.Context(), "greet", map[string]string{"name": "Ana"})
+	require.NoError(t, err)
+	require.Equal(t, "Say hello to Ana", command.Prompt)
+
+	text, err := p.Render(t.Context(), RenderRequest{Tool: "echo", Content: "hi", Width: 40})
+	require.NoError(t, err)
+	require.Equal(t, "40:HI", text)
+
+	err = p.client.call(t.Context(), "unknown", nil, nil)
+	require.ErrorContains(t, err, "method not found")
+
+	plugins.Set(p.ID, p)
+	defer plugins.Del(p.ID)
+	rp, tool, ok := Renderer(ToolName(p.ID, "echo"))
+	require.True(t, ok)
+	require.Equal(t, p, rp)
+	require.Equal(t, "echo", tool)
+	_, _, ok = Renderer(ToolName(p.ID, "secret"))
+	require.False(t, ok)
+	rp, ok = ContentRenderer("application/vnd.test+json")
+	require.True(t, ok)
+	require.Equal(t, p, rp)
+	_, ok = ContentRenderer("application/json")
+	require.False(t, ok)
+
+	require.NoError(t, p.client.close())
+	_, err = p.CallTool(t.Context(), "echo", `{}`, "session")
+	require.ErrorIs(t, err, errClosed)
+}
+
+func TestDiscover(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("executables are told apart by their extension")
+	}
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "jira"), []byte("#!/bin/sh\n"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("readme"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".hidden"), []byte("#!/bin/sh\n"), 0o755))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "data"), 0o755))
+
+	paths, err := Discover(dir)
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join(dir, "jira")}, paths)
+
+	paths, err = Discover(filepath.Join(dir, "missing"))
+	require.NoError(t, err)
+	require.Empty(t, paths)
+}
+
+func TestToolName(t *testing.T) {
+	require.Equal(t, "plugin_jira_create_issue", ToolName("jira", "create_issue"))
+	require.Equal(t, "plugin_my_plugin_run_it", ToolName("my.plugin", "run it"))
+	require.Len(t, ToolName("jira", strings.Repeat("x", 100)), 64)
+	require.Equal(t, "jira", ID("/plugins/jira.exe"))
+}
@@ -0,0 +1,287 @@
+// Package precommit runs a repository's pre-commit hooks against the files
+// the agent changed during a turn.
+//
+// It understands the pre-commit framework (.pre-commit-config.yaml),
+// lint-staged (via husky or package.json) and plain git hooks
+// (.git/hooks/pre-commit). A custom command can be configured instead of
+// auto-detection.
+package precommit
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/shell"
+)
+
+// DefaultTimeout is used when no timeout is configured.
+const DefaultTimeout = 2 * time.Minute
+
+// ErrNoHooks is returned when no pre-commit hooks could be found in the
+// repository and no custom command was configured.
+var ErrNoHooks = errors.New("no pre-commit hooks found")
+
+// Kind identifies the hook runner that was detected.
+type Kind string
+
+const (
+	KindCustom     Kind = "custom"
+	KindPreCommit  Kind = "pre-commit"
+	KindLintStaged Kind = "lint-staged"
+	KindGitHook    Kind = "git-hook"
+)
+
+// Runner describes how hooks are invoked for a repository.
+type Runner struct {
+	Kind    Kind
+	Command string
+	// PassFiles indicates whether the changed files should be appended to
+	// the command as arguments.
+	PassFiles bool
+	// StageFiles indicates whether the changed files should be staged for
+	// the command, which only checks staged files. They're staged in a
+	// temporary copy of the index, leaving the real one as it is.
+	StageFiles bool
+}
+
+// Failure is a single failing hook.
+type Failure struct {
+	Hook   string
+	Output string
+}
+
+// Result is the outcome of running the hooks.
+type Result struct {
+	Runner   Runner
+	Files    []string
+	Output   string
+	Failures []Failure
+}
+
+// Passed reports whether all hooks succeeded.
+func (r Result) Passed() bool {
+	return len(r.Failures) == 0
+}
+
+// Detect finds the hook runner for the repository at dir. If command is not
+// empty it is used as is and the changed files are appended to it.
+func Detect(dir, command string) (Runner, error) {
+	if command != "" {
+		return Runner{Kind: KindCustom, Command: command, PassFiles: true}, nil
+	}
+	if exists(filepath.Join(dir, ".pre-commit-config.yaml")) {
+		return Runner{Kind: KindPreCommit, Command: "pre-commit run --files", PassFiles: true}, nil
+	}
+	if exists(filepath.Join(dir, ".lintstagedrc")) ||
+		exists(filepath.Join(dir, ".lintstagedrc.json")) ||
+		exists(filepath.Join(dir, "lint-staged.config.js")) ||
+		hasLintStagedPackage(dir) {
+		return Runner{Kind: KindLintStaged, Command: "npx --no-install lint-staged", StageFiles: true}, nil
+	}
+	for _, hook := range []string{
+		filepath.Join(dir, ".husky", "pre-commit"),
+		filepath.Join(dir, ".git", "hooks", "pre-commit"),
+	} {
+		if isExecutable(hook) {
+			return Runner{Kind: KindGitHook, Command: hook, StageFiles: true}, nil
+		}
+	}
+	return Runner{}, ErrNoHooks
+}
+
+// Run executes the hooks on the given files, relative to dir.
+func Run(ctx context.Context, dir string, runner Runner, files []string, timeout time.Duration) (Result, error) {
+	result := Result{Runner: runner, Files: files}
+	if len(files) == 0 {
+		return result, nil
+	}
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	command := runner.Command
+	if runner.PassFiles {
+		quoted := make([]string, 0, len(files))
+		for _, f := range files {
+			quoted = append(quoted, shellQuote(f))
+		}
+		command += " " + strings.Join(quoted, " ")
+	}
+
+	opts := &shell.Options{WorkingDir: dir}
+	if runner.StageFiles {
+		index, cleanup, err := stageFiles(ctx, dir, files)
+		if err != nil {
+			return result, err
+		}
+		defer cleanup()
+		opts.Env = append(os.Environ(), "GIT_INDEX_FILE="+index)
+	}
+	sh := shell.NewShell(opts)
+	stdout, stderr, err := sh.Exec(ctx, command)
+	result.Output = strings.TrimSpace(stdout + "\n" + stderr)
+	if err == nil {
+		return result, nil
+	}
+	if shell.IsInterrupt(err) {
+		return result, fmt.Errorf("pre-commit hooks timed out or were canceled: %w", err)
+	}
+	result.Failures = parseFailures(runner, result.Output)
+	return result, nil
+}
+
+// ChangedFiles returns the paths in files relative to dir, skipping files
+// outside of it and files that no longer exist.
+func ChangedFiles(dir string, files []string) []string {
+	var rel []string
+	seen := make(map[string]bool, len(files))
+	for _, f := range files {
+		if !filepath.IsAbs(f) {
+			f = filepath.Join(dir, f)
+		}
+		r, err := filepath.Rel(dir, f)
+		if err != nil || r == ".." || strings.HasPrefix(r, ".."+string(filepath.Separator)) || seen[r] || !exists(f) {
+			continue
+		}
+		seen[r] = true
+		rel = append(rel, filepath.ToSlash(r))
+	}
+	return rel
+}
+
+// stageFiles stages files in a copy of the index of the repository at dir,
+// and returns the path to the copy with a function removing it.
+func stageFiles(ctx context.Context, dir string, files []string) (string, func(), error) {
+	git := func(env []string, args ...string) ([]byte, error) {
+		cmd := exec.CommandContext(ctx, "git", args...)
+		cmd.Dir = dir
+		cmd.Env = env
+		return cmd.CombinedOutput()
+	}
+	out, err := git(nil, "rev-parse", "--git-path", "index")
+	if err != nil {
+		return "", nil, fmt.Errorf("finding the git index: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	index := strings.TrimSpace(string(out))
+	if !filepath.IsAbs(index) {
+		index = filepath.Join(dir, index)
+	}
+
+	tmp, err := os.MkdirTemp("", "crush-precommit-")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() { _ = os.RemoveAll(tmp) }
+	staged := filepath.Join(tmp, "index")
+	// A repository without commits may have no index yet.
+	if err := copyFile(index, staged); err != nil && !errors.Is(err, os.ErrNotExist) {
+		cleanup()
+		return "", nil, fmt.Errorf("copying the git index: %w", err)
+	}
+	env := append(os.Environ(), "GIT_INDEX_FILE="+staged)
+	if out, err := git(env, append([]string{"add", "--"}, files...)...); err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("staging the changed files: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return staged, cleanup, nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// pre-commit prints one line per hook, e.g.:
+//
+//	gofmt....................................................................Failed
+var preCommitHookLine = regexp.MustCompile(`^(.+?)\.{3,}(?:\(.*\))?(Passed|Failed|Skipped)$`)
+
+func parseFailures(runner Runner, output string) []Failure {
+	if runner.Kind != KindPreCommit {
+		return []Failure{{Hook: string(runner.Kind), Output: output}}
+	}
+
+	var failures []Failure
+	var current *Failure
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if m := preCommitHookLine.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			current = nil
+			if m[2] == "Failed" {
+				failures = append(failures, Failure{Hook: strings.TrimSpace(m[1])})
+				current = &failures[len(failures)-1]
+			}
+			continue
+		}
+		if current != nil {
+			current.Output = strings.TrimLeft(current.Output+"\n"+line, "\n")
+		}
+	}
+	if len(failures) == 0 {
+		failures = append(failures, Failure{Hook: string(runner.Kind), Output: output})
+	}
+	return failures
+}
+
+// Summary renders a short, human readable description of the failures
+// suitable for sending back to the agent.
+func (r Result) Summary() string {
+	if r.Passed() {
+		return "All pre-commit hooks passed."
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "The repository's pre-commit hooks (%s) failed on the files you changed:\n", r.Runner.Kind)
+	for _, f := range r.Failures {
+		fmt.Fprintf(&sb, "\n## %s\n", f.Hook)
+		if out := strings.TrimSpace(f.Output); out != "" {
+			fmt.Fprintf(&sb, "\n```\n%s\n```\n", out)
+		}
+	}
+	return sb.String()
+}
+
+func hasLintStagedPackage(dir string) bool {
+	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return false
+	}
+	return strings.Contains(string(data), `"lint-staged"`)
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir() && info.Mode()&0o111 != 0
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,121 @@
+package precommit
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetect(t *testing.T) {
+	t.Parallel()
+
+	t.Run("custom command", func(t *testing.T) {
+		t.Parallel()
+		runner, err := Detect(t.TempDir(), "make lint")
+		require.NoError(t, err)
+		require.Equal(t, KindCustom, runner.Kind)
+		require.True(t, runner.PassFiles)
+	})
+
+	t.Run("pre-commit config", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, ".pre-commit-config.yaml"), []byte("repos: []\n"), 0o644))
+		runner, err := Detect(dir, "")
+		require.NoError(t, err)
+		require.Equal(t, KindPreCommit, runner.Kind)
+	})
+
+	t.Run("lint-staged in package.json", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "package.json"), []byte(`{"lint-staged": {}}`), 0o644))
+		runner, err := Detect(dir, "")
+		require.NoError(t, err)
+		require.Equal(t, KindLintStaged, runner.Kind)
+		require.False(t, runner.PassFiles)
+		require.True(t, runner.StageFiles)
+	})
+
+	t.Run("no hooks", func(t *testing.T) {
+		t.Parallel()
+		_, err := Detect(t.TempDir(), "")
+		require.ErrorIs(t, err, ErrNoHooks)
+	})
+}
+
+func TestParseFailures(t *testing.T) {
+	t.Parallel()
+
+	output := `gofmt....................................................................Passed
+golangci-lint............................................................Failed
+- hook id: golangci-lint
+- exit code: 1
+
+main.go:3:1: unused import
+trailing whitespace......................................(no files to check)Skipped`
+
+	failures := parseFailures(Runner{Kind: KindPreCommit}, output)
+	require.Len(t, failures, 1)
+	require.Equal(t, "golangci-lint", failures[0].Hook)
+	require.Contains(t, failures[0].Output, "unused import")
+}
+
+func TestChangedFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.go"), nil, 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "..b.go"), nil, 0o644))
+
+	files := ChangedFiles(dir, []string{
+		filepath.Join(dir, "a.go"),
+		"a.go",
+		"..b.go",
+		filepath.Join(dir, "deleted.go"),
+		filepath.Join(filepath.Dir(dir), "outside.go"),
+	})
+	require.Equal(t, []string{"a.go", "..b.go"}, files)
+}
+
+func TestRunStagesFiles(t *testing.T) {
+	t.Parallel()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX hook")
+	}
+
+	dir := t.TempDir()
+	git := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+		return strings.TrimSpace(string(out))
+	}
+	git("init", "-q")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.go"), []byte("package a\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.go"), []byte("package b\n"), 0o644))
+	hook := filepath.Join(dir, ".git", "hooks", "pre-commit")
+	require.NoError(t, os.WriteFile(hook, []byte("#!/bin/sh\ngit diff --cached --name-only\nexit 1\n"), 0o755))
+
+	runner, err := Detect(dir, "")
+	require.NoError(t, err)
+	require.Equal(t, KindGitHook, runner.Kind)
+
+	// The hook sees the changed files as staged, and only those.
+	result, err := Run(t.Context(), dir, runner, []string{"a.go"}, 0)
+	require.NoError(t, err)
+	require.False(t, result.Passed())
+	require.Equal(t, "a.go", result.Output)
+
+	// The real index is left as it was.
+	require.Empty(t, git("diff", "--cached", "--name-only"))
+}
@@ -0,0 +1,122 @@
+// Package preview serves Markdown rendered as HTML on a local server, to
+// read long responses in a browser rather than in the terminal.
+package preview
+
+import (
+	"bytes"
+	"crypto/rand"
+	_ "embed"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"net"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// maxPages is how many pages are served at once. Older ones are dropped as
+// new ones are added.
+const maxPages = 20
+
+//go:embed page.html
+var pageHTML string
+
+var pageTemplate = template.Must(template.New("page").Parse(pageHTML))
+
+var markdown = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+var (
+	mu     sync.Mutex
+	server *http.Server
+	addr   string
+	pages  = map[string][]byte{}
+	// order holds the paths of the pages, the oldest first.
+	order []string
+)
+
+// Serve renders Markdown as an HTML page, and returns the URL the page is
+// served at. The server only listens on the loopback interface, and is
+// started the first time a page is served.
+func Serve(title, source string) (string, error) {
+	var body bytes.Buffer
+	if err := markdown.Convert([]byte(source), &body); err != nil {
+		return "", fmt.Errorf("failed to render the preview: %w", err)
+	}
+	var page bytes.Buffer
+	if err := pageTemplate.Execute(&page, struct {
+		Title string
+		Body  template.HTML
+	}{title, template.HTML(body.String())}); err != nil {
+		return "", fmt.Errorf("failed to render the preview: %w", err)
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	path := "/" + hex.EncodeToString(b)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if server == nil {
+		if err := start(); err != nil {
+			return "", err
+		}
+	}
+	pages[path] = page.Bytes()
+	order = append(order, path)
+	if len(order) > maxPages {
+		delete(pages, order[0])
+		order = slices.Delete(order, 0, 1)
+	}
+	return "http://" + addr + path, nil
+}
+
+// start starts the server. It must be called with mu held.
+func start() error {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return fmt.Errorf("failed to start the preview server: %w", err)
+	}
+	srv := &http.Server{
+		Handler:           http.HandlerFunc(servePage),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() { _ = srv.Serve(ln) }()
+	server, addr = srv, ln.Addr().String()
+	return nil
+}
+
+func servePage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	mu.Lock()
+	page, ok := pages[r.URL.Path]
+	mu.Unlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	_, _ = w.Write(page)
+}
+
+// Close stops the server, and drops the pages it served.
+func Close() error {
+	mu.Lock()
+	defer mu.Unlock()
+	if server == nil {
+		return nil
+	}
+	err := server.Close()
+	server, addr, pages, order = nil, "", map[string][]byte{}, nil
+	return err
+}
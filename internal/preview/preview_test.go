@@ -0,0 +1,43 @@
+package preview
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestServe(t *testing.T) {
+	t.Cleanup(func() { _ = Close() })
+
+	url, err := Serve("Design <Doc>", "# Plan\n\n| a | b |\n| - | - |\n| 1 | 2 |\n\n<script>alert(1)</script>\n")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(url, "http://127.0.0.1:"))
+
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Contains(t, string(body), "<h1>Plan</h1>")
+	require.Contains(t, string(body), "<td>1</td>")
+	require.Contains(t, string(body), "Design &lt;Doc&gt;")
+	require.NotContains(t, string(body), "<script>alert(1)</script>")
+
+	resp, err = http.Get(url[:strings.LastIndex(url, "/")] + "/unknown")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	// Older pages are dropped once there are too many.
+	for range maxPages {
+		_, err := Serve("Page", "text")
+		require.NoError(t, err)
+	}
+	resp, err = http.Get(url)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -0,0 +1,77 @@
+// Package privacy implements privacy zones: paths whose contents must never
+// be sent to a provider unless the user explicitly allows it.
+package privacy
+
+import (
+	"path/filepath"
+	"strings"
+
+	ignore "github.com/sabhiram/go-gitignore"
+)
+
+// Zones matches paths against a set of gitignore-style patterns.
+type Zones struct {
+	root    string
+	matcher *ignore.GitIgnore
+}
+
+// NewZones compiles the given patterns relative to root. It returns nil when
+// there are no patterns, and a nil *Zones matches nothing.
+func NewZones(root string, patterns []string) *Zones {
+	if len(patterns) == 0 {
+		return nil
+	}
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	return &Zones{
+		root:    root,
+		matcher: ignore.CompileIgnoreLines(patterns...),
+	}
+}
+
+// Match reports whether path is inside a privacy zone, returning the
+// pattern that matched. Paths outside of the root are matched by their
+// absolute path so unanchored patterns such as ".env*" still apply.
+func (z *Zones) Match(path string) (string, bool) {
+	if z == nil || path == "" {
+		return "", false
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(z.root, path)
+	}
+	rel, err := filepath.Rel(z.root, filepath.Clean(path))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		rel = strings.TrimPrefix(filepath.ToSlash(filepath.Clean(path)), "/")
+	}
+	if rel == "." {
+		return "", false
+	}
+	ok, pattern := z.matcher.MatchesPathHow(rel)
+	if !ok || pattern == nil {
+		return "", false
+	}
+	return pattern.Line, true
+}
+
+// Contains reports whether path is inside a privacy zone.
+func (z *Zones) Contains(path string) bool {
+	_, ok := z.Match(path)
+	return ok
+}
+
+// Filter returns the paths outside of the privacy zones, and how many were
+// withheld. It reuses the backing array of paths.
+func (z *Zones) Filter(paths []string) ([]string, int) {
+	if z == nil {
+		return paths, 0
+	}
+	kept := paths[:0]
+	for _, path := range paths {
+		if _, ok := z.Match(path); ok {
+			continue
+		}
+		kept = append(kept, path)
+	}
+	return kept, len(paths) - len(kept)
+}
@@ -0,0 +1,63 @@
+package privacy
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestZonesMatch(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	zones := NewZones(root, []string{".env*", "secrets/", "customer_data/", "!.env.example"})
+
+	tests := []struct {
+		path    string
+		pattern string
+		match   bool
+	}{
+		{path: ".env", pattern: ".env*", match: true},
+		{path: "config/.env.local", pattern: ".env*", match: true},
+		{path: ".env.example", match: false},
+		{path: "secrets/api.txt", pattern: "secrets/", match: true},
+		{path: filepath.Join(root, "customer_data", "export.csv"), pattern: "customer_data/", match: true},
+		{path: "main.go", match: false},
+		{path: "docs/secrets.md", match: false},
+		{path: filepath.Join(filepath.Dir(root), ".env"), pattern: ".env*", match: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			t.Parallel()
+			pattern, ok := zones.Match(tt.path)
+			require.Equal(t, tt.match, ok)
+			require.Equal(t, tt.pattern, pattern)
+		})
+	}
+}
+
+func TestNilZones(t *testing.T) {
+	t.Parallel()
+
+	zones := NewZones(t.TempDir(), nil)
+	require.Nil(t, zones)
+	_, ok := zones.Match(".env")
+	require.False(t, ok)
+	require.False(t, zones.Contains(".env"))
+}
+
+func TestZonesFilter(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	zones := NewZones(root, []string{".env*", "secrets/"})
+	kept, withheld := zones.Filter([]string{"main.go", ".env", "secrets/token.txt", filepath.Join(root, "go.mod")})
+	require.Equal(t, []string{"main.go", filepath.Join(root, "go.mod")}, kept)
+	require.Equal(t, 2, withheld)
+
+	var none *Zones
+	kept, withheld = none.Filter([]string{".env"})
+	require.Equal(t, []string{".env"}, kept)
+	require.Zero(t, withheld)
+}
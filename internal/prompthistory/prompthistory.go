@@ -0,0 +1,178 @@
+// Package prompthistory keeps the prompts sent to the agent, in a history of
+// the project and one of all projects, so they can be recalled and searched
+// like the history of a shell.
+package prompthistory
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// fileName is the name of the file of the data directory the prompts
+	// are saved to.
+	fileName = "prompts.jsonl"
+
+	// maxEntries is how many prompts a history keeps, forgetting the oldest
+	// ones.
+	maxEntries = 1000
+)
+
+// Entry is a prompt sent to the agent.
+type Entry struct {
+	Prompt string `json:"prompt"`
+	// Project is the working directory the prompt was sent in.
+	Project string `json:"project,omitempty"`
+	Time    int64  `json:"time"`
+}
+
+// History is the prompts sent in a project, and in all projects.
+type History struct {
+	project string
+	local   *file
+	global  *file
+}
+
+// New returns the history of the prompts sent in the project, saved to its
+// data directory, and of all projects, saved to the global data directory.
+func New(dataDir, globalDataDir, project string) *History {
+	return &History{
+		project: project,
+		local:   &file{path: filepath.Join(dataDir, fileName)},
+		global:  &file{path: filepath.Join(globalDataDir, fileName)},
+	}
+}
+
+// Add records a prompt sent in the project.
+func (h *History) Add(prompt string) error {
+	prompt = strings.TrimSpace(prompt)
+	if prompt == "" {
+		return nil
+	}
+	entry := Entry{Prompt: prompt, Project: h.project, Time: time.Now().Unix()}
+	return errors.Join(h.local.add(entry), h.global.add(entry))
+}
+
+// Project returns the prompts sent in the project, the oldest first, without
+// repeating the same prompt sent again in a row.
+func (h *History) Project() ([]Entry, error) {
+	return h.local.list()
+}
+
+// All returns the prompts sent in all projects, the newest first, each
+// prompt only once.
+func (h *History) All() ([]Entry, error) {
+	entries, err := h.global.list()
+	if err != nil {
+		return nil, err
+	}
+	slices.Reverse(entries)
+	seen := make(map[string]bool, len(entries))
+	return slices.DeleteFunc(entries, func(e Entry) bool {
+		if seen[e.Prompt] {
+			return true
+		}
+		seen[e.Prompt] = true
+		return false
+	}), nil
+}
+
+// file is a history saved to a file, an entry a line, so adding one only
+// appends to it.
+type file struct {
+	path string
+
+	mu      sync.Mutex
+	entries []Entry
+	loaded  bool
+}
+
+// load reads the entries once. Must be called with mu held.
+func (f *file) load() error {
+	if f.loaded {
+		return nil
+	}
+	data, err := os.ReadFile(f.path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		var entry Entry
+		// A line cut short by a crash is skipped.
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err == nil && entry.Prompt != "" {
+			f.entries = append(f.entries, entry)
+		}
+	}
+	f.loaded = true
+	return nil
+}
+
+func (f *file) list() ([]Entry, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.load(); err != nil {
+		return nil, err
+	}
+	return slices.Clone(f.entries), nil
+}
+
+func (f *file) add(entry Entry) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.load(); err != nil {
+		return err
+	}
+	if n := len(f.entries); n > 0 && f.entries[n-1].Prompt == entry.Prompt {
+		return nil
+	}
+	f.entries = append(f.entries, entry)
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o700); err != nil {
+		return err
+	}
+	// Rewrite the file once it holds a tenth more entries than kept, to
+	// forget the oldest ones.
+	if len(f.entries) > maxEntries+maxEntries/10 {
+		f.entries = slices.Clone(f.entries[len(f.entries)-maxEntries:])
+		return f.save()
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	w, err := os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(append(line, '\n')); err != nil {
+		_ = w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// save writes all the entries. Must be called with mu held.
+func (f *file) save() error {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	for _, entry := range f.entries {
+		if err := enc.Encode(entry); err != nil {
+			return err
+		}
+	}
+	tmp := f.path + ".tmp"
+	if err := os.WriteFile(tmp, b.Bytes(), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, f.path)
+}
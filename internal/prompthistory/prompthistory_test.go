@@ -0,0 +1,67 @@
+package prompthistory
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func prompts(entries []Entry) []string {
+	var prompts []string
+	for _, e := range entries {
+		prompts = append(prompts, e.Prompt)
+	}
+	return prompts
+}
+
+func TestHistory(t *testing.T) {
+	t.Parallel()
+
+	global := t.TempDir()
+	api := New(filepath.Join(t.TempDir(), ".crush"), global, "/src/api")
+	web := New(filepath.Join(t.TempDir(), ".crush"), global, "/src/web")
+	require.NoError(t, api.Add("Run the tests"))
+	require.NoError(t, api.Add("Run the tests"))
+	require.NoError(t, api.Add("  "))
+	require.NoError(t, web.Add("Fix the build"))
+	require.NoError(t, api.Add("Explain the router"))
+	require.NoError(t, api.Add("Run the tests"))
+
+	// The project history keeps the order, without repeats in a row.
+	entries, err := api.Project()
+	require.NoError(t, err)
+	require.Equal(t, []string{"Run the tests", "Explain the router", "Run the tests"}, prompts(entries))
+	require.Equal(t, "/src/api", entries[0].Project)
+
+	// The global history has each prompt once, the newest first, and is
+	// read back from its file.
+	entries, err = New(t.TempDir(), global, "/src/other").All()
+	require.NoError(t, err)
+	require.Equal(t, []string{"Run the tests", "Explain the router", "Fix the build"}, prompts(entries))
+	require.Equal(t, "/src/web", entries[2].Project)
+}
+
+func TestHistoryForgetsOldest(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	h := New(dir, t.TempDir(), "/src/api")
+	for i := range maxEntries + maxEntries/10 + 1 {
+		require.NoError(t, h.Add(string(rune('a'+i%26))+string(rune('a'+i/26%26))))
+	}
+	entries, err := New(dir, t.TempDir(), "/src/api").Project()
+	require.NoError(t, err)
+	require.Len(t, entries, maxEntries)
+
+	// A line cut short is skipped.
+	f, err := os.OpenFile(filepath.Join(dir, fileName), os.O_APPEND|os.O_WRONLY, 0o600)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"prompt":"cut`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	entries, err = New(dir, t.TempDir(), "/src/api").Project()
+	require.NoError(t, err)
+	require.Len(t, entries, maxEntries)
+}
@@ -32,4 +32,7 @@ type UpdateAvailableMsg struct {
 	CurrentVersion string
 	LatestVersion  string
 	IsDevelopment  bool
+	// Notes are the release notes of the latest version, in Markdown.
+	Notes string
+	URL   string
 }
@@ -0,0 +1,164 @@
+// Package ratelimit queues provider requests so they stay within the
+// configured concurrency and requests-per-minute limits.
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/pubsub"
+)
+
+// Event is published whenever the number of queued requests for a provider
+// changes.
+type Event struct {
+	Provider string
+	Queued   int
+}
+
+var (
+	limiters = csync.NewMap[string, *Limiter]()
+	broker   = pubsub.NewBroker[Event]()
+)
+
+// SubscribeEvents returns a channel for queue events.
+func SubscribeEvents(ctx context.Context) <-chan pubsub.Event[Event] {
+	return broker.Subscribe(ctx)
+}
+
+// For returns the shared limiter for a provider, so every agent and session
+// talking to it counts against the same limits. It returns nil when both
+// limits are zero. Changing the limits replaces the limiter.
+func For(provider string, maxConcurrency, requestsPerMinute int) *Limiter {
+	if maxConcurrency <= 0 && requestsPerMinute <= 0 {
+		limiters.Del(provider)
+		return nil
+	}
+	if l, ok := limiters.Get(provider); ok && l.maxConcurrency == maxConcurrency && l.rpm == requestsPerMinute {
+		return l
+	}
+	l := New(provider, maxConcurrency, requestsPerMinute)
+	limiters.Set(provider, l)
+	return l
+}
+
+// Limiter limits the number of in-flight requests and the number of
+// requests started per minute. A zero limit means unlimited.
+type Limiter struct {
+	provider       string
+	maxConcurrency int
+	rpm            int
+	now            func() time.Time
+
+	mu       sync.Mutex
+	inFlight int
+	started  []time.Time
+	queued   int
+	wake     chan struct{}
+}
+
+// New creates a limiter for the given provider.
+func New(provider string, maxConcurrency, requestsPerMinute int) *Limiter {
+	return &Limiter{
+		provider:       provider,
+		maxConcurrency: maxConcurrency,
+		rpm:            requestsPerMinute,
+		now:            time.Now,
+		wake:           make(chan struct{}),
+	}
+}
+
+// Acquire blocks until a request may be sent, returning a function that
+// must be called once the request has finished. A nil *Limiter never
+// blocks.
+func (l *Limiter) Acquire(ctx context.Context) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+
+	l.mu.Lock()
+	waiting := false
+	defer func() {
+		if waiting {
+			l.setQueued(l.queued - 1)
+		}
+		l.mu.Unlock()
+	}()
+
+	for {
+		wait, ok := l.tryAcquire()
+		if ok {
+			var once sync.Once
+			return func() { once.Do(l.release) }, nil
+		}
+		if !waiting {
+			waiting = true
+			l.setQueued(l.queued + 1)
+		}
+
+		wake := l.wake
+		l.mu.Unlock()
+		var timer <-chan time.Time
+		if wait > 0 {
+			timer = time.After(wait)
+		}
+		select {
+		case <-ctx.Done():
+			l.mu.Lock()
+			return nil, ctx.Err()
+		case <-wake:
+		case <-timer:
+		}
+		l.mu.Lock()
+	}
+}
+
+// Queued returns the number of requests waiting for a slot.
+func (l *Limiter) Queued() int {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.queued
+}
+
+// tryAcquire takes a slot if one is free. Otherwise it returns how long to
+// wait before the per-minute window frees up, or zero to wait for a release.
+// It must be called with l.mu held.
+func (l *Limiter) tryAcquire() (time.Duration, bool) {
+	if l.maxConcurrency > 0 && l.inFlight >= l.maxConcurrency {
+		return 0, false
+	}
+	now := l.now()
+	if l.rpm > 0 {
+		cutoff := now.Add(-time.Minute)
+		i := 0
+		for i < len(l.started) && !l.started[i].After(cutoff) {
+			i++
+		}
+		l.started = l.started[i:]
+		if len(l.started) >= l.rpm {
+			return l.started[0].Sub(cutoff), false
+		}
+		l.started = append(l.started, now)
+	}
+	l.inFlight++
+	return 0, true
+}
+
+func (l *Limiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.inFlight--
+	close(l.wake)
+	l.wake = make(chan struct{})
+}
+
+// setQueued must be called with l.mu held.
+func (l *Limiter) setQueued(n int) {
+	l.queued = n
+	broker.Publish(pubsub.UpdatedEvent, Event{Provider: l.provider, Queued: n})
+}
@@ -0,0 +1,77 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiterConcurrency(t *testing.T) {
+	t.Parallel()
+
+	l := New("test-concurrency", 1, 0)
+	release, err := l.Acquire(t.Context())
+	require.NoError(t, err)
+
+	acquired := make(chan struct{})
+	go func() {
+		release, err := l.Acquire(t.Context())
+		if err == nil {
+			release()
+		}
+		close(acquired)
+	}()
+
+	require.Eventually(t, func() bool { return l.Queued() == 1 }, time.Second, time.Millisecond)
+	release()
+	release() // Releasing twice is harmless.
+
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("queued request was not released")
+	}
+	require.Equal(t, 0, l.Queued())
+}
+
+func TestLimiterRequestsPerMinute(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	l := New("test-rpm", 0, 2)
+	l.now = func() time.Time { return now }
+
+	for range 2 {
+		release, err := l.Acquire(t.Context())
+		require.NoError(t, err)
+		release()
+	}
+
+	ctx, cancel := context.WithTimeout(t.Context(), 20*time.Millisecond)
+	defer cancel()
+	_, err := l.Acquire(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Equal(t, 0, l.Queued())
+
+	now = now.Add(time.Minute + time.Second)
+	release, err := l.Acquire(t.Context())
+	require.NoError(t, err)
+	release()
+}
+
+func TestFor(t *testing.T) {
+	t.Parallel()
+
+	require.Nil(t, For("test-for", 0, 0))
+	l := For("test-for", 2, 0)
+	require.NotNil(t, l)
+	require.Same(t, l, For("test-for", 2, 0))
+	require.NotSame(t, l, For("test-for", 3, 0))
+
+	var nilLimiter *Limiter
+	release, err := nilLimiter.Acquire(t.Context())
+	require.NoError(t, err)
+	release()
+}
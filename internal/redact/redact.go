@@ -0,0 +1,161 @@
+// Package redact masks likely secrets in text before it leaves the machine.
+//
+// Detection combines gitleaks-style rules for well-known credential formats
+// with an entropy heuristic for generic "key = value" assignments, which keeps
+// false positives such as placeholder values and short words out.
+package redact
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Rule describes a single kind of secret.
+type Rule struct {
+	ID      string
+	Pattern *regexp.Regexp
+	// SecretGroup is the capture group holding the secret. Zero masks the
+	// whole match.
+	SecretGroup int
+	// MinEntropy is the minimum Shannon entropy, in bits per character, the
+	// secret must have to be redacted. Zero disables the check.
+	MinEntropy float64
+}
+
+var defaultRules = []Rule{
+	{ID: "private-key", Pattern: regexp.MustCompile(`-----BEGIN[ A-Z0-9_-]{0,100}PRIVATE KEY(?: BLOCK)?-----[\s\S]*?-----END[ A-Z0-9_-]{0,100}PRIVATE KEY(?: BLOCK)?-----`)},
+	{ID: "aws-access-key-id", Pattern: regexp.MustCompile(`\b((?:A3T[A-Z0-9]|AKIA|ASIA|ABIA|ACCA)[A-Z2-7]{16})\b`), SecretGroup: 1},
+	{ID: "github-token", Pattern: regexp.MustCompile(`\b((?:gh[pousr]_[0-9a-zA-Z]{36,255})|(?:github_pat_[0-9a-zA-Z_]{82}))\b`), SecretGroup: 1},
+	{ID: "gitlab-token", Pattern: regexp.MustCompile(`\b(glpat-[0-9a-zA-Z_-]{20})\b`), SecretGroup: 1},
+	{ID: "slack-token", Pattern: regexp.MustCompile(`\b(xox[baprs]-[0-9a-zA-Z-]{10,250})\b`), SecretGroup: 1},
+	{ID: "anthropic-api-key", Pattern: regexp.MustCompile(`\b(sk-ant-[0-9a-zA-Z_-]{20,})`), SecretGroup: 1},
+	{ID: "openai-api-key", Pattern: regexp.MustCompile(`\b(sk-(?:proj-|svcacct-|admin-)?[0-9a-zA-Z_-]{20,})`), SecretGroup: 1, MinEntropy: 3.5},
+	{ID: "google-api-key", Pattern: regexp.MustCompile(`\b(AIza[0-9A-Za-z_-]{35})\b`), SecretGroup: 1},
+	{ID: "stripe-key", Pattern: regexp.MustCompile(`\b((?:sk|rk)_(?:live|test)_[0-9a-zA-Z]{24,})\b`), SecretGroup: 1},
+	{ID: "jwt", Pattern: regexp.MustCompile(`\b(eyJ[0-9A-Za-z_-]{10,}\.eyJ[0-9A-Za-z_-]{10,}\.[0-9A-Za-z_-]{10,})`), SecretGroup: 1},
+	{
+		ID:          "generic-secret",
+		Pattern:     regexp.MustCompile(`(?i)(?:api[_-]?key|secret|token|passw(?:or)?d|credential|auth)[a-z0-9_.-]*["']?\s*[:=]\s*["']?([^\s"'` + "`" + `,;]{12,})`),
+		SecretGroup: 1,
+		MinEntropy:  3.5,
+	},
+}
+
+// Redactor masks secrets in text.
+type Redactor struct {
+	rules []Rule
+	allow []*regexp.Regexp
+}
+
+// New creates a Redactor using the built-in rules plus the given extra
+// patterns. Values matching any of the allowlist patterns are never masked.
+func New(patterns, allowlist []string) (*Redactor, error) {
+	r := &Redactor{rules: slices.Clone(defaultRules)}
+	for i, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", p, err)
+		}
+		r.rules = append(r.rules, Rule{ID: fmt.Sprintf("custom-%d", i+1), Pattern: re})
+	}
+	for _, p := range allowlist {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowlist pattern %q: %w", p, err)
+		}
+		r.allow = append(r.allow, re)
+	}
+	return r, nil
+}
+
+// Redact returns s with likely secrets replaced by a placeholder naming the
+// rule that matched, along with the number of secrets that were masked. It
+// is safe to call on a nil Redactor.
+func (r *Redactor) Redact(s string) (string, int) {
+	if r == nil || s == "" {
+		return s, 0
+	}
+	var total int
+	for _, rule := range r.rules {
+		var n int
+		s, n = r.apply(rule, s)
+		total += n
+	}
+	return s, total
+}
+
+func (r *Redactor) apply(rule Rule, s string) (string, int) {
+	matches := rule.Pattern.FindAllStringSubmatchIndex(s, -1)
+	if len(matches) == 0 {
+		return s, 0
+	}
+	var sb strings.Builder
+	var last, count int
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		if g := rule.SecretGroup; g > 0 && 2*g+1 < len(m) && m[2*g] >= 0 {
+			start, end = m[2*g], m[2*g+1]
+		}
+		secret := s[start:end]
+		if strings.HasPrefix(secret, "[REDACTED:") || r.allowed(secret) {
+			continue
+		}
+		if rule.MinEntropy > 0 && Entropy(secret) < rule.MinEntropy {
+			continue
+		}
+		sb.WriteString(s[last:start])
+		sb.WriteString(Placeholder(rule.ID))
+		last = end
+		count++
+	}
+	if count == 0 {
+		return s, 0
+	}
+	sb.WriteString(s[last:])
+	return sb.String(), count
+}
+
+func (r *Redactor) allowed(secret string) bool {
+	for _, re := range r.allow {
+		if re.MatchString(secret) {
+			return true
+		}
+	}
+	return false
+}
+
+// Placeholder is the text that replaces a secret found by the given rule.
+func Placeholder(ruleID string) string {
+	return "[REDACTED:" + ruleID + "]"
+}
+
+// Note describes a redaction so both the user and the model know that
+// content was masked.
+func Note(count int) string {
+	if count == 1 {
+		return "[1 likely secret was redacted before sending this content to the provider]"
+	}
+	return fmt.Sprintf("[%d likely secrets were redacted before sending this content to the provider]", count)
+}
+
+// Entropy returns the Shannon entropy of s in bits per character.
+func Entropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	freq := make(map[rune]int)
+	var n int
+	for _, c := range s {
+		freq[c]++
+		n++
+	}
+	var h float64
+	for _, count := range freq {
+		p := float64(count) / float64(n)
+		h -= p * math.Log2(p)
+	}
+	return h
+}
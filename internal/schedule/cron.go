@@ -0,0 +1,157 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cron is a parsed cron expression with the usual five fields: minute,
+// hour, day of month, month and day of week.
+type Cron struct {
+	minute, hour, dom, month, dow uint64
+	// Like cron, when both the day of month and the day of week are
+	// restricted, a day matching either of them matches.
+	domAny, dowAny bool
+}
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var cronNames = map[string]string{
+	"jan": "1", "feb": "2", "mar": "3", "apr": "4", "may": "5", "jun": "6",
+	"jul": "7", "aug": "8", "sep": "9", "oct": "10", "nov": "11", "dec": "12",
+	"sun": "0", "mon": "1", "tue": "2", "wed": "3", "thu": "4", "fri": "5", "sat": "6",
+}
+
+// ParseCron parses a cron expression. Besides numbers, fields accept *,
+// lists, ranges, steps, and the English abbreviations of months and days.
+func ParseCron(expr string) (Cron, error) {
+	expr = strings.TrimSpace(expr)
+	if macro, ok := cronMacros[strings.ToLower(expr)]; ok {
+		expr = macro
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return Cron{}, fmt.Errorf("cron expression %q: expected 5 fields, got %d", expr, len(fields))
+	}
+	var c Cron
+	var err error
+	if c.minute, err = parseCronField(fields[0], 0, 59); err != nil {
+		return Cron{}, fmt.Errorf("cron expression %q: minute: %w", expr, err)
+	}
+	if c.hour, err = parseCronField(fields[1], 0, 23); err != nil {
+		return Cron{}, fmt.Errorf("cron expression %q: hour: %w", expr, err)
+	}
+	if c.dom, err = parseCronField(fields[2], 1, 31); err != nil {
+		return Cron{}, fmt.Errorf("cron expression %q: day of month: %w", expr, err)
+	}
+	if c.month, err = parseCronField(fields[3], 1, 12); err != nil {
+		return Cron{}, fmt.Errorf("cron expression %q: month: %w", expr, err)
+	}
+	if c.dow, err = parseCronField(fields[4], 0, 7); err != nil {
+		return Cron{}, fmt.Errorf("cron expression %q: day of week: %w", expr, err)
+	}
+	// 7 is Sunday too.
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	c.domAny = strings.HasPrefix(fields[2], "*")
+	c.dowAny = strings.HasPrefix(fields[4], "*")
+	return c, nil
+}
+
+func parseCronField(field string, lo, hi int) (uint64, error) {
+	var bits uint64
+	for part := range strings.SplitSeq(strings.ToLower(field), ",") {
+		rng, step := part, 1
+		if r, s, ok := strings.Cut(part, "/"); ok {
+			n, err := strconv.Atoi(s)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q", s)
+			}
+			rng, step = r, n
+		}
+		start, end := lo, hi
+		if rng != "*" {
+			from, to, isRange := strings.Cut(rng, "-")
+			var err error
+			if start, err = cronValue(from, lo, hi); err != nil {
+				return 0, err
+			}
+			end = start
+			if isRange {
+				if end, err = cronValue(to, lo, hi); err != nil {
+					return 0, err
+				}
+			} else if step > 1 {
+				// 5/15 means from 5 to the end, every 15.
+				end = hi
+			}
+			if end < start {
+				return 0, fmt.Errorf("invalid range %q", rng)
+			}
+		}
+		for v := start; v <= end; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+func cronValue(s string, lo, hi int) (int, error) {
+	if name, ok := cronNames[s]; ok {
+		s = name
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < lo || n > hi {
+		return 0, fmt.Errorf("invalid value %q: expected %d to %d", s, lo, hi)
+	}
+	return n, nil
+}
+
+// Next returns the first time strictly after t the expression matches, to
+// the minute, in the location of t. It returns the zero time when there is
+// none within five years, like on the 30th of February.
+func (c Cron) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case c.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !c.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case c.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case c.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func (c Cron) matchesDay(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	switch {
+	case c.domAny && c.dowAny:
+		return true
+	case c.domAny:
+		return dow
+	case c.dowAny:
+		return dom
+	default:
+		return dom || dow
+	}
+}
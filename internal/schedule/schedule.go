@@ -0,0 +1,215 @@
+// Package schedule runs the prompts of the schedules of the config at their
+// times, and delivers the reports the agent writes to files or webhooks.
+package schedule
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+)
+
+// webhookTimeout bounds posting a report to a webhook.
+const webhookTimeout = 30 * time.Second
+
+// PromptFunc runs a prompt against the workspace and returns the report of
+// the agent.
+type PromptFunc func(ctx context.Context, prompt string) (string, error)
+
+// Job is a schedule of the config ready to run.
+type Job struct {
+	Name     string
+	Schedule config.Schedule
+	cron     Cron
+}
+
+// Jobs returns the enabled schedules of the config, sorted by name.
+func Jobs(schedules map[string]config.Schedule) ([]Job, error) {
+	var jobs []Job
+	for _, name := range slices.Sorted(maps.Keys(schedules)) {
+		s := schedules[name]
+		if s.Disabled {
+			continue
+		}
+		if strings.TrimSpace(s.Prompt) == "" {
+			return nil, fmt.Errorf("schedule %q: prompt is required", name)
+		}
+		if s.Output == "" && s.Webhook == "" {
+			return nil, fmt.Errorf("schedule %q: output or webhook is required", name)
+		}
+		c, err := ParseCron(s.Cron)
+		if err != nil {
+			return nil, fmt.Errorf("schedule %q: %w", name, err)
+		}
+		jobs = append(jobs, Job{Name: name, Schedule: s, cron: c})
+	}
+	return jobs, nil
+}
+
+// Next returns the next time the job runs after t.
+func (j Job) Next(t time.Time) time.Time {
+	return j.cron.Next(t)
+}
+
+// Run runs the jobs at their times until ctx is done. Jobs run one at a time,
+// so a job due while another runs starts once it's done.
+func Run(ctx context.Context, workingDir string, jobs []Job, prompt PromptFunc) error {
+	if len(jobs) == 0 {
+		return errors.New("no schedules configured")
+	}
+	next := make([]time.Time, len(jobs))
+	now := time.Now()
+	for i, j := range jobs {
+		next[i] = j.Next(now)
+		slog.Info("Scheduled prompt", "schedule", j.Name, "next", next[i])
+	}
+	for {
+		i := soonest(next)
+		if next[i].IsZero() {
+			return errors.New("no schedule has a next run")
+		}
+		timer := time.NewTimer(time.Until(next[i]))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+		if err := RunJob(ctx, workingDir, jobs[i], next[i], prompt); err != nil {
+			slog.Error("Scheduled prompt failed", "schedule", jobs[i].Name, "error", err)
+		}
+		// Runs missed while the job ran are skipped.
+		next[i] = jobs[i].Next(time.Now())
+	}
+}
+
+// soonest returns the index of the earliest time, zero times last.
+func soonest(times []time.Time) int {
+	best := 0
+	for i, t := range times {
+		if !t.IsZero() && (times[best].IsZero() || t.Before(times[best])) {
+			best = i
+		}
+	}
+	return best
+}
+
+// templateData is what the templates of the prompt and the output of a
+// schedule can refer to.
+type templateData struct {
+	Name      string
+	Date      string
+	Yesterday string
+	Now       time.Time
+}
+
+func newTemplateData(name string, at time.Time) templateData {
+	return templateData{
+		Name:      name,
+		Date:      at.Format(time.DateOnly),
+		Yesterday: at.AddDate(0, 0, -1).Format(time.DateOnly),
+		Now:       at,
+	}
+}
+
+func render(name, text string, data templateData) (string, error) {
+	t, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// RunJob runs the prompt of a job as of time at, and delivers the report.
+func RunJob(ctx context.Context, workingDir string, j Job, at time.Time, prompt PromptFunc) error {
+	data := newTemplateData(j.Name, at)
+	text, err := render(j.Name, j.Schedule.Prompt, data)
+	if err != nil {
+		return fmt.Errorf("rendering prompt: %w", err)
+	}
+	slog.Info("Running scheduled prompt", "schedule", j.Name)
+	report, err := prompt(ctx, text)
+	if err != nil {
+		return fmt.Errorf("running prompt: %w", err)
+	}
+
+	var errs []error
+	if j.Schedule.Output != "" {
+		errs = append(errs, writeReport(workingDir, j, data, report))
+	}
+	if j.Schedule.Webhook != "" {
+		errs = append(errs, postReport(ctx, j, data, text, report))
+	}
+	return errors.Join(errs...)
+}
+
+func writeReport(workingDir string, j Job, data templateData, report string) error {
+	path, err := render(j.Name+" output", j.Schedule.Output, data)
+	if err != nil {
+		return fmt.Errorf("rendering output path: %w", err)
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(workingDir, path)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("writing report: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(report), 0o644); err != nil {
+		return fmt.Errorf("writing report: %w", err)
+	}
+	slog.Info("Wrote scheduled report", "schedule", j.Name, "path", path)
+	return nil
+}
+
+// webhookPayload is the JSON body posted to the webhook of a schedule.
+type webhookPayload struct {
+	Schedule string    `json:"schedule"`
+	Time     time.Time `json:"time"`
+	Prompt   string    `json:"prompt"`
+	Report   string    `json:"report"`
+}
+
+func postReport(ctx context.Context, j Job, data templateData, prompt, report string) error {
+	body, err := json.Marshal(webhookPayload{
+		Schedule: j.Name,
+		Time:     data.Now,
+		Prompt:   prompt,
+		Report:   report,
+	})
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, j.Schedule.Webhook, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("posting report: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("posting report: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("posting report: webhook responded %s", resp.Status)
+	}
+	slog.Info("Posted scheduled report", "schedule", j.Name)
+	return nil
+}
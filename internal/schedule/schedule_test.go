@@ -0,0 +1,114 @@
+package schedule
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCronNext(t *testing.T) {
+	t.Parallel()
+
+	at := func(s string) time.Time {
+		tm, err := time.ParseInLocation(time.DateTime, s, time.UTC)
+		require.NoError(t, err)
+		return tm
+	}
+
+	tests := []struct {
+		expr string
+		from string
+		want string
+	}{
+		{"@daily", "2026-03-04 10:30:00", "2026-03-05 00:00:00"},
+		{"0 6 * * *", "2026-03-04 05:59:59", "2026-03-04 06:00:00"},
+		{"0 6 * * *", "2026-03-04 06:00:00", "2026-03-05 06:00:00"},
+		{"*/15 * * * *", "2026-03-04 10:31:00", "2026-03-04 10:45:00"},
+		{"30 9 * * mon-fri", "2026-03-06 10:00:00", "2026-03-09 09:30:00"},
+		{"0 0 1 jan *", "2026-03-04 00:00:00", "2027-01-01 00:00:00"},
+		{"0 12 * * 7", "2026-03-04 00:00:00", "2026-03-08 12:00:00"},
+		// Either the day of month or the day of week.
+		{"0 0 13 * fri", "2026-03-01 00:00:00", "2026-03-06 00:00:00"},
+		{"0 0 29 2 *", "2026-03-01 00:00:00", "2028-02-29 00:00:00"},
+	}
+	for _, tt := range tests {
+		c, err := ParseCron(tt.expr)
+		require.NoError(t, err, tt.expr)
+		require.Equal(t, at(tt.want), c.Next(at(tt.from)), tt.expr)
+	}
+
+	c, err := ParseCron("0 0 30 2 *")
+	require.NoError(t, err)
+	require.True(t, c.Next(at("2026-01-01 00:00:00")).IsZero())
+}
+
+func TestParseCronErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "0 0 0 * *", "5-1 * * * *", "*/0 * * * *", "@sometimes"} {
+		_, err := ParseCron(expr)
+		require.Error(t, err, expr)
+	}
+}
+
+func TestJobs(t *testing.T) {
+	t.Parallel()
+
+	jobs, err := Jobs(map[string]config.Schedule{
+		"b":   {Cron: "@daily", Prompt: "p", Output: "out.md"},
+		"a":   {Cron: "@hourly", Prompt: "p", Webhook: "https://example.com"},
+		"off": {Disabled: true},
+	})
+	require.NoError(t, err)
+	require.Len(t, jobs, 2)
+	require.Equal(t, "a", jobs[0].Name)
+
+	_, err = Jobs(map[string]config.Schedule{"x": {Cron: "@daily", Prompt: "p"}})
+	require.ErrorContains(t, err, "output or webhook is required")
+}
+
+func TestRunJob(t *testing.T) {
+	t.Parallel()
+
+	var posted webhookPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&posted))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	jobs, err := Jobs(map[string]config.Schedule{
+		"nightly": {
+			Cron:    "@daily",
+			Prompt:  "Summarize the commits of {{.Yesterday}}",
+			Output:  "reports/{{.Name}}-{{.Date}}.md",
+			Webhook: server.URL,
+		},
+	})
+	require.NoError(t, err)
+
+	var prompted string
+	at := time.Date(2026, 3, 1, 6, 0, 0, 0, time.UTC)
+	err = RunJob(t.Context(), dir, jobs[0], at, func(_ context.Context, prompt string) (string, error) {
+		prompted = prompt
+		return "3 commits", nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "Summarize the commits of 2026-02-28", prompted)
+
+	report, err := os.ReadFile(filepath.Join(dir, "reports", "nightly-2026-03-01.md"))
+	require.NoError(t, err)
+	require.Equal(t, "3 commits", string(report))
+
+	require.Equal(t, "nightly", posted.Schedule)
+	require.Equal(t, prompted, posted.Prompt)
+	require.Equal(t, "3 commits", posted.Report)
+}
@@ -0,0 +1,142 @@
+// Package screenshot captures the screen, or a window picked by the user,
+// with the screenshot utility of the platform.
+package screenshot
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Target is what a screenshot captures.
+type Target string
+
+const (
+	// Screen captures the whole screen.
+	Screen Target = "screen"
+	// Window captures a window picked by the user, or an area of the screen
+	// where windows can't be picked.
+	Window Target = "window"
+)
+
+// ErrNoUtility is returned when the platform has none of the screenshot
+// utilities supported.
+var ErrNoUtility = errors.New("no screenshot utility found")
+
+// windowsScript captures the screens on Windows, which has no screenshot
+// utility taking a path.
+const windowsScript = `Add-Type -AssemblyName System.Windows.Forms,System.Drawing
+$b = [System.Windows.Forms.SystemInformation]::VirtualScreen
+$bmp = New-Object System.Drawing.Bitmap $b.Width, $b.Height
+$g = [System.Drawing.Graphics]::FromImage($bmp)
+$g.CopyFromScreen($b.Left, $b.Top, 0, 0, $bmp.Size)
+$bmp.Save($env:CRUSH_SCREENSHOT, [System.Drawing.Imaging.ImageFormat]::Png)`
+
+// Commands returns the commands capturing the target to path on the
+// platform, the preferred ones first. Wayland sessions need utilities of
+// their own.
+func Commands(goos string, wayland bool, target Target, path string) [][]string {
+	window := target == Window
+	switch goos {
+	case "darwin":
+		if window {
+			return [][]string{{"screencapture", "-x", "-i", "-w", path}}
+		}
+		return [][]string{{"screencapture", "-x", path}}
+	case "windows":
+		if window {
+			return nil
+		}
+		return [][]string{{"powershell", "-NoProfile", "-NonInteractive", "-Command", windowsScript}}
+	}
+	var commands [][]string
+	if wayland {
+		if window {
+			// grim takes the area picked with slurp.
+			commands = append(commands, []string{"sh", "-c", `grim -g "$(slurp)" "$CRUSH_SCREENSHOT"`})
+		} else {
+			commands = append(commands, []string{"grim", path})
+		}
+	}
+	if window {
+		commands = append(commands,
+			[]string{"gnome-screenshot", "-w", "-f", path},
+			[]string{"spectacle", "-b", "-n", "-a", "-o", path},
+			[]string{"maim", "-s", path},
+			[]string{"scrot", "-s", "-o", path},
+			[]string{"import", path},
+		)
+	} else {
+		commands = append(commands,
+			[]string{"gnome-screenshot", "-f", path},
+			[]string{"spectacle", "-b", "-n", "-f", "-o", path},
+			[]string{"maim", path},
+			[]string{"scrot", "-o", path},
+			[]string{"import", "-window", "root", path},
+		)
+	}
+	return commands
+}
+
+// Capture captures the target with the first screenshot utility found, and
+// returns it as a PNG. Picking a window waits for the user.
+func Capture(ctx context.Context, target Target) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "crush-screenshot-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "screenshot.png")
+
+	wayland := os.Getenv("WAYLAND_DISPLAY") != ""
+	for _, args := range Commands(runtime.GOOS, wayland, target, path) {
+		utilities := requires(args)
+		if !installed(utilities) {
+			continue
+		}
+		name := utilities[0]
+		cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+		cmd.Env = append(os.Environ(), "CRUSH_SCREENSHOT="+path)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				return nil, fmt.Errorf("%s: %s: %w", name, msg, err)
+			}
+			return nil, fmt.Errorf("failed to run %s: %w", name, err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil || len(data) == 0 {
+			// Cancelling the pick of a window leaves no file.
+			return nil, fmt.Errorf("%s took no screenshot", name)
+		}
+		return data, nil
+	}
+	if target == Window && runtime.GOOS == "windows" {
+		return nil, errors.New("capturing a window isn't supported on Windows")
+	}
+	return nil, ErrNoUtility
+}
+
+// requires returns the utilities a command runs.
+func requires(args []string) []string {
+	if args[0] == "sh" {
+		return []string{"grim", "slurp"}
+	}
+	return args[:1]
+}
+
+func installed(utilities []string) bool {
+	for _, name := range utilities {
+		if _, err := exec.LookPath(name); err != nil {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,22 @@
+package screenshot
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommands(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, [][]string{{"screencapture", "-x", "/tmp/s.png"}}, Commands("darwin", false, Screen, "/tmp/s.png"))
+	require.Equal(t, [][]string{{"screencapture", "-x", "-i", "-w", "/tmp/s.png"}}, Commands("darwin", false, Window, "/tmp/s.png"))
+	require.Empty(t, Commands("windows", false, Window, `C:\s.png`))
+
+	// Wayland utilities come before the X11 ones.
+	commands := Commands("linux", true, Screen, "/tmp/s.png")
+	require.Equal(t, []string{"grim", "/tmp/s.png"}, commands[0])
+	require.Contains(t, commands, []string{"import", "-window", "root", "/tmp/s.png"})
+	commands = Commands("linux", false, Window, "/tmp/s.png")
+	require.Equal(t, []string{"gnome-screenshot", "-w", "-f", "/tmp/s.png"}, commands[0])
+}
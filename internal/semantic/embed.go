@@ -0,0 +1,100 @@
+package semantic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Embedder turns texts into embedding vectors.
+type Embedder interface {
+	// Model returns the name of the model the vectors come from. Vectors
+	// of different models can't be compared.
+	Model() string
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// OpenAIEmbedder calls an OpenAI-compatible embeddings API, which OpenAI
+// and most local servers, like Ollama and LM Studio, provide.
+type OpenAIEmbedder struct {
+	baseURL string
+	apiKey  string
+	model   string
+	headers map[string]string
+	client  *http.Client
+}
+
+// NewOpenAIEmbedder returns an embedder using the model of the API at the
+// base URL, like https://api.openai.com/v1.
+func NewOpenAIEmbedder(baseURL, apiKey, model string, headers map[string]string) *OpenAIEmbedder {
+	return &OpenAIEmbedder{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		apiKey:  apiKey,
+		model:   model,
+		headers: headers,
+		client:  &http.Client{Timeout: 2 * time.Minute},
+	}
+}
+
+func (e *OpenAIEmbedder) Model() string {
+	return e.model
+}
+
+func (e *OpenAIEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	body, err := json.Marshal(map[string]any{
+		"model": e.model,
+		"input": texts,
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.baseURL+"/embeddings", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.apiKey)
+	}
+	for name, value := range e.headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("embeddings request failed: %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+
+	var result struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("invalid embeddings response: %w", err)
+	}
+	vectors := make([][]float32, len(texts))
+	for _, d := range result.Data {
+		if d.Index < 0 || d.Index >= len(texts) {
+			return nil, fmt.Errorf("invalid embeddings response: index %d out of range", d.Index)
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	for i, v := range vectors {
+		if len(v) == 0 {
+			return nil, fmt.Errorf("invalid embeddings response: no embedding for input %d", i)
+		}
+	}
+	return vectors, nil
+}
@@ -0,0 +1,451 @@
+// Package semantic indexes the workspace with embeddings to search code by
+// what it does rather than by the words it uses. Results are ranked by both
+// the similarity of their embeddings and the keywords they contain, as
+// either alone misses what the other finds.
+package semantic
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/crush/internal/fsext"
+)
+
+const (
+	// indexVersion changes when the format of the stored index or the
+	// chunking does, to rebuild indexes made by older versions.
+	indexVersion = 1
+
+	maxFiles    = 20000
+	maxFileSize = 256 * 1024
+
+	// Files are embedded in overlapping chunks of lines, so that a chunk
+	// is about one function or section.
+	chunkLines   = 60
+	chunkOverlap = 10
+
+	// embedBatchSize is the number of chunks embedded per request.
+	embedBatchSize = 64
+
+	// rrfK dampens the weight of the first ranks in reciprocal rank fusion.
+	rrfK = 60
+)
+
+// skippedFiles are text files with little meaning to search for, which would
+// only crowd results and cost embeddings.
+var skippedFiles = []string{"go.sum", "package-lock.json", "yarn.lock", "pnpm-lock.yaml", "Cargo.lock", "poetry.lock", "composer.lock", "Gemfile.lock"}
+
+// Chunk is a range of lines of a file of the workspace.
+type Chunk struct {
+	// Path is relative to the workspace, with forward slashes.
+	Path      string
+	StartLine int
+	EndLine   int
+	Text      string
+	Vector    []float32
+}
+
+// Result is a chunk matching a search.
+type Result struct {
+	Chunk
+	Score float64
+}
+
+type file struct {
+	Hash   string
+	Chunks []Chunk
+}
+
+// stored is what's saved to the data directory.
+type stored struct {
+	Version int
+	Model   string
+	Files   map[string]file
+}
+
+// Index is the embeddings index of a workspace, saved to a file so that only
+// the files that changed are embedded again.
+type Index struct {
+	root     string
+	path     string
+	embedder Embedder
+	// skip reports whether a file mustn't be sent to the embedder.
+	skip func(path string) bool
+
+	update sync.Mutex
+
+	mu    sync.RWMutex
+	files map[string]file
+}
+
+// NewIndex returns the index of the workspace at root, loaded from the file
+// at path if it's there and was made with the same model.
+func NewIndex(root, path string, embedder Embedder, skip func(path string) bool) *Index {
+	x := &Index{
+		root:     root,
+		path:     path,
+		embedder: embedder,
+		skip:     skip,
+		files:    make(map[string]file),
+	}
+	if err := x.load(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		slog.Warn("Discarding the semantic search index", "path", path, "error", err)
+	}
+	return x
+}
+
+func (x *Index) load() error {
+	data, err := os.ReadFile(x.path)
+	if err != nil {
+		return err
+	}
+	var s stored
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&s); err != nil {
+		return err
+	}
+	if s.Version != indexVersion || s.Model != x.embedder.Model() {
+		return nil
+	}
+	x.files = s.Files
+	return nil
+}
+
+func (x *Index) save() error {
+	x.mu.RLock()
+	s := stored{Version: indexVersion, Model: x.embedder.Model(), Files: x.files}
+	var b bytes.Buffer
+	err := gob.NewEncoder(&b).Encode(s)
+	x.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(x.path), 0o700); err != nil {
+		return err
+	}
+	tmp := x.path + ".tmp"
+	if err := os.WriteFile(tmp, b.Bytes(), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, x.path)
+}
+
+// Update embeds the files added or changed since the last update, forgets
+// the deleted ones and saves the index. What was embedded before an error
+// is kept.
+func (x *Index) Update(ctx context.Context) error {
+	x.update.Lock()
+	defer x.update.Unlock()
+
+	paths, _, err := fsext.ListDirectory(x.root, nil, 0, maxFiles)
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool, len(paths))
+	var pending []file
+	for _, path := range paths {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if strings.HasSuffix(path, string(filepath.Separator)) || slices.Contains(skippedFiles, filepath.Base(path)) {
+			continue
+		}
+		if x.skip != nil && x.skip(path) {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil || info.Size() == 0 || info.Size() > maxFileSize {
+			continue
+		}
+		rel, err := filepath.Rel(x.root, path)
+		if err != nil {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+
+		content, err := os.ReadFile(path)
+		if err != nil || !isText(content) {
+			continue
+		}
+		seen[rel] = true
+		sum := sha256.Sum256(content)
+		hash := hex.EncodeToString(sum[:])
+		x.mu.RLock()
+		cached, ok := x.files[rel]
+		x.mu.RUnlock()
+		if ok && cached.Hash == hash {
+			continue
+		}
+		pending = append(pending, file{Hash: hash, Chunks: chunk(rel, string(content))})
+	}
+
+	x.mu.Lock()
+	for rel := range x.files {
+		if !seen[rel] {
+			delete(x.files, rel)
+		}
+	}
+	x.mu.Unlock()
+
+	err = x.embed(ctx, pending)
+	if saveErr := x.save(); saveErr != nil {
+		return errors.Join(err, fmt.Errorf("could not save the index: %w", saveErr))
+	}
+	return err
+}
+
+// embed embeds the chunks of the files in batches, and adds each file to the
+// index once all its chunks are.
+func (x *Index) embed(ctx context.Context, files []file) error {
+	var batch []*Chunk
+	var ready []file
+	flush := func() error {
+		if len(batch) > 0 {
+			texts := make([]string, len(batch))
+			for i, c := range batch {
+				// The path tells a lot about what the code is about.
+				texts[i] = c.Path + "\n" + c.Text
+			}
+			vectors, err := x.embedder.Embed(ctx, texts)
+			if err != nil {
+				return err
+			}
+			for i, c := range batch {
+				c.Vector = normalize(vectors[i])
+			}
+			batch = batch[:0]
+		}
+		x.mu.Lock()
+		for _, f := range ready {
+			x.files[f.Chunks[0].Path] = f
+		}
+		x.mu.Unlock()
+		ready = ready[:0]
+		return nil
+	}
+	for _, f := range files {
+		if len(f.Chunks) == 0 {
+			continue
+		}
+		if len(batch)+len(f.Chunks) > embedBatchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+		for i := range f.Chunks {
+			batch = append(batch, &f.Chunks[i])
+		}
+		ready = append(ready, f)
+	}
+	return flush()
+}
+
+// Len returns the number of chunks in the index.
+func (x *Index) Len() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	n := 0
+	for _, f := range x.files {
+		n += len(f.Chunks)
+	}
+	return n
+}
+
+// Search returns at most limit chunks matching the query, ranked by the
+// similarity of their embeddings and by the keywords of the query they
+// contain. Chunks overlapping a better result are left out.
+func (x *Index) Search(ctx context.Context, query string, limit int) ([]Result, error) {
+	vectors, err := x.embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, err
+	}
+	queryVector := normalize(vectors[0])
+
+	x.mu.RLock()
+	var chunks []*Chunk
+	for _, f := range x.files {
+		for i := range f.Chunks {
+			chunks = append(chunks, &f.Chunks[i])
+		}
+	}
+	x.mu.RUnlock()
+
+	similarity := make([]float64, len(chunks))
+	for i, c := range chunks {
+		similarity[i] = dot(queryVector, c.Vector)
+	}
+	keywords := keywordScores(query, chunks)
+
+	scores := make([]float64, len(chunks))
+	for _, ranking := range [][]float64{similarity, keywords} {
+		for rank, i := range rank(ranking) {
+			scores[i] += 1 / float64(rrfK+rank+1)
+		}
+	}
+
+	order := rank(scores)
+	var results []Result
+	for _, i := range order {
+		if len(results) == limit {
+			break
+		}
+		c := chunks[i]
+		if slices.ContainsFunc(results, func(r Result) bool {
+			return r.Path == c.Path && r.StartLine <= c.EndLine && c.StartLine <= r.EndLine
+		}) {
+			continue
+		}
+		results = append(results, Result{Chunk: *c, Score: scores[i]})
+	}
+	return results, nil
+}
+
+// rank returns the indexes of the positive scores, best first.
+func rank(scores []float64) []int {
+	var order []int
+	for i, s := range scores {
+		if s > 0 {
+			order = append(order, i)
+		}
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return cmp.Compare(scores[b], scores[a])
+	})
+	return order
+}
+
+// keywordScores scores the chunks by the words of the query they contain,
+// weighting rare words more, like BM25 without the length normalization.
+func keywordScores(query string, chunks []*Chunk) []float64 {
+	scores := make([]float64, len(chunks))
+	words := tokenize(query)
+	if len(words) == 0 {
+		return scores
+	}
+	lower := make([]string, len(chunks))
+	for i, c := range chunks {
+		lower[i] = strings.ToLower(c.Path + "\n" + c.Text)
+	}
+	for _, word := range words {
+		df := 0
+		counts := make([]int, len(chunks))
+		for i, text := range lower {
+			counts[i] = strings.Count(text, word)
+			if counts[i] > 0 {
+				df++
+			}
+		}
+		if df == 0 {
+			continue
+		}
+		idf := math.Log(1 + (float64(len(chunks))-float64(df)+0.5)/(float64(df)+0.5))
+		for i, n := range counts {
+			if n > 0 {
+				scores[i] += idf * math.Log1p(float64(n))
+			}
+		}
+	}
+	return scores
+}
+
+// tokenize splits text into lowercase words, splitting camelCase and
+// snake_case identifiers too.
+func tokenize(text string) []string {
+	var words []string
+	var word []rune
+	flush := func() {
+		if len(word) > 1 {
+			w := strings.ToLower(string(word))
+			if !slices.Contains(words, w) {
+				words = append(words, w)
+			}
+		}
+		word = word[:0]
+	}
+	runes := []rune(text)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+		// A word starts at an upper case letter after a lower case one, or
+		// before a lower case one when it ends an acronym, like in HTTPClient.
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			if unicode.IsLower(prev) || unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+				flush()
+			}
+		}
+		word = append(word, r)
+	}
+	flush()
+	return words
+}
+
+// chunk splits the content of a file into overlapping chunks of lines.
+func chunk(path, content string) []Chunk {
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+	var chunks []Chunk
+	for start := 0; start < len(lines); start += chunkLines - chunkOverlap {
+		end := min(start+chunkLines, len(lines))
+		text := strings.Join(lines[start:end], "\n")
+		if strings.TrimSpace(text) != "" {
+			chunks = append(chunks, Chunk{
+				Path:      path,
+				StartLine: start + 1,
+				EndLine:   end,
+				Text:      text,
+			})
+		}
+		if end == len(lines) {
+			break
+		}
+	}
+	return chunks
+}
+
+func isText(content []byte) bool {
+	return bytes.IndexByte(content, 0) < 0 && utf8.Valid(content)
+}
+
+func normalize(v []float32) []float32 {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	if sum == 0 {
+		return v
+	}
+	norm := float32(math.Sqrt(sum))
+	out := make([]float32, len(v))
+	for i, x := range v {
+		out[i] = x / norm
+	}
+	return out
+}
+
+func dot(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var sum float64
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
@@ -0,0 +1,128 @@
+package semantic
+
+import (
+	"context"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeEmbedder embeds texts as bags of words with synonyms folded together,
+// which is enough to find code by words it doesn't contain.
+type fakeEmbedder struct {
+	model    string
+	synonyms map[string]string
+	embedded atomic.Int64
+}
+
+func (e *fakeEmbedder) Model() string {
+	return e.model
+}
+
+func (e *fakeEmbedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	e.embedded.Add(int64(len(texts)))
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		v := make([]float32, 64)
+		for _, word := range tokenize(text) {
+			if s, ok := e.synonyms[word]; ok {
+				word = s
+			}
+			h := fnv.New32a()
+			h.Write([]byte(word))
+			v[h.Sum32()%64]++
+		}
+		vectors[i] = v
+	}
+	return vectors, nil
+}
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+func TestIndex(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeFile(t, dir, "auth/session.go", "package auth\n\nfunc renewSession(s *Session) error {\n\treturn s.client.Renew()\n}\n")
+	writeFile(t, dir, "billing/invoice.go", "package billing\n\nfunc sendInvoice(i Invoice) error {\n\treturn mail(i)\n}\n")
+	writeFile(t, dir, "secrets/keys.go", "package secrets\n\nconst renewKey = \"renew\"\n")
+	writeFile(t, dir, "go.sum", "example.com/renew v1.0.0 h1:abc\n")
+
+	embedder := &fakeEmbedder{model: "fake", synonyms: map[string]string{"refresh": "renew", "token": "session"}}
+	path := filepath.Join(t.TempDir(), "semantic", "index.gob")
+	skip := func(path string) bool { return strings.Contains(path, "secrets") }
+	x := NewIndex(dir, path, embedder, skip)
+	require.NoError(t, x.Update(t.Context()))
+	require.Equal(t, 2, x.Len(), "lock files and skipped files aren't indexed")
+
+	results, err := x.Search(t.Context(), "refresh the auth token", 10)
+	require.NoError(t, err)
+	require.NotEmpty(t, results)
+	require.Equal(t, "auth/session.go", results[0].Path)
+	require.Equal(t, 1, results[0].StartLine)
+	require.Equal(t, 5, results[0].EndLine)
+
+	// Unchanged files aren't embedded again, even by a new index loaded
+	// from the saved one.
+	embedded := embedder.embedded.Load()
+	x = NewIndex(dir, path, embedder, skip)
+	require.Equal(t, 2, x.Len())
+	writeFile(t, dir, "billing/invoice.go", "package billing\n")
+	require.NoError(t, os.Remove(filepath.Join(dir, "auth", "session.go")))
+	require.NoError(t, x.Update(t.Context()))
+	require.Equal(t, embedded+1, embedder.embedded.Load())
+	require.Equal(t, 1, x.Len())
+
+	// Vectors of another model can't be compared, so its index is rebuilt.
+	x = NewIndex(dir, path, &fakeEmbedder{model: "other"}, skip)
+	require.Zero(t, x.Len())
+}
+
+func TestChunk(t *testing.T) {
+	t.Parallel()
+
+	var lines []string
+	for i := range 130 {
+		lines = append(lines, strings.Repeat("x", i%3+1))
+	}
+	chunks := chunk("a.go", strings.Join(lines, "\n")+"\n")
+	require.Len(t, chunks, 3)
+	require.Equal(t, [2]int{1, 60}, [2]int{chunks[0].StartLine, chunks[0].EndLine})
+	require.Equal(t, [2]int{51, 110}, [2]int{chunks[1].StartLine, chunks[1].EndLine})
+	require.Equal(t, [2]int{101, 130}, [2]int{chunks[2].StartLine, chunks[2].EndLine})
+	require.Equal(t, strings.Join(lines[50:110], "\n"), chunks[1].Text)
+
+	require.Empty(t, chunk("empty.go", "\n\n"))
+}
+
+func TestTokenize(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, []string{"refresh", "auth", "token", "user", "id"}, tokenize("refreshAuthToken(user_id, a)"))
+	require.Equal(t, []string{"http", "client"}, tokenize("HTTPClient http"))
+}
+
+func TestKeywordScores(t *testing.T) {
+	t.Parallel()
+
+	chunks := []*Chunk{
+		{Path: "a.go", Text: "func parse() {}"},
+		{Path: "b.go", Text: "func parseToken() { token := next() }"},
+		{Path: "c.go", Text: "func main() {}"},
+	}
+	scores := keywordScores("parse token", chunks)
+	require.Greater(t, scores[1], scores[0])
+	require.Positive(t, scores[0])
+	require.Zero(t, scores[2])
+	require.Equal(t, []int{1, 0}, rank(scores))
+}
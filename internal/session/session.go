@@ -4,13 +4,16 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"strconv"
 	"strings"
 
 	"github.com/charmbracelet/crush/internal/db"
 	"github.com/charmbracelet/crush/internal/event"
 	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/charmbracelet/crush/internal/stats"
 	"github.com/google/uuid"
 )
 
@@ -28,6 +31,9 @@ type Todo struct {
 	ActiveForm string     `json:"active_form"`
 }
 
+// ErrNoSessions is returned by Latest when there are no sessions yet.
+var ErrNoSessions = errors.New("no sessions found")
+
 type Session struct {
 	ID               string
 	ParentSessionID  string
@@ -37,9 +43,41 @@ type Session struct {
 	CompletionTokens int64
 	SummaryMessageID string
 	Cost             float64
-	Todos            []Todo
-	CreatedAt        int64
-	UpdatedAt        int64
+	// TotalPromptTokens and TotalCompletionTokens are the tokens sent to and
+	// received from the models over the whole session, where PromptTokens
+	// and CompletionTokens are the ones of the last request.
+	TotalPromptTokens     int64
+	TotalCompletionTokens int64
+	Todos                 []Todo
+	CreatedAt             int64
+	UpdatedAt             int64
+	// ShellProfile is the name of the shell profile the bash tool runs
+	// commands with, or empty for the default one.
+	ShellProfile string
+	// WorkingDir is the directory the tools of the session resolve relative
+	// paths against, or empty for the working directory of Crush.
+	WorkingDir string
+	// ExplainMode is whether the agent only analyzes and explains the code
+	// in the session, without the tools that change anything.
+	ExplainMode bool
+
+	// UI holds the TUI state to restore when the session is reopened.
+	UI UIState
+}
+
+// UIState is the state of the TUI for a session, saved so reopening the
+// session puts the user back where they left off.
+type UIState struct {
+	// Draft is the unsent text in the editor.
+	Draft string `json:"-"`
+	// ScrollOffset is the chat scroll position in lines from the bottom.
+	ScrollOffset int64 `json:"-"`
+
+	PillsExpanded bool `json:"pills_expanded,omitempty"`
+	PillSection   int  `json:"pill_section,omitempty"`
+	ChatFocused   bool `json:"chat_focused,omitempty"`
+	// Dialog is the ID of the dialog that was open, if any.
+	Dialog string `json:"dialog,omitempty"`
 }
 
 type Service interface {
@@ -49,13 +87,21 @@ type Service interface {
 	CreateTaskSession(ctx context.Context, toolCallID, parentSessionID, title string) (Session, error)
 	Get(ctx context.Context, id string) (Session, error)
 	List(ctx context.Context) ([]Session, error)
+	ListChildren(ctx context.Context, parentSessionID string) ([]Session, error)
 	Save(ctx context.Context, session Session) (Session, error)
 	UpdateTitleAndUsage(ctx context.Context, sessionID, title string, promptTokens, completionTokens int64, cost float64) error
+	SaveUIState(ctx context.Context, sessionID string, state UIState) error
+	SetShellProfile(ctx context.Context, sessionID, profile string) error
+	SetWorkingDir(ctx context.Context, sessionID, dir string) error
+	SetExplainMode(ctx context.Context, sessionID string, explain bool) error
+	Latest(ctx context.Context) (Session, error)
 	Delete(ctx context.Context, id string) error
 
 	// Agent tool session management
 	CreateAgentToolSessionID(messageID, toolCallID string) string
+	CreateAgentTaskSessionID(messageID, toolCallID string, task int) string
 	ParseAgentToolSessionID(sessionID string) (messageID string, toolCallID string, ok bool)
+	ParseAgentTaskSessionID(sessionID string) (task int, ok bool)
 	IsAgentToolSession(sessionID string) bool
 }
 
@@ -75,6 +121,7 @@ func (s *service) Create(ctx context.Context, title string) (Session, error) {
 	session := s.fromDBItem(dbSession)
 	s.Publish(pubsub.CreatedEvent, session)
 	event.SessionCreated()
+	stats.SessionCreated()
 	return session, nil
 }
 
@@ -143,7 +190,9 @@ func (s *service) Save(ctx context.Context, session Session) (Session, error) {
 			String: session.SummaryMessageID,
 			Valid:  session.SummaryMessageID != "",
 		},
-		Cost: session.Cost,
+		Cost:                  session.Cost,
+		TotalPromptTokens:     session.TotalPromptTokens,
+		TotalCompletionTokens: session.TotalCompletionTokens,
 		Todos: sql.NullString{
 			String: todosJSON,
 			Valid:  todosJSON != "",
@@ -161,14 +210,98 @@ func (s *service) Save(ctx context.Context, session Session) (Session, error) {
 // This is safer than fetching, modifying, and saving the entire session.
 func (s *service) UpdateTitleAndUsage(ctx context.Context, sessionID, title string, promptTokens, completionTokens int64, cost float64) error {
 	return s.q.UpdateSessionTitleAndUsage(ctx, db.UpdateSessionTitleAndUsageParams{
-		ID:               sessionID,
-		Title:            title,
-		PromptTokens:     promptTokens,
-		CompletionTokens: completionTokens,
-		Cost:             cost,
+		ID:                    sessionID,
+		Title:                 title,
+		PromptTokens:          promptTokens,
+		CompletionTokens:      completionTokens,
+		Cost:                  cost,
+		TotalPromptTokens:     promptTokens,
+		TotalCompletionTokens: completionTokens,
+	})
+}
+
+// SaveUIState stores the TUI state of a session.
+func (s *service) SaveUIState(ctx context.Context, sessionID string, state UIState) error {
+	uiJSON, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return s.q.UpdateSessionUIState(ctx, db.UpdateSessionUIStateParams{
+		ID:           sessionID,
+		Draft:        sql.NullString{String: state.Draft, Valid: state.Draft != ""},
+		ScrollOffset: state.ScrollOffset,
+		UiState:      sql.NullString{String: string(uiJSON), Valid: string(uiJSON) != "{}"},
 	})
 }
 
+// SetShellProfile sets the shell profile the bash tool of the session runs
+// commands with. An empty profile goes back to the default one.
+func (s *service) SetShellProfile(ctx context.Context, sessionID, profile string) error {
+	if err := s.q.UpdateSessionShellProfile(ctx, db.UpdateSessionShellProfileParams{
+		ID:           sessionID,
+		ShellProfile: sql.NullString{String: profile, Valid: profile != ""},
+	}); err != nil {
+		return err
+	}
+	session, err := s.Get(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	s.Publish(pubsub.UpdatedEvent, session)
+	return nil
+}
+
+// SetWorkingDir sets the directory the tools of the session resolve
+// relative paths against. An empty dir goes back to the working directory of
+// Crush.
+func (s *service) SetWorkingDir(ctx context.Context, sessionID, dir string) error {
+	if err := s.q.UpdateSessionWorkingDir(ctx, db.UpdateSessionWorkingDirParams{
+		ID:         sessionID,
+		WorkingDir: sql.NullString{String: dir, Valid: dir != ""},
+	}); err != nil {
+		return err
+	}
+	session, err := s.Get(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	s.Publish(pubsub.UpdatedEvent, session)
+	return nil
+}
+
+// SetExplainMode sets whether the agent only analyzes and explains the code
+// in the session.
+func (s *service) SetExplainMode(ctx context.Context, sessionID string, explain bool) error {
+	var value int64
+	if explain {
+		value = 1
+	}
+	if err := s.q.UpdateSessionExplainMode(ctx, db.UpdateSessionExplainModeParams{
+		ID:          sessionID,
+		ExplainMode: value,
+	}); err != nil {
+		return err
+	}
+	session, err := s.Get(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	s.Publish(pubsub.UpdatedEvent, session)
+	return nil
+}
+
+// Latest returns the most recently updated top-level session.
+func (s *service) Latest(ctx context.Context) (Session, error) {
+	sessions, err := s.List(ctx)
+	if err != nil {
+		return Session{}, err
+	}
+	if len(sessions) == 0 {
+		return Session{}, ErrNoSessions
+	}
+	return sessions[0], nil
+}
+
 func (s *service) List(ctx context.Context) ([]Session, error) {
 	dbSessions, err := s.q.ListSessions(ctx)
 	if err != nil {
@@ -181,23 +314,51 @@ func (s *service) List(ctx context.Context) ([]Session, error) {
 	return sessions, nil
 }
 
+// ListChildren returns the sessions created for the sub-agents and tasks of
+// a session, oldest first.
+func (s *service) ListChildren(ctx context.Context, parentSessionID string) ([]Session, error) {
+	dbSessions, err := s.q.ListChildSessions(ctx, sql.NullString{String: parentSessionID, Valid: true})
+	if err != nil {
+		return nil, err
+	}
+	sessions := make([]Session, len(dbSessions))
+	for i, dbSession := range dbSessions {
+		sessions[i] = s.fromDBItem(dbSession)
+	}
+	return sessions, nil
+}
+
 func (s service) fromDBItem(item db.Session) Session {
 	todos, err := unmarshalTodos(item.Todos.String)
 	if err != nil {
 		slog.Error("failed to unmarshal todos", "session_id", item.ID, "error", err)
 	}
+	var ui UIState
+	if item.UiState.String != "" {
+		if err := json.Unmarshal([]byte(item.UiState.String), &ui); err != nil {
+			slog.Error("failed to unmarshal UI state", "session_id", item.ID, "error", err)
+		}
+	}
+	ui.Draft = item.Draft.String
+	ui.ScrollOffset = item.ScrollOffset
 	return Session{
-		ID:               item.ID,
-		ParentSessionID:  item.ParentSessionID.String,
-		Title:            item.Title,
-		MessageCount:     item.MessageCount,
-		PromptTokens:     item.PromptTokens,
-		CompletionTokens: item.CompletionTokens,
-		SummaryMessageID: item.SummaryMessageID.String,
-		Cost:             item.Cost,
-		Todos:            todos,
-		CreatedAt:        item.CreatedAt,
-		UpdatedAt:        item.UpdatedAt,
+		ID:                    item.ID,
+		ParentSessionID:       item.ParentSessionID.String,
+		Title:                 item.Title,
+		MessageCount:          item.MessageCount,
+		PromptTokens:          item.PromptTokens,
+		CompletionTokens:      item.CompletionTokens,
+		SummaryMessageID:      item.SummaryMessageID.String,
+		Cost:                  item.Cost,
+		TotalPromptTokens:     item.TotalPromptTokens,
+		TotalCompletionTokens: item.TotalCompletionTokens,
+		Todos:                 todos,
+		CreatedAt:             item.CreatedAt,
+		UpdatedAt:             item.UpdatedAt,
+		ShellProfile:          item.ShellProfile.String,
+		WorkingDir:            item.WorkingDir.String,
+		ExplainMode:           item.ExplainMode != 0,
+		UI:                    ui,
 	}
 }
 
@@ -236,15 +397,36 @@ func (s *service) CreateAgentToolSessionID(messageID, toolCallID string) string
 	return fmt.Sprintf("%s$$%s", messageID, toolCallID)
 }
 
-// ParseAgentToolSessionID parses an agent tool session ID into its components
+// CreateAgentTaskSessionID creates a session ID for the session of one of the
+// tasks of an agent tool call using the format "messageID$$toolCallID$$task"
+func (s *service) CreateAgentTaskSessionID(messageID, toolCallID string, task int) string {
+	return fmt.Sprintf("%s$$%s$$%d", messageID, toolCallID, task)
+}
+
+// ParseAgentToolSessionID parses an agent tool session ID into its components.
+// The sessions of the tasks of an agent tool call belong to the tool call too.
 func (s *service) ParseAgentToolSessionID(sessionID string) (messageID string, toolCallID string, ok bool) {
 	parts := strings.Split(sessionID, "$$")
-	if len(parts) != 2 {
+	if len(parts) != 2 && len(parts) != 3 {
 		return "", "", false
 	}
 	return parts[0], parts[1], true
 }
 
+// ParseAgentTaskSessionID returns the index of the task of an agent tool call
+// a session was created for.
+func (s *service) ParseAgentTaskSessionID(sessionID string) (task int, ok bool) {
+	parts := strings.Split(sessionID, "$$")
+	if len(parts) != 3 {
+		return 0, false
+	}
+	task, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return 0, false
+	}
+	return task, true
+}
+
 // IsAgentToolSession checks if a session ID follows the agent tool session format
 func (s *service) IsAgentToolSession(sessionID string) bool {
 	_, _, ok := s.ParseAgentToolSessionID(sessionID)
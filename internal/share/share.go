@@ -0,0 +1,182 @@
+// Package share serves a read-only live view of a session as a web page, so
+// a teammate can watch an agent work in real time from a join link.
+package share
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/redact"
+	"github.com/charmbracelet/crush/internal/session"
+)
+
+// pollInterval is how often the session is checked for changes. The session
+// is usually written to by another process, so there are no events to
+// subscribe to.
+const pollInterval = 500 * time.Millisecond
+
+//go:embed page.html
+var page []byte
+
+// Server serves the view of a single session. It only answers requests under
+// a random path, so the join link is what it takes to watch.
+type Server struct {
+	sessions  session.Service
+	messages  message.Service
+	redactor  *redact.Redactor
+	sessionID string
+	token     string
+}
+
+// New creates a server for the given session. Likely secrets are masked with
+// redactor before anything is served.
+func New(sessions session.Service, messages message.Service, redactor *redact.Redactor, sessionID string) (*Server, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, fmt.Errorf("failed to generate share token: %w", err)
+	}
+	return &Server{
+		sessions:  sessions,
+		messages:  messages,
+		redactor:  redactor,
+		sessionID: sessionID,
+		token:     hex.EncodeToString(b),
+	}, nil
+}
+
+// Path is the path of the join link.
+func (s *Server) Path() string {
+	return "/" + s.token + "/"
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	switch r.URL.Path {
+	case s.Path():
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		_, _ = w.Write(page)
+	case s.Path() + "events":
+		s.serveEvents(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// serveEvents streams a snapshot of the session as server-sent events every
+// time it changes.
+func (s *Server) serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	var last []byte
+	for {
+		data, err := s.render(r.Context())
+		if err != nil {
+			slog.Error("Failed to take a snapshot of the shared session", "error", err)
+		} else if !bytes.Equal(data, last) {
+			if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+				return
+			}
+			flusher.Flush()
+			last = data
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// render returns the view of the session as JSON.
+func (s *Server) render(ctx context.Context) ([]byte, error) {
+	sess, err := s.sessions.Get(ctx, s.sessionID)
+	if err != nil {
+		return nil, err
+	}
+	msgs, err := s.messages.List(ctx, s.sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(newView(sess, msgs, s.redactor))
+}
+
+// view is what the page shows of the session.
+type view struct {
+	Title    string        `json:"title"`
+	Messages []viewMessage `json:"messages"`
+}
+
+// viewMessage is a user or assistant message, with the results of its tool
+// calls.
+type viewMessage struct {
+	Role      message.MessageRole `json:"role"`
+	Text      string              `json:"text,omitempty"`
+	ToolCalls []viewToolCall      `json:"tool_calls,omitempty"`
+	Finished  bool                `json:"finished"`
+}
+
+// viewToolCall is a tool call, along with its result once it's there.
+type viewToolCall struct {
+	Name    string `json:"name"`
+	Input   string `json:"input"`
+	Result  string `json:"result,omitempty"`
+	IsError bool   `json:"is_error,omitempty"`
+	Done    bool   `json:"done"`
+}
+
+func newView(sess session.Session, msgs []message.Message, redactor *redact.Redactor) view {
+	clean := func(s string) string {
+		s, _ = redactor.Redact(s)
+		return s
+	}
+	results := make(map[string]message.ToolResult)
+	for _, msg := range msgs {
+		for _, tr := range msg.ToolResults() {
+			results[tr.ToolCallID] = tr
+		}
+	}
+
+	v := view{Title: sess.Title, Messages: []viewMessage{}}
+	for _, msg := range msgs {
+		if msg.Role == message.Tool {
+			continue
+		}
+		m := viewMessage{
+			Role:     msg.Role,
+			Text:     clean(msg.Content().Text),
+			Finished: msg.IsFinished(),
+		}
+		for _, tc := range msg.ToolCalls() {
+			call := viewToolCall{Name: tc.Name, Input: clean(tc.Input)}
+			if tr, ok := results[tc.ID]; ok {
+				call.Result, call.IsError, call.Done = clean(tr.Content), tr.IsError, true
+			}
+			m.ToolCalls = append(m.ToolCalls, call)
+		}
+		v.Messages = append(v.Messages, m)
+	}
+	return v
+}
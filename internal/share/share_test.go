@@ -0,0 +1,59 @@
+package share
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServeHTTP(t *testing.T) {
+	t.Parallel()
+
+	s, err := New(nil, nil, nil, "session")
+	require.NoError(t, err)
+
+	for _, tt := range []struct {
+		method string
+		path   string
+		status int
+	}{
+		{http.MethodGet, s.Path(), http.StatusOK},
+		{http.MethodGet, "/", http.StatusNotFound},
+		{http.MethodGet, "/wrong/", http.StatusNotFound},
+		{http.MethodPost, s.Path(), http.StatusMethodNotAllowed},
+	} {
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+		require.Equal(t, tt.status, rec.Code, "%s %s", tt.method, tt.path)
+	}
+}
+
+func TestNewView(t *testing.T) {
+	t.Parallel()
+
+	msgs := []message.Message{
+		{Role: message.User, Parts: []message.ContentPart{
+			message.TextContent{Text: "list the files"},
+		}},
+		{Role: message.Assistant, Parts: []message.ContentPart{
+			message.ToolCall{ID: "call-1", Name: "ls", Input: `{"path":"."}`},
+			message.ToolCall{ID: "call-2", Name: "view", Input: `{"file_path":"main.go"}`},
+		}},
+		{Role: message.Tool, Parts: []message.ContentPart{
+			message.ToolResult{ToolCallID: "call-1", Content: "main.go"},
+		}},
+	}
+
+	v := newView(session.Session{Title: "Files"}, msgs, nil)
+	require.Equal(t, "Files", v.Title)
+	require.Len(t, v.Messages, 2)
+	require.Equal(t, "list the files", v.Messages[0].Text)
+	require.Equal(t, []viewToolCall{
+		{Name: "ls", Input: `{"path":"."}`, Result: "main.go", Done: true},
+		{Name: "view", Input: `{"file_path":"main.go"}`},
+	}, v.Messages[1].ToolCalls)
+}
@@ -0,0 +1,53 @@
+package shell
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+
+	"mvdan.cc/sh/v3/interp"
+)
+
+// Backend runs the commands of shells somewhere else than on the host, like
+// in a container or on a remote machine.
+type Backend interface {
+	// Command returns the command that runs a shell command in a directory
+	// of the host, or the one matching it where the backend runs commands,
+	// with the given environment. Cancelling the context stops the command
+	// where it runs.
+	Command(ctx context.Context, dir string, env []string, command string) (*exec.Cmd, error)
+}
+
+// execBackend runs command with the backend of the shell. Like with native
+// shells, the working directory and environment are not updated from the
+// command.
+func (s *Shell) execBackend(ctx context.Context, command string, stdout, stderr io.Writer) error {
+	for _, args := range nativeCommandArgs(command) {
+		for _, blockFunc := range s.blockFuncs {
+			if blockFunc(args) {
+				return fmt.Errorf("command is not allowed for security reasons: %s", strings.Join(args, " "))
+			}
+		}
+	}
+
+	cmd, err := s.backend.Command(ctx, s.cwd, s.env, command)
+	if err != nil {
+		return err
+	}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	err = cmd.Run()
+	s.logger.InfoPersist("command finished", "command", command, "backend", fmt.Sprintf("%T", s.backend), "err", err)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return interp.ExitStatus(uint8(exitErr.ExitCode()))
+	}
+	return err
+}
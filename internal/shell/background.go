@@ -36,7 +36,15 @@ type BackgroundShell struct {
 
 // BackgroundShellManager manages background shell instances.
 type BackgroundShellManager struct {
-	shells *csync.Map[string, *BackgroundShell]
+	shells    *csync.Map[string, *BackgroundShell]
+	shellType atomic.Int64
+	backend   atomic.Value // holds a backendHolder
+}
+
+// backendHolder lets the manager store a nil backend, and backends of
+// different types, in an atomic.Value.
+type backendHolder struct {
+	backend Backend
 }
 
 var (
@@ -55,8 +63,41 @@ func GetBackgroundShellManager() *BackgroundShellManager {
 	return backgroundManager
 }
 
+// SetShellType sets the type of shell used for background shells started
+// after the call.
+func (m *BackgroundShellManager) SetShellType(shellType ShellType) {
+	m.shellType.Store(int64(shellType))
+}
+
+// SetBackend sets the backend background shells started after the call run
+// their commands with, or nil to run them on the host.
+func (m *BackgroundShellManager) SetBackend(backend Backend) {
+	m.backend.Store(backendHolder{backend})
+}
+
+// Backend returns the backend background shells run their commands with, or
+// nil when they run on the host.
+func (m *BackgroundShellManager) Backend() Backend {
+	holder, _ := m.backend.Load().(backendHolder)
+	return holder.backend
+}
+
 // Start creates and starts a new background shell with the given command.
 func (m *BackgroundShellManager) Start(ctx context.Context, workingDir string, blockFuncs []BlockFunc, command string, description string) (*BackgroundShell, error) {
+	return m.StartWith(ctx, Options{
+		WorkingDir: workingDir,
+		BlockFuncs: blockFuncs,
+		Type:       ShellType(m.shellType.Load()),
+	}, command, description)
+}
+
+// StartWith creates and starts a new background shell with the given
+// command, in a shell created with the given options. The shell runs its
+// commands with the backend of the manager unless the options set one.
+func (m *BackgroundShellManager) StartWith(ctx context.Context, opts Options, command string, description string) (*BackgroundShell, error) {
+	if opts.Backend == nil {
+		opts.Backend = m.Backend()
+	}
 	// Check job limit
 	if m.shells.Len() >= MaxBackgroundJobs {
 		return nil, fmt.Errorf("maximum number of background jobs (%d) reached. Please terminate or wait for some jobs to complete", MaxBackgroundJobs)
@@ -64,10 +105,7 @@ func (m *BackgroundShellManager) Start(ctx context.Context, workingDir string, b
 
 	id := fmt.Sprintf("%03X", idCounter.Add(1))
 
-	shell := NewShell(&Options{
-		WorkingDir: workingDir,
-		BlockFuncs: blockFuncs,
-	})
+	shell := NewShell(&opts)
 
 	shellCtx, cancel := context.WithCancel(ctx)
 
@@ -75,7 +113,7 @@ func (m *BackgroundShellManager) Start(ctx context.Context, workingDir string, b
 		ID:          id,
 		Command:     command,
 		Description: description,
-		WorkingDir:  workingDir,
+		WorkingDir:  opts.WorkingDir,
 		Shell:       shell,
 		ctx:         shellCtx,
 		cancel:      cancel,
@@ -0,0 +1,192 @@
+package shell
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultContainerWorkspace is where the workspace is mounted in the
+// container, unless configured otherwise.
+const DefaultContainerWorkspace = "/workspace"
+
+// ContainerOptions configures the container commands run in.
+type ContainerOptions struct {
+	// Runtime is the CLI that runs containers, docker by default. Anything
+	// with a compatible CLI, like podman, works.
+	Runtime string
+	// Image is the image to start the container from. It takes precedence
+	// over the image of the devcontainer.
+	Image string
+	// Devcontainer reads the image, workspace folder, run arguments and
+	// environment from the devcontainer.json of the workspace.
+	Devcontainer bool
+	// WorkspaceFolder is where the workspace is mounted in the container.
+	WorkspaceFolder string
+	// RunArgs are extra arguments to the run command of the runtime.
+	RunArgs []string
+}
+
+// Container runs commands in a container with the workspace mounted, so they
+// run in the same environment as CI and can't change the host outside of the
+// workspace. The container is started by the first command, and removed by
+// Stop.
+type Container struct {
+	runtime   string
+	image     string
+	workspace string
+	folder    string
+	runArgs   []string
+	env       []string
+
+	mu sync.Mutex
+	id string
+}
+
+// NewContainer checks the options and returns the container to run the
+// commands of the workspace in. It doesn't start it yet.
+func NewContainer(workspace string, opts ContainerOptions) (*Container, error) {
+	c := &Container{
+		runtime:   cmp.Or(opts.Runtime, "docker"),
+		image:     opts.Image,
+		workspace: workspace,
+		folder:    opts.WorkspaceFolder,
+		runArgs:   opts.RunArgs,
+	}
+	if opts.Devcontainer {
+		dc, err := loadDevcontainer(workspace)
+		if err != nil {
+			return nil, err
+		}
+		c.image = cmp.Or(c.image, dc.Image)
+		c.folder = cmp.Or(c.folder, dc.WorkspaceFolder)
+		c.runArgs = append(slices.Clone(dc.RunArgs), c.runArgs...)
+		for _, key := range slices.Sorted(maps.Keys(dc.ContainerEnv)) {
+			c.env = append(c.env, key+"="+dc.ContainerEnv[key])
+		}
+	}
+	if c.image == "" {
+		return nil, errors.New("no image to run commands in: set an image, or use a devcontainer.json with one")
+	}
+	c.folder = cmp.Or(c.folder, DefaultContainerWorkspace)
+	if _, err := exec.LookPath(c.runtime); err != nil {
+		return nil, fmt.Errorf("container runtime %s not found: %w", c.runtime, err)
+	}
+	return c, nil
+}
+
+// Image returns the image the container runs.
+func (c *Container) Image() string {
+	return c.image
+}
+
+// WorkspaceFolder returns where the workspace is mounted in the container.
+func (c *Container) WorkspaceFolder() string {
+	return c.folder
+}
+
+// Stop removes the container, if it was started.
+func (c *Container) Stop() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.id == "" {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if out, err := exec.CommandContext(ctx, c.runtime, "rm", "--force", c.id).CombinedOutput(); err != nil {
+		return fmt.Errorf("could not remove container: %w: %s", err, bytes.TrimSpace(out))
+	}
+	c.id = ""
+	return nil
+}
+
+// start starts the container unless it's running already, and returns its
+// ID.
+func (c *Container) start(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.id != "" {
+		return c.id, nil
+	}
+
+	args := []string{
+		"run", "--detach", "--rm",
+		"--volume", c.workspace + ":" + c.folder,
+		"--workdir", c.folder,
+	}
+	for _, kv := range c.env {
+		args = append(args, "--env", kv)
+	}
+	args = append(args, c.runArgs...)
+	// Keep the container alive like the devcontainer CLI does, whatever the
+	// entrypoint of the image.
+	args = append(args,
+		"--entrypoint", "/bin/sh", c.image,
+		"-c", `trap "exit 0" 15; while sleep 1000 & wait $!; do :; done`,
+	)
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, c.runtime, args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("could not start container from %s: %w: %s", c.image, err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	c.id = strings.TrimSpace(string(out))
+	return c.id, nil
+}
+
+// path returns the path a directory of the workspace is mounted at in the
+// container.
+func (c *Container) path(dir string) (string, error) {
+	rel, err := filepath.Rel(c.workspace, dir)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside of the workspace mounted in the container", dir)
+	}
+	return path.Join(c.folder, filepath.ToSlash(rel)), nil
+}
+
+var containerExecCounter atomic.Uint64
+
+// Command returns the command that runs command with sh in the container,
+// in the directory the given one of the workspace is mounted at.
+func (c *Container) Command(ctx context.Context, dir string, env []string, command string) (*exec.Cmd, error) {
+	containerDir, err := c.path(dir)
+	if err != nil {
+		return nil, err
+	}
+	id, err := c.start(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Processes started with exec outlive the CLI, so record the PID to be
+	// able to stop the command when it's cancelled.
+	pidFile := fmt.Sprintf("/tmp/crush-%d.pid", containerExecCounter.Add(1))
+	args := []string{"exec", "--workdir", containerDir}
+	for _, kv := range env {
+		args = append(args, "--env", kv)
+	}
+	args = append(args, id, "/bin/sh", "-c", `echo $$ > "$0"; exec /bin/sh -c "$1"`, pidFile, command)
+
+	cmd := exec.CommandContext(ctx, c.runtime, args...)
+	cmd.Cancel = func() error {
+		killCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = exec.CommandContext(killCtx, c.runtime, "exec", id, "/bin/sh", "-c", `kill -TERM "$(cat "$0")"`, pidFile).Run()
+		return cmd.Process.Kill()
+	}
+	return cmd, nil
+}
@@ -0,0 +1,53 @@
+package shell
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadDevcontainer(t *testing.T) {
+	t.Parallel()
+
+	workspace := filepath.Join(t.TempDir(), "project")
+	require.NoError(t, os.MkdirAll(filepath.Join(workspace, ".devcontainer"), 0o755))
+
+	_, err := loadDevcontainer(t.TempDir())
+	require.Error(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(workspace, ".devcontainer", "devcontainer.json"), []byte(`{
+	// The image CI uses.
+	"image": "mcr.microsoft.com/devcontainers/go:1.25",
+	"workspaceFolder": "/workspaces/${localWorkspaceFolderBasename}",
+	/* Keep the network
+	   of the host. */
+	"runArgs": ["--network=host",],
+	"containerEnv": {"URL": "http://example.com//path"},
+}`), 0o644))
+	dc, err := loadDevcontainer(workspace)
+	require.NoError(t, err)
+	require.Equal(t, "mcr.microsoft.com/devcontainers/go:1.25", dc.Image)
+	require.Equal(t, "/workspaces/project", dc.WorkspaceFolder)
+	require.Equal(t, []string{"--network=host"}, dc.RunArgs)
+	require.Equal(t, map[string]string{"URL": "http://example.com//path"}, dc.ContainerEnv)
+}
+
+func TestContainerPath(t *testing.T) {
+	t.Parallel()
+
+	workspace := t.TempDir()
+	c := &Container{workspace: workspace, folder: DefaultContainerWorkspace}
+
+	p, err := c.path(workspace)
+	require.NoError(t, err)
+	require.Equal(t, "/workspace", p)
+
+	p, err = c.path(filepath.Join(workspace, "cmd", "crush"))
+	require.NoError(t, err)
+	require.Equal(t, "/workspace/cmd/crush", p)
+
+	_, err = c.path(filepath.Dir(workspace))
+	require.Error(t, err)
+}
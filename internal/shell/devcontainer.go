@@ -0,0 +1,96 @@
+package shell
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// devcontainer holds the parts of a devcontainer.json used to run commands.
+type devcontainer struct {
+	Image           string            `json:"image"`
+	WorkspaceFolder string            `json:"workspaceFolder"`
+	RunArgs         []string          `json:"runArgs"`
+	ContainerEnv    map[string]string `json:"containerEnv"`
+	Build           *struct {
+		Dockerfile string `json:"dockerfile"`
+	} `json:"build"`
+}
+
+// devcontainerPaths are where the devcontainer.json of a workspace can be,
+// in order of precedence.
+var devcontainerPaths = []string{
+	filepath.Join(".devcontainer", "devcontainer.json"),
+	".devcontainer.json",
+}
+
+// loadDevcontainer reads the devcontainer.json of the workspace.
+func loadDevcontainer(workspace string) (devcontainer, error) {
+	for _, p := range devcontainerPaths {
+		data, err := os.ReadFile(filepath.Join(workspace, p))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return devcontainer{}, err
+		}
+		var dc devcontainer
+		if err := json.Unmarshal(stripJSONC(data), &dc); err != nil {
+			return devcontainer{}, fmt.Errorf("could not parse %s: %w", p, err)
+		}
+		if dc.Image == "" && dc.Build != nil {
+			return devcontainer{}, fmt.Errorf("%s builds its image from a Dockerfile, which isn't supported: build it and set its name as the image", p)
+		}
+		dc.WorkspaceFolder = strings.ReplaceAll(dc.WorkspaceFolder, "${localWorkspaceFolderBasename}", filepath.Base(workspace))
+		return dc, nil
+	}
+	return devcontainer{}, errors.New("no devcontainer.json found in the working directory")
+}
+
+// stripJSONC removes the comments and trailing commas allowed in JSON with
+// comments, the format of devcontainer.json.
+func stripJSONC(data []byte) []byte {
+	var out []byte
+	inString := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case inString:
+			out = append(out, c)
+			if c == '\\' && i+1 < len(data) {
+				i++
+				out = append(out, data[i])
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+			out = append(out, c)
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			if i < len(data) {
+				out = append(out, '\n')
+			}
+		case c == '}' || c == ']':
+			if trimmed := bytes.TrimRight(out, " \t\r\n"); len(trimmed) > 0 && trimmed[len(trimmed)-1] == ',' {
+				out = trimmed[:len(trimmed)-1]
+			}
+			out = append(out, c)
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			end := strings.Index(string(data[i+2:]), "*/")
+			if end < 0 {
+				return out
+			}
+			i += end + 3
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
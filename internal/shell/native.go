@@ -0,0 +1,110 @@
+package shell
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"mvdan.cc/sh/v3/interp"
+)
+
+// ParseShellType parses a shell name as used in the configuration. An empty
+// name selects the POSIX emulation.
+func ParseShellType(name string) (ShellType, error) {
+	switch strings.ToLower(name) {
+	case "", "posix", "bash", "sh":
+		return ShellTypePOSIX, nil
+	case "powershell", "pwsh":
+		return ShellTypePowerShell, nil
+	case "cmd", "cmd.exe":
+		return ShellTypeCmd, nil
+	default:
+		return ShellTypePOSIX, fmt.Errorf("unknown shell %q: expected posix, powershell or cmd", name)
+	}
+}
+
+func (t ShellType) String() string {
+	switch t {
+	case ShellTypeCmd:
+		return "cmd"
+	case ShellTypePowerShell:
+		return "powershell"
+	default:
+		return "posix"
+	}
+}
+
+// nativeCommand returns the program and arguments that run command with the
+// given native shell.
+func nativeCommand(shellType ShellType, command string) (string, []string) {
+	switch shellType {
+	case ShellTypePowerShell:
+		program := "powershell.exe"
+		if path, err := exec.LookPath("pwsh"); err == nil {
+			program = path
+		}
+		return program, []string{"-NoLogo", "-NoProfile", "-NonInteractive", "-Command", command}
+	default:
+		return "cmd.exe", []string{"/d", "/s", "/c", command}
+	}
+}
+
+var nativeSeparators = regexp.MustCompile(`&&|\|\||[;&|\n]`)
+
+// nativeCommandArgs splits a native shell command line into the rough
+// argument lists of each command in it, for use with block functions.
+func nativeCommandArgs(command string) [][]string {
+	var commands [][]string
+	for part := range strings.SplitSeq(nativeSeparators.ReplaceAllString(command, "\x00"), "\x00") {
+		fields := strings.Fields(part)
+		if len(fields) == 0 {
+			continue
+		}
+		// Strip PowerShell's call operator and any quotes around the program.
+		if fields[0] == "." {
+			fields = fields[1:]
+		}
+		for i, f := range fields {
+			fields[i] = strings.Trim(f, `"'`)
+		}
+		if len(fields) > 0 {
+			commands = append(commands, fields)
+		}
+	}
+	return commands
+}
+
+// execNative runs command with cmd.exe or PowerShell instead of the POSIX
+// emulation. The working directory and environment are not updated from the
+// command, as each call runs in its own process.
+func (s *Shell) execNative(ctx context.Context, command string, stdout, stderr io.Writer) error {
+	for _, args := range nativeCommandArgs(command) {
+		for _, blockFunc := range s.blockFuncs {
+			if blockFunc(args) {
+				return fmt.Errorf("command is not allowed for security reasons: %s", strings.Join(args, " "))
+			}
+		}
+	}
+
+	program, args := nativeCommand(s.shellType, command)
+	cmd := exec.CommandContext(ctx, program, args...)
+	cmd.Dir = s.cwd
+	cmd.Env = s.env
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	err := cmd.Run()
+	s.logger.InfoPersist("command finished", "command", command, "shell", s.shellType.String(), "err", err)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return interp.ExitStatus(uint8(exitErr.ExitCode()))
+	}
+	return err
+}
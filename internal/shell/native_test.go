@@ -0,0 +1,50 @@
+package shell
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseShellType(t *testing.T) {
+	t.Parallel()
+
+	for name, want := range map[string]ShellType{
+		"":           ShellTypePOSIX,
+		"posix":      ShellTypePOSIX,
+		"bash":       ShellTypePOSIX,
+		"PowerShell": ShellTypePowerShell,
+		"pwsh":       ShellTypePowerShell,
+		"cmd":        ShellTypeCmd,
+	} {
+		got, err := ParseShellType(name)
+		require.NoError(t, err, name)
+		require.Equal(t, want, got, name)
+	}
+
+	_, err := ParseShellType("zsh")
+	require.Error(t, err)
+}
+
+func TestNativeCommandArgs(t *testing.T) {
+	t.Parallel()
+
+	got := nativeCommandArgs(`cd src && "curl" https://example.com | Out-Null; . npm install -g x`)
+	require.Equal(t, [][]string{
+		{"cd", "src"},
+		{"curl", "https://example.com"},
+		{"Out-Null"},
+		{"npm", "install", "-g", "x"},
+	}, got)
+}
+
+func TestNativeBlockFuncs(t *testing.T) {
+	t.Parallel()
+
+	sh := NewShell(&Options{
+		Type:       ShellTypeCmd,
+		BlockFuncs: []BlockFunc{CommandsBlocker([]string{"curl"})},
+	})
+	_, _, err := sh.Exec(t.Context(), "echo hi & curl example.com")
+	require.ErrorContains(t, err, "not allowed")
+}
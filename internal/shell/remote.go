@@ -0,0 +1,175 @@
+package shell
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Remote runs commands over SSH on the machine a remote workspace lives on.
+// The workspace is mounted locally with sshfs, so that the tools reading and
+// writing files work on it like on a local one.
+type Remote struct {
+	host  string
+	dir   string
+	mount string
+}
+
+// ParseRemote splits a remote workspace of the form [user@]host:/path into
+// the SSH destination and the directory.
+func ParseRemote(spec string) (host, dir string, err error) {
+	host, dir, ok := strings.Cut(spec, ":")
+	if !ok || host == "" || !path.IsAbs(dir) {
+		return "", "", fmt.Errorf("invalid remote workspace %q: expected [user@]host:/absolute/path", spec)
+	}
+	return host, path.Clean(dir), nil
+}
+
+// RemoteID returns a name identifying the remote workspace on the local
+// machine, to keep its local state in.
+func RemoteID(spec string) string {
+	sum := sha256.Sum256([]byte(spec))
+	return hex.EncodeToString(sum[:8])
+}
+
+// MountRemote mounts the remote workspace of the form [user@]host:/path with
+// sshfs, and returns it. Authentication is left to SSH, so keys, agents and
+// the ssh_config of the user apply.
+func MountRemote(ctx context.Context, spec string) (*Remote, error) {
+	host, dir, err := ParseRemote(spec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := exec.LookPath("sshfs"); err != nil {
+		return nil, fmt.Errorf("sshfs is needed to work on a remote workspace: %w", err)
+	}
+
+	r := &Remote{
+		host:  host,
+		dir:   dir,
+		mount: filepath.Join(os.TempDir(), "crush-remote-"+RemoteID(spec)),
+	}
+	if err := os.MkdirAll(r.mount, 0o700); err != nil {
+		return nil, fmt.Errorf("could not create mount point: %w", err)
+	}
+	if entries, err := os.ReadDir(r.mount); err != nil || len(entries) > 0 {
+		return nil, fmt.Errorf("%s is already mounted, or not empty: is another Crush working on it?", r.mount)
+	}
+
+	cmd := exec.CommandContext(ctx, "sshfs", host+":"+dir, r.mount,
+		"-o", "reconnect,ServerAliveInterval=15,ServerAliveCountMax=3,BatchMode=yes")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("could not mount %s: %w: %s", spec, err, bytes.TrimSpace(out))
+	}
+	return r, nil
+}
+
+// Host returns the SSH destination of the workspace.
+func (r *Remote) Host() string {
+	return r.host
+}
+
+// Dir returns the directory of the workspace on the remote machine.
+func (r *Remote) Dir() string {
+	return r.dir
+}
+
+// Mount returns where the workspace is mounted locally.
+func (r *Remote) Mount() string {
+	return r.mount
+}
+
+// Unmount unmounts the workspace. It's lazy, so files still open don't keep
+// it mounted.
+func (r *Remote) Unmount() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "linux":
+		name := "fusermount3"
+		if _, err := exec.LookPath(name); err != nil {
+			name = "fusermount"
+		}
+		cmd = exec.CommandContext(ctx, name, "-u", "-z", r.mount)
+	default:
+		cmd = exec.CommandContext(ctx, "umount", "-f", r.mount)
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("could not unmount %s: %w: %s", r.mount, err, bytes.TrimSpace(out))
+	}
+	return os.Remove(r.mount)
+}
+
+// path returns the remote path of a directory of the mounted workspace.
+func (r *Remote) path(dir string) (string, error) {
+	rel, err := filepath.Rel(r.mount, dir)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside of the remote workspace", dir)
+	}
+	return path.Join(r.dir, filepath.ToSlash(rel)), nil
+}
+
+var remoteExecCounter atomic.Uint64
+
+// Command returns the command that runs command with sh on the remote
+// machine, in the directory matching the given one of the mount.
+func (r *Remote) Command(ctx context.Context, dir string, env []string, command string) (*exec.Cmd, error) {
+	remoteDir, err := r.path(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	// Closing the SSH session doesn't stop the command, so record its PID to
+	// be able to stop it when it's cancelled.
+	pidFile := fmt.Sprintf("/tmp/crush-%d-%d.pid", os.Getpid(), remoteExecCounter.Add(1))
+	run := "sh -c " + Quote(command)
+	if len(env) > 0 {
+		run = "env " + Quote(env...) + " " + run
+	}
+	script := fmt.Sprintf("cd %s || exit 1; %s & pid=$!; echo $pid > %s; wait $pid; status=$?; rm -f %[3]s; exit $status",
+		Quote(remoteDir), run, pidFile)
+
+	cmd := exec.CommandContext(ctx, "ssh", "-o", "BatchMode=yes", r.host, script)
+	// Local programs run by the command, if any, see the same files.
+	cmd.Dir = dir
+	cmd.Cancel = func() error {
+		killCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		kill := fmt.Sprintf("kill -TERM $(cat %[1]s); rm -f %[1]s", pidFile)
+		_ = exec.CommandContext(killCtx, "ssh", "-o", "BatchMode=yes", r.host, kill).Run()
+		return cmd.Process.Kill()
+	}
+	return cmd, nil
+}
+
+// Quote quotes the arguments for a POSIX shell, and joins them with spaces.
+func Quote(args ...string) string {
+	quoted := make([]string, 0, len(args))
+	for _, arg := range args {
+		if arg != "" && strings.IndexFunc(arg, needsQuote) < 0 {
+			quoted = append(quoted, arg)
+			continue
+		}
+		quoted = append(quoted, "'"+strings.ReplaceAll(arg, "'", `'\''`)+"'")
+	}
+	return strings.Join(quoted, " ")
+}
+
+func needsQuote(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return false
+	}
+	return !strings.ContainsRune("-_./=:,+@%", r)
+}
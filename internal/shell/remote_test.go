@@ -0,0 +1,43 @@
+package shell
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRemote(t *testing.T) {
+	t.Parallel()
+
+	host, dir, err := ParseRemote("dev@devbox:/home/dev/project/")
+	require.NoError(t, err)
+	require.Equal(t, "dev@devbox", host)
+	require.Equal(t, "/home/dev/project", dir)
+
+	for _, spec := range []string{"devbox", ":/home/dev", "devbox:project"} {
+		_, _, err := ParseRemote(spec)
+		require.Error(t, err, spec)
+	}
+}
+
+func TestRemotePath(t *testing.T) {
+	t.Parallel()
+
+	mount := t.TempDir()
+	r := &Remote{host: "devbox", dir: "/home/dev/project", mount: mount}
+
+	p, err := r.path(filepath.Join(mount, "cmd"))
+	require.NoError(t, err)
+	require.Equal(t, "/home/dev/project/cmd", p)
+
+	_, err = r.path(filepath.Dir(mount))
+	require.Error(t, err)
+}
+
+func TestQuote(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "rg --files -- .", Quote("rg", "--files", "--", "."))
+	require.Equal(t, `'' 'a b' 'it'\''s' '$HOME'`, Quote("", "a b", "it's", "$HOME"))
+}
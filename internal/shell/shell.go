@@ -4,9 +4,10 @@
 // working directory and environment. Each shell execution is independent.
 //
 // WINDOWS COMPATIBILITY:
-// This implementation provides POSIX shell emulation (mvdan.cc/sh/v3) even on
-// Windows. Commands should use forward slashes (/) as path separators to work
-// correctly on all platforms.
+// By default this implementation provides POSIX shell emulation
+// (mvdan.cc/sh/v3) even on Windows. Commands should use forward slashes (/) as
+// path separators to work correctly on all platforms. Shells created with
+// ShellTypePowerShell or ShellTypeCmd run commands natively instead.
 package shell
 
 import (
@@ -56,6 +57,8 @@ type Shell struct {
 	mu         sync.Mutex
 	logger     Logger
 	blockFuncs []BlockFunc
+	shellType  ShellType
+	backend    Backend
 }
 
 // Options for creating a new shell
@@ -64,6 +67,9 @@ type Options struct {
 	Env        []string
 	Logger     Logger
 	BlockFuncs []BlockFunc
+	Type       ShellType
+	// Backend runs the commands instead of the host, like in a container.
+	Backend Backend
 }
 
 // NewShell creates a new shell instance with the given options
@@ -78,7 +84,9 @@ func NewShell(opts *Options) *Shell {
 	}
 
 	env := opts.Env
-	if env == nil {
+	// The environment of the host doesn't apply where a backend runs
+	// commands.
+	if env == nil && opts.Backend == nil {
 		env = os.Environ()
 	}
 
@@ -92,6 +100,8 @@ func NewShell(opts *Options) *Shell {
 		env:        env,
 		logger:     logger,
 		blockFuncs: opts.BlockFuncs,
+		shellType:  opts.Type,
+		backend:    opts.Backend,
 	}
 }
 
@@ -258,6 +268,13 @@ func (s *Shell) updateShellFromRunner(runner *interp.Runner) {
 
 // execCommon is the shared implementation for executing commands
 func (s *Shell) execCommon(ctx context.Context, command string, stdout, stderr io.Writer) error {
+	if s.backend != nil {
+		return s.execBackend(ctx, command, stdout, stderr)
+	}
+	if s.shellType != ShellTypePOSIX {
+		return s.execNative(ctx, command, stdout, stderr)
+	}
+
 	line, err := syntax.NewParser().Parse(strings.NewReader(command), "")
 	if err != nil {
 		return fmt.Errorf("could not parse command: %w", err)
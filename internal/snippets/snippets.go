@@ -0,0 +1,136 @@
+// Package snippets expands the named snippets of prompts, like ;rev, into the
+// text they stand for, so boilerplate written again and again takes a few
+// keys.
+package snippets
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Prefix starts the name of a snippet in a prompt.
+const Prefix = ";"
+
+// pattern matches the names of snippets starting words.
+var pattern = regexp.MustCompile(`(?:^|\s)(;[\p{L}\p{N}_-]+)`)
+
+// Expand returns the text with the snippets it names expanded, and the
+// expansions made. Names of unknown snippets are left as they are.
+func Expand(text string, snippets map[string]string) (string, []string) {
+	if len(snippets) == 0 {
+		return text, nil
+	}
+	var (
+		b          strings.Builder
+		expansions []string
+		last       int
+	)
+	for _, loc := range pattern.FindAllStringSubmatchIndex(text, -1) {
+		start, end := loc[2], loc[3]
+		expansion, ok := snippets[text[start+len(Prefix):end]]
+		if !ok {
+			continue
+		}
+		b.WriteString(text[last:start])
+		b.WriteString(expansion)
+		expansions = append(expansions, expansion)
+		last = end
+	}
+	if expansions == nil {
+		return text, nil
+	}
+	b.WriteString(text[last:])
+	return b.String(), expansions
+}
+
+// ExpandBefore expands the snippet named by the word ending at offset, the
+// one just typed. It returns the text up to offset with the snippet expanded,
+// and the expansion.
+func ExpandBefore(text string, offset int, snippets map[string]string) (string, string, bool) {
+	before := text[:offset]
+	start := 0
+	if i := strings.LastIndexFunc(before, unicode.IsSpace); i >= 0 {
+		_, size := utf8.DecodeRuneInString(before[i:])
+		start = i + size
+	}
+	name, ok := strings.CutPrefix(before[start:], Prefix)
+	if !ok {
+		return "", "", false
+	}
+	expansion, ok := snippets[name]
+	if !ok {
+		return "", "", false
+	}
+	return before[:start] + expansion, expansion, true
+}
+
+// Highlight renders the expansions showing in a rendered block of text with
+// render, skipping the first skip cells of each line, like a prompt. An
+// expansion is found however its lines were wrapped, as long as it shows
+// whole.
+func Highlight(view string, expansions []string, skip int, render func(string) string) string {
+	if len(expansions) == 0 {
+		return view
+	}
+	lines := strings.Split(view, "\n")
+
+	// The runes of the lines, but spaces, and where they show.
+	type cell struct{ line, col, width int }
+	var (
+		flat  strings.Builder
+		cells []cell
+	)
+	for i, line := range lines {
+		col := 0
+		for _, r := range ansi.Strip(line) {
+			w := ansi.StringWidth(string(r))
+			if col >= skip && !unicode.IsSpace(r) {
+				flat.WriteRune(r)
+				cells = append(cells, cell{line: i, col: col, width: w})
+			}
+			col += w
+		}
+	}
+	text := flat.String()
+
+	// The cells to highlight of each line, from the first to the last.
+	spans := make(map[int][2]int)
+	mark := func(c cell) {
+		span, ok := spans[c.line]
+		if !ok {
+			span = [2]int{c.col, c.col + c.width}
+		}
+		span[0] = min(span[0], c.col)
+		span[1] = max(span[1], c.col+c.width)
+		spans[c.line] = span
+	}
+	for _, expansion := range expansions {
+		target := strings.Join(strings.Fields(expansion), "")
+		if target == "" {
+			continue
+		}
+		for from := 0; ; {
+			i := strings.Index(text[from:], target)
+			if i < 0 {
+				break
+			}
+			start := utf8.RuneCountInString(text[:from+i])
+			for _, c := range cells[start : start+utf8.RuneCountInString(target)] {
+				mark(c)
+			}
+			from += i + len(target)
+		}
+	}
+
+	for i, span := range spans {
+		line := lines[i]
+		lines[i] = ansi.Cut(line, 0, span[0]) +
+			render(ansi.Strip(ansi.Cut(line, span[0], span[1]))) +
+			ansi.Cut(line, span[1], ansi.StringWidth(line))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,57 @@
+package snippets
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testSnippets = map[string]string{
+	"rev": "Review this diff for correctness, security, and style:",
+	"t":   "Add tests.",
+}
+
+func TestExpand(t *testing.T) {
+	t.Parallel()
+
+	text, expansions := Expand(";rev the change, then ;t ;unknown a;t", testSnippets)
+	require.Equal(t, "Review this diff for correctness, security, and style: the change, then Add tests. ;unknown a;t", text)
+	require.Equal(t, []string{testSnippets["rev"], testSnippets["t"]}, expansions)
+
+	text, expansions = Expand("nothing to expand", testSnippets)
+	require.Equal(t, "nothing to expand", text)
+	require.Empty(t, expansions)
+}
+
+func TestExpandBefore(t *testing.T) {
+	t.Parallel()
+
+	text := "please ;t and more"
+	before, expansion, ok := ExpandBefore(text, len("please ;t"), testSnippets)
+	require.True(t, ok)
+	require.Equal(t, "please Add tests.", before)
+	require.Equal(t, "Add tests.", expansion)
+
+	_, _, ok = ExpandBefore(text, len("please ;"), testSnippets)
+	require.False(t, ok)
+	_, _, ok = ExpandBefore("a;t", 3, testSnippets)
+	require.False(t, ok)
+}
+
+func TestHighlight(t *testing.T) {
+	t.Parallel()
+
+	// The expansion wraps over two lines, after a prompt of two cells.
+	view := strings.Join([]string{
+		"> Please Add",
+		"  tests. Thanks",
+	}, "\n")
+	got := Highlight(view, []string{"Add tests."}, 2, func(s string) string { return "[" + s + "]" })
+	require.Equal(t, strings.Join([]string{
+		"> Please [Add]",
+		"  [tests.] Thanks",
+	}, "\n"), got)
+
+	require.Equal(t, view, Highlight(view, []string{"missing"}, 2, func(s string) string { return "[" + s + "]" }))
+}
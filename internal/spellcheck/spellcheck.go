@@ -0,0 +1,262 @@
+// Package spellcheck finds the likely typos of a prompt: words and
+// identifiers that aren't in a local dictionary but are a letter or two away
+// from one that is. It needs no model, and leaves alone the words it knows
+// nothing close to, as they're more likely new names than typos.
+package spellcheck
+
+import (
+	"bufio"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// minLength is the length of the shortest word checked. Shorter ones are
+// too often abbreviations.
+const minLength = 4
+
+// systemDictionaries are the word lists of the system, the first one found
+// being used.
+var systemDictionaries = []string{"/usr/share/dict/words", "/usr/dict/words"}
+
+var (
+	fieldPattern = regexp.MustCompile(`\S+`)
+	wordPattern  = regexp.MustCompile(`[\p{L}_][\p{L}\p{N}_]*`)
+)
+
+// Suggestion is a likely typo of the text and its fix.
+type Suggestion struct {
+	// Start and End are the byte offsets of the word in the text.
+	Start, End int
+	Word       string
+	Fix        string
+}
+
+// Dictionary is the words and identifiers known to be right.
+type Dictionary struct {
+	words map[string]bool
+	// idents are the identifiers as written, and by their lowercase form.
+	idents      map[string]bool
+	identsLower map[string]string
+	// byLength are the words and identifiers by their length in runes, to
+	// look for the ones close to a typo.
+	byLength map[int][]string
+}
+
+// NewDictionary returns an empty dictionary.
+func NewDictionary() *Dictionary {
+	return &Dictionary{
+		words:       make(map[string]bool),
+		idents:      make(map[string]bool),
+		identsLower: make(map[string]string),
+		byLength:    make(map[int][]string),
+	}
+}
+
+// AddWords adds words, which match regardless of their case.
+func (d *Dictionary) AddWords(words ...string) {
+	for _, word := range words {
+		word = strings.ToLower(strings.TrimSpace(word))
+		if word == "" || d.words[word] {
+			continue
+		}
+		d.words[word] = true
+		n := utf8.RuneCountInString(word)
+		d.byLength[n] = append(d.byLength[n], word)
+	}
+}
+
+// AddIdentifiers adds identifiers, which match as written.
+func (d *Dictionary) AddIdentifiers(names ...string) {
+	for _, name := range names {
+		if name == "" || d.idents[name] {
+			continue
+		}
+		d.idents[name] = true
+		d.identsLower[strings.ToLower(name)] = name
+		n := utf8.RuneCountInString(name)
+		d.byLength[n] = append(d.byLength[n], name)
+	}
+}
+
+// HasWords reports whether the dictionary has words besides identifiers.
+func (d *Dictionary) HasWords() bool {
+	return len(d.words) > 0
+}
+
+// LoadWords reads a word list with a word a line.
+func LoadWords(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var words []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if word := strings.TrimSpace(scanner.Text()); word != "" && !strings.HasPrefix(word, "#") {
+			words = append(words, word)
+		}
+	}
+	return words, scanner.Err()
+}
+
+// SystemWords returns the words of the word list of the system, or none when
+// it has none. The list is read once.
+var SystemWords = sync.OnceValue(func() []string {
+	for _, path := range systemDictionaries {
+		if words, err := LoadWords(path); err == nil {
+			return words
+		}
+	}
+	return nil
+})
+
+// Check returns the likely typos of the text, in the order they appear.
+// Paths, URLs and email addresses are left alone, and so are plain words
+// when the dictionary has no words besides identifiers.
+func (d *Dictionary) Check(text string) []Suggestion {
+	var suggestions []Suggestion
+	for _, field := range fieldPattern.FindAllStringIndex(text, -1) {
+		if strings.ContainsAny(text[field[0]:field[1]], `/\@`) {
+			continue
+		}
+		for _, loc := range wordPattern.FindAllStringIndex(text[field[0]:field[1]], -1) {
+			start, end := field[0]+loc[0], field[0]+loc[1]
+			if fix, ok := d.suggest(text[start:end]); ok {
+				suggestions = append(suggestions, Suggestion{Start: start, End: end, Word: text[start:end], Fix: fix})
+			}
+		}
+	}
+	return suggestions
+}
+
+// suggest returns the fix of a word that looks like a typo.
+func (d *Dictionary) suggest(word string) (string, bool) {
+	if utf8.RuneCountInString(word) < minLength || d.idents[word] || isAcronym(word) {
+		return "", false
+	}
+	if isIdentifier(word) {
+		return d.closest(word, func(candidate string) bool { return d.idents[candidate] })
+	}
+	lower := strings.ToLower(word)
+	if d.words[lower] || d.identsLower[lower] != "" || !d.HasWords() {
+		return "", false
+	}
+	fix, ok := d.closest(lower, func(candidate string) bool { return d.words[candidate] })
+	if !ok {
+		return "", false
+	}
+	// Keep the capital of a word starting a sentence.
+	if r, _ := utf8.DecodeRuneInString(word); unicode.IsUpper(r) {
+		f, size := utf8.DecodeRuneInString(fix)
+		fix = string(unicode.ToUpper(f)) + fix[size:]
+	}
+	return fix, true
+}
+
+// closest returns the candidate nearest to the word, as long as it's near
+// enough to be what was meant: a single edit away for short words, two for
+// longer ones. Ties go to the candidate starting with the same letter.
+func (d *Dictionary) closest(word string, candidate func(string) bool) (string, bool) {
+	n := utf8.RuneCountInString(word)
+	limit := 1
+	if n > 5 {
+		limit = 2
+	}
+	best, bestDistance, bestFirst := "", limit+1, false
+	first, _ := utf8.DecodeRuneInString(word)
+	for length := n - limit; length <= n+limit; length++ {
+		for _, c := range d.byLength[length] {
+			if !candidate(c) {
+				continue
+			}
+			distance := editDistance(word, c, bestDistance)
+			r, _ := utf8.DecodeRuneInString(c)
+			sameFirst := unicode.ToLower(r) == unicode.ToLower(first)
+			if distance < bestDistance || distance == bestDistance && sameFirst && !bestFirst {
+				best, bestDistance, bestFirst = c, distance, sameFirst
+			}
+		}
+	}
+	return best, best != "" && bestDistance <= limit
+}
+
+// editDistance returns the optimal string alignment distance between a and
+// b, where swapping two adjacent letters is a single edit, or more than
+// limit when it's over it.
+func editDistance(a, b string, limit int) int {
+	ra, rb := []rune(a), []rune(b)
+	if abs(len(ra)-len(rb)) > limit {
+		return limit + 1
+	}
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		rowMin := cur[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+			rowMin = min(rowMin, cur[j])
+		}
+		if rowMin > limit {
+			return limit + 1
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(rb)]
+}
+
+// Apply returns the text with the suggestions applied.
+func Apply(text string, suggestions []Suggestion) string {
+	suggestions = slices.Clone(suggestions)
+	slices.SortFunc(suggestions, func(a, b Suggestion) int { return b.Start - a.Start })
+	for _, s := range suggestions {
+		if s.Start < 0 || s.End > len(text) || text[s.Start:s.End] != s.Word {
+			continue
+		}
+		text = text[:s.Start] + s.Fix + text[s.End:]
+	}
+	return text
+}
+
+// isIdentifier reports whether the word looks like an identifier rather than
+// a word: snake_case, camelCase or with digits.
+func isIdentifier(word string) bool {
+	for i, r := range word {
+		if r == '_' || unicode.IsDigit(r) || i > 0 && unicode.IsUpper(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// isAcronym reports whether the word is all capitals, like HTTP, rather
+// than a constant like MAX_SIZE.
+func isAcronym(word string) bool {
+	return strings.ToUpper(word) == word && !strings.ContainsFunc(word, func(r rune) bool {
+		return r == '_' || unicode.IsDigit(r)
+	})
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
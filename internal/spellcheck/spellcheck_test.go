@@ -0,0 +1,55 @@
+package spellcheck
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	d := NewDictionary()
+	d.AddWords("receive", "the", "request", "handler", "should", "retry", "before", "failing", "fails")
+	d.AddIdentifiers("ParseConfig", "max_retries", "HTTPClient")
+
+	text := "The handelr should recieve the requst. Fix ParseConfg and max_retires in internal/confg.go, see HTTP."
+	suggestions := d.Check(text)
+	require.Equal(t, []Suggestion{
+		{Start: 4, End: 11, Word: "handelr", Fix: "handler"},
+		{Start: 19, End: 26, Word: "recieve", Fix: "receive"},
+		{Start: 31, End: 37, Word: "requst", Fix: "request"},
+		{Start: 43, End: 53, Word: "ParseConfg", Fix: "ParseConfig"},
+		{Start: 58, End: 69, Word: "max_retires", Fix: "max_retries"},
+	}, suggestions)
+	require.Equal(t,
+		"The handler should receive the request. Fix ParseConfig and max_retries in internal/confg.go, see HTTP.",
+		Apply(text, suggestions),
+	)
+
+	// Words far from any known one are left alone, and so is the case of
+	// the ones starting a sentence.
+	require.Empty(t, d.Check("Kubernetes orchestrates containers"))
+	require.Equal(t, []Suggestion{{Start: 0, End: 5, Word: "Retyr", Fix: "Retry"}}, d.Check("Retyr before failing."))
+}
+
+func TestCheckWithoutWords(t *testing.T) {
+	t.Parallel()
+
+	// Without a word list only identifiers are checked.
+	d := NewDictionary()
+	d.AddIdentifiers("NewSession")
+	require.Equal(t,
+		[]Suggestion{{Start: 7, End: 16, Word: "NewSesion", Fix: "NewSession"}},
+		d.Check("Rename NewSesion, it's speling"),
+	)
+}
+
+func TestEditDistance(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, 1, editDistance("handelr", "handler", 2))
+	require.Equal(t, 1, editDistance("requst", "request", 2))
+	require.Equal(t, 1, editDistance("recieve", "receive", 2))
+	require.Equal(t, 3, editDistance("abc", "xyzw", 2))
+}
@@ -0,0 +1,257 @@
+// Package stats records usage statistics on this machine when the user opts
+// in, so they can look at their own usage with crush stats. Only metadata is
+// recorded, never the contents of prompts, responses or files, and nothing
+// leaves the machine.
+package stats
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+)
+
+const statsFileName = "stats.jsonl"
+
+// Kind is the kind of an event.
+type Kind string
+
+const (
+	KindSession    Kind = "session"
+	KindTokens     Kind = "tokens"
+	KindToolCall   Kind = "tool_call"
+	KindPermission Kind = "permission"
+)
+
+// editTools are the tools whose permission decisions count as accepting or
+// rejecting an edit.
+var editTools = []string{"edit", "multiedit", "write"}
+
+// Event is a single usage event.
+type Event struct {
+	Time         time.Time `json:"time"`
+	Kind         Kind      `json:"kind"`
+	Provider     string    `json:"provider,omitempty"`
+	Model        string    `json:"model,omitempty"`
+	Tool         string    `json:"tool,omitempty"`
+	Granted      bool      `json:"granted,omitempty"`
+	InputTokens  int64     `json:"input_tokens,omitempty"`
+	OutputTokens int64     `json:"output_tokens,omitempty"`
+}
+
+var (
+	mu   sync.Mutex
+	path string
+)
+
+// FilePath returns the path to the file usage statistics are kept in.
+func FilePath() string {
+	return filepath.Join(filepath.Dir(config.GlobalConfigData()), statsFileName)
+}
+
+// Init starts recording usage statistics. Until it's called, recording does
+// nothing.
+func Init() {
+	mu.Lock()
+	defer mu.Unlock()
+	path = FilePath()
+}
+
+// SessionCreated records that a session was started.
+func SessionCreated() {
+	record(Event{Kind: KindSession})
+}
+
+// TokensUsed records the tokens used by a model in a single step.
+func TokensUsed(provider, model string, input, output int64) {
+	record(Event{
+		Kind:         KindTokens,
+		Provider:     provider,
+		Model:        model,
+		InputTokens:  input,
+		OutputTokens: output,
+	})
+}
+
+// ToolCalled records that the agent called a tool.
+func ToolCalled(tool string) {
+	record(Event{Kind: KindToolCall, Tool: tool})
+}
+
+// PermissionDecided records whether the user granted a tool permission.
+func PermissionDecided(tool string, granted bool) {
+	record(Event{Kind: KindPermission, Tool: tool, Granted: granted})
+}
+
+func record(e Event) {
+	mu.Lock()
+	defer mu.Unlock()
+	if path == "" {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	if err := appendEvent(path, e); err != nil {
+		slog.Warn("Failed to record usage statistics", "error", err)
+	}
+}
+
+func appendEvent(path string, e Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// Load reads the recorded events from path. Lines that can't be parsed are
+// skipped.
+func Load(path string) ([]Event, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var events []Event
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		events = append(events, e)
+	}
+	return events, scanner.Err()
+}
+
+// Week is the number of sessions started in a week.
+type Week struct {
+	Start    time.Time `json:"start"`
+	Sessions int       `json:"sessions"`
+}
+
+// ModelUsage is how much a model was used.
+type ModelUsage struct {
+	Provider     string `json:"provider"`
+	Model        string `json:"model"`
+	Steps        int    `json:"steps"`
+	InputTokens  int64  `json:"input_tokens"`
+	OutputTokens int64  `json:"output_tokens"`
+}
+
+// ToolUsage is how many times a tool was called.
+type ToolUsage struct {
+	Tool  string `json:"tool"`
+	Calls int    `json:"calls"`
+}
+
+// Summary sums up the recorded events.
+type Summary struct {
+	Weeks         []Week       `json:"weeks"`
+	Models        []ModelUsage `json:"models"`
+	Tools         []ToolUsage  `json:"tools"`
+	EditsAccepted int          `json:"edits_accepted"`
+	EditsRejected int          `json:"edits_rejected"`
+}
+
+// AcceptanceRate returns the share of the edits asked for permission that
+// were accepted, or -1 when none were.
+func (s Summary) AcceptanceRate() float64 {
+	total := s.EditsAccepted + s.EditsRejected
+	if total == 0 {
+		return -1
+	}
+	return float64(s.EditsAccepted) / float64(total)
+}
+
+// Summarize sums up the events. Sessions are counted for the given number of
+// weeks up to now, most recent last, weeks starting on Monday.
+func Summarize(events []Event, now time.Time, weeks int) Summary {
+	var s Summary
+	current := weekStart(now)
+	for i := weeks - 1; i >= 0; i-- {
+		s.Weeks = append(s.Weeks, Week{Start: current.AddDate(0, 0, -7*i)})
+	}
+
+	models := map[[2]string]*ModelUsage{}
+	tools := map[string]int{}
+	for _, e := range events {
+		switch e.Kind {
+		case KindSession:
+			start := weekStart(e.Time.In(now.Location()))
+			for i := range s.Weeks {
+				if s.Weeks[i].Start.Equal(start) {
+					s.Weeks[i].Sessions++
+				}
+			}
+		case KindTokens:
+			key := [2]string{e.Provider, e.Model}
+			m, ok := models[key]
+			if !ok {
+				m = &ModelUsage{Provider: e.Provider, Model: e.Model}
+				models[key] = m
+			}
+			m.Steps++
+			m.InputTokens += e.InputTokens
+			m.OutputTokens += e.OutputTokens
+		case KindToolCall:
+			tools[e.Tool]++
+		case KindPermission:
+			if !slices.Contains(editTools, e.Tool) {
+				continue
+			}
+			if e.Granted {
+				s.EditsAccepted++
+			} else {
+				s.EditsRejected++
+			}
+		}
+	}
+
+	for _, m := range models {
+		s.Models = append(s.Models, *m)
+	}
+	slices.SortFunc(s.Models, func(a, b ModelUsage) int {
+		if a.Steps != b.Steps {
+			return b.Steps - a.Steps
+		}
+		return strings.Compare(a.Model, b.Model)
+	})
+	for _, tool := range slices.Sorted(maps.Keys(tools)) {
+		s.Tools = append(s.Tools, ToolUsage{Tool: tool, Calls: tools[tool]})
+	}
+	slices.SortStableFunc(s.Tools, func(a, b ToolUsage) int {
+		return b.Calls - a.Calls
+	})
+	return s
+}
+
+// weekStart returns midnight of the Monday of the week t is in.
+func weekStart(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	y, m, d := t.AddDate(0, 0, -offset).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
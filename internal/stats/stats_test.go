@@ -0,0 +1,64 @@
+package stats
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummarize(t *testing.T) {
+	t.Parallel()
+
+	// A Wednesday.
+	now := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+	events := []Event{
+		{Time: now.AddDate(0, 0, -1), Kind: KindSession},
+		{Time: now.AddDate(0, 0, -2), Kind: KindSession},
+		{Time: now.AddDate(0, 0, -7), Kind: KindSession},
+		{Time: now.AddDate(0, 0, -60), Kind: KindSession},
+		{Time: now, Kind: KindTokens, Provider: "openai", Model: "gpt-5", InputTokens: 100, OutputTokens: 10},
+		{Time: now, Kind: KindTokens, Provider: "anthropic", Model: "claude-sonnet-4", InputTokens: 50, OutputTokens: 5},
+		{Time: now, Kind: KindTokens, Provider: "anthropic", Model: "claude-sonnet-4", InputTokens: 50, OutputTokens: 5},
+		{Time: now, Kind: KindToolCall, Tool: "view"},
+		{Time: now, Kind: KindToolCall, Tool: "edit"},
+		{Time: now, Kind: KindToolCall, Tool: "view"},
+		{Time: now, Kind: KindPermission, Tool: "edit", Granted: true},
+		{Time: now, Kind: KindPermission, Tool: "write", Granted: true},
+		{Time: now, Kind: KindPermission, Tool: "multiedit"},
+		{Time: now, Kind: KindPermission, Tool: "bash"},
+	}
+
+	s := Summarize(events, now, 2)
+	require.Equal(t, []Week{
+		{Start: time.Date(2026, 10, 5, 0, 0, 0, 0, time.UTC), Sessions: 1},
+		{Start: time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC), Sessions: 2},
+	}, s.Weeks)
+	require.Equal(t, []ModelUsage{
+		{Provider: "anthropic", Model: "claude-sonnet-4", Steps: 2, InputTokens: 100, OutputTokens: 10},
+		{Provider: "openai", Model: "gpt-5", Steps: 1, InputTokens: 100, OutputTokens: 10},
+	}, s.Models)
+	require.Equal(t, []ToolUsage{{Tool: "view", Calls: 2}, {Tool: "edit", Calls: 1}}, s.Tools)
+	require.Equal(t, 2, s.EditsAccepted)
+	require.Equal(t, 1, s.EditsRejected)
+	require.InDelta(t, 2.0/3.0, s.AcceptanceRate(), 0.001)
+	require.Equal(t, -1.0, Summary{}.AcceptanceRate())
+}
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "stats.jsonl")
+	events, err := Load(path)
+	require.NoError(t, err)
+	require.Empty(t, events)
+
+	require.NoError(t, appendEvent(path, Event{Time: time.Now(), Kind: KindToolCall, Tool: "ls"}))
+	require.NoError(t, appendEvent(path, Event{Time: time.Now(), Kind: KindSession}))
+	events, err = Load(path)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	require.Equal(t, "ls", events[0].Tool)
+	require.Equal(t, KindSession, events[1].Kind)
+}
@@ -0,0 +1,286 @@
+package symbols
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// pattern finds a kind of symbol on a line. The name is the submatch called
+// name; when there's a submatch called export, the symbol is exported when
+// it matched.
+type pattern struct {
+	re   *regexp.Regexp
+	kind Kind
+}
+
+// language finds the symbols of the files of a language line by line, which
+// is good enough for definitions, as they rarely span lines where names are.
+type language struct {
+	patterns []pattern
+	// exported reports whether a symbol is exported when the patterns don't
+	// tell.
+	exported func(name string) bool
+	// skip matches the lines that look like definitions to the patterns,
+	// but are statements.
+	skip *regexp.Regexp
+}
+
+// statements start the lines of C-like languages that call functions rather
+// than define them.
+var statements = regexp.MustCompile(`^\s*(?:return|new|throw|else|case|if|for|while|switch|catch|do|delete|goto|sizeof)\b`)
+
+func newPattern(kind Kind, re string) pattern {
+	return pattern{re: regexp.MustCompile(re), kind: kind}
+}
+
+func notUnderscored(name string) bool {
+	return !strings.HasPrefix(name, "_")
+}
+
+var (
+	python = &language{
+		patterns: []pattern{
+			newPattern(KindFunction, `^(?P<indent>\s*)(?:async\s+)?def\s+(?P<name>\w+)`),
+			newPattern(KindClass, `^(?P<indent>\s*)class\s+(?P<name>\w+)`),
+			newPattern(KindConstant, `^(?P<name>[A-Z][A-Z0-9_]*)\s*(?::[^=]+)?=`),
+		},
+		exported: notUnderscored,
+	}
+	javascript = &language{
+		patterns: []pattern{
+			newPattern(KindFunction, `^\s*(?P<export>export\s+)?(?:default\s+)?(?:async\s+)?function\s*\*?\s*(?P<name>[\w$]+)`),
+			newPattern(KindClass, `^\s*(?P<export>export\s+)?(?:default\s+)?(?:abstract\s+)?class\s+(?P<name>[\w$]+)`),
+			newPattern(KindInterface, `^\s*(?P<export>export\s+)?(?:declare\s+)?interface\s+(?P<name>[\w$]+)`),
+			newPattern(KindType, `^\s*(?P<export>export\s+)?(?:declare\s+)?type\s+(?P<name>[\w$]+)\s*(?:<[^=]*>)?\s*=`),
+			newPattern(KindType, `^\s*(?P<export>export\s+)?(?:declare\s+)?(?:const\s+)?enum\s+(?P<name>[\w$]+)`),
+			newPattern(KindFunction, `^\s*(?P<export>export\s+)?(?:const|let|var)\s+(?P<name>[\w$]+)\s*(?::[^=]+)?=\s*(?:async\s+)?(?:function\b|\([^)]*\)\s*(?::[^=]+)?=>|[\w$]+\s*=>)`),
+			newPattern(KindVariable, `^(?P<export>export\s+)(?:const|let|var)\s+(?P<name>[\w$]+)`),
+		},
+		exported: func(string) bool { return false },
+	}
+	rust = &language{
+		patterns: []pattern{
+			newPattern(KindFunction, `^\s*(?P<export>pub(?:\([\w:\s]+\))?\s+)?(?:const\s+)?(?:async\s+)?(?:unsafe\s+)?(?:extern\s+"\w+"\s+)?fn\s+(?P<name>\w+)`),
+			newPattern(KindType, `^\s*(?P<export>pub(?:\([\w:\s]+\))?\s+)?(?:struct|enum|union|type)\s+(?P<name>\w+)`),
+			newPattern(KindInterface, `^\s*(?P<export>pub(?:\([\w:\s]+\))?\s+)?(?:unsafe\s+)?trait\s+(?P<name>\w+)`),
+			newPattern(KindConstant, `^\s*(?P<export>pub(?:\([\w:\s]+\))?\s+)?(?:const|static)\s+(?:mut\s+)?(?P<name>[A-Z_][A-Z0-9_]*)\s*:`),
+			newPattern(KindModule, `^\s*(?P<export>pub(?:\([\w:\s]+\))?\s+)?mod\s+(?P<name>\w+)`),
+			newPattern(KindFunction, `^\s*macro_rules!\s+(?P<name>\w+)`),
+		},
+		exported: func(string) bool { return false },
+	}
+	java = &language{
+		patterns: []pattern{
+			newPattern(KindClass, `^\s*(?P<export>public\s+)?(?:(?:protected|private|static|abstract|final|sealed|data|open|internal)\s+)*(?:class|record|object)\s+(?P<name>\w+)`),
+			newPattern(KindInterface, `^\s*(?P<export>public\s+)?(?:(?:protected|private|static|abstract|sealed|fun)\s+)*(?:interface|@interface)\s+(?P<name>\w+)`),
+			newPattern(KindType, `^\s*(?P<export>public\s+)?(?:(?:protected|private|static)\s+)*enum(?:\s+class)?\s+(?P<name>\w+)`),
+			newPattern(KindFunction, `^\s*(?:(?:public|protected|private|internal|override|open|suspend|inline|private)\s+)*fun\s+(?:<[^>]+>\s*)?(?:[\w.]+\.)?(?P<name>\w+)\s*\(`),
+			newPattern(KindMethod, `^\s*(?P<export>public\s+)?(?:(?:protected|private|static|abstract|final|synchronized|native|default)\s+)*(?:<[^>]+>\s+)?[\w.<>\[\],?\s]+?\s+(?P<name>\w+)\s*\([^;]*$`),
+		},
+		exported: func(string) bool { return false },
+		skip:     statements,
+	}
+	ruby = &language{
+		patterns: []pattern{
+			newPattern(KindMethod, `^\s*def\s+(?:self\.)?(?P<name>\w+[?!=]?)`),
+			newPattern(KindClass, `^\s*class\s+(?:\w+::)*(?P<name>[A-Z]\w*)`),
+			newPattern(KindModule, `^\s*module\s+(?:\w+::)*(?P<name>[A-Z]\w*)`),
+		},
+		exported: notUnderscored,
+	}
+	clang = &language{
+		patterns: []pattern{
+			newPattern(KindType, `^\s*(?:typedef\s+)?(?:struct|union|enum(?:\s+class)?)\s+(?P<name>\w+)\s*(?:[{:]|$)`),
+			newPattern(KindClass, `^\s*(?:template\s*<[^>]*>\s*)?class\s+(?:\w+\s+)?(?P<name>\w+)\s*(?:[{:]|final|$)`),
+			newPattern(KindConstant, `^#\s*define\s+(?P<name>\w+)`),
+			newPattern(KindFunction, `^(?:[A-Za-z_][\w:<>,*&\s]*?[\s*&])(?:\w+::)*(?P<name>[A-Za-z_~]\w*)\s*\([^;]*$`),
+		},
+		exported: func(string) bool { return true },
+		skip:     statements,
+	}
+
+	languages = map[string]*language{
+		".py":   python,
+		".pyi":  python,
+		".js":   javascript,
+		".jsx":  javascript,
+		".mjs":  javascript,
+		".cjs":  javascript,
+		".ts":   javascript,
+		".tsx":  javascript,
+		".mts":  javascript,
+		".cts":  javascript,
+		".rs":   rust,
+		".java": java,
+		".kt":   java,
+		".kts":  java,
+		".cs":   java,
+		".rb":   ruby,
+		".c":    clang,
+		".h":    clang,
+		".cc":   clang,
+		".cpp":  clang,
+		".cxx":  clang,
+		".hpp":  clang,
+		".hh":   clang,
+	}
+)
+
+// supported reports whether the symbols of the file can be indexed.
+func supported(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	_, ok := languages[ext]
+	return ok || ext == ".go"
+}
+
+// Parse returns the symbols defined in the content of the file at path.
+func Parse(path string, content []byte) []Symbol {
+	if bytes.IndexByte(content, 0) >= 0 {
+		return nil
+	}
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == ".go" {
+		return parseGo(path, content)
+	}
+	lang, ok := languages[ext]
+	if !ok {
+		return nil
+	}
+	return lang.parse(path, content)
+}
+
+func (lang *language) parse(path string, content []byte) []Symbol {
+	var symbols []Symbol
+	// Python classes contain their methods, which are found by their
+	// indentation.
+	var class string
+	var classIndent int
+	for i, line := range strings.Split(string(content), "\n") {
+		if lang.skip != nil && lang.skip.MatchString(line) {
+			continue
+		}
+		for _, pat := range lang.patterns {
+			m := pat.re.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			s := Symbol{Kind: pat.kind, Path: path, Line: i + 1}
+			hasExport := false
+			indent := -1
+			for j, group := range pat.re.SubexpNames() {
+				switch group {
+				case "name":
+					s.Name = m[j]
+				case "export":
+					hasExport = true
+					s.Exported = m[j] != ""
+				case "indent":
+					indent = len(m[j])
+				}
+			}
+			if !hasExport {
+				s.Exported = lang.exported(s.Name)
+			}
+			if lang == python {
+				switch {
+				case indent > 0 && class != "" && indent > classIndent && s.Kind == KindFunction:
+					s.Kind = KindMethod
+					s.Container = class
+				case indent == 0 || indent <= classIndent:
+					class = ""
+				}
+				if s.Kind == KindClass {
+					class, classIndent = s.Name, indent
+				}
+				// Nested functions aren't worth indexing.
+				if indent > 0 && s.Kind == KindFunction {
+					break
+				}
+			}
+			symbols = append(symbols, s)
+			break
+		}
+	}
+	return symbols
+}
+
+// parseGo finds the top-level declarations of Go files with the Go parser.
+func parseGo(path string, content []byte) []Symbol {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, path, content, parser.SkipObjectResolution)
+	if f == nil {
+		return nil
+	}
+	_ = err // Partial files still have symbols worth indexing.
+
+	var symbols []Symbol
+	add := func(name string, kind Kind, container string, pos token.Pos) {
+		if name == "_" {
+			return
+		}
+		symbols = append(symbols, Symbol{
+			Name:      name,
+			Kind:      kind,
+			Container: container,
+			Path:      path,
+			Line:      fset.Position(pos).Line,
+			Exported:  ast.IsExported(name),
+		})
+	}
+	for _, decl := range f.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Recv == nil || len(decl.Recv.List) == 0 {
+				add(decl.Name.Name, KindFunction, "", decl.Name.Pos())
+				continue
+			}
+			add(decl.Name.Name, KindMethod, receiverName(decl.Recv.List[0].Type), decl.Name.Pos())
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					kind := KindType
+					if _, ok := spec.Type.(*ast.InterfaceType); ok {
+						kind = KindInterface
+					}
+					add(spec.Name.Name, kind, "", spec.Name.Pos())
+				case *ast.ValueSpec:
+					kind := KindVariable
+					if decl.Tok == token.CONST {
+						kind = KindConstant
+					}
+					for _, name := range spec.Names {
+						add(name.Name, kind, "", name.Pos())
+					}
+				}
+			}
+		}
+	}
+	return symbols
+}
+
+// receiverName returns the name of the type of a method receiver, without
+// its pointer and type parameters.
+func receiverName(expr ast.Expr) string {
+	for {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.IndexListExpr:
+			expr = e.X
+		case *ast.ParenExpr:
+			expr = e.X
+		case *ast.Ident:
+			return e.Name
+		default:
+			return ""
+		}
+	}
+}
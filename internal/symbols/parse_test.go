@@ -0,0 +1,131 @@
+package symbols
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type found struct {
+	Name     string
+	Kind     Kind
+	Line     int
+	Exported bool
+}
+
+func summarize(symbols []Symbol) []found {
+	var result []found
+	for _, s := range symbols {
+		result = append(result, found{s.QualifiedName(), s.Kind, s.Line, s.Exported})
+	}
+	return result
+}
+
+func TestParseGo(t *testing.T) {
+	t.Parallel()
+
+	symbols := Parse("main.go", []byte(`package main
+
+const Version = "1.0"
+
+var debug, _ = false, 1
+
+type Server struct{}
+
+type handler interface{ Serve() }
+
+func (s *Server) Start() error { return nil }
+
+func (l List[T]) Len() int { return 0 }
+
+func main() {
+	func() {}()
+}
+`))
+	require.Equal(t, []found{
+		{"Version", KindConstant, 3, true},
+		{"debug", KindVariable, 5, false},
+		{"Server", KindType, 7, true},
+		{"handler", KindInterface, 9, false},
+		{"Server.Start", KindMethod, 11, true},
+		{"List.Len", KindMethod, 13, true},
+		{"main", KindFunction, 15, false},
+	}, summarize(symbols))
+}
+
+func TestParsePython(t *testing.T) {
+	t.Parallel()
+
+	symbols := Parse("app.py", []byte(`MAX_SIZE = 10
+
+class Parser(Base):
+    def parse(self):
+        def helper():
+            pass
+
+    async def _load(self):
+        pass
+
+def main():
+    pass
+`))
+	require.Equal(t, []found{
+		{"MAX_SIZE", KindConstant, 1, true},
+		{"Parser", KindClass, 3, true},
+		{"Parser.parse", KindMethod, 4, true},
+		{"Parser.helper", KindMethod, 5, true},
+		{"Parser._load", KindMethod, 8, false},
+		{"main", KindFunction, 11, true},
+	}, summarize(symbols))
+}
+
+func TestParseTypeScript(t *testing.T) {
+	t.Parallel()
+
+	symbols := Parse("api.ts", []byte(`export interface User {
+  name: string
+}
+export type ID = string
+const fetchUser = async (id: ID): Promise<User> => {
+  return get(id)
+}
+export default class Client {}
+export const TIMEOUT = 30
+function helper() {}
+`))
+	require.Equal(t, []found{
+		{"User", KindInterface, 1, true},
+		{"ID", KindType, 4, true},
+		{"fetchUser", KindFunction, 5, false},
+		{"Client", KindClass, 8, true},
+		{"TIMEOUT", KindVariable, 9, true},
+		{"helper", KindFunction, 10, false},
+	}, summarize(symbols))
+}
+
+func TestParseC(t *testing.T) {
+	t.Parallel()
+
+	symbols := Parse("list.c", []byte(`#define MAX 10
+struct node {
+	int value;
+};
+static int count(struct node *head)
+{
+	if (head == NULL)
+		return 0;
+	return count(head->next) + 1;
+}
+`))
+	require.Equal(t, []found{
+		{"MAX", KindConstant, 1, true},
+		{"node", KindType, 2, true},
+		{"count", KindFunction, 5, true},
+	}, summarize(symbols))
+}
+
+func TestParseSkipsBinary(t *testing.T) {
+	t.Parallel()
+
+	require.Empty(t, Parse("data.py", []byte("def x():\x00")))
+}
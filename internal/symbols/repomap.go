@@ -0,0 +1,136 @@
+package symbols
+
+import (
+	"cmp"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const (
+	// maxMapSymbols is the most symbols listed per file of the repo map.
+	maxMapSymbols = 8
+	// charsPerToken estimates the tokens of the map from its length.
+	charsPerToken = 4
+)
+
+// testFile matches the files of tests, whose symbols are rarely what's
+// looked for first.
+var testFile = regexp.MustCompile(`(?i)(_test\.go|_test\.py|(^|/)test_[^/]*\.py|\.(test|spec)\.[cm]?[jt]sx?|Tests?\.(java|kt|cs))$|(^|/)(tests?|__tests__|spec)/`)
+
+// kindWeights are how much the exported symbols of each kind say about what
+// a file is for.
+var kindWeights = map[Kind]int{
+	KindType:      3,
+	KindClass:     3,
+	KindInterface: 3,
+	KindModule:    2,
+	KindFunction:  2,
+	KindConstant:  1,
+	KindVariable:  1,
+}
+
+type mapFile struct {
+	path    string
+	symbols []string
+	more    int
+	score   float64
+}
+
+// RepoMap returns a tree of the directories and files of the workspace with
+// the main symbols each file defines, keeping the files that define the most
+// exported symbols closest to the root when it doesn't fit in maxTokens.
+// Files skip reports true for are left out.
+func (x *Index) RepoMap(maxTokens int, skip func(path string) bool) string {
+	var files []mapFile
+	x.mu.RLock()
+	for p, f := range x.files {
+		if testFile.MatchString(p) || skip != nil && skip(p) {
+			continue
+		}
+		mf := mapFile{path: p}
+		var listed []Symbol
+		for _, s := range f.symbols {
+			if !s.Exported || s.Kind == KindMethod {
+				continue
+			}
+			mf.score += float64(kindWeights[s.Kind])
+			listed = append(listed, s)
+		}
+		if len(listed) == 0 {
+			continue
+		}
+		slices.SortStableFunc(listed, func(a, b Symbol) int {
+			return cmp.Compare(kindWeights[b.Kind], kindWeights[a.Kind])
+		})
+		for _, s := range listed[:min(len(listed), maxMapSymbols)] {
+			mf.symbols = append(mf.symbols, s.Name)
+		}
+		mf.more = len(listed) - len(mf.symbols)
+		mf.score /= float64(1 + strings.Count(p, "/"))
+		files = append(files, mf)
+	}
+	x.mu.RUnlock()
+
+	slices.SortFunc(files, func(a, b mapFile) int {
+		return cmp.Or(cmp.Compare(b.score, a.score), strings.Compare(a.path, b.path))
+	})
+
+	budget := maxTokens * charsPerToken
+	dirs := map[string]bool{".": true}
+	var included []mapFile
+	for _, f := range files {
+		cost := strings.Count(f.path, "/")*2 + len(fileLine(f)) + 1
+		var newDirs []string
+		for dir := path.Dir(f.path); !dirs[dir]; dir = path.Dir(dir) {
+			newDirs = append(newDirs, dir)
+			cost += strings.Count(dir, "/")*2 + len(path.Base(dir)) + 2
+		}
+		if cost > budget {
+			continue
+		}
+		budget -= cost
+		for _, dir := range newDirs {
+			dirs[dir] = true
+		}
+		included = append(included, f)
+	}
+	if len(included) == 0 {
+		return ""
+	}
+
+	slices.SortFunc(included, func(a, b mapFile) int {
+		return strings.Compare(a.path, b.path)
+	})
+	var out strings.Builder
+	var prev []string
+	for _, f := range included {
+		var parts []string
+		if dir := path.Dir(f.path); dir != "." {
+			parts = strings.Split(dir, "/")
+		}
+		common := 0
+		for common < min(len(parts), len(prev)) && parts[common] == prev[common] {
+			common++
+		}
+		for i := common; i < len(parts); i++ {
+			fmt.Fprintf(&out, "%s%s/\n", strings.Repeat("  ", i), parts[i])
+		}
+		fmt.Fprintf(&out, "%s%s\n", strings.Repeat("  ", len(parts)), fileLine(f))
+		prev = parts
+	}
+	if omitted := len(files) - len(included); omitted > 0 {
+		fmt.Fprintf(&out, "(%d more files with symbols not shown)\n", omitted)
+	}
+	return strings.TrimSuffix(out.String(), "\n")
+}
+
+func fileLine(f mapFile) string {
+	line := path.Base(f.path) + ": " + strings.Join(f.symbols, ", ")
+	if f.more > 0 {
+		line += fmt.Sprintf(" (+%d)", f.more)
+	}
+	return line
+}
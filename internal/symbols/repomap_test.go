@@ -0,0 +1,47 @@
+package symbols
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepoMap(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	write := func(name, content string) {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	write("internal/server/server.go", "package server\n\ntype Server struct{}\n\nfunc (s *Server) Start() {}\n\nfunc New() *Server { return nil }\n\nfunc helper() {}\n")
+	write("internal/server/server_test.go", "package server\n\nfunc TestServer() {}\n")
+	write("internal/secret/keys.go", "package secret\n\nconst Key = 1\n")
+	write("main.go", "package main\n\nfunc main() {}\n")
+	write("web/app.ts", "export class App {}\nexport function render() {}\n")
+
+	x := NewIndex(dir)
+	require.NoError(t, x.Refresh(t.Context()))
+
+	skip := func(path string) bool { return strings.HasPrefix(path, "internal/secret/") }
+	require.Equal(t, strings.Join([]string{
+		"internal/",
+		"  server/",
+		"    server.go: Server, New",
+		"web/",
+		"  app.ts: App, render",
+	}, "\n"), x.RepoMap(1024, skip))
+
+	// Only the files that matter most fit in small budgets.
+	require.Equal(t, strings.Join([]string{
+		"web/",
+		"  app.ts: App, render",
+		"(1 more files with symbols not shown)",
+	}, "\n"), x.RepoMap(10, skip))
+
+	require.Empty(t, NewIndex(dir).RepoMap(1024, nil))
+}
@@ -0,0 +1,296 @@
+// Package symbols keeps an index of the symbols defined in the workspace,
+// like functions, types and constants, so that finding where one is defined
+// doesn't take a search through every file.
+package symbols
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/fsext"
+)
+
+const (
+	// refreshInterval is how often files are checked for changes.
+	refreshInterval = 15 * time.Second
+	// maxFiles bounds the work done on huge workspaces.
+	maxFiles = 20000
+	// maxFileSize skips generated and minified files, which are rarely
+	// where symbols are looked for.
+	maxFileSize = 512 * 1024
+)
+
+// Kind is the kind of a symbol.
+type Kind string
+
+const (
+	KindFunction  Kind = "function"
+	KindMethod    Kind = "method"
+	KindType      Kind = "type"
+	KindClass     Kind = "class"
+	KindInterface Kind = "interface"
+	KindConstant  Kind = "constant"
+	KindVariable  Kind = "variable"
+	KindModule    Kind = "module"
+)
+
+// Symbol is a symbol defined in a file of the workspace.
+type Symbol struct {
+	Name string
+	Kind Kind
+	// Container is the type a method belongs to, if any.
+	Container string
+	// Path is relative to the workspace, with forward slashes.
+	Path     string
+	Line     int
+	Exported bool
+}
+
+// QualifiedName returns the name of the symbol, prefixed with its container.
+func (s Symbol) QualifiedName() string {
+	if s.Container == "" {
+		return s.Name
+	}
+	return s.Container + "." + s.Name
+}
+
+type file struct {
+	modTime time.Time
+	size    int64
+	symbols []Symbol
+}
+
+// Index is the index of the symbols of a workspace. Refresh keeps it up to
+// date, only parsing the files that changed since the last time.
+type Index struct {
+	root  string
+	ready chan struct{}
+	once  sync.Once
+
+	mu    sync.RWMutex
+	files map[string]file
+}
+
+// NewIndex returns an empty index of the workspace.
+func NewIndex(root string) *Index {
+	return &Index{
+		root:  root,
+		ready: make(chan struct{}),
+		files: make(map[string]file),
+	}
+}
+
+var defaultIndex atomic.Pointer[Index]
+
+// Start indexes the workspace in the background, and keeps the index up to
+// date until ctx is done. Calling Start more than once has no effect.
+func Start(ctx context.Context, root string) {
+	x := NewIndex(root)
+	if !defaultIndex.CompareAndSwap(nil, x) {
+		return
+	}
+	go x.Run(ctx, refreshInterval)
+}
+
+// Default returns the index started with Start, or nil when there's none.
+func Default() *Index {
+	return defaultIndex.Load()
+}
+
+// Run refreshes the index every interval until ctx is done.
+func (x *Index) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		start := time.Now()
+		if err := x.Refresh(ctx); err != nil && !errors.Is(err, context.Canceled) {
+			slog.Warn("Failed to index symbols", "error", err)
+		} else {
+			slog.Debug("Indexed symbols", "files", x.fileCount(), "took", time.Since(start))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh parses the files added or changed since the last refresh, and
+// forgets the deleted ones.
+func (x *Index) Refresh(ctx context.Context) error {
+	defer x.once.Do(func() { close(x.ready) })
+
+	paths, _, err := fsext.ListDirectory(x.root, nil, 0, maxFiles)
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if strings.HasSuffix(path, string(filepath.Separator)) || !supported(path) {
+			continue
+		}
+		rel, err := filepath.Rel(x.root, path)
+		if err != nil {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+		seen[rel] = true
+
+		info, err := os.Stat(path)
+		if err != nil || info.Size() > maxFileSize {
+			continue
+		}
+		x.mu.RLock()
+		cached, ok := x.files[rel]
+		x.mu.RUnlock()
+		if ok && cached.modTime.Equal(info.ModTime()) && cached.size == info.Size() {
+			continue
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		x.mu.Lock()
+		x.files[rel] = file{
+			modTime: info.ModTime(),
+			size:    info.Size(),
+			symbols: Parse(rel, content),
+		}
+		x.mu.Unlock()
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	for rel := range x.files {
+		if !seen[rel] {
+			delete(x.files, rel)
+		}
+	}
+	return nil
+}
+
+// Wait waits for the first refresh of the index to finish, and reports
+// whether it did before ctx was done.
+func (x *Index) Wait(ctx context.Context) bool {
+	select {
+	case <-x.ready:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (x *Index) fileCount() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return len(x.files)
+}
+
+// Symbols returns all the symbols of the index, exported ones first,
+// leaving out the files skip reports.
+func (x *Index) Symbols(skip func(path string) bool) []Symbol {
+	x.mu.RLock()
+	var all []Symbol
+	for p, f := range x.files {
+		if skip != nil && skip(p) {
+			continue
+		}
+		all = append(all, f.symbols...)
+	}
+	x.mu.RUnlock()
+	slices.SortFunc(all, compare)
+	return all
+}
+
+// Lookup returns at most limit symbols matching the query, best matches
+// first: exact names, then names starting with the query, then names
+// containing it. A query like Type.Method also matches the container. The
+// files skip reports are left out.
+func (x *Index) Lookup(query string, kind Kind, limit int, skip func(path string) bool) []Symbol {
+	container, name := "", query
+	if i := strings.LastIndex(query, "."); i > 0 {
+		container, name = query[:i], query[i+1:]
+	}
+	if name == "" {
+		return nil
+	}
+
+	type match struct {
+		Symbol
+		rank int
+	}
+	var matches []match
+	x.mu.RLock()
+	for p, f := range x.files {
+		if skip != nil && skip(p) {
+			continue
+		}
+		for _, s := range f.symbols {
+			if kind != "" && s.Kind != kind {
+				continue
+			}
+			if container != "" && !strings.EqualFold(s.Container, container) {
+				continue
+			}
+			if rank, ok := rankName(s.Name, name); ok {
+				matches = append(matches, match{s, rank})
+			}
+		}
+	}
+	x.mu.RUnlock()
+
+	slices.SortFunc(matches, func(a, b match) int {
+		return cmp.Or(cmp.Compare(a.rank, b.rank), compare(a.Symbol, b.Symbol))
+	})
+	result := make([]Symbol, 0, min(len(matches), limit))
+	for _, m := range matches[:min(len(matches), limit)] {
+		result = append(result, m.Symbol)
+	}
+	return result
+}
+
+// rankName returns how well the name matches the query, lower being better.
+func rankName(name, query string) (int, bool) {
+	lowerName, lowerQuery := strings.ToLower(name), strings.ToLower(query)
+	switch {
+	case name == query:
+		return 0, true
+	case lowerName == lowerQuery:
+		return 1, true
+	case strings.HasPrefix(lowerName, lowerQuery):
+		return 2, true
+	case strings.Contains(lowerName, lowerQuery):
+		return 3, true
+	default:
+		return 0, false
+	}
+}
+
+func compare(a, b Symbol) int {
+	if a.Exported != b.Exported {
+		if a.Exported {
+			return -1
+		}
+		return 1
+	}
+	return cmp.Or(
+		strings.Compare(a.Name, b.Name),
+		strings.Compare(a.Path, b.Path),
+		cmp.Compare(a.Line, b.Line),
+	)
+}
@@ -0,0 +1,57 @@
+package symbols
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndexLookup(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	write := func(name, content string) {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	write("server.go", "package app\n\ntype Server struct{}\n\nfunc (s *Server) Start() {}\n")
+	write("web/start.ts", "export function startServer() {}\n")
+
+	x := NewIndex(dir)
+	require.NoError(t, x.Refresh(t.Context()))
+	require.True(t, x.Wait(t.Context()))
+
+	symbols := x.Lookup("Server", "", 10, nil)
+	require.Len(t, symbols, 2)
+	require.Equal(t, "Server", symbols[0].Name, "exact matches come first")
+	require.Equal(t, "startServer", symbols[1].Name)
+
+	symbols = x.Lookup("Server.start", "", 10, nil)
+	require.Len(t, symbols, 1)
+	require.Equal(t, "server.go", symbols[0].Path)
+	require.Equal(t, 5, symbols[0].Line)
+
+	require.Len(t, x.Lookup("Server", KindType, 10, nil), 1)
+	require.Len(t, x.Lookup("s", "", 1, nil), 1)
+
+	// Skipped files are left out.
+	skip := func(path string) bool { return path == "server.go" }
+	symbols = x.Lookup("Server", "", 10, skip)
+	require.Len(t, symbols, 1)
+	require.Equal(t, "startServer", symbols[0].Name)
+	require.Len(t, x.Symbols(nil), 3)
+	require.Len(t, x.Symbols(skip), 1)
+
+	// Changes are picked up by the next refresh.
+	require.NoError(t, os.Remove(filepath.Join(dir, "web/start.ts")))
+	write("server.go", "package app\n\ntype Service struct{}\n")
+	later := time.Now().Add(time.Second)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "server.go"), later, later))
+	require.NoError(t, x.Refresh(t.Context()))
+	require.Empty(t, x.Lookup("Server", "", 10, nil))
+	require.Len(t, x.Lookup("Service", "", 10, nil), 1)
+}
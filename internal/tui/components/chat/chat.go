@@ -2,6 +2,8 @@ package chat
 
 import (
 	"context"
+	"encoding/json"
+	"slices"
 	"time"
 
 	"charm.land/bubbles/v2/key"
@@ -16,6 +18,9 @@ import (
 	"github.com/charmbracelet/crush/internal/session"
 	"github.com/charmbracelet/crush/internal/tui/components/chat/messages"
 	"github.com/charmbracelet/crush/internal/tui/components/core/layout"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/deletemessage"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/messageactions"
 	"github.com/charmbracelet/crush/internal/tui/exp/list"
 	"github.com/charmbracelet/crush/internal/tui/styles"
 	"github.com/charmbracelet/crush/internal/tui/util"
@@ -30,6 +35,23 @@ type SessionSelectedMsg = session.Session
 
 type SessionClearedMsg struct{}
 
+// ToggleExcludedMsg asks to exclude a message from the context sent to the
+// model, or to include it back.
+type ToggleExcludedMsg struct {
+	MessageID string
+}
+
+// ReloadSessionMsg reloads the messages of the open session, after they were
+// changed in ways the list can't follow from message events alone.
+type ReloadSessionMsg struct{}
+
+// ScrollToTopMsg and ScrollToBottomMsg scroll the messages to the start or
+// the end of the conversation.
+type (
+	ScrollToTopMsg    struct{}
+	ScrollToBottomMsg struct{}
+)
+
 type SelectionCopyMsg struct {
 	clickCount   int
 	endSelection bool
@@ -50,6 +72,7 @@ type MessageListCmp interface {
 
 	SetSession(session.Session) tea.Cmd
 	GoToBottom() tea.Cmd
+	ScrollOffset() int
 	GetSelectedText() string
 	CopySelectedText(bool) tea.Cmd
 }
@@ -67,6 +90,10 @@ type messageListCmp struct {
 	lastUserMessageTime int64
 	defaultListKeyMap   list.KeyMap
 
+	// pendingScroll is a scroll offset restored from the session, applied
+	// once the list has a size.
+	pendingScroll int
+
 	// Click tracking for double/triple click detection
 	lastClickTime time.Time
 	lastClickX    int
@@ -104,6 +131,25 @@ func (m *messageListCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 	switch msg := msg.(type) {
 	case tea.KeyPressMsg:
+		if m.listCmp.IsFocused() && key.Matches(msg, messages.ActionsKey) {
+			if summary, ok := m.selectedTurnSummary(); ok {
+				summary.ToggleExpanded()
+				return m, m.listCmp.UpdateItem(summary.ID(), summary)
+			}
+			return m, m.openActions()
+		}
+		if m.listCmp.IsFocused() && key.Matches(msg, messages.ExpandKey) {
+			return m, m.toggleExpanded()
+		}
+		if m.listCmp.IsFocused() && key.Matches(msg, messages.DeleteKey) {
+			return m, m.confirmDelete()
+		}
+		if m.listCmp.IsFocused() && key.Matches(msg, messages.ExcludeKey) {
+			return m, m.toggleExcluded()
+		}
+		if m.listCmp.IsFocused() && key.Matches(msg, messages.DiagramKey) {
+			return m, m.renderDiagrams()
+		}
 		if m.listCmp.IsFocused() && m.listCmp.HasSelection() {
 			switch {
 			case key.Matches(msg, messages.CopyKey):
@@ -184,13 +230,30 @@ func (m *messageListCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 	case pubsub.Event[permission.PermissionNotification]:
 		cmds = append(cmds, m.handlePermissionRequest(msg.Payload))
 		return m, tea.Batch(cmds...)
+	case pubsub.Event[tools.OutputEvent]:
+		items := m.listCmp.Items()
+		if toolCallIndex := m.findToolCallByID(items, msg.Payload.ToolCallID); toolCallIndex != NotFound {
+			toolCall := items[toolCallIndex].(messages.ToolCallCmp)
+			toolCall.SetLiveOutput(msg.Payload.Tail, msg.Payload.Bytes)
+			m.listCmp.UpdateItem(toolCall.ID(), toolCall)
+		}
+		return m, tea.Batch(cmds...)
 	case SessionSelectedMsg:
 		if msg.ID != m.session.ID {
 			cmds = append(cmds, m.SetSession(msg))
 		}
 		return m, tea.Batch(cmds...)
+	case ReloadSessionMsg:
+		return m, m.reload()
+	case messages.PluginRenderedMsg:
+		return m, m.handlePluginRendered(msg.ToolCallID)
+	case ScrollToTopMsg:
+		return m, m.listCmp.GoToTop()
+	case ScrollToBottomMsg:
+		return m, m.listCmp.GoToBottom()
 	case SessionClearedMsg:
 		m.session = session.Session{}
+		m.pendingScroll = 0
 		cmds = append(cmds, m.listCmp.SetItems([]list.Item{}))
 		return m, tea.Batch(cmds...)
 
@@ -237,7 +300,9 @@ func (m *messageListCmp) handlePermissionRequest(permission permission.Permissio
 // handleChildSession handles messages from child sessions (agent tools).
 func (m *messageListCmp) handleChildSession(event pubsub.Event[message.Message]) tea.Cmd {
 	var cmds []tea.Cmd
-	if len(event.Payload.ToolCalls()) == 0 && len(event.Payload.ToolResults()) == 0 {
+	// The last message of the session of a task ends its turn.
+	taskDone := event.Payload.Role == message.Assistant && event.Payload.FinishReason() == message.FinishReasonEndTurn
+	if len(event.Payload.ToolCalls()) == 0 && len(event.Payload.ToolResults()) == 0 && !taskDone {
 		return nil
 	}
 
@@ -288,13 +353,16 @@ func (m *messageListCmp) handleChildSession(event pubsub.Event[message.Message])
 	for _, tr := range event.Payload.ToolResults() {
 		for nestedInx, nestedTC := range nestedToolCalls {
 			if nestedTC.GetToolCall().ID == tr.ToolCallID {
-				nestedToolCalls[nestedInx].SetToolResult(tr)
+				cmds = append(cmds, nestedToolCalls[nestedInx].SetToolResult(tr))
 				break
 			}
 		}
 	}
 
-	toolCall.SetNestedToolCalls(nestedToolCalls)
+	cmds = append(cmds, toolCall.SetNestedToolCalls(nestedToolCalls))
+	if task, ok := m.app.Sessions.ParseAgentTaskSessionID(childSessionID); ok && taskDone {
+		toolCall.SetTaskDone(task)
+	}
 	m.listCmp.UpdateItem(
 		toolCall.ID(),
 		toolCall,
@@ -377,14 +445,32 @@ func (m *messageListCmp) handleNewUserMessage(msg message.Message) tea.Cmd {
 
 // handleToolMessage updates existing tool calls with their results.
 func (m *messageListCmp) handleToolMessage(msg message.Message) tea.Cmd {
+	var cmds []tea.Cmd
 	items := m.listCmp.Items()
 	for _, tr := range msg.ToolResults() {
 		if toolCallIndex := m.findToolCallByID(items, tr.ToolCallID); toolCallIndex != NotFound {
 			toolCall := items[toolCallIndex].(messages.ToolCallCmp)
-			toolCall.SetToolResult(tr)
+			cmds = append(cmds, toolCall.SetToolResult(tr))
 			m.listCmp.UpdateItem(toolCall.ID(), toolCall)
 		}
 	}
+	return tea.Batch(cmds...)
+}
+
+// handlePluginRendered shows what a plugin rendered for a tool call, or a
+// tool call nested in it.
+func (m *messageListCmp) handlePluginRendered(toolCallID string) tea.Cmd {
+	for _, item := range m.listCmp.Items() {
+		toolCall, ok := item.(messages.ToolCallCmp)
+		if !ok {
+			continue
+		}
+		if toolCall.GetToolCall().ID == toolCallID || slices.ContainsFunc(toolCall.GetNestedToolCalls(), func(nested messages.ToolCallCmp) bool {
+			return nested.GetToolCall().ID == toolCallID
+		}) {
+			return m.listCmp.UpdateItem(toolCall.ID(), toolCall)
+		}
+	}
 	return nil
 }
 
@@ -463,12 +549,16 @@ func (m *messageListCmp) updateAssistantMessageContent(msg message.Message, assi
 			uiMsg,
 		)
 		if msg.FinishPart() != nil && msg.FinishPart().Reason == message.FinishReasonEndTurn {
+			summary := m.turnSummary()
 			m.listCmp.AppendItem(
 				messages.NewAssistantSection(
 					msg,
 					time.Unix(m.lastUserMessageTime, 0),
 				),
 			)
+			if summary != nil {
+				m.listCmp.AppendItem(summary)
+			}
 		}
 	} else if hasToolCallsOnly {
 		items := m.listCmp.Items()
@@ -478,6 +568,26 @@ func (m *messageListCmp) updateAssistantMessageContent(msg message.Message, assi
 	return cmd
 }
 
+// turnSummary returns the summary of the turn ending, built from the tool
+// calls listed since the last user message, or nil when it made few calls.
+func (m *messageListCmp) turnSummary() messages.TurnSummaryCmp {
+	items := m.listCmp.Items()
+	var calls []message.ToolCall
+	results := make(map[string]message.ToolResult)
+	for i := len(items) - 1; i >= 0; i-- {
+		if msg, ok := items[i].(messages.MessageCmp); ok && msg.GetMessage().Role == message.User {
+			break
+		}
+		if tc, ok := items[i].(messages.ToolCallCmp); ok {
+			call := tc.GetToolCall()
+			calls = append(calls, call)
+			results[call.ID] = tc.GetToolResult()
+		}
+	}
+	slices.Reverse(calls)
+	return messages.NewTurnSummaryCmp(calls, results)
+}
+
 // shouldShowAssistantMessage determines if an assistant message should be displayed.
 func (m *messageListCmp) shouldShowAssistantMessage(msg message.Message) bool {
 	return len(msg.ToolCalls()) == 0 || msg.Content().Text != "" || msg.ReasoningContent().Thinking != "" || msg.IsThinking()
@@ -544,6 +654,7 @@ func (m *messageListCmp) SetSession(session session.Session) tea.Cmd {
 	}
 
 	m.session = session
+	m.pendingScroll = int(session.UI.ScrollOffset)
 	sessionMessages, err := m.app.Messages.List(context.Background(), session.ID)
 	if err != nil {
 		return util.ReportError(err)
@@ -562,7 +673,26 @@ func (m *messageListCmp) SetSession(session session.Session) tea.Cmd {
 	// Convert messages to UI components
 	uiMessages := m.convertMessagesToUI(sessionMessages, toolResultMap)
 
-	return m.listCmp.SetItems(uiMessages)
+	return tea.Batch(m.listCmp.SetItems(uiMessages), m.restoreScroll())
+}
+
+// restoreScroll scrolls to the offset saved with the session, if any. It
+// does nothing until the list has been sized.
+func (m *messageListCmp) restoreScroll() tea.Cmd {
+	if m.pendingScroll == 0 {
+		return nil
+	}
+	if _, height := m.listCmp.GetSize(); height <= 0 {
+		return nil
+	}
+	n := m.pendingScroll
+	m.pendingScroll = 0
+	return tea.Batch(m.listCmp.GoToBottom(), m.listCmp.MoveUp(n))
+}
+
+// ScrollOffset implements MessageListCmp.
+func (m *messageListCmp) ScrollOffset() int {
+	return m.listCmp.OffsetFromBottom()
 }
 
 // buildToolResultMap creates a map of tool call ID to tool result for efficient lookup.
@@ -579,16 +709,22 @@ func (m *messageListCmp) buildToolResultMap(messages []message.Message) map[stri
 // convertMessagesToUI converts database messages to UI components.
 func (m *messageListCmp) convertMessagesToUI(sessionMessages []message.Message, toolResultMap map[string]message.ToolResult) []list.Item {
 	uiMessages := make([]list.Item, 0)
+	var turnCalls []message.ToolCall
 
 	for _, msg := range sessionMessages {
 		switch msg.Role {
 		case message.User:
 			m.lastUserMessageTime = msg.CreatedAt
+			turnCalls = nil
 			uiMessages = append(uiMessages, messages.NewMessageCmp(msg))
 		case message.Assistant:
 			uiMessages = append(uiMessages, m.convertAssistantMessage(msg, toolResultMap)...)
+			turnCalls = append(turnCalls, msg.ToolCalls()...)
 			if msg.FinishPart() != nil && msg.FinishPart().Reason == message.FinishReasonEndTurn {
 				uiMessages = append(uiMessages, messages.NewAssistantSection(msg, time.Unix(m.lastUserMessageTime, 0)))
+				if summary := messages.NewTurnSummaryCmp(turnCalls, toolResultMap); summary != nil {
+					uiMessages = append(uiMessages, summary)
+				}
 			}
 		}
 	}
@@ -616,24 +752,48 @@ func (m *messageListCmp) convertAssistantMessage(msg message.Message, toolResult
 		uiMessages = append(uiMessages, messages.NewToolCallCmp(msg.ID, tc, m.app.Permissions, options...))
 		// If this tool call is the agent tool or agentic fetch, fetch nested tool calls
 		if tc.Name == agent.AgentToolName || tc.Name == tools.AgenticFetchToolName {
+			toolCall := uiMessages[len(uiMessages)-1].(messages.ToolCallCmp)
 			agentToolSessionID := m.app.Sessions.CreateAgentToolSessionID(msg.ID, tc.ID)
-			nestedMessages, _ := m.app.Messages.List(context.Background(), agentToolSessionID)
-			nestedToolResultMap := m.buildToolResultMap(nestedMessages)
-			nestedUIMessages := m.convertMessagesToUI(nestedMessages, nestedToolResultMap)
-			nestedToolCalls := make([]messages.ToolCallCmp, 0, len(nestedUIMessages))
-			for _, nestedMsg := range nestedUIMessages {
-				if toolCall, ok := nestedMsg.(messages.ToolCallCmp); ok {
-					toolCall.SetIsNested(true)
-					nestedToolCalls = append(nestedToolCalls, toolCall)
+			nestedToolCalls, _ := m.nestedToolCalls(agentToolSessionID)
+			// Each task of an agent tool call has a session of its own.
+			var params agent.AgentParams
+			if tc.Name == agent.AgentToolName && json.Unmarshal([]byte(tc.Input), &params) == nil {
+				for i := range params.Tasks {
+					taskToolCalls, done := m.nestedToolCalls(m.app.Sessions.CreateAgentTaskSessionID(msg.ID, tc.ID, i))
+					nestedToolCalls = append(nestedToolCalls, taskToolCalls...)
+					if done {
+						toolCall.SetTaskDone(i)
+					}
 				}
 			}
-			uiMessages[len(uiMessages)-1].(messages.ToolCallCmp).SetNestedToolCalls(nestedToolCalls)
+			toolCall.SetNestedToolCalls(nestedToolCalls)
 		}
 	}
 
 	return uiMessages
 }
 
+// nestedToolCalls returns the tool calls made in the session of a sub-agent,
+// and whether the sub-agent is done.
+func (m *messageListCmp) nestedToolCalls(sessionID string) ([]messages.ToolCallCmp, bool) {
+	nestedMessages, _ := m.app.Messages.List(context.Background(), sessionID)
+	nestedToolResultMap := m.buildToolResultMap(nestedMessages)
+	nestedUIMessages := m.convertMessagesToUI(nestedMessages, nestedToolResultMap)
+	nestedToolCalls := make([]messages.ToolCallCmp, 0, len(nestedUIMessages))
+	for _, nestedMsg := range nestedUIMessages {
+		if toolCall, ok := nestedMsg.(messages.ToolCallCmp); ok {
+			toolCall.SetIsNested(true)
+			nestedToolCalls = append(nestedToolCalls, toolCall)
+		}
+	}
+	done := false
+	if n := len(nestedMessages); n > 0 {
+		last := nestedMessages[n-1]
+		done = last.Role == message.Assistant && last.FinishReason() == message.FinishReasonEndTurn
+	}
+	return nestedToolCalls, done
+}
+
 // buildToolCallOptions creates options for tool call components based on results and status.
 func (m *messageListCmp) buildToolCallOptions(tc message.ToolCall, msg message.Message, toolResultMap map[string]message.ToolResult) []messages.ToolCallOption {
 	var options []messages.ToolCallOption
@@ -648,6 +808,10 @@ func (m *messageListCmp) buildToolCallOptions(tc message.ToolCall, msg message.M
 		options = append(options, messages.WithToolCallCancelled())
 	}
 
+	if msg.Excluded {
+		options = append(options, messages.WithToolCallExcluded())
+	}
+
 	return options
 }
 
@@ -660,7 +824,8 @@ func (m *messageListCmp) GetSize() (int, int) {
 func (m *messageListCmp) SetSize(width int, height int) tea.Cmd {
 	m.width = width
 	m.height = height
-	return m.listCmp.SetSize(width-2, max(0, height-1)) // for padding
+	cmd := m.listCmp.SetSize(width-2, max(0, height-1)) // for padding
+	return tea.Batch(cmd, m.restoreScroll())
 }
 
 // Blur implements MessageListCmp.
@@ -682,6 +847,140 @@ func (m *messageListCmp) Bindings() []key.Binding {
 	return m.defaultListKeyMap.KeyBindings()
 }
 
+// selectedTurnSummary returns the focused turn summary, if a summary is
+// focused.
+func (m *messageListCmp) selectedTurnSummary() (messages.TurnSummaryCmp, bool) {
+	selected := m.listCmp.SelectedItem()
+	if selected == nil {
+		return nil, false
+	}
+	summary, ok := (*selected).(messages.TurnSummaryCmp)
+	return summary, ok
+}
+
+// toggleExpanded expands or collapses the focused turn summary, or the
+// sub-thread of the focused tool call when it spawned a sub-agent.
+func (m *messageListCmp) toggleExpanded() tea.Cmd {
+	selected := m.listCmp.SelectedItem()
+	if selected == nil {
+		return nil
+	}
+	switch item := (*selected).(type) {
+	case messages.TurnSummaryCmp:
+		item.ToggleExpanded()
+		return m.listCmp.UpdateItem(item.ID(), item)
+	case messages.ToolCallCmp:
+		if len(item.GetNestedToolCalls()) == 0 {
+			return nil
+		}
+		item.ToggleNestedToolCalls()
+		return m.listCmp.UpdateItem(item.ID(), item)
+	}
+	return nil
+}
+
+// openActions opens the actions menu of the focused message. Tool calls
+// act on the assistant message that made them.
+func (m *messageListCmp) openActions() tea.Cmd {
+	selected := m.listCmp.SelectedItem()
+	if selected == nil {
+		return nil
+	}
+	workingDir := m.app.Config().WorkingDir()
+	switch item := (*selected).(type) {
+	case messages.MessageCmp:
+		msg := item.GetMessage()
+		if msg.ID == "" {
+			return nil
+		}
+		return util.CmdHandler(dialogs.OpenDialogMsg{
+			Model: messageactions.NewMessageActionsDialog(msg, "", workingDir),
+		})
+	case messages.ToolCallCmp:
+		messageID, toolCallID := item.ParentMessageID(), item.GetToolCall().ID
+		return func() tea.Msg {
+			msg, err := m.app.Messages.Get(context.Background(), messageID)
+			if err != nil {
+				return util.ReportError(err)()
+			}
+			return dialogs.OpenDialogMsg{
+				Model: messageactions.NewMessageActionsDialog(msg, toolCallID, workingDir),
+			}
+		}
+	}
+	return nil
+}
+
+// renderDiagrams renders the diagrams of the focused message and opens
+// them.
+func (m *messageListCmp) renderDiagrams() tea.Cmd {
+	selected := m.listCmp.SelectedItem()
+	if selected == nil {
+		return nil
+	}
+	item, ok := (*selected).(messages.MessageCmp)
+	if !ok {
+		return nil
+	}
+	return messages.RenderDiagrams(item.GetMessage())
+}
+
+// confirmDelete asks to confirm deleting the focused message or tool call.
+func (m *messageListCmp) confirmDelete() tea.Cmd {
+	selected := m.listCmp.SelectedItem()
+	if selected == nil {
+		return nil
+	}
+	var target deletemessage.Target
+	switch item := (*selected).(type) {
+	case messages.MessageCmp:
+		msg := item.GetMessage()
+		if msg.ID == "" {
+			return nil
+		}
+		target = deletemessage.MessageTarget(msg)
+	case messages.ToolCallCmp:
+		target = deletemessage.ToolCallTarget(m.session.ID, item.ParentMessageID(), item.GetToolCall())
+	default:
+		return nil
+	}
+	return util.CmdHandler(dialogs.OpenDialogMsg{
+		Model: deletemessage.NewDeleteMessageDialog(target),
+	})
+}
+
+// toggleExcluded excludes the focused message from the context sent to the
+// model, or includes it back. Tool calls go along with the message that made
+// them.
+func (m *messageListCmp) toggleExcluded() tea.Cmd {
+	selected := m.listCmp.SelectedItem()
+	if selected == nil {
+		return nil
+	}
+	var messageID string
+	switch item := (*selected).(type) {
+	case messages.MessageCmp:
+		messageID = item.GetMessage().ID
+	case messages.ToolCallCmp:
+		messageID = item.ParentMessageID()
+	}
+	if messageID == "" {
+		return nil
+	}
+	return func() tea.Msg {
+		return ToggleExcludedMsg{MessageID: messageID}
+	}
+}
+
+// reload reloads the messages of the open session, keeping the scroll
+// position.
+func (m *messageListCmp) reload() tea.Cmd {
+	sess := m.session
+	sess.UI.ScrollOffset = int64(m.ScrollOffset())
+	m.session = session.Session{}
+	return m.SetSession(sess)
+}
+
 func (m *messageListCmp) GoToBottom() tea.Cmd {
 	return m.listCmp.GoToBottom()
 }
@@ -1,9 +1,11 @@
 package editor
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"math/rand"
 	"net/http"
 	"os"
@@ -12,6 +14,7 @@ import (
 	"slices"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 
 	"charm.land/bubbles/v2/key"
 	"charm.land/bubbles/v2/textarea"
@@ -19,15 +22,27 @@ import (
 	"charm.land/lipgloss/v2"
 	"github.com/charmbracelet/crush/internal/app"
 	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/home"
+	"github.com/charmbracelet/crush/internal/i18n"
 	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/privacy"
+	"github.com/charmbracelet/crush/internal/prompthistory"
+	"github.com/charmbracelet/crush/internal/screenshot"
 	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/crush/internal/snippets"
+	"github.com/charmbracelet/crush/internal/spellcheck"
+	"github.com/charmbracelet/crush/internal/symbols"
 	"github.com/charmbracelet/crush/internal/tui/components/chat"
 	"github.com/charmbracelet/crush/internal/tui/components/completions"
 	"github.com/charmbracelet/crush/internal/tui/components/core/layout"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/attachments"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/commands"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/filepicker"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/paste"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/prompts"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/quit"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/remember"
 	"github.com/charmbracelet/crush/internal/tui/styles"
 	"github.com/charmbracelet/crush/internal/tui/util"
 	"github.com/rivo/uniseg"
@@ -45,6 +60,7 @@ type Editor interface {
 	IsCompletionsOpen() bool
 	HasAttachments() bool
 	IsEmpty() bool
+	Draft() string
 	Cursor() *tea.Cursor
 }
 
@@ -52,6 +68,15 @@ type FileCompletionItem struct {
 	Path string // The file path
 }
 
+// SymbolCompletionItem is a symbol of the workspace, inserted as a reference
+// to where it's defined.
+type SymbolCompletionItem struct {
+	Symbol symbols.Symbol
+}
+
+// maxSymbolCompletions keeps the completions of huge workspaces responsive.
+const maxSymbolCompletions = 5000
+
 type editorCmp struct {
 	width              int
 	height             int
@@ -60,6 +85,7 @@ type editorCmp struct {
 	session            session.Session
 	textarea           textarea.Model
 	attachments        []message.Attachment
+	quote              *message.Message
 	deleteMode         bool
 	readyPlaceholder   string
 	workingPlaceholder string
@@ -68,9 +94,25 @@ type editorCmp struct {
 
 	// File path completions
 	currentQuery              string
-	completionsStartIndex     int // Display width for curIdx comparison
-	completionsStartByteIndex int // Byte index for string slicing
+	completionsStartByteIndex int // Byte offset of the @ of the query in the value
 	isCompletionsOpen         bool
+
+	// pastes are the attachments holding large blocks of pasted text, by
+	// path.
+	pastes map[string]bool
+
+	// Spellcheck
+	words    *spellcheck.Dictionary
+	typos    []spellcheck.Suggestion
+	typosFor string // The prompt the typos were found in
+
+	// Prompt history, browsed with up and down from an empty editor
+	history      []prompthistory.Entry
+	historyIndex int // The entry shown, -1 when not browsing
+
+	// expansions are the snippets expanded in the prompt, highlighted while
+	// they're in it.
+	expansions []string
 }
 
 var DeleteKeyMaps = DeleteAttachmentKeyMaps{
@@ -86,15 +128,28 @@ var DeleteKeyMaps = DeleteAttachmentKeyMaps{
 		key.WithKeys("r"),
 		key.WithHelp("ctrl+r+r", "delete all attachments"),
 	),
+	DeleteQuote: key.NewBinding(
+		key.WithKeys("q"),
+		key.WithHelp("ctrl+r+q", "remove quote"),
+	),
 }
 
 const maxFileResults = 25
 
+// promptWidth is the width of the prompt before each line of the textarea.
+const promptWidth = 4
+
 type OpenEditorMsg struct {
 	Text string
 }
 
-func (m *editorCmp) openEditor(value string) tea.Cmd {
+// QuoteMsg makes the prompt being written a reply to an earlier message.
+type QuoteMsg struct {
+	Message message.Message
+}
+
+// externalEditor returns the command of the user's editor.
+func externalEditor() string {
 	editor := os.Getenv("EDITOR")
 	if editor == "" {
 		// Use platform-appropriate default editor
@@ -104,6 +159,27 @@ func (m *editorCmp) openEditor(value string) tea.Cmd {
 			editor = "nvim"
 		}
 	}
+	return editor
+}
+
+// OpenFile opens a file in the user's editor.
+func OpenFile(path string) tea.Cmd {
+	cmdStr := externalEditor() + " " + shellQuote(path)
+	return util.ExecShell(context.TODO(), cmdStr, func(err error) tea.Msg {
+		if err != nil {
+			return util.ReportError(err)()
+		}
+		return nil
+	})
+}
+
+// shellQuote quotes s as a single shell word.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func (m *editorCmp) openEditor(value string) tea.Cmd {
+	editor := externalEditor()
 
 	tmpfile, err := os.CreateTemp("", "msg_*.md")
 	if err != nil {
@@ -144,6 +220,38 @@ func (m *editorCmp) send() tea.Cmd {
 	case "exit", "quit":
 		m.textarea.Reset()
 		return util.CmdHandler(dialogs.OpenDialogMsg{Model: quit.NewQuitDialog()})
+	case "/compact":
+		if m.session.ID == "" {
+			return util.ReportWarn("No session to compact")
+		}
+		m.textarea.Reset()
+		return util.CmdHandler(commands.CompactMsg{SessionID: m.session.ID, Small: true})
+	}
+
+	if instruction, ok := rememberedInstruction(value); ok {
+		if instruction == "" {
+			return util.ReportWarn("Write the instruction to remember after /memory")
+		}
+		m.textarea.Reset()
+		return util.CmdHandler(dialogs.OpenDialogMsg{Model: remember.NewRememberDialog(instruction)})
+	}
+
+	if path, ok := strings.CutPrefix(value, "/cd"); ok && (path == "" || path[0] == ' ') {
+		if m.session.ID == "" {
+			return util.ReportWarn("Send a message first, the working directory is set per session")
+		}
+		m.textarea.Reset()
+		return util.CmdHandler(commands.ChangeDirMsg{SessionID: m.session.ID, Path: strings.TrimSpace(path)})
+	}
+
+	if target, ok := strings.CutPrefix(value, "/screenshot"); ok && (target == "" || target[0] == ' ') {
+		switch target = strings.TrimSpace(target); target {
+		case "", string(screenshot.Screen), string(screenshot.Window):
+		default:
+			return util.ReportWarn("Use /screenshot to capture the screen, or /screenshot window")
+		}
+		m.textarea.Reset()
+		return util.CmdHandler(commands.TakeScreenshotMsg{Target: cmp.Or(screenshot.Target(target), screenshot.Screen)})
 	}
 
 	attachments := m.attachments
@@ -152,8 +260,29 @@ func (m *editorCmp) send() tea.Cmd {
 		return nil
 	}
 
+	if m.checkSpelling(m.textarea.Value()) {
+		return nil
+	}
+
+	value, _ = snippets.Expand(value, m.app.Config().Options.TUI.Snippets)
+
+	// The history keeps the prompt as written, without the quote.
+	raw := value
+	if m.quote != nil {
+		value = message.QuotePrompt(*m.quote, value)
+	}
+
+	if err := m.app.Prompts.Add(raw); err != nil {
+		slog.Warn("Failed to save prompt to history", "error", err)
+	}
+
 	m.textarea.Reset()
 	m.attachments = nil
+	clear(m.pastes)
+	m.quote = nil
+	m.typos, m.typosFor = nil, ""
+	m.history, m.historyIndex = nil, -1
+	m.expansions = nil
 	// Change the placeholder when sending a new message.
 	m.randomizePlaceholders()
 
@@ -165,6 +294,19 @@ func (m *editorCmp) send() tea.Cmd {
 	)
 }
 
+// rememberedInstruction returns the instruction of a message asking to
+// remember it: /memory followed by the instruction, or a single line
+// starting with #.
+func rememberedInstruction(value string) (string, bool) {
+	if rest, ok := strings.CutPrefix(value, "/memory"); ok && (rest == "" || rest[0] == ' ' || rest[0] == '\n') {
+		return strings.TrimSpace(rest), true
+	}
+	if rest, ok := strings.CutPrefix(value, "# "); ok && !strings.Contains(rest, "\n") {
+		return strings.TrimSpace(rest), true
+	}
+	return "", false
+}
+
 func (m *editorCmp) repositionCompletions() tea.Msg {
 	x, y := m.completionsPosition()
 	return completions.RepositionCompletionsMsg{X: x, Y: y}
@@ -173,6 +315,9 @@ func (m *editorCmp) repositionCompletions() tea.Msg {
 func (m *editorCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	var cmds []tea.Cmd
+	// The key press passed on to the textarea, with the full-width forms
+	// typed by IMEs replaced.
+	var keyMsg tea.Msg
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		return m, m.repositionCompletions
@@ -184,7 +329,6 @@ func (m *editorCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 	case completions.CompletionsClosedMsg:
 		m.isCompletionsOpen = false
 		m.currentQuery = ""
-		m.completionsStartIndex = 0
 	case completions.SelectCompletionMsg:
 		if !m.isCompletionsOpen {
 			return m, nil
@@ -202,7 +346,6 @@ func (m *editorCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 			if !msg.Insert {
 				m.isCompletionsOpen = false
 				m.currentQuery = ""
-				m.completionsStartIndex = 0
 				m.completionsStartByteIndex = 0
 			}
 			content, err := os.ReadFile(item.Path)
@@ -217,6 +360,19 @@ func (m *editorCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 				Content:  content,
 			})
 		}
+		if item, ok := msg.Value.(SymbolCompletionItem); ok {
+			word := m.textarea.Word()
+			ref := fmt.Sprintf("%s (%s:%d)", item.Symbol.QualifiedName(), item.Symbol.Path, item.Symbol.Line)
+			value := m.textarea.Value()
+			value = value[:m.completionsStartByteIndex] + ref + value[m.completionsStartByteIndex+len(word):]
+			m.textarea.SetValue(value)
+			m.textarea.MoveToEnd()
+			if !msg.Insert {
+				m.isCompletionsOpen = false
+				m.currentQuery = ""
+				m.completionsStartByteIndex = 0
+			}
+		}
 
 	case commands.OpenExternalEditorMsg:
 		if m.app.AgentCoordinator.IsSessionBusy(m.session.ID) {
@@ -226,8 +382,36 @@ func (m *editorCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 	case OpenEditorMsg:
 		m.textarea.SetValue(msg.Text)
 		m.textarea.MoveToEnd()
+	case prompts.EditMsg:
+		m.textarea.SetValue(msg.Prompt)
+		m.textarea.MoveToEnd()
+		return m, nil
+	case prompts.RunMsg:
+		m.textarea.SetValue(msg.Prompt)
+		return m, m.send()
+	case QuoteMsg:
+		m.quote = &msg.Message
+	case paste.ExpandMsg:
+		if i := slices.IndexFunc(m.attachments, func(a message.Attachment) bool { return a.FilePath == msg.Path }); i >= 0 {
+			m.textarea.InsertString(string(m.attachments[i].Content))
+			m.attachments = slices.Delete(m.attachments, i, i+1)
+			delete(m.pastes, msg.Path)
+		}
+		return m, nil
+	case attachments.UpdateMsg:
+		m.attachments = msg.Attachments
+		for path := range m.pastes {
+			if !slices.ContainsFunc(m.attachments, func(a message.Attachment) bool { return a.FilePath == path }) {
+				delete(m.pastes, path)
+			}
+		}
+		return m, nil
+	case paste.RemoveMsg:
+		m.attachments = slices.DeleteFunc(m.attachments, func(a message.Attachment) bool { return a.FilePath == msg.Path })
+		delete(m.pastes, msg.Path)
+		return m, nil
 	case tea.PasteMsg:
-		content, path, err := pasteToFile(msg)
+		content, path, pasted, err := pasteToFile(msg)
 		if errors.Is(err, errNotAFile) {
 			m.textarea, cmd = m.textarea.Update(msg)
 			return m, cmd
@@ -250,7 +434,13 @@ func (m *editorCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		if !attachment.IsText() && !attachment.IsImage() {
 			return m, util.ReportWarn("Invalid file content type: " + mimeType)
 		}
-		m.textarea.InsertString(attachment.FileName)
+		// Large blocks of text are held as a chip instead of flooding the
+		// editor.
+		if pasted {
+			m.pastes[path] = true
+		} else {
+			m.textarea.InsertString(attachment.FileName)
+		}
 		return m, util.CmdHandler(filepicker.FilePickedMsg{
 			Attachment: attachment,
 		})
@@ -259,8 +449,9 @@ func (m *editorCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		m.setEditorPrompt()
 		return m, nil
 	case tea.KeyPressMsg:
-		cur := m.textarea.Cursor()
-		curIdx := m.textarea.Width()*cur.Y + cur.X
+		msg = m.halfWidth(msg)
+		keyMsg = msg
+		curIdx := cursorOffset(&m.textarea)
 		switch {
 		// Open command palette when "/" is pressed on empty prompt
 		case msg.String() == "/" && m.IsEmpty():
@@ -270,15 +461,20 @@ func (m *editorCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		// Completions
 		case msg.String() == "@" && !m.isCompletionsOpen &&
 			// only show if beginning of prompt, or if previous char is a space or newline:
-			(len(m.textarea.Value()) == 0 || unicode.IsSpace(rune(m.textarea.Value()[len(m.textarea.Value())-1]))):
+			m.afterSpace(curIdx):
 			m.isCompletionsOpen = true
 			m.currentQuery = ""
-			m.completionsStartIndex = curIdx
-			m.completionsStartByteIndex = curIdx // Initially same when no multi-byte chars before
+			m.completionsStartByteIndex = curIdx
 			cmds = append(cmds, m.startCompletions)
-		case m.isCompletionsOpen && curIdx <= m.completionsStartIndex:
+		case m.isCompletionsOpen && curIdx <= m.completionsStartByteIndex:
 			cmds = append(cmds, util.CmdHandler(completions.CloseCompletionsMsg{}))
 		}
+		// ctrl+r deletes attachments when there are some, so searching
+		// then takes it twice.
+		if key.Matches(msg, m.keyMap.SearchHistory) && (m.deleteMode || len(m.attachments) == 0 && m.quote == nil) {
+			m.deleteMode = false
+			return m, m.searchHistory()
+		}
 		if key.Matches(msg, DeleteKeyMaps.AttachmentDeleteMode) {
 			m.deleteMode = true
 			return m, nil
@@ -288,6 +484,11 @@ func (m *editorCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 			m.attachments = nil
 			return m, nil
 		}
+		if key.Matches(msg, DeleteKeyMaps.DeleteQuote) && m.deleteMode {
+			m.deleteMode = false
+			m.quote = nil
+			return m, nil
+		}
 		rune := msg.Code
 		if m.deleteMode && unicode.IsDigit(rune) {
 			num := int(rune - '0')
@@ -301,16 +502,41 @@ func (m *editorCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 				return m, nil
 			}
 		}
+		if key.Matches(msg, m.keyMap.PreviewPaste) {
+			for _, attachment := range slices.Backward(m.attachments) {
+				if m.pastes[attachment.FilePath] {
+					return m, util.CmdHandler(dialogs.OpenDialogMsg{Model: paste.NewPasteDialog(attachment)})
+				}
+			}
+			return m, nil
+		}
+		if key.Matches(msg, m.keyMap.ReviewAttachments) && len(m.attachments) > 0 {
+			return m, util.CmdHandler(dialogs.OpenDialogMsg{Model: attachments.NewAttachmentsDialog(m.attachments)})
+		}
 		if key.Matches(msg, m.keyMap.OpenEditor) {
 			if m.app.AgentCoordinator.IsSessionBusy(m.session.ID) {
 				return m, util.ReportWarn("Agent is working, please wait...")
 			}
 			return m, m.openEditor(m.textarea.Value())
 		}
+		if key.Matches(msg, m.keyMap.FixSpelling) && m.hasTypos() {
+			m.textarea.SetValue(spellcheck.Apply(m.typosFor, m.typos))
+			m.textarea.MoveToEnd()
+			m.typos, m.typosFor = nil, ""
+			return m, nil
+		}
 		if key.Matches(msg, DeleteKeyMaps.Escape) {
 			m.deleteMode = false
+			// Dismissed typos are sent as they are.
+			m.typos = nil
+			return m, nil
+		}
+		if !m.isCompletionsOpen && m.browseHistory(msg) {
 			return m, nil
 		}
+		if msg.String() == "space" || key.Matches(msg, m.keyMap.Newline) {
+			m.expandSnippet()
+		}
 		if key.Matches(msg, m.keyMap.Newline) {
 			m.textarea.InsertRune('\n')
 			cmds = append(cmds, util.CmdHandler(completions.CloseCompletionsMsg{}))
@@ -328,6 +554,9 @@ func (m *editorCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		}
 	}
 
+	if keyMsg != nil {
+		msg = keyMsg
+	}
 	m.textarea, cmd = m.textarea.Update(msg)
 	cmds = append(cmds, cmd)
 
@@ -337,26 +566,23 @@ func (m *editorCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 			if kp.String() == "space" || m.textarea.Value() == "" {
 				m.isCompletionsOpen = false
 				m.currentQuery = ""
-				m.completionsStartIndex = 0
 				cmds = append(cmds, util.CmdHandler(completions.CloseCompletionsMsg{}))
 			} else {
 				word := m.textarea.Word()
 				if strings.HasPrefix(word, "@") {
-					// XXX: wont' work if editing in the middle of the field.
-					// Calculate the index of the word by computing the display width
-					// of the text before the word.
-					fullText := m.textarea.Value()
-					wordByteIdx := strings.LastIndex(fullText, word)
-					// Use display width instead of byte index to handle multi-byte
-					// characters like Chinese correctly.
-					m.completionsStartIndex = uniseg.StringWidth(fullText[:wordByteIdx])
-					// Store byte index for string slicing operations.
+					// The word starts after the last space before the cursor.
+					before := m.textarea.Value()[:cursorOffset(&m.textarea)]
+					wordByteIdx := 0
+					if i := strings.LastIndexFunc(before, unicode.IsSpace); i >= 0 {
+						_, size := utf8.DecodeRuneInString(before[i:])
+						wordByteIdx = i + size
+					}
 					m.completionsStartByteIndex = wordByteIdx
-					// Calculate display width for curIdx comparison to handle
-					// multi-byte characters like Chinese correctly.
 					m.currentQuery = word[1:]
+					// Open the completions under the @, measuring the wide
+					// characters of the query typed so far by their width.
 					x, y := m.completionsPosition()
-					x -= len(m.currentQuery)
+					x -= uniseg.StringWidth(before[min(wordByteIdx+1, len(before)):])
 					m.isCompletionsOpen = true
 					cmds = append(cmds,
 						util.CmdHandler(completions.FilterCompletionsMsg{
@@ -369,7 +595,6 @@ func (m *editorCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 				} else if m.isCompletionsOpen {
 					m.isCompletionsOpen = false
 					m.currentQuery = ""
-					m.completionsStartIndex = 0
 					cmds = append(cmds, util.CmdHandler(completions.CloseCompletionsMsg{}))
 				}
 			}
@@ -381,10 +606,59 @@ func (m *editorCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 
 func (m *editorCmp) setEditorPrompt() {
 	if m.app.Permissions.SkipRequests() {
-		m.textarea.SetPromptFunc(4, yoloPromptFunc)
+		m.textarea.SetPromptFunc(promptWidth, yoloPromptFunc)
 		return
 	}
-	m.textarea.SetPromptFunc(4, normalPromptFunc)
+	m.textarea.SetPromptFunc(promptWidth, normalPromptFunc)
+}
+
+// cursorOffset returns the byte offset of the cursor in the value of the
+// textarea, which counts the runes of the line the cursor is on.
+func cursorOffset(ta *textarea.Model) int {
+	lines := strings.Split(ta.Value(), "\n")
+	row := min(ta.Line(), len(lines)-1)
+	offset := 0
+	for _, line := range lines[:row] {
+		offset += len(line) + 1
+	}
+	info := ta.LineInfo()
+	runes := []rune(lines[row])
+	return offset + len(string(runes[:min(info.StartColumn+info.ColumnOffset, len(runes))]))
+}
+
+// afterSpace reports whether the byte offset is at the start of the value
+// or after a space, including the full-width space of CJK text.
+func (m *editorCmp) afterSpace(offset int) bool {
+	if offset == 0 {
+		return true
+	}
+	r, _ := utf8.DecodeLastRuneInString(m.textarea.Value()[:offset])
+	return unicode.IsSpace(r)
+}
+
+// fullWidthKeys are the full-width forms IMEs of CJK languages type for the
+// keys opening completions and the command palette. IME composition isn't
+// handled: the terminal composes the text, and the editor only gets it once
+// it's committed.
+var fullWidthKeys = map[string]rune{
+	"＠": '@',
+	"／": '/',
+}
+
+// halfWidth returns the key press typing the ASCII form of a full-width @ or
+// /, when it opens completions or the command palette, so they open without
+// switching the IME to half-width.
+func (m *editorCmp) halfWidth(msg tea.KeyPressMsg) tea.KeyPressMsg {
+	r, ok := fullWidthKeys[msg.Text]
+	if !ok {
+		return msg
+	}
+	switch {
+	case r == '/' && m.IsEmpty(), r == '@' && m.afterSpace(cursorOffset(&m.textarea)):
+		msg.Text = string(r)
+		msg.Code = r
+	}
+	return msg
 }
 
 func (m *editorCmp) completionsPosition() (int, int) {
@@ -431,27 +705,212 @@ func (m *editorCmp) View() string {
 	t := styles.CurrentTheme()
 	// Update placeholder
 	if m.app.AgentCoordinator != nil && m.app.AgentCoordinator.IsBusy() {
-		m.textarea.Placeholder = m.workingPlaceholder
+		m.textarea.Placeholder = i18n.T(m.workingPlaceholder)
 	} else {
-		m.textarea.Placeholder = m.readyPlaceholder
+		m.textarea.Placeholder = i18n.T(m.readyPlaceholder)
 	}
 	if m.app.Permissions.SkipRequests() {
-		m.textarea.Placeholder = "Yolo mode!"
+		m.textarea.Placeholder = i18n.T("Yolo mode!")
 	}
-	if len(m.attachments) == 0 {
+	if len(m.attachments) == 0 && m.quote == nil && !m.hasTypos() {
 		return t.S().Base.Padding(1, 1, 0, 1).Render(
-			m.textarea.View(),
+			m.textareaView(),
 		)
 	}
+	var header string
+	switch {
+	case m.hasTypos():
+		header = m.typosContent()
+	case m.quote == nil:
+		header = m.attachmentsContent()
+	case len(m.attachments) == 0:
+		header = m.quoteContent()
+	default:
+		header = lipgloss.JoinHorizontal(lipgloss.Left, m.quoteContent(), " ", m.attachmentsContent())
+	}
 	return t.S().Base.Padding(0, 1, 0, 1).Render(
 		lipgloss.JoinVertical(
 			lipgloss.Top,
-			m.attachmentsContent(),
-			m.textarea.View(),
+			header,
+			m.textareaView(),
 		),
 	)
 }
 
+// textareaView renders the textarea with the snippets expanded in it
+// highlighted.
+func (m *editorCmp) textareaView() string {
+	value := m.textarea.Value()
+	m.expansions = slices.DeleteFunc(m.expansions, func(expansion string) bool {
+		return !strings.Contains(value, expansion)
+	})
+	t := styles.CurrentTheme()
+	highlight := t.S().Base.Background(t.BgSubtle).Render
+	return snippets.Highlight(m.textarea.View(), m.expansions, promptWidth, func(s string) string { return highlight(s) })
+}
+
+// expandSnippet expands the snippet named by the word before the cursor.
+func (m *editorCmp) expandSnippet() {
+	value := m.textarea.Value()
+	offset := cursorOffset(&m.textarea)
+	before, expansion, ok := snippets.ExpandBefore(value, offset, m.app.Config().Options.TUI.Snippets)
+	if !ok {
+		return
+	}
+	// Insert the text up to the cursor before the rest, which leaves the
+	// cursor after the expansion.
+	m.textarea.SetValue(value[offset:])
+	m.textarea.MoveToBegin()
+	m.textarea.InsertString(before)
+	m.expansions = append(m.expansions, expansion)
+}
+
+// quoteContent renders the message the prompt replies to on a single line.
+func (m *editorCmp) quoteContent() string {
+	t := styles.CurrentTheme()
+	icon := t.S().Base.
+		Foreground(t.BgSubtle).
+		Background(t.Blue).
+		Padding(0, 1).
+		Bold(true).
+		Render(styles.QuoteIcon)
+	if m.deleteMode {
+		icon = t.S().Base.
+			Foreground(t.FgBase).
+			Background(t.Red).
+			Padding(0, 1).
+			Bold(true).
+			Render("q")
+	}
+	text, _, _ := strings.Cut(strings.TrimSpace(m.quote.Content().Text), "\n")
+	text = ansi.Truncate(text, max(10, m.width/3), "…")
+	return lipgloss.JoinHorizontal(
+		lipgloss.Left,
+		icon,
+		t.S().Base.Padding(0, 1).Background(t.FgMuted).Foreground(t.FgBase).Render(text),
+	)
+}
+
+// browseHistory shows the previous or next prompt sent in the project when up
+// or down is pressed in an empty editor, or in one showing a prompt of the
+// history as it was. It reports whether the key was used.
+func (m *editorCmp) browseHistory(msg tea.KeyPressMsg) bool {
+	older := key.Matches(msg, m.keyMap.PreviousPrompt)
+	if !older && !key.Matches(msg, m.keyMap.NextPrompt) {
+		return false
+	}
+	browsing := m.historyIndex >= 0 && m.historyIndex < len(m.history) &&
+		m.textarea.Value() == m.history[m.historyIndex].Prompt
+	if !browsing {
+		if !older || m.textarea.Value() != "" {
+			return false
+		}
+		history, err := m.app.Prompts.Project()
+		if err != nil {
+			slog.Warn("Failed to read prompt history", "error", err)
+			return false
+		}
+		if len(history) == 0 {
+			return false
+		}
+		m.history, m.historyIndex = history, len(history)
+	}
+	switch {
+	case older && m.historyIndex > 0:
+		m.historyIndex--
+	case older:
+		// The oldest prompt stays.
+		return true
+	case m.historyIndex < len(m.history)-1:
+		m.historyIndex++
+	default:
+		// Past the newest prompt the editor is empty again.
+		m.history, m.historyIndex = nil, -1
+		m.textarea.Reset()
+		return true
+	}
+	m.textarea.SetValue(m.history[m.historyIndex].Prompt)
+	m.textarea.MoveToEnd()
+	return true
+}
+
+// searchHistory opens the search of the prompts sent in all projects.
+func (m *editorCmp) searchHistory() tea.Cmd {
+	entries, err := m.app.Prompts.All()
+	if err != nil {
+		return util.ReportError(err)
+	}
+	if len(entries) == 0 {
+		return util.ReportInfo("No prompts sent yet")
+	}
+	return util.CmdHandler(dialogs.OpenDialogMsg{
+		Model: prompts.NewPromptsDialog(entries, m.app.Config().WorkingDir()),
+	})
+}
+
+// typosContent renders the likely typos of the prompt and their fixes on a
+// single line.
+func (m *editorCmp) typosContent() string {
+	t := styles.CurrentTheme()
+	icon := t.S().Base.
+		Foreground(t.BgSubtle).
+		Background(t.Warning).
+		Padding(0, 1).
+		Bold(true).
+		Render(styles.WarningIcon)
+	fixes := make([]string, 0, len(m.typos))
+	for _, typo := range m.typos {
+		fixes = append(fixes, t.S().Error.Strikethrough(true).Render(typo.Word)+
+			t.S().Subtle.Render(" "+styles.ArrowRightIcon+" ")+
+			t.S().Success.Render(typo.Fix))
+	}
+	hint := t.S().Subtle.Render(m.keyMap.FixSpelling.Help().Key + " fix · enter send as is")
+	line := icon + " " + strings.Join(fixes, t.S().Subtle.Render(", ")) + "  " + hint
+	return ansi.Truncate(line, max(10, m.width-2), "…")
+}
+
+// hasTypos reports whether the prompt has typos to review, found since it
+// was last changed.
+func (m *editorCmp) hasTypos() bool {
+	return len(m.typos) > 0 && m.textarea.Value() == m.typosFor
+}
+
+// checkSpelling looks for likely typos of the prompt when the spellcheck is
+// enabled, reporting whether there are some to review before sending it.
+// Sending the same prompt again sends it as it is.
+func (m *editorCmp) checkSpelling(prompt string) bool {
+	if !m.app.Config().Options.TUI.Spellcheck.Enabled || prompt == m.typosFor {
+		return false
+	}
+	m.typos = m.dictionary().Check(prompt)
+	m.typosFor = prompt
+	return len(m.typos) > 0
+}
+
+// dictionary returns the words the prompt is checked against, with the
+// identifiers of the workspace as they are now.
+func (m *editorCmp) dictionary() *spellcheck.Dictionary {
+	if m.words == nil {
+		opts := m.app.Config().Options.TUI.Spellcheck
+		m.words = spellcheck.NewDictionary()
+		words := spellcheck.SystemWords()
+		if opts.Dictionary != "" {
+			var err error
+			if words, err = spellcheck.LoadWords(home.Long(opts.Dictionary)); err != nil {
+				slog.Warn("Failed to read the spellcheck dictionary", "path", opts.Dictionary, "error", err)
+			}
+		}
+		m.words.AddWords(words...)
+		m.words.AddWords(opts.Words...)
+	}
+	if index := symbols.Default(); index != nil {
+		for _, symbol := range index.Symbols(m.app.Config().PrivacyZones().Contains) {
+			m.words.AddIdentifiers(symbol.Name, symbol.Container)
+		}
+	}
+	return m.words
+}
+
 func (m *editorCmp) SetSize(width, height int) tea.Cmd {
 	m.width = width
 	m.height = height
@@ -487,6 +946,9 @@ func (m *editorCmp) attachmentsContent() string {
 		Render
 	for i, attachment := range m.attachments {
 		filename := ansi.Truncate(filepath.Base(attachment.FileName), 10, "...")
+		if m.pastes[attachment.FilePath] {
+			filename = paste.Label(attachment.Content)
+		}
 		icon := styles.ImageIcon
 		if attachment.IsText() {
 			icon = styles.TextIcon
@@ -514,10 +976,11 @@ func (m *editorCmp) SetPosition(x, y int) tea.Cmd {
 	return nil
 }
 
-func (m *editorCmp) startCompletions() tea.Msg {
-	ls := m.app.Config().Options.TUI.Completions
-	depth, limit := ls.Limits()
+// fileCompletions lists the files of the working directory to complete
+// after @, leaving out the ones in privacy zones.
+func fileCompletions(zones *privacy.Zones, depth, limit int) []completions.Completion {
 	files, _, _ := fsext.ListDirectory(".", nil, depth, limit)
+	files, _ = zones.Filter(files)
 	slices.Sort(files)
 	completionItems := make([]completions.Completion, 0, len(files))
 	for _, file := range files {
@@ -529,6 +992,24 @@ func (m *editorCmp) startCompletions() tea.Msg {
 			},
 		})
 	}
+	return completionItems
+}
+
+func (m *editorCmp) startCompletions() tea.Msg {
+	ls := m.app.Config().Options.TUI.Completions
+	depth, limit := ls.Limits()
+	zones := m.app.Config().PrivacyZones()
+	completionItems := fileCompletions(zones, depth, limit)
+
+	if index := symbols.Default(); index != nil {
+		all := index.Symbols(zones.Contains)
+		for _, symbol := range all[:min(len(all), maxSymbolCompletions)] {
+			completionItems = append(completionItems, completions.Completion{
+				Title: fmt.Sprintf("%s %s:%d", symbol.QualifiedName(), symbol.Path, symbol.Line),
+				Value: SymbolCompletionItem{Symbol: symbol},
+			})
+		}
+	}
 
 	x, y := m.completionsPosition()
 	return completions.OpenCompletionsMsg{
@@ -563,6 +1044,12 @@ func (c *editorCmp) Bindings() []key.Binding {
 // TODO: most likely we do not need to have the session here
 // we need to move some functionality to the page level
 func (c *editorCmp) SetSession(session session.Session) tea.Cmd {
+	// Restore the draft saved with the session, unless this is a fresh
+	// session taking over text typed before it existed.
+	if c.session.ID != session.ID && (c.session.ID != "" || session.UI.Draft != "") {
+		c.textarea.SetValue(session.UI.Draft)
+		c.textarea.MoveToEnd()
+	}
 	c.session = session
 	return nil
 }
@@ -579,6 +1066,11 @@ func (c *editorCmp) IsEmpty() bool {
 	return strings.TrimSpace(c.textarea.Value()) == ""
 }
 
+// Draft returns the text typed in the editor but not sent yet.
+func (c *editorCmp) Draft() string {
+	return c.textarea.Value()
+}
+
 func normalPromptFunc(info textarea.PromptInfo) string {
 	t := styles.CurrentTheme()
 	if info.LineNumber == 0 {
@@ -618,9 +1110,11 @@ func New(app *app.App) Editor {
 	ta.Focus()
 	e := &editorCmp{
 		// TODO: remove the app instance from here
-		app:      app,
-		textarea: ta,
-		keyMap:   DefaultEditorKeyMap(),
+		app:          app,
+		textarea:     ta,
+		keyMap:       DefaultEditorKeyMap(),
+		pastes:       make(map[string]bool),
+		historyIndex: -1,
 	}
 	e.setEditorPrompt()
 
@@ -634,17 +1128,20 @@ var maxAttachmentSize = 5 * 1024 * 1024 // 5MB
 
 var errNotAFile = errors.New("not a file")
 
-func pasteToFile(msg tea.PasteMsg) ([]byte, string, error) {
+// pasteToFile returns the file whose path was pasted, or the pasted text
+// saved to a file when it's a large block, reporting whether it was pasted.
+func pasteToFile(msg tea.PasteMsg) ([]byte, string, bool, error) {
 	content, path, err := filepathToFile(msg.Content)
 	if err == nil {
-		return content, path, err
+		return content, path, false, err
 	}
 
 	if strings.Count(msg.Content, "\n") > 2 {
-		return contentToFile([]byte(msg.Content))
+		content, path, err := contentToFile([]byte(msg.Content))
+		return content, path, true, err
 	}
 
-	return nil, "", errNotAFile
+	return nil, "", false, errNotAFile
 }
 
 func contentToFile(content []byte) ([]byte, string, error) {
@@ -0,0 +1,130 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"charm.land/bubbles/v2/textarea"
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/privacy"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestEditor(width int) *editorCmp {
+	ta := textarea.New()
+	ta.ShowLineNumbers = false
+	ta.CharLimit = -1
+	ta.SetWidth(width)
+	ta.Focus()
+	return &editorCmp{
+		textarea:     ta,
+		keyMap:       DefaultEditorKeyMap(),
+		pastes:       make(map[string]bool),
+		historyIndex: -1,
+	}
+}
+
+func TestCursorOffset(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		width int
+		value string
+		move  func(ta *textarea.Model)
+		want  int
+	}{
+		{"End", 80, "héllo 世界", func(*textarea.Model) {}, len("héllo 世界")},
+		{"AfterAccent", 80, "héllo 世界", func(ta *textarea.Model) { ta.SetCursorColumn(3) }, len("hél")},
+		{"SecondLine", 80, "日本\n語ab", func(ta *textarea.Model) { ta.SetCursorColumn(1) }, len("日本\n語")},
+		{"EndOfFirstLine", 80, "日本\n語ab", func(ta *textarea.Model) { ta.MoveToBegin(); ta.CursorEnd() }, len("日本")},
+		{"SoftWrapped", 10, "一二三四五六七八九十", func(*textarea.Model) {}, len("一二三四五六七八九十")},
+		{"InSoftWrappedLine", 10, "一二三四五六七八九十", func(ta *textarea.Model) { ta.SetCursorColumn(7) }, len("一二三四五六七")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			m := newTestEditor(tt.width)
+			m.textarea.SetValue(tt.value)
+			tt.move(&m.textarea)
+			require.Equal(t, tt.want, cursorOffset(&m.textarea))
+		})
+	}
+}
+
+func TestAfterSpace(t *testing.T) {
+	t.Parallel()
+
+	m := newTestEditor(80)
+	m.textarea.SetValue("日本　語 a")
+	require.True(t, m.afterSpace(0))
+	require.False(t, m.afterSpace(len("日本")))
+	require.True(t, m.afterSpace(len("日本　")))
+	require.False(t, m.afterSpace(len("日本　語")))
+	require.True(t, m.afterSpace(len("日本　語 ")))
+}
+
+func fullWidth(r rune) tea.KeyPressMsg {
+	return tea.KeyPressMsg{Text: string(r), Code: r}
+}
+
+func TestHalfWidth(t *testing.T) {
+	t.Parallel()
+
+	m := newTestEditor(80)
+	require.Equal(t, "/", m.halfWidth(fullWidth('／')).String())
+	require.Equal(t, "@", m.halfWidth(fullWidth('＠')).String())
+
+	// In the middle of a word, or when the prompt isn't empty, they're
+	// typed as they are.
+	m.textarea.SetValue("日本")
+	require.Equal(t, "＠", m.halfWidth(fullWidth('＠')).String())
+	require.Equal(t, "／", m.halfWidth(fullWidth('／')).String())
+	m.textarea.SetValue("日本　")
+	require.Equal(t, "@", m.halfWidth(fullWidth('＠')).String())
+
+	// Other keys are left alone.
+	require.Equal(t, "あ", m.halfWidth(fullWidth('あ')).String())
+}
+
+func TestFullWidthOpensCompletions(t *testing.T) {
+	t.Parallel()
+
+	m := newTestEditor(80)
+	m.textarea.SetValue("日本 ")
+	_, cmd := m.Update(fullWidth('＠'))
+	require.NotNil(t, cmd)
+	require.True(t, m.isCompletionsOpen)
+	require.Equal(t, len("日本 "), m.completionsStartByteIndex)
+	require.Equal(t, "日本 @", m.textarea.Value())
+}
+
+func TestFullWidthOpensCommands(t *testing.T) {
+	t.Parallel()
+
+	m := newTestEditor(80)
+	_, cmd := m.Update(fullWidth('／'))
+	require.NotNil(t, cmd)
+	require.IsType(t, dialogs.OpenDialogMsg{}, cmd())
+	require.Empty(t, m.textarea.Value())
+}
+
+func TestFileCompletionsWithholdPrivacyZones(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"main.go", ".env", "secrets/token.txt"} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, nil, 0o644))
+	}
+	t.Chdir(dir)
+
+	var titles []string
+	for _, c := range fileCompletions(privacy.NewZones(dir, []string{".env*", "secrets/"}), 0, 0) {
+		titles = append(titles, c.Title)
+	}
+	require.Contains(t, titles, "main.go")
+	require.NotContains(t, titles, ".env")
+	require.NotContains(t, titles, "secrets/token.txt")
+}
@@ -5,10 +5,16 @@ import (
 )
 
 type EditorKeyMap struct {
-	AddFile     key.Binding
-	SendMessage key.Binding
-	OpenEditor  key.Binding
-	Newline     key.Binding
+	AddFile           key.Binding
+	SendMessage       key.Binding
+	OpenEditor        key.Binding
+	Newline           key.Binding
+	PreviewPaste      key.Binding
+	ReviewAttachments key.Binding
+	FixSpelling       key.Binding
+	PreviousPrompt    key.Binding
+	NextPrompt        key.Binding
+	SearchHistory     key.Binding
 }
 
 func DefaultEditorKeyMap() EditorKeyMap {
@@ -31,6 +37,30 @@ func DefaultEditorKeyMap() EditorKeyMap {
 			// If the terminal supports these keys, we substitute the help text to reflect that.
 			key.WithHelp("shift+enter or alt+enter", "newline"),
 		),
+		PreviewPaste: key.NewBinding(
+			key.WithKeys("ctrl+y"),
+			key.WithHelp("ctrl+y", "preview paste"),
+		),
+		ReviewAttachments: key.NewBinding(
+			key.WithKeys("alt+a"),
+			key.WithHelp("alt+a", "review attachments"),
+		),
+		FixSpelling: key.NewBinding(
+			key.WithKeys("alt+s"),
+			key.WithHelp("alt+s", "fix typos"),
+		),
+		PreviousPrompt: key.NewBinding(
+			key.WithKeys("up"),
+			key.WithHelp("↑", "previous prompt"),
+		),
+		NextPrompt: key.NewBinding(
+			key.WithKeys("down"),
+			key.WithHelp("↓", "next prompt"),
+		),
+		SearchHistory: key.NewBinding(
+			key.WithKeys("ctrl+r"),
+			key.WithHelp("ctrl+r", "search prompts"),
+		),
 	}
 }
 
@@ -41,8 +71,15 @@ func (k EditorKeyMap) KeyBindings() []key.Binding {
 		k.SendMessage,
 		k.OpenEditor,
 		k.Newline,
+		k.PreviewPaste,
+		k.ReviewAttachments,
+		k.FixSpelling,
+		k.PreviousPrompt,
+		k.NextPrompt,
+		k.SearchHistory,
 		AttachmentsKeyMaps.AttachmentDeleteMode,
 		AttachmentsKeyMaps.DeleteAllAttachments,
+		AttachmentsKeyMaps.DeleteQuote,
 		AttachmentsKeyMaps.Escape,
 	}
 }
@@ -51,6 +88,7 @@ type DeleteAttachmentKeyMaps struct {
 	AttachmentDeleteMode key.Binding
 	Escape               key.Binding
 	DeleteAllAttachments key.Binding
+	DeleteQuote          key.Binding
 }
 
 // TODO: update this to use the new keymap concepts
@@ -67,4 +105,8 @@ var AttachmentsKeyMaps = DeleteAttachmentKeyMaps{
 		key.WithKeys("r"),
 		key.WithHelp("ctrl+r+r", "delete all attachments"),
 	),
+	DeleteQuote: key.NewBinding(
+		key.WithKeys("q"),
+		key.WithHelp("ctrl+r+q", "remove quote"),
+	),
 }
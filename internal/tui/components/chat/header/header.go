@@ -1,6 +1,7 @@
 package header
 
 import (
+	"cmp"
 	"fmt"
 	"strings"
 
@@ -10,11 +11,11 @@ import (
 	"github.com/charmbracelet/crush/internal/csync"
 	"github.com/charmbracelet/crush/internal/fsext"
 	"github.com/charmbracelet/crush/internal/lsp"
+	"github.com/charmbracelet/crush/internal/netstatus"
 	"github.com/charmbracelet/crush/internal/pubsub"
 	"github.com/charmbracelet/crush/internal/session"
 	"github.com/charmbracelet/crush/internal/tui/styles"
 	"github.com/charmbracelet/crush/internal/tui/util"
-	"github.com/charmbracelet/x/ansi"
 	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
 )
 
@@ -115,6 +116,13 @@ func (h *header) details(availWidth int) string {
 		}
 	}
 
+	if config.Get().IsLocalOnly() {
+		parts = append(parts, styles.LocalOnlyBadge())
+	}
+	if !netstatus.Online() {
+		parts = append(parts, styles.OfflineBadge())
+	}
+
 	if errorCount > 0 {
 		parts = append(parts, s.Error.Render(fmt.Sprintf("%s%d", styles.ErrorIcon, errorCount)))
 	}
@@ -132,14 +140,14 @@ func (h *header) details(availWidth int) string {
 		parts = append(parts, s.Muted.Render(keystroke)+s.Subtle.Render(" open "))
 	}
 
-	dot := s.Subtle.Render(" • ")
+	dot := s.Subtle.Render(" " + styles.BulletIcon + " ")
 	metadata := strings.Join(parts, dot)
 	metadata = dot + metadata
 
 	// Truncate cwd if necessary, and insert it at the beginning.
 	const dirTrimLimit = 4
-	cwd := fsext.DirTrim(fsext.PrettyPath(config.Get().WorkingDir()), dirTrimLimit)
-	cwd = ansi.Truncate(cwd, max(0, availWidth-lipgloss.Width(metadata)), "…")
+	cwd := fsext.DirTrim(fsext.PrettyPath(cmp.Or(h.session.WorkingDir, config.Get().WorkingDir())), dirTrimLimit)
+	cwd = util.TruncateMiddle(cwd, availWidth-lipgloss.Width(metadata))
 	cwd = s.Muted.Render(cwd)
 
 	return cwd + metadata
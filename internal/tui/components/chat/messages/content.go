@@ -0,0 +1,285 @@
+package messages
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"charm.land/lipgloss/v2/table"
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/ansiext"
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/plugin"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+)
+
+// contentRenderer renders the result of a tool of a content type. It
+// returns false when the result isn't what its content type says, to be
+// rendered as plain text instead.
+type contentRenderer func(v *toolCallCmp) (string, bool)
+
+// contentRegistry maps content types to their renderers.
+type contentRegistry map[string]contentRenderer
+
+// register adds the renderer of a content type to the registry
+func (cr contentRegistry) register(contentType string, r contentRenderer) { cr[contentType] = r }
+
+// contentRenderers holds the renderers of the content types tools declare
+var contentRenderers = contentRegistry{}
+
+// Register content renderers
+func init() {
+	contentRenderers.register(tools.ContentTypeJSON, renderJSONContent)
+	contentRenderers.register(tools.ContentTypeTable, renderTableContent)
+	contentRenderers.register(tools.ContentTypeTestResults, renderTestResultsContent)
+}
+
+// renderContent renders the result of a tool for the content type declared
+// in its metadata, with the built-in renderer of the content type or the
+// plugin rendering it. It returns false when there's none, or when it
+// can't render the result.
+func renderContent(v *toolCallCmp) (string, bool) {
+	if v.result.Metadata == "" || v.result.IsError {
+		return "", false
+	}
+	var meta tools.ContentMetadata
+	if err := json.Unmarshal([]byte(v.result.Metadata), &meta); err != nil || meta.ContentType == "" {
+		return "", false
+	}
+	if r, ok := contentRenderers[meta.ContentType]; ok {
+		return r(v)
+	}
+	if _, ok := plugin.ContentRenderer(meta.ContentType); ok {
+		return renderWithPlugin(v)
+	}
+	return "", false
+}
+
+// pluginRenderTimeout bounds how long a plugin has to render a result.
+const pluginRenderTimeout = 2 * time.Second
+
+// PluginRenderedMsg is sent once a plugin has rendered the result of a tool
+// call, for the tool call to show it in place of the built-in rendering.
+type PluginRenderedMsg struct {
+	ToolCallID string
+}
+
+var (
+	// pluginRenders caches what plugins rendered, keyed by tool call and
+	// width, since views are rendered far more often than tool results
+	// change.
+	pluginRenders = csync.NewMap[string, string]()
+	// pluginPending are the renders asked of plugins and not done yet.
+	pluginPending = csync.NewMap[string, bool]()
+)
+
+func pluginRenderKey(toolCallID string, width int) string {
+	return fmt.Sprintf("%s:%d", toolCallID, width)
+}
+
+// resultPlugin returns the plugin rendering the result of the tool call, and
+// the tool and content type it renders it for.
+func (m *toolCallCmp) resultPlugin() (p *plugin.Plugin, tool, contentType string, ok bool) {
+	if p, tool, _ := plugin.Renderer(m.call.Name); p != nil {
+		return p, tool, "", true
+	}
+	if m.result.Metadata == "" || m.result.IsError {
+		return nil, "", "", false
+	}
+	var meta tools.ContentMetadata
+	if err := json.Unmarshal([]byte(m.result.Metadata), &meta); err != nil || meta.ContentType == "" {
+		return nil, "", "", false
+	}
+	if _, ok := contentRenderers[meta.ContentType]; ok {
+		return nil, "", "", false
+	}
+	if p, ok := plugin.ContentRenderer(meta.ContentType); ok {
+		return p, m.call.Name, meta.ContentType, true
+	}
+	return nil, "", "", false
+}
+
+// renderPlugin returns the command asking the plugin rendering the result of
+// the tool call to render it at the current width, or nil when there's no
+// such plugin or it was already asked. The result is shown as the built-in
+// renderers show it until the plugin is done, so a slow plugin never holds
+// up the interface.
+func (m *toolCallCmp) renderPlugin() tea.Cmd {
+	if m.result.ToolCallID == "" || m.width <= 0 {
+		return nil
+	}
+	p, tool, contentType, ok := m.resultPlugin()
+	if !ok {
+		return nil
+	}
+	width := m.textWidth() - 2
+	key := pluginRenderKey(m.result.ToolCallID, width)
+	if _, ok := pluginRenders.Get(key); ok {
+		return nil
+	}
+	if _, ok := pluginPending.Get(key); ok {
+		return nil
+	}
+	pluginPending.Set(key, true)
+
+	req := plugin.RenderRequest{
+		Tool:        tool,
+		ContentType: contentType,
+		Input:       m.call.Input,
+		Content:     m.result.Content,
+		Metadata:    json.RawMessage(m.result.Metadata),
+		IsError:     m.result.IsError,
+		Width:       width,
+	}
+	toolCallID, name := m.result.ToolCallID, m.call.Name
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), pluginRenderTimeout)
+		defer cancel()
+		rendered, err := p.Render(ctx, req)
+		if err != nil {
+			slog.Debug("Plugin could not render a tool result", "plugin", p.ID, "tool", name, "error", err)
+		}
+		pluginRenders.Set(key, rendered)
+		pluginPending.Del(key)
+		return PluginRenderedMsg{ToolCallID: toolCallID}
+	}
+}
+
+// renderWithPlugin renders the result of a tool as a plugin rendered it. It
+// returns false when the plugin hasn't rendered it yet, or can't.
+func renderWithPlugin(v *toolCallCmp) (string, bool) {
+	if v.result.ToolCallID == "" {
+		return "", false
+	}
+	width := v.textWidth() - 2
+	text, _ := pluginRenders.Get(pluginRenderKey(v.result.ToolCallID, width))
+	if strings.TrimSpace(text) == "" {
+		return "", false
+	}
+
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = " " + v.fit(line, width-1)
+	}
+	return strings.Join(lines, "\n"), true
+}
+
+// renderJSONContent renders JSON content indented and highlighted.
+func renderJSONContent(v *toolCallCmp) (string, bool) {
+	var out bytes.Buffer
+	if err := json.Indent(&out, []byte(strings.TrimSpace(v.result.Content)), "", "  "); err != nil {
+		return "", false
+	}
+	return renderCodeContent(v, "result.json", out.String(), 0), true
+}
+
+// renderTableContent renders the first rows of a table.
+func renderTableContent(v *toolCallCmp) (string, bool) {
+	var meta tools.TableMetadata
+	if err := json.Unmarshal([]byte(v.result.Metadata), &meta); err != nil || len(meta.Columns) == 0 {
+		return "", false
+	}
+	t := styles.CurrentTheme()
+	width := v.textWidth() - 2
+
+	clean := func(cell string) string {
+		cell = strings.NewReplacer("\r\n", " ", "\n", " ", "\t", " ").Replace(cell)
+		return ansiext.Escape(cell)
+	}
+	headers := make([]string, len(meta.Columns))
+	for i, column := range meta.Columns {
+		headers[i] = clean(column)
+	}
+	visible := min(len(meta.Rows), responseContextHeight)
+	rows := make([][]string, visible)
+	for i, row := range meta.Rows[:visible] {
+		rows[i] = make([]string, len(row))
+		for j, cell := range row {
+			rows[i][j] = clean(cell)
+		}
+	}
+
+	tbl := table.New().
+		Border(lipgloss.RoundedBorder()).
+		BorderStyle(t.S().Base.Foreground(t.Border)).
+		Headers(headers...).
+		Rows(rows...).
+		Width(width).
+		Wrap(false).
+		StyleFunc(func(row, col int) lipgloss.Style {
+			if row == table.HeaderRow {
+				return t.S().Base.Foreground(t.FgHalfMuted).Bold(true).Padding(0, 1)
+			}
+			return t.S().Muted.Padding(0, 1)
+		})
+	out := tbl.Render()
+
+	hidden := len(meta.Rows) - visible
+	switch {
+	case meta.Truncated:
+		out += "\n" + t.S().Muted.Render(fmt.Sprintf("… (%d more rows, result truncated)", hidden))
+	case hidden > 0:
+		out += "\n" + t.S().Muted.Render(fmt.Sprintf("… (%d rows)", hidden))
+	}
+	return out, true
+}
+
+// renderTestResultsContent renders a summary of test results, followed by
+// the tests that failed and why.
+func renderTestResultsContent(v *toolCallCmp) (string, bool) {
+	var meta tools.TestResultsMetadata
+	if err := json.Unmarshal([]byte(v.result.Metadata), &meta); err != nil || len(meta.Tests) == 0 {
+		return "", false
+	}
+	t := styles.CurrentTheme()
+	width := v.textWidth() - 2
+
+	counts := map[string]int{}
+	var duration float64
+	for _, test := range meta.Tests {
+		counts[test.Status]++
+		duration += test.Duration
+	}
+	summary := []string{t.S().Base.Foreground(t.Green).Render(fmt.Sprintf("%s %d passed", styles.ToolSuccess, counts[tools.TestPassed]))}
+	if n := counts[tools.TestFailed]; n > 0 {
+		summary = append(summary, t.S().Base.Foreground(t.Red).Render(fmt.Sprintf("%s %d failed", styles.ToolError, n)))
+	}
+	if n := counts[tools.TestSkipped]; n > 0 {
+		summary = append(summary, t.S().Muted.Render(fmt.Sprintf("%s %d skipped", styles.TodoPendingIcon, n)))
+	}
+	if duration > 0 {
+		summary = append(summary, t.S().Subtle.Render(fmt.Sprintf("in %.2fs", duration)))
+	}
+	lines := []string{" " + strings.Join(summary, "  ")}
+
+	var hidden int
+	for _, test := range meta.Tests {
+		if test.Status != tools.TestFailed {
+			continue
+		}
+		if len(lines) >= responseContextHeight {
+			hidden++
+			continue
+		}
+		name := t.S().Base.Foreground(t.Red).Render(styles.ToolError + " " + ansiext.Escape(test.Name))
+		lines = append(lines, " "+v.fit(name, width-1))
+		message := strings.TrimSpace(test.Message)
+		if message == "" {
+			continue
+		}
+		messageLines := strings.Split(message, "\n")
+		for _, line := range messageLines[:min(len(messageLines), 3)] {
+			lines = append(lines, "   "+v.fit(t.S().Muted.Render(ansiext.Escape(line)), width-3))
+		}
+	}
+	if hidden > 0 {
+		lines = append(lines, t.S().Muted.Render(fmt.Sprintf(" … (%d more failures)", hidden)))
+	}
+	return strings.Join(lines, "\n"), true
+}
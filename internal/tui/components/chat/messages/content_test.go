@@ -0,0 +1,128 @@
+package messages
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/charmbracelet/x/exp/golden"
+	"github.com/stretchr/testify/require"
+)
+
+func metadata(t *testing.T, v any) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	require.NoError(t, err)
+	return string(b)
+}
+
+func TestRenderContent(t *testing.T) {
+	t.Parallel()
+
+	var rows [][]string
+	for i := 1; i <= 12; i++ {
+		rows = append(rows, []string{fmt.Sprint(i), fmt.Sprintf("user%d@example.com", i), "active"})
+	}
+
+	tests := []struct {
+		name     string
+		content  string
+		metadata any
+	}{
+		{
+			name:     "JSON",
+			content:  `{"name":"crush","tags":["cli","ai"],"stars":3,"license":{"spdx":"FSL-1.1-MIT"}}`,
+			metadata: tools.ContentMetadata{ContentType: tools.ContentTypeJSON},
+		},
+		{
+			name: "Table",
+			metadata: tools.TableMetadata{
+				ContentType: tools.ContentTypeTable,
+				Columns:     []string{"id", "email", "status"},
+				Rows:        rows,
+			},
+		},
+		{
+			name: "TableTruncated",
+			metadata: tools.TableMetadata{
+				ContentType: tools.ContentTypeTable,
+				Columns:     []string{"id", "email", "status"},
+				Rows:        rows,
+				Truncated:   true,
+			},
+		},
+		{
+			name: "TestResults",
+			metadata: tools.TestResultsMetadata{
+				ContentType: tools.ContentTypeTestResults,
+				Tests: []tools.TestResult{
+					{Name: "TestParse", Status: tools.TestPassed, Duration: 0.5},
+					{Name: "TestRender", Status: tools.TestFailed, Duration: 1.25, Message: "render_test.go:42: got \"a\"\n\twant \"b\"\nsecond\nfourth line is hidden"},
+					{Name: "TestRenderWithAVeryLongNameThatDoesNotFitInTheWidthOfTheTool", Status: tools.TestFailed},
+					{Name: "TestWindows", Status: tools.TestSkipped, Message: "not on windows"},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			v := &toolCallCmp{
+				width:  60,
+				call:   message.ToolCall{ID: "call", Name: "tool", Finished: true},
+				result: message.ToolResult{ToolCallID: "call", Content: tt.content, Metadata: metadata(t, tt.metadata)},
+			}
+			out, ok := renderContent(v)
+			require.True(t, ok)
+			golden.RequireEqual(t, []byte(ansi.Strip(out)))
+		})
+	}
+}
+
+func TestRenderContentInvalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		content  string
+		metadata any
+	}{
+		{
+			name:     "JSON",
+			content:  "not json",
+			metadata: tools.ContentMetadata{ContentType: tools.ContentTypeJSON},
+		},
+		{
+			name:     "TableWithoutColumns",
+			metadata: tools.TableMetadata{ContentType: tools.ContentTypeTable},
+		},
+		{
+			name:     "TestResultsWithoutTests",
+			metadata: tools.TestResultsMetadata{ContentType: tools.ContentTypeTestResults},
+		},
+		{
+			name:     "UnknownContentType",
+			content:  "{}",
+			metadata: tools.ContentMetadata{ContentType: "application/x-unknown"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			v := &toolCallCmp{
+				width:  60,
+				call:   message.ToolCall{ID: "call", Name: "tool", Finished: true},
+				result: message.ToolResult{ToolCallID: "call", Content: tt.content, Metadata: metadata(t, tt.metadata)},
+			}
+			_, ok := renderContent(v)
+			require.False(t, ok)
+		})
+	}
+}
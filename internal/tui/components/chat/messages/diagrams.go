@@ -0,0 +1,40 @@
+package messages
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/diagram"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/tui/util"
+	"github.com/pkg/browser"
+)
+
+// RenderDiagrams renders the Mermaid and PlantUML diagrams of a message to
+// images, and opens them in the default image viewer.
+func RenderDiagrams(msg message.Message) tea.Cmd {
+	blocks := diagram.Find(msg.Content().Text)
+	if len(blocks) == 0 {
+		return util.ReportWarn("No diagrams in this message")
+	}
+	cfg := config.Get()
+	renderer := diagram.Renderer{Dir: filepath.Join(cfg.Options.DataDirectory, "diagrams")}
+	if tui := cfg.Options.TUI; tui != nil && tui.DiagramRenderer != "" && cfg.EndpointAllowed(tui.DiagramRenderer) {
+		renderer.WebRenderer = tui.DiagramRenderer
+	}
+	return func() tea.Msg {
+		for _, block := range blocks {
+			path, err := renderer.Render(context.Background(), block)
+			if err != nil {
+				return util.ReportError(err)()
+			}
+			if err := browser.OpenFile(path); err != nil {
+				return util.ReportError(fmt.Errorf("failed to open the diagram: %w", err))()
+			}
+		}
+		return util.ReportInfo(fmt.Sprintf("Opened %s", plural(len(blocks), "diagram")))()
+	}
+}
@@ -0,0 +1,128 @@
+package messages
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/ansiext"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+	"github.com/charmbracelet/x/ansi"
+)
+
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline renders values as bars as high as they are relative to the
+// highest one.
+func sparkline(values []int) string {
+	if len(values) == 0 {
+		return ""
+	}
+	peak := slices.Max(values)
+	var b strings.Builder
+	for _, v := range values {
+		i := 0
+		if peak > 0 {
+			i = v * (len(sparkBlocks) - 1) / peak
+		}
+		b.WriteRune(sparkBlocks[i])
+	}
+	return b.String()
+}
+
+// renderLiveOutput renders the end of the output of a running command, under
+// a sparkline of how much it printed lately.
+func renderLiveOutput(v *toolCallCmp) string {
+	t := styles.CurrentTheme()
+	status := t.S().Base.Foreground(t.Green).Render(sparkline(v.activity)) +
+		t.S().Muted.Render(" "+util.FormatSize(v.liveBytes))
+	return status + "\n\n" + renderCommandOutput(v, v.liveOutput)
+}
+
+// renderCommandOutput renders the output of a command like
+// renderPlainContent, keeping its colors, and its last lines in view rather
+// than its first, as errors and summaries come last.
+func renderCommandOutput(v *toolCallCmp, content string) string {
+	t := styles.CurrentTheme()
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	content = strings.ReplaceAll(content, "\t", "    ")
+	content = ansiext.Sanitize(strings.TrimSpace(content))
+	lines := strings.Split(content, "\n")
+
+	width := v.textWidth() - 2
+	base := ansi.Style{}.ForegroundColor(t.FgMuted).BackgroundColor(t.BgBaseLighter).String()
+	var out []string
+	if len(lines) > responseContextHeight {
+		out = append(out, t.S().Muted.
+			Background(t.BgBaseLighter).
+			Width(width).
+			Render(fmt.Sprintf("… (%d lines)", len(lines)-responseContextHeight)))
+		lines = lines[len(lines)-responseContextHeight:]
+	}
+	for _, ln := range lines {
+		ln = " " + restyle(ln, base)
+		if lipgloss.Width(ln) > width {
+			ln = v.fit(ln, width)
+		}
+		out = append(out, t.S().Muted.
+			Width(width).
+			Background(t.BgBaseLighter).
+			Render(ln)+ansi.ResetStyle)
+	}
+	return strings.Join(out, "\n")
+}
+
+// restyle sets the base style back after the SGR sequences of a sanitized
+// line resetting it, so the colors of the output don't leave holes in the
+// background of the block.
+func restyle(line, base string) string {
+	if !strings.Contains(line, "\x1b[") {
+		return line
+	}
+	var b strings.Builder
+	for {
+		i := strings.Index(line, "\x1b[")
+		if i < 0 {
+			b.WriteString(line)
+			break
+		}
+		j := strings.IndexByte(line[i:], 'm')
+		if j < 0 {
+			b.WriteString(line)
+			break
+		}
+		seq := line[i : i+j+1]
+		b.WriteString(line[:i])
+		b.WriteString(seq)
+		if resetsStyle(seq[2 : len(seq)-1]) {
+			b.WriteString(base)
+		}
+		line = line[i+j+1:]
+	}
+	return b.String()
+}
+
+// resetsStyle reports whether the parameters of an SGR sequence reset the
+// style or the background color.
+func resetsStyle(params string) bool {
+	if params == "" {
+		return true
+	}
+	parts := strings.Split(params, ";")
+	for i := 0; i < len(parts); i++ {
+		switch parts[i] {
+		case "", "0", "00", "49":
+			return true
+		case "38", "48", "58":
+			// Skip the parameters of extended colors.
+			if i+1 < len(parts) && parts[i+1] == "5" {
+				i += 2
+			} else if i+1 < len(parts) && parts[i+1] == "2" {
+				i += 4
+			}
+		}
+	}
+	return false
+}
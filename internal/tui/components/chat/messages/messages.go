@@ -17,6 +17,8 @@ import (
 
 	"github.com/atotto/clipboard"
 	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/diagram"
+	"github.com/charmbracelet/crush/internal/latex"
 	"github.com/charmbracelet/crush/internal/message"
 	"github.com/charmbracelet/crush/internal/tui/components/anim"
 	"github.com/charmbracelet/crush/internal/tui/components/core"
@@ -29,6 +31,25 @@ import (
 // CopyKey is the key binding for copying message content to the clipboard.
 var CopyKey = key.NewBinding(key.WithKeys("c", "y", "C", "Y"), key.WithHelp("c/y", "copy"))
 
+// ActionsKey is the key binding for opening the actions menu of the focused
+// message.
+var ActionsKey = key.NewBinding(key.WithKeys("enter", "."), key.WithHelp("enter/.", "actions"))
+
+// ExpandKey is the key binding for expanding or collapsing the focused turn
+// summary or sub-agent.
+var ExpandKey = key.NewBinding(key.WithKeys("o"), key.WithHelp("o", "expand/collapse"))
+
+// ExcludeKey is the key binding for excluding the focused message from the
+// context sent to the model, or including it back.
+var ExcludeKey = key.NewBinding(key.WithKeys("e"), key.WithHelp("e", "exclude from context"))
+
+// DeleteKey is the key binding for deleting the focused message or tool call.
+var DeleteKey = key.NewBinding(key.WithKeys("x", "delete"), key.WithHelp("x/del", "delete"))
+
+// DiagramKey is the key binding for rendering the diagrams of the focused
+// message and opening them.
+var DiagramKey = key.NewBinding(key.WithKeys("v"), key.WithHelp("v", "view diagrams"))
+
 // ClearSelectionKey is the key binding for clearing the current selection in the chat interface.
 var ClearSelectionKey = key.NewBinding(key.WithKeys("esc", "alt+esc"), key.WithHelp("esc", "clear selection"))
 
@@ -212,20 +233,41 @@ func (m *messageCmp) renderAssistantMessage() string {
 		if thinkingContent != "" {
 			parts = append(parts, "")
 		}
-		parts = append(parts, m.toMarkdown(content))
+		parts = append(parts, m.toMarkdown(renderMath(content)))
+		if m.focused && finished {
+			if n := len(diagram.Find(content)); n > 0 {
+				parts = append(parts, t.S().Subtle.Render(fmt.Sprintf("%s %s · %s to view", styles.ImageIcon, plural(n, "diagram"), DiagramKey.Help().Key)))
+			}
+		}
 	}
 
 	joined := lipgloss.JoinVertical(lipgloss.Left, parts...)
+	if m.message.Excluded {
+		joined = dimExcluded(joined)
+	}
 	return m.style().Render(joined)
 }
 
+// renderMath approximates the LaTeX math of a response with Unicode, unless
+// it's turned off to show the math as written.
+func renderMath(content string) string {
+	if cfg := config.Get(); cfg != nil && cfg.Options != nil && cfg.Options.TUI != nil && cfg.Options.TUI.DisableMath {
+		return content
+	}
+	return latex.Render(content)
+}
+
 // renderUserMessage renders user messages with file attachments. It displays
 // message content and any attached files with appropriate icons.
 func (m *messageCmp) renderUserMessage() string {
 	t := styles.CurrentTheme()
-	parts := []string{
-		m.toMarkdown(m.message.Content().String()),
+	var parts []string
+	content := m.message.Content().String()
+	if quote, rest, ok := message.ParseQuote(content); ok {
+		parts = append(parts, m.renderQuote(quote))
+		content = rest
 	}
+	parts = append(parts, m.toMarkdown(content))
 
 	attachmentStyle := t.S().Base.
 		Padding(0, 1).
@@ -241,6 +283,7 @@ func (m *messageCmp) renderUserMessage() string {
 		Render
 
 	attachments := make([]string, len(m.message.BinaryContent()))
+	var notes []string
 	for i, attachment := range m.message.BinaryContent() {
 		const maxFilenameWidth = 10
 		filename := ansi.Truncate(filepath.Base(attachment.Path), 10, "...")
@@ -253,21 +296,69 @@ func (m *messageCmp) renderUserMessage() string {
 			iconStyle(icon),
 			attachmentStyle(filename),
 		)
+		if attachment.Note != "" {
+			notes = append(notes, t.S().Subtle.Render(filepath.Base(attachment.Path)+": "+attachment.Note))
+		}
 	}
 
 	if len(attachments) > 0 {
 		parts = append(parts, "", strings.Join(attachments, ""))
 	}
+	parts = append(parts, notes...)
 
 	joined := lipgloss.JoinVertical(lipgloss.Left, parts...)
+	if m.message.Excluded {
+		joined = dimExcluded(joined)
+	}
 	return m.style().Render(joined)
 }
 
+// dimExcluded greys out the content of something excluded from the context
+// sent to the model, and says so below it.
+func dimExcluded(content string) string {
+	t := styles.CurrentTheme()
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		t.S().Subtle.Render(ansi.Strip(content)),
+		t.S().Subtle.Italic(true).Render("excluded from context"),
+	)
+}
+
+// renderQuote renders the message a prompt replies to, collapsed to its
+// first line.
+func (m *messageCmp) renderQuote(quote message.Quote) string {
+	t := styles.CurrentTheme()
+	text, _, _ := strings.Cut(strings.TrimSpace(quote.Text), "\n")
+	line := fmt.Sprintf("%s %s: %s", styles.QuoteIcon, quote.Role, text)
+	return t.S().Muted.
+		Border(lipgloss.NormalBorder(), false, false, false, true).
+		BorderForeground(t.FgMuted).
+		PaddingLeft(1).
+		Render(ansi.Truncate(line, m.textWidth()-2, "…"))
+}
+
 // toMarkdown converts text content to rendered markdown using the configured renderer
 func (m *messageCmp) toMarkdown(content string) string {
 	r := styles.GetMarkdownRenderer(m.textWidth())
 	rendered, _ := r.Render(content)
-	return strings.TrimSuffix(rendered, "\n")
+	return wrapWide(strings.TrimSuffix(rendered, "\n"), m.textWidth())
+}
+
+// wrapWide wraps the rendered lines wider than width. The markdown renderer
+// only breaks lines at spaces, so it leaves the text of languages written
+// without them, like Chinese and Japanese, for the terminal to wrap, which
+// throws off the layout.
+func wrapWide(rendered string, width int) string {
+	if width <= 0 {
+		return rendered
+	}
+	lines := strings.Split(rendered, "\n")
+	for i, line := range lines {
+		if ansi.StringWidth(line) > width {
+			lines[i] = ansi.Wrap(line, width, "")
+		}
+	}
+	return strings.Join(lines, "\n")
 }
 
 func (m *messageCmp) renderThinkingContent() string {
@@ -282,6 +373,7 @@ func (m *messageCmp) renderThinkingContent() string {
 
 	renderer := styles.GetPlainMarkdownRenderer(width - 1)
 	rendered, err := renderer.Render(reasoningContent.Thinking)
+	rendered = wrapWide(rendered, width-1)
 	if err != nil {
 		lines := strings.Split(reasoningContent.Thinking, "\n")
 		var content strings.Builder
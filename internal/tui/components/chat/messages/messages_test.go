@@ -0,0 +1,29 @@
+package messages
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrapWide(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		rendered string
+		width    int
+		want     string
+	}{
+		{"Fits", "日本語", 10, "日本語"},
+		{"WithoutSpaces", "日本語のテキストはスペースがない", 10, "日本語のテ\nキストはス\nペースがな\nい"},
+		{"OnlyWideLines", "short\n日本語のテキストはスペースがない", 10, "short\n日本語のテ\nキストはス\nペースがな\nい"},
+		{"NoWidth", "日本語のテキスト", 0, "日本語のテキスト"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.want, wrapWide(tt.rendered, tt.width))
+		})
+	}
+}
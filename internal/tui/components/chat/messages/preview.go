@@ -0,0 +1,176 @@
+package messages
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/tui/components/core"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+)
+
+// inputPreviewer is implemented by the renderers that can show a tool call
+// while its arguments are still streaming from the model.
+type inputPreviewer interface {
+	// preview returns the header parameters and the body to show for the
+	// arguments received so far.
+	preview(v *toolCallCmp, input []byte) (args []string, body string)
+}
+
+// renderInputPreview renders what the arguments streamed so far tell about
+// the tool call, or an empty string when there is nothing to show yet.
+func (m *toolCallCmp) renderInputPreview(header string) string {
+	previewer, ok := registry.lookup(m.call.Name).(inputPreviewer)
+	if !ok || m.call.Input == "" {
+		return ""
+	}
+	args, body := previewer.preview(m, completeJSON(m.call.Input))
+	if len(args) == 0 && body == "" {
+		return ""
+	}
+	if len(args) > 0 {
+		header += " " + renderParamList(false, m.textWidth()-lipgloss.Width(header)-1, args...)
+	}
+	return joinHeaderBody(header, body)
+}
+
+func (er editRenderer) preview(v *toolCallCmp, input []byte) ([]string, string) {
+	var params tools.EditParams
+	if json.Unmarshal(input, &params) != nil {
+		return nil, ""
+	}
+	return previewArgs(params.FilePath), renderPreviewDiff(v, params.FilePath, params.OldString, params.NewString)
+}
+
+func (mer multiEditRenderer) preview(v *toolCallCmp, input []byte) ([]string, string) {
+	var params tools.MultiEditParams
+	if json.Unmarshal(input, &params) != nil {
+		return nil, ""
+	}
+	if len(params.Edits) == 0 {
+		return previewArgs(params.FilePath), ""
+	}
+	// The last edit is the one still streaming.
+	edit := params.Edits[len(params.Edits)-1]
+	args := newParamBuilder().
+		addMain(fsext.PrettyPath(params.FilePath)).
+		addKeyValue("edit", fmt.Sprintf("%d", len(params.Edits))).
+		build()
+	return args, renderPreviewDiff(v, params.FilePath, edit.OldString, edit.NewString)
+}
+
+func (wr writeRenderer) preview(v *toolCallCmp, input []byte) ([]string, string) {
+	var params tools.WriteParams
+	if json.Unmarshal(input, &params) != nil {
+		return nil, ""
+	}
+	if params.Content == "" {
+		return previewArgs(params.FilePath), ""
+	}
+	// Show the end of the content, where it's growing.
+	lines := strings.Split(params.Content, "\n")
+	offset := max(0, len(lines)-responseContextHeight)
+	content := strings.Join(lines[offset:], "\n")
+	return previewArgs(params.FilePath), renderCodeContent(v, fsext.PrettyPath(params.FilePath), content, offset)
+}
+
+func (br bashRenderer) preview(_ *toolCallCmp, input []byte) ([]string, string) {
+	var params tools.BashParams
+	if json.Unmarshal(input, &params) != nil {
+		return nil, ""
+	}
+	cmd := strings.ReplaceAll(params.Command, "\n", " ")
+	cmd = strings.ReplaceAll(cmd, "\t", "    ")
+	return newParamBuilder().addMain(cmd).build(), ""
+}
+
+func previewArgs(path string) []string {
+	if path == "" {
+		return nil
+	}
+	return newParamBuilder().addMain(fsext.PrettyPath(path)).build()
+}
+
+// renderPreviewDiff renders the diff of an edit still streaming, keeping its
+// end in view.
+func renderPreviewDiff(v *toolCallCmp, path, before, after string) string {
+	if before == "" && after == "" {
+		return ""
+	}
+	t := styles.CurrentTheme()
+	formatted := core.DiffFormatter().
+		Before(fsext.PrettyPath(path), before).
+		After(fsext.PrettyPath(path), after).
+		Width(v.textWidth() - 2). // -2 for padding
+		String()
+	lines := strings.Split(formatted, "\n")
+	if len(lines) <= responseContextHeight {
+		return formatted
+	}
+	hidden := t.S().Muted.
+		Background(t.BgBaseLighter).
+		PaddingLeft(2).
+		Width(v.textWidth() - 2).
+		Render(fmt.Sprintf("… (%d lines)", len(lines)-responseContextHeight))
+	return hidden + "\n" + strings.Join(lines[len(lines)-responseContextHeight:], "\n")
+}
+
+// completeJSON closes the strings, arrays and objects left open in a JSON
+// document that is still streaming, so the fields received so far can be
+// decoded. The result may still be invalid, for instance in the middle of a
+// key or a number, until more of the document arrives.
+func completeJSON(input string) []byte {
+	var closers []byte
+	inString, escaped := false, false
+	lastEscape := -1
+	for i := 0; i < len(input); i++ {
+		c := input[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+				lastEscape = i
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+		case '{':
+			closers = append(closers, '}')
+		case '[':
+			closers = append(closers, ']')
+		case '}', ']':
+			if len(closers) > 0 {
+				closers = closers[:len(closers)-1]
+			}
+		}
+	}
+
+	out := input
+	if inString {
+		// Drop an escape sequence cut in half.
+		if escaped || lastEscape >= 0 && out[lastEscape+1] == 'u' && len(out)-lastEscape < 6 {
+			out = out[:lastEscape]
+		}
+		out += `"`
+	}
+	out = strings.TrimRight(out, " \t\r\n")
+	switch {
+	case strings.HasSuffix(out, ","):
+		out = strings.TrimSuffix(out, ",")
+	case strings.HasSuffix(out, ":"):
+		out += "null"
+	}
+	for i := len(closers) - 1; i >= 0; i-- {
+		out += string(closers[i])
+	}
+	return []byte(out)
+}
@@ -13,10 +13,12 @@ import (
 	"github.com/charmbracelet/crush/internal/agent/tools"
 	"github.com/charmbracelet/crush/internal/ansiext"
 	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/plugin"
 	"github.com/charmbracelet/crush/internal/tui/components/chat/todos"
 	"github.com/charmbracelet/crush/internal/tui/components/core"
 	"github.com/charmbracelet/crush/internal/tui/highlight"
 	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
 	"github.com/charmbracelet/x/ansi"
 )
 
@@ -44,6 +46,9 @@ func (rr renderRegistry) lookup(name string) renderer {
 	if f, ok := rr[name]; ok {
 		return f()
 	}
+	if _, _, ok := plugin.Renderer(name); ok {
+		return pluginRenderer{}
+	}
 	return genericRenderer{} // sensible fallback
 }
 
@@ -184,11 +189,14 @@ func init() {
 	registry.register(tools.BashToolName, func() renderer { return bashRenderer{} })
 	registry.register(tools.JobOutputToolName, func() renderer { return bashOutputRenderer{} })
 	registry.register(tools.JobKillToolName, func() renderer { return bashKillRenderer{} })
+	registry.register(tools.InteractiveToolName, func() renderer { return interactiveRenderer{} })
 	registry.register(tools.DownloadToolName, func() renderer { return downloadRenderer{} })
 	registry.register(tools.ViewToolName, func() renderer { return viewRenderer{} })
 	registry.register(tools.EditToolName, func() renderer { return editRenderer{} })
 	registry.register(tools.MultiEditToolName, func() renderer { return multiEditRenderer{} })
 	registry.register(tools.WriteToolName, func() renderer { return writeRenderer{} })
+	registry.register(tools.RenameToolName, func() renderer { return workspaceEditRenderer{} })
+	registry.register(tools.CodeActionToolName, func() renderer { return workspaceEditRenderer{} })
 	registry.register(tools.FetchToolName, func() renderer { return simpleFetchRenderer{} })
 	registry.register(tools.AgenticFetchToolName, func() renderer { return agenticFetchRenderer{} })
 	registry.register(tools.WebFetchToolName, func() renderer { return webFetchRenderer{} })
@@ -197,8 +205,14 @@ func init() {
 	registry.register(tools.GrepToolName, func() renderer { return grepRenderer{} })
 	registry.register(tools.LSToolName, func() renderer { return lsRenderer{} })
 	registry.register(tools.SourcegraphToolName, func() renderer { return sourcegraphRenderer{} })
+	registry.register(tools.SymbolsToolName, func() renderer { return symbolsRenderer{} })
+	registry.register(tools.SemanticSearchToolName, func() renderer { return semanticSearchRenderer{} })
 	registry.register(tools.DiagnosticsToolName, func() renderer { return diagnosticsRenderer{} })
 	registry.register(tools.TodosToolName, func() renderer { return todosRenderer{} })
+	registry.register(tools.KubectlToolName, func() renderer { return kubectlRenderer{} })
+	registry.register(tools.TerraformToolName, func() renderer { return terraformRenderer{} })
+	registry.register(tools.SQLToolName, func() renderer { return sqlRenderer{} })
+	registry.register(tools.HTTPToolName, func() renderer { return httpRenderer{} })
 	registry.register(agent.AgentToolName, func() renderer { return agentRenderer{} })
 }
 
@@ -224,10 +238,34 @@ func (gr genericRenderer) Render(v *toolCallCmp) string {
 	}
 
 	return gr.renderWithParams(v, prettifyToolName(v.call.Name), []string{v.call.Input}, func() string {
+		if out, ok := renderContent(v); ok {
+			return out
+		}
 		return renderPlainContent(v, v.result.Content)
 	})
 }
 
+// -----------------------------------------------------------------------------
+//  Plugin renderer
+// -----------------------------------------------------------------------------
+
+// pluginRenderer displays the results of the tools of plugins as the plugin
+// renders them, or as the generic renderer does until it has, or when it
+// can't.
+type pluginRenderer struct {
+	baseRenderer
+}
+
+func (pr pluginRenderer) Render(v *toolCallCmp) string {
+	out, ok := renderWithPlugin(v)
+	if !ok {
+		return genericRenderer{}.Render(v)
+	}
+	return pr.renderWithParams(v, prettifyToolName(v.call.Name), []string{v.call.Input}, func() string {
+		return out
+	})
+}
+
 // -----------------------------------------------------------------------------
 //  Bash renderer
 // -----------------------------------------------------------------------------
@@ -272,10 +310,16 @@ func (br bashRenderer) Render(v *toolCallCmp) string {
 		}
 	}
 
+	// Show what the command prints while it runs.
+	if v.result.ToolCallID == "" && !v.cancelled && !v.isNested && v.liveOutput != "" {
+		header := br.makeHeader(v, "Bash", v.textWidth(), args...)
+		return joinHeaderBody(header, renderLiveOutput(v))
+	}
+
 	return br.renderWithParams(v, "Bash", args, func() string {
 		var meta tools.BashResponseMetadata
 		if err := br.unmarshalParams(v.result.Metadata, &meta); err != nil {
-			return renderPlainContent(v, v.result.Content)
+			return renderCommandOutput(v, v.result.Content)
 		}
 		// for backwards compatibility with older tool calls.
 		if meta.Output == "" && v.result.Content != tools.BashNoOutput {
@@ -285,7 +329,45 @@ func (br bashRenderer) Render(v *toolCallCmp) string {
 		if meta.Output == "" {
 			return ""
 		}
-		return renderPlainContent(v, meta.Output)
+		return renderCommandOutput(v, meta.Output)
+	})
+}
+
+// -----------------------------------------------------------------------------
+//  Interactive renderer
+// -----------------------------------------------------------------------------
+
+// interactiveRenderer handles commands the user ran in the terminal
+type interactiveRenderer struct {
+	baseRenderer
+}
+
+// Render displays the command with what it printed while the user ran it
+func (ir interactiveRenderer) Render(v *toolCallCmp) string {
+	var params tools.InteractiveParams
+	if err := ir.unmarshalParams(v.call.Input, &params); err != nil {
+		return ir.renderError(v, fmt.Sprintf("Invalid interactive parameters: %s", err))
+	}
+
+	cmd := strings.ReplaceAll(params.Command, "\n", " ")
+	cmd = strings.ReplaceAll(cmd, "\t", "    ")
+	args := newParamBuilder().
+		addMain(cmd).
+		build()
+
+	return ir.renderWithParams(v, "Interactive", args, func() string {
+		var meta tools.InteractiveResponseMetadata
+		if err := ir.unmarshalParams(v.result.Metadata, &meta); err != nil {
+			return renderPlainContent(v, v.result.Content)
+		}
+		output := meta.Output
+		if meta.ExitCode != 0 {
+			output = strings.TrimSpace(output + fmt.Sprintf("\nExit code %d", meta.ExitCode))
+		}
+		if output == "" {
+			return ""
+		}
+		return renderPlainContent(v, output)
 	})
 }
 
@@ -367,8 +449,11 @@ func (bor bashOutputRenderer) Render(v *toolCallCmp) string {
 	if res, done := earlyState(header, v); done {
 		return res
 	}
-	body := renderPlainContent(v, v.result.Content)
-	return joinHeaderBody(header, body)
+	// Older tool calls don't have the output in their metadata.
+	if meta.Output == "" {
+		return joinHeaderBody(header, renderPlainContent(v, v.result.Content))
+	}
+	return joinHeaderBody(header, renderCommandOutput(v, meta.Output))
 }
 
 // -----------------------------------------------------------------------------
@@ -487,7 +572,7 @@ func (er editRenderer) Render(v *toolCallCmp) string {
 			Before(fsext.PrettyPath(params.FilePath), meta.OldContent).
 			After(fsext.PrettyPath(params.FilePath), meta.NewContent).
 			Width(v.textWidth() - 2) // -2 for padding
-		if v.textWidth() > 120 {
+		if core.SplitDiff(v.textWidth()) {
 			formatter = formatter.Split()
 		}
 		// add a message to the bottom if the content was truncated
@@ -538,7 +623,7 @@ func (mer multiEditRenderer) Render(v *toolCallCmp) string {
 			Before(fsext.PrettyPath(params.FilePath), meta.OldContent).
 			After(fsext.PrettyPath(params.FilePath), meta.NewContent).
 			Width(v.textWidth() - 2) // -2 for padding
-		if v.textWidth() > 120 {
+		if core.SplitDiff(v.textWidth()) {
 			formatter = formatter.Split()
 		}
 		// add a message to the bottom if the content was truncated
@@ -567,6 +652,72 @@ func (mer multiEditRenderer) Render(v *toolCallCmp) string {
 	})
 }
 
+// -----------------------------------------------------------------------------
+//  Workspace edit renderer
+// -----------------------------------------------------------------------------
+
+// workspaceEditRenderer handles the tools applying the edits of language
+// servers, which can change several files
+type workspaceEditRenderer struct {
+	baseRenderer
+}
+
+// Render displays the diff of each file changed by the edit
+func (wer workspaceEditRenderer) Render(v *toolCallCmp) string {
+	t := styles.CurrentTheme()
+	var args []string
+	switch v.call.Name {
+	case tools.RenameToolName:
+		var params tools.RenameParams
+		if err := wer.unmarshalParams(v.call.Input, &params); err == nil {
+			args = newParamBuilder().
+				addMain(params.Symbol+" → "+params.NewName).
+				addKeyValue("file", fsext.PrettyPath(params.FilePath)).
+				build()
+		}
+	case tools.CodeActionToolName:
+		var params tools.CodeActionParams
+		if err := wer.unmarshalParams(v.call.Input, &params); err == nil {
+			args = newParamBuilder().
+				addMain(fsext.PrettyPath(params.FilePath)).
+				addKeyValue("line", fmt.Sprintf("%d", params.Line)).
+				addKeyValue("action", params.Title).
+				build()
+		}
+	}
+
+	return wer.renderWithParams(v, prettifyToolName(v.call.Name), args, func() string {
+		var meta tools.WorkspaceEditResponseMetadata
+		if err := wer.unmarshalParams(v.result.Metadata, &meta); err != nil || len(meta.Files) == 0 {
+			return renderPlainContent(v, v.result.Content)
+		}
+
+		var diffs []string
+		for _, f := range meta.Files {
+			formatter := core.DiffFormatter().
+				Before(fsext.PrettyPath(f.FilePath), f.OldContent).
+				After(fsext.PrettyPath(f.FilePath), f.NewContent).
+				Width(v.textWidth() - 2) // -2 for padding
+			if core.SplitDiff(v.textWidth()) {
+				formatter = formatter.Split()
+			}
+			diffs = append(diffs, formatter.String())
+		}
+		// add a message to the bottom if the content was truncated
+		formatted := strings.Join(diffs, "\n")
+		if lipgloss.Height(formatted) > responseContextHeight {
+			contentLines := strings.Split(formatted, "\n")
+			truncateMessage := t.S().Muted.
+				Background(t.BgBaseLighter).
+				PaddingLeft(2).
+				Width(v.textWidth() - 4).
+				Render(fmt.Sprintf("… (%d lines in %d files)", len(contentLines)-responseContextHeight, len(meta.Files)))
+			formatted = strings.Join(contentLines[:responseContextHeight], "\n") + "\n" + truncateMessage
+		}
+		return formatted
+	})
+}
+
 // -----------------------------------------------------------------------------
 //  Write renderer
 // -----------------------------------------------------------------------------
@@ -675,14 +826,8 @@ func (fr agenticFetchRenderer) Render(v *toolCallCmp) string {
 			prompt,
 		),
 	)
-	childTools := tree.Root(header)
-
-	for _, call := range v.nestedToolCalls {
-		call.SetSize(remainingWidth, 1)
-		childTools.Child(call.View())
-	}
 	parts := []string{
-		childTools.Enumerator(RoundedEnumeratorWithWidth(2, lipgloss.Width(taskTag)-5)).String(),
+		renderNestedToolCalls(v, header, remainingWidth, lipgloss.Width(taskTag)-5),
 	}
 
 	if v.result.ToolCallID == "" {
@@ -811,6 +956,55 @@ func (gr globRenderer) Render(v *toolCallCmp) string {
 	})
 }
 
+// -----------------------------------------------------------------------------
+//  Symbols renderer
+// -----------------------------------------------------------------------------
+
+// symbolsRenderer handles symbol lookups with their kind filter
+type symbolsRenderer struct {
+	baseRenderer
+}
+
+// Render displays the looked up symbol with the definitions found
+func (sr symbolsRenderer) Render(v *toolCallCmp) string {
+	var params tools.SymbolsParams
+	var args []string
+	if err := sr.unmarshalParams(v.call.Input, &params); err == nil {
+		args = newParamBuilder().
+			addMain(params.Query).
+			addKeyValue("kind", params.Kind).
+			build()
+	}
+
+	return sr.renderWithParams(v, "Symbols", args, func() string {
+		return renderPlainContent(v, v.result.Content)
+	})
+}
+
+// -----------------------------------------------------------------------------
+//  Semantic search renderer
+// -----------------------------------------------------------------------------
+
+// semanticSearchRenderer handles searches of the code by meaning
+type semanticSearchRenderer struct {
+	baseRenderer
+}
+
+// Render displays the query with the matching sections of code
+func (sr semanticSearchRenderer) Render(v *toolCallCmp) string {
+	var params tools.SemanticSearchParams
+	var args []string
+	if err := sr.unmarshalParams(v.call.Input, &params); err == nil {
+		args = newParamBuilder().
+			addMain(params.Query).
+			build()
+	}
+
+	return sr.renderWithParams(v, "Semantic Search", args, func() string {
+		return renderPlainContent(v, v.result.Content)
+	})
+}
+
 // -----------------------------------------------------------------------------
 //  Grep renderer
 // -----------------------------------------------------------------------------
@@ -892,6 +1086,119 @@ func (sr sourcegraphRenderer) Render(v *toolCallCmp) string {
 	})
 }
 
+// -----------------------------------------------------------------------------
+//  Kubectl renderer
+// -----------------------------------------------------------------------------
+
+// kubectlRenderer handles kubectl commands with their namespace
+type kubectlRenderer struct {
+	baseRenderer
+}
+
+// Render displays the kubectl command line with plain output
+func (kr kubectlRenderer) Render(v *toolCallCmp) string {
+	var params tools.KubectlParams
+	var args []string
+	if err := kr.unmarshalParams(v.call.Input, &params); err == nil {
+		args = newParamBuilder().
+			addMain(strings.Join(append([]string{params.Command}, params.Args...), " ")).
+			addKeyValue("namespace", params.Namespace).
+			build()
+	}
+
+	return kr.renderWithParams(v, "Kubectl", args, func() string {
+		return renderPlainContent(v, v.result.Content)
+	})
+}
+
+// -----------------------------------------------------------------------------
+//  Terraform renderer
+// -----------------------------------------------------------------------------
+
+// terraformRenderer handles terraform plans and validations
+type terraformRenderer struct {
+	baseRenderer
+}
+
+// Render displays the terraform command and directory with plain output
+func (tr terraformRenderer) Render(v *toolCallCmp) string {
+	var params tools.TerraformParams
+	var args []string
+	if err := tr.unmarshalParams(v.call.Input, &params); err == nil {
+		args = newParamBuilder().
+			addMain(params.Command).
+			addKeyValue("dir", params.Dir).
+			build()
+	}
+
+	return tr.renderWithParams(v, "Terraform", args, func() string {
+		return renderPlainContent(v, v.result.Content)
+	})
+}
+
+// -----------------------------------------------------------------------------
+//  HTTP renderer
+// -----------------------------------------------------------------------------
+
+// httpRenderer handles API requests with their method
+type httpRenderer struct {
+	baseRenderer
+}
+
+// Render displays the method and URL of the request with the response
+func (hr httpRenderer) Render(v *toolCallCmp) string {
+	var params tools.HTTPParams
+	var args []string
+	if err := hr.unmarshalParams(v.call.Input, &params); err == nil {
+		method := cmp.Or(strings.ToUpper(params.Method), "GET")
+		args = newParamBuilder().
+			addMain(method+" "+params.URL).
+			addKeyValue("select", params.Select).
+			build()
+	}
+
+	return hr.renderWithParams(v, "HTTP", args, func() string {
+		var meta tools.HTTPResponseMetadata
+		if err := hr.unmarshalParams(v.result.Metadata, &meta); err == nil && strings.Contains(meta.ContentType, "json") {
+			// Skip the status and headers, the JSON body is what's worth
+			// highlighting.
+			if _, body, ok := strings.Cut(v.result.Content, "\n\n"); ok {
+				return renderCodeContent(v, "response.json", body, 0)
+			}
+		}
+		return renderPlainContent(v, v.result.Content)
+	})
+}
+
+// -----------------------------------------------------------------------------
+//  SQL renderer
+// -----------------------------------------------------------------------------
+
+// sqlRenderer handles SQL queries, showing their results as a table
+type sqlRenderer struct {
+	baseRenderer
+}
+
+// Render displays the query and the first rows of its result in a table
+func (sr sqlRenderer) Render(v *toolCallCmp) string {
+	var params tools.SQLParams
+	var args []string
+	if err := sr.unmarshalParams(v.call.Input, &params); err == nil {
+		query := strings.Join(strings.Fields(params.Query), " ")
+		args = newParamBuilder().
+			addMain(query).
+			addKeyValue("database", params.Database).
+			build()
+	}
+
+	return sr.renderWithParams(v, "SQL", args, func() string {
+		if out, ok := renderContent(v); ok {
+			return out
+		}
+		return renderPlainContent(v, v.result.Content)
+	})
+}
+
 // -----------------------------------------------------------------------------
 //  Diagnostics renderer
 // -----------------------------------------------------------------------------
@@ -945,14 +1252,26 @@ func (tr agentRenderer) Render(v *toolCallCmp) string {
 	prompt := params.Prompt
 	prompt = strings.ReplaceAll(prompt, "\n", " ")
 
-	header := tr.makeHeader(v, "Agent", v.textWidth())
+	var args []string
+	if len(params.Tasks) > 0 {
+		args = newParamBuilder().addMain(plural(len(params.Tasks), "task")).build()
+	}
+	header := tr.makeHeader(v, "Agent", v.textWidth(), args...)
 	if res, done := earlyState(header, v); v.cancelled && done {
 		return res
 	}
-	taskTag := t.S().Base.Bold(true).Padding(0, 1).MarginLeft(2).Background(t.BlueLight).Foreground(t.White).Render("Task")
+	tag := "Task"
+	if len(params.Tasks) > 0 {
+		tag = "Tasks"
+	}
+	taskTag := t.S().Base.Bold(true).Padding(0, 1).MarginLeft(2).Background(t.BlueLight).Foreground(t.White).Render(tag)
 	remainingWidth := v.textWidth() - lipgloss.Width(header) - lipgloss.Width(taskTag) - 2
 	remainingWidth = min(remainingWidth, 120-lipgloss.Width(taskTag)-2)
-	prompt = t.S().Muted.Width(remainingWidth).Render(prompt)
+	if len(params.Tasks) > 0 {
+		prompt = renderAgentTasks(v, params.Tasks, remainingWidth)
+	} else {
+		prompt = t.S().Muted.Width(remainingWidth).Render(prompt)
+	}
 	header = lipgloss.JoinVertical(
 		lipgloss.Left,
 		header,
@@ -964,14 +1283,8 @@ func (tr agentRenderer) Render(v *toolCallCmp) string {
 			prompt,
 		),
 	)
-	childTools := tree.Root(header)
-
-	for _, call := range v.nestedToolCalls {
-		call.SetSize(remainingWidth, 1)
-		childTools.Child(call.View())
-	}
 	parts := []string{
-		childTools.Enumerator(RoundedEnumeratorWithWidth(2, lipgloss.Width(taskTag)-5)).String(),
+		renderNestedToolCalls(v, header, remainingWidth, lipgloss.Width(taskTag)-5),
 	}
 
 	if v.result.ToolCallID == "" {
@@ -994,6 +1307,63 @@ func (tr agentRenderer) Render(v *toolCallCmp) string {
 	return joinHeaderBody(header, body)
 }
 
+// renderAgentTasks renders the progress of the tasks of an agent tool call,
+// one line per task.
+func renderAgentTasks(v *toolCallCmp, tasks []string, width int) string {
+	t := styles.CurrentTheme()
+	finished := v.result.ToolCallID != ""
+	done := len(tasks)
+	if !finished {
+		done = len(v.tasksDone)
+	}
+	lines := []string{t.S().Subtle.Render(fmt.Sprintf("%d/%d done", done, len(tasks)))}
+	for i, task := range tasks {
+		icon := t.S().Subtle.Render(styles.BulletIcon)
+		if finished || v.tasksDone[i] {
+			icon = t.S().Base.Foreground(t.Success).Render(styles.CheckIcon)
+		}
+		task = ansi.Truncate(strings.ReplaceAll(task, "\n", " "), width-2, "…")
+		lines = append(lines, icon+" "+t.S().Muted.Render(task))
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
+
+// maxCollapsedNestedToolCalls is the number of latest nested tool calls
+// shown while a collapsed sub-agent is still working.
+const maxCollapsedNestedToolCalls = 3
+
+// renderNestedToolCalls renders the tool calls of a sub-agent as an indented
+// sub-thread under header. Collapsed sub-threads only show the latest calls
+// while the sub-agent works, and just their count once it's done.
+func renderNestedToolCalls(v *toolCallCmp, header string, width, enumeratorWidth int) string {
+	t := styles.CurrentTheme()
+	childTools := tree.Root(header)
+
+	calls := v.nestedToolCalls
+	if !v.expanded {
+		shown := maxCollapsedNestedToolCalls
+		if v.result.ToolCallID != "" {
+			shown = 0
+		}
+		if hidden := len(calls) - shown; hidden > 0 {
+			calls = calls[hidden:]
+			summary := plural(hidden, "tool call")
+			if shown > 0 {
+				summary = plural(hidden, "earlier tool call")
+			}
+			if v.focused {
+				summary += fmt.Sprintf(" · %s to expand", ExpandKey.Help().Key)
+			}
+			childTools.Child(t.S().Subtle.Render(summary))
+		}
+	}
+	for _, call := range calls {
+		call.SetSize(width, 1)
+		childTools.Child(call.View())
+	}
+	return childTools.Enumerator(RoundedEnumeratorWithWidth(2, enumeratorWidth)).String()
+}
+
 // renderParamList renders params, params[0] (params[1]=params[2] ....)
 func renderParamList(nested bool, paramsWidth int, params ...string) string {
 	t := styles.CurrentTheme()
@@ -1208,7 +1578,7 @@ func renderImageContent(v *toolCallCmp, data, mediaType, textContent string) str
 	t := styles.CurrentTheme()
 
 	dataSize := len(data) * 3 / 4
-	sizeStr := formatSize(dataSize)
+	sizeStr := util.FormatSize(dataSize)
 
 	loaded := t.S().Base.Foreground(t.Green).Render("Loaded")
 	arrow := t.S().Base.Foreground(t.GreenDark).Render("→")
@@ -1241,17 +1611,6 @@ func renderMediaContent(v *toolCallCmp, mediaType, textContent string) string {
 	return mediaDisplay
 }
 
-// formatSize formats byte count as human-readable size.
-func formatSize(bytes int) string {
-	if bytes < 1024 {
-		return fmt.Sprintf("%d B", bytes)
-	}
-	if bytes < 1024*1024 {
-		return fmt.Sprintf("%.1f KB", float64(bytes)/1024)
-	}
-	return fmt.Sprintf("%.1f MB", float64(bytes)/(1024*1024))
-}
-
 func (v *toolCallCmp) renderToolError() string {
 	t := styles.CurrentTheme()
 	err := strings.ReplaceAll(v.result.Content, "\n", " ")
@@ -1278,12 +1637,18 @@ func prettifyToolName(name string) string {
 		return "Job: Output"
 	case tools.JobKillToolName:
 		return "Job: Kill"
+	case tools.InteractiveToolName:
+		return "Interactive"
 	case tools.DownloadToolName:
 		return "Download"
 	case tools.EditToolName:
 		return "Edit"
 	case tools.MultiEditToolName:
 		return "Multi-Edit"
+	case tools.RenameToolName:
+		return "Rename"
+	case tools.CodeActionToolName:
+		return "Code Action"
 	case tools.FetchToolName:
 		return "Fetch"
 	case tools.AgenticFetchToolName:
@@ -1300,12 +1665,28 @@ func prettifyToolName(name string) string {
 		return "List"
 	case tools.SourcegraphToolName:
 		return "Sourcegraph"
+	case tools.SymbolsToolName:
+		return "Symbols"
+	case tools.SemanticSearchToolName:
+		return "Semantic Search"
+	case tools.MemoryToolName:
+		return "Memory"
+	case tools.KubectlToolName:
+		return "Kubectl"
+	case tools.TerraformToolName:
+		return "Terraform"
+	case tools.SQLToolName:
+		return "SQL"
+	case tools.HTTPToolName:
+		return "HTTP"
 	case tools.TodosToolName:
 		return "To-Do"
 	case tools.ViewToolName:
 		return "View"
 	case tools.WriteToolName:
 		return "Write"
+	case tools.ScreenshotToolName:
+		return "Screenshot"
 	default:
 		return name
 	}
@@ -0,0 +1,270 @@
+package messages
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/tui/components/core/layout"
+	"github.com/charmbracelet/crush/internal/tui/exp/list"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/google/uuid"
+)
+
+// minTurnSummaryToolCalls is how many tool calls a turn needs to get a
+// summary, shorter turns being easy enough to read as they are.
+const minTurnSummaryToolCalls = 5
+
+var (
+	// bashExitCodeRe matches the exit code the bash tool appends to the
+	// output of failed commands.
+	bashExitCodeRe = regexp.MustCompile(`(?m)^Exit code [1-9][0-9]*$`)
+
+	// testCommandRe matches the commands running the tests of the common
+	// toolchains.
+	testCommandRe = regexp.MustCompile(`\b(go test|cargo (nextest|test)|(npm|pnpm|yarn|bun|deno)( run)? test|pytest|tox|jest|vitest|make (check|test)|mvn (test|verify)|gradlew? test|rspec|phpunit|dotnet test|mix test|ctest)\b`)
+)
+
+// TurnSummaryCmp is a collapsible summary of the files changed, the commands
+// run and the tests status of a turn, appended after turns with many tool
+// calls.
+type TurnSummaryCmp interface {
+	list.Item
+	layout.Sizeable
+	layout.Focusable
+	ToggleExpanded()
+}
+
+type turnFile struct {
+	path      string
+	additions int
+	removals  int
+}
+
+type turnCommand struct {
+	command string
+	failed  bool
+}
+
+type turnTests int
+
+const (
+	turnTestsNotRun turnTests = iota
+	turnTestsPassed
+	turnTestsFailed
+)
+
+// turnSummary is what a turn did, as told by the calls of its tools and
+// their results.
+type turnSummary struct {
+	toolCalls int
+	files     []turnFile
+	commands  []turnCommand
+	tests     turnTests
+}
+
+// summarizeTurn sums up the tool calls of a turn. Files are listed once with
+// all their changes, and the tests status is the one of the last test
+// command, as agents usually run the tests again after fixing them.
+func summarizeTurn(calls []message.ToolCall, results map[string]message.ToolResult) turnSummary {
+	summary := turnSummary{toolCalls: len(calls)}
+	fileIndex := make(map[string]int)
+	addFile := func(path string, additions, removals int) {
+		i, ok := fileIndex[path]
+		if !ok {
+			i = len(summary.files)
+			fileIndex[path] = i
+			summary.files = append(summary.files, turnFile{path: path})
+		}
+		summary.files[i].additions += additions
+		summary.files[i].removals += removals
+	}
+
+	for _, call := range calls {
+		result, ok := results[call.ID]
+		if !ok || (result.IsError && call.Name != tools.BashToolName) {
+			continue
+		}
+		switch call.Name {
+		case tools.EditToolName, tools.MultiEditToolName, tools.WriteToolName:
+			var params struct {
+				FilePath string `json:"file_path"`
+			}
+			var meta struct {
+				Additions int `json:"additions"`
+				Removals  int `json:"removals"`
+			}
+			if json.Unmarshal([]byte(call.Input), &params) != nil || json.Unmarshal([]byte(result.Metadata), &meta) != nil {
+				continue
+			}
+			addFile(params.FilePath, meta.Additions, meta.Removals)
+		case tools.RenameToolName, tools.CodeActionToolName:
+			var meta tools.WorkspaceEditResponseMetadata
+			if json.Unmarshal([]byte(result.Metadata), &meta) != nil {
+				continue
+			}
+			for _, f := range meta.Files {
+				addFile(f.FilePath, f.Additions, f.Removals)
+			}
+		case tools.BashToolName:
+			var params tools.BashParams
+			if json.Unmarshal([]byte(call.Input), &params) != nil || params.Command == "" {
+				continue
+			}
+			failed := result.IsError || bashExitCodeRe.MatchString(result.Content)
+			summary.commands = append(summary.commands, turnCommand{command: params.Command, failed: failed})
+			if params.RunInBackground || !testCommandRe.MatchString(params.Command) {
+				continue
+			}
+			summary.tests = turnTestsPassed
+			if failed {
+				summary.tests = turnTestsFailed
+			}
+		}
+	}
+	return summary
+}
+
+type turnSummaryCmp struct {
+	id       string
+	width    int
+	focused  bool
+	expanded bool
+	summary  turnSummary
+}
+
+// NewTurnSummaryCmp returns the summary of a turn from the calls of its
+// tools and their results by call ID, or nil when the turn made too few
+// tool calls to need one.
+func NewTurnSummaryCmp(calls []message.ToolCall, results map[string]message.ToolResult) TurnSummaryCmp {
+	if len(calls) < minTurnSummaryToolCalls {
+		return nil
+	}
+	return &turnSummaryCmp{
+		id:      uuid.NewString(),
+		summary: summarizeTurn(calls, results),
+	}
+}
+
+// ID implements TurnSummaryCmp.
+func (m *turnSummaryCmp) ID() string {
+	return m.id
+}
+
+func (m *turnSummaryCmp) Init() tea.Cmd {
+	return nil
+}
+
+func (m *turnSummaryCmp) Update(tea.Msg) (util.Model, tea.Cmd) {
+	return m, nil
+}
+
+// ToggleExpanded shows or hides the files and commands of the turn.
+func (m *turnSummaryCmp) ToggleExpanded() {
+	m.expanded = !m.expanded
+}
+
+func (m *turnSummaryCmp) View() string {
+	t := styles.CurrentTheme()
+	s := m.summary
+	width := m.width - 2
+
+	arrow := styles.CollapsedIcon
+	if m.expanded {
+		arrow = styles.ExpandedIcon
+	}
+	parts := []string{fmt.Sprintf("%d tool calls", s.toolCalls)}
+	if len(s.files) > 0 {
+		additions, removals := 0, 0
+		for _, f := range s.files {
+			additions += f.additions
+			removals += f.removals
+		}
+		parts = append(parts, fmt.Sprintf("%s %s", plural(len(s.files), "file"), diffStat(additions, removals)))
+	}
+	if len(s.commands) > 0 {
+		parts = append(parts, plural(len(s.commands), "command"))
+	}
+	switch s.tests {
+	case turnTestsPassed:
+		parts = append(parts, t.S().Base.Foreground(t.Success).Render(styles.CheckIcon+" tests passed"))
+	case turnTestsFailed:
+		parts = append(parts, t.S().Base.Foreground(t.Error).Render(styles.ErrorIcon+" tests failed"))
+	}
+	header := t.S().Subtle.Render(arrow+" Turn summary") + " " + strings.Join(parts, t.S().Subtle.Render(" · "))
+	lines := []string{ansi.Truncate(header, width, "…")}
+
+	if m.expanded {
+		if len(s.files) > 0 {
+			lines = append(lines, "", t.S().Subtle.Render("Files"))
+			for _, f := range s.files {
+				stat := diffStat(f.additions, f.removals)
+				path := ansi.Truncate(fsext.PrettyPath(f.path), width-lipgloss.Width(stat)-3, "…")
+				lines = append(lines, "  "+t.S().Text.Render(path)+" "+stat)
+			}
+		}
+		if len(s.commands) > 0 {
+			lines = append(lines, "", t.S().Subtle.Render("Commands"))
+			for _, c := range s.commands {
+				icon := t.S().Base.Foreground(t.Success).Render(styles.ToolSuccess)
+				if c.failed {
+					icon = t.S().Base.Foreground(t.Error).Render(styles.ToolError)
+				}
+				command := strings.ReplaceAll(c.command, "\n", " ")
+				lines = append(lines, "  "+icon+" "+t.S().Muted.Render(ansi.Truncate(command, width-4, "…")))
+			}
+		}
+	}
+
+	style := t.S().Base.PaddingLeft(2)
+	if m.focused {
+		style = t.S().Base.PaddingLeft(1).BorderLeft(true).BorderStyle(focusedMessageBorder).BorderForeground(t.GreenDark)
+	}
+	return style.Render(strings.Join(lines, "\n"))
+}
+
+// diffStat renders the added and removed lines like the files of the
+// sidebar.
+func diffStat(additions, removals int) string {
+	t := styles.CurrentTheme()
+	return t.S().Base.Foreground(t.Success).Render(fmt.Sprintf("+%d", additions)) + " " +
+		t.S().Base.Foreground(t.Error).Render(fmt.Sprintf("-%d", removals))
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+func (m *turnSummaryCmp) GetSize() (int, int) {
+	return m.width, 0
+}
+
+func (m *turnSummaryCmp) SetSize(width int, height int) tea.Cmd {
+	m.width = width
+	return nil
+}
+
+func (m *turnSummaryCmp) Focus() tea.Cmd {
+	m.focused = true
+	return nil
+}
+
+func (m *turnSummaryCmp) Blur() tea.Cmd {
+	m.focused = false
+	return nil
+}
+
+func (m *turnSummaryCmp) IsFocused() bool {
+	return m.focused
+}
@@ -27,19 +27,22 @@ import (
 // ToolCallCmp defines the interface for tool call components in the chat interface.
 // It manages the display of tool execution including pending states, results, and errors.
 type ToolCallCmp interface {
-	util.Model                         // Basic Bubble util.Model interface
-	layout.Sizeable                    // Width/height management
-	layout.Focusable                   // Focus state management
-	GetToolCall() message.ToolCall     // Access to tool call data
-	GetToolResult() message.ToolResult // Access to tool result data
-	SetToolResult(message.ToolResult)  // Update tool result
-	SetToolCall(message.ToolCall)      // Update tool call
-	SetCancelled()                     // Mark as cancelled
-	ParentMessageID() string           // Get parent message ID
-	Spinning() bool                    // Animation state for pending tools
-	GetNestedToolCalls() []ToolCallCmp // Get nested tool calls
-	SetNestedToolCalls([]ToolCallCmp)  // Set nested tool calls
-	SetIsNested(bool)                  // Set whether this tool call is nested
+	util.Model                                // Basic Bubble util.Model interface
+	layout.Sizeable                           // Width/height management
+	layout.Focusable                          // Focus state management
+	GetToolCall() message.ToolCall            // Access to tool call data
+	GetToolResult() message.ToolResult        // Access to tool result data
+	SetToolResult(message.ToolResult) tea.Cmd // Update tool result
+	SetToolCall(message.ToolCall)             // Update tool call
+	SetCancelled()                            // Mark as cancelled
+	ParentMessageID() string                  // Get parent message ID
+	Spinning() bool                           // Animation state for pending tools
+	GetNestedToolCalls() []ToolCallCmp        // Get nested tool calls
+	SetNestedToolCalls([]ToolCallCmp) tea.Cmd // Set nested tool calls
+	SetIsNested(bool)                         // Set whether this tool call is nested
+	ToggleNestedToolCalls()                   // Expand or collapse nested tool calls
+	SetTaskDone(int)                          // Mark a task of the agent tool as done
+	SetLiveOutput(string, int)                // Update the output of a running command
 	ID() string
 	SetPermissionRequested() // Mark permission request
 	SetPermissionGranted()   // Mark permission granted
@@ -59,12 +62,20 @@ type toolCallCmp struct {
 	cancelled           bool               // Whether the tool call was cancelled
 	permissionRequested bool
 	permissionGranted   bool
+	excluded            bool // Whether the parent message is excluded from the context
 
 	// Animation state for pending tool calls
 	spinning bool       // Whether to show loading animation
 	anim     util.Model // Animation component for pending states
 
 	nestedToolCalls []ToolCallCmp // Nested tool calls for hierarchical display
+	expanded        bool          // Whether all nested tool calls are shown
+	tasksDone       map[int]bool  // Tasks of the agent tool whose sub-agent is done
+
+	// Output of a command while it runs
+	liveOutput string // The last lines printed
+	liveBytes  int    // The size of the whole output
+	activity   []int  // Bytes printed between updates, for the sparkline
 }
 
 // ToolCallOption provides functional options for configuring tool call components
@@ -96,6 +107,14 @@ func WithToolCallNestedCalls(calls []ToolCallCmp) ToolCallOption {
 	}
 }
 
+// WithToolCallExcluded marks the tool call as excluded from the context,
+// along with the message that made it.
+func WithToolCallExcluded() ToolCallOption {
+	return func(m *toolCallCmp) {
+		m.excluded = true
+	}
+}
+
 func WithToolPermissionRequested() ToolCallOption {
 	return func(m *toolCallCmp) {
 		m.permissionRequested = true
@@ -186,11 +205,29 @@ func (m *toolCallCmp) View() string {
 	if m.isNested {
 		return box.Render(r.Render(m))
 	}
+	if m.excluded {
+		return box.Render(dimExcluded(r.Render(m)))
+	}
 	return box.Render(r.Render(m))
 }
 
 // State management methods
 
+// maxActivity is the number of updates of the output of a running command
+// shown in its sparkline.
+const maxActivity = 24
+
+// SetLiveOutput updates the output of a running command, shown until its
+// result arrives.
+func (m *toolCallCmp) SetLiveOutput(tail string, bytes int) {
+	m.activity = append(m.activity, max(0, bytes-m.liveBytes))
+	if len(m.activity) > maxActivity {
+		m.activity = m.activity[len(m.activity)-maxActivity:]
+	}
+	m.liveOutput = tail
+	m.liveBytes = bytes
+}
+
 // SetCancelled marks the tool call as cancelled
 func (m *toolCallCmp) SetCancelled() {
 	m.cancelled = true
@@ -724,10 +761,12 @@ func (m *toolCallCmp) ParentMessageID() string {
 	return m.parentMessageID
 }
 
-// SetToolResult updates the tool result and stops the spinning animation
-func (m *toolCallCmp) SetToolResult(result message.ToolResult) {
+// SetToolResult updates the tool result and stops the spinning animation,
+// returning the command rendering it with a plugin, if one renders it.
+func (m *toolCallCmp) SetToolResult(result message.ToolResult) tea.Cmd {
 	m.result = result
 	m.spinning = false
+	return m.renderPlugin()
 }
 
 // GetToolCall returns the current tool call data
@@ -746,11 +785,13 @@ func (m *toolCallCmp) GetNestedToolCalls() []ToolCallCmp {
 }
 
 // SetNestedToolCalls sets the nested tool calls
-func (m *toolCallCmp) SetNestedToolCalls(calls []ToolCallCmp) {
+func (m *toolCallCmp) SetNestedToolCalls(calls []ToolCallCmp) tea.Cmd {
 	m.nestedToolCalls = calls
+	var cmds []tea.Cmd
 	for _, nested := range m.nestedToolCalls {
-		nested.SetSize(m.width, 0)
+		cmds = append(cmds, nested.SetSize(m.width, 0))
 	}
+	return tea.Batch(cmds...)
 }
 
 // SetIsNested sets whether this tool call is nested within another
@@ -760,7 +801,8 @@ func (m *toolCallCmp) SetIsNested(isNested bool) {
 
 // Rendering methods
 
-// renderPending displays the tool name with a loading animation for pending tool calls
+// renderPending displays the tool name with a loading animation for pending
+// tool calls, along with a preview of the arguments streamed so far
 func (m *toolCallCmp) renderPending() string {
 	t := styles.CurrentTheme()
 	icon := t.S().Base.Foreground(t.GreenDark).Render(styles.ToolPending)
@@ -769,7 +811,11 @@ func (m *toolCallCmp) renderPending() string {
 		return fmt.Sprintf("%s %s %s", icon, tool, m.anim.View())
 	}
 	tool := t.S().Base.Foreground(t.Blue).Render(prettifyToolName(m.call.Name))
-	return fmt.Sprintf("%s %s %s", icon, tool, m.anim.View())
+	header := fmt.Sprintf("%s %s %s", icon, tool, m.anim.View())
+	if preview := m.renderInputPreview(header); preview != "" {
+		return preview
+	}
+	return header
 }
 
 // style returns the lipgloss style for the tool call component.
@@ -822,6 +868,21 @@ func (m *toolCallCmp) Focus() tea.Cmd {
 	return nil
 }
 
+// SetTaskDone marks a task of an agent tool call run with several tasks as
+// done.
+func (m *toolCallCmp) SetTaskDone(task int) {
+	if m.tasksDone == nil {
+		m.tasksDone = make(map[int]bool)
+	}
+	m.tasksDone[task] = true
+}
+
+// ToggleNestedToolCalls shows all the nested tool calls of a sub-agent, or
+// only the latest ones.
+func (m *toolCallCmp) ToggleNestedToolCalls() {
+	m.expanded = !m.expanded
+}
+
 // IsFocused returns whether the tool call component is currently focused
 func (m *toolCallCmp) IsFocused() bool {
 	return m.focused
@@ -837,10 +898,11 @@ func (m *toolCallCmp) GetSize() (int, int) {
 // SetSize updates the width of the tool call component for text wrapping
 func (m *toolCallCmp) SetSize(width int, height int) tea.Cmd {
 	m.width = width
+	cmds := []tea.Cmd{m.renderPlugin()}
 	for _, nested := range m.nestedToolCalls {
-		nested.SetSize(width, height)
+		cmds = append(cmds, nested.SetSize(width, height))
 	}
-	return nil
+	return tea.Batch(cmds...)
 }
 
 // shouldSpin determines whether the tool call should show a loading animation.
@@ -1,6 +1,7 @@
 package sidebar
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 	"slices"
@@ -15,7 +16,10 @@ import (
 	"github.com/charmbracelet/crush/internal/fsext"
 	"github.com/charmbracelet/crush/internal/history"
 	"github.com/charmbracelet/crush/internal/home"
+	"github.com/charmbracelet/crush/internal/i18n"
 	"github.com/charmbracelet/crush/internal/lsp"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/netstatus"
 	"github.com/charmbracelet/crush/internal/pubsub"
 	"github.com/charmbracelet/crush/internal/session"
 	"github.com/charmbracelet/crush/internal/tui/components/chat"
@@ -68,17 +72,19 @@ type sidebarCmp struct {
 	width, height int
 	session       session.Session
 	logo          string
-	cwd           string
 	lspClients    *csync.Map[string, *lsp.Client]
 	compactMode   bool
 	history       history.Service
+	messages      message.Service
 	files         *csync.Map[string, SessionFile]
+	stats         *sessionStats
 }
 
-func New(history history.Service, lspClients *csync.Map[string, *lsp.Client], compact bool) Sidebar {
+func New(history history.Service, messages message.Service, lspClients *csync.Map[string, *lsp.Client], compact bool) Sidebar {
 	return &sidebarCmp{
 		lspClients:  lspClients,
 		history:     history,
+		messages:    messages,
 		compactMode: compact,
 		files:       csync.NewMap[string, SessionFile](),
 	}
@@ -96,9 +102,27 @@ func (m *sidebarCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 			m.files.Set(file.FilePath, file)
 		}
 		return m, nil
+	case sessionStatsMsg:
+		if msg.sessionID == m.session.ID {
+			m.stats = msg.stats
+		}
+		return m, nil
 
 	case chat.SessionClearedMsg:
 		m.session = session.Session{}
+		m.stats = nil
+	case pubsub.Event[message.Message]:
+		if msg.Payload.SessionID != m.session.ID {
+			return m, nil
+		}
+		if msg.Type == pubsub.DeletedEvent {
+			// What was deleted can't be taken out of the statistics, so
+			// they're computed again.
+			return m, m.loadSessionStats
+		}
+		if m.stats != nil {
+			m.stats.add(msg.Payload)
+		}
 	case pubsub.Event[history.File]:
 		return m, m.handleFileHistoryEvent(msg)
 	case pubsub.Event[session.Session]:
@@ -142,13 +166,16 @@ func (m *sidebarCmp) View() string {
 
 	if !m.compactMode {
 		parts = append(parts,
-			m.cwd,
+			cwd(m.session.WorkingDir),
 			"",
 		)
 	}
 	parts = append(parts,
 		m.currentModelBlock(),
 	)
+	if m.session.ID != "" && !(m.compactMode && m.width > m.height) {
+		parts = append(parts, "", m.statsBlock(m.getMaxWidth(), core.Section("Session", m.getMaxWidth())))
+	}
 
 	// Check if we should use horizontal layout for sections
 	if m.compactMode && m.width > m.height {
@@ -267,7 +294,6 @@ func (m *sidebarCmp) loadSessionFiles() tea.Msg {
 
 func (m *sidebarCmp) SetSize(width, height int) tea.Cmd {
 	m.logo = m.logoBlock()
-	m.cwd = cwd()
 	m.width = width
 	m.height = height
 	return nil
@@ -318,6 +344,10 @@ func (m *sidebarCmp) calculateAvailableHeight() int {
 
 	usedHeight += 2 // Model info
 
+	if m.session.ID != "" {
+		usedHeight += m.statsHeight()
+	}
+
 	usedHeight += 6 // 3 sections × 2 lines each (header + empty line)
 
 	// Base padding
@@ -366,11 +396,15 @@ func (m *sidebarCmp) getDynamicLimits() (maxFiles, maxLSPs, maxMCPs int) {
 	return maxFiles, maxLSPs, maxMCPs
 }
 
-// renderSectionsHorizontal renders the files, LSPs, and MCPs sections horizontally
+// renderSectionsHorizontal renders the session, files, LSPs, and MCPs sections horizontally
 func (m *sidebarCmp) renderSectionsHorizontal() string {
 	// Calculate available width for each section
 	totalWidth := m.width - 4 // Account for padding and spacing
-	sectionWidth := min(50, totalWidth/3)
+	sections := 3
+	if m.session.ID != "" {
+		sections++
+	}
+	sectionWidth := min(50, totalWidth/sections)
 
 	// Get the sections content with limited height
 	var filesContent, lspContent, mcpContent string
@@ -379,6 +413,10 @@ func (m *sidebarCmp) renderSectionsHorizontal() string {
 	lspContent = m.lspBlockCompact(sectionWidth)
 	mcpContent = m.mcpBlockCompact(sectionWidth)
 
+	if m.session.ID != "" {
+		statsContent := m.statsBlock(sectionWidth, "Session")
+		return lipgloss.JoinHorizontal(lipgloss.Top, statsContent, " ", filesContent, " ", lspContent, " ", mcpContent)
+	}
 	return lipgloss.JoinHorizontal(lipgloss.Top, filesContent, " ", lspContent, " ", mcpContent)
 }
 
@@ -473,7 +511,7 @@ func (m *sidebarCmp) filesBlock() string {
 		MaxWidth:    m.getMaxWidth(),
 		MaxItems:    maxFiles,
 		ShowSection: true,
-		SectionName: core.Section("Modified Files", m.getMaxWidth()),
+		SectionName: core.Section(i18n.T("Modified Files"), m.getMaxWidth()),
 	}, true)
 }
 
@@ -505,9 +543,8 @@ func (m *sidebarCmp) mcpBlock() string {
 	}, true)
 }
 
-func formatTokensAndCost(tokens, contextWindow int64, cost float64) string {
-	t := styles.CurrentTheme()
-	// Format tokens in human-readable format (e.g., 110K, 1.2M)
+// formatTokens formats tokens in human-readable format (e.g., 110K, 1.2M).
+func formatTokens(tokens int64) string {
 	var formattedTokens string
 	switch {
 	case tokens >= 1_000_000:
@@ -525,6 +562,12 @@ func formatTokensAndCost(tokens, contextWindow int64, cost float64) string {
 	if strings.HasSuffix(formattedTokens, ".0M") {
 		formattedTokens = strings.Replace(formattedTokens, ".0M", "M", 1)
 	}
+	return formattedTokens
+}
+
+func formatTokensAndCost(tokens, contextWindow int64, cost float64) string {
+	t := styles.CurrentTheme()
+	formattedTokens := formatTokens(tokens)
 
 	percentage := (float64(tokens) / float64(contextWindow)) * 100
 
@@ -555,8 +598,14 @@ func (s *sidebarCmp) currentModelBlock() string {
 	t := styles.CurrentTheme()
 
 	modelIcon := t.S().Base.Foreground(t.FgSubtle).Render(styles.ModelIcon)
-	modelName := t.S().Text.Render(model.Name)
+	modelName := t.S().Text.Render(util.Truncate(model.Name, s.getMaxWidth()-lipgloss.Width(modelIcon)-1))
 	modelInfo := fmt.Sprintf("%s %s", modelIcon, modelName)
+	if cfg.IsLocalOnly() {
+		modelInfo += " " + styles.LocalOnlyBadge()
+	}
+	if !netstatus.Online() {
+		modelInfo += " " + styles.OfflineBadge()
+	}
 	parts := []string{
 		modelInfo,
 	}
@@ -598,7 +647,8 @@ func (s *sidebarCmp) currentModelBlock() string {
 // SetSession implements Sidebar.
 func (m *sidebarCmp) SetSession(session session.Session) tea.Cmd {
 	m.session = session
-	return m.loadSessionFiles
+	m.stats = nil
+	return tea.Batch(m.loadSessionFiles, m.loadSessionStats)
 }
 
 // SetCompactMode sets the compact mode for the sidebar.
@@ -606,8 +656,10 @@ func (m *sidebarCmp) SetCompactMode(compact bool) {
 	m.compactMode = compact
 }
 
-func cwd() string {
-	cwd := config.Get().WorkingDir()
+// cwd renders the working directory of the session, which defaults to the
+// one of Crush.
+func cwd(sessionDir string) string {
+	cwd := cmp.Or(sessionDir, config.Get().WorkingDir())
 	t := styles.CurrentTheme()
 	return t.S().Muted.Render(home.Short(cwd))
 }
@@ -0,0 +1,233 @@
+package sidebar
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// testCommand matches the shell commands running tests.
+var testCommand = regexp.MustCompile(`(^|[\s;&|(])(go test|(npm|yarn|pnpm|bun|deno|cargo|make|mix|dotnet|mvn|gradle|\./gradlew) (run )?test|pytest|python3? -m (pytest|unittest)|jest|vitest|rspec)\b`)
+
+// failedCommand matches the results of shell commands that didn't succeed.
+var failedCommand = regexp.MustCompile(`(?m)^(Exit code [1-9][0-9]*|Command was aborted before completion)$`)
+
+// sessionStats are the aggregate statistics of the messages of a session.
+// They're updated with each message as it changes, so they stay cheap to
+// keep on long sessions.
+type sessionStats struct {
+	// toolCalls are the number of finished calls by tool, and counted the
+	// IDs of the ones already counted.
+	toolCalls map[string]int
+	counted   map[string]bool
+	read      map[string]bool
+	edited    map[string]bool
+	// testCalls are the IDs of the calls running tests, and tests whether
+	// each of their runs passed.
+	testCalls map[string]bool
+	tests     map[string]bool
+}
+
+// sessionStatsMsg carries the statistics of a session, computed from all
+// of its messages.
+type sessionStatsMsg struct {
+	sessionID string
+	stats     *sessionStats
+}
+
+func newSessionStats() *sessionStats {
+	return &sessionStats{
+		toolCalls: map[string]int{},
+		counted:   map[string]bool{},
+		read:      map[string]bool{},
+		edited:    map[string]bool{},
+		testCalls: map[string]bool{},
+		tests:     map[string]bool{},
+	}
+}
+
+// add accounts for a message. Messages can be added again as they're
+// updated, each tool call and result is only counted once.
+func (s *sessionStats) add(msg message.Message) {
+	for _, call := range msg.ToolCalls() {
+		if !call.Finished || s.counted[call.ID] {
+			continue
+		}
+		s.counted[call.ID] = true
+		s.toolCalls[call.Name]++
+
+		var params struct {
+			FilePath string `json:"file_path"`
+			Command  string `json:"command"`
+		}
+		_ = json.Unmarshal([]byte(call.Input), &params)
+		switch call.Name {
+		case tools.ViewToolName:
+			s.read[params.FilePath] = params.FilePath != ""
+		case tools.EditToolName, tools.MultiEditToolName, tools.WriteToolName:
+			s.edited[params.FilePath] = params.FilePath != ""
+		case tools.BashToolName:
+			if testCommand.MatchString(params.Command) {
+				s.testCalls[call.ID] = true
+			}
+		}
+	}
+
+	for _, result := range msg.ToolResults() {
+		if _, ok := s.tests[result.ToolCallID]; ok {
+			continue
+		}
+		if passed, ok := testResults(result); ok {
+			s.tests[result.ToolCallID] = passed
+		} else if s.testCalls[result.ToolCallID] {
+			s.tests[result.ToolCallID] = !result.IsError && !failedCommand.MatchString(result.Content)
+		}
+	}
+}
+
+// testResults reports whether a result is the outcome of running tests, and
+// whether they all passed.
+func testResults(result message.ToolResult) (passed, ok bool) {
+	if result.Metadata == "" {
+		return false, false
+	}
+	var meta tools.TestResultsMetadata
+	if err := json.Unmarshal([]byte(result.Metadata), &meta); err != nil || meta.ContentType != tools.ContentTypeTestResults {
+		return false, false
+	}
+	for _, test := range meta.Tests {
+		if test.Status == tools.TestFailed {
+			return false, true
+		}
+	}
+	return !result.IsError, true
+}
+
+func (s *sessionStats) totalToolCalls() int {
+	var total int
+	for _, n := range s.toolCalls {
+		total += n
+	}
+	return total
+}
+
+// topTools returns the names of the most called tools first.
+func (s *sessionStats) topTools() []string {
+	return slices.SortedFunc(maps.Keys(s.toolCalls), func(a, b string) int {
+		return cmp.Or(cmp.Compare(s.toolCalls[b], s.toolCalls[a]), strings.Compare(a, b))
+	})
+}
+
+func count(set map[string]bool) int {
+	var n int
+	for _, ok := range set {
+		if ok {
+			n++
+		}
+	}
+	return n
+}
+
+func (s *sessionStats) testsPassed() int {
+	return count(s.tests)
+}
+
+func (m *sidebarCmp) loadSessionStats() tea.Msg {
+	sessionID := m.session.ID
+	msgs, err := m.messages.List(context.Background(), sessionID)
+	if err != nil {
+		return util.InfoMsg{
+			Type: util.InfoTypeError,
+			Msg:  err.Error(),
+		}
+	}
+	stats := newSessionStats()
+	for _, msg := range msgs {
+		stats.add(msg)
+	}
+	return sessionStatsMsg{sessionID: sessionID, stats: stats}
+}
+
+// statsLines returns the lines of the statistics of the session.
+func (m *sidebarCmp) statsLines(maxWidth int) []string {
+	t := styles.CurrentTheme()
+	label := func(s string) string {
+		return t.S().Subtle.Width(7).Render(s)
+	}
+	value := t.S().Muted
+
+	lines := []string{
+		label("Tokens") + value.Render(fmt.Sprintf(
+			"%s in · %s out",
+			formatTokens(m.session.TotalPromptTokens),
+			formatTokens(m.session.TotalCompletionTokens),
+		)),
+		label("Cost") + value.Render(fmt.Sprintf("$%.2f", m.session.Cost)),
+	}
+	stats := m.stats
+	if stats == nil {
+		stats = newSessionStats()
+	}
+
+	calls := label("Tools") + value.Render(plural(stats.totalToolCalls(), "call"))
+	if top := stats.topTools(); len(top) > 0 {
+		names := make([]string, 0, 3)
+		for _, name := range top[:min(3, len(top))] {
+			names = append(names, fmt.Sprintf("%s %d", name, stats.toolCalls[name]))
+		}
+		calls += t.S().Subtle.Render(" · " + strings.Join(names, ", "))
+	}
+	lines = append(lines,
+		calls,
+		label("Files")+value.Render(fmt.Sprintf("%d read · %d edited", count(stats.read), count(stats.edited))),
+	)
+	if runs := len(stats.tests); runs > 0 {
+		passed := stats.testsPassed()
+		lines = append(lines, label("Tests")+value.Render(fmt.Sprintf(
+			"%s · %d%% passed", plural(runs, "run"), passed*100/runs,
+		)))
+	}
+
+	for i, line := range lines {
+		lines[i] = ansi.Truncate(line, maxWidth, "…")
+	}
+	return lines
+}
+
+// statsBlock renders the statistics of the session.
+func (m *sidebarCmp) statsBlock(maxWidth int, section string) string {
+	t := styles.CurrentTheme()
+	return lipgloss.JoinVertical(lipgloss.Left,
+		append([]string{t.S().Subtle.Render(section), ""}, m.statsLines(maxWidth)...)...,
+	)
+}
+
+// statsHeight is the height of the statistics block, with the empty line
+// following it.
+func (m *sidebarCmp) statsHeight() int {
+	height := 4 + 3 // Lines, section header and empty lines
+	if m.stats != nil && len(m.stats.tests) > 0 {
+		height++
+	}
+	return height
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -0,0 +1,41 @@
+package sidebar
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionStats(t *testing.T) {
+	stats := newSessionStats()
+	calls := message.Message{
+		Role: message.Assistant,
+		Parts: []message.ContentPart{
+			message.ToolCall{ID: "1", Name: "view", Input: `{"file_path":"a.go"}`, Finished: true},
+			message.ToolCall{ID: "2", Name: "edit", Input: `{"file_path":"a.go"}`, Finished: true},
+			message.ToolCall{ID: "3", Name: "bash", Input: `{"command":"cd x && go test ./..."}`, Finished: true},
+			message.ToolCall{ID: "4", Name: "bash", Input: `{"command":"npm test"}`, Finished: true},
+			message.ToolCall{ID: "5", Name: "bash", Input: `{"command":"ls"}`},
+		},
+	}
+	// Messages are added again each time they're updated.
+	stats.add(calls)
+	stats.add(calls)
+	stats.add(message.Message{
+		Role: message.Tool,
+		Parts: []message.ContentPart{
+			message.ToolResult{ToolCallID: "3", Content: "ok"},
+			message.ToolResult{ToolCallID: "4", Content: "1 failing\nExit code 1"},
+			message.ToolResult{ToolCallID: "6", Metadata: `{"content_type":"application/vnd.crush.test-results+json","tests":[{"name":"a","status":"pass"}]}`},
+		},
+	})
+
+	require.Equal(t, map[string]int{"view": 1, "edit": 1, "bash": 2}, stats.toolCalls)
+	require.Equal(t, 4, stats.totalToolCalls())
+	require.Equal(t, []string{"bash", "edit", "view"}, stats.topTools())
+	require.Equal(t, 1, count(stats.read))
+	require.Equal(t, 1, count(stats.edited))
+	require.Len(t, stats.tests, 3)
+	require.Equal(t, 2, stats.testsPassed())
+}
@@ -8,6 +8,7 @@ import (
 	"charm.land/bubbles/v2/key"
 	"charm.land/lipgloss/v2"
 	"github.com/alecthomas/chroma/v2"
+	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/tui/exp/diffview"
 	"github.com/charmbracelet/crush/internal/tui/styles"
 	"github.com/charmbracelet/x/ansi"
@@ -202,6 +203,26 @@ func DiffFormatter() *diffview.DiffView {
 	t := styles.CurrentTheme()
 	formatDiff := diffview.New()
 	style := chroma.MustNewStyle("crush", styles.GetChromaTheme())
-	diff := formatDiff.ChromaStyle(style).Style(t.S().Diff).TabWidth(4)
+	diff := formatDiff.ChromaStyle(style).Style(t.S().Diff).TabWidth(4).WordDiff(true)
+	if cfg := config.Get(); cfg != nil && cfg.Options != nil && cfg.Options.TUI != nil {
+		diff = diff.WordDiff(!cfg.Options.TUI.DisableWordDiff)
+		if n := cfg.Options.TUI.DiffContextLines; n != nil {
+			diff = diff.ContextLines(max(0, *n))
+		}
+	}
 	return diff
 }
+
+// SplitDiff reports whether diffs shown at the given width are side by side,
+// as set by the diff mode of the config, or when there's room for it.
+func SplitDiff(width int) bool {
+	if cfg := config.Get(); cfg != nil && cfg.Options != nil && cfg.Options.TUI != nil {
+		switch cfg.Options.TUI.DiffMode {
+		case "split":
+			return true
+		case "unified":
+			return false
+		}
+	}
+	return width > 120
+}
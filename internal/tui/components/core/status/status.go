@@ -1,11 +1,18 @@
 package status
 
 import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
 	"time"
 
 	"charm.land/bubbles/v2/help"
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/agent"
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/charmbracelet/crush/internal/ratelimit"
 	"github.com/charmbracelet/crush/internal/tui/styles"
 	"github.com/charmbracelet/crush/internal/tui/util"
 	"github.com/charmbracelet/x/ansi"
@@ -23,6 +30,9 @@ type statusCmp struct {
 	messageTTL time.Duration
 	help       help.Model
 	keyMap     help.KeyMap
+	queued     map[string]int // queued requests by provider
+	waiting    int            // runs waiting in the run queue
+	latest     string         // version of the available update
 }
 
 // clearMessageCmd is a command that clears status messages after a timeout
@@ -53,19 +63,76 @@ func (m *statusCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		return m, m.clearMessageCmd(ttl)
 	case util.ClearStatusMsg:
 		m.info = util.InfoMsg{}
+	case pubsub.Event[ratelimit.Event]:
+		if msg.Payload.Queued > 0 {
+			m.queued[msg.Payload.Provider] = msg.Payload.Queued
+		} else {
+			delete(m.queued, msg.Payload.Provider)
+		}
+	case pubsub.Event[agent.RunQueueEvent]:
+		m.waiting = msg.Payload.Waiting
+	case pubsub.UpdateAvailableMsg:
+		if !msg.IsDevelopment {
+			m.latest = msg.LatestVersion
+		}
 	}
 	return m, nil
 }
 
 func (m *statusCmp) View() string {
 	t := styles.CurrentTheme()
-	status := t.S().Base.Padding(0, 1, 0, 1).Render(m.help.View(m.keyMap))
+	status := m.helpView()
 	if m.info.Msg != "" {
 		status = m.infoMsg()
+	} else if len(m.queued) > 0 || m.waiting > 0 {
+		status = m.queuedMsg()
 	}
 	return status
 }
 
+// helpView renders the help, with a badge on the right when an update is
+// available.
+func (m *statusCmp) helpView() string {
+	t := styles.CurrentTheme()
+	if m.latest == "" || m.help.ShowAll {
+		return t.S().Base.Padding(0, 1, 0, 1).Render(m.help.View(m.keyMap))
+	}
+	badge := t.S().Base.Foreground(t.BgSubtle).Background(t.Green).Padding(0, 1).Render("↑ v" + m.latest)
+	m.help.SetWidth(m.width - 2 - lipgloss.Width(badge) - 1)
+	defer m.help.SetWidth(m.width - 2)
+	help := t.S().Base.Padding(0, 1, 0, 1).Render(m.help.View(m.keyMap))
+	gap := m.width - lipgloss.Width(help) - lipgloss.Width(badge)
+	if gap < 1 {
+		return help
+	}
+	return help + strings.Repeat(" ", gap) + badge
+}
+
+func (m *statusCmp) queuedMsg() string {
+	t := styles.CurrentTheme()
+	var msgs []string
+	if m.waiting > 0 {
+		runs := "runs"
+		if m.waiting == 1 {
+			runs = "run"
+		}
+		msgs = append(msgs, fmt.Sprintf("%d %s waiting in the run queue", m.waiting, runs))
+	}
+	if len(m.queued) > 0 {
+		providers := slices.Sorted(maps.Keys(m.queued))
+		parts := make([]string, 0, len(providers))
+		for _, p := range providers {
+			parts = append(parts, fmt.Sprintf("%s (%d)", p, m.queued[p]))
+		}
+		msgs = append(msgs, "Waiting for provider limits: "+strings.Join(parts, ", "))
+	}
+	infoType := t.S().Base.Foreground(t.BgOverlay).Background(t.Yellow).Padding(0, 1).Render("QUEUED")
+	widthLeft := m.width - (lipgloss.Width(infoType) + 2)
+	info := util.Truncate(strings.Join(msgs, " · "), widthLeft)
+	message := t.S().Base.Background(t.BgSubtle).Width(widthLeft+2).Foreground(t.FgMuted).Padding(0, 1).Render(info)
+	return ansi.Truncate(infoType+message, m.width, "…")
+}
+
 func (m *statusCmp) infoMsg() string {
 	t := styles.CurrentTheme()
 	message := ""
@@ -74,12 +141,12 @@ func (m *statusCmp) infoMsg() string {
 	case util.InfoTypeError:
 		infoType = t.S().Base.Background(t.Red).Padding(0, 1).Render("ERROR")
 		widthLeft := m.width - (lipgloss.Width(infoType) + 2)
-		info := ansi.Truncate(m.info.Msg, widthLeft, "…")
+		info := util.Truncate(m.info.Msg, widthLeft)
 		message = t.S().Base.Background(t.Error).Width(widthLeft+2).Foreground(t.White).Padding(0, 1).Render(info)
 	case util.InfoTypeWarn:
 		infoType = t.S().Base.Foreground(t.BgOverlay).Background(t.Yellow).Padding(0, 1).Render("WARNING")
 		widthLeft := m.width - (lipgloss.Width(infoType) + 2)
-		info := ansi.Truncate(m.info.Msg, widthLeft, "…")
+		info := util.Truncate(m.info.Msg, widthLeft)
 		message = t.S().Base.Foreground(t.BgOverlay).Width(widthLeft+2).Background(t.Warning).Padding(0, 1).Render(info)
 	default:
 		note := "OKAY!"
@@ -88,7 +155,7 @@ func (m *statusCmp) infoMsg() string {
 		}
 		infoType = t.S().Base.Foreground(t.BgSubtle).Background(t.Green).Padding(0, 1).Bold(true).Render(note)
 		widthLeft := m.width - (lipgloss.Width(infoType) + 2)
-		info := ansi.Truncate(m.info.Msg, widthLeft, "…")
+		info := util.Truncate(m.info.Msg, widthLeft)
 		message = t.S().Base.Background(t.GreenDark).Width(widthLeft+2).Foreground(t.BgSubtle).Padding(0, 1).Render(info)
 	}
 	return ansi.Truncate(infoType+message, m.width, "…")
@@ -109,5 +176,6 @@ func NewStatusCmp() StatusCmp {
 	return &statusCmp{
 		messageTTL: 5 * time.Second,
 		help:       help,
+		queued:     make(map[string]int),
 	}
 }
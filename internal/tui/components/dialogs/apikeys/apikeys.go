@@ -0,0 +1,145 @@
+// Package apikeys provides a dialog showing which of the API keys of each
+// provider is in use, and how each key fared.
+package apikeys
+
+import (
+	"fmt"
+	"time"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+
+	"github.com/charmbracelet/crush/internal/keyring"
+	"github.com/charmbracelet/crush/internal/tui/components/core"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+)
+
+const (
+	APIKeysDialogID dialogs.DialogID = "api_keys"
+
+	width = 76
+)
+
+// APIKeysDialog shows the status of the API keys of the providers.
+type APIKeysDialog interface {
+	dialogs.DialogModel
+}
+
+type apiKeysDialogCmp struct {
+	wWidth  int
+	wHeight int
+
+	status []keyring.Status
+	keyMap KeyMap
+	help   help.Model
+}
+
+// NewAPIKeysDialog creates the dialog of the API keys.
+func NewAPIKeysDialog() APIKeysDialog {
+	t := styles.CurrentTheme()
+	help := help.New()
+	help.Styles = t.S().Help
+	return &apiKeysDialogCmp{
+		status: keyring.All(),
+		keyMap: DefaultKeyMap(),
+		help:   help,
+	}
+}
+
+func (m *apiKeysDialogCmp) Init() tea.Cmd {
+	return nil
+}
+
+func (m *apiKeysDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.wWidth = msg.Width
+		m.wHeight = msg.Height
+	case tea.KeyPressMsg:
+		if key.Matches(msg, m.keyMap.Close) {
+			return m, util.CmdHandler(dialogs.CloseDialogMsg{})
+		}
+	}
+	return m, nil
+}
+
+func (m *apiKeysDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	baseStyle := t.S().Base
+
+	row := func(style lipgloss.Style, key, requests, rateLimited, rejected, state string) string {
+		return lipgloss.JoinHorizontal(
+			lipgloss.Top,
+			style.Width(14).Render(key),
+			style.Width(12).Render(requests),
+			style.Width(14).Render(rateLimited),
+			style.Width(12).Render(rejected),
+			style.Render(state),
+		)
+	}
+
+	var rows []string
+	for i, status := range m.status {
+		if i > 0 {
+			rows = append(rows, "")
+		}
+		rows = append(rows, t.S().Title.Render(status.Provider))
+		rows = append(rows, row(t.S().Muted, "  Key", "Requests", "Rate limited", "Rejected", ""))
+		for _, ks := range status.Keys {
+			style, marker, state := t.S().Text, "  ", ""
+			switch {
+			case !ks.Until.IsZero():
+				style = t.S().Subtle
+				state = "resting " + time.Until(ks.Until).Round(time.Second).String()
+			case ks.Active:
+				marker, state = "● ", "active"
+			}
+			rows = append(rows, row(
+				style,
+				marker+ks.Key,
+				fmt.Sprint(ks.Requests),
+				fmt.Sprint(ks.RateLimited),
+				fmt.Sprint(ks.Unauthorized),
+				state,
+			))
+		}
+	}
+	if len(m.status) == 0 {
+		rows = append(rows, t.S().Subtle.Render("No provider has more than one API key."))
+	}
+
+	content := baseStyle.Width(width).Render(
+		lipgloss.JoinVertical(
+			lipgloss.Left,
+			core.Title("API Keys", width),
+			"",
+			lipgloss.JoinVertical(lipgloss.Left, rows...),
+			"",
+			t.S().Subtle.Render("Keys rest after being rate limited or rejected, and the next one is used."),
+			"",
+			m.help.View(m.keyMap),
+		),
+	)
+
+	return baseStyle.
+		Padding(1, 2).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus).
+		Render(content)
+}
+
+func (m *apiKeysDialogCmp) Position() (int, int) {
+	row := m.wHeight / 2
+	row -= lipgloss.Height(m.View()) / 2
+	col := m.wWidth / 2
+	col -= (width + 6) / 2
+	return row, col
+}
+
+func (m *apiKeysDialogCmp) ID() dialogs.DialogID {
+	return APIKeysDialogID
+}
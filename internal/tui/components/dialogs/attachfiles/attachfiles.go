@@ -0,0 +1,351 @@
+// Package attachfiles provides the dialog to attach several files at once,
+// given as a file, a directory or a glob, with a checklist to leave some of
+// them out before sending.
+package attachfiles
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/tui/components/core"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/commands"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/filepicker"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+	"github.com/charmbracelet/x/ansi"
+)
+
+const (
+	AttachFilesDialogID dialogs.DialogID = "attach_files"
+
+	defaultWidth = 70
+	maxFiles     = 200
+	maxVisible   = 12
+)
+
+// file is an entry of the checklist.
+type file struct {
+	attachment message.Attachment
+	tokens     int
+	selected   bool
+}
+
+// expandedMsg carries the files a pattern expanded to.
+type expandedMsg struct {
+	files     []file
+	skipped   int
+	truncated bool
+	err       error
+}
+
+type AttachFilesDialog interface {
+	dialogs.DialogModel
+}
+
+type attachFilesDialogCmp struct {
+	width   int
+	wWidth  int // Width of the terminal window
+	wHeight int // Height of the terminal window
+
+	workingDir  string
+	allowImages bool
+	input       textinput.Model
+
+	// files is nil while the pattern is being typed.
+	files     []file
+	skipped   int
+	truncated bool
+	cursor    int
+	offset    int
+
+	keyMap KeyMap
+	help   help.Model
+}
+
+// NewAttachFilesDialog creates the dialog. Images are only attached when
+// allowImages is set, as not every model takes them.
+func NewAttachFilesDialog(workingDir string, allowImages bool) AttachFilesDialog {
+	t := styles.CurrentTheme()
+	input := textinput.New()
+	input.Prompt = "> "
+	input.Placeholder = "src/**/*.go"
+	input.SetVirtualCursor(true)
+	input.SetStyles(t.S().TextInput)
+	input.Focus()
+
+	help := help.New()
+	help.Styles = t.S().Help
+
+	d := &attachFilesDialogCmp{
+		width:       defaultWidth,
+		workingDir:  workingDir,
+		allowImages: allowImages,
+		input:       input,
+		keyMap:      DefaultKeyMap(),
+		help:        help,
+	}
+	d.updateKeys()
+	return d
+}
+
+func (d *attachFilesDialogCmp) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (d *attachFilesDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		d.wWidth = msg.Width
+		d.wHeight = msg.Height
+		d.width = min(defaultWidth, d.wWidth-4)
+		d.input.SetWidth(d.width - 6)
+		return d, nil
+	case expandedMsg:
+		if msg.err != nil {
+			return d, util.ReportError(msg.err)
+		}
+		if len(msg.files) == 0 {
+			return d, util.ReportWarn("No files to attach for " + d.input.Value())
+		}
+		d.files, d.skipped, d.truncated = msg.files, msg.skipped, msg.truncated
+		d.cursor, d.offset = 0, 0
+		d.input.Blur()
+		d.updateKeys()
+		return d, nil
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, d.keyMap.Close):
+			return d, util.CmdHandler(dialogs.CloseDialogMsg{})
+		case key.Matches(msg, d.keyMap.BrowseImages):
+			return d, tea.Sequence(
+				util.CmdHandler(dialogs.CloseDialogMsg{}),
+				util.CmdHandler(commands.OpenFilePickerMsg{}),
+			)
+		case d.files == nil && key.Matches(msg, d.keyMap.Select):
+			pattern := strings.TrimSpace(d.input.Value())
+			if pattern == "" {
+				return d, nil
+			}
+			return d, d.expand(pattern)
+		case d.files == nil:
+			var cmd tea.Cmd
+			d.input, cmd = d.input.Update(msg)
+			return d, cmd
+		case key.Matches(msg, d.keyMap.Select):
+			return d, d.attach()
+		case key.Matches(msg, d.keyMap.Back):
+			d.files = nil
+			d.updateKeys()
+			return d, d.input.Focus()
+		case key.Matches(msg, d.keyMap.Next):
+			d.moveCursor(1)
+		case key.Matches(msg, d.keyMap.Previous):
+			d.moveCursor(-1)
+		case key.Matches(msg, d.keyMap.Toggle):
+			d.files[d.cursor].selected = !d.files[d.cursor].selected
+		case key.Matches(msg, d.keyMap.ToggleAll):
+			count, _ := d.selection()
+			for i := range d.files {
+				d.files[i].selected = count < len(d.files)
+			}
+		}
+	}
+	return d, nil
+}
+
+// expand reads the files the pattern stands for, leaving out the ones that
+// can't be attached.
+func (d *attachFilesDialogCmp) expand(pattern string) tea.Cmd {
+	return func() tea.Msg {
+		pattern, err := fsext.Expand(pattern)
+		if err != nil {
+			return expandedMsg{err: err}
+		}
+		paths, truncated, err := fsext.ExpandFiles(pattern, d.workingDir, maxFiles)
+		if err != nil {
+			return expandedMsg{err: err}
+		}
+		var files []file
+		skipped := 0
+		for _, path := range paths {
+			f, ok := d.readFile(path)
+			if !ok {
+				skipped++
+				continue
+			}
+			files = append(files, f)
+		}
+		return expandedMsg{files: files, skipped: skipped, truncated: truncated}
+	}
+}
+
+func (d *attachFilesDialogCmp) readFile(path string) (file, bool) {
+	if tooBig, err := filepicker.IsFileTooBig(path, filepicker.MaxAttachmentSize); err != nil || tooBig {
+		return file{}, false
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return file{}, false
+	}
+	attachment := message.Attachment{
+		FilePath: path,
+		FileName: filepath.Base(path),
+		MimeType: http.DetectContentType(content[:min(512, len(content))]),
+		Content:  content,
+	}
+	switch {
+	case attachment.IsText():
+		return file{attachment: attachment, tokens: util.EstimateTokens(content), selected: true}, true
+	case attachment.IsImage() && d.allowImages:
+		return file{attachment: attachment, selected: true}, true
+	}
+	return file{}, false
+}
+
+func (d *attachFilesDialogCmp) attach() tea.Cmd {
+	cmds := []tea.Cmd{util.CmdHandler(dialogs.CloseDialogMsg{})}
+	for _, f := range d.files {
+		if f.selected {
+			cmds = append(cmds, util.CmdHandler(filepicker.FilePickedMsg{Attachment: f.attachment}))
+		}
+	}
+	return tea.Sequence(cmds...)
+}
+
+func (d *attachFilesDialogCmp) moveCursor(delta int) {
+	d.cursor = (d.cursor + delta + len(d.files)) % len(d.files)
+	if d.cursor < d.offset {
+		d.offset = d.cursor
+	}
+	if d.cursor >= d.offset+maxVisible {
+		d.offset = d.cursor - maxVisible + 1
+	}
+}
+
+// selection returns the number of selected files and their estimated
+// tokens.
+func (d *attachFilesDialogCmp) selection() (int, int) {
+	count, tokens := 0, 0
+	for _, f := range d.files {
+		if f.selected {
+			count++
+			tokens += f.tokens
+		}
+	}
+	return count, tokens
+}
+
+// updateKeys enables the keys of the current step.
+func (d *attachFilesDialogCmp) updateKeys() {
+	listing := d.files != nil
+	d.keyMap.Next.SetEnabled(listing)
+	d.keyMap.Previous.SetEnabled(listing)
+	d.keyMap.Toggle.SetEnabled(listing)
+	d.keyMap.ToggleAll.SetEnabled(listing)
+	d.keyMap.Back.SetEnabled(listing)
+	d.keyMap.BrowseImages.SetEnabled(!listing && d.allowImages)
+	if listing {
+		d.keyMap.Select.SetHelp("enter", "attach")
+	} else {
+		d.keyMap.Select.SetHelp("enter", "expand")
+	}
+}
+
+func (d *attachFilesDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	header := t.S().Base.Padding(0, 1, 1, 1).Render(core.Title("Attach Files", d.width-4))
+
+	var body string
+	if d.files == nil {
+		body = lipgloss.JoinVertical(
+			lipgloss.Left,
+			d.input.View(),
+			"",
+			t.S().Subtle.Width(d.width-4).Render("A file, a directory or a glob. Files ignored by .gitignore and .crushignore are left out."),
+		)
+	} else {
+		body = d.checklist()
+	}
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		header,
+		t.S().Base.PaddingLeft(1).Render(body),
+		"",
+		t.S().Base.Width(d.width-2).PaddingLeft(1).AlignHorizontal(lipgloss.Left).Render(d.help.View(d.keyMap)),
+	)
+	return d.style().Render(content)
+}
+
+func (d *attachFilesDialogCmp) checklist() string {
+	t := styles.CurrentTheme()
+	lines := make([]string, 0, maxVisible+2)
+	end := min(d.offset+maxVisible, len(d.files))
+	for i, f := range d.files[d.offset:end] {
+		check := "[ ]"
+		if f.selected {
+			check = "[x]"
+		}
+		size := "image"
+		if f.attachment.IsText() {
+			size = "~" + util.FormatTokens(f.tokens)
+		}
+		name := ansi.Truncate(displayPath(f.attachment.FilePath, d.workingDir), d.width-lipgloss.Width(size)-12, "…")
+		gap := strings.Repeat(" ", max(1, d.width-8-lipgloss.Width(name)-lipgloss.Width(size)))
+		line := check + " " + name + gap + t.S().Subtle.Render(size)
+		if d.offset+i == d.cursor {
+			line = t.S().Text.Bold(true).Render("> ") + line
+		} else {
+			line = "  " + line
+		}
+		lines = append(lines, line)
+	}
+
+	count, tokens := d.selection()
+	summary := fmt.Sprintf("%d of %d files selected, ~%s tokens", count, len(d.files), util.FormatTokens(tokens))
+	if d.skipped > 0 {
+		summary += fmt.Sprintf(" (%d binary or large files skipped)", d.skipped)
+	}
+	if d.truncated {
+		summary += fmt.Sprintf(" (only the first %d files)", maxFiles)
+	}
+	lines = append(lines, "", t.S().Muted.Width(d.width-4).Render(summary))
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
+
+// displayPath shows paths in the working directory relative to it.
+func displayPath(path, workingDir string) string {
+	if rel, err := filepath.Rel(workingDir, path); err == nil && filepath.IsLocal(rel) {
+		return rel
+	}
+	return fsext.PrettyPath(path)
+}
+
+func (d *attachFilesDialogCmp) style() lipgloss.Style {
+	t := styles.CurrentTheme()
+	return t.S().Base.
+		Width(d.width).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus)
+}
+
+func (d *attachFilesDialogCmp) Position() (int, int) {
+	row := d.wHeight/2 - lipgloss.Height(d.View())/2
+	col := d.wWidth/2 - d.width/2
+	return row, col
+}
+
+func (d *attachFilesDialogCmp) ID() dialogs.DialogID {
+	return AttachFilesDialogID
+}
@@ -0,0 +1,235 @@
+// Package attachments provides the dialog reviewing the attachments of the
+// prompt before it's sent: it previews them, estimates their tokens, and
+// removes or reorders them.
+package attachments
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	stdimage "image"
+	_ "image/gif"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/tui/components/core"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/components/image"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+)
+
+const (
+	AttachmentsDialogID dialogs.DialogID = "attachments"
+
+	width = 80
+
+	// headLines and tailLines are the lines of text attachments previewed
+	// from their start and their end.
+	headLines = 8
+	tailLines = 4
+
+	// thumbnailHeight is the height of the thumbnails of images, in rows.
+	thumbnailHeight = 12
+)
+
+// UpdateMsg replaces the attachments of the prompt with the ones reviewed.
+type UpdateMsg struct {
+	Attachments []message.Attachment
+}
+
+// AttachmentsDialog reviews the attachments of the prompt.
+type AttachmentsDialog interface {
+	dialogs.DialogModel
+}
+
+type attachmentsDialogCmp struct {
+	wWidth  int
+	wHeight int
+
+	attachments []message.Attachment
+	selected    int
+	// thumbnails are the rendered thumbnails of images, by path.
+	thumbnails map[string]string
+
+	keyMap KeyMap
+	help   help.Model
+}
+
+// NewAttachmentsDialog creates the dialog reviewing attachments, with the
+// last one selected.
+func NewAttachmentsDialog(attachments []message.Attachment) AttachmentsDialog {
+	t := styles.CurrentTheme()
+	help := help.New()
+	help.Styles = t.S().Help
+	return &attachmentsDialogCmp{
+		attachments: slices.Clone(attachments),
+		selected:    len(attachments) - 1,
+		thumbnails:  make(map[string]string),
+		keyMap:      DefaultKeyMap(),
+		help:        help,
+	}
+}
+
+func (m *attachmentsDialogCmp) Init() tea.Cmd {
+	return nil
+}
+
+func (m *attachmentsDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.wWidth = msg.Width
+		m.wHeight = msg.Height
+		return m, nil
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, m.keyMap.Next):
+			m.selected = min(m.selected+1, len(m.attachments)-1)
+		case key.Matches(msg, m.keyMap.Previous):
+			m.selected = max(m.selected-1, 0)
+		case key.Matches(msg, m.keyMap.MoveDown):
+			if m.selected < len(m.attachments)-1 {
+				m.attachments[m.selected], m.attachments[m.selected+1] = m.attachments[m.selected+1], m.attachments[m.selected]
+				m.selected++
+			}
+		case key.Matches(msg, m.keyMap.MoveUp):
+			if m.selected > 0 {
+				m.attachments[m.selected], m.attachments[m.selected-1] = m.attachments[m.selected-1], m.attachments[m.selected]
+				m.selected--
+			}
+		case key.Matches(msg, m.keyMap.Remove):
+			if len(m.attachments) > 0 {
+				m.attachments = slices.Delete(m.attachments, m.selected, m.selected+1)
+				m.selected = min(m.selected, len(m.attachments)-1)
+			}
+		case key.Matches(msg, m.keyMap.Confirm):
+			return m, tea.Sequence(
+				util.CmdHandler(dialogs.CloseDialogMsg{}),
+				util.CmdHandler(UpdateMsg{Attachments: m.attachments}),
+			)
+		case key.Matches(msg, m.keyMap.Close):
+			return m, util.CmdHandler(dialogs.CloseDialogMsg{})
+		}
+	}
+	return m, nil
+}
+
+func (m *attachmentsDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	baseStyle := t.S().Base
+
+	total := 0
+	for _, attachment := range m.attachments {
+		total += tokens(attachment)
+	}
+	summary := fmt.Sprintf("%d attachments, ~%s tokens", len(m.attachments), util.FormatTokens(total))
+
+	rows := make([]string, 0, len(m.attachments))
+	for i, attachment := range m.attachments {
+		name := ansi.Truncate(attachment.FileName, width/2, "…")
+		details := t.S().Subtle.Render(describe(attachment))
+		row := fmt.Sprintf("%d. %s", i, name)
+		if i == m.selected {
+			row = t.S().Base.Foreground(t.Primary).Bold(true).Render("› " + row)
+		} else {
+			row = t.S().Text.Render("  " + row)
+		}
+		gap := max(width-lipgloss.Width(row)-lipgloss.Width(details), 1)
+		rows = append(rows, row+strings.Repeat(" ", gap)+details)
+	}
+
+	parts := []string{
+		core.Title("Attachments", width),
+		"",
+		t.S().Muted.Render(summary),
+		"",
+		strings.Join(rows, "\n"),
+	}
+	if len(m.attachments) > 0 {
+		parts = append(parts, "", m.preview(m.attachments[m.selected]))
+	}
+	parts = append(parts, "", m.help.View(m.keyMap))
+
+	content := baseStyle.Width(width).Render(lipgloss.JoinVertical(lipgloss.Left, parts...))
+	return baseStyle.
+		Padding(1, 2).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus).
+		Render(content)
+}
+
+// preview renders a thumbnail of an image, or the start and the end of text.
+func (m *attachmentsDialogCmp) preview(attachment message.Attachment) string {
+	t := styles.CurrentTheme()
+	if attachment.IsImage() {
+		thumbnail, ok := m.thumbnails[attachment.FilePath]
+		if !ok {
+			var err error
+			thumbnail, err = image.ImageFromBase64(width, thumbnailHeight, base64.StdEncoding.EncodeToString(attachment.Content), attachment.MimeType)
+			if err != nil {
+				// Without a thumbnail, the size and format are all there is.
+				thumbnail = t.S().Subtle.Render("No preview: " + err.Error())
+			}
+			m.thumbnails[attachment.FilePath] = thumbnail
+		}
+		return strings.TrimRight(thumbnail, "\n")
+	}
+
+	lines := strings.Split(strings.TrimRight(strings.ReplaceAll(string(attachment.Content), "\t", "    "), "\n"), "\n")
+	if len(lines) > headLines+tailLines {
+		skipped := t.S().Subtle.Render(fmt.Sprintf("⋯ %d more lines ⋯", len(lines)-headLines-tailLines))
+		lines = slices.Concat(lines[:headLines], []string{skipped}, lines[len(lines)-tailLines:])
+	}
+	for i, line := range lines {
+		lines[i] = ansi.Truncate(line, width, "…")
+	}
+	return t.S().Text.Render(strings.Join(lines, "\n"))
+}
+
+func (m *attachmentsDialogCmp) Position() (int, int) {
+	row := m.wHeight / 2
+	row -= lipgloss.Height(m.View()) / 2
+	col := m.wWidth / 2
+	col -= (width + 6) / 2
+	return max(row, 0), col
+}
+
+func (m *attachmentsDialogCmp) ID() dialogs.DialogID {
+	return AttachmentsDialogID
+}
+
+// describe sums up an attachment: its format and dimensions for images, its
+// lines for text, its size and its tokens.
+func describe(attachment message.Attachment) string {
+	var kind string
+	if attachment.IsImage() {
+		kind = strings.ToUpper(strings.TrimPrefix(filepath.Ext(attachment.FileName), "."))
+		if config, format, err := stdimage.DecodeConfig(bytes.NewReader(attachment.Content)); err == nil {
+			kind = fmt.Sprintf("%s %d×%d", strings.ToUpper(format), config.Width, config.Height)
+		}
+	} else {
+		lines := strings.Count(strings.TrimRight(string(attachment.Content), "\n"), "\n") + 1
+		kind = fmt.Sprintf("%d lines", lines)
+	}
+	return fmt.Sprintf("%s · %s · ~%s tokens", kind, util.FormatSize(len(attachment.Content)), util.FormatTokens(tokens(attachment)))
+}
+
+// tokens estimates the tokens an attachment takes.
+func tokens(attachment message.Attachment) int {
+	if attachment.IsImage() {
+		config, _, err := stdimage.DecodeConfig(bytes.NewReader(attachment.Content))
+		if err != nil {
+			return 0
+		}
+		return util.EstimateImageTokens(config.Width, config.Height)
+	}
+	return util.EstimateTokens(attachment.Content)
+}
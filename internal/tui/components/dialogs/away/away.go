@@ -0,0 +1,185 @@
+// Package away provides the dialog summing up what happened while the
+// terminal was unfocused: the turns that finished and the notifications that
+// were held back.
+package away
+
+import (
+	"cmp"
+	"fmt"
+	"strings"
+	"time"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+
+	"github.com/charmbracelet/crush/internal/tui/components/core"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+)
+
+const (
+	AwayDialogID dialogs.DialogID = "away"
+
+	width = 60
+)
+
+// Turns are the turns of a session that finished while away.
+type Turns struct {
+	Session  string
+	Finished int
+	Failed   int
+}
+
+// Summary is what happened while away.
+type Summary struct {
+	Away          time.Duration
+	Turns         []Turns
+	Notifications []util.InfoMsg
+}
+
+// AwayDialog sums up what happened while away.
+type AwayDialog interface {
+	dialogs.DialogModel
+}
+
+type awayDialogCmp struct {
+	wWidth  int
+	wHeight int
+
+	summary Summary
+	keyMap  KeyMap
+	help    help.Model
+}
+
+// NewAwayDialog creates the dialog summing up what happened while away.
+func NewAwayDialog(summary Summary) AwayDialog {
+	t := styles.CurrentTheme()
+	help := help.New()
+	help.Styles = t.S().Help
+	return &awayDialogCmp{
+		summary: summary,
+		keyMap:  DefaultKeyMap(),
+		help:    help,
+	}
+}
+
+func (m *awayDialogCmp) Init() tea.Cmd {
+	return nil
+}
+
+func (m *awayDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.wWidth = msg.Width
+		m.wHeight = msg.Height
+	case tea.KeyPressMsg:
+		if key.Matches(msg, m.keyMap.Close) {
+			return m, util.CmdHandler(dialogs.CloseDialogMsg{})
+		}
+	}
+	return m, nil
+}
+
+// turns renders a line for each session whose turns finished.
+func (m *awayDialogCmp) turns() []string {
+	t := styles.CurrentTheme()
+	lines := make([]string, 0, len(m.summary.Turns))
+	for _, turns := range m.summary.Turns {
+		icon := t.S().Success.Render(styles.CheckIcon)
+		var counts []string
+		if turns.Finished > 0 {
+			counts = append(counts, plural(turns.Finished, "turn finished", "turns finished"))
+		}
+		if turns.Failed > 0 {
+			icon = t.S().Error.Render(styles.ErrorIcon)
+			counts = append(counts, plural(turns.Failed, "turn failed", "turns failed"))
+		}
+		title := util.Truncate(cmp.Or(turns.Session, "New Session"), width-30)
+		lines = append(lines, icon+" "+t.S().Text.Render(title)+t.S().Subtle.Render(" · "+strings.Join(counts, ", ")))
+	}
+	return lines
+}
+
+// notifications renders a line for each notification held back.
+func (m *awayDialogCmp) notifications() []string {
+	t := styles.CurrentTheme()
+	lines := make([]string, 0, len(m.summary.Notifications))
+	for _, n := range m.summary.Notifications {
+		var icon string
+		switch n.Type {
+		case util.InfoTypeError:
+			icon = t.S().Error.Render(styles.ErrorIcon)
+		case util.InfoTypeWarn:
+			icon = t.S().Warning.Render(styles.WarningIcon)
+		case util.InfoTypeSuccess:
+			icon = t.S().Success.Render(styles.CheckIcon)
+		default:
+			icon = t.S().Info.Render(styles.InfoIcon)
+		}
+		lines = append(lines, icon+" "+t.S().Text.Render(util.Truncate(n.Msg, width-2)))
+	}
+	return lines
+}
+
+func (m *awayDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	baseStyle := t.S().Base
+
+	parts := []string{
+		core.Title("While You Were Away", width),
+		"",
+		t.S().Muted.Render("You were away for " + formatAway(m.summary.Away) + "."),
+	}
+	if turns := m.turns(); len(turns) > 0 {
+		parts = append(parts, "", t.S().Subtle.Render("Turns"))
+		parts = append(parts, turns...)
+	}
+	if notifications := m.notifications(); len(notifications) > 0 {
+		parts = append(parts, "", t.S().Subtle.Render("Notifications"))
+		parts = append(parts, notifications...)
+	}
+	parts = append(parts, "", m.help.View(m.keyMap))
+
+	content := baseStyle.Width(width).Render(lipgloss.JoinVertical(lipgloss.Left, parts...))
+
+	return baseStyle.
+		Padding(1, 2).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus).
+		Render(content)
+}
+
+func (m *awayDialogCmp) Position() (int, int) {
+	row := m.wHeight / 2
+	row -= lipgloss.Height(m.View()) / 2
+	col := m.wWidth / 2
+	col -= (width + 6) / 2
+	return row, col
+}
+
+func (m *awayDialogCmp) ID() dialogs.DialogID {
+	return AwayDialogID
+}
+
+// formatAway formats how long the terminal was unfocused, to the minute past
+// the first one.
+func formatAway(d time.Duration) string {
+	if d < time.Minute {
+		return plural(int(d.Seconds()), "second", "seconds")
+	}
+	d = d.Round(time.Minute)
+	if d < time.Hour {
+		return plural(int(d.Minutes()), "minute", "minutes")
+	}
+	return fmt.Sprintf("%dh %dm", int(d.Hours()), int(d.Minutes())%60)
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return "1 " + one
+	}
+	return fmt.Sprintf("%d %s", n, many)
+}
@@ -0,0 +1,136 @@
+// Package changelog provides a dialog showing what's new in the release of
+// Crush available to update to.
+package changelog
+
+import (
+	"strings"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/viewport"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+
+	"github.com/charmbracelet/crush/internal/tui/components/core"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+	"github.com/charmbracelet/crush/internal/update"
+)
+
+const (
+	ChangelogDialogID dialogs.DialogID = "changelog"
+
+	width = 80
+)
+
+// ChangelogDialog shows the release notes of an update.
+type ChangelogDialog interface {
+	dialogs.DialogModel
+}
+
+type changelogDialogCmp struct {
+	wWidth  int
+	wHeight int
+
+	info     update.Info
+	viewport viewport.Model
+	keyMap   KeyMap
+	help     help.Model
+}
+
+// NewChangelogDialog creates the dialog showing the release notes of an
+// update.
+func NewChangelogDialog(info update.Info) ChangelogDialog {
+	t := styles.CurrentTheme()
+	help := help.New()
+	help.Styles = t.S().Help
+	vp := viewport.New()
+	vp.SetWidth(width)
+	return &changelogDialogCmp{
+		info:     info,
+		viewport: vp,
+		keyMap:   DefaultKeyMap(),
+		help:     help,
+	}
+}
+
+func (m *changelogDialogCmp) Init() tea.Cmd {
+	m.viewport.SetContent(m.notes())
+	return nil
+}
+
+func (m *changelogDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.wWidth = msg.Width
+		m.wHeight = msg.Height
+		m.viewport.SetHeight(max(msg.Height-16, 5))
+		return m, nil
+	case tea.KeyPressMsg:
+		if key.Matches(msg, m.keyMap.Close) {
+			return m, util.CmdHandler(dialogs.CloseDialogMsg{})
+		}
+	}
+	vp, cmd := m.viewport.Update(msg)
+	m.viewport = vp
+	return m, cmd
+}
+
+// notes renders the release notes.
+func (m *changelogDialogCmp) notes() string {
+	t := styles.CurrentTheme()
+	notes := strings.TrimSpace(m.info.Notes)
+	if notes == "" {
+		return t.S().Subtle.Render("This release has no notes.")
+	}
+	rendered, err := styles.GetMarkdownRenderer(width).Render(notes)
+	if err != nil {
+		return notes
+	}
+	return strings.TrimSpace(rendered)
+}
+
+func (m *changelogDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	baseStyle := t.S().Base
+
+	m.viewport.SetHeight(min(m.viewport.TotalLineCount(), max(m.wHeight-16, 5)))
+	hint := "Run crush self-update to update."
+	if m.info.URL != "" {
+		hint += " Full notes: " + m.info.URL
+	}
+
+	content := baseStyle.Width(width).Render(
+		lipgloss.JoinVertical(
+			lipgloss.Left,
+			core.Title("What's New in v"+m.info.Latest, width),
+			"",
+			t.S().Muted.Render("You have v"+m.info.Current+"."),
+			"",
+			m.viewport.View(),
+			"",
+			t.S().Subtle.Width(width).Render(hint),
+			"",
+			m.help.View(m.keyMap),
+		),
+	)
+
+	return baseStyle.
+		Padding(1, 2).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus).
+		Render(content)
+}
+
+func (m *changelogDialogCmp) Position() (int, int) {
+	row := m.wHeight / 2
+	row -= lipgloss.Height(m.View()) / 2
+	col := m.wWidth / 2
+	col -= (width + 6) / 2
+	return row, col
+}
+
+func (m *changelogDialogCmp) ID() dialogs.DialogID {
+	return ChangelogDialogID
+}
@@ -50,6 +50,8 @@ type commandArgumentsDialogCmp struct {
 type Argument struct {
 	Name, Title, Description string
 	Required                 bool
+	// Value prefills the input.
+	Value string
 }
 
 func NewCommandArgumentsDialog(
@@ -66,6 +68,7 @@ func NewCommandArgumentsDialog(
 		ti.SetWidth(40)
 		ti.SetVirtualCursor(false)
 		ti.Prompt = ""
+		ti.SetValue(arg.Value)
 
 		ti.SetStyles(t.S().TextInput)
 		// Only focus the first input initially
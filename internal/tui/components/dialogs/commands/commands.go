@@ -17,7 +17,10 @@ import (
 	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/i18n"
+	"github.com/charmbracelet/crush/internal/keyring"
 	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/charmbracelet/crush/internal/screenshot"
 	"github.com/charmbracelet/crush/internal/tui/components/chat"
 	"github.com/charmbracelet/crush/internal/tui/components/core"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
@@ -25,6 +28,7 @@ import (
 	"github.com/charmbracelet/crush/internal/tui/styles"
 	"github.com/charmbracelet/crush/internal/tui/util"
 	"github.com/charmbracelet/crush/internal/uicmd"
+	"github.com/charmbracelet/crush/internal/update"
 )
 
 const (
@@ -75,14 +79,52 @@ type (
 	SwitchModelMsg         struct{}
 	QuitMsg                struct{}
 	OpenFilePickerMsg      struct{}
+	OpenAttachFilesMsg     struct{}
 	ToggleHelpMsg          struct{}
 	ToggleCompactModeMsg   struct{}
 	ToggleThinkingMsg      struct{}
 	OpenReasoningDialogMsg struct{}
 	OpenExternalEditorMsg  struct{}
 	ToggleYoloModeMsg      struct{}
-	CompactMsg             struct {
+	ToggleExplainModeMsg   struct{}
+	OpenLogLevelDialogMsg  struct{}
+	OpenShellProfileMsg    struct{}
+	OpenLSPSetupMsg        struct{}
+	OpenLSPServersMsg      struct{}
+	OpenInstructionsMsg    struct{}
+	OpenMemoriesMsg        struct{}
+	OpenDiffReviewMsg      struct{}
+	OpenRunQueueMsg        struct{}
+	OpenModelSpeedMsg      struct{}
+	OpenAPIKeysMsg         struct{}
+	OpenChangelogMsg       struct{}
+	OpenProfilesMsg        struct{}
+	OpenChatTemplateMsg    struct{}
+	ToggleLogsMsg          struct{}
+	// CompactMsg summarizes a session, with the small model when Small is
+	// set.
+	CompactMsg struct {
 		SessionID string
+		Small     bool
+	}
+	// ChangeDirMsg moves a session to another working directory, or back
+	// to the one of Crush when Path is empty.
+	ChangeDirMsg struct {
+		SessionID string
+		Path      string
+	}
+	DismissPreCommitMsg struct {
+		SessionID string
+	}
+	// OpenSessionInBrowserMsg opens the session rendered as HTML in the
+	// browser.
+	OpenSessionInBrowserMsg struct {
+		SessionID string
+	}
+	// TakeScreenshotMsg captures the screen, or a window picked by the
+	// user, and attaches it to the prompt.
+	TakeScreenshotMsg struct {
+		Target screenshot.Target
 	}
 )
 
@@ -123,7 +165,7 @@ func (c *commandDialogCmp) Init() tea.Cmd {
 	if err != nil {
 		return util.ReportError(err)
 	}
-	c.userCommands = commands
+	c.userCommands = append(commands, uicmd.LoadPluginCommands()...)
 	c.mcpPrompts.SetSlice(uicmd.LoadMCPPrompts())
 	return c.setCommandType(c.selected)
 }
@@ -202,9 +244,9 @@ func (c *commandDialogCmp) View() string {
 	listView := c.commandList
 	radio := c.commandTypeRadio()
 
-	header := t.S().Base.Padding(0, 1, 1, 1).Render(core.Title("Commands", c.width-lipgloss.Width(radio)-5) + " " + radio)
+	header := t.S().Base.Padding(0, 1, 1, 1).Render(core.Title(i18n.T("Commands"), c.width-lipgloss.Width(radio)-5) + " " + radio)
 	if len(c.userCommands) == 0 && c.mcpPrompts.Len() == 0 {
-		header = t.S().Base.Padding(0, 1, 1, 1).Render(core.Title("Commands", c.width-4))
+		header = t.S().Base.Padding(0, 1, 1, 1).Render(core.Title(i18n.T("Commands"), c.width-4))
 	}
 	content := lipgloss.JoinVertical(
 		lipgloss.Left,
@@ -232,9 +274,9 @@ func (c *commandDialogCmp) commandTypeRadio() string {
 
 	fn := func(i commandType) string {
 		if i == c.selected {
-			return "◉ " + i.String()
+			return styles.RadioOnIcon + " " + i18n.T(i.String())
 		}
-		return "○ " + i.String()
+		return styles.RadioOffIcon + " " + i18n.T(i.String())
 	}
 
 	parts := []string{
@@ -277,7 +319,12 @@ func (c *commandDialogCmp) setCommandType(commandType commandType) tea.Cmd {
 				list.WithCompletionShortcut(cmd.Shortcut),
 			)
 		}
-		commandItems = append(commandItems, list.NewCompletionItem(cmd.Title, cmd, opts...))
+		title := cmd.Title
+		if c.selected == SystemCommands {
+			// Only the built-in commands have translations.
+			title = i18n.T(title)
+		}
+		commandItems = append(commandItems, list.NewCompletionItem(title, cmd, opts...))
 	}
 	return c.commandList.SetItems(commandItems)
 }
@@ -334,7 +381,7 @@ func (c *commandDialogCmp) defaultCommands() []Command {
 			ID:          "switch_model",
 			Title:       "Switch Model",
 			Description: "Switch to a different model",
-			Shortcut:    "ctrl+l",
+			Shortcut:    "ctrl+m",
 			Handler: func(cmd Command) tea.Cmd {
 				return util.CmdHandler(SwitchModelMsg{})
 			},
@@ -352,11 +399,33 @@ func (c *commandDialogCmp) defaultCommands() []Command {
 					SessionID: c.sessionID,
 				})
 			},
+		}, Command{
+			ID:          "open_session_in_browser",
+			Title:       "Open Session in Browser",
+			Description: "Read the session rendered as HTML in the browser",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(OpenSessionInBrowserMsg{
+					SessionID: c.sessionID,
+				})
+			},
 		})
 	}
 
-	// Add reasoning toggle for models that support it
 	cfg := config.Get()
+	if c.sessionID != "" && cfg.Options.PreCommit.IsEnabled() {
+		commands = append(commands, Command{
+			ID:          "dismiss_pre_commit",
+			Title:       "Dismiss Pre-commit Failures",
+			Description: "Stop waiting on failing pre-commit hooks for this turn",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(DismissPreCommitMsg{
+					SessionID: c.sessionID,
+				})
+			},
+		})
+	}
+
+	// Add reasoning toggle for models that support it
 	if agentCfg, ok := cfg.Agents[config.AgentCoder]; ok {
 		providerCfg := cfg.GetProviderForModel(agentCfg.Model)
 		model := cfg.GetModelByType(agentCfg.Model)
@@ -392,6 +461,20 @@ func (c *commandDialogCmp) defaultCommands() []Command {
 			}
 		}
 	}
+	// Chat templates only apply to the local servers behind OpenAI-compatible
+	// providers.
+	if agentCfg, ok := cfg.Agents[config.AgentCoder]; ok {
+		if providerCfg := cfg.GetProviderForModel(agentCfg.Model); providerCfg != nil && providerCfg.Type == catwalk.TypeOpenAICompat {
+			commands = append(commands, Command{
+				ID:          "edit_chat_template",
+				Title:       "Edit Chat Template",
+				Description: "Override the stop sequences and prompt format of the current model",
+				Handler: func(cmd Command) tea.Cmd {
+					return util.CmdHandler(OpenChatTemplateMsg{})
+				},
+			})
+		}
+	}
 	// Only show toggle compact mode command if window width is larger than compact breakpoint (90)
 	if c.wWidth > 120 && c.sessionID != "" {
 		commands = append(commands, Command{
@@ -404,21 +487,162 @@ func (c *commandDialogCmp) defaultCommands() []Command {
 		})
 	}
 	if c.sessionID != "" {
+		commands = append(commands, Command{
+			ID:          "attach_files",
+			Title:       "Attach Files",
+			Shortcut:    "ctrl+t",
+			Description: "Attach a file, a directory or a glob of files",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(OpenAttachFilesMsg{})
+			},
+		})
 		agentCfg := config.Get().Agents[config.AgentCoder]
 		model := config.Get().GetModelByType(agentCfg.Model)
 		if model.SupportsImages {
 			commands = append(commands, Command{
 				ID:          "file_picker",
 				Title:       "Open File Picker",
-				Shortcut:    "ctrl+t",
 				Description: "Open file picker",
 				Handler: func(cmd Command) tea.Cmd {
 					return util.CmdHandler(OpenFilePickerMsg{})
 				},
 			})
+			commands = append(commands, Command{
+				ID:          "screenshot",
+				Title:       "Take Screenshot",
+				Description: "Capture the screen and attach it",
+				Handler: func(cmd Command) tea.Cmd {
+					return util.CmdHandler(TakeScreenshotMsg{Target: screenshot.Screen})
+				},
+			}, Command{
+				ID:          "screenshot_window",
+				Title:       "Take Screenshot of a Window",
+				Description: "Capture a window you click and attach it",
+				Handler: func(cmd Command) tea.Cmd {
+					return util.CmdHandler(TakeScreenshotMsg{Target: screenshot.Window})
+				},
+			})
+		}
+		if len(config.Profiles()) > 0 {
+			commands = append(commands, Command{
+				ID:          "switch_profile",
+				Title:       "Switch Profile",
+				Description: "Restart Crush with another config profile, like work or personal",
+				Handler: func(cmd Command) tea.Cmd {
+					return util.CmdHandler(OpenProfilesMsg{})
+				},
+			})
+		}
+		if len(config.Get().Options.ShellProfiles) > 0 {
+			commands = append(commands, Command{
+				ID:          "switch_shell_profile",
+				Title:       "Switch Shell Profile",
+				Description: "Choose the environment the bash tool runs commands in for this session",
+				Handler: func(cmd Command) tea.Cmd {
+					return util.CmdHandler(OpenShellProfileMsg{})
+				},
+			})
 		}
 	}
 
+	commands = append(commands, Command{
+		ID:          "toggle_explain_mode",
+		Title:       "Toggle Explain Mode",
+		Description: "Only let the agent analyze and explain the code in this session, without the tools that change anything",
+		Handler: func(cmd Command) tea.Cmd {
+			return util.CmdHandler(ToggleExplainModeMsg{})
+		},
+	})
+
+	commands = append(commands, Command{
+		ID:          "setup_lsps",
+		Title:       "Set Up Language Servers",
+		Description: "Configure and install the language servers of the languages of this project",
+		Handler: func(cmd Command) tea.Cmd {
+			return util.CmdHandler(OpenLSPSetupMsg{})
+		},
+	})
+
+	commands = append(commands, Command{
+		ID:          "instruction_files",
+		Title:       "Instruction Files",
+		Description: "See the instruction files of the workspace and of its subdirectories, and the files they apply to",
+		Handler: func(cmd Command) tea.Cmd {
+			return util.CmdHandler(OpenInstructionsMsg{})
+		},
+	})
+
+	if config.Get().Options.Memory.IsEnabled() {
+		commands = append(commands, Command{
+			ID:          "memories",
+			Title:       "Memories",
+			Description: "Review what the agent remembers about this project, and forget memories",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(OpenMemoriesMsg{})
+			},
+		})
+	}
+
+	if len(config.Get().LSP) > 0 {
+		commands = append(commands, Command{
+			ID:          "manage_lsps",
+			Title:       "Manage Language Servers",
+			Description: "See the state of the language servers, and restart, stop or disable them",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(OpenLSPServersMsg{})
+			},
+		})
+	}
+
+	commands = append(commands, Command{
+		ID:          "run_queue",
+		Title:       "Run Queue",
+		Description: "See the sessions the agent works in and the runs waiting for a slot, to reorder or cancel them",
+		Handler: func(cmd Command) tea.Cmd {
+			return util.CmdHandler(OpenRunQueueMsg{})
+		},
+	})
+
+	commands = append(commands, Command{
+		ID:          "model_speed",
+		Title:       "Model Speed",
+		Description: "Compare the time to first token and tokens per second of the models you used",
+		Handler: func(cmd Command) tea.Cmd {
+			return util.CmdHandler(OpenModelSpeedMsg{})
+		},
+	})
+
+	if info, ok := update.Pending(); ok && !info.IsDevelopment() {
+		commands = append(commands, Command{
+			ID:          "changelog",
+			Title:       "What's New in v" + info.Latest,
+			Description: "Read the changelog of the Crush release available to update to",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(OpenChangelogMsg{})
+			},
+		})
+	}
+
+	if len(keyring.All()) > 0 {
+		commands = append(commands, Command{
+			ID:          "api_keys",
+			Title:       "API Keys",
+			Description: "See which API key of each provider is in use and how often each was rate limited or rejected",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(OpenAPIKeysMsg{})
+			},
+		})
+	}
+
+	commands = append(commands, Command{
+		ID:          "review_changes",
+		Title:       "Review Changes",
+		Description: "Go through the unstaged changes hunk by hunk and stage them with git",
+		Handler: func(cmd Command) tea.Cmd {
+			return util.CmdHandler(OpenDiffReviewMsg{})
+		},
+	})
+
 	// Add external editor command if $EDITOR is available
 	if os.Getenv("EDITOR") != "" {
 		commands = append(commands, Command{
@@ -441,6 +665,23 @@ func (c *commandDialogCmp) defaultCommands() []Command {
 				return util.CmdHandler(ToggleYoloModeMsg{})
 			},
 		},
+		{
+			ID:          "toggle_logs",
+			Title:       "Toggle Logs",
+			Shortcut:    "ctrl+l",
+			Description: "Show or hide the log pane",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(ToggleLogsMsg{})
+			},
+		},
+		{
+			ID:          "log_level",
+			Title:       "Set Log Level",
+			Description: "Change how verbose the log file is until Crush exits",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(OpenLogLevelDialogMsg{})
+			},
+		},
 		{
 			ID:          "toggle_help",
 			Title:       "Toggle Help",
@@ -0,0 +1,148 @@
+package crashreport
+
+import (
+	"fmt"
+
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/crash"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+	"github.com/charmbracelet/x/ansi"
+)
+
+const (
+	CrashDialogID dialogs.DialogID = "crash"
+
+	question = "Reopen the session you were in?"
+	width    = 60
+)
+
+// ReopenSessionMsg is sent when the user chooses to reopen the session that
+// was open when Crush crashed.
+type ReopenSessionMsg struct {
+	SessionID string
+}
+
+// CrashDialog tells the user Crush crashed last time and offers to reopen the
+// session they were in.
+type CrashDialog interface {
+	dialogs.DialogModel
+}
+
+type crashDialogCmp struct {
+	wWidth  int
+	wHeight int
+
+	report     crash.Report
+	selectedNo bool // true if "No" button is selected
+	keymap     KeyMap
+}
+
+// NewCrashDialog creates a dialog for the given crash report.
+func NewCrashDialog(report crash.Report) CrashDialog {
+	return &crashDialogCmp{
+		report: report,
+		keymap: DefaultKeymap(),
+	}
+}
+
+func (c *crashDialogCmp) Init() tea.Cmd {
+	return nil
+}
+
+func (c *crashDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		c.wWidth = msg.Width
+		c.wHeight = msg.Height
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, c.keymap.LeftRight, c.keymap.Tab):
+			c.selectedNo = !c.selectedNo
+			return c, nil
+		case key.Matches(msg, c.keymap.EnterSpace):
+			if !c.selectedNo {
+				return c, c.reopen()
+			}
+			return c, util.CmdHandler(dialogs.CloseDialogMsg{})
+		case key.Matches(msg, c.keymap.Yes):
+			return c, c.reopen()
+		case key.Matches(msg, c.keymap.No, c.keymap.Close):
+			return c, util.CmdHandler(dialogs.CloseDialogMsg{})
+		}
+	}
+	return c, nil
+}
+
+func (c *crashDialogCmp) reopen() tea.Cmd {
+	return tea.Sequence(
+		util.CmdHandler(dialogs.CloseDialogMsg{}),
+		util.CmdHandler(ReopenSessionMsg{SessionID: c.report.SessionID}),
+	)
+}
+
+func (c *crashDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	baseStyle := t.S().Base
+	yesStyle := t.S().Text
+	noStyle := yesStyle
+
+	if c.selectedNo {
+		noStyle = noStyle.Foreground(t.White).Background(t.Secondary)
+		yesStyle = yesStyle.Background(t.BgSubtle)
+	} else {
+		yesStyle = yesStyle.Foreground(t.White).Background(t.Secondary)
+		noStyle = noStyle.Background(t.BgSubtle)
+	}
+
+	const horizontalPadding = 3
+	yesButton := yesStyle.PaddingLeft(horizontalPadding).Underline(true).Render("Y") +
+		yesStyle.PaddingRight(horizontalPadding).Render("es")
+	noButton := noStyle.PaddingLeft(horizontalPadding).Underline(true).Render("N") +
+		noStyle.PaddingRight(horizontalPadding).Render("o")
+
+	buttons := baseStyle.Width(width).Align(lipgloss.Right).Render(
+		lipgloss.JoinHorizontal(lipgloss.Center, yesButton, "  ", noButton),
+	)
+
+	title := t.S().Error.Bold(true).Render("Crush crashed last time")
+	details := t.S().Muted.Width(width).Render(fmt.Sprintf(
+		"%s\n\nA crash report was saved to %s. Please attach it if you open an issue.",
+		ansi.Truncate(c.report.Panic, width*2, "…"),
+		c.report.Dir,
+	))
+
+	content := baseStyle.Width(width).Render(
+		lipgloss.JoinVertical(
+			lipgloss.Left,
+			title,
+			"",
+			details,
+			"",
+			question,
+			"",
+			buttons,
+		),
+	)
+
+	return baseStyle.
+		Padding(1, 2).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus).
+		Render(content)
+}
+
+func (c *crashDialogCmp) Position() (int, int) {
+	row := c.wHeight / 2
+	row -= lipgloss.Height(c.View()) / 2
+	col := c.wWidth / 2
+	col -= (width + 6) / 2
+	return row, col
+}
+
+func (c *crashDialogCmp) ID() dialogs.DialogID {
+	return CrashDialogID
+}
@@ -0,0 +1,183 @@
+// Package deletemessage provides the confirmation dialog shown before a
+// message or a tool call is deleted from a session.
+package deletemessage
+
+import (
+	"fmt"
+	"strings"
+
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+	"github.com/charmbracelet/x/ansi"
+)
+
+const (
+	DeleteMessageDialogID dialogs.DialogID = "delete_message"
+
+	width = 60
+)
+
+// Target is what gets deleted: a whole message, or a single tool call of an
+// assistant message when ToolCallID is set.
+type Target struct {
+	SessionID  string
+	MessageID  string
+	ToolCallID string
+	// Summary describes the target to the user.
+	Summary string
+}
+
+// MessageTarget returns the target for deleting a whole message.
+func MessageTarget(msg message.Message) Target {
+	summary, _, _ := strings.Cut(strings.TrimSpace(msg.Content().Text), "\n")
+	if summary == "" {
+		summary = fmt.Sprintf("%s message", msg.Role)
+	}
+	return Target{
+		SessionID: msg.SessionID,
+		MessageID: msg.ID,
+		Summary:   summary,
+	}
+}
+
+// ToolCallTarget returns the target for deleting a single tool call of an
+// assistant message.
+func ToolCallTarget(sessionID, messageID string, tc message.ToolCall) Target {
+	return Target{
+		SessionID:  sessionID,
+		MessageID:  messageID,
+		ToolCallID: tc.ID,
+		Summary:    fmt.Sprintf("%s %s", tc.Name, tc.Input),
+	}
+}
+
+// ConfirmedMsg is sent when the user confirms the deletion.
+type ConfirmedMsg struct {
+	Target Target
+}
+
+// DeleteMessageDialog asks the user to confirm deleting a message.
+type DeleteMessageDialog interface {
+	dialogs.DialogModel
+}
+
+type deleteMessageDialogCmp struct {
+	wWidth  int
+	wHeight int
+
+	target     Target
+	selectedNo bool // true if "No" button is selected
+	keymap     KeyMap
+}
+
+// NewDeleteMessageDialog creates a dialog confirming the deletion of target.
+func NewDeleteMessageDialog(target Target) DeleteMessageDialog {
+	return &deleteMessageDialogCmp{
+		target:     target,
+		selectedNo: true, // Default to "No" for safety
+		keymap:     DefaultKeymap(),
+	}
+}
+
+func (d *deleteMessageDialogCmp) Init() tea.Cmd {
+	return nil
+}
+
+func (d *deleteMessageDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		d.wWidth = msg.Width
+		d.wHeight = msg.Height
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, d.keymap.LeftRight, d.keymap.Tab):
+			d.selectedNo = !d.selectedNo
+			return d, nil
+		case key.Matches(msg, d.keymap.EnterSpace):
+			if !d.selectedNo {
+				return d, d.confirm()
+			}
+			return d, util.CmdHandler(dialogs.CloseDialogMsg{})
+		case key.Matches(msg, d.keymap.Yes):
+			return d, d.confirm()
+		case key.Matches(msg, d.keymap.No, d.keymap.Close):
+			return d, util.CmdHandler(dialogs.CloseDialogMsg{})
+		}
+	}
+	return d, nil
+}
+
+func (d *deleteMessageDialogCmp) confirm() tea.Cmd {
+	return tea.Sequence(
+		util.CmdHandler(dialogs.CloseDialogMsg{}),
+		util.CmdHandler(ConfirmedMsg{Target: d.target}),
+	)
+}
+
+func (d *deleteMessageDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	baseStyle := t.S().Base
+	yesStyle := t.S().Text
+	noStyle := yesStyle
+
+	if d.selectedNo {
+		noStyle = noStyle.Foreground(t.White).Background(t.Secondary)
+		yesStyle = yesStyle.Background(t.BgSubtle)
+	} else {
+		yesStyle = yesStyle.Foreground(t.White).Background(t.Secondary)
+		noStyle = noStyle.Background(t.BgSubtle)
+	}
+
+	const horizontalPadding = 3
+	yesButton := yesStyle.PaddingLeft(horizontalPadding).Underline(true).Render("Y") +
+		yesStyle.PaddingRight(horizontalPadding).Render("es")
+	noButton := noStyle.PaddingLeft(horizontalPadding).Underline(true).Render("N") +
+		noStyle.PaddingRight(horizontalPadding).Render("o")
+
+	buttons := baseStyle.Width(width).Align(lipgloss.Right).Render(
+		lipgloss.JoinHorizontal(lipgloss.Center, yesButton, "  ", noButton),
+	)
+
+	question := "Delete this message?"
+	details := "It will be removed from the session and from the context sent to the model. This can't be undone."
+	if d.target.ToolCallID != "" {
+		question = "Delete this tool call?"
+		details = "The call and its result will be removed from the session and from the context sent to the model. This can't be undone."
+	}
+
+	content := baseStyle.Width(width).Render(
+		lipgloss.JoinVertical(
+			lipgloss.Left,
+			t.S().Text.Bold(true).Render(question),
+			"",
+			t.S().Muted.Render(ansi.Truncate(d.target.Summary, width, "…")),
+			"",
+			t.S().Subtle.Width(width).Render(details),
+			"",
+			buttons,
+		),
+	)
+
+	return baseStyle.
+		Padding(1, 2).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus).
+		Render(content)
+}
+
+func (d *deleteMessageDialogCmp) Position() (int, int) {
+	row := d.wHeight / 2
+	row -= lipgloss.Height(d.View()) / 2
+	col := d.wWidth / 2
+	col -= (width + 6) / 2
+	return row, col
+}
+
+func (d *deleteMessageDialogCmp) ID() dialogs.DialogID {
+	return DeleteMessageDialogID
+}
@@ -0,0 +1,296 @@
+// Package diffreview provides a dialog going through the unstaged changes of
+// the working directory hunk by hunk, to stage the ones worth committing
+// like git add -p does.
+package diffreview
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/gitdiff"
+	"github.com/charmbracelet/crush/internal/tui/components/core"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+	"github.com/charmbracelet/x/ansi"
+)
+
+const (
+	DiffReviewDialogID dialogs.DialogID = "diff_review"
+
+	maxWidth = 110
+
+	// gitTimeout bounds the git commands of the dialog.
+	gitTimeout = 10 * time.Second
+)
+
+// loadedMsg carries the unstaged changes read by a dialog.
+type loadedMsg struct {
+	dialog *diffReviewDialogCmp
+	files  []gitdiff.File
+	err    error
+}
+
+// stagedMsg reports a hunk or a file staged by a dialog.
+type stagedMsg struct {
+	dialog *diffReviewDialogCmp
+	what   string
+	err    error
+}
+
+// DiffReviewDialog shows the unstaged changes of the working directory.
+type DiffReviewDialog interface {
+	dialogs.DialogModel
+}
+
+type diffReviewDialogCmp struct {
+	wWidth  int
+	wHeight int
+
+	workingDir string
+	files      []gitdiff.File
+	loaded     bool
+	err        error
+
+	// file and hunk are the indexes of the hunk shown. Files without
+	// hunks have a single position, hunk 0.
+	file int
+	hunk int
+
+	keyMap KeyMap
+	help   help.Model
+}
+
+// NewDiffReviewDialog creates the dialog reviewing the unstaged changes of
+// the working directory.
+func NewDiffReviewDialog() DiffReviewDialog {
+	t := styles.CurrentTheme()
+	help := help.New()
+	help.Styles = t.S().Help
+	return &diffReviewDialogCmp{
+		workingDir: config.Get().WorkingDir(),
+		keyMap:     DefaultKeyMap(),
+		help:       help,
+	}
+}
+
+func (d *diffReviewDialogCmp) Init() tea.Cmd {
+	return d.load()
+}
+
+// load reads the unstaged changes.
+func (d *diffReviewDialogCmp) load() tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), gitTimeout)
+		defer cancel()
+		files, err := gitdiff.Unstaged(ctx, d.workingDir)
+		return loadedMsg{dialog: d, files: files, err: err}
+	}
+}
+
+// stage stages the hunk shown, or the whole file when all is true or the
+// file has no hunks.
+func (d *diffReviewDialogCmp) stage(all bool) tea.Cmd {
+	if d.file >= len(d.files) {
+		return nil
+	}
+	f, hunk := d.files[d.file], d.hunk
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), gitTimeout)
+		defer cancel()
+		if all || len(f.Hunks) == 0 {
+			return stagedMsg{dialog: d, what: f.Path, err: gitdiff.StageFile(ctx, d.workingDir, f)}
+		}
+		err := gitdiff.StageHunk(ctx, d.workingDir, f, f.Hunks[hunk])
+		return stagedMsg{dialog: d, what: fmt.Sprintf("hunk of %s", f.Path), err: err}
+	}
+}
+
+func (d *diffReviewDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		d.wWidth = msg.Width
+		d.wHeight = msg.Height
+	case loadedMsg:
+		if msg.dialog != d {
+			return d, nil
+		}
+		d.setFiles(msg.files)
+		d.loaded, d.err = true, msg.err
+	case stagedMsg:
+		if msg.dialog != d {
+			return d, nil
+		}
+		if msg.err != nil {
+			return d, util.ReportError(msg.err)
+		}
+		return d, tea.Batch(util.ReportInfo("Staged "+msg.what), d.load())
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, d.keyMap.Close):
+			return d, util.CmdHandler(dialogs.CloseDialogMsg{})
+		case key.Matches(msg, d.keyMap.Refresh):
+			return d, d.load()
+		}
+		if len(d.files) == 0 {
+			return d, nil
+		}
+		switch {
+		case key.Matches(msg, d.keyMap.Next):
+			d.move(1)
+		case key.Matches(msg, d.keyMap.Previous):
+			d.move(-1)
+		case key.Matches(msg, d.keyMap.NextFile):
+			d.file, d.hunk = (d.file+1)%len(d.files), 0
+		case key.Matches(msg, d.keyMap.PreviousFile):
+			d.file, d.hunk = (d.file-1+len(d.files))%len(d.files), 0
+		case key.Matches(msg, d.keyMap.StageHunk):
+			return d, d.stage(false)
+		case key.Matches(msg, d.keyMap.StageFile):
+			return d, d.stage(true)
+		}
+	}
+	return d, nil
+}
+
+// setFiles replaces the changes shown, staying on the same file and hunk
+// index so that the hunk after a staged one comes up next.
+func (d *diffReviewDialogCmp) setFiles(files []gitdiff.File) {
+	file, hunk := d.file, 0
+	if d.file < len(d.files) {
+		path := d.files[d.file].Path
+		for i, f := range files {
+			if f.Path == path {
+				file, hunk = i, d.hunk
+				break
+			}
+		}
+	}
+	d.files = files
+	d.file = min(file, max(len(files)-1, 0))
+	d.hunk = 0
+	if d.file < len(files) {
+		d.hunk = min(hunk, max(len(files[d.file].Hunks)-1, 0))
+	}
+}
+
+// move goes to the next or previous hunk, across files.
+func (d *diffReviewDialogCmp) move(delta int) {
+	d.hunk += delta
+	if d.hunk >= 0 && d.hunk < len(d.files[d.file].Hunks) {
+		return
+	}
+	d.file = (d.file + delta + len(d.files)) % len(d.files)
+	d.hunk = 0
+	if delta < 0 {
+		d.hunk = max(len(d.files[d.file].Hunks)-1, 0)
+	}
+}
+
+func (d *diffReviewDialogCmp) width() int {
+	return min(maxWidth, max(d.wWidth-10, 40))
+}
+
+func (d *diffReviewDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	baseStyle := t.S().Base
+	width := d.width()
+
+	var body []string
+	switch {
+	case d.err != nil:
+		body = append(body, t.S().Error.Render(ansi.Wrap(d.err.Error(), width, "")))
+	case !d.loaded:
+		body = append(body, t.S().Muted.Render("Reading the changes…"))
+	case len(d.files) == 0:
+		body = append(body, t.S().Muted.Render("No unstaged changes."))
+	default:
+		body = d.renderHunk(width)
+	}
+
+	content := baseStyle.Width(width).Render(
+		lipgloss.JoinVertical(
+			lipgloss.Left,
+			core.Title("Review Changes", width),
+			"",
+			lipgloss.JoinVertical(lipgloss.Left, body...),
+			"",
+			d.help.View(d.keyMap),
+		),
+	)
+
+	return baseStyle.
+		Padding(1, 2).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus).
+		Render(content)
+}
+
+// renderHunk renders the file and hunk shown, cut to the height of the
+// window.
+func (d *diffReviewDialogCmp) renderHunk(width int) []string {
+	t := styles.CurrentTheme()
+	f := d.files[d.file]
+
+	position := fmt.Sprintf("file %d/%d", d.file+1, len(d.files))
+	if len(f.Hunks) > 0 {
+		h := f.Hunks[d.hunk]
+		position += fmt.Sprintf(" · hunk %d/%d ", d.hunk+1, len(f.Hunks)) +
+			t.S().Base.Foreground(t.Success).Render(fmt.Sprintf("+%d", h.Additions())) + " " +
+			t.S().Base.Foreground(t.Error).Render(fmt.Sprintf("-%d", h.Removals()))
+	}
+	path := ansi.Truncate(f.Path, width-lipgloss.Width(position)-2, "…")
+	lines := []string{
+		t.S().Text.Bold(true).Render(path) + "  " + t.S().Muted.Render(position),
+		"",
+	}
+
+	switch {
+	case f.Untracked:
+		return append(lines, t.S().Muted.Render("Untracked file, stage it whole with S."))
+	case len(f.Hunks) == 0:
+		return append(lines, t.S().Muted.Render("No text changes to show, stage the file whole with S."))
+	}
+
+	h := f.Hunks[d.hunk]
+	lines = append(lines, t.S().Subtle.Render(ansi.Truncate(h.Header, width, "…")))
+	maxLines := max(d.wHeight-16, 5)
+	for i, l := range h.Lines {
+		if i == maxLines {
+			lines = append(lines, t.S().Subtle.Render(fmt.Sprintf("… %d more lines", len(h.Lines)-i)))
+			break
+		}
+		l = ansi.Truncate(strings.ReplaceAll(l, "\t", "    "), width, "…")
+		style := t.S().Muted
+		switch {
+		case strings.HasPrefix(l, "+"):
+			style = t.S().Base.Foreground(t.Success)
+		case strings.HasPrefix(l, "-"):
+			style = t.S().Base.Foreground(t.Error)
+		case strings.HasPrefix(l, `\`):
+			style = t.S().Subtle
+		}
+		lines = append(lines, style.Render(l))
+	}
+	return lines
+}
+
+func (d *diffReviewDialogCmp) Position() (int, int) {
+	row := d.wHeight / 2
+	row -= lipgloss.Height(d.View()) / 2
+	col := d.wWidth / 2
+	col -= (d.width() + 6) / 2
+	return row, col
+}
+
+func (d *diffReviewDialogCmp) ID() dialogs.DialogID {
+	return DiffReviewDialogID
+}
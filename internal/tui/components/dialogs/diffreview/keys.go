@@ -0,0 +1,84 @@
+package diffreview
+
+import (
+	"charm.land/bubbles/v2/key"
+)
+
+// KeyMap defines the keyboard bindings for the diff review dialog.
+type KeyMap struct {
+	Next,
+	Previous,
+	NextFile,
+	PreviousFile,
+	StageHunk,
+	StageFile,
+	Refresh,
+	Close key.Binding
+}
+
+func DefaultKeyMap() KeyMap {
+	return KeyMap{
+		Next: key.NewBinding(
+			key.WithKeys("down", "j", "ctrl+n"),
+			key.WithHelp("↓/j", "next hunk"),
+		),
+		Previous: key.NewBinding(
+			key.WithKeys("up", "k", "ctrl+p"),
+			key.WithHelp("↑/k", "previous hunk"),
+		),
+		NextFile: key.NewBinding(
+			key.WithKeys("tab", "l"),
+			key.WithHelp("tab/l", "next file"),
+		),
+		PreviousFile: key.NewBinding(
+			key.WithKeys("shift+tab", "h"),
+			key.WithHelp("shift+tab/h", "previous file"),
+		),
+		StageHunk: key.NewBinding(
+			key.WithKeys("s", "space"),
+			key.WithHelp("s", "stage hunk"),
+		),
+		StageFile: key.NewBinding(
+			key.WithKeys("S"),
+			key.WithHelp("S", "stage file"),
+		),
+		Refresh: key.NewBinding(
+			key.WithKeys("r"),
+			key.WithHelp("r", "refresh"),
+		),
+		Close: key.NewBinding(
+			key.WithKeys("esc", "alt+esc"),
+			key.WithHelp("esc", "close"),
+		),
+	}
+}
+
+// KeyBindings implements layout.KeyMapProvider
+func (k KeyMap) KeyBindings() []key.Binding {
+	return []key.Binding{
+		k.Next,
+		k.Previous,
+		k.NextFile,
+		k.PreviousFile,
+		k.StageHunk,
+		k.StageFile,
+		k.Refresh,
+		k.Close,
+	}
+}
+
+// FullHelp implements help.KeyMap.
+func (k KeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{k.KeyBindings()}
+}
+
+// ShortHelp implements help.KeyMap.
+func (k KeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{
+		k.Next,
+		k.NextFile,
+		k.StageHunk,
+		k.StageFile,
+		k.Close,
+	}
+}
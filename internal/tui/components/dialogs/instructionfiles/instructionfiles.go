@@ -0,0 +1,143 @@
+// Package instructionfiles provides a dialog showing the instruction files
+// of the workspace and of its subdirectories, and which files they apply
+// to.
+package instructionfiles
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/viewport"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+
+	"github.com/charmbracelet/crush/internal/instructions"
+	"github.com/charmbracelet/crush/internal/tui/components/core"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+)
+
+const (
+	InstructionFilesDialogID dialogs.DialogID = "instruction_files"
+
+	width = 80
+)
+
+// InstructionFilesDialog shows the instruction files.
+type InstructionFilesDialog interface {
+	dialogs.DialogModel
+}
+
+type instructionFilesDialogCmp struct {
+	wWidth  int
+	wHeight int
+
+	workspace []instructions.File
+	nested    []instructions.File
+	viewport  viewport.Model
+	keyMap    KeyMap
+	help      help.Model
+}
+
+// NewInstructionFilesDialog creates the dialog showing the instruction
+// files of the workspace, and the ones of its subdirectories.
+func NewInstructionFilesDialog(workspace, nested []instructions.File) InstructionFilesDialog {
+	t := styles.CurrentTheme()
+	help := help.New()
+	help.Styles = t.S().Help
+	vp := viewport.New()
+	vp.SetWidth(width)
+	return &instructionFilesDialogCmp{
+		workspace: workspace,
+		nested:    nested,
+		viewport:  vp,
+		keyMap:    DefaultKeyMap(),
+		help:      help,
+	}
+}
+
+func (m *instructionFilesDialogCmp) Init() tea.Cmd {
+	m.viewport.SetContent(m.files())
+	return nil
+}
+
+func (m *instructionFilesDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.wWidth = msg.Width
+		m.wHeight = msg.Height
+		m.viewport.SetHeight(max(msg.Height-14, 5))
+		return m, nil
+	case tea.KeyPressMsg:
+		if key.Matches(msg, m.keyMap.Close) {
+			return m, util.CmdHandler(dialogs.CloseDialogMsg{})
+		}
+	}
+	vp, cmd := m.viewport.Update(msg)
+	m.viewport = vp
+	return m, cmd
+}
+
+// files renders the instruction files, the ones of the workspace first.
+func (m *instructionFilesDialogCmp) files() string {
+	t := styles.CurrentTheme()
+	if len(m.workspace) == 0 && len(m.nested) == 0 {
+		return t.S().Subtle.Render("This workspace has no instruction files.")
+	}
+
+	var b strings.Builder
+	for _, f := range m.workspace {
+		fmt.Fprintf(&b, "## %s\n\n_Always in the system prompt._\n\n%s\n\n", filepath.Base(f.Path), strings.TrimSpace(f.Content))
+	}
+	for _, f := range m.nested {
+		fmt.Fprintf(&b, "## %s/%s\n\n_Included when the agent works on files under %s/._\n\n%s\n\n", f.Dir, filepath.Base(f.Path), f.Dir, strings.TrimSpace(f.Content))
+	}
+	rendered, err := styles.GetMarkdownRenderer(width).Render(b.String())
+	if err != nil {
+		return b.String()
+	}
+	return strings.TrimSpace(rendered)
+}
+
+func (m *instructionFilesDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	baseStyle := t.S().Base
+
+	m.viewport.SetHeight(min(m.viewport.TotalLineCount(), max(m.wHeight-14, 5)))
+	hint := "Instructions of a directory take precedence over the ones of its parent directories and of the workspace."
+
+	content := baseStyle.Width(width).Render(
+		lipgloss.JoinVertical(
+			lipgloss.Left,
+			core.Title("Instruction Files", width),
+			"",
+			m.viewport.View(),
+			"",
+			t.S().Subtle.Width(width).Render(hint),
+			"",
+			m.help.View(m.keyMap),
+		),
+	)
+
+	return baseStyle.
+		Padding(1, 2).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus).
+		Render(content)
+}
+
+func (m *instructionFilesDialogCmp) Position() (int, int) {
+	row := m.wHeight / 2
+	row -= lipgloss.Height(m.View()) / 2
+	col := m.wWidth / 2
+	col -= (width + 6) / 2
+	return row, col
+}
+
+func (m *instructionFilesDialogCmp) ID() dialogs.DialogID {
+	return InstructionFilesDialogID
+}
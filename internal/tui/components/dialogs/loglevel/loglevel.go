@@ -0,0 +1,240 @@
+// Package loglevel provides a dialog to change the log level of the running
+// process, to raise verbosity while reproducing an issue without restarting.
+package loglevel
+
+import (
+	"log/slog"
+	"strings"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+
+	"github.com/charmbracelet/crush/internal/log"
+	"github.com/charmbracelet/crush/internal/tui/components/core"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/exp/list"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+)
+
+const (
+	LogLevelDialogID dialogs.DialogID = "log_level"
+
+	defaultWidth int = 50
+)
+
+type listModel = list.FilterableList[list.CompletionItem[slog.Level]]
+
+type LogLevelDialog interface {
+	dialogs.DialogModel
+}
+
+type logLevelDialogCmp struct {
+	width   int
+	wWidth  int // Width of the terminal window
+	wHeight int // Height of the terminal window
+
+	levelList listModel
+	keyMap    LogLevelDialogKeyMap
+	help      help.Model
+}
+
+// LogLevelSelectedMsg is sent when a log level is picked in the dialog.
+type LogLevelSelectedMsg struct {
+	Level slog.Level
+}
+
+type LogLevelDialogKeyMap struct {
+	Next     key.Binding
+	Previous key.Binding
+	Select   key.Binding
+	Close    key.Binding
+}
+
+func DefaultLogLevelDialogKeyMap() LogLevelDialogKeyMap {
+	return LogLevelDialogKeyMap{
+		Next: key.NewBinding(
+			key.WithKeys("down", "j", "ctrl+n"),
+			key.WithHelp("↓/j/ctrl+n", "next"),
+		),
+		Previous: key.NewBinding(
+			key.WithKeys("up", "k", "ctrl+p"),
+			key.WithHelp("↑/k/ctrl+p", "previous"),
+		),
+		Select: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "select"),
+		),
+		Close: key.NewBinding(
+			key.WithKeys("esc", "ctrl+c"),
+			key.WithHelp("esc/ctrl+c", "close"),
+		),
+	}
+}
+
+func (k LogLevelDialogKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Select, k.Close}
+}
+
+func (k LogLevelDialogKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Next, k.Previous},
+		{k.Select, k.Close},
+	}
+}
+
+func NewLogLevelDialog() LogLevelDialog {
+	keyMap := DefaultLogLevelDialogKeyMap()
+	listKeyMap := list.DefaultKeyMap()
+	listKeyMap.Down.SetEnabled(false)
+	listKeyMap.Up.SetEnabled(false)
+	listKeyMap.DownOneItem = keyMap.Next
+	listKeyMap.UpOneItem = keyMap.Previous
+
+	t := styles.CurrentTheme()
+	inputStyle := t.S().Base.PaddingLeft(1).PaddingBottom(1)
+	levelList := list.NewFilterableList(
+		[]list.CompletionItem[slog.Level]{},
+		list.WithFilterInputStyle(inputStyle),
+		list.WithFilterListOptions(
+			list.WithKeyMap(listKeyMap),
+			list.WithWrapNavigation(),
+			list.WithResizeByList(),
+		),
+	)
+	help := help.New()
+	help.Styles = t.S().Help
+
+	return &logLevelDialogCmp{
+		levelList: levelList,
+		width:     defaultWidth,
+		keyMap:    keyMap,
+		help:      help,
+	}
+}
+
+func (l *logLevelDialogCmp) Init() tea.Cmd {
+	current := log.Level()
+	items := make([]list.CompletionItem[slog.Level], 0, len(log.Levels))
+	for _, level := range log.Levels {
+		id := strings.ToLower(level.String())
+		opts := []list.CompletionItemOption{
+			list.WithCompletionID(id),
+		}
+		if level == current {
+			opts = append(opts, list.WithCompletionShortcut("current"))
+		}
+		items = append(items, list.NewCompletionItem(levelTitle(level), level, opts...))
+	}
+	return tea.Sequence(
+		l.levelList.SetItems(items),
+		l.levelList.SetSelected(strings.ToLower(current.String())),
+	)
+}
+
+// levelTitle describes what a level writes to the log.
+func levelTitle(level slog.Level) string {
+	switch level {
+	case slog.LevelDebug:
+		return "Debug (everything, including requests)"
+	case slog.LevelInfo:
+		return "Info"
+	case slog.LevelWarn:
+		return "Warn (warnings and errors only)"
+	default:
+		return "Error (errors only)"
+	}
+}
+
+func (l *logLevelDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		l.wWidth = msg.Width
+		l.wHeight = msg.Height
+		return l, l.levelList.SetSize(l.listWidth(), l.listHeight())
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, l.keyMap.Select):
+			selectedItem := l.levelList.SelectedItem()
+			if selectedItem == nil {
+				return l, nil // No item selected, do nothing
+			}
+			level := (*selectedItem).Value()
+			return l, tea.Sequence(
+				util.CmdHandler(dialogs.CloseDialogMsg{}),
+				util.CmdHandler(LogLevelSelectedMsg{Level: level}),
+			)
+		case key.Matches(msg, l.keyMap.Close):
+			return l, util.CmdHandler(dialogs.CloseDialogMsg{})
+		default:
+			u, cmd := l.levelList.Update(msg)
+			l.levelList = u.(listModel)
+			return l, cmd
+		}
+	}
+	return l, nil
+}
+
+func (l *logLevelDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	listView := l.levelList
+
+	header := t.S().Base.Padding(0, 1, 1, 1).Render(core.Title("Select Log Level", l.width-4))
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		header,
+		listView.View(),
+		"",
+		t.S().Base.Width(l.width-2).PaddingLeft(1).AlignHorizontal(lipgloss.Left).Render(l.help.View(l.keyMap)),
+	)
+	return l.style().Render(content)
+}
+
+func (l *logLevelDialogCmp) Cursor() *tea.Cursor {
+	if cursor, ok := l.levelList.(util.Cursor); ok {
+		cursor := cursor.Cursor()
+		if cursor != nil {
+			cursor = l.moveCursor(cursor)
+		}
+		return cursor
+	}
+	return nil
+}
+
+func (l *logLevelDialogCmp) listWidth() int {
+	return l.width - 2 // 4 for padding
+}
+
+func (l *logLevelDialogCmp) listHeight() int {
+	listHeight := len(l.levelList.Items()) + 2 + 4 // height based on items + 2 for the input + 4 for the sections
+	return min(listHeight, l.wHeight/2)
+}
+
+func (l *logLevelDialogCmp) moveCursor(cursor *tea.Cursor) *tea.Cursor {
+	row, col := l.Position()
+	offset := row + 3
+	cursor.Y += offset
+	cursor.X = cursor.X + col + 2
+	return cursor
+}
+
+func (l *logLevelDialogCmp) style() lipgloss.Style {
+	t := styles.CurrentTheme()
+	return t.S().Base.
+		Width(l.width).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus)
+}
+
+func (l *logLevelDialogCmp) Position() (int, int) {
+	row := l.wHeight/4 - 2 // just a bit above the center
+	col := l.wWidth / 2
+	col -= l.width / 2
+	return row, col
+}
+
+func (l *logLevelDialogCmp) ID() dialogs.DialogID {
+	return LogLevelDialogID
+}
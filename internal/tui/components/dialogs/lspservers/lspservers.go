@@ -0,0 +1,265 @@
+// Package lspservers provides a dialog showing the state of the configured
+// language servers, to restart, stop, enable or disable them.
+package lspservers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+
+	"github.com/charmbracelet/crush/internal/app"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/lsp"
+	"github.com/charmbracelet/crush/internal/tui/components/core"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+	"github.com/charmbracelet/x/ansi"
+)
+
+const (
+	LSPServersDialogID dialogs.DialogID = "lsp_servers"
+
+	width = 76
+
+	// refreshInterval is how often the memory usage of the servers is read.
+	refreshInterval = 2 * time.Second
+)
+
+// RestartLSPMsg asks to restart the client of a language server.
+type RestartLSPMsg struct {
+	Name string
+}
+
+// StopLSPMsg asks to stop the client of a language server.
+type StopLSPMsg struct {
+	Name string
+}
+
+// StartLSPMsg asks to start the client of a stopped language server.
+type StartLSPMsg struct {
+	Name string
+}
+
+// SetLSPEnabledMsg asks to enable or disable a language server in the config.
+type SetLSPEnabledMsg struct {
+	Name    string
+	Enabled bool
+}
+
+// refreshMsg carries the memory usage of the servers, in bytes by name.
+type refreshMsg struct {
+	dialog *lspServersDialogCmp
+	memory map[string]int64
+}
+
+// LSPServersDialog shows the configured language servers.
+type LSPServersDialog interface {
+	dialogs.DialogModel
+}
+
+type lspServersDialogCmp struct {
+	wWidth  int
+	wHeight int
+
+	cursor int
+	memory map[string]int64
+	keyMap KeyMap
+	help   help.Model
+}
+
+// NewLSPServersDialog creates the dialog of the configured language servers.
+func NewLSPServersDialog() LSPServersDialog {
+	t := styles.CurrentTheme()
+	help := help.New()
+	help.Styles = t.S().Help
+	return &lspServersDialogCmp{
+		keyMap: DefaultKeyMap(),
+		help:   help,
+	}
+}
+
+func (l *lspServersDialogCmp) Init() tea.Cmd {
+	return l.refresh()
+}
+
+// refresh reads the memory usage of the running servers.
+func (l *lspServersDialogCmp) refresh() tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), refreshInterval)
+		defer cancel()
+		memory := make(map[string]int64)
+		procs, err := lsp.ChildProcesses(ctx)
+		if err == nil {
+			for name, info := range app.GetLSPStates() {
+				if info.Client == nil {
+					continue
+				}
+				if p, ok := info.Client.Process(procs); ok {
+					memory[name] = p.RSS
+				}
+			}
+		}
+		return refreshMsg{dialog: l, memory: memory}
+	}
+}
+
+func (l *lspServersDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	servers := config.Get().LSP.Sorted()
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		l.wWidth = msg.Width
+		l.wHeight = msg.Height
+	case refreshMsg:
+		// Refreshes of a closed dialog stop there.
+		if msg.dialog != l {
+			return l, nil
+		}
+		l.memory = msg.memory
+		return l, tea.Tick(refreshInterval, func(time.Time) tea.Msg {
+			return l.refresh()()
+		})
+	case tea.KeyPressMsg:
+		if key.Matches(msg, l.keyMap.Close) {
+			return l, util.CmdHandler(dialogs.CloseDialogMsg{})
+		}
+		if len(servers) == 0 {
+			return l, nil
+		}
+		l.cursor = min(l.cursor, len(servers)-1)
+		server := servers[l.cursor]
+		switch {
+		case key.Matches(msg, l.keyMap.Next):
+			l.cursor = (l.cursor + 1) % len(servers)
+		case key.Matches(msg, l.keyMap.Previous):
+			l.cursor = (l.cursor - 1 + len(servers)) % len(servers)
+		case key.Matches(msg, l.keyMap.Restart):
+			if server.LSP.Disabled {
+				return l, util.ReportWarn(server.Name + " is disabled, enable it first")
+			}
+			return l, util.CmdHandler(RestartLSPMsg{Name: server.Name})
+		case key.Matches(msg, l.keyMap.Stop):
+			if server.LSP.Disabled {
+				return l, util.ReportWarn(server.Name + " is disabled, enable it first")
+			}
+			info, _ := app.GetLSPState(server.Name)
+			if isRunning(info.State) {
+				return l, util.CmdHandler(StopLSPMsg{Name: server.Name})
+			}
+			return l, util.CmdHandler(StartLSPMsg{Name: server.Name})
+		case key.Matches(msg, l.keyMap.Enable):
+			return l, util.CmdHandler(SetLSPEnabledMsg{Name: server.Name, Enabled: server.LSP.Disabled})
+		}
+	}
+	return l, nil
+}
+
+// isRunning reports whether a client in the state can be stopped.
+func isRunning(state lsp.ServerState) bool {
+	return state == lsp.StateStarting || state == lsp.StateReady || state == lsp.StateError
+}
+
+// stateText describes the state of a server.
+func stateText(t *styles.Theme, server config.LSP, info app.LSPClientInfo) string {
+	if server.LSP.Disabled {
+		return t.S().Subtle.Render("disabled")
+	}
+	switch info.State {
+	case lsp.StateStarting:
+		return t.S().Warning.Render("starting")
+	case lsp.StateReady:
+		return t.S().Success.Render("ready")
+	case lsp.StateError:
+		if errors.Is(info.Error, app.ErrLSPServerExited) {
+			return t.S().Error.Render("crashed")
+		}
+		return t.S().Error.Render("error")
+	case lsp.StateDisabled:
+		return t.S().Subtle.Render("inactive")
+	case lsp.StateStopped:
+		return t.S().Subtle.Render("stopped")
+	default:
+		return t.S().Subtle.Render("not started")
+	}
+}
+
+func (l *lspServersDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	baseStyle := t.S().Base
+	servers := config.Get().LSP.Sorted()
+	states := app.GetLSPStates()
+
+	var rows []string
+	for i, server := range servers {
+		info := states[server.Name]
+		columns := []string{
+			t.S().Text.Bold(true).Width(20).Render(ansi.Truncate(server.Name, 19, "…")),
+			lipgloss.NewStyle().Width(12).Render(stateText(t, server, info)),
+		}
+		memory := "-"
+		if rss, ok := l.memory[server.Name]; ok {
+			memory = fmt.Sprintf("%d MB", rss>>20)
+		}
+		columns = append(columns,
+			t.S().Muted.Width(10).Render(memory),
+			t.S().Muted.Render(fmt.Sprintf("%d diagnostics", info.DiagnosticCount)),
+		)
+		style := baseStyle.Width(width).PaddingLeft(1)
+		if i == l.cursor {
+			style = style.Background(t.BgSubtle)
+		}
+		rows = append(rows, style.Render(lipgloss.JoinHorizontal(lipgloss.Top, columns...)))
+	}
+	if len(rows) == 0 {
+		rows = append(rows, t.S().Muted.Render("No language servers configured."))
+	}
+
+	var errs []string
+	if l.cursor < len(servers) {
+		for _, e := range states[servers[l.cursor].Name].RecentErrors {
+			line := fmt.Sprintf("%s %s", e.Time.Format(time.TimeOnly), e.Err)
+			errs = append(errs, t.S().Muted.Render(ansi.Truncate(line, width, "…")))
+		}
+	}
+	if len(errs) == 0 {
+		errs = append(errs, t.S().Subtle.Render("No recent errors."))
+	}
+
+	content := baseStyle.Width(width).Render(
+		lipgloss.JoinVertical(
+			lipgloss.Left,
+			core.Title("Language Servers", width),
+			"",
+			lipgloss.JoinVertical(lipgloss.Left, rows...),
+			"",
+			t.S().Text.Bold(true).Render("Recent errors"),
+			lipgloss.JoinVertical(lipgloss.Left, errs...),
+			"",
+			l.help.View(l.keyMap),
+		),
+	)
+
+	return baseStyle.
+		Padding(1, 2).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus).
+		Render(content)
+}
+
+func (l *lspServersDialogCmp) Position() (int, int) {
+	row := l.wHeight / 2
+	row -= lipgloss.Height(l.View()) / 2
+	col := l.wWidth / 2
+	col -= (width + 6) / 2
+	return row, col
+}
+
+func (l *lspServersDialogCmp) ID() dialogs.DialogID {
+	return LSPServersDialogID
+}
@@ -0,0 +1,216 @@
+// Package lspsetup provides a dialog offering to set up the language servers
+// of the languages detected in the project, installing the missing ones.
+package lspsetup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/lsp"
+	"github.com/charmbracelet/crush/internal/tui/components/core"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+	"github.com/charmbracelet/x/ansi"
+)
+
+const (
+	LSPSetupDialogID dialogs.DialogID = "lsp_setup"
+
+	width = 70
+)
+
+// LSPsConfiguredMsg is sent once the language servers picked in the dialog
+// are installed and configured. Err holds why the others couldn't be.
+type LSPsConfiguredMsg struct {
+	Names []string
+	Err   error
+}
+
+// LSPSetupDialog offers to set up the language servers of a project.
+type LSPSetupDialog interface {
+	dialogs.DialogModel
+}
+
+type lspSetupDialogCmp struct {
+	wWidth  int
+	wHeight int
+
+	suggestions []lsp.Suggestion
+	selected    []bool
+	cursor      int
+	keyMap      KeyMap
+	help        help.Model
+}
+
+// NewLSPSetupDialog creates the dialog for the suggested servers. The ones
+// that are installed or can be are picked by default.
+func NewLSPSetupDialog(suggestions []lsp.Suggestion) LSPSetupDialog {
+	selected := make([]bool, len(suggestions))
+	for i, s := range suggestions {
+		selected[i] = s.Installed || s.CanInstall
+	}
+	t := styles.CurrentTheme()
+	help := help.New()
+	help.Styles = t.S().Help
+	return &lspSetupDialogCmp{
+		suggestions: suggestions,
+		selected:    selected,
+		keyMap:      DefaultKeyMap(),
+		help:        help,
+	}
+}
+
+func (l *lspSetupDialogCmp) Init() tea.Cmd {
+	return nil
+}
+
+func (l *lspSetupDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		l.wWidth = msg.Width
+		l.wHeight = msg.Height
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, l.keyMap.Next):
+			l.cursor = (l.cursor + 1) % len(l.suggestions)
+		case key.Matches(msg, l.keyMap.Previous):
+			l.cursor = (l.cursor - 1 + len(l.suggestions)) % len(l.suggestions)
+		case key.Matches(msg, l.keyMap.Toggle):
+			l.selected[l.cursor] = !l.selected[l.cursor]
+		case key.Matches(msg, l.keyMap.Confirm):
+			return l, tea.Sequence(
+				util.CmdHandler(dialogs.CloseDialogMsg{}),
+				l.setUp(),
+			)
+		case key.Matches(msg, l.keyMap.Close):
+			return l, tea.Sequence(
+				util.CmdHandler(dialogs.CloseDialogMsg{}),
+				l.markOffered(),
+			)
+		}
+	}
+	return l, nil
+}
+
+// markOffered records the servers as offered, so that declining isn't asked
+// again on every start.
+func (l *lspSetupDialogCmp) markOffered() tea.Cmd {
+	names := make([]string, len(l.suggestions))
+	for i, s := range l.suggestions {
+		names[i] = s.Name
+	}
+	return func() tea.Msg {
+		if err := config.MarkLSPsOffered(names); err != nil {
+			return util.ReportError(err)()
+		}
+		return nil
+	}
+}
+
+// setUp installs the picked servers that are missing and configures them.
+func (l *lspSetupDialogCmp) setUp() tea.Cmd {
+	var picked []lsp.Suggestion
+	for i, s := range l.suggestions {
+		if l.selected[i] {
+			picked = append(picked, s)
+		}
+	}
+	if len(picked) == 0 {
+		return l.markOffered()
+	}
+	return tea.Sequence(
+		l.markOffered(),
+		util.ReportInfo("Setting up language servers..."),
+		func() tea.Msg {
+			var names []string
+			var errs []error
+			for _, s := range picked {
+				if !s.Installed {
+					if err := lsp.Install(context.Background(), s.Server); err != nil {
+						errs = append(errs, fmt.Errorf("failed to install %s: %w", s.Name, err))
+						continue
+					}
+				}
+				if err := config.Get().AddLSP(s.Name, s.Config()); err != nil {
+					errs = append(errs, err)
+					continue
+				}
+				names = append(names, s.Name)
+			}
+			return LSPsConfiguredMsg{Names: names, Err: errors.Join(errs...)}
+		},
+	)
+}
+
+func (l *lspSetupDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	baseStyle := t.S().Base
+
+	var rows []string
+	for i, s := range l.suggestions {
+		check := "[ ]"
+		if l.selected[i] {
+			check = "[x]"
+		}
+		var status string
+		switch {
+		case s.Installed:
+			status = t.S().Success.Render("installed")
+		case s.CanInstall:
+			status = t.S().Muted.Render("will run " + strings.Join(s.Install, " "))
+		default:
+			status = t.S().Warning.Render("install " + s.Command + " yourself")
+		}
+		row := fmt.Sprintf("%s %s %s", check, t.S().Text.Bold(true).Render(s.Language), t.S().Subtle.Render(s.Name))
+		row = ansi.Truncate(row+"  "+status, width-2, "…")
+		style := baseStyle.Width(width).PaddingLeft(1)
+		if i == l.cursor {
+			style = style.Background(t.BgSubtle)
+		}
+		rows = append(rows, style.Render(row))
+	}
+
+	details := t.S().Muted.Width(width).Render(
+		"Language servers give the agent diagnostics and references for the languages of this project. " +
+			"The picked ones are added to the lsp section of your config.",
+	)
+	content := baseStyle.Width(width).Render(
+		lipgloss.JoinVertical(
+			lipgloss.Left,
+			core.Title("Set Up Language Servers", width),
+			"",
+			details,
+			"",
+			lipgloss.JoinVertical(lipgloss.Left, rows...),
+			"",
+			l.help.View(l.keyMap),
+		),
+	)
+
+	return baseStyle.
+		Padding(1, 2).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus).
+		Render(content)
+}
+
+func (l *lspSetupDialogCmp) Position() (int, int) {
+	row := l.wHeight / 2
+	row -= lipgloss.Height(l.View()) / 2
+	col := l.wWidth / 2
+	col -= (width + 6) / 2
+	return row, col
+}
+
+func (l *lspSetupDialogCmp) ID() dialogs.DialogID {
+	return LSPSetupDialogID
+}
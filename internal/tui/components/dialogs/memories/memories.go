@@ -0,0 +1,178 @@
+// Package memories provides a dialog listing what the agent remembers about
+// the project, to review and forget memories.
+package memories
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+
+	"github.com/charmbracelet/crush/internal/memory"
+	"github.com/charmbracelet/crush/internal/tui/components/core"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+	"github.com/charmbracelet/x/ansi"
+)
+
+const (
+	MemoriesDialogID dialogs.DialogID = "memories"
+
+	width = 76
+	// maxRows is how many memories are shown at once.
+	maxRows = 12
+)
+
+// DeleteMemoryMsg asks to forget a memory.
+type DeleteMemoryMsg struct {
+	ID string
+}
+
+// Store is what the dialog lists the memories of, like memory.Store.
+type Store interface {
+	List() ([]memory.Memory, error)
+}
+
+// MemoriesDialog lists the memories.
+type MemoriesDialog interface {
+	dialogs.DialogModel
+}
+
+type memoriesDialogCmp struct {
+	wWidth  int
+	wHeight int
+
+	store  Store
+	cursor int // Index of the selected memory.
+	keyMap KeyMap
+	help   help.Model
+}
+
+// NewMemoriesDialog creates the dialog listing the memories of the store.
+func NewMemoriesDialog(store Store) MemoriesDialog {
+	t := styles.CurrentTheme()
+	help := help.New()
+	help.Styles = t.S().Help
+	return &memoriesDialogCmp{
+		store:  store,
+		keyMap: DefaultKeyMap(),
+		help:   help,
+	}
+}
+
+func (m *memoriesDialogCmp) Init() tea.Cmd {
+	return nil
+}
+
+func (m *memoriesDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.wWidth = msg.Width
+		m.wHeight = msg.Height
+	case tea.KeyPressMsg:
+		if key.Matches(msg, m.keyMap.Close) {
+			return m, util.CmdHandler(dialogs.CloseDialogMsg{})
+		}
+		memories, err := m.store.List()
+		if err != nil || len(memories) == 0 {
+			return m, nil
+		}
+		m.cursor = min(m.cursor, len(memories)-1)
+		switch {
+		case key.Matches(msg, m.keyMap.Next):
+			m.cursor = (m.cursor + 1) % len(memories)
+		case key.Matches(msg, m.keyMap.Previous):
+			m.cursor = (m.cursor - 1 + len(memories)) % len(memories)
+		case key.Matches(msg, m.keyMap.Delete):
+			return m, util.CmdHandler(DeleteMemoryMsg{ID: memories[m.cursor].ID})
+		}
+	}
+	return m, nil
+}
+
+// memoryRow renders a memory with how long ago it was learned.
+func memoryRow(t *styles.Theme, mem memory.Memory, now time.Time) string {
+	content := strings.Join(strings.Fields(mem.Content), " ")
+	return lipgloss.JoinHorizontal(
+		lipgloss.Top,
+		t.S().Text.Render(ansi.Truncate(content, width-12, "…")),
+		" ",
+		t.S().Muted.Render(age(now.Sub(time.Unix(mem.CreatedAt, 0)))),
+	)
+}
+
+// age renders a duration in its largest unit.
+func age(d time.Duration) string {
+	switch {
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
+
+func (m *memoriesDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	baseStyle := t.S().Base
+	memories, err := m.store.List()
+	cursor := min(m.cursor, len(memories)-1)
+
+	// Scroll so the selected memory is visible.
+	first := max(0, cursor-maxRows+1)
+	var rows []string
+	for i := first; i < min(len(memories), first+maxRows); i++ {
+		style := baseStyle.Width(width).PaddingLeft(1)
+		if i == cursor {
+			style = style.Background(t.BgSubtle)
+		}
+		rows = append(rows, style.Render(memoryRow(t, memories[i], time.Now())))
+	}
+	switch {
+	case err != nil:
+		rows = append(rows, t.S().Error.Render(err.Error()))
+	case len(rows) == 0:
+		rows = append(rows, t.S().Subtle.Render("Nothing remembered yet."))
+	}
+
+	summary := fmt.Sprintf("%d remembered", len(memories))
+	if len(memories) > maxRows {
+		summary += fmt.Sprintf(" · %d/%d", cursor+1, len(memories))
+	}
+
+	content := baseStyle.Width(width).Render(
+		lipgloss.JoinVertical(
+			lipgloss.Left,
+			core.Title("Memories", width),
+			"",
+			t.S().Muted.Render(summary),
+			lipgloss.JoinVertical(lipgloss.Left, rows...),
+			"",
+			m.help.View(m.keyMap),
+		),
+	)
+
+	return baseStyle.
+		Padding(1, 2).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus).
+		Render(content)
+}
+
+func (m *memoriesDialogCmp) Position() (int, int) {
+	row := m.wHeight / 2
+	row -= lipgloss.Height(m.View()) / 2
+	col := m.wWidth / 2
+	col -= (width + 6) / 2
+	return row, col
+}
+
+func (m *memoriesDialogCmp) ID() dialogs.DialogID {
+	return MemoriesDialogID
+}
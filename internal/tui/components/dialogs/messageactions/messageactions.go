@@ -0,0 +1,320 @@
+// Package messageactions provides the menu of actions on a single chat
+// message: copying, quoting, forking, regenerating, deleting, exporting,
+// reading it in a browser, previewing its math, viewing its diagrams and
+// opening the files it refers to.
+package messageactions
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+
+	"github.com/charmbracelet/crush/internal/diagram"
+	"github.com/charmbracelet/crush/internal/home"
+	"github.com/charmbracelet/crush/internal/latex"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/tui/components/core"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/exp/list"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+)
+
+const (
+	MessageActionsDialogID dialogs.DialogID = "message_actions"
+
+	defaultWidth int = 50
+
+	// maxFiles is the most referenced files offered in the menu.
+	maxFiles = 10
+)
+
+// ActionKind is what an action does with the message.
+type ActionKind string
+
+const (
+	ActionCopy           ActionKind = "copy"
+	ActionQuote          ActionKind = "quote"
+	ActionFork           ActionKind = "fork"
+	ActionRegenerate     ActionKind = "regenerate"
+	ActionDelete         ActionKind = "delete"
+	ActionDeleteToolCall ActionKind = "delete_tool_call"
+	ActionExport         ActionKind = "export"
+	ActionOpenFile       ActionKind = "open_file"
+	ActionToggleExcluded ActionKind = "toggle_excluded"
+	ActionOpenInBrowser  ActionKind = "open_in_browser"
+	ActionPreviewMath    ActionKind = "preview_math"
+	ActionViewDiagrams   ActionKind = "view_diagrams"
+)
+
+// Action is an entry of the menu.
+type Action struct {
+	Kind ActionKind
+	// Path is the file to open, for ActionOpenFile.
+	Path string
+	// ToolCallID is the tool call to delete, for ActionDeleteToolCall.
+	ToolCallID string
+}
+
+// ActionSelectedMsg is sent when an action is picked in the menu.
+type ActionSelectedMsg struct {
+	Action  Action
+	Message message.Message
+}
+
+type listModel = list.FilterableList[list.CompletionItem[Action]]
+
+type MessageActionsDialog interface {
+	dialogs.DialogModel
+}
+
+type messageActionsDialogCmp struct {
+	width   int
+	wWidth  int // Width of the terminal window
+	wHeight int // Height of the terminal window
+
+	message    message.Message
+	toolCallID string
+	workingDir string
+	actionList listModel
+	keyMap     KeyMap
+	help       help.Model
+}
+
+// NewMessageActionsDialog creates the actions menu of a message. When it's
+// opened on one of the message's tool calls, toolCallID is set and the menu
+// also offers to delete just that call.
+func NewMessageActionsDialog(msg message.Message, toolCallID, workingDir string) MessageActionsDialog {
+	keyMap := DefaultKeyMap()
+	listKeyMap := list.DefaultKeyMap()
+	listKeyMap.Down.SetEnabled(false)
+	listKeyMap.Up.SetEnabled(false)
+	listKeyMap.DownOneItem = keyMap.Next
+	listKeyMap.UpOneItem = keyMap.Previous
+
+	t := styles.CurrentTheme()
+	inputStyle := t.S().Base.PaddingLeft(1).PaddingBottom(1)
+	actionList := list.NewFilterableList(
+		[]list.CompletionItem[Action]{},
+		list.WithFilterInputStyle(inputStyle),
+		list.WithFilterListOptions(
+			list.WithKeyMap(listKeyMap),
+			list.WithWrapNavigation(),
+			list.WithResizeByList(),
+		),
+	)
+	help := help.New()
+	help.Styles = t.S().Help
+
+	return &messageActionsDialogCmp{
+		message:    msg,
+		toolCallID: toolCallID,
+		workingDir: workingDir,
+		actionList: actionList,
+		width:      defaultWidth,
+		keyMap:     keyMap,
+		help:       help,
+	}
+}
+
+func (m *messageActionsDialogCmp) Init() tea.Cmd {
+	var items []list.CompletionItem[Action]
+	add := func(title string, action Action) {
+		id := string(action.Kind)
+		if action.Path != "" {
+			id += ":" + action.Path
+		}
+		items = append(items, list.NewCompletionItem(title, action, list.WithCompletionID(id)))
+	}
+
+	if strings.TrimSpace(m.message.Content().Text) != "" {
+		add("Copy", Action{Kind: ActionCopy})
+		add("Quote in Reply", Action{Kind: ActionQuote})
+	}
+	add("Fork Session from Here", Action{Kind: ActionFork})
+	if m.message.Role == message.User {
+		add("Resend", Action{Kind: ActionRegenerate})
+	} else {
+		add("Regenerate Response", Action{Kind: ActionRegenerate})
+	}
+	if m.toolCallID != "" {
+		add("Delete Tool Call", Action{Kind: ActionDeleteToolCall, ToolCallID: m.toolCallID})
+		add("Delete Message with All Tool Calls", Action{Kind: ActionDelete})
+	} else {
+		add("Delete", Action{Kind: ActionDelete})
+	}
+	if m.message.Excluded {
+		add("Include in Context", Action{Kind: ActionToggleExcluded})
+	} else {
+		add("Exclude from Context", Action{Kind: ActionToggleExcluded})
+	}
+	add("Export as Markdown", Action{Kind: ActionExport})
+	add("Open in Browser", Action{Kind: ActionOpenInBrowser})
+	if m.message.Role == message.Assistant && len(latex.Find(m.message.Content().Text)) > 0 {
+		add("Preview Math in Browser", Action{Kind: ActionPreviewMath})
+	}
+	if m.message.Role == message.Assistant && len(diagram.Find(m.message.Content().Text)) > 0 {
+		add("View Diagrams", Action{Kind: ActionViewDiagrams})
+	}
+	for _, path := range referencedFiles(m.message, m.workingDir) {
+		add("Open "+displayPath(path, m.workingDir), Action{Kind: ActionOpenFile, Path: path})
+	}
+	return m.actionList.SetItems(items)
+}
+
+func (m *messageActionsDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.wWidth = msg.Width
+		m.wHeight = msg.Height
+		return m, m.actionList.SetSize(m.listWidth(), m.listHeight())
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, m.keyMap.Select):
+			selectedItem := m.actionList.SelectedItem()
+			if selectedItem == nil {
+				return m, nil // No item selected, do nothing
+			}
+			action := (*selectedItem).Value()
+			return m, tea.Sequence(
+				util.CmdHandler(dialogs.CloseDialogMsg{}),
+				util.CmdHandler(ActionSelectedMsg{Action: action, Message: m.message}),
+			)
+		case key.Matches(msg, m.keyMap.Close):
+			return m, util.CmdHandler(dialogs.CloseDialogMsg{})
+		default:
+			u, cmd := m.actionList.Update(msg)
+			m.actionList = u.(listModel)
+			return m, cmd
+		}
+	}
+	return m, nil
+}
+
+func (m *messageActionsDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	title := "User Message"
+	if m.message.Role == message.Assistant {
+		title = "Assistant Message"
+	}
+	header := t.S().Base.Padding(0, 1, 1, 1).Render(core.Title(title, m.width-4))
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		header,
+		m.actionList.View(),
+		"",
+		t.S().Base.Width(m.width-2).PaddingLeft(1).AlignHorizontal(lipgloss.Left).Render(m.help.View(m.keyMap)),
+	)
+	return m.style().Render(content)
+}
+
+func (m *messageActionsDialogCmp) Cursor() *tea.Cursor {
+	if cursor, ok := m.actionList.(util.Cursor); ok {
+		cursor := cursor.Cursor()
+		if cursor != nil {
+			cursor = m.moveCursor(cursor)
+		}
+		return cursor
+	}
+	return nil
+}
+
+func (m *messageActionsDialogCmp) listWidth() int {
+	return m.width - 2 // 4 for padding
+}
+
+func (m *messageActionsDialogCmp) listHeight() int {
+	listHeight := len(m.actionList.Items()) + 2 + 4 // height based on items + 2 for the input + 4 for the sections
+	return min(listHeight, m.wHeight/2)
+}
+
+func (m *messageActionsDialogCmp) moveCursor(cursor *tea.Cursor) *tea.Cursor {
+	row, col := m.Position()
+	offset := row + 3
+	cursor.Y += offset
+	cursor.X = cursor.X + col + 2
+	return cursor
+}
+
+func (m *messageActionsDialogCmp) style() lipgloss.Style {
+	t := styles.CurrentTheme()
+	return t.S().Base.
+		Width(m.width).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus)
+}
+
+func (m *messageActionsDialogCmp) Position() (int, int) {
+	row := m.wHeight/4 - 2 // just a bit above the center
+	col := m.wWidth / 2
+	col -= m.width / 2
+	return row, col
+}
+
+func (m *messageActionsDialogCmp) ID() dialogs.DialogID {
+	return MessageActionsDialogID
+}
+
+// pathPattern matches words that look like file paths in message text.
+var pathPattern = regexp.MustCompile(`[\w.~/-]*[\w-]\.\w+`)
+
+// referencedFiles returns the existing files a message refers to: its
+// attachments, the paths given to its tool calls and the paths mentioned in
+// its text.
+func referencedFiles(msg message.Message, workingDir string) []string {
+	var candidates []string
+	for _, bc := range msg.BinaryContent() {
+		candidates = append(candidates, bc.Path)
+	}
+	for _, tc := range msg.ToolCalls() {
+		var params struct {
+			FilePath string `json:"file_path"`
+			Path     string `json:"path"`
+		}
+		if json.Unmarshal([]byte(tc.Input), &params) == nil {
+			candidates = append(candidates, params.FilePath, params.Path)
+		}
+	}
+	candidates = append(candidates, pathPattern.FindAllString(msg.Content().Text, -1)...)
+
+	var files []string
+	seen := make(map[string]bool)
+	for _, path := range candidates {
+		if path == "" {
+			continue
+		}
+		path = home.Long(path)
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(workingDir, path)
+		}
+		path = filepath.Clean(path)
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+		if fi, err := os.Stat(path); err != nil || !fi.Mode().IsRegular() {
+			continue
+		}
+		files = append(files, path)
+		if len(files) == maxFiles {
+			break
+		}
+	}
+	return files
+}
+
+// displayPath shortens a path for the menu, relative to the working
+// directory when it's inside it.
+func displayPath(path, workingDir string) string {
+	if rel, err := filepath.Rel(workingDir, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return home.Short(path)
+}
@@ -9,6 +9,8 @@ import (
 	tea "charm.land/bubbletea/v2"
 	"github.com/charmbracelet/catwalk/pkg/catwalk"
 	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/latency"
+	"github.com/charmbracelet/crush/internal/netstatus"
 	"github.com/charmbracelet/crush/internal/tui/exp/list"
 	"github.com/charmbracelet/crush/internal/tui/styles"
 	"github.com/charmbracelet/crush/internal/tui/util"
@@ -29,6 +31,16 @@ func modelKey(providerID, modelID string) string {
 	return providerID + ":" + modelID
 }
 
+// speed returns the average time to first token and tokens per second of
+// the latest responses of a model, or "" when none were recorded.
+func speed(providerID, modelID string) string {
+	stats, ok := latency.Get(providerID, modelID)
+	if !ok {
+		return ""
+	}
+	return stats.String()
+}
+
 func NewModelListComponent(keyMap list.KeyMap, inputPlaceholder string, shouldResize bool) *ModelListComponent {
 	t := styles.CurrentTheme()
 	inputStyle := t.S().Base.PaddingLeft(1).PaddingBottom(1)
@@ -141,7 +153,7 @@ func (m *ModelListComponent) SetModelType(modelType int) tea.Cmd {
 		return util.ReportError(err)
 	}
 	for providerID, providerConfig := range cfg.Providers.Seq2() {
-		if providerConfig.Disable {
+		if providerConfig.Disable || !providerAvailable(cfg, providerConfig.BaseURL) {
 			continue
 		}
 
@@ -172,6 +184,7 @@ func (m *ModelListComponent) SetModelType(modelType int) tea.Cmd {
 					model.Name,
 					modelOption,
 					list.WithCompletionID(key),
+					list.WithCompletionShortcut(speed(string(configProvider.ID), model.ID)),
 				)
 
 				// Check if this model is already added to prevent duplicates
@@ -218,6 +231,9 @@ func (m *ModelListComponent) SetModelType(modelType int) tea.Cmd {
 		if providerConfigured && providerConfig.Disable {
 			continue
 		}
+		if !providerAvailable(cfg, cmp.Or(providerConfig.BaseURL, provider.APIEndpoint)) {
+			continue
+		}
 
 		displayProvider := provider
 		if providerConfigured {
@@ -266,6 +282,7 @@ func (m *ModelListComponent) SetModelType(modelType int) tea.Cmd {
 				model.Name,
 				modelOption,
 				list.WithCompletionID(key),
+				list.WithCompletionShortcut(speed(string(displayProvider.ID), model.ID)),
 			)
 			itemsByKey[key] = item
 
@@ -304,6 +321,9 @@ func (m *ModelListComponent) SetModelType(modelType int) tea.Cmd {
 			if providerName == "" {
 				providerName = string(modelOption.Provider.ID)
 			}
+			if info := speed(recent.Provider, recent.Model); info != "" {
+				providerName += " · " + info
+			}
 			item := list.NewCompletionItem(
 				modelOption.Model.Name,
 				option.Value(),
@@ -316,7 +336,9 @@ func (m *ModelListComponent) SetModelType(modelType int) tea.Cmd {
 			}
 		}
 
-		if len(validRecentItems) != len(recentItems) {
+		// Keep remote models around for when local-only mode is turned off
+		// or the network comes back.
+		if len(validRecentItems) != len(recentItems) && !cfg.IsLocalOnly() && netstatus.Online() {
 			if err := cfg.SetConfigField(fmt.Sprintf("recent_models.%s", selectedType), validRecentItems); err != nil {
 				return util.ReportError(err)
 			}
@@ -350,3 +372,16 @@ func (m *ModelListComponent) GetModelType() int {
 func (m *ModelListComponent) SetInputPlaceholder(placeholder string) {
 	m.list.SetInputPlaceholder(placeholder)
 }
+
+// providerAvailable reports whether a provider with the given endpoint can
+// be used right now, taking local-only mode and network status into account.
+func providerAvailable(cfg *config.Config, baseURL string) bool {
+	if !cfg.EndpointAllowed(baseURL) {
+		return false
+	}
+	if netstatus.Online() {
+		return true
+	}
+	resolved, err := cfg.Resolve(baseURL)
+	return err == nil && config.IsLocalEndpoint(resolved)
+}
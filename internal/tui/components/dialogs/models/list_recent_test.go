@@ -50,7 +50,7 @@ func readRecentModels(t *testing.T, path string) map[string]any {
 
 func TestModelList_RecentlyUsedSectionAndPrunesInvalid(t *testing.T) {
 	// Pre-initialize logger to os.DevNull to prevent file lock on Windows.
-	log.Setup(os.DevNull, false)
+	log.Setup(os.DevNull, log.Options{})
 
 	// Isolate config/data paths
 	cfgDir := t.TempDir()
@@ -159,7 +159,7 @@ func TestModelList_RecentlyUsedSectionAndPrunesInvalid(t *testing.T) {
 
 func TestModelList_PrunesInvalidModelWithinValidProvider(t *testing.T) {
 	// Pre-initialize logger to os.DevNull to prevent file lock on Windows.
-	log.Setup(os.DevNull, false)
+	log.Setup(os.DevNull, log.Options{})
 
 	// Isolate config/data paths
 	cfgDir := t.TempDir()
@@ -263,7 +263,7 @@ func TestModelList_PrunesInvalidModelWithinValidProvider(t *testing.T) {
 
 func TestModelList_NoDuplicateModels(t *testing.T) {
 	// Pre-initialize logger to os.DevNull to prevent file lock on Windows.
-	log.Setup(os.DevNull, false)
+	log.Setup(os.DevNull, log.Options{})
 
 	// Isolate config/data paths
 	cfgDir := t.TempDir()
@@ -372,7 +372,7 @@ func TestModelList_NoDuplicateModels(t *testing.T) {
 
 func TestModelList_LinuxXDGPaths(t *testing.T) {
 	// Pre-initialize logger to os.DevNull to prevent file lock on Windows.
-	log.Setup(os.DevNull, false)
+	log.Setup(os.DevNull, log.Options{})
 
 	// Isolate config/data paths
 	cfgDir := t.TempDir()
@@ -499,7 +499,7 @@ func TestModelKey_EmptyInputs(t *testing.T) {
 
 func TestModelList_AllRecentsInvalid(t *testing.T) {
 	// Pre-initialize logger to os.DevNull to prevent file lock on Windows.
-	log.Setup(os.DevNull, false)
+	log.Setup(os.DevNull, log.Options{})
 
 	// Isolate config/data paths
 	cfgDir := t.TempDir()
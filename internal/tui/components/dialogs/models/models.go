@@ -610,8 +610,8 @@ func (m *modelDialogCmp) ID() dialogs.DialogID {
 func (m *modelDialogCmp) modelTypeRadio() string {
 	t := styles.CurrentTheme()
 	choices := []string{"Large Task", "Small Task"}
-	iconSelected := "◉"
-	iconUnselected := "○"
+	iconSelected := styles.RadioOnIcon
+	iconUnselected := styles.RadioOffIcon
 	if m.modelList.GetModelType() == LargeModelType {
 		return t.S().Base.Foreground(t.FgHalfMuted).Render(iconSelected + " " + choices[0] + "  " + iconUnselected + " " + choices[1])
 	}
@@ -0,0 +1,133 @@
+// Package modelspeed provides a dialog comparing how fast the models used on
+// this machine respond.
+package modelspeed
+
+import (
+	"fmt"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+
+	"github.com/charmbracelet/crush/internal/latency"
+	"github.com/charmbracelet/crush/internal/tui/components/core"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+	"github.com/charmbracelet/x/ansi"
+)
+
+const (
+	ModelSpeedDialogID dialogs.DialogID = "model_speed"
+
+	width = 76
+)
+
+// ModelSpeedDialog shows the average latencies of the models.
+type ModelSpeedDialog interface {
+	dialogs.DialogModel
+}
+
+type modelSpeedDialogCmp struct {
+	wWidth  int
+	wHeight int
+
+	stats  []latency.Stats
+	keyMap KeyMap
+	help   help.Model
+}
+
+// NewModelSpeedDialog creates the dialog of the model latencies.
+func NewModelSpeedDialog() ModelSpeedDialog {
+	t := styles.CurrentTheme()
+	help := help.New()
+	help.Styles = t.S().Help
+	return &modelSpeedDialogCmp{
+		stats:  latency.All(),
+		keyMap: DefaultKeyMap(),
+		help:   help,
+	}
+}
+
+func (m *modelSpeedDialogCmp) Init() tea.Cmd {
+	return nil
+}
+
+func (m *modelSpeedDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.wWidth = msg.Width
+		m.wHeight = msg.Height
+	case tea.KeyPressMsg:
+		if key.Matches(msg, m.keyMap.Close) {
+			return m, util.CmdHandler(dialogs.CloseDialogMsg{})
+		}
+	}
+	return m, nil
+}
+
+func (m *modelSpeedDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	baseStyle := t.S().Base
+
+	row := func(style lipgloss.Style, model, ttft, tps, samples string) string {
+		return lipgloss.JoinHorizontal(
+			lipgloss.Top,
+			style.Width(40).Render(ansi.Truncate(model, 39, "…")),
+			style.Width(12).Render(ttft),
+			style.Width(12).Render(tps),
+			style.Render(samples),
+		)
+	}
+
+	rows := []string{row(t.S().Muted, "Model", "First token", "Tokens/s", "Responses")}
+	for _, stats := range m.stats {
+		tps := "–"
+		if stats.TokensPerSecond > 0 {
+			tps = fmt.Sprintf("%.0f", stats.TokensPerSecond)
+		}
+		rows = append(rows, row(
+			t.S().Text,
+			stats.Provider+"/"+stats.Model,
+			// Without tokens per second, only the time to first token shows.
+			latency.Stats{TTFT: stats.TTFT}.String(),
+			tps,
+			fmt.Sprint(stats.Samples),
+		))
+	}
+	if len(m.stats) == 0 {
+		rows = append(rows, t.S().Subtle.Render("No responses recorded yet."))
+	}
+
+	content := baseStyle.Width(width).Render(
+		lipgloss.JoinVertical(
+			lipgloss.Left,
+			core.Title("Model Speed", width),
+			"",
+			lipgloss.JoinVertical(lipgloss.Left, rows...),
+			"",
+			t.S().Subtle.Render("Averages of the latest responses of each model."),
+			"",
+			m.help.View(m.keyMap),
+		),
+	)
+
+	return baseStyle.
+		Padding(1, 2).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus).
+		Render(content)
+}
+
+func (m *modelSpeedDialogCmp) Position() (int, int) {
+	row := m.wHeight / 2
+	row -= lipgloss.Height(m.View()) / 2
+	col := m.wWidth / 2
+	col -= (width + 6) / 2
+	return row, col
+}
+
+func (m *modelSpeedDialogCmp) ID() dialogs.DialogID {
+	return ModelSpeedDialogID
+}
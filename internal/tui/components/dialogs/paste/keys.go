@@ -0,0 +1,54 @@
+package paste
+
+import (
+	"charm.land/bubbles/v2/key"
+)
+
+// KeyMap defines the keyboard bindings for the pasted text dialog.
+type KeyMap struct {
+	Scroll,
+	Expand,
+	Remove,
+	Close key.Binding
+}
+
+func DefaultKeyMap() KeyMap {
+	return KeyMap{
+		Scroll: key.NewBinding(
+			key.WithKeys("up", "down", "pgup", "pgdown", "k", "j"),
+			key.WithHelp("↑/↓", "scroll"),
+		),
+		Expand: key.NewBinding(
+			key.WithKeys("e", "enter"),
+			key.WithHelp("e", "expand into prompt"),
+		),
+		Remove: key.NewBinding(
+			key.WithKeys("d", "x"),
+			key.WithHelp("d", "remove"),
+		),
+		Close: key.NewBinding(
+			key.WithKeys("esc", "alt+esc", "q"),
+			key.WithHelp("esc", "close"),
+		),
+	}
+}
+
+// KeyBindings implements layout.KeyMapProvider
+func (k KeyMap) KeyBindings() []key.Binding {
+	return []key.Binding{
+		k.Scroll,
+		k.Expand,
+		k.Remove,
+		k.Close,
+	}
+}
+
+// FullHelp implements help.KeyMap.
+func (k KeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{k.KeyBindings()}
+}
+
+// ShortHelp implements help.KeyMap.
+func (k KeyMap) ShortHelp() []key.Binding {
+	return k.KeyBindings()
+}
@@ -0,0 +1,145 @@
+// Package paste provides the dialog previewing a large block of pasted text,
+// which the prompt holds as an attachment instead of in the editor.
+package paste
+
+import (
+	"fmt"
+	"strings"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/viewport"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/tui/components/core"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+)
+
+const (
+	PasteDialogID dialogs.DialogID = "paste"
+
+	width = 80
+)
+
+// ExpandMsg replaces the pasted text attachment at Path with its text in the
+// editor.
+type ExpandMsg struct {
+	Path string
+}
+
+// RemoveMsg removes the pasted text attachment at Path.
+type RemoveMsg struct {
+	Path string
+}
+
+// PasteDialog previews pasted text.
+type PasteDialog interface {
+	dialogs.DialogModel
+}
+
+type pasteDialogCmp struct {
+	wWidth  int
+	wHeight int
+
+	attachment message.Attachment
+	viewport   viewport.Model
+	keyMap     KeyMap
+	help       help.Model
+}
+
+// NewPasteDialog creates the dialog previewing the pasted text of an
+// attachment.
+func NewPasteDialog(attachment message.Attachment) PasteDialog {
+	t := styles.CurrentTheme()
+	help := help.New()
+	help.Styles = t.S().Help
+	vp := viewport.New()
+	vp.SetWidth(width)
+	return &pasteDialogCmp{
+		attachment: attachment,
+		viewport:   vp,
+		keyMap:     DefaultKeyMap(),
+		help:       help,
+	}
+}
+
+func (m *pasteDialogCmp) Init() tea.Cmd {
+	m.viewport.SetContent(styles.CurrentTheme().S().Text.Render(strings.ReplaceAll(string(m.attachment.Content), "\t", "    ")))
+	return nil
+}
+
+func (m *pasteDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.wWidth = msg.Width
+		m.wHeight = msg.Height
+		return m, nil
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, m.keyMap.Expand):
+			return m, tea.Sequence(
+				util.CmdHandler(dialogs.CloseDialogMsg{}),
+				util.CmdHandler(ExpandMsg{Path: m.attachment.FilePath}),
+			)
+		case key.Matches(msg, m.keyMap.Remove):
+			return m, tea.Sequence(
+				util.CmdHandler(dialogs.CloseDialogMsg{}),
+				util.CmdHandler(RemoveMsg{Path: m.attachment.FilePath}),
+			)
+		case key.Matches(msg, m.keyMap.Close):
+			return m, util.CmdHandler(dialogs.CloseDialogMsg{})
+		}
+	}
+	vp, cmd := m.viewport.Update(msg)
+	m.viewport = vp
+	return m, cmd
+}
+
+func (m *pasteDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	baseStyle := t.S().Base
+
+	m.viewport.SetHeight(min(m.viewport.TotalLineCount(), max(m.wHeight-14, 5)))
+
+	content := baseStyle.Width(width).Render(
+		lipgloss.JoinVertical(
+			lipgloss.Left,
+			core.Title("Pasted Text", width),
+			"",
+			t.S().Muted.Render(Label(m.attachment.Content)),
+			"",
+			m.viewport.View(),
+			"",
+			m.help.View(m.keyMap),
+		),
+	)
+
+	return baseStyle.
+		Padding(1, 2).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus).
+		Render(content)
+}
+
+func (m *pasteDialogCmp) Position() (int, int) {
+	row := m.wHeight / 2
+	row -= lipgloss.Height(m.View()) / 2
+	col := m.wWidth / 2
+	col -= (width + 6) / 2
+	return row, col
+}
+
+func (m *pasteDialogCmp) ID() dialogs.DialogID {
+	return PasteDialogID
+}
+
+// Label describes pasted text by its lines and tokens, which are counted
+// apart from the ones of the prompt.
+func Label(content []byte) string {
+	lines := strings.Count(strings.TrimRight(string(content), "\n"), "\n") + 1
+	return fmt.Sprintf("%d lines, ~%s tokens", lines, util.FormatTokens(util.EstimateTokens(content)))
+}
@@ -3,6 +3,7 @@ package permissions
 import (
 	"encoding/json"
 	"fmt"
+	"slices"
 	"strings"
 
 	"charm.land/bubbles/v2/help"
@@ -13,6 +14,7 @@ import (
 	"github.com/charmbracelet/crush/internal/agent/tools"
 	"github.com/charmbracelet/crush/internal/fsext"
 	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/pubsub"
 	"github.com/charmbracelet/crush/internal/tui/components/core"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
 	"github.com/charmbracelet/crush/internal/tui/styles"
@@ -67,6 +69,10 @@ type permissionDialogCmp struct {
 
 	finalDialogHeight int
 
+	// decided is set once the user answered the request, so the dialog
+	// only closes itself for requests answered elsewhere.
+	decided bool
+
 	keyMap KeyMap
 }
 
@@ -75,6 +81,9 @@ func NewPermissionDialogCmp(permission permission.PermissionRequest, opts *Optio
 		opts = &Options{}
 	}
 
+	keyMap := DefaultKeyMap()
+	keyMap.AllowSession.SetEnabled(!permission.Sensitive)
+
 	// Create viewport for content
 	contentViewport := viewport.New()
 	return &permissionDialogCmp{
@@ -82,7 +91,7 @@ func NewPermissionDialogCmp(permission permission.PermissionRequest, opts *Optio
 		selectedOption:  0, // Default to "Allow"
 		permission:      permission,
 		diffSplitMode:   opts.isSplitMode(),
-		keyMap:          DefaultKeyMap(),
+		keyMap:          keyMap,
 		contentDirty:    true, // Mark as dirty initially
 	}
 }
@@ -92,7 +101,11 @@ func (p *permissionDialogCmp) Init() tea.Cmd {
 }
 
 func (p *permissionDialogCmp) supportsDiffView() bool {
-	return p.permission.ToolName == tools.EditToolName || p.permission.ToolName == tools.WriteToolName || p.permission.ToolName == tools.MultiEditToolName
+	switch p.permission.ToolName {
+	case tools.EditToolName, tools.WriteToolName, tools.MultiEditToolName, tools.RenameToolName, tools.CodeActionToolName:
+		return true
+	}
+	return false
 }
 
 func (p *permissionDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
@@ -109,22 +122,31 @@ func (p *permissionDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		switch {
 		case key.Matches(msg, p.keyMap.Right) || key.Matches(msg, p.keyMap.Tab):
 			p.selectedOption = (p.selectedOption + 1) % 3
+			if p.permission.Sensitive && p.selectedOption == 1 {
+				p.selectedOption = 2
+			}
 			return p, nil
 		case key.Matches(msg, p.keyMap.Left):
 			p.selectedOption = (p.selectedOption + 2) % 3
+			if p.permission.Sensitive && p.selectedOption == 1 {
+				p.selectedOption = 0
+			}
 		case key.Matches(msg, p.keyMap.Select):
 			return p, p.selectCurrentOption()
 		case key.Matches(msg, p.keyMap.Allow):
+			p.decided = true
 			return p, tea.Batch(
 				util.CmdHandler(dialogs.CloseDialogMsg{}),
 				util.CmdHandler(PermissionResponseMsg{Action: PermissionAllow, Permission: p.permission}),
 			)
 		case key.Matches(msg, p.keyMap.AllowSession):
+			p.decided = true
 			return p, tea.Batch(
 				util.CmdHandler(dialogs.CloseDialogMsg{}),
 				util.CmdHandler(PermissionResponseMsg{Action: PermissionAllowForSession, Permission: p.permission}),
 			)
 		case key.Matches(msg, p.keyMap.Deny):
+			p.decided = true
 			return p, tea.Batch(
 				util.CmdHandler(dialogs.CloseDialogMsg{}),
 				util.CmdHandler(PermissionResponseMsg{Action: PermissionDeny, Permission: p.permission}),
@@ -166,6 +188,13 @@ func (p *permissionDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 			p.contentViewPort = viewPort
 			cmds = append(cmds, cmd)
 		}
+	case pubsub.Event[permission.PermissionNotification]:
+		// The request was answered elsewhere, like from the chat bridge.
+		n := msg.Payload
+		if !p.decided && n.ToolCallID == p.permission.ToolCallID && (n.Granted || n.Denied) {
+			p.decided = true
+			return p, util.CmdHandler(dialogs.CloseDialogMsg{})
+		}
 	case tea.MouseWheelMsg:
 		if p.supportsDiffView() && p.isMouseOverDialog(msg.Mouse().X, msg.Mouse().Y) {
 			switch msg.Button {
@@ -222,6 +251,7 @@ func (p *permissionDialogCmp) isMouseOverDialog(x, y int) bool {
 func (p *permissionDialogCmp) selectCurrentOption() tea.Cmd {
 	var action PermissionAction
 
+	p.decided = true
 	switch p.selectedOption {
 	case 0:
 		action = PermissionAllow
@@ -258,6 +288,10 @@ func (p *permissionDialogCmp) renderButtons() string {
 			Selected:       p.selectedOption == 2,
 		},
 	}
+	// Sensitive requests can only be granted one at a time.
+	if p.permission.Sensitive {
+		buttons = slices.Delete(buttons, 1, 2)
+	}
 
 	content := core.SelectableButtons(buttons, "  ")
 	if lipgloss.Width(content) > p.width-4 {
@@ -300,7 +334,7 @@ func (p *permissionDialogCmp) renderHeader() string {
 
 	// Add tool-specific header information
 	switch p.permission.ToolName {
-	case tools.BashToolName:
+	case tools.BashToolName, tools.InteractiveToolName:
 		params := p.permission.Params.(tools.BashPermissionsParams)
 		descKey := t.S().Muted.Render("Desc")
 		descValue := t.S().Text.
@@ -381,6 +415,19 @@ func (p *permissionDialogCmp) renderHeader() string {
 			),
 			baseStyle.Render(strings.Repeat(" ", p.width)),
 		)
+	case tools.RenameToolName, tools.CodeActionToolName:
+		descKey := t.S().Muted.Render("Desc")
+		descValue := t.S().Text.
+			Width(p.width - lipgloss.Width(descKey)).
+			Render(fmt.Sprintf(" %s", p.permission.Description))
+		headerParts = append(headerParts,
+			lipgloss.JoinHorizontal(
+				lipgloss.Left,
+				descKey,
+				descValue,
+			),
+			baseStyle.Render(strings.Repeat(" ", p.width)),
+		)
 	case tools.FetchToolName:
 		headerParts = append(headerParts,
 			baseStyle.Render(strings.Repeat(" ", p.width)),
@@ -433,7 +480,7 @@ func (p *permissionDialogCmp) getOrGenerateContent() string {
 	// Generate new content
 	var content string
 	switch p.permission.ToolName {
-	case tools.BashToolName:
+	case tools.BashToolName, tools.InteractiveToolName:
 		content = p.generateBashContent()
 	case tools.DownloadToolName:
 		content = p.generateDownloadContent()
@@ -443,6 +490,8 @@ func (p *permissionDialogCmp) getOrGenerateContent() string {
 		content = p.generateWriteContent()
 	case tools.MultiEditToolName:
 		content = p.generateMultiEditContent()
+	case tools.RenameToolName, tools.CodeActionToolName:
+		content = p.generateWorkspaceEditContent()
 	case tools.FetchToolName:
 		content = p.generateFetchContent()
 	case tools.AgenticFetchToolName:
@@ -588,6 +637,25 @@ func (p *permissionDialogCmp) generateMultiEditContent() string {
 	return ""
 }
 
+func (p *permissionDialogCmp) generateWorkspaceEditContent() string {
+	if pr, ok := p.permission.Params.(tools.WorkspaceEditPermissionsParams); ok {
+		formatter := core.DiffFormatter().
+			Before(fsext.PrettyPath(pr.FilePath), pr.OldContent).
+			After(fsext.PrettyPath(pr.FilePath), pr.NewContent).
+			Height(p.contentViewPort.Height()).
+			Width(p.contentViewPort.Width()).
+			XOffset(p.diffXOffset).
+			YOffset(p.diffYOffset)
+		if p.useDiffSplitMode() {
+			formatter = formatter.Split()
+		} else {
+			formatter = formatter.Unified()
+		}
+		return formatter.String()
+	}
+	return ""
+}
+
 func (p *permissionDialogCmp) generateFetchContent() string {
 	t := styles.CurrentTheme()
 	baseStyle := t.S().Base.Background(t.BgSubtle)
@@ -808,7 +876,7 @@ func (p *permissionDialogCmp) SetSize() tea.Cmd {
 	oldWidth, oldHeight := p.width, p.height
 
 	switch p.permission.ToolName {
-	case tools.BashToolName:
+	case tools.BashToolName, tools.InteractiveToolName:
 		p.width = int(float64(p.wWidth) * 0.8)
 		p.height = int(float64(p.wHeight) * 0.3)
 	case tools.DownloadToolName:
@@ -820,7 +888,7 @@ func (p *permissionDialogCmp) SetSize() tea.Cmd {
 	case tools.WriteToolName:
 		p.width = int(float64(p.wWidth) * 0.8)
 		p.height = int(float64(p.wHeight) * 0.8)
-	case tools.MultiEditToolName:
+	case tools.MultiEditToolName, tools.RenameToolName, tools.CodeActionToolName:
 		p.width = int(float64(p.wWidth) * 0.8)
 		p.height = int(float64(p.wHeight) * 0.8)
 	case tools.FetchToolName:
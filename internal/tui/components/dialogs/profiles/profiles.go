@@ -0,0 +1,236 @@
+// Package profiles provides a dialog to switch the config profile Crush runs
+// with.
+package profiles
+
+import (
+	"cmp"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/tui/components/core"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/exp/list"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+)
+
+const (
+	ProfilesDialogID dialogs.DialogID = "profiles"
+
+	// noProfile is the ID of the item running without a profile, which
+	// can't be the name of one.
+	noProfile = ":none"
+
+	defaultWidth int = 50
+)
+
+type listModel = list.FilterableList[list.CompletionItem[string]]
+
+type ProfilesDialog interface {
+	dialogs.DialogModel
+}
+
+type profilesDialogCmp struct {
+	width   int
+	wWidth  int // Width of the terminal window
+	wHeight int // Height of the terminal window
+
+	current     string
+	profileList listModel
+	keyMap      ProfilesDialogKeyMap
+	help        help.Model
+}
+
+// ProfileSelectedMsg is sent when a config profile is picked in the dialog.
+// An empty profile is running without one.
+type ProfileSelectedMsg struct {
+	Profile string
+}
+
+type ProfilesDialogKeyMap struct {
+	Next     key.Binding
+	Previous key.Binding
+	Select   key.Binding
+	Close    key.Binding
+}
+
+func DefaultProfilesDialogKeyMap() ProfilesDialogKeyMap {
+	return ProfilesDialogKeyMap{
+		Next: key.NewBinding(
+			key.WithKeys("down", "j", "ctrl+n"),
+			key.WithHelp("↓/j/ctrl+n", "next"),
+		),
+		Previous: key.NewBinding(
+			key.WithKeys("up", "k", "ctrl+p"),
+			key.WithHelp("↑/k/ctrl+p", "previous"),
+		),
+		Select: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "select"),
+		),
+		Close: key.NewBinding(
+			key.WithKeys("esc", "ctrl+c"),
+			key.WithHelp("esc/ctrl+c", "close"),
+		),
+	}
+}
+
+func (k ProfilesDialogKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Select, k.Close}
+}
+
+func (k ProfilesDialogKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Next, k.Previous},
+		{k.Select, k.Close},
+	}
+}
+
+// NewProfilesDialog creates the dialog, with the current profile marked.
+func NewProfilesDialog(current string) ProfilesDialog {
+	keyMap := DefaultProfilesDialogKeyMap()
+	listKeyMap := list.DefaultKeyMap()
+	listKeyMap.Down.SetEnabled(false)
+	listKeyMap.Up.SetEnabled(false)
+	listKeyMap.DownOneItem = keyMap.Next
+	listKeyMap.UpOneItem = keyMap.Previous
+
+	t := styles.CurrentTheme()
+	inputStyle := t.S().Base.PaddingLeft(1).PaddingBottom(1)
+	profileList := list.NewFilterableList(
+		[]list.CompletionItem[string]{},
+		list.WithFilterInputStyle(inputStyle),
+		list.WithFilterListOptions(
+			list.WithKeyMap(listKeyMap),
+			list.WithWrapNavigation(),
+			list.WithResizeByList(),
+		),
+	)
+	help := help.New()
+	help.Styles = t.S().Help
+
+	return &profilesDialogCmp{
+		current:     current,
+		profileList: profileList,
+		width:       defaultWidth,
+		keyMap:      keyMap,
+		help:        help,
+	}
+}
+
+func (l *profilesDialogCmp) Init() tea.Cmd {
+	names := append([]string{""}, config.Profiles()...)
+	items := make([]list.CompletionItem[string], 0, len(names))
+	for _, name := range names {
+		title := name
+		if name == "" {
+			title = "No Profile"
+		}
+		opts := []list.CompletionItemOption{
+			list.WithCompletionID(cmp.Or(name, noProfile)),
+		}
+		if name == l.current {
+			opts = append(opts, list.WithCompletionShortcut("current"))
+		}
+		items = append(items, list.NewCompletionItem(title, name, opts...))
+	}
+	return tea.Sequence(
+		l.profileList.SetItems(items),
+		l.profileList.SetSelected(cmp.Or(l.current, noProfile)),
+	)
+}
+
+func (l *profilesDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		l.wWidth = msg.Width
+		l.wHeight = msg.Height
+		return l, l.profileList.SetSize(l.listWidth(), l.listHeight())
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, l.keyMap.Select):
+			selectedItem := l.profileList.SelectedItem()
+			if selectedItem == nil {
+				return l, nil // No item selected, do nothing
+			}
+			profile := (*selectedItem).Value()
+			return l, tea.Sequence(
+				util.CmdHandler(dialogs.CloseDialogMsg{}),
+				util.CmdHandler(ProfileSelectedMsg{Profile: profile}),
+			)
+		case key.Matches(msg, l.keyMap.Close):
+			return l, util.CmdHandler(dialogs.CloseDialogMsg{})
+		default:
+			u, cmd := l.profileList.Update(msg)
+			l.profileList = u.(listModel)
+			return l, cmd
+		}
+	}
+	return l, nil
+}
+
+func (l *profilesDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	listView := l.profileList
+
+	header := t.S().Base.Padding(0, 1, 1, 1).Render(core.Title("Switch Profile", l.width-4))
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		header,
+		listView.View(),
+		"",
+		t.S().Base.Width(l.width-2).PaddingLeft(1).AlignHorizontal(lipgloss.Left).Render(l.help.View(l.keyMap)),
+	)
+	return l.style().Render(content)
+}
+
+func (l *profilesDialogCmp) Cursor() *tea.Cursor {
+	if cursor, ok := l.profileList.(util.Cursor); ok {
+		cursor := cursor.Cursor()
+		if cursor != nil {
+			cursor = l.moveCursor(cursor)
+		}
+		return cursor
+	}
+	return nil
+}
+
+func (l *profilesDialogCmp) listWidth() int {
+	return l.width - 2 // 4 for padding
+}
+
+func (l *profilesDialogCmp) listHeight() int {
+	listHeight := len(l.profileList.Items()) + 2 + 4 // height based on items + 2 for the input + 4 for the sections
+	return min(listHeight, l.wHeight/2)
+}
+
+func (l *profilesDialogCmp) moveCursor(cursor *tea.Cursor) *tea.Cursor {
+	row, col := l.Position()
+	offset := row + 3
+	cursor.Y += offset
+	cursor.X = cursor.X + col + 2
+	return cursor
+}
+
+func (l *profilesDialogCmp) style() lipgloss.Style {
+	t := styles.CurrentTheme()
+	return t.S().Base.
+		Width(l.width).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus)
+}
+
+func (l *profilesDialogCmp) Position() (int, int) {
+	row := l.wHeight/4 - 2 // just a bit above the center
+	col := l.wWidth / 2
+	col -= l.width / 2
+	return row, col
+}
+
+func (l *profilesDialogCmp) ID() dialogs.DialogID {
+	return ProfilesDialogID
+}
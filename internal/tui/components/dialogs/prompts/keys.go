@@ -0,0 +1,69 @@
+package prompts
+
+import (
+	"charm.land/bubbles/v2/key"
+)
+
+// KeyMap defines the keyboard bindings for the prompt history dialog.
+type KeyMap struct {
+	Select,
+	Run,
+	Next,
+	Previous,
+	Close key.Binding
+}
+
+func DefaultKeyMap() KeyMap {
+	return KeyMap{
+		Select: key.NewBinding(
+			key.WithKeys("enter", "tab"),
+			key.WithHelp("enter", "edit"),
+		),
+		Run: key.NewBinding(
+			key.WithKeys("alt+enter", "ctrl+enter"),
+			key.WithHelp("alt+enter", "run"),
+		),
+		// Like in shells, ctrl+r goes on to older matches.
+		Next: key.NewBinding(
+			key.WithKeys("down", "ctrl+n", "ctrl+r"),
+			key.WithHelp("↓", "older"),
+		),
+		Previous: key.NewBinding(
+			key.WithKeys("up", "ctrl+p"),
+			key.WithHelp("↑", "newer"),
+		),
+		Close: key.NewBinding(
+			key.WithKeys("esc", "alt+esc"),
+			key.WithHelp("esc", "exit"),
+		),
+	}
+}
+
+// KeyBindings implements layout.KeyMapProvider
+func (k KeyMap) KeyBindings() []key.Binding {
+	return []key.Binding{
+		k.Select,
+		k.Run,
+		k.Next,
+		k.Previous,
+		k.Close,
+	}
+}
+
+// FullHelp implements help.KeyMap.
+func (k KeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{k.KeyBindings()}
+}
+
+// ShortHelp implements help.KeyMap.
+func (k KeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{
+		key.NewBinding(
+			key.WithKeys("down", "up"),
+			key.WithHelp("↑↓", "choose"),
+		),
+		k.Select,
+		k.Run,
+		k.Close,
+	}
+}
@@ -0,0 +1,176 @@
+// Package prompts provides the dialog searching the history of the prompts
+// sent, to edit one again or run it in the current session.
+package prompts
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/prompthistory"
+	"github.com/charmbracelet/crush/internal/tui/components/core"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/exp/list"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+)
+
+const PromptsDialogID dialogs.DialogID = "prompts"
+
+// EditMsg puts a prompt of the history in the editor.
+type EditMsg struct {
+	Prompt string
+}
+
+// RunMsg sends a prompt of the history again, with the attachments of the
+// editor, in the current session.
+type RunMsg struct {
+	Prompt string
+}
+
+// PromptsDialog searches the history of prompts.
+type PromptsDialog interface {
+	dialogs.DialogModel
+}
+
+type PromptsList = list.FilterableList[list.CompletionItem[prompthistory.Entry]]
+
+type promptsDialogCmp struct {
+	wWidth  int
+	wHeight int
+	width   int
+
+	keyMap      KeyMap
+	promptsList PromptsList
+	help        help.Model
+}
+
+// NewPromptsDialog creates the dialog searching the prompts, the newest
+// first. The ones sent in other projects than project are marked with it.
+func NewPromptsDialog(entries []prompthistory.Entry, project string) PromptsDialog {
+	t := styles.CurrentTheme()
+	listKeyMap := list.DefaultKeyMap()
+	keyMap := DefaultKeyMap()
+	listKeyMap.Down.SetEnabled(false)
+	listKeyMap.Up.SetEnabled(false)
+	listKeyMap.DownOneItem = keyMap.Next
+	listKeyMap.UpOneItem = keyMap.Previous
+
+	items := make([]list.CompletionItem[prompthistory.Entry], len(entries))
+	for i, entry := range entries {
+		title := strings.Join(strings.Fields(entry.Prompt), " ")
+		if entry.Project != "" && entry.Project != project {
+			title += " · " + filepath.Base(entry.Project)
+		}
+		items[i] = list.NewCompletionItem(title, entry, list.WithCompletionID(strconv.Itoa(i)))
+	}
+
+	inputStyle := t.S().Base.PaddingLeft(1).PaddingBottom(1)
+	promptsList := list.NewFilterableList(
+		items,
+		list.WithFilterPlaceholder("Search prompts"),
+		list.WithFilterInputStyle(inputStyle),
+		list.WithFilterListOptions(
+			list.WithKeyMap(listKeyMap),
+			list.WithWrapNavigation(),
+		),
+	)
+	help := help.New()
+	help.Styles = t.S().Help
+	return &promptsDialogCmp{
+		keyMap:      keyMap,
+		promptsList: promptsList,
+		help:        help,
+	}
+}
+
+func (p *promptsDialogCmp) Init() tea.Cmd {
+	return tea.Sequence(p.promptsList.Init(), p.promptsList.Focus())
+}
+
+func (p *promptsDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		p.wWidth = msg.Width
+		p.wHeight = msg.Height
+		p.width = min(120, p.wWidth-8)
+		p.promptsList.SetInputWidth(p.listWidth() - 2)
+		return p, p.promptsList.SetSize(p.listWidth(), p.listHeight())
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, p.keyMap.Select), key.Matches(msg, p.keyMap.Run):
+			selectedItem := p.promptsList.SelectedItem()
+			if selectedItem == nil {
+				return p, nil
+			}
+			prompt := (*selectedItem).Value().Prompt
+			var next tea.Msg = EditMsg{Prompt: prompt}
+			if key.Matches(msg, p.keyMap.Run) {
+				next = RunMsg{Prompt: prompt}
+			}
+			return p, tea.Sequence(
+				util.CmdHandler(dialogs.CloseDialogMsg{}),
+				util.CmdHandler(next),
+			)
+		case key.Matches(msg, p.keyMap.Close):
+			return p, util.CmdHandler(dialogs.CloseDialogMsg{})
+		default:
+			u, cmd := p.promptsList.Update(msg)
+			p.promptsList = u.(PromptsList)
+			return p, cmd
+		}
+	}
+	return p, nil
+}
+
+func (p *promptsDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		t.S().Base.Padding(0, 1, 1, 1).Render(core.Title("Prompt History", p.width-4)),
+		p.promptsList.View(),
+		"",
+		t.S().Base.Width(p.width-2).PaddingLeft(1).AlignHorizontal(lipgloss.Left).Render(p.help.View(p.keyMap)),
+	)
+	return t.S().Base.
+		Width(p.width).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus).
+		Render(content)
+}
+
+func (p *promptsDialogCmp) Cursor() *tea.Cursor {
+	if cursor, ok := p.promptsList.(util.Cursor); ok {
+		cursor := cursor.Cursor()
+		if cursor != nil {
+			row, col := p.Position()
+			cursor.Y += row + 3 // Border + title
+			cursor.X += col + 2
+		}
+		return cursor
+	}
+	return nil
+}
+
+func (p *promptsDialogCmp) listHeight() int {
+	return p.wHeight/2 - 6 // 5 for the border, title and help
+}
+
+func (p *promptsDialogCmp) listWidth() int {
+	return p.width - 2 // 2 for the border
+}
+
+func (p *promptsDialogCmp) Position() (int, int) {
+	row := p.wHeight/4 - 2 // just a bit above the center
+	col := p.wWidth / 2
+	col -= p.width / 2
+	return row, col
+}
+
+func (p *promptsDialogCmp) ID() dialogs.DialogID {
+	return PromptsDialogID
+}
@@ -0,0 +1,144 @@
+// Package remember provides the dialog asking whether an instruction sent
+// with /memory is remembered for the project or for all of them.
+package remember
+
+import (
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/tui/components/core"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+	"github.com/charmbracelet/x/ansi"
+)
+
+const (
+	RememberDialogID dialogs.DialogID = "remember"
+
+	width = 60
+)
+
+// RememberMsg asks to remember an instruction in the CRUSH.md of the
+// project, or in the global one when Global is set.
+type RememberMsg struct {
+	Instruction string
+	Global      bool
+}
+
+// RememberDialog asks where to remember an instruction.
+type RememberDialog interface {
+	dialogs.DialogModel
+}
+
+type rememberDialogCmp struct {
+	wWidth  int
+	wHeight int
+
+	instruction string
+	global      bool
+	keyMap      KeyMap
+	help        help.Model
+}
+
+// NewRememberDialog creates the dialog asking where to remember an
+// instruction.
+func NewRememberDialog(instruction string) RememberDialog {
+	t := styles.CurrentTheme()
+	help := help.New()
+	help.Styles = t.S().Help
+	return &rememberDialogCmp{
+		instruction: instruction,
+		keyMap:      DefaultKeyMap(),
+		help:        help,
+	}
+}
+
+func (m *rememberDialogCmp) Init() tea.Cmd {
+	return nil
+}
+
+func (m *rememberDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.wWidth = msg.Width
+		m.wHeight = msg.Height
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, m.keyMap.LeftRight, m.keyMap.Tab):
+			m.global = !m.global
+		case key.Matches(msg, m.keyMap.Enter):
+			return m, m.remember(m.global)
+		case key.Matches(msg, m.keyMap.Project):
+			return m, m.remember(false)
+		case key.Matches(msg, m.keyMap.Global):
+			return m, m.remember(true)
+		case key.Matches(msg, m.keyMap.Close):
+			return m, util.CmdHandler(dialogs.CloseDialogMsg{})
+		}
+	}
+	return m, nil
+}
+
+func (m *rememberDialogCmp) remember(global bool) tea.Cmd {
+	return tea.Sequence(
+		util.CmdHandler(dialogs.CloseDialogMsg{}),
+		util.CmdHandler(RememberMsg{Instruction: m.instruction, Global: global}),
+	)
+}
+
+func (m *rememberDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	baseStyle := t.S().Base
+
+	projectStyle := t.S().Text.Padding(0, 3)
+	globalStyle := projectStyle
+	if m.global {
+		globalStyle = globalStyle.Foreground(t.White).Background(t.Secondary)
+		projectStyle = projectStyle.Background(t.BgSubtle)
+	} else {
+		projectStyle = projectStyle.Foreground(t.White).Background(t.Secondary)
+		globalStyle = globalStyle.Background(t.BgSubtle)
+	}
+	buttons := lipgloss.JoinHorizontal(lipgloss.Center,
+		projectStyle.Render("Project"), "  ", globalStyle.Render("Global"),
+	)
+	scope := "In the CRUSH.md of this project."
+	if m.global {
+		scope = "In the global CRUSH.md, for all projects."
+	}
+
+	content := baseStyle.Width(width).Render(
+		lipgloss.JoinVertical(
+			lipgloss.Left,
+			core.Title("Remember", width),
+			"",
+			t.S().Text.Width(width).Render(ansi.Truncate(m.instruction, width*3, "…")),
+			"",
+			buttons,
+			"",
+			t.S().Subtle.Render(scope),
+			"",
+			m.help.View(m.keyMap),
+		),
+	)
+
+	return baseStyle.
+		Padding(1, 2).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus).
+		Render(content)
+}
+
+func (m *rememberDialogCmp) Position() (int, int) {
+	row := m.wHeight / 2
+	row -= lipgloss.Height(m.View()) / 2
+	col := m.wWidth / 2
+	col -= (width + 6) / 2
+	return row, col
+}
+
+func (m *rememberDialogCmp) ID() dialogs.DialogID {
+	return RememberDialogID
+}
@@ -0,0 +1,71 @@
+package runqueue
+
+import (
+	"charm.land/bubbles/v2/key"
+)
+
+// KeyMap defines the keyboard bindings for the run queue dialog.
+type KeyMap struct {
+	Next,
+	Previous,
+	MoveUp,
+	MoveDown,
+	Cancel,
+	Close key.Binding
+}
+
+func DefaultKeyMap() KeyMap {
+	return KeyMap{
+		Next: key.NewBinding(
+			key.WithKeys("down", "j", "ctrl+n"),
+			key.WithHelp("↓/j", "next"),
+		),
+		Previous: key.NewBinding(
+			key.WithKeys("up", "k", "ctrl+p"),
+			key.WithHelp("↑/k", "previous"),
+		),
+		MoveUp: key.NewBinding(
+			key.WithKeys("shift+up", "K"),
+			key.WithHelp("K", "run sooner"),
+		),
+		MoveDown: key.NewBinding(
+			key.WithKeys("shift+down", "J"),
+			key.WithHelp("J", "run later"),
+		),
+		Cancel: key.NewBinding(
+			key.WithKeys("x", "delete"),
+			key.WithHelp("x", "cancel run"),
+		),
+		Close: key.NewBinding(
+			key.WithKeys("esc", "alt+esc"),
+			key.WithHelp("esc", "close"),
+		),
+	}
+}
+
+// KeyBindings implements layout.KeyMapProvider
+func (k KeyMap) KeyBindings() []key.Binding {
+	return []key.Binding{
+		k.Next,
+		k.Previous,
+		k.MoveUp,
+		k.MoveDown,
+		k.Cancel,
+		k.Close,
+	}
+}
+
+// FullHelp implements help.KeyMap.
+func (k KeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{k.KeyBindings()}
+}
+
+// ShortHelp implements help.KeyMap.
+func (k KeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{
+		k.MoveUp,
+		k.MoveDown,
+		k.Cancel,
+		k.Close,
+	}
+}
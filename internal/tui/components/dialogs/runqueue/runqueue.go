@@ -0,0 +1,192 @@
+// Package runqueue provides a dialog showing the runs of the agent in
+// progress and waiting in the run queue, to reorder or cancel waiting runs.
+package runqueue
+
+import (
+	"cmp"
+	"fmt"
+	"strings"
+	"time"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+
+	"github.com/charmbracelet/crush/internal/agent"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/tui/components/core"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+	"github.com/charmbracelet/x/ansi"
+)
+
+const (
+	RunQueueDialogID dialogs.DialogID = "run_queue"
+
+	width = 76
+)
+
+// MoveRunMsg asks to move a waiting run by Delta places, towards the front
+// of the queue when negative.
+type MoveRunMsg struct {
+	ID    int
+	Delta int
+}
+
+// CancelRunMsg asks to cancel a waiting run.
+type CancelRunMsg struct {
+	ID int
+}
+
+// Queue is what the dialog shows the runs of, like agent.Coordinator.
+type Queue interface {
+	RunQueue() (running, waiting []agent.QueuedRun)
+}
+
+// RunQueueDialog shows the run queue.
+type RunQueueDialog interface {
+	dialogs.DialogModel
+}
+
+type runQueueDialogCmp struct {
+	wWidth  int
+	wHeight int
+
+	queue  Queue
+	cursor int // Index of the selected waiting run.
+	keyMap KeyMap
+	help   help.Model
+}
+
+// NewRunQueueDialog creates the dialog of the run queue.
+func NewRunQueueDialog(queue Queue) RunQueueDialog {
+	t := styles.CurrentTheme()
+	help := help.New()
+	help.Styles = t.S().Help
+	return &runQueueDialogCmp{
+		queue:  queue,
+		keyMap: DefaultKeyMap(),
+		help:   help,
+	}
+}
+
+func (r *runQueueDialogCmp) Init() tea.Cmd {
+	return nil
+}
+
+func (r *runQueueDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		r.wWidth = msg.Width
+		r.wHeight = msg.Height
+	case tea.KeyPressMsg:
+		if key.Matches(msg, r.keyMap.Close) {
+			return r, util.CmdHandler(dialogs.CloseDialogMsg{})
+		}
+		_, waiting := r.queue.RunQueue()
+		if len(waiting) == 0 {
+			return r, nil
+		}
+		r.cursor = min(r.cursor, len(waiting)-1)
+		run := waiting[r.cursor]
+		switch {
+		case key.Matches(msg, r.keyMap.Next):
+			r.cursor = (r.cursor + 1) % len(waiting)
+		case key.Matches(msg, r.keyMap.Previous):
+			r.cursor = (r.cursor - 1 + len(waiting)) % len(waiting)
+		case key.Matches(msg, r.keyMap.MoveUp):
+			if r.cursor > 0 {
+				r.cursor--
+				return r, util.CmdHandler(MoveRunMsg{ID: run.ID, Delta: -1})
+			}
+		case key.Matches(msg, r.keyMap.MoveDown):
+			if r.cursor < len(waiting)-1 {
+				r.cursor++
+				return r, util.CmdHandler(MoveRunMsg{ID: run.ID, Delta: 1})
+			}
+		case key.Matches(msg, r.keyMap.Cancel):
+			return r, util.CmdHandler(CancelRunMsg{ID: run.ID})
+		}
+	}
+	return r, nil
+}
+
+// runRow renders a run, with its position in the queue when waiting.
+func runRow(t *styles.Theme, position string, run agent.QueuedRun, since time.Duration) string {
+	prompt, _, _ := strings.Cut(run.Prompt, "\n")
+	return lipgloss.JoinHorizontal(
+		lipgloss.Top,
+		t.S().Muted.Width(4).Render(position),
+		t.S().Text.Bold(true).Width(24).Render(ansi.Truncate(cmp.Or(run.Session, "New Session"), 23, "…")),
+		t.S().Muted.Width(8).Render(since.Truncate(time.Second).String()),
+		t.S().Subtle.Render(ansi.Truncate(prompt, width-38, "…")),
+	)
+}
+
+func (r *runQueueDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	baseStyle := t.S().Base
+	running, waiting := r.queue.RunQueue()
+	now := time.Now()
+
+	var runningRows []string
+	for _, run := range running {
+		row := runRow(t, t.S().Success.Render(styles.DotIcon), run, now.Sub(run.Since))
+		runningRows = append(runningRows, baseStyle.Width(width).PaddingLeft(1).Render(row))
+	}
+	if len(runningRows) == 0 {
+		runningRows = append(runningRows, t.S().Subtle.Render("Nothing running."))
+	}
+
+	var waitingRows []string
+	for i, run := range waiting {
+		style := baseStyle.Width(width).PaddingLeft(1)
+		if i == min(r.cursor, len(waiting)-1) {
+			style = style.Background(t.BgSubtle)
+		}
+		waitingRows = append(waitingRows, style.Render(runRow(t, fmt.Sprintf("%d.", i+1), run, now.Sub(run.Since))))
+	}
+	if len(waitingRows) == 0 {
+		waitingRows = append(waitingRows, t.S().Subtle.Render("No runs waiting."))
+	}
+
+	limit := "no limit"
+	if n := config.Get().Options.MaxConcurrentRuns; n > 0 {
+		limit = fmt.Sprintf("%d at once", n)
+	}
+
+	content := baseStyle.Width(width).Render(
+		lipgloss.JoinVertical(
+			lipgloss.Left,
+			core.Title("Run Queue", width),
+			"",
+			t.S().Text.Bold(true).Render("Running")+t.S().Muted.Render(" · "+limit),
+			lipgloss.JoinVertical(lipgloss.Left, runningRows...),
+			"",
+			t.S().Text.Bold(true).Render("Waiting"),
+			lipgloss.JoinVertical(lipgloss.Left, waitingRows...),
+			"",
+			r.help.View(r.keyMap),
+		),
+	)
+
+	return baseStyle.
+		Padding(1, 2).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus).
+		Render(content)
+}
+
+func (r *runQueueDialogCmp) Position() (int, int) {
+	row := r.wHeight / 2
+	row -= lipgloss.Height(r.View()) / 2
+	col := r.wWidth / 2
+	col -= (width + 6) / 2
+	return row, col
+}
+
+func (r *runQueueDialogCmp) ID() dialogs.DialogID {
+	return RunQueueDialogID
+}
@@ -0,0 +1,242 @@
+// Package shellprofile provides a dialog to pick the shell profile the bash
+// tool of the current session runs commands with.
+package shellprofile
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/tui/components/core"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/exp/list"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+)
+
+const (
+	ShellProfileDialogID dialogs.DialogID = "shell_profile"
+
+	defaultWidth int = 50
+)
+
+type listModel = list.FilterableList[list.CompletionItem[string]]
+
+type ShellProfileDialog interface {
+	dialogs.DialogModel
+}
+
+type shellProfileDialogCmp struct {
+	width   int
+	wWidth  int // Width of the terminal window
+	wHeight int // Height of the terminal window
+
+	current     string
+	profileList listModel
+	keyMap      ShellProfileDialogKeyMap
+	help        help.Model
+}
+
+// ShellProfileSelectedMsg is sent when a shell profile is picked in the
+// dialog. An empty profile is the default one.
+type ShellProfileSelectedMsg struct {
+	Profile string
+}
+
+type ShellProfileDialogKeyMap struct {
+	Next     key.Binding
+	Previous key.Binding
+	Select   key.Binding
+	Close    key.Binding
+}
+
+func DefaultShellProfileDialogKeyMap() ShellProfileDialogKeyMap {
+	return ShellProfileDialogKeyMap{
+		Next: key.NewBinding(
+			key.WithKeys("down", "j", "ctrl+n"),
+			key.WithHelp("↓/j/ctrl+n", "next"),
+		),
+		Previous: key.NewBinding(
+			key.WithKeys("up", "k", "ctrl+p"),
+			key.WithHelp("↑/k/ctrl+p", "previous"),
+		),
+		Select: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "select"),
+		),
+		Close: key.NewBinding(
+			key.WithKeys("esc", "ctrl+c"),
+			key.WithHelp("esc/ctrl+c", "close"),
+		),
+	}
+}
+
+func (k ShellProfileDialogKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Select, k.Close}
+}
+
+func (k ShellProfileDialogKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Next, k.Previous},
+		{k.Select, k.Close},
+	}
+}
+
+// NewShellProfileDialog creates the dialog, with the current profile of the
+// session marked.
+func NewShellProfileDialog(current string) ShellProfileDialog {
+	keyMap := DefaultShellProfileDialogKeyMap()
+	listKeyMap := list.DefaultKeyMap()
+	listKeyMap.Down.SetEnabled(false)
+	listKeyMap.Up.SetEnabled(false)
+	listKeyMap.DownOneItem = keyMap.Next
+	listKeyMap.UpOneItem = keyMap.Previous
+
+	t := styles.CurrentTheme()
+	inputStyle := t.S().Base.PaddingLeft(1).PaddingBottom(1)
+	profileList := list.NewFilterableList(
+		[]list.CompletionItem[string]{},
+		list.WithFilterInputStyle(inputStyle),
+		list.WithFilterListOptions(
+			list.WithKeyMap(listKeyMap),
+			list.WithWrapNavigation(),
+			list.WithResizeByList(),
+		),
+	)
+	help := help.New()
+	help.Styles = t.S().Help
+
+	return &shellProfileDialogCmp{
+		current:     current,
+		profileList: profileList,
+		width:       defaultWidth,
+		keyMap:      keyMap,
+		help:        help,
+	}
+}
+
+func (l *shellProfileDialogCmp) Init() tea.Cmd {
+	profiles := config.Get().Options.ShellProfiles
+	names := []string{""}
+	for _, name := range slices.Sorted(maps.Keys(profiles)) {
+		if name != tools.DefaultShellProfile {
+			names = append(names, name)
+		}
+	}
+	items := make([]list.CompletionItem[string], 0, len(names))
+	for _, name := range names {
+		title := name
+		if name == "" {
+			title = "Default"
+		}
+		opts := []list.CompletionItemOption{
+			list.WithCompletionID(cmp.Or(name, tools.DefaultShellProfile)),
+		}
+		if name == l.current {
+			opts = append(opts, list.WithCompletionShortcut("current"))
+		}
+		items = append(items, list.NewCompletionItem(title, name, opts...))
+	}
+	return tea.Sequence(
+		l.profileList.SetItems(items),
+		l.profileList.SetSelected(cmp.Or(l.current, tools.DefaultShellProfile)),
+	)
+}
+
+func (l *shellProfileDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		l.wWidth = msg.Width
+		l.wHeight = msg.Height
+		return l, l.profileList.SetSize(l.listWidth(), l.listHeight())
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, l.keyMap.Select):
+			selectedItem := l.profileList.SelectedItem()
+			if selectedItem == nil {
+				return l, nil // No item selected, do nothing
+			}
+			profile := (*selectedItem).Value()
+			return l, tea.Sequence(
+				util.CmdHandler(dialogs.CloseDialogMsg{}),
+				util.CmdHandler(ShellProfileSelectedMsg{Profile: profile}),
+			)
+		case key.Matches(msg, l.keyMap.Close):
+			return l, util.CmdHandler(dialogs.CloseDialogMsg{})
+		default:
+			u, cmd := l.profileList.Update(msg)
+			l.profileList = u.(listModel)
+			return l, cmd
+		}
+	}
+	return l, nil
+}
+
+func (l *shellProfileDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	listView := l.profileList
+
+	header := t.S().Base.Padding(0, 1, 1, 1).Render(core.Title("Switch Shell Profile", l.width-4))
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		header,
+		listView.View(),
+		"",
+		t.S().Base.Width(l.width-2).PaddingLeft(1).AlignHorizontal(lipgloss.Left).Render(l.help.View(l.keyMap)),
+	)
+	return l.style().Render(content)
+}
+
+func (l *shellProfileDialogCmp) Cursor() *tea.Cursor {
+	if cursor, ok := l.profileList.(util.Cursor); ok {
+		cursor := cursor.Cursor()
+		if cursor != nil {
+			cursor = l.moveCursor(cursor)
+		}
+		return cursor
+	}
+	return nil
+}
+
+func (l *shellProfileDialogCmp) listWidth() int {
+	return l.width - 2 // 4 for padding
+}
+
+func (l *shellProfileDialogCmp) listHeight() int {
+	listHeight := len(l.profileList.Items()) + 2 + 4 // height based on items + 2 for the input + 4 for the sections
+	return min(listHeight, l.wHeight/2)
+}
+
+func (l *shellProfileDialogCmp) moveCursor(cursor *tea.Cursor) *tea.Cursor {
+	row, col := l.Position()
+	offset := row + 3
+	cursor.Y += offset
+	cursor.X = cursor.X + col + 2
+	return cursor
+}
+
+func (l *shellProfileDialogCmp) style() lipgloss.Style {
+	t := styles.CurrentTheme()
+	return t.S().Base.
+		Width(l.width).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus)
+}
+
+func (l *shellProfileDialogCmp) Position() (int, int) {
+	row := l.wHeight/4 - 2 // just a bit above the center
+	col := l.wWidth / 2
+	col -= l.width / 2
+	return row, col
+}
+
+func (l *shellProfileDialogCmp) ID() dialogs.DialogID {
+	return ShellProfileDialogID
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/fsext"
 	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/i18n"
 	"github.com/charmbracelet/crush/internal/tui/components/core"
 	"github.com/charmbracelet/crush/internal/tui/styles"
 )
@@ -49,12 +50,12 @@ func RenderFileList(fileSlice []SessionFile, opts RenderOptions) []string {
 		if sectionName == "" {
 			sectionName = "Modified Files"
 		}
-		section := t.S().Subtle.Render(sectionName)
+		section := t.S().Subtle.Render(i18n.T(sectionName))
 		fileList = append(fileList, section, "")
 	}
 
 	if len(fileSlice) == 0 {
-		fileList = append(fileList, t.S().Base.Foreground(t.Border).Render("None"))
+		fileList = append(fileList, t.S().Base.Foreground(t.Border).Render(i18n.T("None")))
 		return fileList
 	}
 
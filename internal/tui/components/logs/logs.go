@@ -0,0 +1,262 @@
+// Package logs implements the log pane, which tails Crush's own logs inside
+// the TUI with level filtering and search.
+package logs
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/log"
+	"github.com/charmbracelet/crush/internal/tui/components/core"
+	"github.com/charmbracelet/crush/internal/tui/components/core/layout"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// refreshInterval is how often the pane checks for new log entries while it
+// is open.
+const refreshInterval = 500 * time.Millisecond
+
+// RefreshMsg asks the pane to pick up new log entries.
+type RefreshMsg struct{}
+
+// CloseMsg is sent when the pane asks to be closed.
+type CloseMsg struct{}
+
+type LogPane interface {
+	util.Model
+	layout.Sizeable
+	core.KeyMapHelp
+	// Refresh reloads the log entries and keeps polling for new ones.
+	Refresh() tea.Cmd
+	// Height returns the height the pane wants for the given window height.
+	Height(windowHeight int) int
+}
+
+type logPaneCmp struct {
+	width, height int
+	keyMap        KeyMap
+
+	entries []log.Entry
+	seen    uint64
+
+	// level is the minimum level shown.
+	level slog.Level
+	// offset is the number of matching entries hidden below the view. Zero
+	// follows new entries as they come in.
+	offset    int
+	search    textinput.Model
+	searching bool
+}
+
+func New() LogPane {
+	t := styles.CurrentTheme()
+	search := textinput.New()
+	search.Prompt = "/"
+	search.Placeholder = "search"
+	search.SetVirtualCursor(true)
+	search.SetStyles(t.S().TextInput)
+	return &logPaneCmp{
+		keyMap: DefaultKeyMap(),
+		level:  slog.LevelDebug,
+		search: search,
+	}
+}
+
+func (l *logPaneCmp) Init() tea.Cmd {
+	return l.Refresh()
+}
+
+func (l *logPaneCmp) Refresh() tea.Cmd {
+	l.load()
+	return tea.Tick(refreshInterval, func(time.Time) tea.Msg {
+		return RefreshMsg{}
+	})
+}
+
+// load picks up new log entries, keeping the view in place when scrolled up.
+func (l *logPaneCmp) load() {
+	count := log.Count()
+	if count == l.seen {
+		return
+	}
+	before := len(l.filtered())
+	l.entries = log.Recent()
+	l.seen = count
+	if l.offset > 0 {
+		l.offset = max(0, l.offset+len(l.filtered())-before)
+	}
+}
+
+func (l *logPaneCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case RefreshMsg:
+		return l, l.Refresh()
+	case tea.KeyPressMsg:
+		if l.searching {
+			return l, l.updateSearch(msg)
+		}
+		switch {
+		case key.Matches(msg, l.keyMap.Close):
+			return l, util.CmdHandler(CloseMsg{})
+		case key.Matches(msg, l.keyMap.Search):
+			l.searching = true
+			return l, l.search.Focus()
+		case key.Matches(msg, l.keyMap.Level):
+			l.cycleLevel()
+		case key.Matches(msg, l.keyMap.Up):
+			l.scroll(1)
+		case key.Matches(msg, l.keyMap.Down):
+			l.scroll(-1)
+		case key.Matches(msg, l.keyMap.PageUp):
+			l.scroll(l.rows())
+		case key.Matches(msg, l.keyMap.PageDown):
+			l.scroll(-l.rows())
+		case key.Matches(msg, l.keyMap.Top):
+			l.scroll(len(l.entries))
+		case key.Matches(msg, l.keyMap.Bottom):
+			l.offset = 0
+		}
+	}
+	return l, nil
+}
+
+func (l *logPaneCmp) updateSearch(msg tea.KeyPressMsg) tea.Cmd {
+	switch {
+	case key.Matches(msg, l.keyMap.Accept):
+		l.searching = false
+		l.search.Blur()
+		return nil
+	case key.Matches(msg, l.keyMap.Close):
+		l.searching = false
+		l.search.Blur()
+		l.search.SetValue("")
+		l.offset = 0
+		return nil
+	}
+	var cmd tea.Cmd
+	l.search, cmd = l.search.Update(msg)
+	l.offset = 0
+	return cmd
+}
+
+// cycleLevel raises the minimum level shown, wrapping back to debug.
+func (l *logPaneCmp) cycleLevel() {
+	for i, level := range log.Levels {
+		if level == l.level {
+			l.level = log.Levels[(i+1)%len(log.Levels)]
+			break
+		}
+	}
+	l.offset = 0
+}
+
+func (l *logPaneCmp) scroll(n int) {
+	maxOffset := max(0, len(l.filtered())-l.rows())
+	l.offset = min(max(0, l.offset+n), maxOffset)
+}
+
+// filtered returns the entries that match the level and search filters.
+func (l *logPaneCmp) filtered() []log.Entry {
+	query := strings.TrimSpace(l.search.Value())
+	entries := make([]log.Entry, 0, len(l.entries))
+	for _, e := range l.entries {
+		if e.Level < l.level {
+			continue
+		}
+		if query != "" && !e.Matches(query) {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// rows is the number of entries that fit in the pane.
+func (l *logPaneCmp) rows() int {
+	return max(1, l.height-3) // 2 for the border and 1 for the header
+}
+
+func (l *logPaneCmp) View() string {
+	t := styles.CurrentTheme()
+	innerWidth := max(0, l.width-4) // 2 for the border and 2 for the padding
+
+	entries := l.filtered()
+	end := max(0, len(entries)-l.offset)
+	start := max(0, end-l.rows())
+
+	info := fmt.Sprintf("%s+ · %d/%d", strings.ToLower(l.level.String()), len(entries), len(l.entries))
+	if l.offset > 0 {
+		info += fmt.Sprintf(" · ↓%d", l.offset)
+	}
+	title := "Logs"
+	if l.searching || l.search.Value() != "" {
+		title += " " + l.search.View()
+	}
+	lines := []string{
+		core.SectionWithInfo(title, innerWidth, t.S().Subtle.Render(info)),
+	}
+	for _, e := range entries[start:end] {
+		lines = append(lines, ansi.Truncate(renderEntry(e), innerWidth, "…"))
+	}
+	if len(entries) == 0 {
+		lines = append(lines, t.S().Muted.Render("No log entries"))
+	}
+
+	return t.S().Base.
+		Width(l.width).
+		Height(l.height).
+		Padding(0, 1).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus).
+		Render(strings.Join(lines, "\n"))
+}
+
+func renderEntry(e log.Entry) string {
+	t := styles.CurrentTheme()
+	levelStyle := t.S().Muted
+	switch {
+	case e.Level >= slog.LevelError:
+		levelStyle = t.S().Error
+	case e.Level >= slog.LevelWarn:
+		levelStyle = t.S().Warning
+	case e.Level >= slog.LevelInfo:
+		levelStyle = t.S().Info
+	}
+	line := fmt.Sprintf(
+		"%s %s %s",
+		t.S().Subtle.Render(e.Time.Format("15:04:05")),
+		levelStyle.Render(fmt.Sprintf("%-5s", e.Level.String())),
+		t.S().Text.Render(e.Message),
+	)
+	if e.Attrs != "" {
+		line += " " + t.S().Muted.Render(e.Attrs)
+	}
+	return strings.ReplaceAll(line, "\n", " ")
+}
+
+func (l *logPaneCmp) SetSize(width, height int) tea.Cmd {
+	l.width, l.height = width, height
+	l.search.SetWidth(max(10, width/3))
+	return nil
+}
+
+func (l *logPaneCmp) GetSize() (int, int) {
+	return l.width, l.height
+}
+
+func (l *logPaneCmp) Height(windowHeight int) int {
+	return max(6, windowHeight/3)
+}
+
+func (l *logPaneCmp) Help() help.KeyMap {
+	return l.keyMap
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/charmbracelet/crush/internal/app"
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/i18n"
 	"github.com/charmbracelet/crush/internal/lsp"
 	"github.com/charmbracelet/crush/internal/tui/components/core"
 	"github.com/charmbracelet/crush/internal/tui/styles"
@@ -38,7 +39,7 @@ func RenderLSPList(lspClients *csync.Map[string, *lsp.Client], opts RenderOption
 
 	lspConfigs := config.Get().LSP.Sorted()
 	if len(lspConfigs) == 0 {
-		lspList = append(lspList, t.S().Base.Foreground(t.Border).Render("None"))
+		lspList = append(lspList, t.S().Base.Foreground(t.Border).Render(i18n.T("None")))
 		return lspList
 	}
 
@@ -128,6 +129,8 @@ func iconAndDescription(l config.LSP, t *styles.Theme, states map[string]app.LSP
 		return t.ItemErrorIcon, description
 	case lsp.StateDisabled:
 		return t.ItemOfflineIcon.Foreground(t.FgMuted), t.S().Subtle.Render("inactive")
+	case lsp.StateStopped:
+		return t.ItemOfflineIcon.Foreground(t.FgMuted), t.S().Subtle.Render("stopped")
 	default:
 		return t.ItemOfflineIcon, ""
 	}
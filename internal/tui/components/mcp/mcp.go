@@ -8,6 +8,7 @@ import (
 
 	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
 	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/i18n"
 	"github.com/charmbracelet/crush/internal/tui/components/core"
 	"github.com/charmbracelet/crush/internal/tui/styles"
 )
@@ -36,7 +37,7 @@ func RenderMCPList(opts RenderOptions) []string {
 
 	mcps := config.Get().MCP.Sorted()
 	if len(mcps) == 0 {
-		mcpList = append(mcpList, t.S().Base.Foreground(t.Border).Render("None"))
+		mcpList = append(mcpList, t.S().Base.Foreground(t.Border).Render(i18n.T("None")))
 		return mcpList
 	}
 
@@ -14,44 +14,87 @@ import (
 var _ chroma.Formatter = chromaFormatter{}
 
 // chromaFormatter is a custom formatter for Chroma that uses Lip Gloss for
-// foreground styling, while keeping a forced background color.
+// foreground styling, while keeping a forced background color. The changed
+// spans of the source get changedBgColor instead.
 type chromaFormatter struct {
-	bgColor color.Color
+	bgColor        color.Color
+	changedBgColor color.Color
+	changed        []span
 }
 
 // Format implements the chroma.Formatter interface.
 func (c chromaFormatter) Format(w io.Writer, style *chroma.Style, it chroma.Iterator) error {
+	offset := 0
 	for token := it(); token != chroma.EOF; token = it() {
+		start := offset
+		offset += len(token.Value)
 		value := strings.TrimRight(token.Value, "\n")
-		value = ansiext.Escape(value)
 
 		entry := style.Get(token.Type)
-		if entry.IsZero() {
-			if _, err := fmt.Fprint(w, value); err != nil {
+		for _, part := range c.split(value, start) {
+			text := ansiext.Escape(part.text)
+			if entry.IsZero() && !part.changed {
+				if _, err := fmt.Fprint(w, text); err != nil {
+					return err
+				}
+				continue
+			}
+
+			s := lipgloss.NewStyle().
+				Background(c.bgColor)
+			if part.changed {
+				s = s.Background(c.changedBgColor)
+			}
+
+			if entry.Bold == chroma.Yes {
+				s = s.Bold(true)
+			}
+			if entry.Underline == chroma.Yes {
+				s = s.Underline(true)
+			}
+			if entry.Italic == chroma.Yes {
+				s = s.Italic(true)
+			}
+			if entry.Colour.IsSet() {
+				s = s.Foreground(lipgloss.Color(entry.Colour.String()))
+			}
+
+			if _, err := fmt.Fprint(w, s.Render(text)); err != nil {
 				return err
 			}
-			continue
 		}
+	}
+	return nil
+}
 
-		s := lipgloss.NewStyle().
-			Background(c.bgColor)
+type tokenPart struct {
+	text    string
+	changed bool
+}
 
-		if entry.Bold == chroma.Yes {
-			s = s.Bold(true)
-		}
-		if entry.Underline == chroma.Yes {
-			s = s.Underline(true)
-		}
-		if entry.Italic == chroma.Yes {
-			s = s.Italic(true)
-		}
-		if entry.Colour.IsSet() {
-			s = s.Foreground(lipgloss.Color(entry.Colour.String()))
+// split splits the value of a token starting at offset start of the source
+// at the bounds of the changed spans.
+func (c chromaFormatter) split(value string, start int) []tokenPart {
+	if len(c.changed) == 0 {
+		return []tokenPart{{text: value}}
+	}
+	var parts []tokenPart
+	end := start + len(value)
+	pos := start
+	for _, s := range c.changed {
+		if s.end <= pos || s.start >= end {
+			continue
 		}
-
-		if _, err := fmt.Fprint(w, s.Render(value)); err != nil {
-			return err
+		if s.start > pos {
+			parts = append(parts, tokenPart{text: value[pos-start : s.start-start]})
+			pos = s.start
 		}
+		spanEnd := min(s.end, end)
+		parts = append(parts, tokenPart{text: value[pos-start : spanEnd-start], changed: true})
+		pos = spanEnd
 	}
-	return nil
+	if pos < end {
+		parts = append(parts, tokenPart{text: value[pos-start:]})
+	}
+	return parts
 }
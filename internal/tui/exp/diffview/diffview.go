@@ -38,6 +38,7 @@ type DiffView struct {
 	after           file
 	contextLines    int
 	lineNumbers     bool
+	wordDiff        bool
 	height          int
 	width           int
 	xOffset         int
@@ -123,6 +124,13 @@ func (dv *DiffView) ContextLines(contextLines int) *DiffView {
 	return dv
 }
 
+// WordDiff sets whether to highlight the words that changed within the
+// changed lines.
+func (dv *DiffView) WordDiff(wordDiff bool) *DiffView {
+	dv.wordDiff = wordDiff
+	return dv
+}
+
 // Style sets the style for the DiffView.
 func (dv *DiffView) Style(style Style) *DiffView {
 	dv.style = style
@@ -402,9 +410,9 @@ func (dv *DiffView) renderUnified() string {
 	printedLines := -dv.yOffset
 	shouldWrite := func() bool { return printedLines >= 0 }
 
-	getContent := func(in string, ls LineStyle) (content string, leadingEllipsis bool) {
+	getContent := func(in string, ls LineStyle, changed []span) (content string, leadingEllipsis bool) {
 		content = strings.TrimSuffix(in, "\n")
-		content = dv.hightlightCode(content, ls.Code.GetBackground())
+		content = dv.hightlightCode(content, ls, changed)
 		content = ansi.GraphemeWidth.Cut(content, dv.xOffset, len(content))
 		content = ansi.Truncate(content, dv.codeWidth, "…")
 		leadingEllipsis = dv.xOffset > 0 && strings.TrimSpace(content) != ""
@@ -428,6 +436,11 @@ outer:
 		beforeLine := h.FromLine
 		afterLine := h.ToLine
 
+		var pairs map[int]int
+		if dv.wordDiff {
+			pairs = pairChangedLines(h.Lines)
+		}
+
 		for j, l := range h.Lines {
 			// print ellipis if we don't have enough space to print the rest of the diff
 			hasReachedHeight := dv.height > 0 && printedLines+1 == dv.height
@@ -452,7 +465,7 @@ outer:
 			case udiff.Equal:
 				if shouldWrite() {
 					ls := dv.style.EqualLine
-					content, leadingEllipsis := getContent(l.Content, ls)
+					content, leadingEllipsis := getContent(l.Content, ls, nil)
 					if dv.lineNumbers {
 						b.WriteString(ls.LineNumber.Render(pad(beforeLine, dv.beforeNumDigits)))
 						b.WriteString(ls.LineNumber.Render(pad(afterLine, dv.afterNumDigits)))
@@ -466,7 +479,11 @@ outer:
 			case udiff.Insert:
 				if shouldWrite() {
 					ls := dv.style.InsertLine
-					content, leadingEllipsis := getContent(l.Content, ls)
+					var changed []span
+					if p, ok := pairs[j]; ok {
+						_, changed = wordDiff(strings.TrimSuffix(h.Lines[p].Content, "\n"), strings.TrimSuffix(l.Content, "\n"))
+					}
+					content, leadingEllipsis := getContent(l.Content, ls, changed)
 					if dv.lineNumbers {
 						b.WriteString(ls.LineNumber.Render(pad(" ", dv.beforeNumDigits)))
 						b.WriteString(ls.LineNumber.Render(pad(afterLine, dv.afterNumDigits)))
@@ -480,7 +497,11 @@ outer:
 			case udiff.Delete:
 				if shouldWrite() {
 					ls := dv.style.DeleteLine
-					content, leadingEllipsis := getContent(l.Content, ls)
+					var changed []span
+					if p, ok := pairs[j]; ok {
+						changed, _ = wordDiff(strings.TrimSuffix(l.Content, "\n"), strings.TrimSuffix(h.Lines[p].Content, "\n"))
+					}
+					content, leadingEllipsis := getContent(l.Content, ls, changed)
 					if dv.lineNumbers {
 						b.WriteString(ls.LineNumber.Render(pad(beforeLine, dv.beforeNumDigits)))
 						b.WriteString(ls.LineNumber.Render(pad(" ", dv.afterNumDigits)))
@@ -512,9 +533,9 @@ func (dv *DiffView) renderSplit() string {
 	printedLines := -dv.yOffset
 	shouldWrite := func() bool { return printedLines >= 0 }
 
-	getContent := func(in string, ls LineStyle) (content string, leadingEllipsis bool) {
+	getContent := func(in string, ls LineStyle, changed []span) (content string, leadingEllipsis bool) {
 		content = strings.TrimSuffix(in, "\n")
-		content = dv.hightlightCode(content, ls.Code.GetBackground())
+		content = dv.hightlightCode(content, ls, changed)
 		content = ansi.GraphemeWidth.Cut(content, dv.xOffset, len(content))
 		content = ansi.Truncate(content, dv.codeWidth, "…")
 		leadingEllipsis = dv.xOffset > 0 && strings.TrimSpace(content) != ""
@@ -573,6 +594,11 @@ outer:
 				break outer
 			}
 
+			var beforeChanged, afterChanged []span
+			if dv.wordDiff && l.before != nil && l.after != nil && l.before.Kind == udiff.Delete && l.after.Kind == udiff.Insert {
+				beforeChanged, afterChanged = wordDiff(strings.TrimSuffix(l.before.Content, "\n"), strings.TrimSuffix(l.after.Content, "\n"))
+			}
+
 			switch {
 			case l.before == nil:
 				if shouldWrite() {
@@ -587,7 +613,7 @@ outer:
 			case l.before.Kind == udiff.Equal:
 				if shouldWrite() {
 					ls := dv.style.EqualLine
-					content, leadingEllipsis := getContent(l.before.Content, ls)
+					content, leadingEllipsis := getContent(l.before.Content, ls, nil)
 					if dv.lineNumbers {
 						b.WriteString(ls.LineNumber.Render(pad(beforeLine, dv.beforeNumDigits)))
 					}
@@ -599,7 +625,7 @@ outer:
 			case l.before.Kind == udiff.Delete:
 				if shouldWrite() {
 					ls := dv.style.DeleteLine
-					content, leadingEllipsis := getContent(l.before.Content, ls)
+					content, leadingEllipsis := getContent(l.before.Content, ls, beforeChanged)
 					if dv.lineNumbers {
 						b.WriteString(ls.LineNumber.Render(pad(beforeLine, dv.beforeNumDigits)))
 					}
@@ -625,7 +651,7 @@ outer:
 			case l.after.Kind == udiff.Equal:
 				if shouldWrite() {
 					ls := dv.style.EqualLine
-					content, leadingEllipsis := getContent(l.after.Content, ls)
+					content, leadingEllipsis := getContent(l.after.Content, ls, nil)
 					if dv.lineNumbers {
 						b.WriteString(ls.LineNumber.Render(pad(afterLine, dv.afterNumDigits)))
 					}
@@ -637,7 +663,7 @@ outer:
 			case l.after.Kind == udiff.Insert:
 				if shouldWrite() {
 					ls := dv.style.InsertLine
-					content, leadingEllipsis := getContent(l.after.Content, ls)
+					content, leadingEllipsis := getContent(l.after.Content, ls, afterChanged)
 					if dv.lineNumbers {
 						b.WriteString(ls.LineNumber.Render(pad(afterLine, dv.afterNumDigits)))
 					}
@@ -703,13 +729,31 @@ func (dv *DiffView) lineStyleForType(t udiff.OpKind) LineStyle {
 	}
 }
 
-func (dv *DiffView) hightlightCode(source string, bgColor color.Color) string {
+func (dv *DiffView) hightlightCode(source string, ls LineStyle, changed []span) string {
+	bgColor := ls.Code.GetBackground()
+	changedBgColor := ls.Changed.GetBackground()
+	if _, ok := changedBgColor.(lipgloss.NoColor); ok {
+		changedBgColor = bgColor
+	}
+
 	if dv.chromaStyle == nil {
-		return source
+		if len(changed) == 0 {
+			return source
+		}
+		changedStyle := lipgloss.NewStyle().Background(changedBgColor)
+		var b strings.Builder
+		prev := 0
+		for _, s := range changed {
+			b.WriteString(source[prev:s.start])
+			b.WriteString(changedStyle.Render(source[s.start:s.end]))
+			prev = s.end
+		}
+		b.WriteString(source[prev:])
+		return b.String()
 	}
 
-	// Create cache key from content and background color
-	cacheKey := dv.createSyntaxCacheKey(source, bgColor)
+	// Create cache key from content, background color and changed words
+	cacheKey := dv.createSyntaxCacheKey(source, bgColor, changed)
 
 	// Check if we already have this highlighted
 	if cached, exists := dv.syntaxCache[cacheKey]; exists {
@@ -717,7 +761,11 @@ func (dv *DiffView) hightlightCode(source string, bgColor color.Color) string {
 	}
 
 	l := dv.getChromaLexer()
-	f := dv.getChromaFormatter(bgColor)
+	f := chromaFormatter{
+		bgColor:        bgColor,
+		changedBgColor: changedBgColor,
+		changed:        changed,
+	}
 
 	it, err := l.Tokenise(nil, source)
 	if err != nil {
@@ -737,12 +785,12 @@ func (dv *DiffView) hightlightCode(source string, bgColor color.Color) string {
 	return result
 }
 
-// createSyntaxCacheKey creates a cache key from source content and background color.
-// We use a simple hash to keep memory usage reasonable.
-func (dv *DiffView) createSyntaxCacheKey(source string, bgColor color.Color) string {
+// createSyntaxCacheKey creates a cache key from source content, background color
+// and changed words. We use a simple hash to keep memory usage reasonable.
+func (dv *DiffView) createSyntaxCacheKey(source string, bgColor color.Color, changed []span) string {
 	// Convert color to string representation
 	r, g, b, a := bgColor.RGBA()
-	colorStr := fmt.Sprintf("%d,%d,%d,%d", r, g, b, a)
+	colorStr := fmt.Sprintf("%d,%d,%d,%d,%v", r, g, b, a, changed)
 
 	// Create a hash of the content + color to use as cache key
 	h := xxh3.New()
@@ -766,9 +814,3 @@ func (dv *DiffView) getChromaLexer() chroma.Lexer {
 	dv.cachedLexer = chroma.Coalesce(l)
 	return dv.cachedLexer
 }
-
-func (dv *DiffView) getChromaFormatter(bgColor color.Color) chroma.Formatter {
-	return chromaFormatter{
-		bgColor: bgColor,
-	}
-}
@@ -0,0 +1,145 @@
+package diffview
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/aymanbagabas/go-udiff"
+)
+
+// maxWordDiffTokens is the most words of a line diffed word by word, as the
+// diff is quadratic.
+const maxWordDiffTokens = 256
+
+// span is a range of bytes of a line.
+type span struct {
+	start, end int
+}
+
+type wordToken struct {
+	text  string
+	start int
+}
+
+// wordTokens splits a line into words, runs of spaces, and single
+// punctuation characters.
+func wordTokens(s string) []wordToken {
+	class := func(r rune) int {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+			return 1
+		case unicode.IsSpace(r):
+			return 2
+		default:
+			return 0
+		}
+	}
+	var tokens []wordToken
+	start, prev := 0, -1
+	for i, r := range s {
+		c := class(r)
+		if i > start && (c == 0 || c != prev) {
+			tokens = append(tokens, wordToken{text: s[start:i], start: start})
+			start = i
+		}
+		prev = c
+	}
+	if start < len(s) {
+		tokens = append(tokens, wordToken{text: s[start:], start: start})
+	}
+	return tokens
+}
+
+// wordDiff returns the spans of the words that differ between a deleted line
+// and the line inserted in its place. Lines with no word in common are
+// rewrites, for which highlighting every word would only be noise, so no
+// spans are returned.
+func wordDiff(before, after string) (beforeSpans, afterSpans []span) {
+	if before == after {
+		return nil, nil
+	}
+	bt, at := wordTokens(before), wordTokens(after)
+	if len(bt) > maxWordDiffTokens || len(at) > maxWordDiffTokens {
+		return nil, nil
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of bt[i:]
+	// and at[j:].
+	lcs := make([][]int, len(bt)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(at)+1)
+	}
+	for i := len(bt) - 1; i >= 0; i-- {
+		for j := len(at) - 1; j >= 0; j-- {
+			if bt[i].text == at[j].text {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	keepBefore := make([]bool, len(bt))
+	keepAfter := make([]bool, len(at))
+	common := false
+	for i, j := 0, 0; i < len(bt) && j < len(at); {
+		switch {
+		case bt[i].text == at[j].text:
+			keepBefore[i], keepAfter[j] = true, true
+			common = common || strings.TrimSpace(bt[i].text) != ""
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	if !common {
+		return nil, nil
+	}
+	return changedSpans(bt, keepBefore), changedSpans(at, keepAfter)
+}
+
+// changedSpans merges the tokens that aren't kept into spans.
+func changedSpans(tokens []wordToken, keep []bool) []span {
+	var spans []span
+	for i, t := range tokens {
+		if keep[i] {
+			continue
+		}
+		end := t.start + len(t.text)
+		if n := len(spans); n > 0 && spans[n-1].end == t.start {
+			spans[n-1].end = end
+			continue
+		}
+		spans = append(spans, span{t.start, end})
+	}
+	return spans
+}
+
+// pairChangedLines pairs the deleted lines of a hunk with the lines inserted
+// right after them, which are likely their new version, by index in both
+// directions.
+func pairChangedLines(lines []udiff.Line) map[int]int {
+	pairs := make(map[int]int)
+	for i := 0; i < len(lines); {
+		if lines[i].Kind != udiff.Delete {
+			i++
+			continue
+		}
+		deleted := i
+		for i < len(lines) && lines[i].Kind == udiff.Delete {
+			i++
+		}
+		inserted := i
+		for i < len(lines) && lines[i].Kind == udiff.Insert {
+			i++
+		}
+		for k := range min(inserted-deleted, i-inserted) {
+			pairs[deleted+k] = inserted + k
+			pairs[inserted+k] = deleted + k
+		}
+	}
+	return pairs
+}
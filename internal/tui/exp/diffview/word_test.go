@@ -0,0 +1,63 @@
+package diffview
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aymanbagabas/go-udiff"
+)
+
+func TestWordDiff(t *testing.T) {
+	tests := []struct {
+		before, after string
+		beforeSpans   []span
+		afterSpans    []span
+	}{
+		{
+			before:      "x := compute(a, b)",
+			after:       "x := computeAll(a, b, c)",
+			beforeSpans: []span{{5, 12}},
+			afterSpans:  []span{{5, 15}, {20, 23}},
+		},
+		{
+			before:      "return nil",
+			after:       "return err",
+			beforeSpans: []span{{7, 10}},
+			afterSpans:  []span{{7, 10}},
+		},
+		// Rewritten lines share no word, so nothing is highlighted.
+		{before: "foo bar", after: "baz qux"},
+		{before: "same", after: "same"},
+	}
+
+	for _, tt := range tests {
+		beforeSpans, afterSpans := wordDiff(tt.before, tt.after)
+		if !reflect.DeepEqual(beforeSpans, tt.beforeSpans) || !reflect.DeepEqual(afterSpans, tt.afterSpans) {
+			t.Errorf("wordDiff(%q, %q) = %v, %v, expected %v, %v", tt.before, tt.after, beforeSpans, afterSpans, tt.beforeSpans, tt.afterSpans)
+		}
+	}
+}
+
+func TestPairChangedLines(t *testing.T) {
+	lines := []udiff.Line{
+		{Kind: udiff.Equal},
+		{Kind: udiff.Delete},
+		{Kind: udiff.Delete},
+		{Kind: udiff.Insert},
+		{Kind: udiff.Equal},
+		{Kind: udiff.Insert},
+	}
+	expected := map[int]int{1: 3, 3: 1}
+	if pairs := pairChangedLines(lines); !reflect.DeepEqual(pairs, expected) {
+		t.Errorf("expected %v, got %v", expected, pairs)
+	}
+}
+
+func TestChromaFormatterSplit(t *testing.T) {
+	f := chromaFormatter{changed: []span{{2, 5}}}
+	parts := f.split("abcdef", 1)
+	expected := []tokenPart{{text: "a"}, {text: "bcd", changed: true}, {text: "ef"}}
+	if !reflect.DeepEqual(parts, expected) {
+		t.Errorf("expected %v, got %v", expected, parts)
+	}
+}
@@ -129,12 +129,12 @@ func (c *completionItemCmp[T]) View() string {
 
 	var truncatedTitle string
 
-	if len(c.matchIndexes) > 0 && len(c.text) > innerWidth {
+	if len(c.matchIndexes) > 0 && ansi.StringWidth(c.text) > innerWidth {
 		// Smart truncation: ensure the last matching part is visible
 		truncatedTitle = c.smartTruncate(c.text, innerWidth, c.matchIndexes)
 	} else {
 		// No matches, use regular truncation
-		truncatedTitle = ansi.Truncate(c.text, innerWidth, "…")
+		truncatedTitle = util.Truncate(c.text, innerWidth)
 	}
 
 	text := titleStyle.Render(truncatedTitle)
@@ -361,7 +361,7 @@ func (m *itemSectionModel) Update(tea.Msg) (util.Model, tea.Cmd) {
 
 func (m *itemSectionModel) View() string {
 	t := styles.CurrentTheme()
-	title := ansi.Truncate(m.title, m.width-2, "…")
+	title := util.Truncate(m.title, m.width-2)
 	style := t.S().Base.Padding(1, 1, 0, 1)
 	if m.inx == 0 {
 		style = style.Padding(0, 1, 0, 1)
@@ -74,6 +74,7 @@ type List[T Item] interface {
 	SelectParagraph(col, line int)
 	GetSelectedText(paddingLeft int) string
 	HasSelection() bool
+	OffsetFromBottom() int
 }
 
 type direction int
@@ -1226,6 +1227,14 @@ func (l *list[T]) decrementOffset(n int) {
 	l.cachedViewDirty = true
 }
 
+// OffsetFromBottom implements List.
+func (l *list[T]) OffsetFromBottom() int {
+	if l.direction == DirectionBackward {
+		return l.offset
+	}
+	return max(0, l.renderedHeight-l.height-l.offset)
+}
+
 // MoveDown implements List.
 func (l *list[T]) MoveDown(n int) tea.Cmd {
 	oldOffset := l.offset
@@ -0,0 +1,133 @@
+package tui
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"charm.land/bubbles/v2/spinner"
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/charmbracelet/crush/internal/tui/components/anim"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/away"
+	"github.com/charmbracelet/crush/internal/tui/util"
+)
+
+// awaySummaryAfter is how long the terminal has to be unfocused for Crush
+// to sum up what happened meanwhile when it's focused again.
+const awaySummaryAfter = 30 * time.Second
+
+// awayState is what happened while the terminal was unfocused.
+type awayState struct {
+	since time.Time
+
+	// steps are the animation steps held back, to resume the animations.
+	steps []tea.Msg
+	// notifications are the status messages held back.
+	notifications []util.InfoMsg
+	// turns are the assistant messages that ended a turn, by ID, in the
+	// order their sessions finished.
+	turns    map[string]bool
+	sessions []string
+	finished map[string]int
+	failed   map[string]int
+}
+
+// holdWhileAway holds back the animation steps and the notifications
+// arriving while the terminal is unfocused, and records the turns that
+// finish. It reports whether msg was held back.
+func (a *appModel) holdWhileAway(msg tea.Msg) bool {
+	switch msg := msg.(type) {
+	case anim.StepMsg, spinner.TickMsg:
+		// Rendering animations nobody sees is a waste.
+		a.away.steps = append(a.away.steps, msg)
+		return true
+	case util.InfoMsg:
+		a.away.notifications = append(a.away.notifications, msg)
+		return true
+	case pubsub.Event[message.Message]:
+		a.recordTurn(msg.Payload)
+	}
+	return false
+}
+
+// recordTurn records the message when it ends a turn of a session other
+// than the ones of sub-agents.
+func (a *appModel) recordTurn(msg message.Message) {
+	if msg.Role != message.Assistant || a.away.turns[msg.ID] {
+		return
+	}
+	reason := msg.FinishReason()
+	if reason != message.FinishReasonEndTurn && reason != message.FinishReasonError {
+		return
+	}
+	if _, _, ok := a.app.Sessions.ParseAgentToolSessionID(msg.SessionID); ok {
+		return
+	}
+	a.away.turns[msg.ID] = true
+	if !slices.Contains(a.away.sessions, msg.SessionID) {
+		a.away.sessions = append(a.away.sessions, msg.SessionID)
+	}
+	if reason == message.FinishReasonError {
+		a.away.failed[msg.SessionID]++
+	} else {
+		a.away.finished[msg.SessionID]++
+	}
+}
+
+// blur starts holding back what happens while the terminal is unfocused.
+func (a *appModel) blur() {
+	if a.away != nil {
+		return
+	}
+	a.away = &awayState{
+		since:    time.Now(),
+		turns:    make(map[string]bool),
+		finished: make(map[string]int),
+		failed:   make(map[string]int),
+	}
+}
+
+// focus resumes the animations and, after a long enough time away, sums up
+// what happened meanwhile. After a short one, the notifications held back
+// are shown as they would have been.
+func (a *appModel) focus() tea.Cmd {
+	state := a.away
+	if state == nil {
+		return nil
+	}
+	a.away = nil
+
+	var cmds []tea.Cmd
+	for _, step := range state.steps {
+		cmds = append(cmds, util.CmdHandler(step))
+	}
+	elapsed := time.Since(state.since)
+	if elapsed < awaySummaryAfter {
+		notifications := make([]tea.Cmd, 0, len(state.notifications))
+		for _, n := range state.notifications {
+			notifications = append(notifications, util.CmdHandler(n))
+		}
+		return tea.Batch(append(cmds, tea.Sequence(notifications...))...)
+	}
+	if len(state.sessions) == 0 && len(state.notifications) == 0 {
+		return tea.Batch(cmds...)
+	}
+	cmds = append(cmds, func() tea.Msg {
+		summary := away.Summary{
+			Away:          elapsed,
+			Notifications: state.notifications,
+		}
+		for _, id := range state.sessions {
+			turns := away.Turns{Finished: state.finished[id], Failed: state.failed[id]}
+			if sess, err := a.app.Sessions.Get(context.Background(), id); err == nil {
+				turns.Session = sess.Title
+			}
+			summary.Turns = append(summary.Turns, turns)
+		}
+		return dialogs.OpenDialogMsg{Model: away.NewAwayDialog(summary)}
+	})
+	return tea.Batch(cmds...)
+}
@@ -2,6 +2,9 @@ package tui
 
 import (
 	"charm.land/bubbles/v2/key"
+	cmpChat "github.com/charmbracelet/crush/internal/tui/components/chat"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/commands"
+	"github.com/charmbracelet/crush/internal/tui/keyseq"
 )
 
 type KeyMap struct {
@@ -11,6 +14,11 @@ type KeyMap struct {
 	Suspend  key.Binding
 	Models   key.Binding
 	Sessions key.Binding
+	Logs     key.Binding
+
+	// Sequences are multi-key bindings, matched while the messages have
+	// the focus so they don't get in the way of typing.
+	Sequences []keyseq.Binding
 
 	pageBindings []key.Binding
 }
@@ -34,12 +42,39 @@ func DefaultKeyMap() KeyMap {
 			key.WithHelp("ctrl+z", "suspend"),
 		),
 		Models: key.NewBinding(
-			key.WithKeys("ctrl+l", "ctrl+m"),
-			key.WithHelp("ctrl+l", "models"),
+			key.WithKeys("ctrl+m"),
+			key.WithHelp("ctrl+m", "models"),
 		),
 		Sessions: key.NewBinding(
 			key.WithKeys("ctrl+s"),
 			key.WithHelp("ctrl+s", "sessions"),
 		),
+		Logs: key.NewBinding(
+			key.WithKeys("ctrl+l"),
+			key.WithHelp("ctrl+l", "logs"),
+		),
+		Sequences: []keyseq.Binding{
+			{Keys: []string{"g", "g"}, Help: "jump to top", Msg: cmpChat.ScrollToTopMsg{}},
+			{Keys: []string{"space", "m"}, Help: "models", Msg: commands.SwitchModelMsg{}},
+			{Keys: []string{"space", "s"}, Help: "sessions", Msg: commands.SwitchSessionsMsg{}},
+			{Keys: []string{"space", "n"}, Help: "new session", Msg: commands.NewSessionsMsg{}},
+			{Keys: []string{"space", "l"}, Help: "logs", Msg: commands.ToggleLogsMsg{}},
+			{Keys: []string{"space", "t"}, Help: "toggle thinking", Msg: commands.ToggleThinkingMsg{}},
+		},
+	}
+}
+
+// setKeyboardEnhanced picks the models key. ctrl+m is only distinct from
+// enter with key disambiguation, so without it the models fall back to
+// ctrl+l, which they had before the log pane, and the logs are left to
+// space l and the command palette.
+func (k *KeyMap) setKeyboardEnhanced(enhanced bool) {
+	if enhanced {
+		k.Models.SetKeys("ctrl+m")
+		k.Models.SetHelp("ctrl+m", "models")
+	} else {
+		k.Models.SetKeys("ctrl+l")
+		k.Models.SetHelp("ctrl+l", "models")
 	}
+	k.Logs.SetEnabled(enhanced)
 }
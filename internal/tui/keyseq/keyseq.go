@@ -0,0 +1,102 @@
+// Package keyseq matches multi-key sequences, such as "g g" or a leader key
+// followed by another key like "space m", on top of single-chord key
+// bindings.
+package keyseq
+
+import (
+	"slices"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+// Binding binds a sequence of keys to a message that's sent when the whole
+// sequence is pressed.
+type Binding struct {
+	// Keys are the keys to press in order, as returned by
+	// tea.KeyPressMsg.String, such as "space" or "g".
+	Keys []string
+	// Help describes what the binding does.
+	Help string
+	// Msg is sent when the sequence is complete.
+	Msg tea.Msg
+}
+
+// Sequence returns the keys of the binding joined with spaces.
+func (b Binding) Sequence() string {
+	return strings.Join(b.Keys, " ")
+}
+
+// Result is the outcome of pressing a key.
+type Result int
+
+const (
+	// NoMatch means the key doesn't start or continue any sequence. Any
+	// pending keys were discarded.
+	NoMatch Result = iota
+	// Pending means the key is part of a sequence that isn't complete yet.
+	Pending
+	// Matched means the key completed a sequence.
+	Matched
+)
+
+// Matcher tracks the keys pressed so far and matches them against its
+// bindings.
+type Matcher struct {
+	bindings []Binding
+	pending  []string
+}
+
+// NewMatcher returns a Matcher for the given bindings.
+func NewMatcher(bindings ...Binding) *Matcher {
+	return &Matcher{bindings: bindings}
+}
+
+// Press records a key press. When it completes a sequence, the matching
+// binding is returned along with Matched.
+func (m *Matcher) Press(key string) (Binding, Result) {
+	keys := append(slices.Clone(m.pending), key)
+	var prefix bool
+	for _, b := range m.bindings {
+		switch {
+		case slices.Equal(b.Keys, keys):
+			m.pending = nil
+			return b, Matched
+		case len(b.Keys) > len(keys) && slices.Equal(b.Keys[:len(keys)], keys):
+			prefix = true
+		}
+	}
+	if prefix {
+		m.pending = keys
+		return Binding{}, Pending
+	}
+	m.pending = nil
+	return Binding{}, NoMatch
+}
+
+// Pending returns the keys of the sequence pressed so far.
+func (m *Matcher) Pending() []string {
+	return m.pending
+}
+
+// Active reports whether a sequence has been started.
+func (m *Matcher) Active() bool {
+	return len(m.pending) > 0
+}
+
+// Reset discards the keys pressed so far.
+func (m *Matcher) Reset() {
+	m.pending = nil
+}
+
+// Next returns the bindings that continue the pending sequence, for showing
+// hints about which keys can be pressed next.
+func (m *Matcher) Next() []Binding {
+	var next []Binding
+	for _, b := range m.bindings {
+		if len(b.Keys) > len(m.pending) && slices.Equal(b.Keys[:len(m.pending)], m.pending) {
+			next = append(next, b)
+		}
+	}
+	return next
+}
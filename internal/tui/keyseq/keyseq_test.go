@@ -0,0 +1,52 @@
+package keyseq
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type topMsg struct{}
+
+type modelsMsg struct{}
+
+func TestMatcher(t *testing.T) {
+	t.Parallel()
+
+	m := NewMatcher(
+		Binding{Keys: []string{"g", "g"}, Help: "top", Msg: topMsg{}},
+		Binding{Keys: []string{"space", "m"}, Help: "models", Msg: modelsMsg{}},
+		Binding{Keys: []string{"space", "s", "n"}, Help: "new session"},
+	)
+
+	_, res := m.Press("x")
+	require.Equal(t, NoMatch, res)
+	require.False(t, m.Active())
+
+	_, res = m.Press("g")
+	require.Equal(t, Pending, res)
+	require.Equal(t, []string{"g"}, m.Pending())
+	b, res := m.Press("g")
+	require.Equal(t, Matched, res)
+	require.Equal(t, topMsg{}, b.Msg)
+	require.False(t, m.Active())
+
+	_, res = m.Press("space")
+	require.Equal(t, Pending, res)
+	next := m.Next()
+	require.Len(t, next, 2)
+	require.Equal(t, "space m", next[0].Sequence())
+
+	_, res = m.Press("s")
+	require.Equal(t, Pending, res)
+	require.Len(t, m.Next(), 1)
+
+	_, res = m.Press("x")
+	require.Equal(t, NoMatch, res)
+	require.False(t, m.Active())
+
+	_, res = m.Press("space")
+	require.Equal(t, Pending, res)
+	m.Reset()
+	require.False(t, m.Active())
+}
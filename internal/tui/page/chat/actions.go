@@ -0,0 +1,212 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/atotto/clipboard"
+	"github.com/charmbracelet/crush/internal/latex"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/tui/components/chat"
+	"github.com/charmbracelet/crush/internal/tui/components/chat/editor"
+	"github.com/charmbracelet/crush/internal/tui/components/chat/messages"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/deletemessage"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/messageactions"
+	"github.com/charmbracelet/crush/internal/tui/util"
+	"github.com/pkg/browser"
+)
+
+// handleMessageAction runs an action picked in the actions menu of a
+// message.
+func (p *chatPage) handleMessageAction(msg messageactions.ActionSelectedMsg) tea.Cmd {
+	action, target := msg.Action, msg.Message
+	switch action.Kind {
+	case messageactions.ActionCopy:
+		text := target.Content().Text
+		return tea.Sequence(
+			tea.SetClipboard(text),
+			func() tea.Msg {
+				_ = clipboard.WriteAll(text)
+				return nil
+			},
+			util.ReportInfo("Message copied to clipboard"),
+		)
+	case messageactions.ActionQuote:
+		u, cmd := p.editor.Update(editor.QuoteMsg{Message: target})
+		p.editor = u.(editor.Editor)
+		if p.focusedPane == PanelTypeChat {
+			return tea.Batch(cmd, p.changeFocus())
+		}
+		return cmd
+	case messageactions.ActionExport:
+		path, err := p.app.ExportMessage(target)
+		if err != nil {
+			return util.ReportError(err)
+		}
+		return util.ReportInfo("Message exported to " + path)
+	case messageactions.ActionOpenFile:
+		return editor.OpenFile(action.Path)
+	case messageactions.ActionOpenInBrowser:
+		return func() tea.Msg {
+			url, err := p.app.PreviewMessage(target)
+			if err != nil {
+				return util.ReportError(err)()
+			}
+			if err := browser.OpenURL(url); err != nil {
+				return util.ReportError(fmt.Errorf("failed to open the browser, the message is at %s: %w", url, err))()
+			}
+			return util.ReportInfo("Message opened in the browser")()
+		}
+	case messageactions.ActionPreviewMath:
+		return previewMath(target)
+	case messageactions.ActionViewDiagrams:
+		return messages.RenderDiagrams(target)
+	case messageactions.ActionDelete:
+		return util.CmdHandler(dialogs.OpenDialogMsg{
+			Model: deletemessage.NewDeleteMessageDialog(deletemessage.MessageTarget(target)),
+		})
+	case messageactions.ActionDeleteToolCall:
+		tc, ok := findToolCall(target, action.ToolCallID)
+		if !ok {
+			return nil
+		}
+		return util.CmdHandler(dialogs.OpenDialogMsg{
+			Model: deletemessage.NewDeleteMessageDialog(deletemessage.ToolCallTarget(target.SessionID, target.ID, tc)),
+		})
+	}
+
+	// The rest change the conversation, which can't happen under the
+	// agent's feet.
+	if p.app.AgentCoordinator != nil && p.app.AgentCoordinator.IsSessionBusy(target.SessionID) {
+		return util.ReportWarn("Agent is working, please wait...")
+	}
+	switch action.Kind {
+	case messageactions.ActionFork:
+		return func() tea.Msg {
+			fork, err := p.app.ForkSession(context.Background(), target.SessionID, target.ID)
+			if err != nil {
+				return util.ReportError(err)()
+			}
+			return chat.SessionSelectedMsg(fork)
+		}
+	case messageactions.ActionRegenerate:
+		return p.regenerate(target)
+	case messageactions.ActionToggleExcluded:
+		return p.toggleExcluded(target.ID)
+	}
+	return nil
+}
+
+// toggleExcluded excludes a message from the context sent to the model, or
+// includes it back.
+func (p *chatPage) toggleExcluded(messageID string) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		msg, err := p.app.Messages.Get(ctx, messageID)
+		if err != nil {
+			return util.ReportError(err)()
+		}
+		if p.app.AgentCoordinator != nil && p.app.AgentCoordinator.IsSessionBusy(msg.SessionID) {
+			return util.ReportWarn("Agent is working, please wait...")()
+		}
+		if err := p.app.Messages.SetExcluded(ctx, msg.ID, !msg.Excluded); err != nil {
+			return util.ReportError(err)()
+		}
+		return chat.ReloadSessionMsg{}
+	}
+}
+
+// regenerate sends the prompt of a user message again, or the prompt that
+// led to an assistant message, after removing it and everything after it.
+func (p *chatPage) regenerate(target message.Message) tea.Cmd {
+	ctx := context.Background()
+	prompt := target
+	if target.Role != message.User {
+		msgs, err := p.app.Messages.List(ctx, target.SessionID)
+		if err != nil {
+			return util.ReportError(err)
+		}
+		i := slices.IndexFunc(msgs, func(m message.Message) bool { return m.ID == target.ID })
+		for i >= 0 && msgs[i].Role != message.User {
+			i--
+		}
+		if i < 0 {
+			return util.ReportWarn("No prompt to regenerate the response from")
+		}
+		prompt = msgs[i]
+	}
+
+	var attachments []message.Attachment
+	for _, bc := range prompt.BinaryContent() {
+		attachments = append(attachments, message.Attachment{
+			FilePath: bc.Path,
+			FileName: filepath.Base(bc.Path),
+			MimeType: bc.MIMEType,
+			Content:  bc.Data,
+			Note:     bc.Note,
+		})
+	}
+	if err := p.app.RewindSession(ctx, prompt.SessionID, prompt.ID); err != nil {
+		return util.ReportError(err)
+	}
+	return p.sendMessage(prompt.Content().Text, attachments)
+}
+
+// deleteMessage deletes a message or a tool call once the user confirmed it.
+func (p *chatPage) deleteMessage(target deletemessage.Target) tea.Cmd {
+	if p.app.AgentCoordinator != nil && p.app.AgentCoordinator.IsSessionBusy(target.SessionID) {
+		return util.ReportWarn("Agent is working, please wait...")
+	}
+	return func() tea.Msg {
+		ctx := context.Background()
+		var err error
+		if target.ToolCallID != "" {
+			err = p.app.DeleteToolCall(ctx, target.SessionID, target.MessageID, target.ToolCallID)
+		} else {
+			err = p.app.DeleteMessage(ctx, target.SessionID, target.MessageID)
+		}
+		if err != nil {
+			return util.ReportError(err)()
+		}
+		return chat.ReloadSessionMsg{}
+	}
+}
+
+// previewMath opens the math of a message typeset in the browser.
+func previewMath(msg message.Message) tea.Cmd {
+	formulas := latex.Find(msg.Content().Text)
+	if len(formulas) == 0 {
+		return util.ReportWarn("No math in this message")
+	}
+	return func() tea.Msg {
+		f, err := os.CreateTemp("", "crush-math-*.html")
+		if err != nil {
+			return util.ReportError(fmt.Errorf("failed to preview math: %w", err))()
+		}
+		_, err = f.WriteString(latex.Preview("Math", formulas))
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return util.ReportError(fmt.Errorf("failed to preview math: %w", err))()
+		}
+		if err := browser.OpenFile(f.Name()); err != nil {
+			return util.ReportError(fmt.Errorf("failed to open the math preview: %w", err))()
+		}
+		return util.ReportInfo("Math preview opened in the browser")()
+	}
+}
+
+func findToolCall(msg message.Message, id string) (message.ToolCall, bool) {
+	for _, tc := range msg.ToolCalls() {
+		if tc.ID == id {
+			return tc, true
+		}
+	}
+	return message.ToolCall{}, false
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"time"
 
 	"charm.land/bubbles/v2/help"
@@ -11,6 +12,7 @@ import (
 	"charm.land/bubbles/v2/spinner"
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/agent/tools"
 	"github.com/charmbracelet/crush/internal/app"
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/history"
@@ -29,12 +31,17 @@ import (
 	"github.com/charmbracelet/crush/internal/tui/components/core"
 	"github.com/charmbracelet/crush/internal/tui/components/core/layout"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/attachments"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/claude"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/commands"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/copilot"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/deletemessage"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/filepicker"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/hyper"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/messageactions"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/models"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/paste"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/prompts"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/reasoning"
 	"github.com/charmbracelet/crush/internal/tui/page"
 	"github.com/charmbracelet/crush/internal/tui/styles"
@@ -89,6 +96,8 @@ type ChatPage interface {
 	util.Model
 	layout.Help
 	IsChatFocused() bool
+	UIState() session.UIState
+	SaveSessionState()
 }
 
 // cancelTimerCmd creates a command that expires the cancel timer
@@ -142,7 +151,7 @@ func New(app *app.App) ChatPage {
 		app:         app,
 		keyMap:      DefaultKeyMap(),
 		header:      header.New(app.LSPClients),
-		sidebar:     sidebar.New(app.History, app.LSPClients, false),
+		sidebar:     sidebar.New(app.History, app.Messages, app.LSPClients, false),
 		chat:        chat.New(app),
 		editor:      editor.New(app),
 		splash:      splash.New(),
@@ -252,7 +261,7 @@ func (p *chatPage) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 			return p, cmd
 		}
 		return p, nil
-	case chat.SelectionCopyMsg:
+	case chat.SelectionCopyMsg, chat.ScrollToTopMsg, chat.ScrollToBottomMsg, chat.ReloadSessionMsg, messages.PluginRenderedMsg:
 		u, cmd := p.chat.Update(msg)
 		p.chat = u.(chat.MessageListCmp)
 		return p, cmd
@@ -287,10 +296,18 @@ func (p *chatPage) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 			cmd = p.updateCompactConfig(false)
 		}
 		return p, tea.Batch(p.SetSize(p.width, p.height), cmd)
+	case commands.ToggleExplainModeMsg:
+		return p, p.toggleExplainMode()
 	case commands.ToggleThinkingMsg:
 		return p, p.toggleThinking()
 	case commands.OpenReasoningDialogMsg:
 		return p, p.openReasoningDialog()
+	case messageactions.ActionSelectedMsg:
+		return p, p.handleMessageAction(msg)
+	case deletemessage.ConfirmedMsg:
+		return p, p.deleteMessage(msg.Target)
+	case chat.ToggleExcludedMsg:
+		return p, p.toggleExcluded(msg.MessageID)
 	case reasoning.ReasoningEffortSelectedMsg:
 		return p, p.handleReasoningEffortSelected(msg.Effort)
 	case commands.OpenExternalEditorMsg:
@@ -300,11 +317,12 @@ func (p *chatPage) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 	case pubsub.Event[session.Session]:
 		if msg.Payload.ID == p.session.ID {
 			prevHasIncompleteTodos := hasIncompleteTodos(p.session.Todos)
+			prevExplainMode := p.session.ExplainMode
 			prevHasInProgress := p.hasInProgressTodo()
 			p.session = msg.Payload
 			newHasIncompleteTodos := hasIncompleteTodos(p.session.Todos)
 			newHasInProgress := p.hasInProgressTodo()
-			if prevHasIncompleteTodos != newHasIncompleteTodos {
+			if prevHasIncompleteTodos != newHasIncompleteTodos || prevExplainMode != p.session.ExplainMode {
 				cmds = append(cmds, p.SetSize(p.width, p.height))
 			}
 			if !prevHasInProgress && newHasInProgress {
@@ -330,6 +348,11 @@ func (p *chatPage) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		cmds = append(cmds, cmd)
 		return p, tea.Batch(cmds...)
 	case filepicker.FilePickedMsg,
+		attachments.UpdateMsg,
+		paste.ExpandMsg,
+		paste.RemoveMsg,
+		prompts.EditMsg,
+		prompts.RunMsg,
 		completions.CompletionsClosedMsg,
 		completions.SelectCompletionMsg:
 		u, cmd := p.editor.Update(msg)
@@ -361,6 +384,11 @@ func (p *chatPage) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 	case pubsub.Event[message.Message],
 		anim.StepMsg,
 		spinner.TickMsg:
+		if msg, ok := msg.(pubsub.Event[message.Message]); ok {
+			u, cmd := p.sidebar.Update(msg)
+			p.sidebar = u.(sidebar.Sidebar)
+			cmds = append(cmds, cmd)
+		}
 		// Update todo spinner if agent is busy and we have in-progress todos
 		agentBusy := p.app.AgentCoordinator != nil && p.app.AgentCoordinator.IsBusy()
 		if _, ok := msg.(spinner.TickMsg); ok && p.hasInProgressTodo() && agentBusy {
@@ -393,7 +421,7 @@ func (p *chatPage) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		p.sidebar = u.(sidebar.Sidebar)
 		cmds = append(cmds, cmd)
 		return p, tea.Batch(cmds...)
-	case pubsub.Event[permission.PermissionNotification]:
+	case pubsub.Event[permission.PermissionNotification], pubsub.Event[tools.OutputEvent]:
 		u, cmd := p.chat.Update(msg)
 		p.chat = u.(chat.MessageListCmp)
 		cmds = append(cmds, cmd)
@@ -451,11 +479,7 @@ func (p *chatPage) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 			if model == nil {
 				return p, util.ReportWarn("No model configured yet")
 			}
-			if model.SupportsImages {
-				return p, util.CmdHandler(commands.OpenFilePickerMsg{})
-			} else {
-				return p, util.ReportWarn("File attachments are not supported by the current model: " + model.Name)
-			}
+			return p, util.CmdHandler(commands.OpenAttachFilesMsg{})
 		case key.Matches(msg, p.keyMap.Tab):
 			if p.session.ID == "" {
 				u, cmd := p.splash.Update(msg)
@@ -571,6 +595,9 @@ func (p *chatPage) View() string {
 		}
 
 		var pills []string
+		if p.session.ExplainMode {
+			pills = append(pills, explainPill(p.pillsExpanded, t))
+		}
 		if hasIncompleteTodos {
 			pills = append(pills, todoPill(p.session.Todos, inProgressIcon, todosFocused, p.pillsExpanded, t))
 		}
@@ -593,17 +620,20 @@ func (p *chatPage) View() string {
 			pillsRow := lipgloss.JoinHorizontal(lipgloss.Top, pills...)
 
 			// Add help hint for expanding/collapsing pills based on state.
-			var helpDesc string
-			if p.pillsExpanded {
-				helpDesc = "close"
-			} else {
-				helpDesc = "open"
+			// The explain mode pill has nothing to expand.
+			if hasIncompleteTodos || hasQueue {
+				var helpDesc string
+				if p.pillsExpanded {
+					helpDesc = "close"
+				} else {
+					helpDesc = "open"
+				}
+				// Style to match help section: keys in FgMuted, description in FgSubtle
+				helpKey := t.S().Base.Foreground(t.FgMuted).Render("ctrl+space")
+				helpText := t.S().Base.Foreground(t.FgSubtle).Render(helpDesc)
+				helpHint := lipgloss.JoinHorizontal(lipgloss.Center, helpKey, " ", helpText)
+				pillsRow = lipgloss.JoinHorizontal(lipgloss.Center, pillsRow, " ", helpHint)
 			}
-			// Style to match help section: keys in FgMuted, description in FgSubtle
-			helpKey := t.S().Base.Foreground(t.FgMuted).Render("ctrl+space")
-			helpText := t.S().Base.Foreground(t.FgSubtle).Render(helpDesc)
-			helpHint := lipgloss.JoinHorizontal(lipgloss.Center, helpKey, " ", helpText)
-			pillsRow = lipgloss.JoinHorizontal(lipgloss.Center, pillsRow, " ", helpHint)
 
 			if expandedList != "" {
 				pillsArea = lipgloss.JoinVertical(
@@ -808,7 +838,7 @@ func (p *chatPage) SetSize(width, height int) tea.Cmd {
 	} else {
 		hasIncompleteTodos := hasIncompleteTodos(p.session.Todos)
 		hasQueue := p.promptQueue > 0
-		hasPills := hasIncompleteTodos || hasQueue
+		hasPills := hasIncompleteTodos || hasQueue || p.session.ExplainMode
 
 		pillsAreaHeight := 0
 		if hasPills {
@@ -843,6 +873,7 @@ func (p *chatPage) newSession() tea.Cmd {
 		return nil
 	}
 
+	p.SaveSessionState()
 	p.session = session.Session{}
 	p.focusedPane = PanelTypeEditor
 	p.editor.Focus()
@@ -860,12 +891,14 @@ func (p *chatPage) setSession(sess session.Session) tea.Cmd {
 	}
 
 	var cmds []tea.Cmd
+	p.SaveSessionState()
 	p.session = sess
 
 	if p.hasInProgressTodo() {
 		cmds = append(cmds, p.todoSpinner.Tick)
 	}
 
+	p.restoreUIState(sess.UI)
 	cmds = append(cmds, p.SetSize(p.width, p.height))
 	cmds = append(cmds, p.chat.SetSession(sess))
 	cmds = append(cmds, p.sidebar.SetSession(sess))
@@ -875,6 +908,48 @@ func (p *chatPage) setSession(sess session.Session) tea.Cmd {
 	return tea.Sequence(cmds...)
 }
 
+// restoreUIState puts the pills and focus back the way they were when the
+// session was last open. The draft and scroll position are restored by the
+// editor and chat themselves.
+func (p *chatPage) restoreUIState(state session.UIState) {
+	p.pillsExpanded = state.PillsExpanded && (hasIncompleteTodos(p.session.Todos) || p.promptQueue > 0)
+	p.focusedPillSection = PillSection(state.PillSection)
+	if p.focusedPillSection == PillSectionQueue && p.promptQueue == 0 {
+		p.focusedPillSection = PillSectionTodos
+	}
+	if state.ChatFocused {
+		p.focusedPane = PanelTypeChat
+		p.chat.Focus()
+		p.editor.Blur()
+	} else {
+		p.focusedPane = PanelTypeEditor
+		p.editor.Focus()
+		p.chat.Blur()
+	}
+}
+
+// UIState returns the TUI state of the current session.
+func (p *chatPage) UIState() session.UIState {
+	return session.UIState{
+		Draft:         p.editor.Draft(),
+		ScrollOffset:  int64(p.chat.ScrollOffset()),
+		PillsExpanded: p.pillsExpanded,
+		PillSection:   int(p.focusedPillSection),
+		ChatFocused:   p.focusedPane == PanelTypeChat,
+	}
+}
+
+// SaveSessionState stores the TUI state of the current session so it's
+// restored when the session is opened again.
+func (p *chatPage) SaveSessionState() {
+	if p.session.ID == "" {
+		return
+	}
+	if err := p.app.Sessions.SaveUIState(context.Background(), p.session.ID, p.UIState()); err != nil {
+		slog.Error("Failed to save session state", "session_id", p.session.ID, "error", err)
+	}
+}
+
 func (p *chatPage) changeFocus() tea.Cmd {
 	if p.session.ID == "" {
 		return nil
@@ -893,6 +968,21 @@ func (p *chatPage) changeFocus() tea.Cmd {
 	return nil
 }
 
+// toggleExplainMode turns explain mode on or off for the session, or for
+// the one the next message creates.
+func (p *chatPage) toggleExplainMode() tea.Cmd {
+	explain := !p.session.ExplainMode
+	if p.session.ID == "" {
+		p.session.ExplainMode = explain
+	} else if err := p.app.Sessions.SetExplainMode(context.Background(), p.session.ID, explain); err != nil {
+		return util.ReportError(err)
+	}
+	if explain {
+		return util.ReportInfo("Explain mode on: the agent only analyzes and explains, without changing anything")
+	}
+	return util.ReportInfo("Explain mode off")
+}
+
 func (p *chatPage) togglePillsExpanded() tea.Cmd {
 	hasPills := hasIncompleteTodos(p.session.Todos) || p.promptQueue > 0
 	if !hasPills {
@@ -970,6 +1060,13 @@ func (p *chatPage) sendMessage(text string, attachments []message.Attachment) te
 		if err != nil {
 			return util.ReportError(err)
 		}
+		// Explain mode can be turned on before the session exists.
+		if p.session.ExplainMode {
+			if err := p.app.Sessions.SetExplainMode(context.Background(), newSession.ID, true); err != nil {
+				return util.ReportError(err)
+			}
+			newSession.ExplainMode = true
+		}
 		session = newSession
 		cmds = append(cmds, util.CmdHandler(chat.SessionSelectedMsg(session)))
 	}
@@ -1262,18 +1359,25 @@ func (p *chatPage) Help() help.KeyMap {
 			commandsBinding.SetHelp("/ or ctrl+p", "commands")
 		}
 		modelsBinding := key.NewBinding(
-			key.WithKeys("ctrl+m", "ctrl+l"),
-			key.WithHelp("ctrl+l", "models"),
+			key.WithKeys("ctrl+m"),
+			key.WithHelp("ctrl+m", "models"),
+		)
+		logsBinding := key.NewBinding(
+			key.WithKeys("ctrl+l"),
+			key.WithHelp("ctrl+l", "logs"),
 		)
-		if p.keyboardEnhancements.Flags > 0 {
-			// non-zero flags mean we have at least key disambiguation
-			modelsBinding.SetHelp("ctrl+m", "models")
+		// ctrl+m is only distinct from enter with key disambiguation, so
+		// the models fall back to ctrl+l without it.
+		if p.keyboardEnhancements.Flags == 0 {
+			modelsBinding.SetKeys("ctrl+l")
+			modelsBinding.SetHelp("ctrl+l", "models")
+			logsBinding.SetEnabled(false)
 		}
 		helpBinding := key.NewBinding(
 			key.WithKeys("ctrl+g"),
 			key.WithHelp("ctrl+g", "more"),
 		)
-		globalBindings = append(globalBindings, commandsBinding, modelsBinding)
+		globalBindings = append(globalBindings, commandsBinding, modelsBinding, logsBinding)
 		globalBindings = append(globalBindings,
 			key.NewBinding(
 				key.WithKeys("ctrl+s"),
@@ -1302,6 +1406,7 @@ func (p *chatPage) Help() help.KeyMap {
 					key.WithHelp("↑↓", "scroll"),
 				),
 				messages.CopyKey,
+				messages.ActionsKey,
 			)
 			fullList = append(fullList,
 				[]key.Binding{
@@ -1333,7 +1438,7 @@ func (p *chatPage) Help() help.KeyMap {
 					),
 					key.NewBinding(
 						key.WithKeys("g", "home"),
-						key.WithHelp("g", "home"),
+						key.WithHelp("g g", "home"),
 					),
 					key.NewBinding(
 						key.WithKeys("G", "end"),
@@ -1342,6 +1447,11 @@ func (p *chatPage) Help() help.KeyMap {
 				},
 				[]key.Binding{
 					messages.CopyKey,
+					messages.ActionsKey,
+					messages.ExpandKey,
+					messages.DeleteKey,
+					messages.ExcludeKey,
+					messages.DiagramKey,
 					messages.ClearSelectionKey,
 				},
 			)
@@ -1363,7 +1473,7 @@ func (p *chatPage) Help() help.KeyMap {
 					newLineBinding,
 					key.NewBinding(
 						key.WithKeys("ctrl+t"),
-						key.WithHelp("ctrl+t", "add image"),
+						key.WithHelp("ctrl+t", "attach files"),
 					),
 					key.NewBinding(
 						key.WithKeys("@"),
@@ -23,7 +23,7 @@ func DefaultKeyMap() KeyMap {
 		),
 		AddAttachment: key.NewBinding(
 			key.WithKeys("ctrl+t"),
-			key.WithHelp("ctrl+t", "add attachment"),
+			key.WithHelp("ctrl+t", "attach files"),
 		),
 		Cancel: key.NewBinding(
 			key.WithKeys("esc", "alt+esc"),
@@ -8,6 +8,7 @@ import (
 	"github.com/charmbracelet/crush/internal/session"
 	"github.com/charmbracelet/crush/internal/tui/components/chat/todos"
 	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
 )
 
 func hasIncompleteTodos(todos []session.Todo) bool {
@@ -29,7 +30,7 @@ func queuePill(queue int, focused, pillsPanelFocused bool, t *styles.Theme) stri
 	if queue <= 0 {
 		return ""
 	}
-	triangles := styles.ForegroundGrad("▶▶▶▶▶▶▶▶▶", false, t.RedDark, t.Accent)
+	triangles := styles.ForegroundGrad(strings.Repeat(styles.TriangleIcon, 9), false, t.RedDark, t.Accent)
 	if queue < 10 {
 		triangles = triangles[:queue]
 	}
@@ -45,6 +46,20 @@ func queuePill(queue int, focused, pillsPanelFocused bool, t *styles.Theme) stri
 	return style.Render(content)
 }
 
+// explainPill shows that the session is in explain mode, where the agent
+// only analyzes and explains the code.
+func explainPill(pillsPanelFocused bool, t *styles.Theme) string {
+	content := t.S().Base.Foreground(t.Info).Render(styles.RadioOnIcon) + " Explain Mode"
+
+	style := t.S().Base.PaddingLeft(1).PaddingRight(1)
+	if !pillsPanelFocused {
+		style = style.BorderStyle(lipgloss.RoundedBorder()).BorderForeground(t.BgOverlay)
+	} else {
+		style = style.BorderStyle(lipgloss.HiddenBorder())
+	}
+	return style.Render(content)
+}
+
 func todoPill(todos []session.Todo, spinnerView string, focused, pillsPanelFocused bool, t *styles.Theme) string {
 	if !hasIncompleteTodos(todos) {
 		return ""
@@ -76,10 +91,7 @@ func todoPill(todos []session.Todo, spinnerView string, focused, pillsPanelFocus
 		if currentTodo.ActiveForm != "" {
 			taskText = currentTodo.ActiveForm
 		}
-		if len(taskText) > maxTaskDisplayLength {
-			taskText = taskText[:maxTaskDisplayLength-1] + "…"
-		}
-		task := t.S().Base.Foreground(t.FgSubtle).Render(taskText)
+		task := t.S().Base.Foreground(t.FgSubtle).Render(util.Truncate(taskText, maxTaskDisplayLength))
 		content = fmt.Sprintf("%s %s %s  %s", spinnerView, label, progress, task)
 	} else {
 		content = fmt.Sprintf("%s %s", label, progress)
@@ -105,11 +117,8 @@ func queueList(queueItems []string, t *styles.Theme) string {
 
 	var lines []string
 	for _, item := range queueItems {
-		text := item
-		if len(text) > maxQueueDisplayLength {
-			text = text[:maxQueueDisplayLength-1] + "…"
-		}
-		prefix := t.S().Base.Foreground(t.FgMuted).Render("  •") + " "
+		text := util.Truncate(item, maxQueueDisplayLength)
+		prefix := t.S().Base.Foreground(t.FgMuted).Render("  "+styles.BulletIcon) + " "
 		lines = append(lines, prefix+t.S().Base.Foreground(t.FgMuted).Render(text))
 	}
 
@@ -0,0 +1,46 @@
+package tui
+
+import (
+	"runtime/debug"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+// PanicGuard wraps the root model to record a panic in Update or View. The
+// panic is passed on, so Bubble Tea still restores the terminal and Run
+// returns tea.ErrProgramPanic, after which the recorded panic can be
+// reported.
+type PanicGuard struct {
+	tea.Model
+
+	// Panic and Stack describe the first panic caught, if any.
+	Panic any
+	Stack []byte
+}
+
+// NewPanicGuard wraps m in a PanicGuard.
+func NewPanicGuard(m tea.Model) *PanicGuard {
+	return &PanicGuard{Model: m}
+}
+
+func (g *PanicGuard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	defer g.catch()
+	var cmd tea.Cmd
+	g.Model, cmd = g.Model.Update(msg)
+	return g, cmd
+}
+
+func (g *PanicGuard) View() tea.View {
+	defer g.catch()
+	return g.Model.View()
+}
+
+func (g *PanicGuard) catch() {
+	if r := recover(); r != nil {
+		if g.Panic == nil {
+			g.Panic = r
+			g.Stack = debug.Stack()
+		}
+		panic(r)
+	}
+}
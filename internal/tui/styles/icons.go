@@ -1,32 +1,86 @@
 package styles
 
-const (
-	CheckIcon         string = "✓"
-	ErrorIcon         string = "×"
-	WarningIcon       string = "⚠"
-	InfoIcon          string = "ⓘ"
-	HintIcon          string = "∵"
-	SpinnerIcon       string = "..."
-	ArrowRightIcon    string = "→"
-	CenterSpinnerIcon string = "⋯"
-	LoadingIcon       string = "⟳"
-	ImageIcon         string = "■"
-	TextIcon          string = "☰"
-	ModelIcon         string = "◇"
+// Icons are variables so UseASCIIIcons can replace them.
+var (
+	CheckIcon         = "✓"
+	ErrorIcon         = "×"
+	WarningIcon       = "⚠"
+	InfoIcon          = "ⓘ"
+	HintIcon          = "∵"
+	SpinnerIcon       = "..."
+	ArrowRightIcon    = "→"
+	CenterSpinnerIcon = "⋯"
+	LoadingIcon       = "⟳"
+	ImageIcon         = "■"
+	TextIcon          = "☰"
+	ModelIcon         = "◇"
+	DotIcon           = "●"
+	BulletIcon        = "•"
+	QuoteIcon         = "↪"
+	TriangleIcon      = "▶"
+
+	// Radio button icons
+	RadioOnIcon  = "◉"
+	RadioOffIcon = "○"
+
+	// Collapsible section icons
+	CollapsedIcon = "▸"
+	ExpandedIcon  = "▾"
 
 	// Tool call icons
-	ToolPending string = "●"
-	ToolSuccess string = "✓"
-	ToolError   string = "×"
+	ToolPending = "●"
+	ToolSuccess = "✓"
+	ToolError   = "×"
 
-	BorderThin  string = "│"
-	BorderThick string = "▌"
+	BorderThin  = "│"
+	BorderThick = "▌"
 
 	// Todo icons
-	TodoCompletedIcon string = "✓"
-	TodoPendingIcon   string = "•"
+	TodoCompletedIcon = "✓"
+	TodoPendingIcon   = "•"
 )
 
+// UseASCIIIcons replaces the icons with plain ASCII, for the terminals and
+// fonts that render them as boxes or at the wrong width. It must be called
+// before anything is rendered.
+func UseASCIIIcons() {
+	CheckIcon = "v"
+	ErrorIcon = "x"
+	WarningIcon = "!"
+	InfoIcon = "i"
+	HintIcon = "?"
+	ArrowRightIcon = "->"
+	CenterSpinnerIcon = "..."
+	LoadingIcon = "~"
+	ImageIcon = "#"
+	TextIcon = "="
+	ModelIcon = "*"
+	DotIcon = "*"
+	BulletIcon = "-"
+	QuoteIcon = ">"
+	TriangleIcon = ">"
+	RadioOnIcon = "(*)"
+	RadioOffIcon = "( )"
+	CollapsedIcon = ">"
+	ExpandedIcon = "v"
+	ToolPending = "*"
+	ToolSuccess = "v"
+	ToolError = "x"
+	BorderThin = "|"
+	BorderThick = "|"
+	TodoCompletedIcon = "x"
+	TodoPendingIcon = "-"
+
+	// The styles were made with the icons they render.
+	for _, t := range DefaultManager().themes {
+		t.ItemOfflineIcon = t.ItemOfflineIcon.SetString(DotIcon)
+		t.ItemBusyIcon = t.ItemBusyIcon.SetString(DotIcon)
+		t.ItemErrorIcon = t.ItemErrorIcon.SetString(DotIcon)
+		t.ItemOnlineIcon = t.ItemOnlineIcon.SetString(DotIcon)
+		t.styles = nil
+	}
+}
+
 var SelectionIgnoreIcons = []string{
 	// CheckIcon,
 	// ErrorIcon,
@@ -134,7 +134,7 @@ func PlainMarkdownStyle() ansi.StyleConfig {
 			BackgroundColor: bgColor,
 		},
 		Item: ansi.StylePrimitive{
-			BlockPrefix:     "• ",
+			BlockPrefix:     BulletIcon + " ",
 			Color:           fgColor,
 			BackgroundColor: bgColor,
 		},
@@ -148,7 +148,7 @@ func PlainMarkdownStyle() ansi.StyleConfig {
 				Color:           fgColor,
 				BackgroundColor: bgColor,
 			},
-			Ticked:   "[✓] ",
+			Ticked:   "[" + TodoCompletedIcon + "] ",
 			Unticked: "[ ] ",
 		},
 		Link: ansi.StylePrimitive{
@@ -290,14 +290,14 @@ func (t *Theme) buildStyles() *Styles {
 				Format: "\n--------\n",
 			},
 			Item: ansi.StylePrimitive{
-				BlockPrefix: "• ",
+				BlockPrefix: BulletIcon + " ",
 			},
 			Enumeration: ansi.StylePrimitive{
 				BlockPrefix: ". ",
 			},
 			Task: ansi.StyleTask{
 				StylePrimitive: ansi.StylePrimitive{},
-				Ticked:         "[✓] ",
+				Ticked:         "[" + TodoCompletedIcon + "] ",
 				Unticked:       "[ ] ",
 			},
 			Link: ansi.StylePrimitive{
@@ -467,6 +467,8 @@ func (t *Theme) buildStyles() *Styles {
 					Background(lipgloss.Color("#323931")),
 				Code: lipgloss.NewStyle().
 					Background(lipgloss.Color("#323931")),
+				Changed: lipgloss.NewStyle().
+					Background(lipgloss.Color("#43573f")),
 			},
 			DeleteLine: diffview.LineStyle{
 				LineNumber: lipgloss.NewStyle().
@@ -477,6 +479,8 @@ func (t *Theme) buildStyles() *Styles {
 					Background(lipgloss.Color("#383030")),
 				Code: lipgloss.NewStyle().
 					Background(lipgloss.Color("#383030")),
+				Changed: lipgloss.NewStyle().
+					Background(lipgloss.Color("#5c3b39")),
 			},
 		},
 		FilePicker: filepicker.Styles{
@@ -655,6 +659,18 @@ func ApplyBoldForegroundGrad(input string, color1, color2 color.Color) string {
 	return o.String()
 }
 
+// LocalOnlyBadge renders the badge shown while local-only mode is enabled.
+func LocalOnlyBadge() string {
+	t := CurrentTheme()
+	return t.S().Base.Foreground(t.BgSubtle).Background(t.Green).Padding(0, 1).Bold(true).Render("LOCAL")
+}
+
+// OfflineBadge renders the badge shown while the network is unreachable.
+func OfflineBadge() string {
+	t := CurrentTheme()
+	return t.S().Base.Foreground(t.BgSubtle).Background(t.Warning).Padding(0, 1).Bold(true).Render("OFFLINE")
+}
+
 // blendColors returns a slice of colors blended between the given keys.
 // Blending is done in Hcl to stay in gamut.
 func blendColors(size int, stops ...color.Color) []color.Color {
@@ -1,11 +1,15 @@
 package tui
 
 import (
+	"cmp"
 	"context"
 	"fmt"
+	"log/slog"
 	"math/rand"
+	"path/filepath"
 	"regexp"
 	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -15,9 +19,21 @@ import (
 	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
 	"github.com/charmbracelet/crush/internal/app"
 	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/crash"
 	"github.com/charmbracelet/crush/internal/event"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/handoff"
+	"github.com/charmbracelet/crush/internal/home"
+	"github.com/charmbracelet/crush/internal/instructions"
+	"github.com/charmbracelet/crush/internal/log"
+	"github.com/charmbracelet/crush/internal/lsp"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/multiplexer"
+	"github.com/charmbracelet/crush/internal/netstatus"
 	"github.com/charmbracelet/crush/internal/permission"
 	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/charmbracelet/crush/internal/screenshot"
+	"github.com/charmbracelet/crush/internal/session"
 	"github.com/charmbracelet/crush/internal/stringext"
 	cmpChat "github.com/charmbracelet/crush/internal/tui/components/chat"
 	"github.com/charmbracelet/crush/internal/tui/components/chat/splash"
@@ -26,16 +42,36 @@ import (
 	"github.com/charmbracelet/crush/internal/tui/components/core/layout"
 	"github.com/charmbracelet/crush/internal/tui/components/core/status"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/apikeys"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/attachfiles"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/changelog"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/commands"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/crashreport"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/diffreview"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/filepicker"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/instructionfiles"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/loglevel"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/lspservers"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/lspsetup"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/memories"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/models"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/modelspeed"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/permissions"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/profiles"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/quit"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/reasoning"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/remember"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/runqueue"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/sessions"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/shellprofile"
+	"github.com/charmbracelet/crush/internal/tui/components/logs"
+	"github.com/charmbracelet/crush/internal/tui/keyseq"
 	"github.com/charmbracelet/crush/internal/tui/page"
 	"github.com/charmbracelet/crush/internal/tui/page/chat"
 	"github.com/charmbracelet/crush/internal/tui/styles"
 	"github.com/charmbracelet/crush/internal/tui/util"
+	"github.com/charmbracelet/crush/internal/update"
+	"github.com/pkg/browser"
 	"golang.org/x/mod/semver"
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
@@ -56,6 +92,12 @@ func MouseEventFilter(m tea.Model, msg tea.Msg) tea.Msg {
 	return msg
 }
 
+// autosaveInterval is how often the state of the open session is saved, so
+// little is lost if Crush is killed.
+const autosaveInterval = 5 * time.Second
+
+type autosaveMsg struct{}
+
 // appModel represents the main application model that manages pages, dialogs, and UI state.
 type appModel struct {
 	wWidth, wHeight int // Window dimensions
@@ -71,6 +113,12 @@ type appModel struct {
 	status          status.StatusCmp
 	showingFullHelp bool
 
+	logs        logs.LogPane
+	showingLogs bool
+
+	// sequence matches the multi-key bindings of the keymap.
+	sequence *keyseq.Matcher
+
 	app *app.App
 
 	dialog       dialogs.DialogCmp
@@ -78,7 +126,18 @@ type appModel struct {
 	isConfigured bool
 
 	// Chat Page Specific
-	selectedSessionID string // The ID of the currently selected session
+	selectedSessionID    string // The ID of the currently selected session
+	selectedSessionTitle string
+
+	// paneTitle is the title of the multiplexer pane Crush runs in.
+	paneTitle string
+
+	// away is what happened since the terminal was unfocused, while it is.
+	away *awayState
+
+	// The last TUI state saved for the selected session.
+	savedSessionID string
+	savedState     session.UIState
 
 	// sendProgressBar instructs the TUI to send progress bar updates to the
 	// terminal.
@@ -87,6 +146,21 @@ type appModel struct {
 	// QueryVersion instructs the TUI to query for the terminal version when it
 	// starts.
 	QueryVersion bool
+
+	// ResumeSession is the session to open when the TUI starts, if any.
+	ResumeSession *session.Session
+
+	// Attachments are added to the editor when the TUI starts, such as
+	// input piped into Crush.
+	Attachments []message.Attachment
+
+	// Crash is the crash from the previous run, if any, which is reported
+	// when the TUI starts.
+	Crash *crash.Report
+
+	// SwitchProfile is the config profile to restart Crush with once the
+	// TUI exits, if one was picked. An empty profile is running without one.
+	SwitchProfile *string
 }
 
 // Init initializes the application model and returns initial commands.
@@ -106,12 +180,44 @@ func (a appModel) Init() tea.Cmd {
 	if a.QueryVersion {
 		cmds = append(cmds, tea.RequestTerminalVersion)
 	}
+	if a.ResumeSession != nil {
+		cmds = append(cmds, tea.Sequence(
+			util.CmdHandler(cmpChat.SessionSelectedMsg(*a.ResumeSession)),
+			a.reopenDialog(dialogs.DialogID(a.ResumeSession.UI.Dialog), a.ResumeSession.ID),
+		))
+	}
+	cmds = append(cmds, autosaveTick())
+	for _, attachment := range a.Attachments {
+		cmds = append(cmds, util.CmdHandler(filepicker.FilePickedMsg{Attachment: attachment}))
+	}
+	if a.Crash != nil {
+		if a.Crash.SessionID != "" && a.ResumeSession == nil {
+			cmds = append(cmds, util.CmdHandler(dialogs.OpenDialogMsg{
+				Model: crashreport.NewCrashDialog(*a.Crash),
+			}))
+		} else {
+			cmds = append(cmds, util.ReportWarn("Crush crashed last time. A crash report was saved to "+a.Crash.Dir))
+		}
+	} else if config.HasInitialDataConfig() {
+		cmds = append(cmds, a.offerLSPSetup())
+	}
 
 	return tea.Batch(cmds...)
 }
 
 // Update handles incoming messages and updates the application state.
 func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if e, ok := msg.(pubsub.Event[session.Session]); ok && e.Payload.ID == a.selectedSessionID {
+		a.selectedSessionTitle = e.Payload.Title
+	}
+	if a.away != nil && a.holdWhileAway(msg) {
+		return a, nil
+	}
+	m, cmd := a.update(msg)
+	return m, tea.Batch(cmd, a.updatePaneTitle())
+}
+
+func (a *appModel) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 	var cmd tea.Cmd
 	a.isConfigured = config.HasInitialDataConfig()
@@ -139,10 +245,7 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return a, nil
 	case tea.KeyboardEnhancementsMsg:
-		// A non-zero value means we have key disambiguation support.
-		if msg.Flags > 0 {
-			a.keyMap.Models.SetHelp("ctrl+m", "models")
-		}
+		a.keyMap.setKeyboardEnhanced(msg.Flags > 0)
 		for id, page := range a.pages {
 			m, pageCmd := page.Update(msg)
 			a.pages[id] = m
@@ -152,6 +255,11 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 		return a, tea.Batch(cmds...)
+	case tea.BlurMsg:
+		a.blur()
+		return a, nil
+	case tea.FocusMsg:
+		return a, a.focus()
 	case tea.WindowSizeMsg:
 		a.wWidth, a.wHeight = msg.Width, msg.Height
 		a.completions.Update(msg)
@@ -167,6 +275,12 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return a, handleMCPToolsEvent(context.Background(), msg.Payload.Name)
 		}
 
+	case pubsub.Event[netstatus.Event]:
+		if msg.Payload.Online {
+			return a, util.ReportInfo("Back online")
+		}
+		return a, util.ReportWarn("You're offline: remote models are unavailable until the connection is back")
+
 	// Completions messages
 	case completions.OpenCompletionsMsg, completions.FilterCompletionsMsg,
 		completions.CloseCompletionsMsg, completions.RepositionCompletionsMsg:
@@ -208,7 +322,12 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case commands.ShowMCPPromptArgumentsDialogMsg:
 		args := make([]commands.Argument, 0, len(msg.Prompt.Arguments))
 		for _, arg := range msg.Prompt.Arguments {
-			args = append(args, commands.Argument(*arg))
+			args = append(args, commands.Argument{
+				Name:        arg.Name,
+				Title:       arg.Title,
+				Description: arg.Description,
+				Required:    arg.Required,
+			})
 		}
 		dialog := commands.NewCommandArgumentsDialog(
 			msg.Prompt.Name,
@@ -237,8 +356,21 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	// Session
 	case cmpChat.SessionSelectedMsg:
 		a.selectedSessionID = msg.ID
+		a.selectedSessionTitle = msg.Title
 	case cmpChat.SessionClearedMsg:
 		a.selectedSessionID = ""
+		a.selectedSessionTitle = ""
+	case crashreport.ReopenSessionMsg:
+		return a, func() tea.Msg {
+			sess, err := a.app.Sessions.Get(context.Background(), msg.SessionID)
+			if err != nil {
+				return util.InfoMsg{
+					Type: util.InfoTypeError,
+					Msg:  "Could not reopen the session: " + err.Error(),
+				}
+			}
+			return cmpChat.SessionSelectedMsg(sess)
+		}
 	// Commands
 	case commands.SwitchSessionsMsg:
 		return a, func() tea.Msg {
@@ -256,19 +388,211 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		)
 	// Compact
 	case commands.CompactMsg:
+		if !msg.Small {
+			return a, func() tea.Msg {
+				err := a.app.AgentCoordinator.Summarize(context.Background(), msg.SessionID)
+				if err != nil {
+					return util.ReportError(err)()
+				}
+				return nil
+			}
+		}
 		return a, func() tea.Msg {
-			err := a.app.AgentCoordinator.Summarize(context.Background(), msg.SessionID)
+			saved, err := a.app.AgentCoordinator.Compact(context.Background(), msg.SessionID)
 			if err != nil {
 				return util.ReportError(err)()
 			}
-			return nil
+			return util.ReportInfo(fmt.Sprintf("Session compacted, %d context tokens saved", saved))()
+		}
+	case commands.ChangeDirMsg:
+		return a, func() tea.Msg {
+			dir, err := a.app.ChangeSessionDir(context.Background(), msg.SessionID, msg.Path)
+			if err != nil {
+				return util.ReportError(err)()
+			}
+			return util.ReportInfo("Working directory set to " + fsext.PrettyPath(dir))()
 		}
+	case commands.DismissPreCommitMsg:
+		if a.app.AgentCoordinator != nil {
+			a.app.AgentCoordinator.DismissPreCommit(msg.SessionID)
+		}
+		return a, util.ReportInfo("Pre-commit failures dismissed")
 	case commands.QuitMsg:
 		return a, util.CmdHandler(dialogs.OpenDialogMsg{
 			Model: quit.NewQuitDialog(),
 		})
 	case commands.ToggleYoloModeMsg:
 		a.app.Permissions.SetSkipRequests(!a.app.Permissions.SkipRequests())
+	case commands.OpenLogLevelDialogMsg:
+		return a, util.CmdHandler(dialogs.OpenDialogMsg{
+			Model: loglevel.NewLogLevelDialog(),
+		})
+	case loglevel.LogLevelSelectedMsg:
+		log.SetLevel(msg.Level)
+		return a, util.ReportInfo("Log level set to " + strings.ToLower(msg.Level.String()))
+	case commands.OpenShellProfileMsg:
+		return a, func() tea.Msg {
+			sess, err := a.app.Sessions.Get(context.Background(), a.selectedSessionID)
+			if err != nil {
+				return util.ReportError(err)()
+			}
+			return dialogs.OpenDialogMsg{
+				Model: shellprofile.NewShellProfileDialog(sess.ShellProfile),
+			}
+		}
+	case commands.OpenLSPSetupMsg:
+		return a, func() tea.Msg {
+			suggestions := lsp.Detect(a.app.Config().WorkingDir(), a.app.Config().LSP)
+			if len(suggestions) == 0 {
+				return util.ReportInfo("All the language servers known for this project are configured")()
+			}
+			return dialogs.OpenDialogMsg{Model: lspsetup.NewLSPSetupDialog(suggestions)}
+		}
+	case lspsetup.LSPsConfiguredMsg:
+		for _, name := range msg.Names {
+			a.app.StartLSPClient(name)
+		}
+		if len(msg.Names) > 0 {
+			// The agent only gets the LSP tools when servers are configured.
+			go a.app.UpdateAgentModel(context.TODO())
+		}
+		if msg.Err != nil {
+			return a, util.ReportError(msg.Err)
+		}
+		return a, util.ReportInfo("Language servers set up: " + strings.Join(msg.Names, ", "))
+	case commands.OpenLSPServersMsg:
+		return a, util.CmdHandler(dialogs.OpenDialogMsg{Model: lspservers.NewLSPServersDialog()})
+	case commands.OpenSessionInBrowserMsg:
+		return a, func() tea.Msg {
+			url, err := a.app.PreviewSession(context.Background(), msg.SessionID)
+			if err != nil {
+				return util.ReportError(err)()
+			}
+			if err := browser.OpenURL(url); err != nil {
+				return util.ReportError(fmt.Errorf("failed to open the browser, the session is at %s: %w", url, err))()
+			}
+			return util.ReportInfo("Session opened in the browser")()
+		}
+	case commands.OpenDiffReviewMsg:
+		return a, util.CmdHandler(dialogs.OpenDialogMsg{Model: diffreview.NewDiffReviewDialog()})
+	case commands.OpenChatTemplateMsg:
+		return a, a.openChatTemplateDialog()
+	case commands.OpenModelSpeedMsg:
+		return a, util.CmdHandler(dialogs.OpenDialogMsg{Model: modelspeed.NewModelSpeedDialog()})
+	case commands.OpenProfilesMsg:
+		return a, util.CmdHandler(dialogs.OpenDialogMsg{Model: profiles.NewProfilesDialog(config.Profile())})
+	case profiles.ProfileSelectedMsg:
+		if msg.Profile == config.Profile() {
+			return a, nil
+		}
+		if a.app.AgentCoordinator != nil && a.app.AgentCoordinator.IsBusy() {
+			return a, util.ReportWarn("Agent is busy, please wait...")
+		}
+		a.SwitchProfile = &msg.Profile
+		return a, tea.Quit
+	case remember.RememberMsg:
+		return a, func() tea.Msg {
+			path := instructions.ProjectFile(config.Get().WorkingDir())
+			if msg.Global {
+				path = config.GlobalContextFile()
+			}
+			if err := instructions.Remember(path, msg.Instruction); err != nil {
+				return util.InfoMsg{Type: util.InfoTypeError, Msg: err.Error()}
+			}
+			if a.app.AgentCoordinator != nil {
+				if err := a.app.AgentCoordinator.ReloadInstructions(context.Background()); err != nil {
+					return util.InfoMsg{Type: util.InfoTypeError, Msg: err.Error()}
+				}
+			}
+			return util.InfoMsg{Type: util.InfoTypeInfo, Msg: "Remembered in " + home.Short(path)}
+		}
+	case commands.OpenInstructionsMsg:
+		return a, func() tea.Msg {
+			cfg := config.Get()
+			names := instructions.Names(cfg.Options.ContextPaths)
+			nested, err := instructions.All(cfg.WorkingDir(), names)
+			if err != nil {
+				return util.InfoMsg{Type: util.InfoTypeError, Msg: err.Error()}
+			}
+			return dialogs.OpenDialogMsg{
+				Model: instructionfiles.NewInstructionFilesDialog(instructions.Workspace(cfg.WorkingDir(), names), nested),
+			}
+		}
+	case commands.OpenMemoriesMsg:
+		return a, util.CmdHandler(dialogs.OpenDialogMsg{Model: memories.NewMemoriesDialog(a.app.Memories)})
+	case memories.DeleteMemoryMsg:
+		return a, func() tea.Msg {
+			if err := a.app.Memories.Delete(msg.ID); err != nil {
+				return util.InfoMsg{Type: util.InfoTypeError, Msg: err.Error()}
+			}
+			return util.InfoMsg{Type: util.InfoTypeInfo, Msg: "Memory forgotten"}
+		}
+	case commands.OpenChangelogMsg:
+		info, ok := update.Pending()
+		if !ok {
+			return a, util.ReportInfo("Crush is up to date")
+		}
+		return a, util.CmdHandler(dialogs.OpenDialogMsg{Model: changelog.NewChangelogDialog(info)})
+	case commands.OpenAPIKeysMsg:
+		return a, util.CmdHandler(dialogs.OpenDialogMsg{Model: apikeys.NewAPIKeysDialog()})
+	case commands.OpenRunQueueMsg:
+		if a.app.AgentCoordinator == nil {
+			return a, util.ReportWarn("Agent is not configured")
+		}
+		return a, util.CmdHandler(dialogs.OpenDialogMsg{Model: runqueue.NewRunQueueDialog(a.app.AgentCoordinator)})
+	case runqueue.MoveRunMsg:
+		a.app.AgentCoordinator.MoveQueuedRun(msg.ID, msg.Delta)
+		return a, nil
+	case runqueue.CancelRunMsg:
+		a.app.AgentCoordinator.CancelQueuedRun(msg.ID)
+		return a, util.ReportInfo("Run canceled")
+	case lspservers.RestartLSPMsg:
+		return a, func() tea.Msg {
+			a.app.RestartLSPClient(msg.Name)
+			return util.ReportInfo("Restarting " + msg.Name)()
+		}
+	case lspservers.StopLSPMsg:
+		return a, func() tea.Msg {
+			a.app.StopLSPClient(msg.Name)
+			return util.ReportInfo("Stopped " + msg.Name)()
+		}
+	case lspservers.StartLSPMsg:
+		a.app.StartLSPClient(msg.Name)
+		return a, util.ReportInfo("Starting " + msg.Name)
+	case lspservers.SetLSPEnabledMsg:
+		return a, func() tea.Msg {
+			if err := a.app.SetLSPEnabled(msg.Name, msg.Enabled); err != nil {
+				return util.ReportError(err)()
+			}
+			if msg.Enabled {
+				return util.ReportInfo("Enabled " + msg.Name)()
+			}
+			return util.ReportInfo("Disabled " + msg.Name)()
+		}
+	case shellprofile.ShellProfileSelectedMsg:
+		if err := a.app.Sessions.SetShellProfile(context.Background(), a.selectedSessionID, msg.Profile); err != nil {
+			return a, util.ReportError(err)
+		}
+		if msg.Profile == "" {
+			return a, util.ReportInfo("Shell profile set to the default one")
+		}
+		return a, util.ReportInfo("Shell profile set to " + msg.Profile)
+	case autosaveMsg:
+		return a, tea.Batch(a.autosave(), autosaveTick())
+	case commands.ToggleLogsMsg:
+		return a, a.toggleLogs()
+	case logs.CloseMsg:
+		if a.showingLogs {
+			return a, a.toggleLogs()
+		}
+		return a, nil
+	case logs.RefreshMsg:
+		if !a.showingLogs {
+			return a, nil
+		}
+		u, cmd := a.logs.Update(msg)
+		a.logs = u.(logs.LogPane)
+		return a, cmd
 	case commands.ToggleHelpMsg:
 		a.status.ToggleFullHelp()
 		a.showingFullHelp = !a.showingFullHelp
@@ -303,18 +627,40 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return a, util.CmdHandler(dialogs.OpenDialogMsg{
 			Model: filepicker.NewFilePickerCmp(a.app.Config().WorkingDir()),
 		})
+	case commands.TakeScreenshotMsg:
+		if msg.Target == screenshot.Window {
+			return a, tea.Batch(util.ReportInfo("Click the window to capture"), takeScreenshot(msg.Target))
+		}
+		return a, takeScreenshot(msg.Target)
+	case commands.OpenAttachFilesMsg:
+		if a.dialog.ActiveDialogID() == attachfiles.AttachFilesDialogID {
+			return a, util.CmdHandler(dialogs.CloseDialogMsg{})
+		}
+		agentCfg := config.Get().Agents[config.AgentCoder]
+		model := config.Get().GetModelByType(agentCfg.Model)
+		return a, util.CmdHandler(dialogs.OpenDialogMsg{
+			Model: attachfiles.NewAttachFilesDialog(a.app.Config().WorkingDir(), model != nil && model.SupportsImages),
+		})
 	// Permissions
 	case pubsub.Event[permission.PermissionNotification]:
+		var cmds []tea.Cmd
+		// Close the dialog of a request answered elsewhere.
+		if a.dialog.ActiveDialogID() == permissions.PermissionsDialogID {
+			u, dialogCmd := a.dialog.Update(msg)
+			a.dialog = u.(dialogs.DialogCmp)
+			cmds = append(cmds, dialogCmd)
+		}
 		item, ok := a.pages[a.currentPage]
 		if !ok {
-			return a, nil
+			return a, tea.Batch(cmds...)
 		}
 
 		// Forward to view.
 		updated, itemCmd := item.Update(msg)
 		a.pages[a.currentPage] = updated
+		cmds = append(cmds, itemCmd)
 
-		return a, itemCmd
+		return a, tea.Batch(cmds...)
 	case pubsub.Event[permission.PermissionRequest]:
 		return a, util.CmdHandler(dialogs.OpenDialogMsg{
 			Model: permissions.NewPermissionDialogCmp(msg.Payload, &permissions.Options{
@@ -331,6 +677,14 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			a.app.Permissions.Deny(msg.Permission)
 		}
 		return a, nil
+	// Interactive commands
+	case pubsub.Event[handoff.Request]:
+		req := msg.Payload
+		cmd := handoff.NewCommand(req)
+		return a, tea.Exec(cmd, func(err error) tea.Msg {
+			handoff.Respond(req.ID, cmd.Result(err))
+			return nil
+		})
 	case splash.OnboardingCompleteMsg:
 		item, ok := a.pages[a.currentPage]
 		if !ok {
@@ -386,11 +740,13 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return a, tea.Batch(cmds...)
 	// Update Available
 	case pubsub.UpdateAvailableMsg:
-		// Show update notification in status bar
-		statusMsg := fmt.Sprintf("Crush update available: v%s → v%s.", msg.CurrentVersion, msg.LatestVersion)
+		// Show update notification in status bar, and keep a badge there.
+		statusMsg := fmt.Sprintf("Crush update available: v%s → v%s. See what's new from the commands.", msg.CurrentVersion, msg.LatestVersion)
 		if msg.IsDevelopment {
 			statusMsg = fmt.Sprintf("This is a development version of Crush. The latest version is v%s.", msg.LatestVersion)
 		}
+		s, _ := a.status.Update(msg)
+		a.status = s.(status.StatusCmp)
 		s, statusCmd := a.status.Update(util.InfoMsg{
 			Type: util.InfoTypeUpdate,
 			Msg:  statusMsg,
@@ -433,6 +789,12 @@ func (a *appModel) handleWindowResize(width, height int) tea.Cmd {
 		height -= 0
 	}
 
+	if a.showingLogs {
+		logsHeight := a.logs.Height(height)
+		height -= logsHeight
+		cmds = append(cmds, a.logs.SetSize(width, logsHeight))
+	}
+
 	a.width, a.height = width, height
 	// Update status bar
 	s, cmd := a.status.Update(tea.WindowSizeMsg{Width: width, Height: height})
@@ -489,6 +851,17 @@ func (a *appModel) handleKeyPressMsg(msg tea.KeyPressMsg) tea.Cmd {
 		a.dialog = u.(dialogs.DialogCmp)
 		return dialogCmd
 	}
+	if key.Matches(msg, a.keyMap.Logs) {
+		return a.toggleLogs()
+	}
+	if a.showingLogs {
+		u, cmd := a.logs.Update(msg)
+		a.logs = u.(logs.LogPane)
+		return cmd
+	}
+	if cmd, handled := a.handleSequence(msg); handled {
+		return cmd
+	}
 	switch {
 	// help
 	case key.Matches(msg, a.keyMap.Help):
@@ -535,16 +908,7 @@ func (a *appModel) handleKeyPressMsg(msg tea.KeyPressMsg) tea.Cmd {
 		if a.dialog.HasDialogs() && a.dialog.ActiveDialogID() != commands.CommandsDialogID {
 			return nil
 		}
-		var cmds []tea.Cmd
-		cmds = append(cmds,
-			func() tea.Msg {
-				allSessions, _ := a.app.Sessions.List(context.Background())
-				return dialogs.OpenDialogMsg{
-					Model: sessions.NewSessionDialogCmp(allSessions, a.selectedSessionID),
-				}
-			},
-		)
-		return tea.Sequence(cmds...)
+		return a.openSessionsDialog(a.selectedSessionID)
 	case key.Matches(msg, a.keyMap.Suspend):
 		if a.app.AgentCoordinator != nil && a.app.AgentCoordinator.IsBusy() {
 			return util.ReportWarn("Agent is busy, please wait...")
@@ -591,7 +955,14 @@ func (a *appModel) View() tea.View {
 	t := styles.CurrentTheme()
 	view.AltScreen = true
 	view.MouseMode = tea.MouseModeCellMotion
+	view.ReportFocus = true
 	view.BackgroundColor = t.BgBase
+	switch {
+	case !a.app.Config().Options.TUI.DisableWindowTitle:
+		view.WindowTitle = a.windowTitle()
+	case multiplexer.Detect() == multiplexer.Zellij:
+		view.WindowTitle = a.paneTitle
+	}
 	if a.wWidth < 25 || a.wHeight < 15 {
 		view.Content = t.S().Base.Width(a.wWidth).Height(a.wHeight).
 			Align(lipgloss.Center, lipgloss.Center).
@@ -606,13 +977,18 @@ func (a *appModel) View() tea.View {
 	}
 
 	page := a.pages[a.currentPage]
-	if withHelp, ok := page.(core.KeyMapHelp); ok {
+	if a.showingLogs {
+		a.status.SetKeyMap(a.logs.Help())
+	} else if withHelp, ok := page.(core.KeyMapHelp); ok {
 		a.status.SetKeyMap(withHelp.Help())
 	}
 	pageView := page.View()
 	components := []string{
 		pageView,
 	}
+	if a.showingLogs {
+		components = append(components, a.logs.View())
+	}
 	components = append(components, a.status.View())
 
 	appView := lipgloss.JoinVertical(lipgloss.Top, components...)
@@ -626,8 +1002,18 @@ func (a *appModel) View() tea.View {
 		)
 	}
 
+	if a.sequence.Active() && !a.dialog.HasDialogs() {
+		hints := a.sequenceHints()
+		layers = append(
+			layers,
+			lipgloss.NewLayer(hints).
+				X(max(0, a.wWidth-lipgloss.Width(hints)-2)).
+				Y(max(0, a.wHeight-lipgloss.Height(hints)-2)),
+		)
+	}
+
 	var cursor *tea.Cursor
-	if v, ok := page.(util.Cursor); ok {
+	if v, ok := page.(util.Cursor); ok && !a.showingLogs {
 		cursor = v.Cursor()
 		// Hide the cursor if it's positioned outside the textarea
 		statusHeight := a.height - strings.Count(pageView, "\n") + 1
@@ -687,6 +1073,9 @@ func handleMCPToolsEvent(ctx context.Context, name string) tea.Cmd {
 
 // New creates and initializes a new TUI application model.
 func New(app *app.App) *appModel {
+	if config.Get().Options.TUI.ASCIIIcons {
+		styles.UseASCIIIcons()
+	}
 	chatPage := chat.New(app)
 	keyMap := DefaultKeyMap()
 	keyMap.pageBindings = chatPage.Bindings()
@@ -704,7 +1093,322 @@ func New(app *app.App) *appModel {
 
 		dialog:      dialogs.NewDialogCmp(),
 		completions: completions.New(),
+		logs:        logs.New(),
+		sequence:    keyseq.NewMatcher(keyMap.Sequences...),
 	}
 
 	return model
 }
+
+// handleSequence feeds the key to the sequence matcher while the messages
+// have the focus. Keys that start or continue a sequence are swallowed, as is
+// esc, which cancels the pending sequence.
+func (a *appModel) handleSequence(msg tea.KeyPressMsg) (tea.Cmd, bool) {
+	chatPage, ok := a.pages[a.currentPage].(chat.ChatPage)
+	if !ok || !chatPage.IsChatFocused() {
+		a.sequence.Reset()
+		return nil, false
+	}
+	if a.sequence.Active() && msg.String() == "esc" {
+		a.sequence.Reset()
+		return nil, true
+	}
+	b, result := a.sequence.Press(msg.String())
+	switch result {
+	case keyseq.Matched:
+		return util.CmdHandler(b.Msg), true
+	case keyseq.Pending:
+		return nil, true
+	default:
+		return nil, false
+	}
+}
+
+// sequenceHints renders the keys that can follow the pending sequence.
+func (a *appModel) sequenceHints() string {
+	t := styles.CurrentTheme()
+	next := a.sequence.Next()
+	pending := len(a.sequence.Pending())
+	width := 0
+	for _, b := range next {
+		width = max(width, lipgloss.Width(b.Keys[pending]))
+	}
+	lines := []string{
+		t.S().Subtle.Render(strings.Join(a.sequence.Pending(), " ") + " …"),
+	}
+	for _, b := range next {
+		lines = append(lines, fmt.Sprintf(
+			"%s  %s",
+			t.S().Base.Foreground(t.FgBase).Bold(true).Width(width).Render(b.Keys[pending]),
+			t.S().Muted.Render(b.Help),
+		))
+	}
+	return t.S().Base.
+		Padding(0, 1).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus).
+		Render(strings.Join(lines, "\n"))
+}
+
+// toggleLogs shows or hides the log pane, which takes the keyboard focus
+// while it's open.
+func (a *appModel) toggleLogs() tea.Cmd {
+	a.showingLogs = !a.showingLogs
+	cmd := a.handleWindowResize(a.wWidth, a.wHeight)
+	if a.showingLogs {
+		return tea.Batch(cmd, a.logs.Refresh())
+	}
+	return cmd
+}
+
+// SaveSessionState stores the TUI state of the open session so it can be
+// restored with --continue or --resume.
+func (a *appModel) SaveSessionState() {
+	if a.selectedSessionID == "" {
+		return
+	}
+	if err := a.app.Sessions.SaveUIState(context.Background(), a.selectedSessionID, a.sessionUIState()); err != nil {
+		slog.Error("Failed to save session state", "session_id", a.selectedSessionID, "error", err)
+	}
+}
+
+// restorableDialogs are the dialogs that are reopened along with a session.
+var restorableDialogs = []dialogs.DialogID{
+	commands.CommandsDialogID,
+	models.ModelsDialogID,
+	sessions.SessionsDialogID,
+	reasoning.ReasoningDialogID,
+}
+
+// sessionUIState returns the TUI state of the open session, including the
+// dialog that's open, if it can be restored.
+func (a *appModel) sessionUIState() session.UIState {
+	p, ok := a.pages[chat.ChatPageID].(chat.ChatPage)
+	if !ok {
+		return session.UIState{}
+	}
+	state := p.UIState()
+	for _, d := range slices.Backward(a.dialog.Dialogs()) {
+		if d.ID() == quit.QuitDialogID {
+			continue
+		}
+		if slices.Contains(restorableDialogs, d.ID()) {
+			state.Dialog = string(d.ID())
+		}
+		break
+	}
+	return state
+}
+
+// sessionStatus returns what the open session is doing: running, waiting
+// for a permission to be granted, or idle.
+func (a *appModel) sessionStatus() string {
+	switch {
+	case a.dialog.ActiveDialogID() == permissions.PermissionsDialogID:
+		return "needs approval"
+	case a.selectedSessionID != "" && a.app.AgentCoordinator != nil && a.app.AgentCoordinator.IsSessionBusy(a.selectedSessionID):
+		return "running"
+	default:
+		return "idle"
+	}
+}
+
+// windowTitle returns the title of the terminal window: the project, the
+// open session and its status.
+// takeScreenshot captures the target and attaches it to the prompt.
+func takeScreenshot(target screenshot.Target) tea.Cmd {
+	return func() tea.Msg {
+		data, err := screenshot.Capture(context.Background(), target)
+		if err != nil {
+			return util.InfoMsg{
+				Type: util.InfoTypeError,
+				Msg:  "Failed to take a screenshot: " + err.Error(),
+			}
+		}
+		name := fmt.Sprintf("screenshot-%s.png", time.Now().Format("20060102-150405"))
+		return filepicker.FilePickedMsg{
+			Attachment: message.Attachment{
+				FilePath: name,
+				FileName: name,
+				MimeType: "image/png",
+				Content:  data,
+			},
+		}
+	}
+}
+
+func (a *appModel) windowTitle() string {
+	project := filepath.Base(a.app.Config().WorkingDir())
+	return fmt.Sprintf("crush — %s — %s [%s]", project, cmp.Or(a.selectedSessionTitle, "New Session"), a.sessionStatus())
+}
+
+// updatePaneTitle titles the multiplexer pane Crush runs in with the open
+// session and its status when they changed. zellij titles panes after the
+// title of the terminal, which View sets, and so does tmux while Crush
+// titles the window.
+func (a *appModel) updatePaneTitle() tea.Cmd {
+	kind := multiplexer.Detect()
+	if kind == multiplexer.None || !a.app.Config().Options.TUI.DisableWindowTitle {
+		return nil
+	}
+	title := fmt.Sprintf("crush: %s [%s]", cmp.Or(a.selectedSessionTitle, "New Session"), a.sessionStatus())
+	if title == a.paneTitle {
+		return nil
+	}
+	a.paneTitle = title
+	if kind != multiplexer.Tmux {
+		return nil
+	}
+	return func() tea.Msg {
+		if err := multiplexer.SetPaneTitle(title); err != nil {
+			slog.Warn("Failed to set the title of the tmux pane", "error", err)
+		}
+		return nil
+	}
+}
+
+func autosaveTick() tea.Cmd {
+	return tea.Tick(autosaveInterval, func(time.Time) tea.Msg {
+		return autosaveMsg{}
+	})
+}
+
+// autosave saves the TUI state of the open session in the background if it
+// changed since it was last saved.
+func (a *appModel) autosave() tea.Cmd {
+	id := a.selectedSessionID
+	if id == "" {
+		return nil
+	}
+	state := a.sessionUIState()
+	if id == a.savedSessionID && state == a.savedState {
+		return nil
+	}
+	a.savedSessionID, a.savedState = id, state
+	return func() tea.Msg {
+		if err := a.app.Sessions.SaveUIState(context.Background(), id, state); err != nil {
+			slog.Error("Failed to autosave session state", "session_id", id, "error", err)
+		}
+		return nil
+	}
+}
+
+// offerLSPSetup offers to set up the language servers of the languages of the
+// project that aren't configured yet, unless they were already offered.
+func (a *appModel) offerLSPSetup() tea.Cmd {
+	return func() tea.Msg {
+		offered := config.OfferedLSPs()
+		var suggestions []lsp.Suggestion
+		for _, s := range lsp.Detect(a.app.Config().WorkingDir(), a.app.Config().LSP) {
+			if !slices.Contains(offered, s.Name) {
+				suggestions = append(suggestions, s)
+			}
+		}
+		if len(suggestions) == 0 {
+			return nil
+		}
+		return dialogs.OpenDialogMsg{Model: lspsetup.NewLSPSetupDialog(suggestions)}
+	}
+}
+
+// reopenDialog opens the dialog with the given ID again for the session, if
+// it's one of the restorable dialogs.
+func (a *appModel) reopenDialog(id dialogs.DialogID, sessionID string) tea.Cmd {
+	switch id {
+	case commands.CommandsDialogID:
+		return util.CmdHandler(dialogs.OpenDialogMsg{Model: commands.NewCommandDialog(sessionID)})
+	case models.ModelsDialogID:
+		return util.CmdHandler(dialogs.OpenDialogMsg{Model: models.NewModelDialogCmp()})
+	case sessions.SessionsDialogID:
+		return a.openSessionsDialog(sessionID)
+	case reasoning.ReasoningDialogID:
+		return util.CmdHandler(commands.OpenReasoningDialogMsg{})
+	default:
+		return nil
+	}
+}
+
+// openChatTemplateDialog opens the dialog editing the chat template override
+// of the large model, with the special characters of the markers escaped.
+func (a *appModel) openChatTemplateDialog() tea.Cmd {
+	cfg := a.app.Config()
+	selected := cfg.Models[config.SelectedModelTypeLarge]
+	providerCfg, ok := cfg.Providers.Get(selected.Provider)
+	if !ok {
+		return util.ReportWarn("No model selected")
+	}
+	tmpl := providerCfg.ChatTemplates[selected.Model]
+	var stop []string
+	for _, s := range tmpl.Stop {
+		stop = append(stop, escapeMarker(s))
+	}
+	args := []commands.Argument{
+		{Name: "stop", Title: "Stop sequences", Description: "Comma-separated, like <|im_end|>", Value: strings.Join(stop, ", ")},
+		{Name: "system", Title: "System marker", Description: `Before system messages, like <|im_start|>system\n`, Value: escapeMarker(tmpl.System)},
+		{Name: "user", Title: "User marker", Description: `Before user messages, like <|im_start|>user\n`, Value: escapeMarker(tmpl.User)},
+		{Name: "assistant", Title: "Assistant marker", Description: `Before assistant messages, like <|im_start|>assistant\n`, Value: escapeMarker(tmpl.Assistant)},
+		{Name: "end", Title: "End marker", Description: `After each message, like <|im_end|>\n`, Value: escapeMarker(tmpl.End)},
+		{Name: "file", Title: "Template file", Description: "A Go text/template file, used instead of the markers", Value: tmpl.File},
+	}
+	onSubmit := func(values map[string]string) tea.Cmd {
+		tmpl := config.ChatTemplate{
+			System:    unescapeMarker(values["system"]),
+			User:      unescapeMarker(values["user"]),
+			Assistant: unescapeMarker(values["assistant"]),
+			End:       unescapeMarker(values["end"]),
+			File:      strings.TrimSpace(values["file"]),
+		}
+		for s := range strings.SplitSeq(values["stop"], ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				tmpl.Stop = append(tmpl.Stop, unescapeMarker(s))
+			}
+		}
+		if err := cfg.SetChatTemplate(selected.Provider, selected.Model, tmpl); err != nil {
+			return util.ReportError(err)
+		}
+		return func() tea.Msg {
+			if err := a.app.UpdateAgentModel(context.TODO()); err != nil {
+				return util.ReportError(err)()
+			}
+			return util.ReportInfo("Chat template of " + selected.Model + " saved")()
+		}
+	}
+	return util.CmdHandler(dialogs.OpenDialogMsg{
+		Model: commands.NewCommandArgumentsDialog(
+			"chat_template",
+			"Chat Template",
+			"chat_template",
+			"Override how the prompt of "+selected.Model+" is formatted, for servers applying its template wrong.",
+			args,
+			onSubmit,
+		),
+	})
+}
+
+// escapeMarker escapes the newlines and other special characters of a chat
+// template marker, to edit it on a single line.
+func escapeMarker(s string) string {
+	quoted := strconv.Quote(s)
+	return quoted[1 : len(quoted)-1]
+}
+
+func unescapeMarker(s string) string {
+	if unquoted, err := strconv.Unquote(`"` + s + `"`); err == nil {
+		return unquoted
+	}
+	return s
+}
+
+func (a *appModel) openSessionsDialog(selectedSessionID string) tea.Cmd {
+	return func() tea.Msg {
+		allSessions, _ := a.app.Sessions.List(context.Background())
+		return dialogs.OpenDialogMsg{
+			Model: sessions.NewSessionDialogCmp(allSessions, selectedSessionID),
+		}
+	}
+}
+
+// SelectedSessionID returns the ID of the open session, if any.
+func (a *appModel) SelectedSessionID() string {
+	return a.selectedSessionID
+}
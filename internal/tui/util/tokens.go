@@ -0,0 +1,44 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EstimateTokens estimates the tokens of text from its length, at four bytes
+// a token.
+func EstimateTokens(text []byte) int {
+	return len(text) / 4
+}
+
+// FormatTokens formats a token count in a human-readable way (e.g. 1.2K).
+func FormatTokens(tokens int) string {
+	var s string
+	switch {
+	case tokens >= 1_000_000:
+		s = fmt.Sprintf("%.1fM", float64(tokens)/1_000_000)
+	case tokens >= 1_000:
+		s = fmt.Sprintf("%.1fK", float64(tokens)/1_000)
+	default:
+		return fmt.Sprintf("%d", tokens)
+	}
+	s = strings.Replace(s, ".0K", "K", 1)
+	return strings.Replace(s, ".0M", "M", 1)
+}
+
+// EstimateImageTokens estimates the tokens of an image from its size, at 750
+// pixels a token.
+func EstimateImageTokens(width, height int) int {
+	return width * height / 750
+}
+
+// FormatSize formats a byte count in a human-readable way (e.g. 1.2 MB).
+func FormatSize(bytes int) string {
+	if bytes < 1024 {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	if bytes < 1024*1024 {
+		return fmt.Sprintf("%.1f KB", float64(bytes)/1024)
+	}
+	return fmt.Sprintf("%.1f MB", float64(bytes)/(1024*1024))
+}
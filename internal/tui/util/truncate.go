@@ -0,0 +1,68 @@
+package util
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Ellipsis ends the text shortened to fit.
+const Ellipsis = "…"
+
+// singleLine replaces the line breaks and tabs of text with spaces, as they
+// break the layout of the single line it's shown on.
+var singleLine = strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ", "\t", " ")
+
+// Truncate shortens text to fit width cells, ending it with an ellipsis
+// when cut. Grapheme clusters are measured by the cells they take, so wide
+// characters and emoji are never split and the result is never wider than
+// width.
+func Truncate(text string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	return ansi.Truncate(singleLine.Replace(text), width, Ellipsis)
+}
+
+// TruncateLeft is like Truncate, but cuts the start of text instead, keeping
+// its end.
+func TruncateLeft(text string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	text = singleLine.Replace(text)
+	w := ansi.StringWidth(text)
+	if w <= width {
+		return text
+	}
+	return Ellipsis + end(text, w, width-1)
+}
+
+// TruncateMiddle is like Truncate, but cuts the middle of text instead,
+// keeping both ends, like the root and the file name of a path.
+func TruncateMiddle(text string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	text = singleLine.Replace(text)
+	w := ansi.StringWidth(text)
+	if w <= width {
+		return text
+	}
+	if width <= 2 {
+		return ansi.Truncate(text, width, Ellipsis)
+	}
+	right := (width - 1) / 2
+	left := width - 1 - right
+	return ansi.Truncate(text, left, "") + Ellipsis + end(text, w, right)
+}
+
+// end returns the end of text, w cells wide, that fits width cells. A wide
+// character cut in half is left out.
+func end(text string, w, width int) string {
+	for cut := w - width; ; cut++ {
+		if rest := ansi.TruncateLeft(text, cut, ""); ansi.StringWidth(rest) <= width {
+			return rest
+		}
+	}
+}
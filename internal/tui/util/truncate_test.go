@@ -0,0 +1,49 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTruncate(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		text, want string
+		width      int
+	}{
+		{"hello", "hello", 5},
+		{"hello world", "hello w…", 8},
+		{"hello", "", 0},
+		{"line one\nline two", "line one…", 9},
+		// Wide characters aren't split, even when that leaves a cell free.
+		{"你好世界", "你好…", 6},
+		{"你好世界", "你…", 4},
+		{"👍🏽 done", "👍🏽 d…", 5},
+	} {
+		got := Truncate(tt.text, tt.width)
+		require.Equal(t, tt.want, got, tt.text)
+		require.LessOrEqual(t, ansi.StringWidth(got), tt.width)
+	}
+}
+
+func TestTruncateLeft(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "…/main.go", TruncateLeft("internal/cmd/main.go", 9))
+	require.Equal(t, "main.go", TruncateLeft("main.go", 9))
+	require.Equal(t, "…界", TruncateLeft("你好世界", 4))
+}
+
+func TestTruncateMiddle(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "~/src/g…main.go", TruncateMiddle("~/src/github.com/crush/cmd/main.go", 15))
+	require.Equal(t, "short", TruncateMiddle("short", 15))
+	require.Equal(t, "a…", TruncateMiddle("abcdef", 2))
+	for width := range 10 {
+		require.LessOrEqual(t, ansi.StringWidth(TruncateMiddle("项目/内部/文件.go", width)), width)
+	}
+}
@@ -1,5 +1,5 @@
 // Package uicmd provides functionality to load and handle custom commands
-// from markdown files and MCP prompts.
+// from markdown files, MCP prompts and plugins.
 // TODO: Move this into internal/ui after refactoring.
 package uicmd
 
@@ -17,6 +17,8 @@ import (
 	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/home"
+	"github.com/charmbracelet/crush/internal/library"
+	"github.com/charmbracelet/crush/internal/plugin"
 	"github.com/charmbracelet/crush/internal/tui/components/chat"
 	"github.com/charmbracelet/crush/internal/tui/util"
 )
@@ -59,6 +61,8 @@ type CloseArgumentsDialogMsg struct {
 const (
 	userCommandPrefix    = "user:"
 	projectCommandPrefix = "project:"
+	libraryCommandPrefix = "team:"
+	pluginCommandPrefix  = "plugin:"
 )
 
 var namedArgPattern = regexp.MustCompile(`\$([A-Z][A-Z0-9_]*)`)
@@ -113,6 +117,14 @@ func buildCommandSources(cfg *config.Config) []commandSource {
 		prefix: projectCommandPrefix,
 	})
 
+	// Shared library
+	if cfg.Options.Library != nil {
+		sources = append(sources, commandSource{
+			path:   filepath.Join(library.Dir(cfg.Options.DataDirectory), library.CommandsDir),
+			prefix: libraryCommandPrefix,
+		})
+	}
+
 	return sources
 }
 
@@ -307,6 +319,54 @@ func execMCPPrompt(clientName, promptName string, args map[string]string) tea.Cm
 	}
 }
 
+// LoadPluginCommands returns the commands of the running plugins.
+func LoadPluginCommands() []Command {
+	var commands []Command
+	for _, p := range plugin.List() {
+		for _, spec := range p.Manifest.Commands {
+			id := pluginCommandPrefix + p.ID + ":" + spec.Name
+			commands = append(commands, Command{
+				ID:          id,
+				Title:       cmp.Or(spec.Title, spec.Name),
+				Description: cmp.Or(spec.Description, "Command of the "+p.Manifest.Name+" plugin"),
+				Handler:     createPluginCommandHandler(id, p, spec),
+			})
+		}
+	}
+	return commands
+}
+
+func createPluginCommandHandler(id string, p *plugin.Plugin, spec plugin.CommandSpec) func(Command) tea.Cmd {
+	return func(cmd Command) tea.Cmd {
+		if len(spec.Arguments) == 0 {
+			return execPluginCommand(p, spec.Name, nil)
+		}
+		return util.CmdHandler(ShowArgumentsDialogMsg{
+			CommandID:   id,
+			Description: spec.Description,
+			ArgNames:    spec.Arguments,
+			OnSubmit: func(args map[string]string) tea.Cmd {
+				return execPluginCommand(p, spec.Name, args)
+			},
+		})
+	}
+}
+
+// execPluginCommand runs a command of a plugin, and sends the prompt it
+// returns or shows its message.
+func execPluginCommand(p *plugin.Plugin, name string, args map[string]string) tea.Cmd {
+	return func() tea.Msg {
+		result, err := p.RunCommand(context.Background(), name, args)
+		if err != nil {
+			return util.InfoMsg{Type: util.InfoTypeError, Msg: fmt.Sprintf("%s: %v", name, err)}
+		}
+		if result.Prompt != "" {
+			return CommandRunCustomMsg{Content: result.Prompt}
+		}
+		return util.InfoMsg{Type: util.InfoTypeInfo, Msg: cmp.Or(result.Message, name+" done")}
+	}
+}
+
 type ShowMCPPromptArgumentsDialogMsg struct {
 	Prompt   *mcp.Prompt
 	OnSubmit func(arg map[string]string) tea.Cmd
@@ -0,0 +1,291 @@
+package update
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+const (
+	checksumsAsset = "checksums.txt"
+	// signatureAsset is the cosign bundle signing the checksums.
+	signatureAsset = "checksums.txt.sigstore.json"
+
+	// maxArchiveSize bounds the size of the downloaded archive.
+	maxArchiveSize = 256 << 20
+
+	// certificateIdentity and certificateIssuer are who signs the releases:
+	// the shared GoReleaser workflow of Charm on GitHub Actions.
+	certificateIdentity = `^https://github\.com/charmbracelet/meta/\.github/workflows/goreleaser\.yml@`
+	certificateIssuer   = "https://token.actions.githubusercontent.com"
+)
+
+// ErrNoCosign is returned when the signature of a release can't be verified
+// because cosign isn't installed.
+var ErrNoCosign = errors.New("cosign is needed to verify the signature of the release, install it from https://docs.sigstore.dev/cosign/system_config/installation")
+
+// InstallOptions configures how a release is installed.
+type InstallOptions struct {
+	// SkipSignature only verifies the checksum of the archive, not the
+	// signature of the checksums.
+	SkipSignature bool
+	// Client downloads the release, defaults to a client with a timeout.
+	Client *http.Client
+	// Verify verifies the signature of the checksums, defaults to cosign.
+	Verify func(ctx context.Context, checksums, signature []byte) error
+}
+
+// ArchiveName returns the name of the archive of a version for a platform,
+// as the release pipeline names them.
+func ArchiveName(version, goos, goarch string) string {
+	arch := goarch
+	switch goarch {
+	case "amd64":
+		arch = "x86_64"
+	case "386":
+		arch = "i386"
+	case "arm":
+		arch = "armv7"
+	}
+	ext := ".tar.gz"
+	if goos == "windows" {
+		ext = ".zip"
+	}
+	return fmt.Sprintf("crush_%s_%s_%s%s", strings.TrimPrefix(version, "v"), strings.ToUpper(goos[:1])+goos[1:], arch, ext)
+}
+
+// ManagedBy returns the package manager the executable was installed with,
+// which is the one to update it with, or an empty string.
+func ManagedBy(exe string) string {
+	p := filepath.ToSlash(exe)
+	switch {
+	case strings.Contains(p, "/Cellar/"), strings.Contains(p, "/homebrew/"), strings.Contains(p, "/linuxbrew/"):
+		return "Homebrew"
+	case strings.Contains(p, "/node_modules/"):
+		return "npm"
+	case strings.HasPrefix(p, "/nix/store/"):
+		return "Nix"
+	case strings.Contains(strings.ToLower(p), "/scoop/"):
+		return "Scoop"
+	case strings.Contains(p, "/WinGet/"):
+		return "WinGet"
+	}
+	return ""
+}
+
+// Install downloads the archive of the release for the running platform,
+// verifies it against the signed checksums of the release, and replaces the
+// executable at exe with the one in the archive.
+func Install(ctx context.Context, release *Release, exe string, opts InstallOptions) error {
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 5 * time.Minute}
+	}
+	if opts.Verify == nil {
+		opts.Verify = verifyWithCosign
+	}
+
+	name := ArchiveName(release.TagName, runtime.GOOS, runtime.GOARCH)
+	assets := map[string]string{}
+	for _, a := range release.Assets {
+		assets[a.Name] = a.URL
+	}
+	for _, required := range []string{name, checksumsAsset} {
+		if assets[required] == "" {
+			return fmt.Errorf("release %s has no %s", release.TagName, required)
+		}
+	}
+
+	checksums, err := download(ctx, opts.Client, assets[checksumsAsset], 1<<20)
+	if err != nil {
+		return err
+	}
+	if !opts.SkipSignature {
+		if assets[signatureAsset] == "" {
+			return fmt.Errorf("release %s isn't signed", release.TagName)
+		}
+		signature, err := download(ctx, opts.Client, assets[signatureAsset], 1<<20)
+		if err != nil {
+			return err
+		}
+		if err := opts.Verify(ctx, checksums, signature); err != nil {
+			return fmt.Errorf("failed to verify the signature of the release: %w", err)
+		}
+	}
+	want, err := checksum(checksums, name)
+	if err != nil {
+		return err
+	}
+
+	archive, err := download(ctx, opts.Client, assets[name], maxArchiveSize)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(archive)
+	if got := hex.EncodeToString(sum[:]); got != want {
+		return fmt.Errorf("checksum mismatch for %s: expected %s, got %s", name, want, got)
+	}
+
+	binary, err := extract(archive, name)
+	if err != nil {
+		return fmt.Errorf("failed to extract %s: %w", name, err)
+	}
+	return replace(exe, binary)
+}
+
+func download(ctx context.Context, client *http.Client, url string, limit int64) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: status %d", url, resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("failed to download %s: larger than %d bytes", url, limit)
+	}
+	return data, nil
+}
+
+// checksum returns the SHA-256 checksum of a file in a checksums file, in
+// the format of sha256sum.
+func checksum(checksums []byte, name string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf("no checksum for %s", name)
+}
+
+// extract returns the crush executable in a release archive.
+func extract(archive []byte, name string) ([]byte, error) {
+	isBinary := func(p string) bool {
+		base := path.Base(filepath.ToSlash(p))
+		return base == "crush" || base == "crush.exe"
+	}
+	if strings.HasSuffix(name, ".zip") {
+		zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range zr.File {
+			if f.FileInfo().IsDir() || !isBinary(f.Name) {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return nil, err
+			}
+			defer rc.Close()
+			return io.ReadAll(io.LimitReader(rc, maxArchiveSize))
+		}
+		return nil, errors.New("no crush executable in the archive")
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, err
+	}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil, errors.New("no crush executable in the archive")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag == tar.TypeReg && isBinary(hdr.Name) {
+			return io.ReadAll(io.LimitReader(tr, maxArchiveSize))
+		}
+	}
+}
+
+// replace replaces the executable with a new one, next to it first so the
+// swap is a rename. Windows doesn't allow replacing a running executable,
+// but allows renaming it.
+func replace(exe string, binary []byte) error {
+	info, err := os.Stat(exe)
+	if err != nil {
+		return err
+	}
+	tmp := exe + ".new"
+	if err := os.WriteFile(tmp, binary, info.Mode().Perm()|0o111); err != nil {
+		return fmt.Errorf("failed to write the new executable: %w", err)
+	}
+	old := exe + ".old"
+	if runtime.GOOS == "windows" {
+		_ = os.Remove(old)
+		if err := os.Rename(exe, old); err != nil {
+			os.Remove(tmp)
+			return fmt.Errorf("failed to move the current executable aside: %w", err)
+		}
+	}
+	if err := os.Rename(tmp, exe); err != nil {
+		os.Remove(tmp)
+		if runtime.GOOS == "windows" {
+			_ = os.Rename(old, exe)
+		}
+		return fmt.Errorf("failed to replace the executable: %w", err)
+	}
+	return nil
+}
+
+// verifyWithCosign verifies the signature of the checksums with cosign.
+func verifyWithCosign(ctx context.Context, checksums, signature []byte) error {
+	cosign, err := exec.LookPath("cosign")
+	if err != nil {
+		return ErrNoCosign
+	}
+	dir, err := os.MkdirTemp("", "crush-update-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	checksumsPath := filepath.Join(dir, checksumsAsset)
+	signaturePath := filepath.Join(dir, signatureAsset)
+	if err := os.WriteFile(checksumsPath, checksums, 0o600); err != nil {
+		return err
+	}
+	if err := os.WriteFile(signaturePath, signature, 0o600); err != nil {
+		return err
+	}
+	out, err := exec.CommandContext(ctx, cosign, "verify-blob",
+		"--bundle", signaturePath,
+		"--certificate-identity-regexp", certificateIdentity,
+		"--certificate-oidc-issuer", certificateIssuer,
+		checksumsPath,
+	).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, bytes.TrimSpace(out))
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package update
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchiveName(t *testing.T) {
+	require.Equal(t, "crush_0.12.0_Darwin_arm64.tar.gz", ArchiveName("v0.12.0", "darwin", "arm64"))
+	require.Equal(t, "crush_0.12.0_Linux_x86_64.tar.gz", ArchiveName("0.12.0", "linux", "amd64"))
+	require.Equal(t, "crush_0.12.0_Windows_i386.zip", ArchiveName("v0.12.0", "windows", "386"))
+	require.Equal(t, "crush_0.12.0_Linux_armv7.tar.gz", ArchiveName("v0.12.0", "linux", "arm"))
+}
+
+func TestManagedBy(t *testing.T) {
+	require.Equal(t, "Homebrew", ManagedBy("/opt/homebrew/Cellar/crush/0.11.0/bin/crush"))
+	require.Equal(t, "npm", ManagedBy("/usr/lib/node_modules/@charmland/crush/bin/crush"))
+	require.Equal(t, "Nix", ManagedBy("/nix/store/abc-crush-0.11.0/bin/crush"))
+	require.Empty(t, ManagedBy("/home/me/.local/bin/crush"))
+}
+
+func TestCertificateIdentity(t *testing.T) {
+	identity := regexp.MustCompile(certificateIdentity)
+	require.True(t, identity.MatchString("https://github.com/charmbracelet/meta/.github/workflows/goreleaser.yml@refs/heads/main"))
+	require.False(t, identity.MatchString("https://github.com/charmbracelet/crush/.github/workflows/build.yml@refs/heads/main"))
+	require.False(t, identity.MatchString("https://github.com/charmbracelet/meta/.github/workflows/other.yml@refs/heads/main"))
+}
+
+func TestInstall(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a tar.gz archive")
+	}
+
+	name := ArchiveName("v0.12.0", runtime.GOOS, runtime.GOARCH)
+	var archive bytes.Buffer
+	gz := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(gz)
+	for file, content := range map[string]string{"crush_0.12.0/README.md": "readme", "crush_0.12.0/crush": "new crush"} {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: file, Mode: 0o755, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	sum := sha256.Sum256(archive.Bytes())
+	checksums := fmt.Sprintf("%s  %s\n0000  other.zip\n", hex.EncodeToString(sum[:]), name)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/" + name:
+			w.Write(archive.Bytes())
+		case "/checksums.txt":
+			w.Write([]byte(checksums))
+		case "/checksums.txt.sigstore.json":
+			w.Write([]byte("signature"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	release := &Release{TagName: "v0.12.0"}
+	for _, asset := range []string{name, "checksums.txt", "checksums.txt.sigstore.json"} {
+		release.Assets = append(release.Assets, Asset{Name: asset, URL: server.URL + "/" + asset})
+	}
+
+	exe := filepath.Join(t.TempDir(), "crush")
+	require.NoError(t, os.WriteFile(exe, []byte("old crush"), 0o755))
+
+	var verified []byte
+	err := Install(t.Context(), release, exe, InstallOptions{Verify: func(_ context.Context, c, s []byte) error {
+		require.Equal(t, "signature", string(s))
+		verified = c
+		return nil
+	}})
+	require.NoError(t, err)
+	require.Equal(t, checksums, string(verified))
+	content, err := os.ReadFile(exe)
+	require.NoError(t, err)
+	require.Equal(t, "new crush", string(content))
+
+	err = Install(t.Context(), release, exe, InstallOptions{Verify: func(context.Context, []byte, []byte) error {
+		return errors.New("bad signature")
+	}})
+	require.ErrorContains(t, err, "bad signature")
+
+	checksums = fmt.Sprintf("%s  %s\n", hex.EncodeToString(make([]byte, 32)), name)
+	err = Install(t.Context(), release, exe, InstallOptions{SkipSignature: true})
+	require.ErrorContains(t, err, "checksum mismatch")
+}
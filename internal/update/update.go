@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"regexp"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -24,6 +25,10 @@ type Info struct {
 	Current string
 	Latest  string
 	URL     string
+	// Notes are the release notes of the latest version, in Markdown.
+	Notes string
+	// Release is the latest release.
+	Release *Release
 }
 
 // Matches a version string like:
@@ -69,13 +74,39 @@ func Check(ctx context.Context, current string, client Client) (Info, error) {
 	info.Latest = strings.TrimPrefix(release.TagName, "v")
 	info.Current = strings.TrimPrefix(info.Current, "v")
 	info.URL = release.HTMLURL
+	info.Notes = release.Body
+	info.Release = release
 	return info, nil
 }
 
+var pending atomic.Pointer[Info]
+
+// SetPending records the update found by the background check.
+func SetPending(info Info) {
+	pending.Store(&info)
+}
+
+// Pending returns the update found by the background check, if any.
+func Pending() (Info, bool) {
+	info := pending.Load()
+	if info == nil {
+		return Info{}, false
+	}
+	return *info, true
+}
+
 // Release represents a GitHub release.
 type Release struct {
-	TagName string `json:"tag_name"`
-	HTMLURL string `json:"html_url"`
+	TagName string  `json:"tag_name"`
+	HTMLURL string  `json:"html_url"`
+	Body    string  `json:"body"`
+	Assets  []Asset `json:"assets"`
+}
+
+// Asset is a file attached to a release.
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
 }
 
 // Client is a client that can get the latest release.
@@ -0,0 +1,151 @@
+// Package webhook posts the events of the agent to the webhooks of the
+// config, signed so that receivers can tell they come from Crush.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+)
+
+// The events posted to webhooks.
+const (
+	// EventRunCompleted is posted when the agent ends its turn in a session.
+	EventRunCompleted = "run_completed"
+	// EventPermissionRequested is posted when a tool call waits for the
+	// user to grant it permission.
+	EventPermissionRequested = "permission_requested"
+	// EventBudgetExceeded is posted when the agent stops a turn that
+	// reached one of the turn limits or got stuck in a loop.
+	EventBudgetExceeded = "budget_exceeded"
+)
+
+var events = []string{EventRunCompleted, EventPermissionRequested, EventBudgetExceeded}
+
+const (
+	// EventHeader is the header holding the event of a payload.
+	EventHeader = "X-Crush-Event"
+	// SignatureHeader is the header holding the signature of a payload,
+	// when the webhook has a secret.
+	SignatureHeader = "X-Crush-Signature"
+)
+
+// timeout bounds posting an event to a webhook.
+const timeout = 30 * time.Second
+
+// Payload is the JSON body posted to webhooks.
+type Payload struct {
+	Event     string    `json:"event"`
+	Time      time.Time `json:"time"`
+	SessionID string    `json:"session_id"`
+	Session   string    `json:"session,omitempty"`
+	// Text is the last message of the agent for run_completed, and the
+	// limit the turn reached for budget_exceeded.
+	Text       string      `json:"text,omitempty"`
+	Permission *Permission `json:"permission,omitempty"`
+}
+
+// Permission is the permission request of a permission_requested event.
+type Permission struct {
+	Tool        string `json:"tool"`
+	Action      string `json:"action"`
+	Path        string `json:"path"`
+	Description string `json:"description"`
+}
+
+// Notifier posts events to webhooks.
+type Notifier struct {
+	hooks  []config.Webhook
+	client *http.Client
+	wg     sync.WaitGroup
+}
+
+// New returns a notifier posting to the given webhooks, with their secrets
+// already resolved.
+func New(hooks []config.Webhook) (*Notifier, error) {
+	for _, h := range hooks {
+		if h.URL == "" {
+			return nil, errors.New("webhook: url is required")
+		}
+		for _, e := range h.Events {
+			if !slices.Contains(events, e) {
+				return nil, fmt.Errorf("webhook %s: unknown event %q", h.URL, e)
+			}
+		}
+	}
+	return &Notifier{
+		hooks:  hooks,
+		client: &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// Send posts the payload in the background to the webhooks that want its
+// event. Failures are logged.
+func (n *Notifier) Send(p Payload) {
+	if p.Time.IsZero() {
+		p.Time = time.Now()
+	}
+	body, err := json.Marshal(p)
+	if err != nil {
+		slog.Error("Failed to encode webhook payload", "event", p.Event, "error", err)
+		return
+	}
+	for _, h := range n.hooks {
+		if len(h.Events) > 0 && !slices.Contains(h.Events, p.Event) {
+			continue
+		}
+		n.wg.Go(func() {
+			if err := n.post(h, p.Event, body); err != nil {
+				slog.Error("Failed to post webhook", "url", h.URL, "event", p.Event, "error", err)
+			}
+		})
+	}
+}
+
+// Wait waits for the payloads being posted.
+func (n *Notifier) Wait() {
+	n.wg.Wait()
+}
+
+func (n *Notifier) post(h config.Webhook, event string, body []byte) error {
+	// Posting outlives the context of the app, so that the events of a
+	// run finishing right before shutdown still go out.
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, h.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, event)
+	if h.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(h.Secret, body))
+	}
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded %s", resp.Status)
+	}
+	return nil
+}
+
+// Sign returns the signature of body with secret, as sent in the
+// SignatureHeader: sha256= followed by the hex encoded HMAC-SHA256.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,81 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifier(t *testing.T) {
+	t.Parallel()
+
+	type delivery struct {
+		event     string
+		signature string
+		body      []byte
+	}
+	var (
+		mu         sync.Mutex
+		deliveries = map[string][]delivery{}
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		mu.Lock()
+		defer mu.Unlock()
+		deliveries[r.URL.Path] = append(deliveries[r.URL.Path], delivery{
+			event:     r.Header.Get(EventHeader),
+			signature: r.Header.Get(SignatureHeader),
+			body:      body,
+		})
+	}))
+	defer server.Close()
+
+	n, err := New([]config.Webhook{
+		{URL: server.URL + "/all", Secret: "s3cret"},
+		{URL: server.URL + "/permissions", Events: []string{EventPermissionRequested}},
+	})
+	require.NoError(t, err)
+
+	n.Send(Payload{Event: EventRunCompleted, SessionID: "a", Text: "Done"})
+	n.Send(Payload{Event: EventPermissionRequested, SessionID: "a", Permission: &Permission{Tool: "bash", Action: "execute"}})
+	n.Wait()
+
+	all := deliveries["/all"]
+	require.Len(t, all, 2)
+	for _, d := range all {
+		require.Equal(t, Sign("s3cret", d.body), d.signature)
+	}
+
+	permissions := deliveries["/permissions"]
+	require.Len(t, permissions, 1)
+	require.Equal(t, EventPermissionRequested, permissions[0].event)
+	require.Empty(t, permissions[0].signature)
+	var p Payload
+	require.NoError(t, json.Unmarshal(permissions[0].body, &p))
+	require.Equal(t, "bash", p.Permission.Tool)
+	require.False(t, p.Time.IsZero())
+}
+
+func TestNewErrors(t *testing.T) {
+	t.Parallel()
+
+	_, err := New([]config.Webhook{{URL: "https://example.com", Events: []string{"run_started"}}})
+	require.ErrorContains(t, err, `unknown event "run_started"`)
+
+	_, err = New([]config.Webhook{{Events: []string{EventRunCompleted}}})
+	require.ErrorContains(t, err, "url is required")
+}
+
+func TestSign(t *testing.T) {
+	t.Parallel()
+
+	// echo -n 'hello' | openssl dgst -sha256 -hmac key
+	require.Equal(t, "sha256=9307b3b915efb5171ff14d8cb55fbcc798c6c0ef1456d66ded1a6aa723a58b7b", Sign("key", []byte("hello")))
+}